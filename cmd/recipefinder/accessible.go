@@ -0,0 +1,151 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/spoonacular"
+)
+
+var accessible = flag.Bool("accessible", false,
+	"Screen-reader-friendly text output: no tables or box drawing, a label on every line")
+
+// accessibleFormatter replaces textFormatter with --accessible. Every line
+// starts with what it holds, lists are counted ("Step 2 of 5") and empty
+// values are spelled out rather than left blank.
+type accessibleFormatter struct{}
+
+func (accessibleFormatter) Format(w io.Writer, allRecipes []recipes.Recipe, withInstructions bool) error {
+	fmt.Fprintf(w, "Found %d recipes.\n", len(allRecipes))
+	for i, recipe := range allRecipes {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "Recipe %d of %d: %s%s%s\n", i+1, len(allRecipes), recipe.Title,
+			localMark(recipe, ", a local recipe of your own"), favoriteMark(recipe, ", saved as a favorite"))
+		fmt.Fprintln(w, "Ingredients you have:", accessibleList(recipes.IngredientNames(recipe.UsedIngredients)))
+		fmt.Fprintln(w, "Ingredients you are missing:", accessibleList(missedNames(recipe)))
+		for _, note := range replacementNotes(recipe) {
+			fmt.Fprintln(w, "Substitutes for", note)
+		}
+		if notes := nutritionNotes(recipe); len(notes) > 0 {
+			fmt.Fprintln(w, "Nutrition:", strings.Join(notes, ", "))
+		}
+		for _, name := range recipe.NutrientNames() {
+			nutrient := recipe.Nutrients[name]
+			if nutrient.DailyPercent > 0 {
+				fmt.Fprintf(w, "%s: %.0f %s, %.0f percent of the daily value\n", name, nutrient.Amount, nutrient.Unit,
+					nutrient.DailyPercent)
+			} else {
+				fmt.Fprintf(w, "%s: %.0f %s\n", name, nutrient.Amount, nutrient.Unit)
+			}
+			if total, ok := recipe.TotalNutrient(name); ok && recipe.ScaledFrom > 0 {
+				fmt.Fprintf(w, "%s in all %d servings: %.0f %s\n", name, recipe.Servings, total.Amount, total.Unit)
+			}
+		}
+		fmt.Fprintln(w, "Price per serving:", recipePrice(recipe))
+		if servings := scaledServings(recipe); servings != "" {
+			fmt.Fprintln(w, "Servings:", servings)
+		}
+		if recipe.ReadyInMinutes > 0 {
+			fmt.Fprintf(w, "Ready in %d minutes\n", recipe.ReadyInMinutes)
+		}
+		if len(recipe.Equipment) > 0 {
+			fmt.Fprintln(w, "Equipment:", accessibleList(recipe.Equipment))
+		}
+		if recipe.Pairing != nil {
+			fmt.Fprintln(w, "Pairing:", recipe.Pairing)
+		}
+		for j, warning := range recipe.Warnings {
+			fmt.Fprintf(w, "Warning %d of %d: %s\n", j+1, len(recipe.Warnings), warning)
+		}
+		if withInstructions {
+			for j, step := range recipe.Instructions {
+				fmt.Fprintf(w, "Step %d of %d: %s\n", j+1, len(recipe.Instructions), step)
+			}
+		}
+	}
+	return nil
+}
+
+func (accessibleFormatter) FormatShoppingList(w io.Writer, items []recipes.ShoppingItem) error {
+	fmt.Fprintf(w, "Shopping list, %d items.\n", len(items))
+	for i, item := range items {
+		if newAisle(items, i) {
+			fmt.Fprintf(w, "Aisle: %s.\n", aisleHeading(item.Aisle))
+		}
+		amount := shoppingAmount(item)
+		if amount == "" {
+			amount = "amount not given"
+		}
+		fmt.Fprintf(w, "Item %d of %d: %s, %s\n", i+1, len(items), item.Name, amount)
+	}
+	return nil
+}
+
+// accessibleList joins items with commas, or says "none".
+func accessibleList(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, ", ")
+}
+
+// renderAccessibleLabel writes the nutrition label data as labelled lines in
+// the order of the printed label, without the panel drawing.
+func renderAccessibleLabel(w io.Writer, widget *spoonacular.NutritionWidget) {
+	fmt.Fprintln(w, "Nutrition facts.")
+	fmt.Fprintf(w, "Serving size: %.0f %s\n", widget.WeightPerServing.Amount, widget.WeightPerServing.Unit)
+
+	nutrients := make(map[string]int, len(widget.Nutrients))
+	for i, n := range widget.Nutrients {
+		nutrients[n.Name] = i
+	}
+	if i, ok := nutrients["Calories"]; ok {
+		fmt.Fprintf(w, "Calories per serving: %.0f\n", widget.Nutrients[i].Amount)
+	}
+	for _, row := range append(append([]labelRow(nil), labelMacroRows...), labelMicroRows...) {
+		i, ok := nutrients[row.Name]
+		if !ok {
+			continue
+		}
+		n := widget.Nutrients[i]
+		fmt.Fprintf(w, "%s: %.1f %s, %.0f percent of daily value\n", row.Title, n.Amount, n.Unit, n.PercentOfDailyNeeds)
+	}
+	fmt.Fprintln(w, "Daily values are based on 2,000 calories a day.")
+}
+
+// printAccessiblePlan writes the plan one labelled line per meal instead of
+// as a table.
+func printAccessiblePlan(w io.Writer, plan recipes.Plan) error {
+	fmt.Fprintf(w, "Meal plan for %d days.\n", len(plan.Days))
+	for dayIndex, day := range plan.Days {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "Day %d of %d.\n", dayIndex+1, len(plan.Days))
+		if day.Theme != "" {
+			fmt.Fprintf(w, "Theme: %s, the last meal.\n", day.Theme)
+		}
+		for mealIndex, meal := range day.Meals {
+			fmt.Fprintf(w, "Meal %d of %d: %s. Calories: %.0f. Protein: %.1f g. Missing ingredients: %s.\n",
+				mealIndex+1, len(day.Meals), meal.Title, meal.Nutrients["Calories"].Amount,
+				meal.Nutrients["Protein"].Amount, accessibleList(recipes.IngredientNames(meal.MissedIngredients)))
+		}
+		fmt.Fprintf(w, "Day %d total: %.0f calories, %.1f g protein.\n", dayIndex+1, day.Calories, day.Protein)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, planCost(plan))
+	summary := plan.Summary
+	fmt.Fprintf(w, "Total: %s.\n", formatMacros(summary.Total))
+	fmt.Fprintf(w, "Daily average: %s.\n", formatMacros(summary.DailyAverage))
+	fmt.Fprintf(w, "Pantry items used: %d.\n", summary.PantryItemsUsed)
+	for _, target := range summary.Targets {
+		fmt.Fprintf(w, "Average meal %s: %.1f, %s.\n", strings.ToLower(target.Nutrient), target.Average,
+			formatVariance(target))
+	}
+	printGoals(w, summary.Goals, false, ".")
+	if leftovers := formatLeftovers(plan.Leftovers); leftovers != "" {
+		fmt.Fprintf(w, "Leftovers: %s.\n", leftovers)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+// cookingProgress is what the meals logged with "recipefinder cooked" tell
+// of a profile's habits, shown by "recipefinder stats" and in the digest.
+type cookingProgress struct {
+	// streak is how many days in a row a meal was cooked, up to today, or up
+	// to yesterday while nothing is cooked yet today. longestStreak is the
+	// most ever.
+	streak, longestStreak int
+	// budgetWeeks is how many weeks in a row up to the last one the meals
+	// cooked cost no more than the weekly budget, a serving each, and
+	// longestBudgetWeeks the most ever. Weeks without meals break the run,
+	// and meals without a price cost nothing.
+	budgetWeeks, longestBudgetWeeks int
+	// vegetables is how many different vegetables were cooked this week, and
+	// mostVegetables the most in a week.
+	vegetables, mostVegetables int
+}
+
+// achievements returns the milestones the progress has reached, once reached
+// for good.
+func (p cookingProgress) achievements() []string {
+	var reached []string
+	for _, streak := range []struct {
+		days int
+		name string
+	}{{3, "Getting started"}, {7, "Home cook"}, {30, "Month at the stove"}} {
+		if p.longestStreak >= streak.days {
+			reached = append(reached, fmt.Sprintf("%s: cooked %d days in a row", streak.name, streak.days))
+		}
+	}
+	if p.longestBudgetWeeks >= 1 {
+		reached = append(reached, "On budget: a week under budget")
+	}
+	if p.longestBudgetWeeks >= 4 {
+		reached = append(reached, "Budget keeper: 4 weeks in a row under budget")
+	}
+	if p.mostVegetables >= 10 {
+		reached = append(reached, "Eat the rainbow: 10 different vegetables in a week")
+	}
+	return reached
+}
+
+// measureProgress sums up the cooked meals, oldest first, as of now, with
+// days and weeks starting in now's time zone and weeks on Mondays. Without a
+// budget no week is under it.
+func measureProgress(meals []store.CookedMeal, now time.Time, budget recipes.Money) cookingProgress {
+	day := func(t time.Time) time.Time {
+		t = t.In(now.Location())
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, now.Location())
+	}
+	week := func(t time.Time) time.Time {
+		t = day(t)
+		return t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+	}
+
+	var progress cookingProgress
+	cooked := make(map[time.Time]bool)
+	costs := make(map[time.Time]recipes.Money)
+	vegetables := make(map[time.Time][]string)
+	for _, meal := range meals {
+		cooked[day(meal.CookedAt)] = true
+		start := week(meal.CookedAt)
+		costs[start] += meal.Price
+		for _, vegetable := range meal.Vegetables {
+			if !slices.Contains(vegetables[start], vegetable) {
+				vegetables[start] = append(vegetables[start], vegetable)
+			}
+		}
+	}
+	if len(meals) == 0 {
+		return progress
+	}
+
+	today := day(now)
+	run := 0
+	for date := day(meals[0].CookedAt); !date.After(today); date = date.AddDate(0, 0, 1) {
+		if cooked[date] {
+			run++
+		} else if date.Before(today) {
+			run = 0
+		}
+		progress.longestStreak = max(progress.longestStreak, run)
+	}
+	progress.streak = run
+
+	thisWeek := week(now)
+	run = 0
+	for start := week(meals[0].CookedAt); start.Before(thisWeek); start = start.AddDate(0, 0, 7) {
+		if _, ok := costs[start]; ok && budget > 0 && costs[start] <= budget {
+			run++
+		} else {
+			run = 0
+		}
+		progress.longestBudgetWeeks = max(progress.longestBudgetWeeks, run)
+	}
+	progress.budgetWeeks = run
+	for _, found := range vegetables {
+		progress.mostVegetables = max(progress.mostVegetables, len(found))
+	}
+	progress.vegetables = len(vegetables[thisWeek])
+	return progress
+}
+
+// writeProgress writes the streaks and the achievements reached, the weeks
+// under budget only when there is a budget.
+func writeProgress(w io.Writer, progress cookingProgress, budget recipes.Money) {
+	fmt.Fprintf(w, "Days cooked in a row: %d, %d at most\n", progress.streak, progress.longestStreak)
+	if budget > 0 {
+		fmt.Fprintf(w, "Weeks in a row under the %s budget: %d, %d at most\n", budget, progress.budgetWeeks,
+			progress.longestBudgetWeeks)
+	}
+	fmt.Fprintf(w, "Different vegetables this week: %d, %d at most in a week\n", progress.vegetables,
+		progress.mostVegetables)
+	for _, achievement := range progress.achievements() {
+		fmt.Fprintf(w, "★ %s\n", achievement)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+func TestMeasureProgress(t *testing.T) {
+	// 2026-10-05 and 2026-10-12 are Mondays
+	cooked := func(day int, price float64, vegetables ...string) store.CookedMeal {
+		return store.CookedMeal{CookedAt: time.Date(2026, 10, day, 19, 0, 0, 0, time.UTC),
+			Price: recipes.DollarsToMoney(price), Vegetables: vegetables}
+	}
+	meals := []store.CookedMeal{
+		cooked(1, 40), // Thursday of the week before
+		cooked(5, 8, "onion", "tomato"), cooked(6, 8, "onion"), cooked(7, 8), cooked(8, 8, "carrot"),
+		cooked(12, 10, "leek"), cooked(13, 10, "leek", "kale"),
+	}
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+
+	progress := measureProgress(meals, now, recipes.DollarsToMoney(35))
+	want := cookingProgress{
+		streak: 2, longestStreak: 4,
+		// The week of the 1st cost $40, the next $32
+		budgetWeeks: 1, longestBudgetWeeks: 1,
+		vegetables: 2, mostVegetables: 3,
+	}
+	if progress != want {
+		t.Errorf("got %+v, want %+v", progress, want)
+	}
+	if got := progress.achievements(); !slices.Equal(got, []string{"Getting started: cooked 3 days in a row",
+		"On budget: a week under budget"}) {
+		t.Errorf("got achievements %q", got)
+	}
+
+	// Without a budget no week is under it
+	if progress := measureProgress(meals, now, 0); progress.longestBudgetWeeks != 0 {
+		t.Errorf("got %d weeks under no budget, want 0", progress.longestBudgetWeeks)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var activity = flag.String("activity", "", "With the plan command, a CSV export of daily active calories, such "+
+	"as Garmin Connect's, whose average for each weekday is added to the daily calorie goal of that day")
+
+// readActivity reads a CSV export of active calories and returns their daily
+// average for each weekday. The header names the columns: the first with
+// "date" in its name holds the day, as YYYY-MM-DD optionally followed by a
+// time, and the first with "active" and "calories" or "energy" the amount.
+// Rows of the same day are added up, and rows without an amount, which
+// Garmin writes as "--", are skipped.
+func readActivity(r io.Reader) (map[time.Weekday]float64, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the activity file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading activity: %v", err)
+	}
+	dateColumn, caloriesColumn := -1, -1
+	for i, name := range header {
+		name = strings.ToLower(name)
+		if dateColumn == -1 && strings.Contains(name, "date") {
+			dateColumn = i
+		}
+		if caloriesColumn == -1 && strings.Contains(name, "active") &&
+			(strings.Contains(name, "calories") || strings.Contains(name, "energy")) {
+			caloriesColumn = i
+		}
+	}
+	if dateColumn == -1 || caloriesColumn == -1 {
+		return nil, errors.New("the activity file needs a date column and an active calories column")
+	}
+
+	days := make(map[string]float64)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading activity: %v", err)
+		}
+		if max(dateColumn, caloriesColumn) >= len(record) {
+			continue
+		}
+		amount := strings.ReplaceAll(strings.TrimSpace(record[caloriesColumn]), ",", "")
+		if amount == "" || amount == "--" {
+			continue
+		}
+		calories, err := strconv.ParseFloat(amount, 64)
+		if err != nil || calories < 0 {
+			return nil, fmt.Errorf("invalid active calories %q on line %d of the activity file", amount, line)
+		}
+		date := strings.TrimSpace(record[dateColumn])
+		if len(date) > len(time.DateOnly) {
+			date = date[:len(time.DateOnly)]
+		}
+		_, err = time.Parse(time.DateOnly, date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q on line %d of the activity file, expected YYYY-MM-DD",
+				record[dateColumn], line)
+		}
+		days[date] += calories
+	}
+
+	totals := make(map[time.Weekday]float64)
+	counts := make(map[time.Weekday]int)
+	for date, calories := range days {
+		day, _ := time.Parse(time.DateOnly, date)
+		totals[day.Weekday()] += calories
+		counts[day.Weekday()]++
+	}
+	for weekday := range totals {
+		totals[weekday] /= float64(counts[weekday])
+	}
+	return totals, nil
+}
+
+// activityCalories returns the calories to eat on each day of a plan starting
+// at start: the daily goal plus the active calories of --activity for the
+// day's weekday. Weekdays the file has no data for get the goal alone.
+func activityCalories(path string, goal float64, start time.Time, days int) ([]float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening activity file: %v", err)
+	}
+	defer func() {
+		err := file.Close()
+		if err != nil {
+			slog.Warn("error closing activity file", "error", err)
+		}
+	}()
+	active, err := readActivity(file)
+	if err != nil {
+		return nil, err
+	}
+	calories := make([]float64, days)
+	for i := range calories {
+		calories[i] = goal + active[start.AddDate(0, 0, i).Weekday()]
+	}
+	return calories, nil
+}
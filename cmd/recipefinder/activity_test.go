@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadActivity(t *testing.T) {
+	// 2024-03-04 and 2024-03-11 are Mondays, 2024-03-05 a Tuesday
+	export := `Date,Steps,Active Calories
+2024-03-04,9000,"1,000"
+2024-03-05,3000,--
+2024-03-11 07:00:00,2000,300
+2024-03-11 18:00:00,8000,200
+`
+	active, err := readActivity(strings.NewReader(export))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(active) != 1 || active[time.Monday] != 750 {
+		t.Errorf("got %v, want 750 on Mondays only", active)
+	}
+
+	for name, export := range map[string]string{
+		"no calories column": "Date,Steps\n2024-03-04,9000\n",
+		"bad date":           "Date,Active Calories\n04/03/2024,500\n",
+		"bad amount":         "Date,Active Calories\n2024-03-04,lots\n",
+		"empty":              "",
+	} {
+		if _, err := readActivity(strings.NewReader(export)); err == nil {
+			t.Errorf("%s: got no error", name)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+	"github.com/mawojcik/meals_generator/pkg/telegram"
+)
+
+var telegramToken = flag.String("telegram-token", "",
+	"Token of the Telegram bot to answer as (overrides RECIPEFINDER_TELEGRAM_TOKEN and the config file)")
+
+const (
+	// botRecipes is how many recipe cards the bot answers a message with
+	// without --numberOfRecipes.
+	botRecipes = 3
+	// botPollTimeout is how long one long poll for chat updates waits.
+	botPollTimeout = 50 * time.Second
+	// botRetryDelay is how long the bot waits after failing to get updates.
+	botRetryDelay = 5 * time.Second
+	// maxSentRecipes is how many recipes the bot remembers the cards of, for
+	// their buttons; older buttons ask to search again.
+	maxSentRecipes = 1000
+)
+
+const botHelp = "Send me the ingredients you have, separated by commas, e.g. \"chicken, rice, garlic\", " +
+	"and I will answer with recipes using them."
+
+// botChat is the chat service the bot talks through, see telegram.Client.
+type botChat interface {
+	Updates(ctx context.Context, offset int, timeout time.Duration) ([]telegram.Update, error)
+	SendMessage(ctx context.Context, chatID int64, text string, buttons []telegram.Button) error
+	AnswerCallback(ctx context.Context, callbackID string, text string) error
+}
+
+// recipeBot answers chat messages listing ingredients with recipe cards,
+// whose buttons show the instructions or add the missing ingredients to the
+// chat's shopping list. It answers one update at a time.
+type recipeBot struct {
+	chat botChat
+	// query is the search the flags describe, without ingredients.
+	query recipes.Query
+	// search finds and screens the recipes for a query.
+	search func(ctx context.Context, query recipes.Query) ([]recipes.Recipe, error)
+	// sent are the recipes cards were sent of, by recipeKey.
+	sent map[string]recipes.Recipe
+	// shopping are the recipes added to each chat's shopping list.
+	shopping map[int64][]recipes.Recipe
+	// acceptInvite makes an account a member with an invitation token from
+	// "recipefinder user invite" and returns its profile. It is nil without
+	// a cache to keep the members in.
+	acceptInvite func(ctx context.Context, token string, account string) (string, error)
+}
+
+// runBot implements "recipefinder bot": it answers Telegram messages until it
+// is stopped, searching like "recipefinder search" with the flags given.
+func runBot(cfg *config.Config, provider recipes.RecipeProvider, reporter *errorReporter) error {
+	if cfg.Telegram.Token == "" {
+		return errors.New("usage: recipefinder bot --telegram-token=<token> [flags], or set telegram.token " +
+			"in the config file, see recipefinder help bot")
+	}
+	count := *numberOfRecipes
+	if count == 0 {
+		count = botRecipes
+	}
+	query, err := parseFilters(count, cfg.Allergens)
+	if err != nil {
+		return err
+	}
+	screen, err := parseScreening()
+	if err != nil {
+		return err
+	}
+
+	// A bot runs until it is stopped, not for the command timeout
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	provider = withQuota(ctx, provider, cache, cfg)
+
+	client := telegram.NewClient(cfg.Telegram.Token)
+	client.HTTPClient = &http.Client{Timeout: botPollTimeout + 10*time.Second}
+	bot := &recipeBot{
+		chat:  client,
+		query: query,
+		search: func(ctx context.Context, query recipes.Query) ([]recipes.Recipe, error) {
+			return screenedSearch(ctx, cfg, provider, cache, reporter, screen, query)
+		},
+		sent:     make(map[string]recipes.Recipe),
+		shopping: make(map[int64][]recipes.Recipe),
+	}
+	if cache != nil {
+		bot.acceptInvite = cache.AcceptInvite
+	}
+	fmt.Println("Answering Telegram messages, stop with Ctrl+C")
+	bot.run(ctx)
+	return nil
+}
+
+// run polls for updates and answers them until the context is done.
+func (b *recipeBot) run(ctx context.Context) {
+	offset := 0
+	for {
+		updates, err := b.chat.Updates(ctx, offset, botPollTimeout)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			slog.Error("error getting chat updates", "error", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(botRetryDelay):
+			}
+			continue
+		}
+		for _, update := range updates {
+			offset = update.ID + 1
+			err := b.handle(ctx, update)
+			if err != nil && ctx.Err() == nil {
+				slog.Error("error answering chat", "error", err)
+			}
+		}
+	}
+}
+
+func (b *recipeBot) handle(ctx context.Context, update telegram.Update) error {
+	switch {
+	case update.Message != nil:
+		return b.answerMessage(ctx, *update.Message)
+	case update.CallbackQuery != nil:
+		return b.answerButton(ctx, *update.CallbackQuery)
+	}
+	return nil
+}
+
+// answerMessage searches for the ingredients a message lists and sends a card
+// for each recipe found.
+func (b *recipeBot) answerMessage(ctx context.Context, message telegram.Message) error {
+	chatID := message.Chat.ID
+	text := strings.TrimSpace(message.Text)
+	// Invitation links open the chat with "/start <token>"
+	if token, ok := strings.CutPrefix(text, "/start "); ok {
+		return b.join(ctx, chatID, strings.TrimSpace(token))
+	}
+	if text == "" || strings.HasPrefix(text, "/") {
+		return b.chat.SendMessage(ctx, chatID, botHelp, nil)
+	}
+	ingredientList, notes, err := recipes.CleanIngredientList(strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == '\n'
+	}))
+	if err != nil {
+		return b.chat.SendMessage(ctx, chatID, err.Error(), nil)
+	}
+	for _, note := range notes {
+		err := b.chat.SendMessage(ctx, chatID, "Note: "+note, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	query := b.query
+	query.Ingredients = ingredientList
+	found, err := b.search(ctx, query)
+	if err != nil {
+		sendErr := b.chat.SendMessage(ctx, chatID, "Sorry, the search failed, please try again later.", nil)
+		return errors.Join(fmt.Errorf("search failed: %w", err), sendErr)
+	}
+	if len(found) == 0 {
+		return b.chat.SendMessage(ctx, chatID, "No recipes found.", nil)
+	}
+	if len(b.sent)+len(found) > maxSentRecipes {
+		clear(b.sent)
+	}
+	for _, recipe := range recipes.SuggestSubstitutes(found, ingredientList) {
+		key := recipeKey(recipe)
+		b.sent[key] = recipe
+		err := b.chat.SendMessage(ctx, chatID, recipeCard(recipe), []telegram.Button{
+			{Text: "Show instructions", CallbackData: "instructions:" + key},
+			{Text: "Add to shopping list", CallbackData: "shop:" + key},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// join accepts an invitation for the chat.
+func (b *recipeBot) join(ctx context.Context, chatID int64, token string) error {
+	if b.acceptInvite == nil {
+		return b.chat.SendMessage(ctx, chatID, "Sorry, invitations cannot be accepted right now.", nil)
+	}
+	profile, err := b.acceptInvite(ctx, token, telegramAccount(chatID))
+	if errors.Is(err, store.ErrInvalidInvite) {
+		return b.chat.SendMessage(ctx, chatID, "This invitation is invalid, expired or already used, please ask "+
+			"for a new one.", nil)
+	}
+	if err != nil {
+		sendErr := b.chat.SendMessage(ctx, chatID, "Sorry, joining failed, please try again later.", nil)
+		return errors.Join(fmt.Errorf("error accepting invitation: %w", err), sendErr)
+	}
+	return b.chat.SendMessage(ctx, chatID, fmt.Sprintf("Welcome, you joined as %s. %s", profile, botHelp), nil)
+}
+
+// telegramAccount is the member account of a Telegram chat.
+func telegramAccount(chatID int64) string {
+	return fmt.Sprintf("telegram:%d", chatID)
+}
+
+// telegramChat is the Telegram chat of a member account, false for accounts
+// elsewhere.
+func telegramChat(account string) (int64, bool) {
+	id, found := strings.CutPrefix(account, "telegram:")
+	if !found {
+		return 0, false
+	}
+	chatID, err := strconv.ParseInt(id, 10, 64)
+	return chatID, err == nil
+}
+
+// answerButton handles a press of a card's button.
+func (b *recipeBot) answerButton(ctx context.Context, callback telegram.CallbackQuery) error {
+	action, key, _ := strings.Cut(callback.Data, ":")
+	recipe, ok := b.sent[key]
+	if !ok || callback.Message == nil {
+		return b.chat.AnswerCallback(ctx, callback.ID, "I no longer know this recipe, please search again")
+	}
+	chatID := callback.Message.Chat.ID
+
+	var text, notice string
+	switch action {
+	case "instructions":
+		text = recipeInstructions(recipe)
+	case "shop":
+		added := b.shopping[chatID]
+		notice = "Already on your shopping list"
+		if !containsRecipe(added, recipe) {
+			added = append(added, recipe)
+			b.shopping[chatID] = added
+			notice = "Added to your shopping list"
+		}
+		var list bytes.Buffer
+		err := textFormatter{}.FormatShoppingList(&list, recipes.ShoppingList(added))
+		if err != nil {
+			return err
+		}
+		text = list.String()
+	default:
+		return b.chat.AnswerCallback(ctx, callback.ID, "")
+	}
+	err := b.chat.SendMessage(ctx, chatID, botText(text), nil)
+	if err != nil {
+		return err
+	}
+	return b.chat.AnswerCallback(ctx, callback.ID, notice)
+}
+
+func containsRecipe(allRecipes []recipes.Recipe, recipe recipes.Recipe) bool {
+	for _, other := range allRecipes {
+		if recipeKey(other) == recipeKey(recipe) {
+			return true
+		}
+	}
+	return false
+}
+
+// recipeCard is the message a recipe is sent as: what the text output shows
+// for it, with a link to the full recipe.
+func recipeCard(recipe recipes.Recipe) string {
+	var card bytes.Buffer
+	// Writing to a buffer cannot fail
+	_ = textFormatter{}.Format(&card, []recipes.Recipe{recipe}, false)
+	text := strings.TrimSpace(card.String())
+	if recipe.SourceURL != "" {
+		text += "\n" + recipe.SourceURL
+	}
+	return botText(text)
+}
+
+// recipeInstructions is the message a recipe's instructions are sent as.
+func recipeInstructions(recipe recipes.Recipe) string {
+	if len(recipe.Instructions) == 0 {
+		text := "No instructions known for " + recipe.Title
+		if recipe.SourceURL != "" {
+			text += ", see " + recipe.SourceURL
+		}
+		return text
+	}
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s\n", recipe.Title)
+	for i, step := range recipe.Instructions {
+		fmt.Fprintf(&text, "%d. %s\n", i+1, step)
+	}
+	return text.String()
+}
+
+// botText cuts a message down to the most a chat message may have.
+func botText(text string) string {
+	runes := []rune(text)
+	if len(runes) <= telegram.MaxMessageLength {
+		return text
+	}
+	return string(runes[:telegram.MaxMessageLength-1]) + "…"
+}
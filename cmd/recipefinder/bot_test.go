@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+	"github.com/mawojcik/meals_generator/pkg/telegram"
+)
+
+// fakeChat records what the bot sends.
+type fakeChat struct {
+	sent     []string
+	buttons  [][]telegram.Button
+	answered []string
+}
+
+func (c *fakeChat) Updates(ctx context.Context, offset int, timeout time.Duration) ([]telegram.Update, error) {
+	return nil, nil
+}
+
+func (c *fakeChat) SendMessage(ctx context.Context, chatID int64, text string, buttons []telegram.Button) error {
+	c.sent = append(c.sent, text)
+	c.buttons = append(c.buttons, buttons)
+	return nil
+}
+
+func (c *fakeChat) AnswerCallback(ctx context.Context, callbackID string, text string) error {
+	c.answered = append(c.answered, text)
+	return nil
+}
+
+func TestBot(t *testing.T) {
+	ctx := context.Background()
+	chat := &fakeChat{}
+	var searched []string
+	bot := &recipeBot{
+		chat:  chat,
+		query: recipes.Query{NumberOfRecipes: 2},
+		search: func(ctx context.Context, query recipes.Query) ([]recipes.Recipe, error) {
+			searched = query.Ingredients
+			return testRecipes(t)[:2], nil
+		},
+		sent:     make(map[string]recipes.Recipe),
+		shopping: make(map[int64][]recipes.Recipe),
+	}
+
+	err := bot.handle(ctx, telegram.Update{Message: &telegram.Message{Chat: telegram.Chat{ID: 1}, Text: "Garlic, spaghetti"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(searched, []string{"garlic", "spaghetti"}) {
+		t.Errorf("searched for %q, want [garlic spaghetti]", searched)
+	}
+	if len(chat.sent) != 2 || !strings.HasPrefix(chat.sent[0], "Recipe: Pasta with Garlic") {
+		t.Fatalf("got messages %q, want a card per recipe", chat.sent)
+	}
+
+	for _, button := range chat.buttons[0] {
+		err := bot.handle(ctx, telegram.Update{CallbackQuery: &telegram.CallbackQuery{
+			Data:    button.CallbackData,
+			Message: &telegram.Message{Chat: telegram.Chat{ID: 1}},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := chat.sent[2]; !strings.Contains(got, "1. Boil the pasta.") {
+		t.Errorf("got instructions %q, want the steps", got)
+	}
+	if got := chat.sent[3]; !strings.HasPrefix(got, "Shopping List:") || !strings.Contains(got, "red onion") {
+		t.Errorf("got shopping list %q, want the missing ingredients", got)
+	}
+	if !slices.Equal(chat.answered, []string{"", "Added to your shopping list"}) {
+		t.Errorf("got button answers %q", chat.answered)
+	}
+
+	err = bot.handle(ctx, telegram.Update{CallbackQuery: &telegram.CallbackQuery{Data: "shop:spoonacular:999"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := chat.answered[len(chat.answered)-1]; !strings.Contains(got, "search again") {
+		t.Errorf("got answer %q to an unknown recipe, want to search again", got)
+	}
+}
+
+func TestBotJoin(t *testing.T) {
+	ctx := context.Background()
+	chat := &fakeChat{}
+	members := make(map[string]string)
+	bot := &recipeBot{
+		chat: chat,
+		acceptInvite: func(ctx context.Context, token string, account string) (string, error) {
+			if token != "secret" {
+				return "", store.ErrInvalidInvite
+			}
+			members[account] = "alice"
+			return "alice", nil
+		},
+	}
+	for _, text := range []string{"/start secret", "/start reused"} {
+		err := bot.handle(ctx, telegram.Update{Message: &telegram.Message{Chat: telegram.Chat{ID: 7}, Text: text}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if members["telegram:7"] != "alice" || !strings.HasPrefix(chat.sent[0], "Welcome, you joined as alice.") {
+		t.Errorf("got members %v and message %q, want the chat joined as alice", members, chat.sent[0])
+	}
+	if !strings.Contains(chat.sent[1], "invalid, expired or already used") {
+		t.Errorf("got %q for an invalid invitation, want it refused", chat.sent[1])
+	}
+}
+
+// fakeMembers keeps members, their preferences and digests in memory.
+type fakeMembers struct {
+	members []store.Member
+	prefs   map[string]store.NotificationPrefs
+	digests []store.DigestItem
+	cooked  map[string][]store.CookedMeal
+}
+
+func (m *fakeMembers) Members(ctx context.Context) ([]store.Member, error) {
+	return m.members, nil
+}
+
+func (m *fakeMembers) NotificationPrefs(ctx context.Context, profile string) (store.NotificationPrefs, error) {
+	if prefs, ok := m.prefs[profile]; ok {
+		return prefs, nil
+	}
+	return store.DefaultNotificationPrefs(profile), nil
+}
+
+func (m *fakeMembers) QueueDigest(ctx context.Context, item store.DigestItem) error {
+	item.CreatedAt = time.Date(2026, 10, 15, 12, 0, 0, 0, time.Local)
+	m.digests = append(m.digests, item)
+	return nil
+}
+
+func (m *fakeMembers) PendingDigests(ctx context.Context, before time.Time) ([]store.DigestItem, error) {
+	var pending []store.DigestItem
+	for _, item := range m.digests {
+		if item.CreatedAt.Before(before) {
+			pending = append(pending, item)
+		}
+	}
+	return pending, nil
+}
+
+func (m *fakeMembers) MemberProfile(ctx context.Context, account string) (string, error) {
+	for _, member := range m.members {
+		if member.Account == account {
+			return member.Profile, nil
+		}
+	}
+	return "", nil
+}
+
+func (m *fakeMembers) ProfileCooked(ctx context.Context, profile string, since time.Time) ([]store.CookedMeal, error) {
+	return m.cooked[profile], nil
+}
+
+func (m *fakeMembers) ClearDigest(ctx context.Context, account string, before time.Time) error {
+	m.digests = slices.DeleteFunc(m.digests, func(item store.DigestItem) bool {
+		return item.Account == account && item.CreatedAt.Before(before)
+	})
+	return nil
+}
+
+func TestMemberNotifier(t *testing.T) {
+	chat := &fakeChat{}
+	quiet := store.DefaultNotificationPrefs("carol")
+	quiet.QuietStart, quiet.QuietEnd = 22*60, 7*60
+	n := memberNotifier{
+		chat: chat,
+		cache: &fakeMembers{
+			members: []store.Member{
+				{Account: "telegram:1", Profile: "alice"},
+				{Account: "telegram:2", Profile: "bob"},
+				{Account: "telegram:3", Profile: "carol"},
+			},
+			prefs: map[string]store.NotificationPrefs{
+				"bob":   {Profile: "bob", Channels: []string{store.ChannelNone}, Events: store.Events},
+				"carol": quiet,
+			},
+		},
+		now: func() time.Time { return time.Date(2026, 10, 15, 23, 0, 0, 0, time.Local) },
+	}
+	err := n.notify(context.Background(), notification{
+		Event:   store.EventNewMatches,
+		Message: "1 new recipe matches",
+		Recipes: []recipes.Recipe{{ID: 1, Title: "Shakshuka"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chat.sent) != 1 || !strings.Contains(chat.sent[0], "Shakshuka") {
+		t.Errorf("got %q, want one notification, to alice only", chat.sent)
+	}
+}
+
+func TestMemberNotifierDigest(t *testing.T) {
+	ctx := context.Background()
+	chat := &fakeChat{}
+	members := &fakeMembers{
+		members: []store.Member{{Account: "telegram:1", Profile: "alice"}},
+		cooked: map[string][]store.CookedMeal{
+			"alice": {{Title: "Shakshuka", CookedAt: time.Date(2026, 10, 15, 19, 0, 0, 0, time.Local)}},
+		},
+	}
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.Local)
+	n := memberNotifier{
+		chat:     chat,
+		cache:    members,
+		digest:   []string{store.EventNewMatches},
+		digestAt: 8 * 60,
+		now:      func() time.Time { return now },
+	}
+	for _, message := range []string{"1 new recipe matches", "2 new recipes match"} {
+		err := n.notify(ctx, notification{Event: store.EventNewMatches, Message: message})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(chat.sent) != 0 || len(members.digests) != 2 {
+		t.Fatalf("sent %q and queued %d, want both notifications queued", chat.sent, len(members.digests))
+	}
+
+	// Queued at noon, they wait for tomorrow's 08:00
+	err := n.sendDigests(ctx)
+	if err != nil || len(chat.sent) != 0 {
+		t.Fatalf("sent %q, %v before the digest time, want nothing", chat.sent, err)
+	}
+	now = time.Date(2026, 10, 16, 8, 0, 0, 0, time.Local)
+	err = n.sendDigests(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chat.sent) != 1 || !strings.Contains(chat.sent[0], "2 new recipes match") || len(members.digests) != 0 {
+		t.Errorf("sent %q, %d left queued, want one digest of both", chat.sent, len(members.digests))
+	}
+	// Nothing is cooked yet today, so yesterday's meal keeps the streak
+	if len(chat.sent) == 1 && !strings.Contains(chat.sent[0], "Days cooked in a row: 1,") {
+		t.Errorf("sent %q, want alice's cooking streak in the digest", chat.sent[0])
+	}
+}
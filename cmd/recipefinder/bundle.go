@@ -0,0 +1,150 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+var bundle = flag.String("bundle", "", "With the plan command, write the plan as a PDF, a Markdown file per "+
+	"recipe, the shopping list as CSV, an .ics calendar and the recipes' images to this zip file")
+
+// maxSlugLength bounds the part of a recipe's Markdown file name taken from its
+// title.
+const maxSlugLength = 60
+
+// bundlePlan writes the plan to the --bundle zip file, starting the day after
+// exportTime as exportPlan does, with the recipes' images downloaded first.
+func bundlePlan(ctx context.Context, cfg *config.Config, plan recipes.Plan) error {
+	start := firstPlanDay(exportTime(), timeZone(cfg))
+	images := downloadImages(ctx, http.DefaultClient, filepath.Join(cfg.DataDir, "images"), planRecipes(plan))
+
+	file, err := os.Create(*bundle)
+	if err != nil {
+		return fmt.Errorf("error creating bundle file: %v", err)
+	}
+	err = writeBundle(file, plan, start, images)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing bundle: %v", err)
+	}
+	fmt.Printf("Bundled the meal plan to %s\n", *bundle)
+	return nil
+}
+
+// writeBundle writes the zip of a plan: plan.pdf, plan.ics,
+// shopping-list.csv, a recipes/<n>-<title>.md per recipe and the images
+// among the files, by recipeKey, in images. Every file is dated exportTime,
+// so bundling the same plan twice gives identical zips.
+func writeBundle(w io.Writer, plan recipes.Plan, start time.Time, images map[string]string) error {
+	archive := zip.NewWriter(w)
+	modified := exportTime()
+	add := func(name string, method uint16, write func(w io.Writer) error) error {
+		entry, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: modified})
+		if err != nil {
+			return err
+		}
+		err = write(entry)
+		if err != nil {
+			return fmt.Errorf("error writing %s: %v", name, err)
+		}
+		return nil
+	}
+
+	err := add("plan.pdf", zip.Deflate, func(w io.Writer) error {
+		var table bytes.Buffer
+		err := printPlan(&table, plan)
+		if err != nil {
+			return err
+		}
+		return writePDF(w, strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n"), newPDFLayout(*printSize))
+	})
+	if err != nil {
+		return err
+	}
+	err = add("plan.ics", zip.Deflate, func(w io.Writer) error {
+		return icsExporter{}.ExportPlan(w, plan, start)
+	})
+	if err != nil {
+		return err
+	}
+	allRecipes := planRecipes(plan)
+	err = add("shopping-list.csv", zip.Deflate, func(w io.Writer) error {
+		return csvExporter{}.ExportShoppingList(w, convertedShoppingList(allRecipes))
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, recipe := range allRecipes {
+		image, hasImage := images[recipeKey(recipe)]
+		name := fmt.Sprintf("recipes/%02d-%s.md", i+1, fileSlug(recipe.Title))
+		err := add(name, zip.Deflate, func(w io.Writer) error {
+			err := markdownFormatter{}.Format(w, []recipes.Recipe{recipe}, true)
+			if err != nil || !hasImage {
+				return err
+			}
+			_, err = fmt.Fprintf(w, "\n![%s](../images/%s)\n", recipe.Title, filepath.Base(image))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if !hasImage {
+			continue
+		}
+		// Images are compressed already
+		err = add(path.Join("images", filepath.Base(image)), zip.Store, func(w io.Writer) error {
+			data, err := os.ReadFile(image)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(data)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// planRecipes returns the meals of a plan in the order they are planned.
+func planRecipes(plan recipes.Plan) []recipes.Recipe {
+	var allRecipes []recipes.Recipe
+	for _, day := range plan.Days {
+		allRecipes = append(allRecipes, day.Meals...)
+	}
+	return allRecipes
+}
+
+// fileSlug turns a title into a file name: lowercase letters and digits with
+// dashes between the words.
+func fileSlug(title string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return r > unicode.MaxASCII || !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	slug := strings.Join(words, "-")
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimRight(slug[:maxSlugLength], "-")
+	}
+	if slug == "" {
+		return "recipe"
+	}
+	return slug
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+const cacheUsage = "usage: recipefinder cache purge | cache refresh | cache stats | cache search <ingredient>"
+
+// runCache implements "recipefinder cache <subcommand>".
+func runCache(ctx context.Context, args []string, cfg *config.Config) error {
+	switch {
+	case len(args) == 1 && (args[0] == "purge" || args[0] == "refresh" || args[0] == "stats"):
+	case len(args) == 2 && args[0] == "search" && strings.TrimSpace(args[1]) != "":
+	default:
+		return errors.New(cacheUsage)
+	}
+	if args[0] == "refresh" && len(cfg.SpoonacularKeys()) == 0 {
+		return config.ErrNoAPIKey
+	}
+
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache")
+	}
+
+	switch args[0] {
+	case "stats":
+		stats, err := cache.CacheStats(ctx)
+		if err != nil {
+			return fmt.Errorf("error reading cache statistics: %v", err)
+		}
+		printCacheStats(os.Stdout, stats, timeZone(cfg))
+		return nil
+	case "search":
+		return searchCache(ctx, cache, args[1], cfg.CacheTTL, timeZone(cfg))
+	}
+	if cfg.CacheTTL <= 0 {
+		fmt.Println("Cache TTL is 0, nothing expires")
+		return nil
+	}
+	if args[0] == "refresh" {
+		return refreshCache(ctx, cache, newSpoonacular(cfg))
+	}
+	purged, err := cache.Purge(ctx)
+	if err != nil {
+		return fmt.Errorf("error purging cache: %v", err)
+	}
+	fmt.Printf("Purged %d expired recipes\n", purged)
+	return nil
+}
+
+// printCacheStats writes what the cache holds, to help decide when to purge
+// or refresh it.
+func printCacheStats(w io.Writer, stats store.CacheStats, location *time.Location) {
+	fmt.Fprintf(w, "Recipes: %d, %d expired\n", stats.Recipes, stats.Expired)
+	fmt.Fprintf(w, "Ingredient rows: %d\n", stats.Ingredients)
+	fmt.Fprintf(w, "Nutrient rows: %d\n", stats.Nutrients)
+	fmt.Fprintf(w, "Cached searches: %d\n", stats.Queries)
+	if stats.TrackedSince.IsZero() {
+		fmt.Fprintln(w, "Hit rate: no lookups counted yet")
+	} else {
+		fmt.Fprintf(w, "Hit rate: %.1f%% of %d lookups since %s\n", stats.HitRate()*100, stats.Hits+stats.Misses,
+			stats.TrackedSince.In(location).Format(time.DateTime))
+	}
+	if !stats.Oldest.IsZero() {
+		fmt.Fprintf(w, "Oldest recipe fetched: %s\n", stats.Oldest.In(location).Format(time.DateTime))
+		fmt.Fprintf(w, "Newest recipe fetched: %s\n", stats.Newest.In(location).Format(time.DateTime))
+	}
+	fmt.Fprintf(w, "Database size: %s\n", formatSize(stats.Size))
+}
+
+// formatSize writes a size in bytes with a binary unit, e.g. "4.2 MiB".
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, prefix := float64(size)/unit, 0
+	for value >= unit && prefix < len("KMGT")-1 {
+		value /= unit
+		prefix++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGT"[prefix])
+}
+
+// searchCache lists the cached recipes using an ingredient, with when they
+// were fetched and whether that is longer than the TTL ago.
+func searchCache(ctx context.Context, cache store.Store, ingredient string, ttl time.Duration,
+	location *time.Location) error {
+	found, err := cache.SearchCached(ctx, recipes.Query{Ingredients: []string{strings.ToLower(ingredient)}})
+	if err != nil {
+		return fmt.Errorf("error searching the cache: %v", err)
+	}
+	if len(found) == 0 {
+		fmt.Printf("No cached recipes use %s\n", ingredient)
+		return nil
+	}
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ID\tSource\tTitle\tFetched")
+	for _, recipe := range found {
+		fetched := "unknown"
+		if !recipe.FetchedAt.IsZero() {
+			fetched = recipe.FetchedAt.In(location).Format(time.DateTime)
+			if ttl > 0 && time.Since(recipe.FetchedAt) > ttl {
+				fetched += " (expired)"
+			}
+		}
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\n", recipe.ID, recipe.Source, recipe.Title, fetched)
+	}
+	return table.Flush()
+}
+
+// refreshCache brings the expired recipes from the updater's source up to
+// date by fetching only their titles and nutrients, instead of searching for
+// them again, and writing just those columns. Recipes the source no longer
+// has stay expired and are removed by the next purge.
+func refreshCache(ctx context.Context, cache store.Store, updater recipes.Updater) error {
+	stale, err := cache.StaleRecipes(ctx, updater.Name())
+	if err != nil {
+		return fmt.Errorf("error reading expired recipes: %v", err)
+	}
+	if len(stale) == 0 {
+		fmt.Println("No expired recipes to refresh")
+		return nil
+	}
+
+	ids := make([]int, 0, len(stale))
+	for _, recipe := range stale {
+		ids = append(ids, recipe.ID)
+	}
+	updates, err := updater.Updates(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("error fetching recipe updates: %v", err)
+	}
+
+	byID := make(map[int]recipes.Recipe, len(stale))
+	for _, recipe := range stale {
+		byID[recipe.ID] = recipe
+	}
+	changed := 0
+	for i := range updates {
+		updates[i].Title = recipes.SanitizeText(updates[i].Title)
+		if updates[i].Changes(byID[updates[i].ID]) {
+			changed++
+		}
+	}
+	_, err = cache.UpdateRecipes(ctx, updater.Name(), updates)
+	if err != nil {
+		return fmt.Errorf("error updating recipes: %v", err)
+	}
+
+	fmt.Printf("Refreshed %d of %d expired recipes, %d had changed\n", len(updates), len(stale), changed)
+	if missing := len(stale) - len(updates); missing > 0 {
+		fmt.Printf("%d recipes are no longer available and will be removed by cache purge\n", missing)
+	}
+	return nil
+}
@@ -0,0 +1,390 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Flags are declared on flag.CommandLine next to the code using them, but
+// flag.CommandLine is never parsed itself. Each command parses a flag set of
+// its own holding the global flags and the ones listed for it, which share
+// their values with the flag.CommandLine declarations.
+
+// command describes a subcommand for parsing, help and shell completion.
+type command struct {
+	name    string
+	aliases []string
+	// usage follows "recipefinder <name>" in the help.
+	usage   string
+	summary string
+	flags   []string
+	// subcommands are completed after the command name.
+	subcommands []string
+}
+
+// globalFlags are accepted by every command.
+var globalFlags = []string{
+	"config", "portable", "apiKey", "db", "cacheTTL", "timeout", "provider", "region",
+	"logFile", "logMaxSize", "logMaxBackups", "logFormat", "sentryDSN", "features", "verbose", "quiet",
+	"profile", "demo", "record", "replay", "nutrients", "strict", "requireCache",
+	"api-key-file", "budget", "parallel", "lang",
+}
+
+// hiddenFlags are accepted by every command but left out of the help and the
+// completions.
+var hiddenFlags = []string{"fault-inject"}
+
+// queryFlags choose and screen the recipes of a search or a plan.
+var queryFlags = []string{
+	"ingredients", "diet", "intolerances", "excludeIngredients", "religious-diet", "no-alcohol", "low-fodmap", "pregnancy-safe",
+	"disliked-forms", "maxCalories", "minProtein", "maxCarbs", "maxPricePerServing", "minHealthScore", "fuzzy", "skipPantry", "refresh",
+	"allowDuplicates", "maxReadyTime", "equipment", "noEquipment", "translate-ingredients",
+}
+
+// sortFlags order the recipes of a search.
+var sortFlags = []string{"sort", "rankMissing", "rankProtein", "rankCalories", "rankTime", "calorieTarget"}
+
+var commands = []command{
+	{
+		name: "search",
+		usage: "--ingredients=<ingredient1>,... --numberOfRecipes=<number> [flags] | save --name=<name> [flags] | " +
+			"run <name> [flags] | list | delete <name>",
+		summary: "Find recipes using the given ingredients (the default command), or save searches to run by name",
+		flags: append(append([]string{"numberOfRecipes", "instructions", "output", "units", "accessible",
+			"shopping-list", "export", "out", "interactive", "serve", "port", "offline", "name", "images", "dry-run",
+			"servings", "pairing", "substitutes", "stream", "save-session"}, sortFlags...),
+			queryFlags...),
+		subcommands: []string{"save", "run", "list", "delete"},
+	},
+	{
+		name:    "plan",
+		usage:   "--ingredients=<ingredient1>,... [--days=7] [--mealsPerDay=3] [flags]",
+		summary: "Spread found recipes over a meal plan",
+		flags: append([]string{"days", "mealsPerDay", "minimizeLeftovers", "seed", "activity", "stretch", "output",
+			"accessible", "export", "out", "bundle", "print-size"}, queryFlags...),
+	},
+	{
+		name:    "random",
+		usage:   "[--tags=<tag1>,...] [--number=1] [--seed=<n>] [flags]",
+		summary: "Surprise me: show recipes picked at random, optionally with the given tags",
+		flags: []string{"tags", "number", "seed", "instructions", "output", "units", "servings", "accessible",
+			"skipPantry"},
+	},
+	{
+		name:    "watch",
+		usage:   "--ingredients=<ingredient1>,... [--interval=<duration>] [flags] | <saved search> [flags]",
+		summary: "Search again whenever the pantry changes and report recipes not found before",
+		flags:   append(append([]string{"numberOfRecipes", "interval"}, sortFlags...), queryFlags...),
+	},
+	{
+		name:        "schedule",
+		usage:       "[list] [flags]",
+		summary:     "Run the saved searches of the config's schedules and send their new recipes to a webhook or by email",
+		flags:       append(append([]string{"numberOfRecipes"}, sortFlags...), queryFlags...),
+		subcommands: []string{"list"},
+	},
+	{
+		name:    "diff-last",
+		usage:   "--ingredients=<ingredient1>,... [flags] | <saved search> [flags]",
+		summary: "Show which recipes a search finds that it did not the last time, and which changed",
+		flags:   append(append([]string{"numberOfRecipes", "output"}, sortFlags...), queryFlags...),
+	},
+	{
+		name:    "bot",
+		usage:   "--telegram-token=<token> [flags]",
+		summary: "Answer Telegram messages listing ingredients with recipe cards",
+		flags:   append(append([]string{"telegram-token", "numberOfRecipes"}, sortFlags...), queryFlags...),
+	},
+	{
+		name:    "serve",
+		usage:   "[--port=8080] [--ui=false] [--grpc-port=<port>]",
+		summary: "Serve searches over HTTP, with a web UI on /, Prometheus metrics on /metrics, checks on /healthz and what it offers on /capabilities",
+		flags:   []string{"port", "refresh", "ui", "grpc-port"},
+	},
+	{
+		name:        "pantry",
+		usage:       "add <ingredient1>,... | remove <ingredient1>,... | list",
+		summary:     "Manage the ingredients always added to searches",
+		subcommands: []string{"add", "remove", "list"},
+	},
+	{
+		name:        "user",
+		usage:       "invite <profile> | list | remove <profile> | notifications <profile>",
+		summary:     "Invite household members to join as a profile through the Telegram bot",
+		flags:       []string{"inviteTTL", "channels", "events", "quietHours"},
+		subcommands: []string{"invite", "list", "remove", "notifications"},
+	},
+	{
+		name:        "cache",
+		usage:       "purge | refresh | stats | search <ingredient>",
+		summary:     "Delete expired recipes from the cache, refresh their titles and nutrients, or inspect it",
+		subcommands: []string{"purge", "refresh", "stats", "search"},
+	},
+	{
+		name:        "jobs",
+		usage:       "list [status] | add <kind> [--priority=<n>] | cancel <id> | work",
+		summary:     "Inspect, queue and cancel background jobs, or run a worker for them",
+		flags:       []string{"priority"},
+		subcommands: []string{"list", "add", "cancel", "work"},
+	},
+	{
+		name:        "favorites",
+		aliases:     []string{"favorite"},
+		usage:       "add <id> | remove <id> | list",
+		summary:     "Star recipes and list the starred ones",
+		subcommands: []string{"add", "remove", "list"},
+	},
+	{
+		name:        "cooked",
+		usage:       "<id> | list",
+		summary:     "Log recipes as cooked, so plans know the cuisines already tried",
+		subcommands: []string{"list"},
+	},
+	{
+		name: "session",
+		usage: "list | <name> [show] | <name> pick <n1>,... | <name> shopping-list | <name> instructions | " +
+			"<name> nutrition | <name> delete",
+		summary: "Pick from the recipes a search saved with --save-session, then print the picks' shopping list, " +
+			"instructions or nutrition against the profile's daily goals",
+		flags:       []string{"output", "units", "accessible", "export", "out"},
+		subcommands: []string{"list"},
+	},
+	{
+		name:        "migrate",
+		usage:       "[status]",
+		summary:     "Bring the cache database schema up to date, or list its migrations",
+		subcommands: []string{"status"},
+	},
+	{
+		name:        "db",
+		usage:       "rollback-migration",
+		summary:     "Undo the last cache database migration, backing up what it drops",
+		subcommands: []string{"rollback-migration"},
+	},
+	{
+		name:        "snapshot",
+		usage:       "create <file> | restore <file>",
+		summary:     "Save the whole database and the config, without secrets, to a file, or restore them from one",
+		subcommands: []string{"create", "restore"},
+	},
+	{
+		name:    "history",
+		summary: "List past searches",
+	},
+	{
+		name:        "ingredient",
+		usage:       "show <name>",
+		summary:     "Show an ingredient's category, season, price, substitutes, unit weights and nutrition",
+		subcommands: []string{"show"},
+	},
+	{
+		name:    "report-issue",
+		usage:   `<recipeID> "<what is wrong>"`,
+		summary: "Report wrong data in a recipe, leaving it out of later results and sending the report if configured",
+	},
+	{
+		name:        "preferences",
+		usage:       "quiz [--questions=8] | show | reset",
+		summary:     "Learn ranking weights and disliked ingredients from which of two cached recipes you would rather eat",
+		flags:       []string{"questions", "seed"},
+		subcommands: []string{"quiz", "show", "reset"},
+	},
+	{
+		name:        "experiment",
+		usage:       "pick <recipeID> | report",
+		summary:     "Record a recipe picked while comparing rankings, or report which ranking is picked more often",
+		subcommands: []string{"pick", "report"},
+	},
+	{
+		name: "stats",
+		summary: "Show how often each provider's recipes miss nutrition, have broken images or implausible values, " +
+			"and the streaks and achievements of the meals logged as cooked",
+	},
+	{
+		name:    "label",
+		usage:   "<recipeID> [--output=png --out=<file>]",
+		summary: "Print the nutrition facts label of a recipe, or draw it as a PNG image",
+		flags:   []string{"accessible", "output", "out"},
+	},
+	{
+		name:    "show",
+		usage:   "<recipeID>",
+		summary: "Print a recipe's servings, time, links, ingredients and instructions",
+		flags:   []string{"output", "units", "servings", "images"},
+	},
+	{
+		name: "recipes",
+		usage: "add --title=<title> --ingredients=<line1>,... [--steps=<step1>;...] [--servings=<number>] " +
+			"[--diet=<diet1>,...] | import <file.json|file.yaml> | list | delete <id>",
+		summary:     "Keep recipes of your own, which searches show as local when their ingredients match",
+		flags:       []string{"title", "ingredients", "steps", "servings", "diet"},
+		subcommands: []string{"add", "import", "list", "delete"},
+	},
+	{
+		name:        "dataset",
+		usage:       "build --from=<recipes.jsonl>",
+		summary:     "Index a recipe dataset for the offline provider",
+		flags:       []string{"from"},
+		subcommands: []string{"build"},
+	},
+	{
+		name:        "telemetry",
+		usage:       "status | enable | disable",
+		summary:     "Show or change whether anonymous usage metrics are sent",
+		subcommands: []string{"status", "enable", "disable"},
+	},
+	{
+		name:        "completion",
+		usage:       "bash | zsh | fish",
+		summary:     "Print a shell completion script",
+		subcommands: []string{"bash", "zsh", "fish"},
+	},
+	{
+		name:    "help",
+		usage:   "[command]",
+		summary: "Show help for a command",
+	},
+}
+
+// errHelp is returned by parseCommandLine once help has been printed.
+var errHelp = errors.New("help requested")
+
+// commandFlags is the flag set of the command being run, for looking up
+// which flags were set explicitly.
+var commandFlags = flag.NewFlagSet("recipefinder", flag.ContinueOnError)
+
+// lookupCommand finds a command by name or alias.
+func lookupCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+		for _, alias := range cmd.aliases {
+			if alias == name {
+				return cmd, true
+			}
+		}
+	}
+	return command{}, false
+}
+
+// parseCommandLine splits the arguments into the command, defaulting to
+// search, and its positional arguments, and parses its flags. Flags may come
+// before, between or after the positional arguments.
+func parseCommandLine(args []string) (string, []string, error) {
+	if len(args) > 0 && (args[0] == "-h" || args[0] == "-help" || args[0] == "--help") {
+		printHelp(os.Stdout)
+		return "", nil, errHelp
+	}
+	name := "search"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	cmd, ok := lookupCommand(name)
+	if !ok {
+		return "", nil, fmt.Errorf("unknown command %q, see recipefinder help", name)
+	}
+
+	commandFlags = newFlagSet(cmd)
+	var positional []string
+	for {
+		err := commandFlags.Parse(args)
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(os.Stdout, cmd)
+			return "", nil, errHelp
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("%v, see recipefinder help %s", err, cmd.name)
+		}
+		args = commandFlags.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if cmd.name == "help" {
+		if len(positional) == 0 {
+			printHelp(os.Stdout)
+			return "", nil, errHelp
+		}
+		helpFor, ok := lookupCommand(positional[0])
+		if !ok {
+			return "", nil, fmt.Errorf("unknown command %q, see recipefinder help", positional[0])
+		}
+		printCommandHelp(os.Stdout, helpFor)
+		return "", nil, errHelp
+	}
+	return cmd.name, positional, nil
+}
+
+// newFlagSet returns a flag set with the global flags and the command's own,
+// sharing their values with flag.CommandLine.
+func newFlagSet(cmd command) *flag.FlagSet {
+	flags := flag.NewFlagSet("recipefinder "+cmd.name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	for _, name := range append(commandFlagNames(cmd), hiddenFlags...) {
+		declared := flag.CommandLine.Lookup(name)
+		flags.Var(declared.Value, declared.Name, declared.Usage)
+	}
+	return flags
+}
+
+// commandFlagNames returns the names of the flags a command accepts, sorted.
+func commandFlagNames(cmd command) []string {
+	names := append(append([]string(nil), globalFlags...), cmd.flags...)
+	sort.Strings(names)
+	return names
+}
+
+func printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: recipefinder [command] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-11s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run \"recipefinder help <command>\" or \"recipefinder <command> --help\" for its flags.")
+}
+
+func printCommandHelp(w io.Writer, cmd command) {
+	fmt.Fprintf(w, "Usage: recipefinder %s %s\n\n%s\n", cmd.name, cmd.usage, cmd.summary)
+	if len(cmd.aliases) > 0 {
+		fmt.Fprintf(w, "Also available as: %s\n", strings.Join(cmd.aliases, ", "))
+	}
+	if cmd.name == "help" || cmd.name == "completion" {
+		return
+	}
+	printFlags(w, "Flags", cmd.flags)
+	printFlags(w, "Global flags", globalFlags)
+}
+
+func printFlags(w io.Writer, title string, names []string) {
+	if len(names) == 0 {
+		return
+	}
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+
+	fmt.Fprintf(w, "\n%s:\n", title)
+	for _, name := range sorted {
+		declared := flag.CommandLine.Lookup(name)
+		kind, usage := flag.UnquoteUsage(declared)
+		if kind != "" {
+			fmt.Fprintf(w, "  --%s=<%s>\n", name, kind)
+		} else {
+			fmt.Fprintf(w, "  --%s\n", name)
+		}
+		if declared.DefValue != "" && declared.DefValue != "false" && declared.DefValue != "0" {
+			usage += fmt.Sprintf(" (default %s)", declared.DefValue)
+		}
+		fmt.Fprintf(w, "        %s\n", usage)
+	}
+}
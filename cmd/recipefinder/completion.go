@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const completionUsage = "usage: recipefinder completion bash | completion zsh | completion fish"
+
+// runCompletion implements "recipefinder completion <shell>". The scripts are
+// generated from the command table, so they always list the current commands
+// and flags.
+func runCompletion(args []string) error {
+	if len(args) != 1 {
+		return errors.New(completionUsage)
+	}
+	switch args[0] {
+	case "bash":
+		writeBashCompletion(os.Stdout)
+	case "zsh":
+		// zsh runs the bash script through its bash completion emulation
+		fmt.Println("autoload -U +X bashcompinit && bashcompinit")
+		writeBashCompletion(os.Stdout)
+	case "fish":
+		writeFishCompletion(os.Stdout)
+	default:
+		return errors.New(completionUsage)
+	}
+	return nil
+}
+
+// completionWords returns what can follow a command's name: its subcommands,
+// or for help the names of the commands.
+func completionWords(cmd command) []string {
+	if cmd.name != "help" {
+		return cmd.subcommands
+	}
+	var names []string
+	for _, other := range commands {
+		names = append(names, other.name)
+	}
+	return names
+}
+
+func writeBashCompletion(w io.Writer) {
+	var names []string
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+		names = append(names, cmd.aliases...)
+	}
+
+	fmt.Fprintln(w, "_recipefinder() {")
+	fmt.Fprintln(w, `    local cur="${COMP_WORDS[COMP_CWORD]}" command="" word`)
+	fmt.Fprintln(w, `    for word in "${COMP_WORDS[@]:1:COMP_CWORD-1}"; do`)
+	fmt.Fprintln(w, `        case "$word" in -*) ;; *) command="$word"; break ;; esac`)
+	fmt.Fprintln(w, "    done")
+	fmt.Fprintln(w, `    if [ -z "$command" ] && [[ "$cur" != -* ]]; then`)
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	fmt.Fprintln(w, "        return")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, "    local words")
+	fmt.Fprintln(w, `    case "${command:-search}" in`)
+	for _, cmd := range commands {
+		var words []string
+		if cmd.name != "search" {
+			words = completionWords(cmd)
+		}
+		for _, name := range commandFlagNames(cmd) {
+			words = append(words, "--"+name)
+		}
+		fmt.Fprintf(w, "    %s) words=%q ;;\n", strings.Join(append([]string{cmd.name}, cmd.aliases...), "|"),
+			strings.Join(words, " "))
+	}
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w, `    COMPREPLY=($(compgen -W "$words" -- "$cur"))`)
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -F _recipefinder recipefinder")
+}
+
+func writeFishCompletion(w io.Writer) {
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "complete -c recipefinder -n __fish_use_subcommand -a %s -d %s\n",
+			cmd.name, fishQuote(cmd.summary))
+		for _, word := range completionWords(cmd) {
+			fmt.Fprintf(w, "complete -c recipefinder -n '__fish_seen_subcommand_from %s' -a %s\n", cmd.name, word)
+		}
+	}
+	for _, name := range globalFlags {
+		fmt.Fprintf(w, "complete -c recipefinder -l %s -d %s\n", name, fishQuote(flag.CommandLine.Lookup(name).Usage))
+	}
+	for _, cmd := range commands {
+		// search is also the command when none is given
+		condition := "'__fish_seen_subcommand_from " + strings.Join(append([]string{cmd.name}, cmd.aliases...), " ") + "'"
+		if cmd.name == "search" {
+			condition = "'__fish_use_subcommand; or __fish_seen_subcommand_from search'"
+		}
+		for _, name := range cmd.flags {
+			fmt.Fprintf(w, "complete -c recipefinder -n %s -l %s -d %s\n",
+				condition, name, fishQuote(flag.CommandLine.Lookup(name).Usage))
+		}
+	}
+}
+
+// fishQuote quotes a description for fish, where only backslashes and single
+// quotes need escaping inside single quotes.
+func fishQuote(text string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(text) + "'"
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/spoonacular"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+const cookedUsage = "usage: recipefinder cooked <recipeID> | cooked list"
+
+// runCooked implements "recipefinder cooked". Logging a recipe as cooked
+// records its cuisines and techniques, which tell the plan command the ones
+// that are not new any more.
+func runCooked(ctx context.Context, args []string, cfg *config.Config) error {
+	if len(args) != 1 {
+		return errors.New(cookedUsage)
+	}
+
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache")
+	}
+
+	if args[0] == "list" {
+		meals, err := cache.Cooked(ctx, time.Time{})
+		if err != nil {
+			return fmt.Errorf("error listing cooked meals: %v", err)
+		}
+		if len(meals) == 0 {
+			fmt.Println("Nothing cooked yet")
+			return nil
+		}
+		location := timeZone(cfg)
+		for _, meal := range meals {
+			cuisines := ""
+			if len(meal.Cuisines) > 0 {
+				cuisines = " (" + strings.Join(meal.Cuisines, ", ") + ")"
+			}
+			fmt.Printf("%s  %d  %s%s\n", meal.CookedAt.In(location).Format(time.DateOnly), meal.RecipeID,
+				meal.Title, cuisines)
+		}
+		return nil
+	}
+
+	recipeID, err := strconv.Atoi(args[0])
+	if err != nil || recipeID <= 0 {
+		return fmt.Errorf("invalid recipe ID %q", args[0])
+	}
+	var client *spoonacular.Client
+	if len(cfg.SpoonacularKeys()) > 0 {
+		client = newSpoonacular(cfg)
+	}
+	// A stale cached recipe is good enough to log
+	recipe, err := lookupRecipe(ctx, cache, client, recipeID)
+	if recipe == nil {
+		return fmt.Errorf("error fetching recipe %d: %v", recipeID, err)
+	}
+	err = cache.AddCooked(ctx, *recipe, time.Now())
+	if err != nil {
+		return fmt.Errorf("error logging cooked meal: %v", err)
+	}
+	fmt.Printf("Logged %s as cooked\n", recipe.Title)
+	return nil
+}
+
+// cookedSkills returns the cuisines and techniques of the meals the profile
+// logged as cooked. Without a cache every cuisine and technique is new.
+func cookedSkills(ctx context.Context, cache store.Store) (cuisines []string, techniques []string) {
+	if cache == nil {
+		return nil, nil
+	}
+	meals, err := cache.Cooked(ctx, time.Time{})
+	if err != nil {
+		slog.Warn("error reading cooked meals", "error", err)
+		return nil, nil
+	}
+	for _, meal := range meals {
+		cuisines = append(cuisines, meal.Cuisines...)
+		techniques = append(techniques, meal.Techniques...)
+	}
+	return recipes.Cuisines(cuisines), techniques
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/offline"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+const recipesUsage = "usage: recipefinder recipes add --title=<title> --ingredients=<line1>,... [--steps=<step1>;...] " +
+	"[--servings=<number>] [--diet=<diet1>,...] | import <file.json|file.yaml> | list | delete <id>"
+
+var (
+	recipeTitle = flag.String("title", "", "Title of the recipe added with recipes add")
+	recipeSteps = flag.String("steps", "", "Semicolon-separated cooking steps of the recipe added with recipes add")
+)
+
+// customRecipeInput is a recipe given to "recipefinder recipes add", in the
+// format of offline.ReadRecipes.
+type customRecipeInput struct {
+	Title        string   `json:"title"`
+	Servings     int      `json:"servings,omitempty"`
+	Ingredients  []string `json:"ingredients"`
+	Diets        []string `json:"diets,omitempty"`
+	Instructions []string `json:"instructions,omitempty"`
+}
+
+// runRecipes implements "recipefinder recipes", which keeps the user's own
+// recipes in the cache database, to be found by searches along with the
+// providers' as "local" recipes.
+func runRecipes(ctx context.Context, args []string, cfg *config.Config) error {
+	var recipeID int
+	switch {
+	case len(args) == 1 && (args[0] == "add" || args[0] == "list"):
+	case len(args) == 2 && args[0] == "import":
+	case len(args) == 2 && args[0] == "delete":
+		id, err := strconv.Atoi(args[1])
+		if err != nil || id <= 0 {
+			return fmt.Errorf("invalid recipe ID %q", args[1])
+		}
+		recipeID = id
+	default:
+		return errors.New(recipesUsage)
+	}
+
+	var custom []offline.Recipe
+	if args[0] == "add" || args[0] == "import" {
+		var notes []string
+		var err error
+		if args[0] == "add" {
+			custom, notes, err = flagRecipe()
+		} else {
+			custom, notes, err = readCustomRecipes(args[1])
+		}
+		for _, note := range notes {
+			fmt.Println("Note:", note)
+		}
+		if err != nil {
+			return err
+		}
+		if len(custom) == 0 {
+			return errors.New("no usable recipes, nothing was added")
+		}
+	}
+
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache")
+	}
+
+	switch args[0] {
+	case "add", "import":
+		for _, recipe := range custom {
+			id, err := cache.AddCustomRecipe(ctx, recipe)
+			if err != nil {
+				return fmt.Errorf("error adding recipe %q: %v", recipe.Title, err)
+			}
+			fmt.Printf("Added recipe %d  %s\n", id, recipe.Title)
+		}
+	case "delete":
+		deleted, err := cache.DeleteCustomRecipe(ctx, recipeID)
+		if err != nil {
+			return fmt.Errorf("error deleting recipe: %v", err)
+		}
+		if !deleted {
+			fmt.Printf("Recipe %d is not one of your recipes\n", recipeID)
+			return nil
+		}
+		fmt.Printf("Deleted recipe %d\n", recipeID)
+	default:
+		custom, err := cache.CustomRecipes(ctx)
+		if err != nil {
+			return fmt.Errorf("error listing recipes: %v", err)
+		}
+		if len(custom) == 0 {
+			fmt.Println("No recipes of your own yet, add some with recipefinder recipes add or import")
+			return nil
+		}
+		for _, recipe := range custom {
+			fmt.Printf("%d  %s (%d ingredients)\n", recipe.ID, recipe.Title, len(recipe.Ingredients))
+		}
+	}
+	return nil
+}
+
+// flagRecipe returns the recipe described by the flags of recipes add.
+func flagRecipe() ([]offline.Recipe, []string, error) {
+	if *recipeTitle == "" || *ingredients == "" {
+		return nil, nil, errors.New(recipesUsage)
+	}
+	input := customRecipeInput{
+		Title:        *recipeTitle,
+		Servings:     *servings,
+		Ingredients:  splitNonEmpty(*ingredients, ","),
+		Diets:        splitNonEmpty(*diet, ","),
+		Instructions: splitNonEmpty(*recipeSteps, ";"),
+	}
+	encoded, err := json.Marshal(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	return offline.ReadRecipes(encoded)
+}
+
+// readCustomRecipes reads a file of recipes: JSON as offline.ReadRecipes
+// takes it, or the same written as YAML when the file name ends in .yaml or
+// .yml.
+func readCustomRecipes(file string) ([]offline.Recipe, []string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading recipes: %v", err)
+	}
+	return parseCustomRecipes(file, data)
+}
+
+func parseCustomRecipes(file string, data []byte) ([]offline.Recipe, []string, error) {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		var parsed any
+		err := yaml.Unmarshal(data, &parsed)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing %s: %v", file, err)
+		}
+		// YAML is read through JSON, so both take the same fields
+		data, err = json.Marshal(parsed)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing %s: %v", file, err)
+		}
+	}
+	return offline.ReadRecipes(data)
+}
+
+// splitNonEmpty splits a list and drops the blank entries.
+func splitNonEmpty(list string, separator string) []string {
+	var entries []string
+	for _, entry := range strings.Split(list, separator) {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// withLocalRecipes adds the user's recipes matching the query to the
+// providers' recipes, so the ranking that follows orders them all together.
+// Local recipes are matched as the offline provider matches its dataset and
+// are never cached with the query, so edits to them show at once.
+func withLocalRecipes(ctx context.Context, cache store.Store, query recipes.Query,
+	found []recipes.Recipe) []recipes.Recipe {
+	if cache == nil {
+		return found
+	}
+	custom, err := cache.CustomRecipes(ctx)
+	if err != nil {
+		slog.Warn("error reading your recipes", "error", err)
+		return found
+	}
+	if len(custom) == 0 {
+		return found
+	}
+	query.Offset = 0
+	local, err := offline.NewProvider(offline.BuildIndex(custom)).Search(ctx, query)
+	if err != nil {
+		slog.Warn("error searching your recipes", "error", err)
+		return found
+	}
+	for i := range local {
+		local[i].Source = store.LocalSource
+	}
+	return append(local, found...)
+}
+
+// localMark returns mark for the user's own recipes and nothing for the
+// providers'.
+func localMark(recipe recipes.Recipe, mark string) string {
+	if recipe.Source == store.LocalSource {
+		return mark
+	}
+	return ""
+}
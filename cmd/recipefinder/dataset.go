@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/offline"
+)
+
+const datasetUsage = "usage: recipefinder dataset build --from=<recipes.jsonl>"
+
+var datasetFrom = flag.String("from", "", "JSON Lines file with one recipe per line, with dataset build")
+
+// runDataset implements "recipefinder dataset build".
+func runDataset(args []string, cfg *config.Config) error {
+	if len(args) != 1 || args[0] != "build" || *datasetFrom == "" {
+		return errors.New(datasetUsage)
+	}
+
+	file, err := os.Open(*datasetFrom)
+	if err != nil {
+		return fmt.Errorf("error opening dataset: %v", err)
+	}
+	defer func() {
+		err := file.Close()
+		if err != nil {
+			slog.Warn("error closing dataset", "error", err)
+		}
+	}()
+
+	dataset, notes, err := offline.ReadDataset(file)
+	for _, note := range notes {
+		fmt.Println("Note:", note)
+	}
+	if err != nil {
+		return err
+	}
+	if len(dataset) == 0 {
+		return errors.New("the dataset has no usable recipes, the index was not changed")
+	}
+
+	index := offline.BuildIndex(dataset)
+	path := filepath.Join(cfg.DataDir, offline.IndexFile)
+	err = offline.SaveIndex(path, index)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Indexed %d recipes and %d ingredient words into %s\n", len(index.Recipes), len(index.Words), path)
+	fmt.Println("Search them with --provider=offline")
+	return nil
+}
+
+// offlineIndex returns the index built with "recipefinder dataset build", or
+// the bundled one when none was built.
+func offlineIndex(dataDir string) (*offline.Index, error) {
+	index, err := offline.LoadIndex(filepath.Join(dataDir, offline.IndexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return offline.Bundled()
+	}
+	return index, err
+}
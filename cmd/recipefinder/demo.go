@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/offline"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+var demo = flag.Bool("demo", false,
+	"Try recipefinder on sample recipes, pantry, favorites and history, without an API key; nothing is saved")
+
+// demoSetupTimeout bounds seeding the demo cache.
+const demoSetupTimeout = 30 * time.Second
+
+// demoSearches are the searches the demo history and cache start with.
+var demoSearches = [][]string{
+	{"tomato", "garlic", "basil"},
+	{"chicken", "rice"},
+	{"egg", "spinach"},
+}
+
+// demoPantry is the pantry the demo starts with.
+var demoPantry = []string{"salt", "pepper", "olive oil", "butter"}
+
+// setUpDemo implements --demo: it points the configuration at a throwaway
+// data directory, searched with the bundled offline recipes, whose cache is
+// seeded with sample searches, pantry and favorites. The user's data is never
+// read nor changed. It returns a function removing the directory, which must
+// be called when the run ends.
+func setUpDemo(cfg *config.Config) (func(), error) {
+	dir, err := os.MkdirTemp("", "recipefinder-demo-")
+	if err != nil {
+		return nil, fmt.Errorf("error creating demo directory: %v", err)
+	}
+	cleanup := func() {
+		_ = os.RemoveAll(dir)
+	}
+	cfg.DataDir = dir
+	cfg.DB = config.DB{}
+	cfg.APIKey = ""
+	cfg.APIKeys = nil
+	cfg.APIKeys = nil
+	cfg.Providers = []string{"offline"}
+	// Nothing is kept for telemetry to ask about
+	err = saveTelemetryState(dir, telemetryState{})
+	if err == nil {
+		err = seedDemo(cfg)
+	}
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+	fmt.Fprintln(os.Stderr, "Demo mode: sample data only, every change is discarded when recipefinder exits")
+	return cleanup, nil
+}
+
+// seedDemo fills the demo cache with the recipes of demoSearches, in the
+// history too, demoPantry and a favorite.
+func seedDemo(cfg *config.Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), demoSetupTimeout)
+	defer cancel()
+	index, err := offline.Bundled()
+	if err != nil {
+		return err
+	}
+	provider := offline.NewProvider(index)
+	cache, err := store.OpenSQLite(ctx, filepath.Join(cfg.DataDir, "cache.db"), store.Options{Profile: cfg.Profile})
+	if err != nil {
+		return fmt.Errorf("error creating demo cache: %v", err)
+	}
+	defer func() {
+		_ = cache.Close()
+	}()
+
+	for _, ingredientList := range demoSearches {
+		query := recipes.Query{Ingredients: ingredientList, NumberOfRecipes: 5}
+		found, err := provider.Search(ctx, query)
+		if err != nil {
+			return err
+		}
+		err = cache.Save(ctx, query, found)
+		if err != nil {
+			return err
+		}
+		err = cache.AddHistory(ctx, query, len(found))
+		if err != nil {
+			return err
+		}
+		if len(found) > 0 {
+			err = cache.AddFavorite(ctx, found[0].ID, found[0].Title)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return cache.AddToPantry(ctx, demoPantry)
+}
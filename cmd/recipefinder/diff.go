@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+// runDiffLast implements "recipefinder diff-last": it runs the search and
+// compares its results with those of the previous run of the same query,
+// whether by a search or by diff-last, which they then replace.
+func runDiffLast(ctx context.Context, cfg *config.Config, provider recipes.RecipeProvider,
+	reporter *errorReporter) error {
+	if *ingredients == "" {
+		return errors.New("usage: recipefinder diff-last --ingredients=<ingredient1>,... [flags], " +
+			"see recipefinder help diff-last")
+	}
+	if *output != "text" && *output != "json" {
+		return fmt.Errorf("the diff-last command supports text and json output, not %q", *output)
+	}
+
+	query, err := parseArguments(*numberOfRecipes, cfg.Allergens)
+	if err != nil {
+		return err
+	}
+	screen, err := parseScreening()
+	if err != nil {
+		return err
+	}
+
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache, which keeps the previous results")
+	}
+	query = withPantry(ctx, cache, query)
+	provider = withQuota(ctx, provider, cache, cfg)
+
+	allRecipes, err := newFinder(provider, cache, reporter, query.Ingredients, cfg.Sources).Find(ctx, query)
+	printQuota(provider)
+	if err != nil {
+		slog.Error("search failed", "error", err)
+		reporter.captureError(err, newErrorContext(provider, query.Ingredients))
+		return errors.New(searchError(err, cfg.Timeout))
+	}
+	tieBreakOrder(ctx, cache, allRecipes)
+	allRecipes = screen.apply(allRecipes, cfg, reportedRecipes(ctx, cache, cfg.Reports), query.NumberOfRecipes)
+
+	previous, err := cache.LastSnapshot(ctx, query)
+	if err != nil {
+		return fmt.Errorf("error reading the previous results: %v", err)
+	}
+	err = cache.SaveSnapshot(ctx, query, allRecipes)
+	if err != nil {
+		return fmt.Errorf("error saving the results: %v", err)
+	}
+	if previous == nil {
+		if *output == "json" {
+			return writeDiffJSON(os.Stdout, nil, recipes.ResultDiff{Added: allRecipes})
+		}
+		fmt.Printf("This search was not run before; its %d recipes are kept to compare the next run with\n",
+			len(allRecipes))
+		return nil
+	}
+
+	diff := recipes.DiffResults(previous.Recipes, allRecipes)
+	if *output == "json" {
+		return writeDiffJSON(os.Stdout, previous, diff)
+	}
+	printDiff(os.Stdout, previous, diff, timeZone(cfg))
+	return nil
+}
+
+// writeDiffJSON writes the diff with when the results compared with were
+// found, null when there were none.
+func writeDiffJSON(w io.Writer, previous *store.Snapshot, diff recipes.ResultDiff) error {
+	var since *time.Time
+	if previous != nil {
+		since = &previous.TakenAt
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Since *time.Time `json:"since"`
+		recipes.ResultDiff
+	}{since, diff})
+}
+
+// printDiff writes a line per added (+), removed (-) and changed (~) recipe.
+func printDiff(w io.Writer, previous *store.Snapshot, diff recipes.ResultDiff, location *time.Location) {
+	since := previous.TakenAt.In(location).Format(time.DateTime)
+	if diff.Empty() {
+		fmt.Fprintf(w, "No changes since %s\n", since)
+		return
+	}
+	fmt.Fprintf(w, "Changes since %s:\n", since)
+	for _, recipe := range diff.Added {
+		fmt.Fprintf(w, "+ %d  %s\n", recipe.ID, recipe.Title)
+	}
+	for _, recipe := range diff.Removed {
+		fmt.Fprintf(w, "- %d  %s\n", recipe.ID, recipe.Title)
+	}
+	for _, change := range diff.Changed {
+		fmt.Fprintf(w, "~ %d  %s\n", change.Recipe.ID, change.Recipe.Title)
+		for _, description := range change.Changes {
+			fmt.Fprintf(w, "    %s\n", description)
+		}
+	}
+}
+
+// recordSnapshot keeps the results shown for diff-last; failures are only
+// logged.
+func recordSnapshot(ctx context.Context, cache store.Store, query recipes.Query, allRecipes []recipes.Recipe) {
+	if cache == nil {
+		return
+	}
+	err := cache.SaveSnapshot(ctx, query, allRecipes)
+	if err != nil {
+		slog.Warn("error saving results snapshot", "error", err)
+	}
+}
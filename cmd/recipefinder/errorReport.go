@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+var sentryDSN = flag.String("sentryDSN", "", "Sentry-compatible DSN errors and panics are reported to")
+
+// errorContext is attached to every report. It must never carry the
+// ingredient list itself, only the hash of the query.
+type errorContext struct {
+	QueryHash string
+	Provider  string
+	QuotaLeft string
+}
+
+type errorReporter struct {
+	storeURL string
+	auth     string
+	client   *http.Client
+}
+
+type sentryEvent struct {
+	EventID   string            `json:"event_id"`
+	Timestamp string            `json:"timestamp"`
+	Level     string            `json:"level"`
+	Platform  string            `json:"platform"`
+	Logger    string            `json:"logger"`
+	Message   string            `json:"message"`
+	Tags      map[string]string `json:"tags"`
+	Extra     map[string]string `json:"extra,omitempty"`
+}
+
+// newErrorReporter parses a DSN of the form https://<key>@<host>/<projectID>.
+// An empty DSN yields a nil reporter, on which every method is a no-op.
+func newErrorReporter(dsn string) (*errorReporter, error) {
+	if dsn == "" {
+		return nil, nil
+	}
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sentry DSN: %v", err)
+	}
+	projectID := strings.Trim(parsed.Path, "/")
+	if parsed.User == nil || parsed.User.Username() == "" || projectID == "" {
+		return nil, errors.New("invalid sentry DSN: expected https://<key>@<host>/<projectID>")
+	}
+
+	return &errorReporter{
+		storeURL: fmt.Sprintf("%s://%s/api/%s/store/", parsed.Scheme, parsed.Host, projectID),
+		auth: fmt.Sprintf("Sentry sentry_version=7, sentry_client=recipefinder/1.0, sentry_key=%s",
+			parsed.User.Username()),
+		client: &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+func newErrorContext(provider recipes.RecipeProvider, ingredientList []string) errorContext {
+	return errorContext{
+		QueryHash: hashQuery(ingredientList),
+		Provider:  provider.Name(),
+		QuotaLeft: quotaLeft(provider),
+	}
+}
+
+// hashQuery identifies a query in reports without revealing its ingredients.
+func hashQuery(ingredientList []string) string {
+	sorted := append([]string(nil), ingredientList...)
+	sort.Strings(sorted)
+	sum := sha256.Sum256([]byte(strings.Join(sorted, ",")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// captureError reports an internal failure. Errors caused by the user's own
+// input should not be passed here.
+func (r *errorReporter) captureError(err error, context errorContext) {
+	if r == nil || err == nil {
+		return
+	}
+	r.send("error", err.Error(), context, nil)
+}
+
+// recoverPanic reports a panic and then re-raises it. It must be deferred
+// directly by the function it protects.
+func (r *errorReporter) recoverPanic(context errorContext) {
+	if r == nil {
+		return
+	}
+	if recovered := recover(); recovered != nil {
+		r.capturePanic(recovered, context)
+		panic(recovered)
+	}
+}
+
+func (r *errorReporter) capturePanic(recovered any, context errorContext) {
+	if r == nil {
+		return
+	}
+	r.send("fatal", fmt.Sprint(recovered), context, map[string]string{"stacktrace": string(debug.Stack())})
+}
+
+func (r *errorReporter) send(level string, message string, context errorContext, extra map[string]string) {
+	eventID := make([]byte, 16)
+	_, _ = rand.Read(eventID)
+
+	quota := context.QuotaLeft
+	if quota == "" {
+		quota = "unknown"
+	}
+	event := sentryEvent{
+		EventID:   hex.EncodeToString(eventID),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Level:     level,
+		Platform:  "go",
+		Logger:    "recipefinder",
+		Message:   message,
+		Tags: map[string]string{
+			"query_hash": context.QueryHash,
+			"provider":   context.Provider,
+			"quota_left": quota,
+		},
+		Extra: extra,
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		slog.Warn("error encoding error report", "error", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, r.storeURL, bytes.NewReader(payload))
+	if err != nil {
+		slog.Warn("error sending error report", "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		slog.Warn("error sending error report", "error", err)
+		return
+	}
+	err = resp.Body.Close()
+	if err != nil {
+		slog.Warn("error closing response body", "error", err)
+	}
+}
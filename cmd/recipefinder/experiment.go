@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+const experimentUsage = "usage: recipefinder experiment pick <recipeID> | experiment report"
+
+// minExperimentChoices is how many picks the report wants before it says
+// which strategy ranks better.
+const minExperimentChoices = 10
+
+// experimenting reports whether the search interleaves two rankings, see
+// config.Experiment.
+func experimenting(cfg *config.Config) bool {
+	return cfg.Experiment.Enabled && *sortOrder == "score"
+}
+
+// interleaveRankings ranks the recipes by both the configured ranking and the
+// experiment's and interleaves the two, returning which strategy placed each
+// recipe by recipe ID.
+func interleaveRankings(allRecipes []recipes.Recipe, cfg *config.Config) ([]recipes.Recipe, map[int]string) {
+	a := slices.Clone(allRecipes)
+	recipes.SortRecipes(a, "score", rankWeights(cfg.Ranking))
+	b := slices.Clone(allRecipes)
+	recipes.SortRecipes(b, "score", rankWeights(cfg.Experiment.Ranking))
+	return recipes.Interleave(a, b, rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())))
+}
+
+// recordExperiment records which strategy placed the recipes shown; failures
+// are only logged. Without an experiment strategies is nil.
+func recordExperiment(ctx context.Context, cache store.Store, shown []recipes.Recipe, strategies map[int]string) {
+	if cache == nil || strategies == nil {
+		return
+	}
+	placed := make(map[int]string, len(shown))
+	for _, recipe := range shown {
+		placed[recipe.ID] = strategies[recipe.ID]
+	}
+	err := cache.RecordExperimentShown(ctx, placed)
+	if err != nil {
+		slog.Warn("error recording the ranking experiment", "error", err)
+	}
+}
+
+// runExperiment implements "recipefinder experiment <subcommand>".
+func runExperiment(ctx context.Context, args []string, cfg *config.Config) error {
+	var recipeID int
+	switch {
+	case len(args) == 1 && args[0] == "report":
+	case len(args) == 2 && args[0] == "pick":
+		id, err := strconv.Atoi(args[1])
+		if err != nil || id <= 0 {
+			return fmt.Errorf("invalid recipe ID %q", args[1])
+		}
+		recipeID = id
+	default:
+		return errors.New(experimentUsage)
+	}
+
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache")
+	}
+
+	if args[0] == "pick" {
+		strategy, err := cache.RecordExperimentChoice(ctx, recipeID)
+		if err != nil {
+			return fmt.Errorf("error recording pick: %v", err)
+		}
+		if strategy == "" {
+			fmt.Printf("Recipe %d was not shown by the ranking experiment, nothing recorded\n", recipeID)
+			return nil
+		}
+		fmt.Printf("Recorded picking recipe %d, ranked by strategy %s\n", recipeID, strategy)
+		return nil
+	}
+
+	results, err := cache.ExperimentResults(ctx)
+	if err != nil {
+		return fmt.Errorf("error reading experiment results: %v", err)
+	}
+	if len(results) == 0 {
+		fmt.Println("The ranking experiment has not shown any recipes yet")
+		return nil
+	}
+	return printExperimentReport(os.Stdout, results)
+}
+
+// printExperimentReport writes how often each strategy's recipes were picked,
+// by week and in total, and which strategy is ahead once there are enough
+// picks to tell.
+func printExperimentReport(w io.Writer, results []store.ExperimentResult) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "Week of\tStrategy\tShown\tPicked\tPick rate")
+	totals := map[string]*store.ExperimentResult{
+		recipes.StrategyA: {Strategy: recipes.StrategyA},
+		recipes.StrategyB: {Strategy: recipes.StrategyB},
+	}
+	for _, result := range results {
+		fmt.Fprintf(table, "%s\t%s\t%d\t%d\t%.1f%%\n", result.Week.Format(time.DateOnly), result.Strategy,
+			result.Shown, result.Chosen, result.ChoiceRate()*100)
+		if total, ok := totals[result.Strategy]; ok {
+			total.Shown += result.Shown
+			total.Chosen += result.Chosen
+		}
+	}
+	a, b := totals[recipes.StrategyA], totals[recipes.StrategyB]
+	for _, total := range []*store.ExperimentResult{a, b} {
+		fmt.Fprintf(table, "Total\t%s\t%d\t%d\t%.1f%%\n", total.Strategy, total.Shown, total.Chosen,
+			total.ChoiceRate()*100)
+	}
+	err := table.Flush()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, experimentVerdict(*a, *b))
+	return err
+}
+
+// experimentVerdict says which strategy's recipes were picked more often.
+// Interleaving shows both strategies' best recipes side by side, so the
+// picks are compared directly.
+func experimentVerdict(a store.ExperimentResult, b store.ExperimentResult) string {
+	switch {
+	case a.Chosen+b.Chosen < minExperimentChoices:
+		return fmt.Sprintf("Too few picks to tell yet, %d of %d recorded", a.Chosen+b.Chosen, minExperimentChoices)
+	case a.Chosen == b.Chosen:
+		return "Both strategies were picked as often"
+	case a.Chosen > b.Chosen:
+		return fmt.Sprintf("Strategy a, the configured ranking, was picked %d times to %d", a.Chosen, b.Chosen)
+	default:
+		return fmt.Sprintf("Strategy b, the experiment's ranking, was picked %d times to %d", b.Chosen, a.Chosen)
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+var (
+	export    = flag.String("export", "", "Export the shopping list or the meal plan as ics, todo or csv")
+	exportOut = flag.String("out", "", "File to write --export or --output=html to, stdout when empty")
+)
+
+// exporter writes the shopping list or a meal plan in a format other apps
+// import, unlike formatter, which is meant for reading.
+type exporter interface {
+	ExportShoppingList(w io.Writer, items []recipes.ShoppingItem) error
+	// ExportPlan writes a plan whose first day is start.
+	ExportPlan(w io.Writer, plan recipes.Plan, start time.Time) error
+}
+
+var exporters = map[string]exporter{
+	"ics":  icsExporter{},
+	"todo": todoExporter{},
+	"csv":  csvExporter{},
+}
+
+// checkExport checks --export, which a search only applies to its shopping
+// list.
+func checkExport(isPlan bool) error {
+	if *export == "" {
+		if *exportOut != "" && *output != "html" {
+			return errors.New("--out needs --export or --output=html")
+		}
+		return nil
+	}
+	if _, ok := exporters[*export]; !ok {
+		return fmt.Errorf("unknown export format %q, expected ics, todo or csv", *export)
+	}
+	if !isPlan && !*shoppingList {
+		return errors.New("--export applies to plans and to the --shopping-list of a search")
+	}
+	return nil
+}
+
+// writeExport runs write on the --out file, or on stdout when there is none.
+func writeExport(what string, write func(w io.Writer) error) error {
+	if *exportOut == "" {
+		return write(os.Stdout)
+	}
+	file, err := os.Create(*exportOut)
+	if err != nil {
+		return fmt.Errorf("error creating export file: %v", err)
+	}
+	err = write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error exporting %s: %v", what, err)
+	}
+	fmt.Printf("Exported the %s to %s\n", what, *exportOut)
+	return nil
+}
+
+// exportShoppingList writes the shopping list as --export says.
+func exportShoppingList(items []recipes.ShoppingItem) error {
+	return writeExport("shopping list", func(w io.Writer) error {
+		return exporters[*export].ExportShoppingList(w, items)
+	})
+}
+
+// exportTime is the time exports are written at: SOURCE_DATE_EPOCH, in
+// seconds since 1970, when it is set, as for reproducible builds, so that
+// exporting the same list or plan twice gives identical files, and the
+// current time otherwise.
+func exportTime() time.Time {
+	epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64)
+	if err != nil {
+		return time.Now()
+	}
+	return time.Unix(epoch, 0)
+}
+
+// exportPlan writes the plan as --export says, starting the day after
+// exportTime in the location.
+func exportPlan(plan recipes.Plan, location *time.Location) error {
+	start := firstPlanDay(exportTime(), location)
+	return writeExport("meal plan", func(w io.Writer) error {
+		return exporters[*export].ExportPlan(w, plan, start)
+	})
+}
+
+// firstPlanDay returns the midnight in the location that starts the day after
+// now there, the first day of an exported plan.
+func firstPlanDay(now time.Time, location *time.Location) time.Time {
+	now = now.In(location)
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, location)
+}
+
+// icsExporter writes iCalendar files: the shopping list as to-dos and the
+// plan as an hour-long event per meal, in floating local time.
+type icsExporter struct {
+	// now is when the calendar is written, exportTime when nil.
+	now func() time.Time
+}
+
+// stamp is the DTSTAMP of the calendar, which also keeps the UIDs of
+// separate exports apart.
+func (e icsExporter) stamp() string {
+	now := exportTime
+	if e.now != nil {
+		now = e.now
+	}
+	return now().UTC().Format("20060102T150405Z")
+}
+
+func (e icsExporter) ExportShoppingList(w io.Writer, items []recipes.ShoppingItem) error {
+	ics := newICSWriter(w)
+	stamp := e.stamp()
+	for i, item := range items {
+		ics.line("BEGIN:VTODO")
+		ics.line(fmt.Sprintf("UID:shopping-%s-%d@recipefinder", stamp, i))
+		ics.line("DTSTAMP:" + stamp)
+		summary := item.Name
+		if amount := shoppingAmount(item); amount != "" {
+			summary += " (" + amount + ")"
+		}
+		ics.line("SUMMARY:" + icsText(summary))
+		if len(item.Recipes) > 0 {
+			ics.line("DESCRIPTION:" + icsText("For "+strings.Join(item.Recipes, ", ")))
+		}
+		if item.Aisle != "" {
+			ics.line("CATEGORIES:" + icsText(item.Aisle))
+		}
+		ics.line("STATUS:NEEDS-ACTION")
+		ics.line("END:VTODO")
+	}
+	return ics.close()
+}
+
+func (e icsExporter) ExportPlan(w io.Writer, plan recipes.Plan, start time.Time) error {
+	ics := newICSWriter(w)
+	stamp := e.stamp()
+	for dayIndex, day := range plan.Days {
+		for mealIndex, meal := range day.Meals {
+			minute := plan.MealMinute(mealIndex, len(day.Meals))
+			at := start.AddDate(0, 0, dayIndex).Add(time.Duration(minute) * time.Minute)
+			ics.line("BEGIN:VEVENT")
+			ics.line(fmt.Sprintf("UID:plan-%s-%d-%d@recipefinder", stamp, dayIndex, mealIndex))
+			ics.line("DTSTAMP:" + stamp)
+			ics.line("DTSTART:" + at.Format("20060102T150405"))
+			ics.line("DTEND:" + at.Add(time.Hour).Format("20060102T150405"))
+			ics.line("SUMMARY:" + icsText(meal.Title))
+			description := fmt.Sprintf("%.0f kcal, %.1f g protein", meal.Nutrients["Calories"].Amount,
+				meal.Nutrients["Protein"].Amount)
+			if missing := recipes.IngredientNames(meal.MissedIngredients); len(missing) > 0 {
+				description += "\nMissing: " + strings.Join(missing, ", ")
+			}
+			ics.line("DESCRIPTION:" + icsText(description))
+			ics.line("END:VEVENT")
+		}
+	}
+	return ics.close()
+}
+
+// icsWriter writes the lines of a calendar, CRLF-terminated and folded at 75
+// bytes as RFC 5545 asks. The first error is kept and returned by close.
+type icsWriter struct {
+	w   io.Writer
+	err error
+}
+
+func newICSWriter(w io.Writer) *icsWriter {
+	ics := &icsWriter{w: w}
+	ics.line("BEGIN:VCALENDAR")
+	ics.line("VERSION:2.0")
+	ics.line("PRODID:-//recipefinder//EN")
+	return ics
+}
+
+func (ics *icsWriter) line(content string) {
+	for len(content) > 75 && ics.err == nil {
+		// Folding must not split a UTF-8 sequence
+		cut := 75
+		for cut > 0 && content[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		_, ics.err = io.WriteString(ics.w, content[:cut]+"\r\n")
+		content = " " + content[cut:]
+	}
+	if ics.err == nil {
+		_, ics.err = io.WriteString(ics.w, content+"\r\n")
+	}
+}
+
+func (ics *icsWriter) close() error {
+	ics.line("END:VCALENDAR")
+	return ics.err
+}
+
+// icsText escapes a TEXT value.
+func icsText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(text)
+}
+
+// todoExporter writes Markdown checklists, which Todoist and most task apps
+// import as one task per line.
+type todoExporter struct{}
+
+func (todoExporter) ExportShoppingList(w io.Writer, items []recipes.ShoppingItem) error {
+	fmt.Fprintln(w, "# Shopping list")
+	for i, item := range items {
+		if newAisle(items, i) {
+			fmt.Fprintf(w, "\n## %s\n\n", aisleHeading(item.Aisle))
+		}
+		if amount := shoppingAmount(item); amount != "" {
+			fmt.Fprintf(w, "- [ ] %s (%s)\n", item.Name, amount)
+		} else {
+			fmt.Fprintf(w, "- [ ] %s\n", item.Name)
+		}
+	}
+	return nil
+}
+
+func (todoExporter) ExportPlan(w io.Writer, plan recipes.Plan, start time.Time) error {
+	fmt.Fprintln(w, "# Meal plan")
+	for dayIndex, day := range plan.Days {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "## %s\n\n", start.AddDate(0, 0, dayIndex).Format("Monday, 2 January"))
+		for mealIndex, meal := range day.Meals {
+			fmt.Fprintf(w, "- [ ] %s %s\n", clockTime(plan.MealMinute(mealIndex, len(day.Meals))), meal.Title)
+		}
+	}
+	return nil
+}
+
+// csvExporter writes a header row and a row per shopping list item or per
+// planned meal.
+type csvExporter struct{}
+
+func (csvExporter) ExportShoppingList(w io.Writer, items []recipes.ShoppingItem) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"name", "amount", "unit", "recipes", "aisle"})
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		amount := ""
+		if item.Amount != 0 {
+			amount = fmt.Sprintf("%.4g", item.Amount)
+		}
+		err := writer.Write([]string{item.Name, amount, item.Unit, strings.Join(item.Recipes, "; "), item.Aisle})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (csvExporter) ExportPlan(w io.Writer, plan recipes.Plan, start time.Time) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"date", "meal", "id", "title", "calories", "protein", "missing_ingredients"})
+	if err != nil {
+		return err
+	}
+	for dayIndex, day := range plan.Days {
+		date := start.AddDate(0, 0, dayIndex).Format(time.DateOnly)
+		for mealIndex, meal := range day.Meals {
+			err := writer.Write([]string{date, fmt.Sprint(mealIndex + 1), fmt.Sprint(meal.ID), meal.Title,
+				fmt.Sprintf("%.0f", meal.Nutrients["Calories"].Amount), fmt.Sprintf("%.1f", meal.Nutrients["Protein"].Amount),
+				strings.Join(recipes.IngredientNames(meal.MissedIngredients), "; ")})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
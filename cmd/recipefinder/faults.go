@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+// faultInject is hidden from the help: it is for checking a deployment copes
+// with failures, not for everyday use.
+var faultInject = flag.String("fault-inject", "",
+	"Comma-separated kind:probability failures to inject, e.g. api_timeout:0.1,db_error:0.05")
+
+// errInjected is wrapped by every injected failure.
+var errInjected = errors.New("injected fault")
+
+// faultKinds are the failures --fault-inject can inject. api_timeout fails a
+// provider search as if it had timed out, api_error as if the API had
+// returned an error, and db_error fails a cache query.
+var faultKinds = []string{"api_error", "api_timeout", "db_error"}
+
+// faultRates is how likely each kind of failure is, between 0 and 1. A nil
+// faultRates injects nothing.
+type faultRates map[string]float64
+
+// injectedFaults holds the parsed --fault-inject.
+var injectedFaults faultRates
+
+func parseFaults(spec string) (faultRates, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	rates := make(faultRates)
+	for _, entry := range strings.Split(spec, ",") {
+		kind, rate, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found {
+			return nil, fmt.Errorf("invalid fault %q, expected kind:probability", entry)
+		}
+		if !slices.Contains(faultKinds, kind) {
+			return nil, fmt.Errorf("unknown fault %q, expected one of %s", kind, strings.Join(faultKinds, ", "))
+		}
+		probability, err := strconv.ParseFloat(rate, 64)
+		if err != nil || probability < 0 || probability > 1 {
+			return nil, fmt.Errorf("invalid probability %q for fault %s, expected a number from 0 to 1", rate, kind)
+		}
+		rates[kind] = probability
+	}
+	return rates, nil
+}
+
+// inject returns an injected failure of the kind, or nil, as often as its
+// rate says.
+func (f faultRates) inject(kind string) error {
+	rate := f[kind]
+	if rate <= 0 || rand.Float64() >= rate {
+		return nil
+	}
+	slog.Info("injecting fault", "kind", kind)
+	if kind == "api_timeout" {
+		return fmt.Errorf("%w: %w", errInjected, context.DeadlineExceeded)
+	}
+	return fmt.Errorf("%w: %s", errInjected, kind)
+}
+
+// faultyProvider fails searches of the provider it wraps as --fault-inject
+// says, so fallbacks to the next provider and error reporting can be tried
+// out.
+type faultyProvider struct {
+	recipes.RecipeProvider
+	faults faultRates
+}
+
+func (p faultyProvider) Search(ctx context.Context, query recipes.Query) ([]recipes.Recipe, error) {
+	if err := p.faults.inject("api_timeout"); err != nil {
+		return nil, err
+	}
+	if err := p.faults.inject("api_error"); err != nil {
+		return nil, err
+	}
+	return p.RecipeProvider.Search(ctx, query)
+}
+
+// faultyStore fails the cache queries a search makes as --fault-inject says,
+// which searches must survive by going to the provider.
+type faultyStore struct {
+	store.Store
+	faults faultRates
+}
+
+func (s faultyStore) Lookup(ctx context.Context, query recipes.Query) ([]recipes.Recipe, error) {
+	if err := s.faults.inject("db_error"); err != nil {
+		return nil, err
+	}
+	return s.Store.Lookup(ctx, query)
+}
+
+func (s faultyStore) Save(ctx context.Context, query recipes.Query, allRecipes []recipes.Recipe) error {
+	if err := s.faults.inject("db_error"); err != nil {
+		return err
+	}
+	return s.Store.Save(ctx, query, allRecipes)
+}
+
+func (s faultyStore) SaveRecipes(ctx context.Context, query recipes.Query,
+	allRecipes []recipes.Recipe) (store.SaveResult, error) {
+	if err := s.faults.inject("db_error"); err != nil {
+		return store.SaveResult{}, err
+	}
+	return s.Store.SaveRecipes(ctx, query, allRecipes)
+}
+
+func (s faultyStore) Pantry(ctx context.Context) ([]string, error) {
+	if err := s.faults.inject("db_error"); err != nil {
+		return nil, err
+	}
+	return s.Store.Pantry(ctx)
+}
+
+func (s faultyStore) Favorites(ctx context.Context) ([]store.Favorite, error) {
+	if err := s.faults.inject("db_error"); err != nil {
+		return nil, err
+	}
+	return s.Store.Favorites(ctx)
+}
+
+func (s faultyStore) AddHistory(ctx context.Context, query recipes.Query, results int) error {
+	if err := s.faults.inject("db_error"); err != nil {
+		return err
+	}
+	return s.Store.AddHistory(ctx, query, results)
+}
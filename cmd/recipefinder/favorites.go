@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+const favoriteUsage = "usage: recipefinder favorites add <id> | favorites remove <id> | favorites list"
+
+// historyLimit is how many past searches "recipefinder history" shows.
+const historyLimit = 20
+
+// runFavorite implements "recipefinder favorites <subcommand>".
+func runFavorite(ctx context.Context, args []string, cfg *config.Config) error {
+	var recipeID int
+	switch {
+	case len(args) == 1 && args[0] == "list":
+	case len(args) == 2 && (args[0] == "add" || args[0] == "remove"):
+		id, err := strconv.Atoi(args[1])
+		if err != nil || id <= 0 {
+			return fmt.Errorf("invalid recipe ID %q", args[1])
+		}
+		recipeID = id
+	default:
+		return errors.New(favoriteUsage)
+	}
+
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache")
+	}
+
+	switch args[0] {
+	case "add":
+		err := cache.AddFavorite(ctx, recipeID, "")
+		if err != nil {
+			return fmt.Errorf("error adding favorite: %v", err)
+		}
+		fmt.Printf("Added recipe %d to favorites\n", recipeID)
+	case "remove":
+		removed, err := cache.RemoveFavorite(ctx, recipeID)
+		if err != nil {
+			return fmt.Errorf("error removing favorite: %v", err)
+		}
+		if !removed {
+			fmt.Printf("Recipe %d is not a favorite\n", recipeID)
+			return nil
+		}
+		fmt.Printf("Removed recipe %d from favorites\n", recipeID)
+	default:
+		favorites, err := cache.Favorites(ctx)
+		if err != nil {
+			return fmt.Errorf("error listing favorites: %v", err)
+		}
+		if len(favorites) == 0 {
+			fmt.Println("No favorites yet")
+			return nil
+		}
+		for _, favorite := range favorites {
+			title := favorite.Title
+			if title == "" {
+				title = "(title unknown)"
+			}
+			fmt.Printf("★ %d  %s\n", favorite.RecipeID, title)
+		}
+	}
+	return nil
+}
+
+// runHistory implements "recipefinder history".
+func runHistory(ctx context.Context, args []string, cfg *config.Config) error {
+	if len(args) != 0 {
+		return errors.New("usage: recipefinder history")
+	}
+
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache")
+	}
+
+	history, err := cache.History(ctx, historyLimit)
+	if err != nil {
+		return fmt.Errorf("error reading history: %v", err)
+	}
+	if len(history) == 0 {
+		fmt.Println("No searches yet")
+		return nil
+	}
+	location := timeZone(cfg)
+	for _, entry := range history {
+		fmt.Printf("%s  %d recipes  %s\n", entry.SearchedAt.In(location).Format(time.DateTime), entry.Results, entry.Query)
+	}
+	return nil
+}
+
+// markFavorites sets Favorite on the recipes the user has starred. Without a
+// cache, or when it fails, the recipes are returned unmarked.
+func markFavorites(ctx context.Context, cache store.Store, allRecipes []recipes.Recipe) []recipes.Recipe {
+	if cache == nil {
+		return allRecipes
+	}
+	favorites, err := cache.Favorites(ctx)
+	if err != nil {
+		slog.Warn("error reading favorites", "error", err)
+		return allRecipes
+	}
+	starred := make(map[int]bool, len(favorites))
+	for _, favorite := range favorites {
+		starred[favorite.RecipeID] = true
+	}
+	for i := range allRecipes {
+		allRecipes[i].Favorite = starred[allRecipes[i].ID]
+	}
+	return allRecipes
+}
+
+// recordHistory saves the search in the history; failures are only logged.
+func recordHistory(ctx context.Context, cache store.Store, query recipes.Query, results int) {
+	if cache == nil {
+		return
+	}
+	err := cache.AddHistory(ctx, query, results)
+	if err != nil {
+		slog.Warn("error saving history", "error", err)
+	}
+}
+
+// favoriteMark returns mark for starred recipes and nothing for the others.
+func favoriteMark(recipe recipes.Recipe, mark string) string {
+	if recipe.Favorite {
+		return mark
+	}
+	return ""
+}
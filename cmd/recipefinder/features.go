@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Names of experimental subsystems. They ship disabled and are switched on
+// per deployment through RECIPEFINDER_FEATURES or --features.
+const (
+	featureNewProviders = "new_providers"
+)
+
+var knownFeatures = map[string]string{
+	featureNewProviders: "recipe providers other than Spoonacular",
+}
+
+const featuresEnvVar = "RECIPEFINDER_FEATURES"
+
+var featuresFlag = flag.String("features", "",
+	"Comma-separated experimental features to enable, prefix with - to disable (overrides "+featuresEnvVar+")")
+
+var enabledFeatures = map[string]bool{}
+
+// loadFeatures applies the config file list, then the environment variable and
+// then the flag, so "--features=-new_providers" can switch off something
+// turned on earlier.
+func loadFeatures(configured []string) error {
+	err := applyFeatureList(strings.Join(configured, ","))
+	if err != nil {
+		return fmt.Errorf("config file: %v", err)
+	}
+	err = applyFeatureList(os.Getenv(featuresEnvVar))
+	if err != nil {
+		return fmt.Errorf("%s: %v", featuresEnvVar, err)
+	}
+	err = applyFeatureList(*featuresFlag)
+	if err != nil {
+		return fmt.Errorf("--features: %v", err)
+	}
+	return nil
+}
+
+func applyFeatureList(list string) error {
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		enabled := !strings.HasPrefix(entry, "-")
+		name := strings.TrimPrefix(entry, "-")
+		if _, ok := knownFeatures[name]; !ok {
+			return fmt.Errorf("unknown feature %q (known: %s)", name, strings.Join(featureNames(), ", "))
+		}
+		enabledFeatures[name] = enabled
+	}
+	return nil
+}
+
+func featureNames() []string {
+	names := make([]string, 0, len(knownFeatures))
+	for name := range knownFeatures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func featureEnabled(name string) bool {
+	return enabledFeatures[name]
+}
+
+// requireFeature is called at the entry point of an experimental command.
+func requireFeature(name string) error {
+	if featureEnabled(name) {
+		return nil
+	}
+	return fmt.Errorf("%s is experimental; enable it with --features=%s or %s=%s",
+		knownFeatures[name], name, featuresEnvVar, name)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// goalIndicators mark how a daily amount compares with its goal.
+var goalIndicators = map[string]string{"over": "▲", "under": "▼", "on target": "✓"}
+
+// dailyGoals returns the daily goals of the --profile, none without one. The
+// profile was checked by applyProfile.
+func dailyGoals(cfg *config.Config) recipes.DailyGoals {
+	profile, err := cfg.ActiveProfile()
+	if err != nil {
+		return recipes.DailyGoals{}
+	}
+	return recipes.DailyGoals(profile.DailyGoals)
+}
+
+// eatingWindow returns when the --profile eats, nil for the usual day. The
+// profile was checked by applyProfile.
+func eatingWindow(cfg *config.Config) *recipes.EatingWindow {
+	profile, err := cfg.ActiveProfile()
+	if err != nil {
+		return nil
+	}
+	start, end, err := profile.EatingMinutes()
+	if err != nil || start == end {
+		return nil
+	}
+	return &recipes.EatingWindow{Start: start, End: end}
+}
+
+// mealCalories returns the most calories a meal of a plan may have: those of
+// the day with the most to eat shared out between its meals, so fewer meals
+// are larger. It is 0, no limit, without a calorie goal.
+func mealCalories(goal float64, dayCalories []float64, mealsPerDay int) float64 {
+	most := goal
+	for _, calories := range dayCalories {
+		most = max(most, calories)
+	}
+	return most / float64(mealsPerDay)
+}
+
+// planGoals compares the average day of a plan with the daily goals.
+func planGoals(plan recipes.Plan, goals recipes.DailyGoals) []recipes.NutrientTally {
+	return recipes.SummarizeNutrition(planRecipes(plan), len(plan.Days), goals).Goals()
+}
+
+// formatTally describes the daily amount of a nutrient and how it compares
+// with its goal, e.g. "1850 kcal, 93% of the goal of 2000, ✓ on target". It
+// is unknown when the meals lacking the nutrient leave nothing to count. The
+// indicator is left out of accessible output.
+func formatTally(tally recipes.NutrientTally, indicator bool) string {
+	if tally.Missing > 0 && tally.Total == 0 {
+		return "unknown, no meal has it"
+	}
+	precision := 1
+	if tally.Unit == "kcal" {
+		precision = 0
+	}
+	text := fmt.Sprintf("%.*f %s", precision, tally.Daily, tally.Unit)
+	if tally.Missing > 0 {
+		text += fmt.Sprintf(" not counting %d meals without it", tally.Missing)
+	}
+	if tally.Goal > 0 {
+		text += fmt.Sprintf(", %.0f%% of the goal of %g, ", tally.Percent(), tally.Goal)
+		if indicator {
+			text += goalIndicators[tally.Status] + " "
+		}
+		text += tally.Status
+	}
+	return text
+}
+
+// printNutritionSummary writes what a serving of each of some recipes adds
+// up to, eaten in a day, next to the daily goals.
+func printNutritionSummary(w io.Writer, summary recipes.NutritionSummary) {
+	fmt.Fprintf(w, "Nutrition of a serving of each of the %d recipes:\n", summary.Meals)
+	for _, tally := range summary.Nutrients {
+		fmt.Fprintf(w, "%s: %s\n", tally.Nutrient, formatTally(tally, true))
+	}
+}
+
+// printGoals writes how the average day of a plan compares with the daily
+// goals.
+func printGoals(w io.Writer, goals []recipes.NutrientTally, indicator bool, end string) {
+	for _, tally := range goals {
+		fmt.Fprintf(w, "Daily %s: %s%s\n", strings.ToLower(tally.Nutrient), formatTally(tally, indicator), end)
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipefinderpb"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/spoonacular"
+)
+
+// grpcServer serves the gRPC API of proto/recipefinder/v1 with the searches
+// of the REST API.
+type grpcServer struct {
+	recipefinderpb.UnimplementedRecipeFinderServer
+	srv *recipeServer
+}
+
+// newGRPCServer returns a gRPC server for srv, logging each call and turning
+// panics into Internal errors like the HTTP server does.
+func newGRPCServer(srv *recipeServer) *grpc.Server {
+	server := grpc.NewServer(grpc.UnaryInterceptor(srv.interceptCall))
+	recipefinderpb.RegisterRecipeFinderServer(server, grpcServer{srv: srv})
+	return server
+}
+
+func (s *recipeServer) interceptCall(ctx context.Context, request any, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (response any, err error) {
+	start := time.Now()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			slog.Error("panic serving call", "method", info.FullMethod, "panic", recovered)
+			s.reporter.capturePanic(recovered, errorContext{Provider: s.provider.Name(), QuotaLeft: quotaLeft(s.provider)})
+			response, err = nil, status.Error(codes.Internal, "internal server error")
+		}
+		slog.Info("call", "method", info.FullMethod, "code", status.Code(err), "duration", time.Since(start))
+	}()
+	return handler(ctx, request)
+}
+
+func (g grpcServer) SearchRecipes(ctx context.Context,
+	request *recipefinderpb.SearchRecipesRequest) (*recipefinderpb.SearchRecipesResponse, error) {
+	order := request.GetSort()
+	if order == "" {
+		order = "score"
+	}
+	err := recipes.CheckSortOrder(order)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	query, options, err := g.srv.protoQuery(request.GetQuery(), int(request.GetNumberOfRecipes()))
+	if err != nil {
+		return nil, err
+	}
+	options.order = order
+	allRecipes, err := g.srv.search(ctx, query, options)
+	if err != nil {
+		return nil, searchStatus(err)
+	}
+	response := &recipefinderpb.SearchRecipesResponse{Recipes: make([]*recipefinderpb.Recipe, len(allRecipes))}
+	for i, recipe := range allRecipes {
+		response.Recipes[i] = protoRecipe(recipe, request.GetInstructions())
+	}
+	return response, nil
+}
+
+func (g grpcServer) GetRecipe(ctx context.Context, request *recipefinderpb.GetRecipeRequest) (*recipefinderpb.Recipe, error) {
+	if source := request.GetSource(); source != "" && source != "spoonacular" {
+		return nil, status.Errorf(codes.InvalidArgument, "only spoonacular recipes can be looked up, not %s ones", source)
+	}
+	if request.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "id must be a positive recipe ID")
+	}
+	recipe, err := lookupRecipe(ctx, g.srv.cache, g.srv.spoonacular, int(request.GetId()))
+	var apiErr *spoonacular.APIError
+	switch {
+	case recipe == nil && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+		return nil, status.Errorf(codes.NotFound, "there is no recipe %d", request.GetId())
+	case recipe == nil && errors.Is(err, config.ErrNoAPIKey):
+		return nil, status.Errorf(codes.Unavailable, "recipe %d is not cached and no API key is configured", request.GetId())
+	case recipe == nil:
+		slog.Error("error fetching recipe", "recipe", request.GetId(), "error", err)
+		return nil, status.Errorf(codes.Unavailable, "problem fetching recipe %d from API", request.GetId())
+	case err != nil:
+		slog.Warn("serving the cached recipe, which may be incomplete", "recipe", request.GetId(), "error", err)
+	}
+	return protoRecipe(*recipe, true), nil
+}
+
+func (g grpcServer) BuildMealPlan(ctx context.Context,
+	request *recipefinderpb.BuildMealPlanRequest) (*recipefinderpb.MealPlan, error) {
+	days, mealsPerDay := int(request.GetDays()), int(request.GetMealsPerDay())
+	if days <= 0 || mealsPerDay <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "a plan needs at least one day and one meal per day")
+	}
+	if days*mealsPerDay > maxServerRecipes {
+		return nil, status.Errorf(codes.InvalidArgument, "a plan may have at most %d meals", maxServerRecipes)
+	}
+	query, options, err := g.srv.protoQuery(request.GetQuery(), days*mealsPerDay)
+	if err != nil {
+		return nil, err
+	}
+	// BuildPlan prefers the first recipes, so the ones missing the fewest
+	// ingredients come first, as with the plan command
+	options.order = "missing"
+	allRecipes, err := g.srv.search(ctx, query, options)
+	if err != nil {
+		return nil, searchStatus(err)
+	}
+	plan, err := recipes.BuildPlan(allRecipes, days, mealsPerDay)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return protoPlan(plan), nil
+}
+
+// protoQuery checks and normalizes a query of the gRPC API the way
+// handleRecipes does its parameters. Its errors are InvalidArgument statuses.
+func (s *recipeServer) protoQuery(query *recipefinderpb.Query, numberOfRecipes int) (recipes.Query,
+	searchOptions, error) {
+	if numberOfRecipes <= 0 || numberOfRecipes > maxServerRecipes {
+		return recipes.Query{}, searchOptions{}, status.Errorf(codes.InvalidArgument,
+			"number_of_recipes must be between 1 and %d", maxServerRecipes)
+	}
+	if len(query.GetIngredients()) == 0 {
+		return recipes.Query{}, searchOptions{}, status.Error(codes.InvalidArgument, "query.ingredients are required")
+	}
+	ingredientList, _, err := recipes.CleanIngredientList(query.GetIngredients())
+	if err != nil {
+		return recipes.Query{}, searchOptions{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	diets, err := recipes.NormalizeDiets(strings.Join(query.GetDiets(), ","))
+	if err != nil {
+		return recipes.Query{}, searchOptions{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	intolerances, err := recipes.NormalizeIntolerances(strings.Join(query.GetIntolerances(), ","))
+	if err != nil {
+		return recipes.Query{}, searchOptions{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	for name, value := range map[string]float64{
+		"max_calories":          query.GetMaxCalories(),
+		"min_protein":           query.GetMinProtein(),
+		"max_carbs":             query.GetMaxCarbs(),
+		"max_price_per_serving": query.GetMaxPricePerServing(),
+		"max_ready_time":        float64(query.GetMaxReadyTime()),
+	} {
+		if value < 0 {
+			return recipes.Query{}, searchOptions{}, status.Errorf(codes.InvalidArgument, "%s must not be negative", name)
+		}
+	}
+	searchQuery := recipes.Query{
+		Ingredients:     ingredientList,
+		NumberOfRecipes: numberOfRecipes,
+		Diets:           diets,
+		Intolerances:    intolerances,
+		Exclude:         recipes.NormalizeExclusions(strings.Join(query.GetExcludeIngredients(), ","), s.allergens),
+		Targets: recipes.NutritionTargets{
+			MaxCalories: query.GetMaxCalories(),
+			MinProtein:  query.GetMinProtein(),
+			MaxCarbs:    query.GetMaxCarbs(),
+		},
+		Prep: recipes.PrepLimits{
+			MaxReadyTime: int(query.GetMaxReadyTime()),
+			Equipment:    recipes.NormalizeEquipment(strings.Join(query.GetEquipment(), ",")),
+			NoEquipment:  recipes.NormalizeEquipment(strings.Join(query.GetNoEquipment(), ",")),
+		},
+	}
+	return searchQuery, searchOptions{maxPrice: recipes.DollarsToMoney(query.GetMaxPricePerServing())}, nil
+}
+
+// searchStatus is the status of a failed search, the codes matching the
+// REST API's 504 and 502.
+func searchStatus(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, "search timed out")
+	}
+	return status.Error(codes.Unavailable, "problem fetching recipes from API")
+}
+
+// protoRecipe converts a recipe to its message, with its instructions only
+// when withInstructions is set.
+func protoRecipe(recipe recipes.Recipe, withInstructions bool) *recipefinderpb.Recipe {
+	message := &recipefinderpb.Recipe{
+		Id:                   int64(recipe.ID),
+		Source:               recipe.Source,
+		Title:                recipe.Title,
+		UsedIngredients:      protoIngredients(recipe.UsedIngredients),
+		MissedIngredients:    protoIngredients(recipe.MissedIngredients),
+		Nutrients:            make(map[string]*recipefinderpb.NutrientAmount, len(recipe.Nutrients)),
+		Servings:             int32(recipe.Servings),
+		PricePerServingCents: recipe.PricePerServing.Cents(),
+		ReadyInMinutes:       int32(recipe.ReadyInMinutes),
+		Equipment:            recipe.Equipment,
+		SourceUrl:            recipe.SourceURL,
+		ImageUrl:             recipe.ImageURL,
+		Warnings:             recipe.Warnings,
+		Estimated:            recipe.Estimated,
+	}
+	for name, nutrient := range recipe.Nutrients {
+		message.Nutrients[name] = &recipefinderpb.NutrientAmount{
+			Amount:       nutrient.Amount,
+			Unit:         nutrient.Unit,
+			DailyPercent: nutrient.DailyPercent,
+		}
+	}
+	if withInstructions {
+		message.Instructions = recipe.Instructions
+	}
+	if !recipe.FetchedAt.IsZero() {
+		message.FetchedAt = recipe.FetchedAt.Unix()
+	}
+	return message
+}
+
+func protoIngredients(ingredients []recipes.Ingredient) []*recipefinderpb.Ingredient {
+	messages := make([]*recipefinderpb.Ingredient, len(ingredients))
+	for i, ingredient := range ingredients {
+		messages[i] = &recipefinderpb.Ingredient{
+			Name:        ingredient.Name,
+			Amount:      ingredient.Amount,
+			Unit:        ingredient.Unit,
+			Substitutes: ingredient.Substitutes,
+		}
+	}
+	return messages
+}
+
+func protoPlan(plan recipes.Plan) *recipefinderpb.MealPlan {
+	message := &recipefinderpb.MealPlan{
+		Days:      make([]*recipefinderpb.MealPlanDay, len(plan.Days)),
+		CostCents: plan.Cost.Cents(),
+		Unpriced:  int32(plan.Unpriced),
+	}
+	for i, day := range plan.Days {
+		meals := make([]*recipefinderpb.Recipe, len(day.Meals))
+		for j, meal := range day.Meals {
+			meals[j] = protoRecipe(meal, true)
+		}
+		message.Days[i] = &recipefinderpb.MealPlanDay{
+			Meals:     meals,
+			Calories:  day.Calories,
+			Protein:   day.Protein,
+			CostCents: day.Cost.Cents(),
+		}
+	}
+	return message
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/mawojcik/meals_generator/pkg/recipefinderpb"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// stubProvider serves the first of its recipes, or fails with err.
+type stubProvider struct {
+	recipes []recipes.Recipe
+	err     error
+}
+
+func (p stubProvider) Name() string { return "stub" }
+
+func (p stubProvider) Search(ctx context.Context, query recipes.Query) ([]recipes.Recipe, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if query.Offset >= len(p.recipes) {
+		return nil, nil
+	}
+	found := p.recipes[query.Offset:]
+	return found[:min(len(found), query.NumberOfRecipes)], nil
+}
+
+// newTestGRPCClient serves srv's gRPC API in memory and returns a client of
+// it.
+func newTestGRPCClient(t *testing.T, srv *recipeServer) recipefinderpb.RecipeFinderClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := newGRPCServer(srv)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+	conn, err := grpc.NewClient("passthrough:///recipefinder",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return recipefinderpb.NewRecipeFinderClient(conn)
+}
+
+func TestGRPCSearchRecipes(t *testing.T) {
+	ctx := context.Background()
+	client := newTestGRPCClient(t, &recipeServer{provider: stubProvider{recipes: testRecipes(t)}})
+
+	response, err := client.SearchRecipes(ctx, &recipefinderpb.SearchRecipesRequest{
+		Query:           &recipefinderpb.Query{Ingredients: []string{"garlic"}},
+		NumberOfRecipes: 2,
+		Sort:            "missing",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Recipes) != 2 {
+		t.Fatalf("got %d recipes, want 2", len(response.Recipes))
+	}
+	for _, recipe := range response.Recipes {
+		if recipe.Title == "" || recipe.Id <= 0 || len(recipe.Instructions) > 0 {
+			t.Errorf("got %v, want a titled recipe without instructions", recipe)
+		}
+	}
+
+	response, err = client.SearchRecipes(ctx, &recipefinderpb.SearchRecipesRequest{
+		Query:           &recipefinderpb.Query{Ingredients: []string{"pasta"}},
+		NumberOfRecipes: 1,
+		Instructions:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pasta := response.Recipes[0]
+	if pasta.Id != 716429 || len(pasta.Instructions) != 2 || pasta.Nutrients["Calories"].GetAmount() <= 0 {
+		t.Errorf("got %v, want the pasta with its instructions and nutrients", pasta)
+	}
+}
+
+func TestGRPCInvalidArguments(t *testing.T) {
+	ctx := context.Background()
+	client := newTestGRPCClient(t, &recipeServer{provider: stubProvider{recipes: testRecipes(t)}})
+
+	for name, request := range map[string]*recipefinderpb.SearchRecipesRequest{
+		"no ingredients": {Query: &recipefinderpb.Query{}, NumberOfRecipes: 1},
+		"no recipes":     {Query: &recipefinderpb.Query{Ingredients: []string{"egg"}}},
+		"too many":       {Query: &recipefinderpb.Query{Ingredients: []string{"egg"}}, NumberOfRecipes: maxServerRecipes + 1},
+		"unknown diet": {Query: &recipefinderpb.Query{Ingredients: []string{"egg"}, Diets: []string{"carnivore"}},
+			NumberOfRecipes: 1},
+		"negative target": {Query: &recipefinderpb.Query{Ingredients: []string{"egg"}, MaxCalories: -1},
+			NumberOfRecipes: 1},
+		"unknown sort": {Query: &recipefinderpb.Query{Ingredients: []string{"egg"}}, NumberOfRecipes: 1,
+			Sort: "color"},
+	} {
+		_, err := client.SearchRecipes(ctx, request)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s: got %v, want InvalidArgument", name, err)
+		}
+	}
+	_, err := client.GetRecipe(ctx, &recipefinderpb.GetRecipeRequest{Source: "edamam", Id: 1})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("got %v for an edamam recipe, want InvalidArgument", err)
+	}
+	_, err = client.BuildMealPlan(ctx, &recipefinderpb.BuildMealPlanRequest{
+		Query: &recipefinderpb.Query{Ingredients: []string{"egg"}},
+		Days:  7,
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("got %v for a plan without meals, want InvalidArgument", err)
+	}
+}
+
+func TestGRPCBuildMealPlan(t *testing.T) {
+	ctx := context.Background()
+	client := newTestGRPCClient(t, &recipeServer{provider: stubProvider{recipes: testRecipes(t)}})
+
+	plan, err := client.BuildMealPlan(ctx, &recipefinderpb.BuildMealPlanRequest{
+		Query:       &recipefinderpb.Query{Ingredients: []string{"garlic"}},
+		Days:        1,
+		MealsPerDay: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Days) != 1 || len(plan.Days[0].Meals) != 2 {
+		t.Errorf("got %v, want a day of 2 meals", plan)
+	}
+
+	// The fixture has 3 recipes, not enough for 2 days of 2 meals
+	_, err = client.BuildMealPlan(ctx, &recipefinderpb.BuildMealPlanRequest{
+		Query:       &recipefinderpb.Query{Ingredients: []string{"garlic"}},
+		Days:        2,
+		MealsPerDay: 2,
+	})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("got %v, want FailedPrecondition for too few recipes", err)
+	}
+}
+
+func TestGRPCUnavailable(t *testing.T) {
+	ctx := context.Background()
+	client := newTestGRPCClient(t, &recipeServer{provider: stubProvider{err: errors.New("quota exhausted")}})
+
+	_, err := client.SearchRecipes(ctx, &recipefinderpb.SearchRecipesRequest{
+		Query:           &recipefinderpb.Query{Ingredients: []string{"egg"}},
+		NumberOfRecipes: 1,
+	})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("got %v for a failing provider, want Unavailable", err)
+	}
+	// Without a cache or an API key no recipe can be looked up
+	_, err = client.GetRecipe(ctx, &recipefinderpb.GetRecipeRequest{Id: 716429})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("got %v without a cache or API key, want Unavailable", err)
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+var showImages = flag.Bool("images", false,
+	"Download the recipes' images to the data directory and show them as thumbnails where the terminal can, "+
+		"as links otherwise")
+
+const (
+	// maxImageSize is the largest image downloaded, in bytes.
+	maxImageSize = 5 << 20
+	// thumbnailWidth is the width thumbnails are scaled down to, in pixels.
+	thumbnailWidth = 160
+	// thumbnailColumns is how many terminal columns a kitty thumbnail spans.
+	thumbnailColumns = 20
+	// kittyChunkSize is the most base64 data a kitty graphics command holds.
+	kittyChunkSize = 4096
+)
+
+// graphicsProtocol is how a terminal is sent images, if at all.
+type graphicsProtocol int
+
+const (
+	noGraphics graphicsProtocol = iota
+	kittyGraphics
+	sixelGraphics
+)
+
+// recipeImages shows the images of the recipes in the text outputs: a
+// thumbnail of the downloaded file where the terminal draws images, a link to
+// it otherwise.
+type recipeImages struct {
+	// files are the downloaded images, by recipeKey.
+	files    map[string]string
+	protocol graphicsProtocol
+	// links makes the URLs clickable, for terminals.
+	links bool
+}
+
+// withImages makes the text output show the recipes' images, and the HTML
+// output embed them, downloading them first. The other formats are left as
+// they are, the images only downloaded.
+func withImages(ctx context.Context, cfg *config.Config, f formatter, allRecipes []recipes.Recipe) formatter {
+	files := downloadImages(ctx, http.DefaultClient, filepath.Join(cfg.DataDir, "images"), allRecipes)
+	if html, ok := f.(htmlFormatter); ok {
+		html.images = files
+		return html
+	}
+	text, ok := f.(textFormatter)
+	if !ok {
+		return f
+	}
+	terminal := isTerminal(os.Stdout) && os.Getenv("TERM") != "dumb"
+	images := &recipeImages{files: files, links: terminal}
+	if terminal {
+		images.protocol = terminalGraphics()
+	}
+	text.images = images
+	return text
+}
+
+// terminalGraphics guesses the image protocol of the terminal from its
+// environment, as asking it would need reading its answer from stdin.
+func terminalGraphics() graphicsProtocol {
+	term := os.Getenv("TERM")
+	switch {
+	case term == "xterm-kitty" || os.Getenv("KITTY_WINDOW_ID") != "",
+		os.Getenv("TERM_PROGRAM") == "WezTerm", os.Getenv("TERM_PROGRAM") == "ghostty":
+		return kittyGraphics
+	case strings.Contains(term, "sixel"), term == "foot", strings.HasPrefix(term, "foot-"),
+		strings.HasPrefix(term, "mlterm"):
+		return sixelGraphics
+	}
+	return noGraphics
+}
+
+// downloadImages saves the images of the recipes to the directory, keeping
+// those saved before, and returns the files by recipeKey. Images failing to
+// download are left out.
+func downloadImages(ctx context.Context, client *http.Client, dir string, allRecipes []recipes.Recipe) map[string]string {
+	files := make(map[string]string)
+	for _, recipe := range allRecipes {
+		if recipe.ImageURL == "" {
+			continue
+		}
+		file, err := downloadImage(ctx, client, dir, recipe)
+		if err != nil {
+			slog.Warn("error downloading recipe image", "recipe", recipe.ID, "error", err)
+			continue
+		}
+		files[recipeKey(recipe)] = file
+	}
+	return files
+}
+
+func downloadImage(ctx context.Context, client *http.Client, dir string, recipe recipes.Recipe) (string, error) {
+	imageURL, err := url.Parse(recipe.ImageURL)
+	if err != nil || (imageURL.Scheme != "http" && imageURL.Scheme != "https") {
+		return "", fmt.Errorf("invalid image URL %q", recipe.ImageURL)
+	}
+	extension := strings.ToLower(path.Ext(imageURL.Path))
+	if extension == "" || len(extension) > 5 {
+		extension = ".jpg"
+	}
+	file := filepath.Join(dir, fmt.Sprintf("%s-%d%s", recipe.Source, recipe.ID, extension))
+	if _, err := os.Stat(file); err == nil {
+		return file, nil
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer func(body io.ReadCloser) {
+		err := body.Close()
+		if err != nil {
+			slog.Warn("error closing response body", "error", err)
+		}
+	}(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxImageSize {
+		return "", errors.New("image too large")
+	}
+
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return "", err
+	}
+	// Written aside first, so an interrupted download is not taken for an image
+	err = os.WriteFile(file+".part", data, 0o644)
+	if err != nil {
+		return "", err
+	}
+	return file, os.Rename(file+".part", file)
+}
+
+// write shows a recipe's image: its thumbnail, or a link to it when the
+// terminal draws no images or the image cannot be decoded.
+func (r *recipeImages) write(w io.Writer, recipe recipes.Recipe) {
+	if recipe.ImageURL == "" {
+		return
+	}
+	if file, ok := r.files[recipeKey(recipe)]; ok && r.protocol != noGraphics {
+		thumbnail, err := loadThumbnail(file)
+		if err == nil {
+			if r.protocol == kittyGraphics {
+				err = writeKitty(w, thumbnail)
+			} else {
+				err = writeSixel(w, thumbnail)
+			}
+			if err == nil {
+				fmt.Fprintln(w)
+				return
+			}
+		}
+		slog.Warn("error showing recipe image", "file", file, "error", err)
+	}
+	link := recipe.ImageURL
+	if file, ok := r.files[recipeKey(recipe)]; ok {
+		link = (&url.URL{Scheme: "file", Path: filepath.ToSlash(file)}).String()
+	}
+	fmt.Fprintln(w, "Image:", r.hyperlink(link, recipe.ImageURL))
+}
+
+// hyperlink makes the text a link to the target in terminals that support
+// OSC 8 links, which the others ignore.
+func (r *recipeImages) hyperlink(target, text string) string {
+	if !r.links {
+		return text
+	}
+	return "\x1b]8;;" + target + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// loadThumbnail decodes an image and scales it down to thumbnailWidth.
+func loadThumbnail(file string) (image.Image, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func(f *os.File) {
+		err := f.Close()
+		if err != nil {
+			slog.Warn("error closing image", "error", err)
+		}
+	}(f)
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding image: %v", err)
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() <= thumbnailWidth {
+		return img, nil
+	}
+	width, height := thumbnailWidth, max(1, bounds.Dy()*thumbnailWidth/bounds.Dx())
+	// Nearest neighbour is enough at thumbnail size
+	thumbnail := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			thumbnail.Set(x, y, img.At(bounds.Min.X+x*bounds.Dx()/width, bounds.Min.Y+y*bounds.Dy()/height))
+		}
+	}
+	return thumbnail, nil
+}
+
+// writeKitty draws an image with the kitty graphics protocol, as a PNG sent in
+// chunks, thumbnailColumns wide.
+func writeKitty(w io.Writer, img image.Image) error {
+	var encoded bytes.Buffer
+	err := png.Encode(&encoded, img)
+	if err != nil {
+		return err
+	}
+	data := base64.StdEncoding.EncodeToString(encoded.Bytes())
+	for first := true; first || data != ""; first = false {
+		chunk := data[:min(len(data), kittyChunkSize)]
+		data = data[len(chunk):]
+		more := 0
+		if data != "" {
+			more = 1
+		}
+		if first {
+			// q=2 keeps the terminal from answering on stdin
+			fmt.Fprintf(w, "\x1b_Ga=T,f=100,q=2,c=%d,m=%d;%s\x1b\\", thumbnailColumns, more, chunk)
+		} else {
+			fmt.Fprintf(w, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	return nil
+}
+
+// writeSixel draws an image as sixels, with its colors reduced to a 6×6×6
+// color cube.
+func writeSixel(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	colors := make([]uint8, width*height)
+	for y := range height {
+		for x := range width {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			colors[y*width+x] = uint8((r*5+0x7fff)/0xffff*36 + (g*5+0x7fff)/0xffff*6 + (b*5+0x7fff)/0xffff)
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "\x1bPq\"1;1;%d;%d", width, height)
+	for color := range 216 {
+		fmt.Fprintf(&out, "#%d;2;%d;%d;%d", color, color/36*20, color/6%6*20, color%6*20)
+	}
+	// Each band is six rows of pixels, drawn once per color it uses
+	for top := 0; top < height; top += 6 {
+		var used [216]bool
+		for y := top; y < min(top+6, height); y++ {
+			for x := range width {
+				used[colors[y*width+x]] = true
+			}
+		}
+		for color := range 216 {
+			if !used[color] {
+				continue
+			}
+			fmt.Fprintf(&out, "#%d", color)
+			row := make([]byte, width)
+			for x := range width {
+				var bits byte
+				for i := range min(6, height-top) {
+					if colors[(top+i)*width+x] == uint8(color) {
+						bits |= 1 << i
+					}
+				}
+				row[x] = '?' + bits
+			}
+			writeSixelRow(&out, row)
+			out.WriteByte('$')
+		}
+		out.WriteByte('-')
+	}
+	out.WriteString("\x1b\\")
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// writeSixelRow writes a row of sixels, repeats run-length encoded.
+func writeSixelRow(out *bytes.Buffer, row []byte) {
+	for i := 0; i < len(row); {
+		run := 1
+		for i+run < len(row) && row[i+run] == row[i] {
+			run++
+		}
+		if run > 3 {
+			fmt.Fprintf(out, "!%d%c", run, row[i])
+		} else {
+			out.Write(row[i : i+run])
+		}
+		i += run
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+func TestImages(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 320, 240))
+	for x := range 320 {
+		img.Set(x, x%240, color.RGBA{R: 255, A: 255})
+	}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		err := png.Encode(w, img)
+		if err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	recipe := recipes.Recipe{ID: 1, Source: "spoonacular", ImageURL: server.URL + "/recipes/1-312x231.png"}
+	dir := t.TempDir()
+	var files map[string]string
+	for range 2 {
+		files = downloadImages(context.Background(), server.Client(), dir, []recipes.Recipe{recipe})
+	}
+	if len(files) != 1 || !strings.HasSuffix(files[recipeKey(recipe)], "spoonacular-1.png") {
+		t.Fatalf("got files %q, want the recipe's image", files)
+	}
+	if requests != 1 {
+		t.Errorf("downloaded the image %d times, want it kept once downloaded", requests)
+	}
+
+	for protocol, prefix := range map[graphicsProtocol]string{
+		noGraphics:    "Image: \x1b]8;;file://",
+		kittyGraphics: "\x1b_Ga=T,f=100,q=2,c=20,m=0;",
+		sixelGraphics: "\x1bPq\"1;1;160;120#0;2;0;0;0",
+	} {
+		var out bytes.Buffer
+		(&recipeImages{files: files, protocol: protocol, links: true}).write(&out, recipe)
+		if !strings.HasPrefix(out.String(), prefix) {
+			t.Errorf("protocol %d wrote %.60q, want it to start with %q", protocol, out.String(), prefix)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/fdc"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+const ingredientUsage = "usage: recipefinder ingredient show <name>"
+
+// runIngredient implements "recipefinder ingredient show": the card of an
+// ingredient, from the taxonomy and from FoodData Central, with a photo from
+// Spoonacular when an API key is configured. What is fetched is cached like
+// recipe details; when it cannot be fetched the cached card is shown however
+// old, and without one only what the taxonomy knows.
+func runIngredient(ctx context.Context, args []string, cfg *config.Config) error {
+	if len(args) < 2 || args[0] != "show" {
+		return errors.New(ingredientUsage)
+	}
+	info := recipes.DescribeIngredient(strings.Join(args[1:], " "))
+	if info.Name == "" {
+		return errors.New(ingredientUsage)
+	}
+
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	fetched, err := fetchIngredient(ctx, cache, cfg, info.Name)
+	if err != nil && fetched != nil {
+		fmt.Printf("Could not look up %s (%v), showing the cached card, which may be out of date\n", info.Name, err)
+	} else if err != nil {
+		fmt.Printf("Could not look up %s (%v), showing what the taxonomy knows\n", info.Name, err)
+	}
+	if fetched != nil {
+		info.ImageURL, info.Food, info.FetchedAt = fetched.ImageURL, fetched.Food, fetched.FetchedAt
+	}
+	writeIngredient(os.Stdout, info)
+	return nil
+}
+
+// fetchIngredient returns the fetched parts of an ingredient's card, from the
+// cache while they are fresh. When fetching fails the cached ones are
+// returned with the error, however old.
+func fetchIngredient(ctx context.Context, cache store.Store, cfg *config.Config,
+	name string) (*recipes.IngredientInfo, error) {
+	var cached *recipes.IngredientInfo
+	if cache != nil {
+		info, fresh, err := cache.CachedIngredient(ctx, name)
+		if err != nil {
+			slog.Warn("error reading cached ingredient", "error", err)
+		}
+		if fresh {
+			return info, nil
+		}
+		cached = info
+	}
+
+	food, err := fdc.NewClient(cfg.FDC.APIKey).Food(ctx, name)
+	if err != nil && !errors.Is(err, fdc.ErrNotFound) {
+		return cached, err
+	}
+	info := &recipes.IngredientInfo{Name: name, Food: food, FetchedAt: time.Now()}
+	if len(cfg.SpoonacularKeys()) > 0 {
+		// The photo is a nicety, not worth failing the card for
+		info.ImageURL, err = newSpoonacular(cfg).IngredientImage(ctx, name)
+		if err != nil {
+			slog.Warn("error fetching ingredient image", "error", err)
+		}
+	}
+	if cache != nil {
+		err := cache.SaveIngredient(ctx, *info)
+		if err != nil {
+			slog.Warn("error caching ingredient", "error", err)
+		}
+	}
+	return info, nil
+}
+
+// writeIngredient writes an ingredient's card, leaving out what is not known.
+func writeIngredient(w io.Writer, info recipes.IngredientInfo) {
+	fmt.Fprintln(w, info.Name)
+	if info.ImageURL != "" {
+		fmt.Fprintln(w, "Photo:", info.ImageURL)
+	}
+	if info.Category != "" {
+		fmt.Fprintln(w, "Category:", info.Category)
+	}
+	if len(info.Season) > 0 {
+		months := make([]string, 0, len(info.Season))
+		for _, month := range info.Season {
+			months = append(months, month.String())
+		}
+		now := ""
+		if info.InSeason(time.Now().Month()) {
+			now = " (in season now)"
+		}
+		fmt.Fprintf(w, "Season: %s%s\n", strings.Join(months, ", "), now)
+	}
+	if info.PricePerKg > 0 {
+		fmt.Fprintf(w, "Average price: ~%s per kg (estimate)\n", formatPrice(recipes.DollarsToMoney(info.PricePerKg)))
+	}
+	if len(info.Substitutes) > 0 {
+		fmt.Fprintln(w, "Substitutes:", strings.Join(info.Substitutes, ", "))
+	}
+	if info.Food == nil {
+		if info.FetchedAt.IsZero() {
+			fmt.Fprintln(w, "Nutrition: unknown")
+		} else {
+			fmt.Fprintln(w, "Nutrition: not found in FoodData Central")
+		}
+		return
+	}
+	if len(info.Food.UnitWeights) > 0 {
+		fmt.Fprintln(w, "Unit weights:")
+		for _, weight := range info.Food.UnitWeights {
+			fmt.Fprintf(w, "- %s: %.4g g\n", weight.Unit, weight.Grams)
+		}
+	}
+	heading := fmt.Sprintf("Nutrition per 100 g (%s", info.Food.Description)
+	if age := time.Since(info.FetchedAt); age >= staleAfter {
+		heading += fmt.Sprintf(", fetched %d days ago", int(age.Hours()/24))
+	}
+	fmt.Fprintln(w, heading+"):")
+	names := make([]string, 0, len(info.Food.Nutrients))
+	for name := range info.Food.Nutrients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		nutrient := info.Food.Nutrients[name]
+		fmt.Fprintf(w, "%s: %.2f %s\n", name, nutrient.Amount, nutrient.Unit)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+var interactive = flag.Bool("interactive", false, "Browse the found recipes in the terminal instead of printing them")
+
+const browserHelp = "↑/↓ move · enter details · f favorite · s shopping list · q quit"
+
+// browser is the --interactive view: a list of recipes of which any can be
+// expanded to show its ingredients, nutrients and instructions.
+type browser struct {
+	allRecipes []recipes.Recipe
+	cursor     int
+	expanded   map[int]bool
+	// favorites and shopping are keyed by recipe ID; shopping holds the
+	// recipes whose missing ingredients go on the shopping list.
+	favorites map[int]bool
+	shopping  map[int]bool
+	height    int
+}
+
+// checkInteractive rejects --interactive where it cannot work, before any
+// search is made.
+func checkInteractive() error {
+	if !*interactive {
+		return nil
+	}
+	if *output != "text" || *shoppingList {
+		return errors.New("--interactive cannot be combined with --output or --shopping-list")
+	}
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return errors.New("--interactive needs a terminal")
+	}
+	return nil
+}
+
+// browseRecipes runs the browser until the user quits, then saves the
+// favorites changed in it and prints its shopping list. Without a cache the
+// favorites cannot be saved and are printed instead.
+func browseRecipes(ctx context.Context, cache store.Store, allRecipes []recipes.Recipe, outputFormat formatter) error {
+	start := &browser{
+		allRecipes: allRecipes,
+		expanded:   map[int]bool{},
+		favorites:  map[int]bool{},
+		shopping:   map[int]bool{},
+	}
+	for _, recipe := range allRecipes {
+		start.favorites[recipe.ID] = recipe.Favorite
+	}
+	final, err := tea.NewProgram(start, tea.WithAltScreen()).Run()
+	if err != nil {
+		return fmt.Errorf("error running interactive mode: %v", err)
+	}
+	result := final.(*browser)
+
+	var favorites, shopping []recipes.Recipe
+	for _, recipe := range result.allRecipes {
+		if result.favorites[recipe.ID] {
+			favorites = append(favorites, recipe)
+		}
+		if result.shopping[recipe.ID] {
+			shopping = append(shopping, recipe)
+		}
+	}
+	if cache != nil {
+		err = saveFavorites(ctx, cache, result.allRecipes, result.favorites)
+		if err != nil {
+			return err
+		}
+	} else if len(favorites) > 0 {
+		fmt.Println("Favorites (not saved, the recipe cache is unavailable):")
+		for _, recipe := range favorites {
+			fmt.Printf("- %s (%d)\n", recipe.Title, recipe.ID)
+		}
+	}
+	if len(shopping) > 0 {
+		return outputFormat.FormatShoppingList(os.Stdout, convertedShoppingList(shopping))
+	}
+	return nil
+}
+
+// saveFavorites stars and unstars the recipes whose favorite mark was changed.
+func saveFavorites(ctx context.Context, cache store.Store, allRecipes []recipes.Recipe, favorites map[int]bool) error {
+	for _, recipe := range allRecipes {
+		switch {
+		case favorites[recipe.ID] && !recipe.Favorite:
+			err := cache.AddFavorite(ctx, recipe.ID, recipe.Title)
+			if err != nil {
+				return fmt.Errorf("error adding favorite: %v", err)
+			}
+		case !favorites[recipe.ID] && recipe.Favorite:
+			_, err := cache.RemoveFavorite(ctx, recipe.ID)
+			if err != nil {
+				return fmt.Errorf("error removing favorite: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (b *browser) Init() tea.Cmd {
+	return nil
+}
+
+func (b *browser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.height = msg.Height
+	case tea.KeyMsg:
+		if len(b.allRecipes) == 0 {
+			return b, tea.Quit
+		}
+		id := b.allRecipes[b.cursor].ID
+		switch msg.String() {
+		case "up", "k":
+			b.cursor = max(b.cursor-1, 0)
+		case "down", "j":
+			b.cursor = min(b.cursor+1, len(b.allRecipes)-1)
+		case "enter", " ":
+			b.expanded[b.cursor] = !b.expanded[b.cursor]
+		case "f":
+			b.favorites[id] = !b.favorites[id]
+		case "s":
+			b.shopping[id] = !b.shopping[id]
+		case "q", "esc", "ctrl+c":
+			return b, tea.Quit
+		}
+	}
+	return b, nil
+}
+
+func (b *browser) View() string {
+	var lines []string
+	var cursorStart, cursorEnd int
+	for i, recipe := range b.allRecipes {
+		if i == b.cursor {
+			cursorStart = len(lines)
+		}
+		lines = append(lines, b.recipeLine(i, recipe))
+		if b.expanded[i] {
+			lines = append(lines, recipeDetails(recipe)...)
+		}
+		if i == b.cursor {
+			cursorEnd = len(lines) - 1
+		}
+	}
+
+	// Scroll just far enough to show the selected recipe, details included
+	// when they fit
+	visible := b.height - 2
+	if visible > 0 && len(lines) > visible {
+		top := max(cursorEnd-visible+1, 0)
+		top = min(top, cursorStart)
+		lines = lines[top:min(top+visible, len(lines))]
+	}
+	return browserHelp + "\n\n" + strings.Join(lines, "\n") + "\n"
+}
+
+func (b *browser) recipeLine(i int, recipe recipes.Recipe) string {
+	cursor := "  "
+	if i == b.cursor {
+		cursor = "> "
+	}
+	favorite := "  "
+	if b.favorites[recipe.ID] {
+		favorite = "★ "
+	}
+	shopping := "    "
+	if b.shopping[recipe.ID] {
+		shopping = "[+] "
+	}
+	return fmt.Sprintf("%s%s%s%s (missing %d)", cursor, favorite, shopping, recipe.Title, len(recipe.MissedIngredients))
+}
+
+func recipeDetails(recipe recipes.Recipe) []string {
+	const indent = "      "
+	details := []string{
+		indent + "Used: " + strings.Join(recipes.IngredientNames(recipe.UsedIngredients), ", "),
+		indent + "Missing: " + strings.Join(missedNames(recipe), ", "),
+	}
+	if notes := nutritionNotes(recipe); len(notes) > 0 {
+		details = append(details, indent+"Nutrition: "+strings.Join(notes, ", "))
+	}
+	for _, name := range recipe.NutrientNames() {
+		nutrient := recipe.Nutrients[name]
+		details = append(details, fmt.Sprintf("%s%s: %.2f %s%s", indent, name, nutrient.Amount, nutrient.Unit,
+			dailyValue(nutrient)))
+	}
+	for _, warning := range recipe.Warnings {
+		details = append(details, indent+"Warning: "+warning)
+	}
+	for i, step := range recipe.Instructions {
+		details = append(details, fmt.Sprintf("%s%d. %s", indent, i+1, step))
+	}
+	return details
+}
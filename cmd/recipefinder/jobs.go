@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+var jobPriority = flag.Int("priority", 0, "Priority of a queued job, higher runs first")
+
+const jobsUsage = "usage: recipefinder jobs list [status] | jobs add <kind> [--priority=<n>] | jobs cancel <id> | jobs work"
+
+const (
+	// jobsLimit is how many jobs "recipefinder jobs list" shows.
+	jobsLimit = 50
+	// jobPollInterval is how long a worker waits before looking for jobs again
+	// once the queue is empty.
+	jobPollInterval = 5 * time.Second
+)
+
+// jobHandler runs the jobs of one kind.
+type jobHandler struct {
+	// provider is the recipe provider the jobs call, empty for none, for
+	// config.Jobs.ProviderConcurrency.
+	provider string
+	// run runs a job; returning an error has it retried.
+	run func(ctx context.Context, job store.Job) error
+}
+
+// jobHandlers returns the handler of every job kind this configuration can
+// run, keyed by kind.
+func jobHandlers(cache store.Store, cfg *config.Config) map[string]jobHandler {
+	handlers := map[string]jobHandler{
+		"purge": {
+			run: func(ctx context.Context, job store.Job) error {
+				purged, err := cache.Purge(ctx)
+				if err != nil {
+					return err
+				}
+				slog.Info("purged expired recipes", "job", job.ID, "purged", purged)
+				return nil
+			},
+		},
+	}
+	if len(cfg.SpoonacularKeys()) > 0 {
+		handlers["refresh"] = jobHandler{
+			provider: "spoonacular",
+			run: func(ctx context.Context, job store.Job) error {
+				client := newSpoonacular(cfg)
+				rotateKeys(ctx, client, cache, cfg)
+				setBudget(client, cache, cfg)
+				reserver := newQuotaReserver(client, cache, cfg)
+				if reserver == nil {
+					return refreshCache(ctx, cache, client)
+				}
+				return reserver.do(ctx, func() error {
+					return refreshCache(ctx, cache, client)
+				})
+			},
+		}
+	}
+	return handlers
+}
+
+// runJobs implements "recipefinder jobs <subcommand>".
+func runJobs(ctx context.Context, args []string, cfg *config.Config) error {
+	if len(args) == 0 {
+		return errors.New(jobsUsage)
+	}
+	var status string
+	var id int64
+	switch args[0] {
+	case "list":
+		if len(args) > 2 {
+			return errors.New(jobsUsage)
+		}
+		if len(args) == 2 {
+			status = args[1]
+		}
+	case "add":
+		if len(args) != 2 {
+			return errors.New(jobsUsage)
+		}
+	case "cancel":
+		if len(args) != 2 {
+			return errors.New(jobsUsage)
+		}
+		parsed, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || parsed <= 0 {
+			return fmt.Errorf("invalid job ID %q", args[1])
+		}
+		id = parsed
+	case "work":
+		if len(args) != 1 {
+			return errors.New(jobsUsage)
+		}
+		err := cfg.Jobs.Validate()
+		if err != nil {
+			return err
+		}
+		// A worker runs until it is stopped, not for the command timeout
+		workCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		ctx = workCtx
+	default:
+		return errors.New(jobsUsage)
+	}
+
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache")
+	}
+	handlers := jobHandlers(cache, cfg)
+
+	switch args[0] {
+	case "add":
+		if _, ok := handlers[args[1]]; !ok {
+			return fmt.Errorf("unknown job kind %q, expected one of %v", args[1], jobKinds(handlers))
+		}
+		id, err := cache.EnqueueJob(ctx, store.Job{Kind: args[1], Priority: *jobPriority})
+		if errors.Is(err, store.ErrQueueFull) {
+			return fmt.Errorf("the job queue is full (jobs maxQueued is %d), try again once workers catch up",
+				cfg.Jobs.MaxQueued)
+		}
+		if err != nil {
+			return fmt.Errorf("error queueing job: %v", err)
+		}
+		fmt.Printf("Queued %s job %d\n", args[1], id)
+	case "cancel":
+		found, err := cache.CancelJob(ctx, id)
+		if errors.Is(err, store.ErrJobFinished) {
+			fmt.Printf("Job %d has already finished\n", id)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error cancelling job: %v", err)
+		}
+		if !found {
+			fmt.Printf("There is no job %d\n", id)
+			return nil
+		}
+		fmt.Printf("Cancelled job %d\n", id)
+	case "work":
+		slog.Info("working on jobs", "kinds", jobKinds(handlers), "workers", cfg.Jobs.Workers)
+		newJobWorkers(cache, handlers, cfg.Jobs).run(ctx)
+	default:
+		jobs, err := cache.Jobs(ctx, status, jobsLimit)
+		if err != nil {
+			return fmt.Errorf("error listing jobs: %v", err)
+		}
+		if len(jobs) == 0 {
+			fmt.Println("No jobs")
+			return nil
+		}
+		for _, job := range jobs {
+			fmt.Printf("%d  %-9s  %-8s  priority %d  attempt %d/%d  %s\n", job.ID, job.Status, job.Kind, job.Priority,
+				job.Attempts, job.MaxAttempts, job.UpdatedAt.In(timeZone(cfg)).Format(time.DateTime))
+			if job.LastError != "" {
+				fmt.Printf("    last error: %s\n", job.LastError)
+			}
+		}
+	}
+	return nil
+}
+
+// jobWorkers run the queued jobs they have handlers for, including jobs
+// queued by other processes sharing the database.
+type jobWorkers struct {
+	cache    store.Store
+	handlers map[string]jobHandler
+	workers  int
+	// providerSlots hold a token per running job calling a capped provider,
+	// keyed by provider name.
+	providerSlots map[string]chan struct{}
+}
+
+func newJobWorkers(cache store.Store, handlers map[string]jobHandler, cfg config.Jobs) *jobWorkers {
+	w := &jobWorkers{
+		cache:         cache,
+		handlers:      handlers,
+		workers:       max(cfg.Workers, 1),
+		providerSlots: make(map[string]chan struct{}),
+	}
+	for provider, limit := range cfg.ProviderConcurrency {
+		w.providerSlots[provider] = make(chan struct{}, limit)
+	}
+	return w
+}
+
+// run works on jobs until ctx is cancelled, then waits for the running ones.
+func (w *jobWorkers) run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range w.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *jobWorkers) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := w.cache.ClaimJob(ctx, w.availableKinds())
+		if err != nil && ctx.Err() == nil {
+			slog.Error("error claiming job", "error", err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(jobPollInterval):
+			}
+			continue
+		}
+
+		jobErr := w.runJob(ctx, *job)
+		if jobErr != nil {
+			slog.Warn("job attempt failed", "job", job.ID, "kind", job.Kind, "attempt", job.Attempts, "error", jobErr)
+		}
+		// The outcome is recorded even when the worker is being stopped
+		err = w.cache.FinishJob(context.WithoutCancel(ctx), *job, jobErr)
+		if err != nil {
+			slog.Error("error finishing job", "job", job.ID, "error", err)
+		}
+	}
+}
+
+// runJob runs a job once its provider has a free slot.
+func (w *jobWorkers) runJob(ctx context.Context, job store.Job) error {
+	handler := w.handlers[job.Kind]
+	slots := w.providerSlots[handler.provider]
+	if slots != nil {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		defer func() {
+			<-slots
+		}()
+	}
+	return handler.run(ctx, job)
+}
+
+// availableKinds are the kinds of jobs whose provider has a free slot, so
+// workers do not claim jobs they would only wait on while others are due.
+func (w *jobWorkers) availableKinds() []string {
+	var kinds []string
+	for _, kind := range jobKinds(w.handlers) {
+		slots := w.providerSlots[w.handlers[kind].provider]
+		if slots == nil || len(slots) < cap(slots) {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+func jobKinds(handlers map[string]jobHandler) []string {
+	kinds := make([]string, 0, len(handlers))
+	for kind := range handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/mawojcik/meals_generator/pkg/spoonacular"
+)
+
+// labelRow is one line of the nutrition facts panel. Name is the Spoonacular
+// nutrient name, Title is what the FDA label calls it.
+type labelRow struct {
+	Name   string
+	Title  string
+	Indent bool
+}
+
+var labelMacroRows = []labelRow{
+	{Name: "Fat", Title: "Total Fat"},
+	{Name: "Saturated Fat", Title: "Saturated Fat", Indent: true},
+	{Name: "Trans Fat", Title: "Trans Fat", Indent: true},
+	{Name: "Cholesterol", Title: "Cholesterol"},
+	{Name: "Sodium", Title: "Sodium"},
+	{Name: "Carbohydrates", Title: "Total Carbohydrate"},
+	{Name: "Fiber", Title: "Dietary Fiber", Indent: true},
+	{Name: "Sugar", Title: "Total Sugars", Indent: true},
+	{Name: "Protein", Title: "Protein"},
+}
+
+var labelMicroRows = []labelRow{
+	{Name: "Vitamin D", Title: "Vitamin D"},
+	{Name: "Calcium", Title: "Calcium"},
+	{Name: "Iron", Title: "Iron"},
+	{Name: "Potassium", Title: "Potassium"},
+}
+
+const labelWidth = 40
+
+// The rules of the panel are lines of labelWidth of these.
+const (
+	labelThick = "█"
+	labelThin  = "─"
+)
+
+// The PNG label draws the panel in basicfont.Face7x13 within labelMargin
+// pixels, and is then scaled up labelScale times to print sharply.
+const (
+	labelMargin      = 10
+	labelLineHeight  = 15
+	labelThickHeight = 6
+	labelThinHeight  = 1
+	labelRulePadding = 3
+	labelScale       = 2
+)
+
+func runLabel(ctx context.Context, args []string, client *spoonacular.Client) error {
+	if len(args) != 1 {
+		return errors.New("usage: recipefinder label <recipeID> [--output=png --out=<file>]")
+	}
+	recipeID, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid recipe ID %q", args[0])
+	}
+	switch {
+	case *output != "text" && *output != "png":
+		return fmt.Errorf("the label command supports text and png output, not %q", *output)
+	case *output == "png" && *exportOut == "":
+		return errors.New("--output=png needs --out=<file> to write the label to")
+	case *output == "text" && *exportOut != "":
+		return errors.New("--out applies to --output=png")
+	}
+
+	widget, err := client.NutritionWidget(ctx, recipeID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *output == "png":
+		return writeExport("label", func(w io.Writer) error {
+			return renderLabelPNG(w, widget)
+		})
+	case *accessible:
+		renderAccessibleLabel(os.Stdout, widget)
+	default:
+		renderNutritionLabel(os.Stdout, widget)
+	}
+	return nil
+}
+
+// renderNutritionLabel writes a plain-text panel laid out like the FDA
+// Nutrition Facts label. Nutrients missing from the data are left out.
+func renderNutritionLabel(w io.Writer, widget *spoonacular.NutritionWidget) {
+	for _, line := range labelPanel(widget) {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// renderLabelPNG draws the panel of renderNutritionLabel as a PNG image, its
+// rules as bars.
+func renderLabelPNG(w io.Writer, widget *spoonacular.NutritionWidget) error {
+	lines := labelPanel(widget)
+	face := basicfont.Face7x13
+	height := 2 * labelMargin
+	for _, line := range lines {
+		height += labelLineSize(line)
+	}
+	panel := image.NewGray(image.Rect(0, 0, labelWidth*face.Advance+2*labelMargin, height))
+	draw.Draw(panel, panel.Bounds(), image.White, image.Point{}, draw.Src)
+
+	drawer := font.Drawer{Dst: panel, Src: image.Black, Face: face}
+	y := labelMargin
+	for _, line := range lines {
+		size := labelLineSize(line)
+		switch {
+		case strings.HasPrefix(line, labelThick):
+			bar := image.Rect(labelMargin, y+labelRulePadding, panel.Bounds().Dx()-labelMargin,
+				y+labelRulePadding+labelThickHeight)
+			draw.Draw(panel, bar, image.Black, image.Point{}, draw.Src)
+		case strings.HasPrefix(line, labelThin):
+			bar := image.Rect(labelMargin, y+labelRulePadding, panel.Bounds().Dx()-labelMargin,
+				y+labelRulePadding+labelThinHeight)
+			draw.Draw(panel, bar, image.Black, image.Point{}, draw.Src)
+		default:
+			drawer.Dot = fixed.P(labelMargin, y+face.Ascent)
+			drawer.DrawString(line)
+		}
+		y += size
+	}
+	return png.Encode(w, scaleImage(panel, labelScale))
+}
+
+// labelLineSize is the height in pixels of a line of the PNG panel.
+func labelLineSize(line string) int {
+	switch {
+	case strings.HasPrefix(line, labelThick):
+		return labelThickHeight + 2*labelRulePadding
+	case strings.HasPrefix(line, labelThin):
+		return labelThinHeight + 2*labelRulePadding
+	}
+	return labelLineHeight
+}
+
+// scaleImage enlarges img factor times, each pixel becoming a square of them,
+// which keeps the bitmap font crisp.
+func scaleImage(img *image.Gray, factor int) *image.Gray {
+	bounds := img.Bounds()
+	scaled := image.NewGray(image.Rect(0, 0, bounds.Dx()*factor, bounds.Dy()*factor))
+	for y := 0; y < scaled.Bounds().Dy(); y++ {
+		for x := 0; x < scaled.Bounds().Dx(); x++ {
+			scaled.SetGray(x, y, img.GrayAt(bounds.Min.X+x/factor, bounds.Min.Y+y/factor))
+		}
+	}
+	return scaled
+}
+
+// labelPanel lays out the lines of the panel, its rules being lines of
+// labelThick or labelThin.
+func labelPanel(widget *spoonacular.NutritionWidget) []string {
+	type nutrient struct {
+		amount  float64
+		unit    string
+		percent float64
+	}
+	nutrients := make(map[string]nutrient, len(widget.Nutrients))
+	for _, n := range widget.Nutrients {
+		nutrients[n.Name] = nutrient{amount: n.Amount, unit: n.Unit, percent: n.PercentOfDailyNeeds}
+	}
+
+	thick := strings.Repeat(labelThick, labelWidth)
+	thin := strings.Repeat(labelThin, labelWidth)
+
+	lines := []string{
+		thin,
+		"Nutrition Facts",
+		thin,
+		labelLine("Serving size", fmt.Sprintf("%.0f%s", widget.WeightPerServing.Amount, widget.WeightPerServing.Unit)),
+		thick,
+		"Amount per serving",
+		labelLine("Calories", fmt.Sprintf("%.0f", nutrients["Calories"].amount)),
+		thick,
+		labelLine("", "% Daily Value*"),
+		thin,
+	}
+
+	for _, row := range labelMacroRows {
+		n, ok := nutrients[row.Name]
+		if !ok {
+			continue
+		}
+		title := row.Title
+		if row.Indent {
+			title = "  " + title
+		}
+		lines = append(lines, labelLine(fmt.Sprintf("%s %.1f%s", title, n.amount, n.unit),
+			fmt.Sprintf("%.0f%%", n.percent)))
+	}
+	lines = append(lines, thick)
+
+	for _, row := range labelMicroRows {
+		n, ok := nutrients[row.Name]
+		if !ok {
+			continue
+		}
+		lines = append(lines, labelLine(fmt.Sprintf("%s %.1f%s", row.Title, n.amount, n.unit),
+			fmt.Sprintf("%.0f%%", n.percent)))
+	}
+	return append(lines, thin,
+		"* The % Daily Value tells you how much a",
+		"nutrient in a serving contributes to a",
+		"daily diet. 2,000 calories a day is used",
+		"for general nutrition advice.")
+}
+
+// labelLine puts left and right at the edges of a label-wide line.
+func labelLine(left string, right string) string {
+	padding := labelWidth - len([]rune(left)) - len([]rune(right))
+	if padding < 1 {
+		padding = 1
+	}
+	return left + strings.Repeat(" ", padding) + right
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+var (
+	lang           = flag.String("lang", "en", "Language of the printed labels: en, pl, de or es")
+	translateInput = flag.Bool("translate-ingredients", false,
+		"Translate the --ingredients from the --lang language to English before searching")
+)
+
+// translationTimeout bounds a run of the configured translation command.
+const translationTimeout = 30 * time.Second
+
+// inputTranslator translates the ingredients of --translate-ingredients, set
+// once the config is loaded.
+var inputTranslator recipes.Translator = recipes.Dictionaries
+
+// labels are the translations of the text output's labels, by their English
+// text. A missing translation, or nil labels, leaves the label in English.
+type labels map[string]string
+
+func (l labels) get(english string) string {
+	if translated, ok := l[english]; ok {
+		return translated
+	}
+	return english
+}
+
+// outputLabels are the labels of each --lang other than English.
+var outputLabels = map[string]labels{
+	"pl": {
+		"Recipe": "Przepis", "Used Ingredients": "Użyte składniki", "Missed Ingredients": "Brakujące składniki",
+		"Nutrients": "Wartości odżywcze", "Nutrients per serving": "Wartości odżywcze na porcję",
+		"Price per serving": "Cena za porcję", "Servings": "Porcje", "Ready in": "Gotowe w", "minutes": "minut",
+		"Equipment": "Sprzęt", "Warning": "Uwaga", "Instructions": "Instrukcje", "Shopping List": "Lista zakupów",
+		"already saved": "już zapisany", "Pairing": "Wino do dania", "local": "własny",
+		"Substitutes": "Zamienniki",
+	},
+	"de": {
+		"Recipe": "Rezept", "Used Ingredients": "Verwendete Zutaten", "Missed Ingredients": "Fehlende Zutaten",
+		"Nutrients": "Nährwerte", "Nutrients per serving": "Nährwerte pro Portion",
+		"Price per serving": "Preis pro Portion", "Servings": "Portionen", "Ready in": "Fertig in",
+		"minutes": "Minuten", "Equipment": "Küchengeräte", "Warning": "Warnung", "Instructions": "Zubereitung",
+		"Shopping List": "Einkaufsliste", "already saved": "bereits gespeichert", "Pairing": "Weinempfehlung",
+		"local": "eigenes", "Substitutes": "Ersatz",
+	},
+	"es": {
+		"Recipe": "Receta", "Used Ingredients": "Ingredientes usados", "Missed Ingredients": "Ingredientes que faltan",
+		"Nutrients": "Nutrientes", "Nutrients per serving": "Nutrientes por ración",
+		"Price per serving": "Precio por ración", "Servings": "Raciones", "Ready in": "Listo en",
+		"minutes": "minutos", "Equipment": "Utensilios", "Warning": "Aviso", "Instructions": "Instrucciones",
+		"Shopping List": "Lista de la compra", "already saved": "ya guardada", "Pairing": "Maridaje", "local": "propia",
+		"Substitutes": "Sustitutos",
+	},
+}
+
+// checkLang rejects an unknown --lang, and --translate-ingredients without
+// a language to translate from.
+func checkLang() error {
+	if _, ok := outputLabels[*lang]; !ok && *lang != "en" {
+		return fmt.Errorf("unknown --lang %q, expected en, pl, de or es", *lang)
+	}
+	if *translateInput && *lang == "en" {
+		return errors.New("--translate-ingredients needs --lang, the language the ingredients are in")
+	}
+	return nil
+}
+
+// newTranslator returns the built-in dictionaries, followed by the configured
+// translation command for the names they lack.
+func newTranslator(translation config.Translation) recipes.Translator {
+	if translation.Command == "" {
+		return recipes.Dictionaries
+	}
+	return recipes.Chain{recipes.Dictionaries, commandTranslator{command: translation.Command}}
+}
+
+// translatedIngredients translates the ingredients with --translate-ingredients
+// and leaves them as they are otherwise. When translating fails, the
+// ingredients are searched for as given.
+func translatedIngredients(names []string) []string {
+	if !*translateInput {
+		return names
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), translationTimeout)
+	defer cancel()
+	translated, err := inputTranslator.Translate(ctx, *lang, names)
+	if err != nil {
+		slog.Warn("error translating ingredients, searching for them as given", "error", err)
+		return names
+	}
+	for i, name := range names {
+		if name != translated[i] {
+			slog.Debug("translated ingredient", "from", name, "to", translated[i])
+		}
+	}
+	return translated
+}
+
+// commandTranslator runs a command to translate names: it gets the language
+// in $RECIPEFINDER_LANG and the names on stdin, one per line, and prints the
+// English names, one per line in the same order.
+type commandTranslator struct {
+	command string
+}
+
+func (t commandTranslator) Translate(ctx context.Context, lang string, names []string) ([]string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", t.command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", t.command)
+	}
+	cmd.Env = append(os.Environ(), "RECIPEFINDER_LANG="+lang)
+	cmd.Stdin = strings.NewReader(strings.Join(names, "\n") + "\n")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if stderr.Len() > 0 {
+			return nil, fmt.Errorf("translation command failed: %s", strings.TrimSpace(stderr.String()))
+		}
+		return nil, fmt.Errorf("translation command failed: %v", err)
+	}
+	translated := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	if len(translated) != len(names) {
+		return nil, fmt.Errorf("translation command printed %d names for %d", len(translated), len(names))
+	}
+	for i := range translated {
+		translated[i] = strings.TrimSpace(translated[i])
+	}
+	return translated, nil
+}
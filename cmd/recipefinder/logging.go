@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+)
+
+var (
+	logFile       = flag.String("logFile", "", "Write logs to this file instead of stderr")
+	logMaxSize    = flag.Int64("logMaxSize", 10, "Rotate the log file after it reaches this many megabytes")
+	logMaxBackups = flag.Int("logMaxBackups", 3, "Number of rotated log files to keep")
+	logFormat     = flag.String("logFormat", "", "Log format: text or json (default json for serve, text otherwise)")
+	quiet         = flag.Bool("quiet", false, "Only log warnings and errors")
+)
+
+// logLevels are the log.level values, from the most to the least verbose.
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// setupLogging points the default slog logger, and the standard logger with
+// it, at the configured target, dropping messages below the configured level.
+// The format defaults to JSON for servers, whose logs are usually collected,
+// and to text otherwise. Timestamps are in the location. It returns a
+// function that closes the log file, if one was opened.
+func setupLogging(logConfig config.Log, location *time.Location, serving bool) (func(), error) {
+	level, ok := logLevels[logConfig.Level]
+	if !ok {
+		return nil, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", logConfig.Level)
+	}
+	format := logConfig.Format
+	if format == "" {
+		format = "text"
+		if serving {
+			format = "json"
+		}
+	}
+	if format != "text" && format != "json" {
+		return nil, fmt.Errorf("unknown log format %q, expected text or json", format)
+	}
+
+	var output io.Writer = os.Stderr
+	closeLog := func() {}
+
+	if logConfig.File != "" {
+		writer, err := newRotatingWriter(logConfig.File, logConfig.MaxSizeMB*1024*1024, logConfig.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		output = writer
+		closeLog = func() {
+			err := writer.Close()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error closing log file: %v\n", err)
+			}
+		}
+	}
+
+	options := &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey && attr.Value.Kind() == slog.KindTime {
+				attr.Value = slog.TimeValue(attr.Value.Time().In(location))
+			}
+			return attr
+		},
+	}
+	var handler slog.Handler = slog.NewTextHandler(output, options)
+	if format == "json" {
+		handler = slog.NewJSONHandler(output, options)
+	}
+	slog.SetDefault(slog.New(handler))
+	return closeLog, nil
+}
+
+// rotatingWriter is an io.Writer appending to a file that is renamed to
+// path.1, path.2, ... once it grows past maxSize bytes.
+type rotatingWriter struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func newRotatingWriter(path string, maxSize int64, maxBackups int) (*rotatingWriter, error) {
+	if maxSize <= 0 {
+		return nil, errors.New("log file size limit must be positive")
+	}
+	writer := &rotatingWriter{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	err := writer.open()
+	if err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (w *rotatingWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("error opening log file: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("error opening log file: %v", err)
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+func (w *rotatingWriter) rotate() error {
+	err := w.file.Close()
+	if err != nil {
+		return err
+	}
+
+	if w.maxBackups <= 0 {
+		err = os.Remove(w.path)
+	} else {
+		for i := w.maxBackups - 1; i > 0; i-- {
+			older := fmt.Sprintf("%s.%d", w.path, i)
+			if _, statErr := os.Stat(older); statErr == nil {
+				err = os.Rename(older, fmt.Sprintf("%s.%d", w.path, i+1))
+				if err != nil {
+					return err
+				}
+			}
+		}
+		err = os.Rename(w.path, w.path+".1")
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return w.open()
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		err := w.rotate()
+		if err != nil {
+			return 0, fmt.Errorf("error rotating log file: %v", err)
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/metrics"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// serverMetrics are what the server exposes on /metrics: the calls made to
+// the recipe APIs and how long they took, how often the cache answered a
+// search, and the API quota left.
+type serverMetrics struct {
+	registry     *metrics.Registry
+	apiRequests  *metrics.Counter
+	apiDuration  *metrics.Histogram
+	cacheLookups *metrics.Counter
+}
+
+func newServerMetrics() *serverMetrics {
+	registry := metrics.NewRegistry()
+	m := &serverMetrics{
+		registry: registry,
+		apiRequests: registry.Counter("recipefinder_api_requests_total",
+			"Requests made to the recipe APIs, by host and response status code, error when none arrived.",
+			"upstream", "code"),
+		apiDuration: registry.Histogram("recipefinder_api_request_duration_seconds",
+			"How long the recipe APIs took to respond, by host.", metrics.DefaultBuckets, "upstream"),
+		cacheLookups: registry.Counter("recipefinder_cache_lookups_total",
+			"Searches looked up in the cache, by whether it had enough recipes (hit) or not (miss).", "result"),
+	}
+	registry.GaugeFunc("recipefinder_cache_hit_ratio",
+		"Share of the cache lookups since the server started that were hits.", func() (float64, bool) {
+			hits, misses := m.cacheLookups.Value("hit"), m.cacheLookups.Value("miss")
+			if hits+misses == 0 {
+				return 0, false
+			}
+			return hits / (hits + misses), true
+		})
+	return m
+}
+
+// httpClient returns a client for the recipe APIs that records their calls.
+func (m *serverMetrics) httpClient() *http.Client {
+	return &http.Client{Transport: meteredTransport{next: http.DefaultTransport, metrics: m}}
+}
+
+// watchQuota exposes the API quota the provider chain last reported.
+func (m *serverMetrics) watchQuota(provider recipes.RecipeProvider) {
+	m.registry.GaugeFunc("recipefinder_api_quota_remaining_points",
+		"Spoonacular points left for the day, as its last response reported.", func() (float64, bool) {
+			left, err := strconv.ParseFloat(quotaLeft(provider), 64)
+			return left, err == nil
+		})
+}
+
+// cacheLookup records a Finder's cache lookup, see recipes.Finder.
+func (m *serverMetrics) cacheLookup(hit bool) {
+	if hit {
+		m.cacheLookups.Inc("hit")
+	} else {
+		m.cacheLookups.Inc("miss")
+	}
+}
+
+// meteredTransport records the requests it makes in the metrics.
+type meteredTransport struct {
+	next    http.RoundTripper
+	metrics *serverMetrics
+}
+
+func (t meteredTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(request)
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	t.metrics.apiRequests.Inc(request.URL.Host, code)
+	t.metrics.apiDuration.Observe(time.Since(start).Seconds(), request.URL.Host)
+	return resp, err
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+const migrateUsage = "usage: recipefinder migrate [status]"
+
+// runMigrate implements "recipefinder migrate", which applies the pending
+// schema migrations, and "recipefinder migrate status", which lists them.
+func runMigrate(args []string, cfg *config.Config) error {
+	if len(args) > 1 || (len(args) == 1 && args[0] != "status") {
+		return errors.New(migrateUsage)
+	}
+	// Moving a large cache to a new layout can take longer than a search may
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cache, closeCache := openStore(ctx, cfg, store.Options{TTL: cfg.CacheTTL, Migrations: store.SkipMigrations})
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache")
+	}
+
+	if len(args) == 1 {
+		migrations, err := cache.Migrations(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range migrations {
+			applied := "pending"
+			if !migration.AppliedAt.IsZero() {
+				applied = "applied " + migration.AppliedAt.In(timeZone(cfg)).Format(time.DateTime)
+			}
+			fmt.Printf("%3d  %-27s  %s\n", migration.Version, applied, migration.Name)
+		}
+		return nil
+	}
+
+	applied, err := cache.Migrate(ctx)
+	for _, migration := range applied {
+		fmt.Printf("Applied migration %d: %s\n", migration.Version, migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Println("The database schema is up to date")
+	}
+	return nil
+}
+
+const dbUsage = "usage: recipefinder db rollback-migration"
+
+// runDB implements "recipefinder db rollback-migration", which undoes the last
+// schema migration, for going back to the previous version of recipefinder.
+func runDB(args []string, cfg *config.Config) error {
+	if len(args) != 1 || args[0] != "rollback-migration" {
+		return errors.New(dbUsage)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cache, closeCache := openStore(ctx, cfg, store.Options{TTL: cfg.CacheTTL, Migrations: store.SkipMigrations})
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache")
+	}
+
+	migration, err := cache.RollbackMigration(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Rolled back migration %d: %s\n", migration.Version, migration.Name)
+	fmt.Println("This version applies it again when it next opens the cache; run the previous version, " +
+		"or set db.manualMigrations to true to keep it rolled back")
+	return nil
+}
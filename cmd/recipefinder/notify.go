@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+	"github.com/mawojcik/meals_generator/pkg/telegram"
+	"github.com/mawojcik/meals_generator/pkg/webhook"
+)
+
+// notification tells the user about recipes found while they were not
+// looking.
+type notification struct {
+	// Event is one of store.Events, which members choose to be notified of.
+	Event   string           `json:"event"`
+	Message string           `json:"message"`
+	Recipes []recipes.Recipe `json:"recipes"`
+}
+
+// notifier is a channel notifications go out through.
+type notifier interface {
+	notify(ctx context.Context, n notification) error
+}
+
+// newNotifiers returns the configured channels, printing to stdout first,
+// and with a Telegram bot, the chats of the members who joined through it.
+func newNotifiers(cfg *config.Config, cache store.Store) []notifier {
+	location := timeZone(cfg)
+	notifiers := []notifier{stdoutNotifier{location: location}}
+	if cfg.Notifications.WebhookURL != "" {
+		notifiers = append(notifiers, webhookNotifier{
+			url:     cfg.Notifications.WebhookURL,
+			secrets: cfg.Notifications.WebhookSecrets,
+			client:  &http.Client{Timeout: 10 * time.Second},
+		})
+	}
+	if cfg.Telegram.Token != "" && cache != nil {
+		// Validated with the rest of the config
+		digestAt, _ := cfg.Notifications.DigestMinute()
+		notifiers = append(notifiers, memberNotifier{
+			chat:     telegram.NewClient(cfg.Telegram.Token),
+			cache:    cache,
+			digest:   cfg.Notifications.Digest,
+			digestAt: digestAt,
+			budget:   recipes.DollarsToMoney(cfg.Planning.WeeklyBudget),
+			now:      func() time.Time { return time.Now().In(location) },
+		})
+	}
+	return notifiers
+}
+
+// sendNotification sends n through every channel; failures are only logged.
+func sendNotification(ctx context.Context, notifiers []notifier, n notification) {
+	for _, channel := range notifiers {
+		err := channel.notify(ctx, n)
+		if err != nil {
+			slog.Error("error sending notification", "error", err)
+		}
+	}
+}
+
+// stdoutNotifier prints notifications with the time in location.
+type stdoutNotifier struct {
+	location *time.Location
+}
+
+func (s stdoutNotifier) notify(ctx context.Context, n notification) error {
+	fmt.Printf("%s: %s\n", time.Now().In(s.location).Format(time.DateTime), n.Message)
+	for _, recipe := range n.Recipes {
+		fmt.Printf("  %d  %s\n", recipe.ID, recipe.Title)
+	}
+	return nil
+}
+
+// webhookNotifier posts notifications as JSON, signed with the secrets when
+// there are any.
+type webhookNotifier struct {
+	url     string
+	secrets []string
+	client  *http.Client
+}
+
+func (n webhookNotifier) notify(ctx context.Context, sent notification) error {
+	payload, err := json.Marshal(sent)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if len(n.secrets) > 0 {
+		request.Header.Set(webhook.SignatureHeader, webhook.Sign(n.secrets, time.Now(), payload))
+	}
+	resp, err := n.client.Do(request)
+	if err != nil {
+		return err
+	}
+	err = resp.Body.Close()
+	if err != nil {
+		slog.Warn("error closing response body", "error", err)
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// emailNotifier mails notifications to the addresses through the SMTP
+// server, over TLS when the server offers STARTTLS.
+type emailNotifier struct {
+	server config.Email
+	to     []string
+}
+
+func (n emailNotifier) notify(ctx context.Context, sent notification) error {
+	var message strings.Builder
+	fmt.Fprintf(&message, "From: %s\r\n", n.server.From)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", sent.Message))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	message.WriteString(sent.Message + "\r\n\r\n")
+	for _, recipe := range sent.Recipes {
+		fmt.Fprintf(&message, "- %s\r\n", recipe.Title)
+		if recipe.SourceURL != "" {
+			fmt.Fprintf(&message, "  %s\r\n", recipe.SourceURL)
+		}
+	}
+
+	var auth smtp.Auth
+	if n.server.Username != "" {
+		auth = smtp.PlainAuth("", n.server.Username, n.server.Password, n.server.Host)
+	}
+	address := net.JoinHostPort(n.server.Host, strconv.Itoa(n.server.Port))
+	err := smtp.SendMail(address, auth, n.server.From, n.to, []byte(message.String()))
+	if err != nil {
+		return fmt.Errorf("error sending email: %v", err)
+	}
+	return nil
+}
+
+// memberStore is what memberNotifier keeps in the cache.
+type memberStore interface {
+	Members(ctx context.Context) ([]store.Member, error)
+	NotificationPrefs(ctx context.Context, profile string) (store.NotificationPrefs, error)
+	QueueDigest(ctx context.Context, item store.DigestItem) error
+	PendingDigests(ctx context.Context, before time.Time) ([]store.DigestItem, error)
+	ClearDigest(ctx context.Context, account string, before time.Time) error
+	MemberProfile(ctx context.Context, account string) (string, error)
+	ProfileCooked(ctx context.Context, profile string, since time.Time) ([]store.CookedMeal, error)
+}
+
+// memberNotifier sends notifications to the Telegram chats of the members,
+// those whose profile wants the event through Telegram and is outside its
+// quiet hours. A notification sent during quiet hours is not sent later. The
+// events in digest are queued instead, for sendDigests to send together.
+type memberNotifier struct {
+	chat   botChat
+	cache  memberStore
+	digest []string
+	// digestAt is the time of day digests are sent at, in minutes after
+	// midnight.
+	digestAt int
+	// budget is the weekly budget the digest counts the weeks under, see
+	// cookingProgress.
+	budget recipes.Money
+	now    func() time.Time
+}
+
+func (n memberNotifier) notify(ctx context.Context, sent notification) error {
+	members, err := n.cache.Members(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing members: %v", err)
+	}
+	text := sent.Message
+	for _, recipe := range sent.Recipes {
+		text += "\n• " + recipe.Title
+	}
+	digested := slices.Contains(n.digest, sent.Event)
+	now := n.now()
+	profiles := make(map[string]store.NotificationPrefs)
+	var errs []error
+	for _, member := range members {
+		chatID, ok := telegramChat(member.Account)
+		if !ok {
+			continue
+		}
+		prefs, ok := profiles[member.Profile]
+		if !ok {
+			prefs, err = n.cache.NotificationPrefs(ctx, member.Profile)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error reading the notification preferences of %s: %v",
+					member.Profile, err))
+				continue
+			}
+			profiles[member.Profile] = prefs
+		}
+		switch {
+		case digested && prefs.Subscribed(store.ChannelTelegram, sent.Event):
+			err = n.cache.QueueDigest(ctx, store.DigestItem{Account: member.Account, Event: sent.Event, Message: text})
+		case !digested && prefs.Wants(store.ChannelTelegram, sent.Event, now):
+			err = n.chat.SendMessage(ctx, chatID, text, nil)
+		default:
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error notifying %s: %v", member.Account, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sendDigests sends every account its digest of the notifications queued
+// before the last digest time. An account whose digest fails to send gets it
+// on the next call.
+func (n memberNotifier) sendDigests(ctx context.Context) error {
+	now := n.now()
+	cutoff := time.Date(now.Year(), now.Month(), now.Day(), n.digestAt/60, n.digestAt%60, 0, 0, now.Location())
+	if cutoff.After(now) {
+		cutoff = cutoff.AddDate(0, 0, -1)
+	}
+	items, err := n.cache.PendingDigests(ctx, cutoff)
+	if err != nil {
+		return fmt.Errorf("error reading digests: %v", err)
+	}
+	var errs []error
+	for start := 0; start < len(items); {
+		account := items[start].Account
+		end := start
+		var messages []string
+		for ; end < len(items) && items[end].Account == account; end++ {
+			messages = append(messages, items[end].Message)
+		}
+		start = end
+		chatID, ok := telegramChat(account)
+		if !ok {
+			continue
+		}
+		text := fmt.Sprintf("Your daily digest, %d notifications:\n\n%s", len(messages), strings.Join(messages, "\n\n"))
+		if progress := n.progress(ctx, account, now); progress != "" {
+			text += "\n\n" + progress
+		}
+		err := n.chat.SendMessage(ctx, chatID, text, nil)
+		if err == nil {
+			err = n.cache.ClearDigest(ctx, account, cutoff)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error sending the digest of %s: %v", account, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// progress returns the cooking progress of the member's profile for the
+// digest, empty when the profile has logged no meals as cooked or it cannot be
+// read.
+func (n memberNotifier) progress(ctx context.Context, account string, now time.Time) string {
+	profile, err := n.cache.MemberProfile(ctx, account)
+	if err != nil {
+		slog.Warn("error reading the profile of a member", "error", err)
+		return ""
+	}
+	meals, err := n.cache.ProfileCooked(ctx, profile, time.Time{})
+	if err != nil {
+		slog.Warn("error reading cooked meals", "error", err)
+		return ""
+	}
+	if len(meals) == 0 {
+		return ""
+	}
+	var text strings.Builder
+	writeProgress(&text, measureProgress(meals, now, n.budget), n.budget)
+	return strings.TrimSpace(text.String())
+}
+
+// sendDigests sends the digests due through every channel that batches
+// notifications; failures are only logged.
+func sendDigests(ctx context.Context, notifiers []notifier) {
+	for _, channel := range notifiers {
+		if members, ok := channel.(memberNotifier); ok {
+			err := members.sendDigests(ctx)
+			if err != nil {
+				slog.Error("error sending digests", "error", err)
+			}
+		}
+	}
+}
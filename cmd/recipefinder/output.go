@@ -0,0 +1,530 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+var (
+	output = flag.String("output", "text", "Output format: text, json, csv, markdown or html, or png for the label command")
+	units  = flag.String("units", "", "Show ingredient amounts in metric or imperial units rather than the recipes' own")
+)
+
+var servings = flag.Int("servings", 0,
+	"Scale ingredient amounts and shopping lists to this many servings, 0 for the recipes' own")
+
+// formatter writes found recipes, or the shopping list for them, in one
+// output format. Instructions are only written when withInstructions is set.
+type formatter interface {
+	Format(w io.Writer, allRecipes []recipes.Recipe, withInstructions bool) error
+	FormatShoppingList(w io.Writer, items []recipes.ShoppingItem) error
+}
+
+// formatPrice writes a price, or "-" when it is unknown.
+func formatPrice(price recipes.Money) string {
+	if price <= 0 {
+		return "-"
+	}
+	return price.String()
+}
+
+// missedNames lists the missing ingredients of a recipe, each followed by
+// the substitutes the user has for it, e.g. "cheddar (or gouda)".
+func missedNames(recipe recipes.Recipe) []string {
+	names := make([]string, 0, len(recipe.MissedIngredients))
+	for _, ingredient := range recipe.MissedIngredients {
+		if len(ingredient.Substitutes) > 0 {
+			names = append(names, fmt.Sprintf("%s (or %s)", ingredient.Name, strings.Join(ingredient.Substitutes, ", ")))
+		} else {
+			names = append(names, ingredient.Name)
+		}
+	}
+	return names
+}
+
+// replacementNotes describes the --substitutes of a recipe's missing
+// ingredients, one per ingredient having any, e.g. "buttermilk: 1 cup = 1 cup
+// milk + 1 tbsp lemon juice; 1 cup = 1 cup plain yogurt".
+func replacementNotes(recipe recipes.Recipe) []string {
+	var notes []string
+	for _, ingredient := range recipe.MissedIngredients {
+		if len(ingredient.Replacements) > 0 {
+			notes = append(notes, fmt.Sprintf("%s: %s", ingredient.Name, strings.Join(ingredient.Replacements, "; ")))
+		}
+	}
+	return notes
+}
+
+// recipeKey identifies a recipe among those of every source, e.g. in the
+// callback data of the bot's buttons.
+func recipeKey(recipe recipes.Recipe) string {
+	return fmt.Sprintf("%s:%d", recipe.Source, recipe.ID)
+}
+
+// newAisle reports whether the shopping list item starts an aisle. Items are
+// sorted by aisle, see recipes.ShoppingList.
+func newAisle(items []recipes.ShoppingItem, i int) bool {
+	return i == 0 || items[i].Aisle != items[i-1].Aisle
+}
+
+// aisleHeading names an aisle in a shopping list, "Other" for items in no
+// known aisle.
+func aisleHeading(aisle string) string {
+	if aisle == "" {
+		return "Other"
+	}
+	return strings.ToUpper(aisle[:1]) + aisle[1:]
+}
+
+// staleAfter is how old recipe data gets before outputs point out its age.
+const staleAfter = 30 * 24 * time.Hour
+
+// recipePrice writes a recipe's price per serving, marked as such when the
+// source estimated it, or "unknown".
+func recipePrice(recipe recipes.Recipe) string {
+	if recipe.PricePerServing <= 0 {
+		return "unknown"
+	}
+	if slices.Contains(recipe.Estimated, recipes.EstimatedPrice) {
+		return "~" + formatPrice(recipe.PricePerServing) + " (estimate)"
+	}
+	return formatPrice(recipe.PricePerServing)
+}
+
+// shortPrice is recipePrice for tables: "~" marks an estimate and "-" an
+// unknown price.
+func shortPrice(recipe recipes.Recipe) string {
+	if recipe.PricePerServing > 0 && slices.Contains(recipe.Estimated, recipes.EstimatedPrice) {
+		return "~" + formatPrice(recipe.PricePerServing)
+	}
+	return formatPrice(recipe.PricePerServing)
+}
+
+// nutritionNotes qualify a recipe's nutrients: "unknown" when it has none,
+// "estimate" when the source estimated them and how long ago they were cached
+// when that was a while ago.
+func nutritionNotes(recipe recipes.Recipe) []string {
+	if len(recipe.Nutrients) == 0 {
+		return []string{"unknown"}
+	}
+	var notes []string
+	if slices.Contains(recipe.Estimated, recipes.EstimatedNutrition) {
+		notes = append(notes, "estimate")
+	}
+	if age := dataAge(recipe); age != "" {
+		notes = append(notes, "cached "+age)
+	}
+	return notes
+}
+
+// dataAge says how long ago a recipe's data was fetched, e.g. "45 days ago",
+// when that was over staleAfter ago, and is empty otherwise.
+func dataAge(recipe recipes.Recipe) string {
+	if recipe.FetchedAt.IsZero() {
+		return ""
+	}
+	age := time.Since(recipe.FetchedAt)
+	if age < staleAfter {
+		return ""
+	}
+	return fmt.Sprintf("%d days ago", int(age.Hours()/24))
+}
+
+// dailyValue notes the share of the daily value a nutrient is, e.g.
+// " (12% of daily value)", when the source gave it.
+func dailyValue(nutrient recipes.NutrientAmount) string {
+	if nutrient.DailyPercent <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%.0f%% of daily value)", nutrient.DailyPercent)
+}
+
+// nutrientsHeading is the heading of a recipe's nutrients in the labels'
+// language, with its nutritionNotes in parentheses.
+func nutrientsHeading(recipe recipes.Recipe, l labels) string {
+	heading := l.get("Nutrients")
+	if recipe.ScaledFrom > 0 {
+		heading = l.get("Nutrients per serving")
+	}
+	if notes := nutritionNotes(recipe); len(notes) > 0 {
+		return fmt.Sprintf("%s (%s):", heading, strings.Join(notes, ", "))
+	}
+	return heading + ":"
+}
+
+// nutrientTotal notes how much of a nutrient a recipe scaled with --servings
+// makes in all, e.g. ", 1800.00 kcal in all 6 servings", and is empty for
+// recipes that were not scaled.
+func nutrientTotal(recipe recipes.Recipe, name string) string {
+	total, ok := recipe.TotalNutrient(name)
+	if recipe.ScaledFrom == 0 || !ok {
+		return ""
+	}
+	return fmt.Sprintf(", %.2f %s in all %d servings", total.Amount, total.Unit, recipe.Servings)
+}
+
+// scaledServings describes the servings of a recipe scaled with --servings,
+// e.g. "6 (scaled from 4)", and is empty for recipes that were not scaled.
+func scaledServings(recipe recipes.Recipe) string {
+	if recipe.ScaledFrom == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d (scaled from %d)", recipe.Servings, recipe.ScaledFrom)
+}
+
+// planCost sums up the estimated cost of a plan.
+func planCost(plan recipes.Plan) string {
+	meals := 0
+	for _, day := range plan.Days {
+		meals += len(day.Meals)
+	}
+	if plan.Unpriced == meals {
+		return "Estimated cost: unknown, no meal has a price"
+	}
+	text := fmt.Sprintf("Estimated cost: %s for a serving of every meal", formatPrice(plan.Cost))
+	if plan.Unpriced > 0 {
+		text += fmt.Sprintf(", not counting %d meals without a price", plan.Unpriced)
+	}
+	return text
+}
+
+// shoppingAmount is the amount and unit of a shopping list item, or an empty
+// string when no amount is known.
+func shoppingAmount(item recipes.ShoppingItem) string {
+	if item.Amount == 0 {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprintf("%.4g %s", item.Amount, item.Unit))
+}
+
+// checkAmounts rejects invalid --units and --servings, before any search is
+// made.
+func checkAmounts() error {
+	_, err := recipes.ParseUnitSystem(*units)
+	if err != nil {
+		return err
+	}
+	if *servings < 0 {
+		return errors.New("--servings cannot be negative")
+	}
+	return nil
+}
+
+// selectedUnits returns the --units system, once checked with checkAmounts.
+func selectedUnits() recipes.UnitSystem {
+	system, _ := recipes.ParseUnitSystem(*units)
+	return system
+}
+
+// scaledRecipes scales the ingredient amounts of the recipes to --servings,
+// then converts them to the --units system.
+func scaledRecipes(allRecipes []recipes.Recipe) []recipes.Recipe {
+	return selectedUnits().Recipes(recipes.ScaleServings(allRecipes, *servings))
+}
+
+// convertedShoppingList merges the missing ingredients of the recipes, with amounts in
+// the --units system.
+func convertedShoppingList(allRecipes []recipes.Recipe) []recipes.ShoppingItem {
+	return selectedUnits().ShoppingList(recipes.ShoppingList(allRecipes))
+}
+
+var formatters = map[string]formatter{
+	"text":     textFormatter{},
+	"json":     jsonFormatter{},
+	"csv":      csvFormatter{},
+	"markdown": markdownFormatter{},
+	"html":     htmlFormatter{},
+}
+
+// lookupFormatter returns the formatter for an --output value. With
+// --accessible, text output uses accessibleFormatter, and otherwise labels
+// in the --lang language.
+func lookupFormatter(name string) (formatter, error) {
+	if name == "text" && *accessible {
+		return accessibleFormatter{}, nil
+	}
+	if name == "text" {
+		return textFormatter{labels: outputLabels[*lang]}, nil
+	}
+	f, ok := formatters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q, expected text, json, csv, markdown or html", name)
+	}
+	return f, nil
+}
+
+// textFormatter is the plain layout meant for reading in a terminal.
+type textFormatter struct {
+	// images shows the recipes' images, with --images.
+	images *recipeImages
+	// labels translate the labels, English when nil.
+	labels labels
+}
+
+func (f textFormatter) Format(w io.Writer, allRecipes []recipes.Recipe, withInstructions bool) error {
+	for _, recipe := range allRecipes {
+		fmt.Fprintf(w, "\n\n%s: %s%s%s\n", f.labels.get("Recipe"), recipe.Title,
+			localMark(recipe, " ["+f.labels.get("local")+"]"), favoriteMark(recipe, " ★ "+f.labels.get("already saved")))
+		if f.images != nil {
+			f.images.write(w, recipe)
+		}
+		fmt.Fprintf(w, "%s: %s\n", f.labels.get("Used Ingredients"),
+			strings.Join(recipes.IngredientNames(recipe.UsedIngredients), ", "))
+		fmt.Fprintf(w, "%s: %s\n", f.labels.get("Missed Ingredients"), strings.Join(missedNames(recipe), ", "))
+		if notes := replacementNotes(recipe); len(notes) > 0 {
+			fmt.Fprintf(w, "%s:\n", f.labels.get("Substitutes"))
+			for _, note := range notes {
+				fmt.Fprintf(w, "- %s\n", note)
+			}
+		}
+		fmt.Fprintln(w, nutrientsHeading(recipe, f.labels))
+		for _, name := range recipe.NutrientNames() {
+			nutrient := recipe.Nutrients[name]
+			fmt.Fprintf(w, "%s: %.2f %s%s%s\n", name, nutrient.Amount, nutrient.Unit, dailyValue(nutrient),
+				nutrientTotal(recipe, name))
+		}
+		fmt.Fprintf(w, "%s: %s\n", f.labels.get("Price per serving"), recipePrice(recipe))
+		if servings := scaledServings(recipe); servings != "" {
+			fmt.Fprintf(w, "%s: %s\n", f.labels.get("Servings"), servings)
+		}
+		if recipe.ReadyInMinutes > 0 {
+			fmt.Fprintf(w, "%s: %d %s\n", f.labels.get("Ready in"), recipe.ReadyInMinutes, f.labels.get("minutes"))
+		}
+		if len(recipe.Equipment) > 0 {
+			fmt.Fprintf(w, "%s: %s\n", f.labels.get("Equipment"), strings.Join(recipe.Equipment, ", "))
+		}
+		if recipe.Pairing != nil {
+			fmt.Fprintf(w, "%s: %s\n", f.labels.get("Pairing"), recipe.Pairing)
+		}
+		for _, warning := range recipe.Warnings {
+			fmt.Fprintf(w, "%s: %s\n", f.labels.get("Warning"), warning)
+		}
+		if withInstructions && len(recipe.Instructions) > 0 {
+			fmt.Fprintf(w, "%s:\n", f.labels.get("Instructions"))
+			for i, step := range recipe.Instructions {
+				fmt.Fprintf(w, "%d. %s\n", i+1, step)
+			}
+		}
+	}
+	return nil
+}
+
+func (f textFormatter) FormatShoppingList(w io.Writer, items []recipes.ShoppingItem) error {
+	fmt.Fprintf(w, "%s:\n", f.labels.get("Shopping List"))
+	for i, item := range items {
+		if newAisle(items, i) {
+			fmt.Fprintf(w, "%s:\n", aisleHeading(item.Aisle))
+		}
+		if amount := shoppingAmount(item); amount != "" {
+			fmt.Fprintf(w, "- %s: %s\n", item.Name, amount)
+		} else {
+			fmt.Fprintf(w, "- %s\n", item.Name)
+		}
+	}
+	return nil
+}
+
+// jsonFormatter writes the recipes as an indented JSON array, the same shape
+// the HTTP server responds with.
+type jsonFormatter struct{}
+
+func (jsonFormatter) Format(w io.Writer, allRecipes []recipes.Recipe, withInstructions bool) error {
+	if allRecipes == nil {
+		allRecipes = []recipes.Recipe{}
+	}
+	if !withInstructions {
+		stripped := make([]recipes.Recipe, len(allRecipes))
+		for i, recipe := range allRecipes {
+			recipe.Instructions = nil
+			stripped[i] = recipe
+		}
+		allRecipes = stripped
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(allRecipes)
+}
+
+func (jsonFormatter) FormatShoppingList(w io.Writer, items []recipes.ShoppingItem) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(items)
+}
+
+// csvFormatter writes one row per recipe with a column per nutrient. Lists
+// are joined with "; " within their cell.
+type csvFormatter struct{}
+
+func (csvFormatter) Format(w io.Writer, allRecipes []recipes.Recipe, withInstructions bool) error {
+	// Recipes read from the cache and from the API may not share every
+	// nutrient, so the columns are the union of all of them
+	nutrientSet := make(map[string]bool)
+	for _, recipe := range allRecipes {
+		for name := range recipe.Nutrients {
+			nutrientSet[name] = true
+		}
+	}
+	nutrientNames := make([]string, 0, len(nutrientSet))
+	for name := range nutrientSet {
+		nutrientNames = append(nutrientNames, name)
+	}
+	sort.Strings(nutrientNames)
+
+	header := []string{"id", "title", "used_ingredients", "missed_ingredients"}
+	header = append(header, nutrientNames...)
+	header = append(header, "price_per_serving", "estimated", "fetched_at", "warnings", "ready_in_minutes", "equipment")
+	if withInstructions {
+		header = append(header, "instructions")
+	}
+
+	writer := csv.NewWriter(w)
+	err := writer.Write(header)
+	if err != nil {
+		return err
+	}
+	for _, recipe := range allRecipes {
+		row := []string{
+			fmt.Sprint(recipe.ID),
+			recipe.Title,
+			strings.Join(recipes.IngredientNames(recipe.UsedIngredients), "; "),
+			strings.Join(recipes.IngredientNames(recipe.MissedIngredients), "; "),
+		}
+		for _, name := range nutrientNames {
+			nutrient, ok := recipe.Nutrients[name]
+			if !ok {
+				row = append(row, "")
+				continue
+			}
+			row = append(row, fmt.Sprintf("%.2f %s", nutrient.Amount, nutrient.Unit))
+		}
+		price := ""
+		if recipe.PricePerServing > 0 {
+			price = recipe.PricePerServing.Decimal()
+		}
+		fetchedAt := ""
+		if !recipe.FetchedAt.IsZero() {
+			fetchedAt = recipe.FetchedAt.UTC().Format(time.RFC3339)
+		}
+		readyIn := ""
+		if recipe.ReadyInMinutes > 0 {
+			readyIn = fmt.Sprint(recipe.ReadyInMinutes)
+		}
+		row = append(row, price, strings.Join(recipe.Estimated, "; "), fetchedAt, strings.Join(recipe.Warnings, "; "),
+			readyIn, strings.Join(recipe.Equipment, "; "))
+		if withInstructions {
+			row = append(row, strings.Join(recipe.Instructions, " "))
+		}
+		err = writer.Write(row)
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (csvFormatter) FormatShoppingList(w io.Writer, items []recipes.ShoppingItem) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"name", "amount", "unit", "recipes", "aisle"})
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		amount := ""
+		if item.Amount != 0 {
+			amount = fmt.Sprintf("%.4g", item.Amount)
+		}
+		err = writer.Write([]string{item.Name, amount, item.Unit, strings.Join(item.Recipes, "; "), item.Aisle})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// markdownFormatter writes a section per recipe, for pasting into notes.
+type markdownFormatter struct{}
+
+func (markdownFormatter) Format(w io.Writer, allRecipes []recipes.Recipe, withInstructions bool) error {
+	for i, recipe := range allRecipes {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "## %s%s%s\n\n", recipe.Title, localMark(recipe, " _(local)_"), favoriteMark(recipe, " ★"))
+		fmt.Fprintf(w, "**Used ingredients:** %s  \n", strings.Join(recipes.IngredientNames(recipe.UsedIngredients), ", "))
+		fmt.Fprintf(w, "**Missed ingredients:** %s\n\n", strings.Join(missedNames(recipe), ", "))
+		if notes := replacementNotes(recipe); len(notes) > 0 {
+			fmt.Fprintln(w, "**Substitutes:**")
+			fmt.Fprintln(w)
+			for _, note := range notes {
+				fmt.Fprintf(w, "- %s\n", note)
+			}
+			fmt.Fprintln(w)
+		}
+		if notes := nutritionNotes(recipe); len(notes) > 0 {
+			fmt.Fprintf(w, "*Nutrition: %s*\n\n", strings.Join(notes, ", "))
+		}
+		fmt.Fprintln(w, "| Nutrient | Amount |")
+		fmt.Fprintln(w, "| --- | --- |")
+		for _, name := range recipe.NutrientNames() {
+			nutrient := recipe.Nutrients[name]
+			fmt.Fprintf(w, "| %s | %.2f %s%s%s |\n", name, nutrient.Amount, nutrient.Unit, dailyValue(nutrient),
+				nutrientTotal(recipe, name))
+		}
+		// Trailing double spaces break the lines within the paragraph
+		fmt.Fprintf(w, "\n**Price per serving:** %s", recipePrice(recipe))
+		if servings := scaledServings(recipe); servings != "" {
+			fmt.Fprintf(w, "  \n**Servings:** %s", servings)
+		}
+		if recipe.ReadyInMinutes > 0 {
+			fmt.Fprintf(w, "  \n**Ready in:** %d minutes", recipe.ReadyInMinutes)
+		}
+		if len(recipe.Equipment) > 0 {
+			fmt.Fprintf(w, "  \n**Equipment:** %s", strings.Join(recipe.Equipment, ", "))
+		}
+		if recipe.Pairing != nil {
+			fmt.Fprintf(w, "  \n**Pairing:** %s", recipe.Pairing)
+		}
+		fmt.Fprintln(w)
+		if len(recipe.Warnings) > 0 {
+			fmt.Fprintln(w)
+			for _, warning := range recipe.Warnings {
+				fmt.Fprintf(w, "> **Warning:** %s\n", warning)
+			}
+		}
+		if withInstructions && len(recipe.Instructions) > 0 {
+			fmt.Fprintln(w, "\n### Instructions")
+			fmt.Fprintln(w)
+			for i, step := range recipe.Instructions {
+				fmt.Fprintf(w, "%d. %s\n", i+1, step)
+			}
+		}
+	}
+	return nil
+}
+
+func (markdownFormatter) FormatShoppingList(w io.Writer, items []recipes.ShoppingItem) error {
+	fmt.Fprintln(w, "## Shopping list")
+	for i, item := range items {
+		if newAisle(items, i) {
+			fmt.Fprintf(w, "\n### %s\n\n", aisleHeading(item.Aisle))
+		}
+		if amount := shoppingAmount(item); amount != "" {
+			fmt.Fprintf(w, "- [ ] %s (%s)\n", item.Name, amount)
+		} else {
+			fmt.Fprintf(w, "- [ ] %s\n", item.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mawojcik/meals_generator/internal/golden"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/spoonacular"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+// The outputs are compared with golden files in testdata; run
+// go test ./cmd/recipefinder -update-golden after changing one on purpose and
+// review the diff of the golden files.
+
+// planStart is the first day of the exported plans.
+var planStart = time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)
+
+func testRecipes(t *testing.T) []recipes.Recipe {
+	t.Helper()
+	var allRecipes []recipes.Recipe
+	err := json.Unmarshal(golden.Fixture(t, "recipes.json"), &allRecipes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return allRecipes
+}
+
+// testFormatters are the --output formats, accessible text included.
+func testFormatters() map[string]formatter {
+	all := map[string]formatter{"accessible": accessibleFormatter{}}
+	for name, f := range formatters {
+		all[name] = f
+	}
+	return all
+}
+
+func TestFormatsGolden(t *testing.T) {
+	// Dates the HTML report
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	allRecipes := testRecipes(t)
+	for name, f := range testFormatters() {
+		t.Run(name, func(t *testing.T) {
+			var results, shopping bytes.Buffer
+			err := f.Format(&results, allRecipes, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden.Check(t, "search."+name+".golden", results.Bytes())
+			err = f.FormatShoppingList(&shopping, recipes.ShoppingList(allRecipes))
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden.Check(t, "shopping."+name+".golden", shopping.Bytes())
+		})
+	}
+}
+
+func TestLocalizedTextGolden(t *testing.T) {
+	var results bytes.Buffer
+	err := textFormatter{labels: outputLabels["pl"]}.Format(&results, testRecipes(t), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "search.text.pl.golden", results.Bytes())
+}
+
+func TestExportsGolden(t *testing.T) {
+	allRecipes := testRecipes(t)
+	plan, err := recipes.BuildPlan(allRecipes, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := map[string]exporter{"ics": icsExporter{now: func() time.Time {
+		return planStart.Add(-time.Hour)
+	}}}
+	for name, e := range exporters {
+		if name != "ics" {
+			all[name] = e
+		}
+	}
+	for name, e := range all {
+		t.Run(name, func(t *testing.T) {
+			var shopping, planned bytes.Buffer
+			err := e.ExportShoppingList(&shopping, recipes.ShoppingList(allRecipes))
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden.Check(t, "shopping."+name+".export.golden", shopping.Bytes())
+			err = e.ExportPlan(&planned, plan, planStart)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden.Check(t, "plan."+name+".export.golden", planned.Bytes())
+		})
+	}
+}
+
+func TestFirstPlanDay(t *testing.T) {
+	warsaw := time.FixedZone("CEST", 2*60*60)
+	denver := time.FixedZone("MST", -7*60*60)
+	for _, test := range []struct {
+		now      time.Time
+		location *time.Location
+		want     time.Time
+	}{
+		{planStart.Add(23*time.Hour + 30*time.Minute), time.UTC, time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)},
+		// Already the 5th in Warsaw, so the plan starts on the 6th
+		{planStart.Add(23*time.Hour + 30*time.Minute), warsaw, time.Date(2024, time.March, 6, 0, 0, 0, 0, warsaw)},
+		// Still the 4th in Denver
+		{planStart.Add(27 * time.Hour), denver, time.Date(2024, time.March, 5, 0, 0, 0, 0, denver)},
+	} {
+		got := firstPlanDay(test.now, test.location)
+		if !got.Equal(test.want) || got.Location() != test.location {
+			t.Errorf("firstPlanDay(%v, %v) = %v, want %v", test.now, test.location, got, test.want)
+		}
+	}
+}
+
+func TestPlanGolden(t *testing.T) {
+	plan, err := recipes.BuildPlan(testRecipes(t), 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan.Summary = recipes.SummarizePlan(plan, recipes.NutritionTargets{MaxCalories: 500}, []string{"garlic"})
+	plan.Summary.Goals = planGoals(plan, recipes.DailyGoals{Calories: 600, Protein: 5, Fat: 20})
+	var text, accessibleText bytes.Buffer
+	err = printPlan(&text, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "plan.golden", text.Bytes())
+	err = printAccessiblePlan(&accessibleText, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "plan.accessible.golden", accessibleText.Bytes())
+}
+
+func TestPlanReportGolden(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	plan, err := recipes.BuildPlan(testRecipes(t), 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	image := filepath.Join(t.TempDir(), "spoonacular-1.jpg")
+	err = os.WriteFile(image, []byte("jpeg"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	images := map[string]string{recipeKey(plan.Days[0].Meals[0]): image}
+	var report bytes.Buffer
+	err = writeReport(&report, planReport(plan, images))
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "plan.html.golden", report.Bytes())
+}
+
+func TestBundle(t *testing.T) {
+	allRecipes := testRecipes(t)
+	plan, err := recipes.BuildPlan(allRecipes, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	image := filepath.Join(t.TempDir(), "spoonacular-1.jpg")
+	err = os.WriteFile(image, []byte("jpeg"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := plan.Days[0].Meals[0]
+	var zipped bytes.Buffer
+	err = writeBundle(&zipped, plan, planStart, map[string]string{recipeKey(first): image})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(zipped.Bytes()), int64(zipped.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, file := range archive.File {
+		f, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name] = string(data)
+	}
+	want := []string{"plan.pdf", "plan.ics", "shopping-list.csv", "images/spoonacular-1.jpg"}
+	for i, recipe := range planRecipes(plan) {
+		want = append(want, fmt.Sprintf("recipes/%02d-%s.md", i+1, fileSlug(recipe.Title)))
+	}
+	for _, name := range want {
+		if _, ok := files[name]; !ok {
+			t.Errorf("bundle has no %s, got %d files", name, len(files))
+		}
+	}
+	if len(files) != len(want) {
+		t.Errorf("bundle has %d files, want %d", len(files), len(want))
+	}
+	if pdf := files["plan.pdf"]; !strings.HasPrefix(pdf, "%PDF-") || !strings.Contains(pdf, first.Title) {
+		t.Errorf("plan.pdf is not a PDF of the plan: %.40q", pdf)
+	}
+	firstRecipe := fmt.Sprintf("recipes/01-%s.md", fileSlug(first.Title))
+	if !strings.HasSuffix(files[firstRecipe], "(../images/spoonacular-1.jpg)\n") {
+		t.Errorf("%s does not link its image", firstRecipe)
+	}
+}
+
+func TestLargePrintPDF(t *testing.T) {
+	long := strings.Repeat("x", 100)
+	var pdf bytes.Buffer
+	err := writePDF(&pdf, []string{"Day 1", long}, newPDFLayout(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"/BaseFont /Courier-Bold", "/F1 16 Tf", "20 TL", "(" + long[:80] + ") '", "(" + long[80:] + ") '"} {
+		if !strings.Contains(pdf.String(), want) {
+			t.Errorf("large-print PDF has no %q", want)
+		}
+	}
+}
+
+func TestShowGolden(t *testing.T) {
+	var recipe, card bytes.Buffer
+	shown := testRecipes(t)[0]
+	shown.Likes = 209
+	shown.HealthScore = 52
+	shown.Taste = &recipes.Taste{Sweetness: 28, Spiciness: 1200}
+	err := writeRecipe(&recipe, textFormatter{}, shown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden.Check(t, "show.golden", recipe.Bytes())
+
+	info := recipes.DescribeIngredient("cheddar")
+	info.FetchedAt = time.Now()
+	info.Food = &recipes.FoodData{
+		Description: "Cheese, cheddar",
+		UnitWeights: []recipes.UnitWeight{{Unit: "1 cup, shredded", Grams: 113}},
+		Nutrients: map[string]recipes.NutrientAmount{
+			"Calories": {Amount: 403, Unit: "kcal"},
+			"Protein":  {Amount: 22.9, Unit: "g"},
+		},
+	}
+	writeIngredient(&card, info)
+	golden.Check(t, "ingredient.golden", card.Bytes())
+}
+
+func TestLocalRecipes(t *testing.T) {
+	custom, notes, err := parseCustomRecipes("mine.json", []byte(`[
+		{"title": "Grandma's omelette", "servings": 2, "ingredients": ["3 eggs", "1 tbsp butter", "chives"],
+			"instructions": ["Whisk the eggs.", "Fry in the butter."]},
+		{"title": "No ingredients"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(custom) != 1 || custom[0].Title != "Grandma's omelette" || len(custom[0].Ingredients) != 3 ||
+		custom[0].Ingredients[0].Amount != 3 {
+		t.Fatalf("got %+v, want the omelette with its 3 ingredients", custom)
+	}
+	if len(notes) != 1 {
+		t.Errorf("got notes %q, want one for the recipe without ingredients", notes)
+	}
+
+	recipe := testRecipes(t)[0]
+	recipe.Source = store.LocalSource
+	var results bytes.Buffer
+	err = textFormatter{}.Format(&results, []recipes.Recipe{recipe}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(results.String(), recipe.Title+" [local]") {
+		t.Errorf("got %q, want the recipe labeled local", results.String())
+	}
+}
+
+func TestLabelPNG(t *testing.T) {
+	var widget spoonacular.NutritionWidget
+	err := json.Unmarshal([]byte(`{
+		"nutrients": [
+			{"name": "Calories", "amount": 584.5, "unit": "kcal", "percentOfDailyNeeds": 29.2},
+			{"name": "Fat", "amount": 21.3, "unit": "g", "percentOfDailyNeeds": 32.8},
+			{"name": "Protein", "amount": 19.1, "unit": "g", "percentOfDailyNeeds": 38.2},
+			{"name": "Iron", "amount": 3.2, "unit": "mg", "percentOfDailyNeeds": 17.8}
+		],
+		"weightPerServing": {"amount": 368, "unit": "g"}
+	}`), &widget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text bytes.Buffer
+	renderNutritionLabel(&text, &widget)
+	if !strings.Contains(text.String(), "Total Fat 21.3g") || !strings.Contains(text.String(), "Iron 3.2mg") {
+		t.Errorf("got label %q, want its fat and iron", text.String())
+	}
+
+	var encoded bytes.Buffer
+	err = renderLabelPNG(&encoded, &widget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 40 characters 7 pixels wide within margins of 10, scaled twice; the
+	// 3 thick rules take 12 pixels, the 4 thin ones 7 and the 12 text lines
+	// 15
+	wantWidth, wantHeight := 2*(40*7+2*10), 2*(2*10+3*12+4*7+12*15)
+	if got := img.Bounds(); got.Dx() != wantWidth || got.Dy() != wantHeight {
+		t.Fatalf("got a %dx%d label, want %dx%d", got.Dx(), got.Dy(), wantWidth, wantHeight)
+	}
+	// The margin is white and the top rule black
+	if r, _, _, _ := img.At(0, 0).RGBA(); r != 0xffff {
+		t.Errorf("got corner %v, want white", img.At(0, 0))
+	}
+	if r, _, _, _ := img.At(wantWidth/2, 2*(10+labelRulePadding)).RGBA(); r != 0 {
+		t.Errorf("got %v on the top rule, want black", img.At(wantWidth/2, 2*(10+labelRulePadding)))
+	}
+}
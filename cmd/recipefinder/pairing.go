@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/spoonacular"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+var pairing = flag.Bool("pairing", false, "Suggest a wine to go with each printed recipe, from Spoonacular")
+
+// maxPairingFoods is how many of a recipe's foods, see recipes.PairingFoods,
+// are looked up before deciding it has no pairing. Each costs a request.
+const maxPairingFoods = 3
+
+// checkPairing checks that --pairing can be looked up.
+func checkPairing(cfg *config.Config) error {
+	if !*pairing {
+		return nil
+	}
+	if len(cfg.SpoonacularKeys()) == 0 {
+		return errors.New("--pairing looks pairings up on Spoonacular and needs its API key")
+	}
+	if *offlineSearch {
+		return errors.New("--pairing looks pairings up on Spoonacular, it cannot be combined with --offline")
+	}
+	return nil
+}
+
+// addPairings sets the pairing of each recipe, from the cache while it is
+// fresh. A pairing that cannot be looked up is taken from the cache however
+// old, or left out, as it is not worth failing the search for.
+func addPairings(ctx context.Context, cache store.Store, cfg *config.Config,
+	allRecipes []recipes.Recipe) []recipes.Recipe {
+	client := newSpoonacular(cfg)
+	paired := make([]recipes.Recipe, len(allRecipes))
+	for i, recipe := range allRecipes {
+		found, err := fetchPairing(ctx, cache, client, recipe)
+		if err != nil {
+			slog.Warn("error looking up pairing", "recipe", recipe.ID, "error", err)
+		}
+		if found != nil && len(found.Wines) > 0 {
+			recipe.Pairing = found
+		}
+		paired[i] = recipe
+	}
+	return paired
+}
+
+// fetchPairing returns the pairing of a recipe, without wines when it has
+// none. When looking it up fails the cached one is returned with the error.
+func fetchPairing(ctx context.Context, cache store.Store, client *spoonacular.Client,
+	recipe recipes.Recipe) (*recipes.Pairing, error) {
+	var cached *recipes.Pairing
+	if cache != nil {
+		found, fresh, err := cache.CachedPairing(ctx, recipe.Source, recipe.ID)
+		if err != nil {
+			slog.Warn("error reading cached pairing", "error", err)
+		}
+		if fresh {
+			return found, nil
+		}
+		cached = found
+	}
+
+	found := &recipes.Pairing{}
+	for _, food := range recipes.PairingFoods(recipe, maxPairingFoods) {
+		wines, err := client.WinePairing(ctx, food)
+		if err != nil {
+			return cached, err
+		}
+		if wines != nil {
+			found = wines
+			break
+		}
+	}
+	if cache != nil {
+		err := cache.SavePairing(ctx, recipe.Source, recipe.ID, *found)
+		if err != nil {
+			slog.Warn("error caching pairing", "error", err)
+		}
+	}
+	return found, nil
+}
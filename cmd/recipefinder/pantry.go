@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+var skipPantry = flag.Bool("skipPantry", false, "Search without adding the pantry items to the ingredients")
+
+const pantryUsage = "usage: recipefinder pantry add <ingredient1>,... | pantry remove <ingredient1>,... | pantry list"
+
+// runPantry implements "recipefinder pantry <subcommand>".
+func runPantry(ctx context.Context, args []string, cfg *config.Config) error {
+	if len(args) == 0 {
+		return errors.New(pantryUsage)
+	}
+
+	var items []string
+	switch args[0] {
+	case "list":
+		if len(args) != 1 {
+			return errors.New(pantryUsage)
+		}
+	case "add", "remove":
+		if len(args) != 2 {
+			return errors.New(pantryUsage)
+		}
+		cleaned, _, err := recipes.CleanIngredientList(strings.Split(args[1], ","))
+		if err != nil {
+			return err
+		}
+		items = cleaned
+	default:
+		return errors.New(pantryUsage)
+	}
+
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache")
+	}
+
+	switch args[0] {
+	case "add":
+		err := cache.AddToPantry(ctx, items)
+		if err != nil {
+			return fmt.Errorf("error adding to pantry: %v", err)
+		}
+		fmt.Printf("Added %s to the pantry\n", strings.Join(items, ", "))
+	case "remove":
+		removed, err := cache.RemoveFromPantry(ctx, items)
+		if err != nil {
+			return fmt.Errorf("error removing from pantry: %v", err)
+		}
+		fmt.Printf("Removed %d items from the pantry\n", removed)
+	case "list":
+		pantry, err := cache.Pantry(ctx)
+		if err != nil {
+			return fmt.Errorf("error reading pantry: %v", err)
+		}
+		if len(pantry) == 0 {
+			fmt.Println("The pantry is empty")
+		}
+		for _, item := range pantry {
+			fmt.Println(item)
+		}
+	}
+	return nil
+}
+
+// withPantry adds the pantry items to the ingredients of the query, unless
+// --skipPantry is given. Failing to read the pantry only loses the extra
+// ingredients.
+func withPantry(ctx context.Context, cache store.Store, query recipes.Query) recipes.Query {
+	return addPantry(query, readPantry(ctx, cache))
+}
+
+// readPantry returns the pantry items searches add, none with --skipPantry
+// or when the pantry cannot be read.
+func readPantry(ctx context.Context, cache store.Store) []string {
+	if cache == nil || *skipPantry {
+		return nil
+	}
+	pantry, err := cache.Pantry(ctx)
+	if err != nil {
+		slog.Warn("error reading pantry", "error", err)
+		return nil
+	}
+	return pantry
+}
+
+// addPantry adds the pantry items to the ingredients of the query.
+func addPantry(query recipes.Query, pantry []string) recipes.Query {
+	if len(pantry) == 0 {
+		return query
+	}
+	ingredientList, _, err := recipes.CleanIngredientList(append(append([]string(nil), query.Ingredients...), pantry...))
+	if err != nil {
+		slog.Warn("error adding pantry items", "error", err)
+		return query
+	}
+	query.Ingredients = ingredientList
+	return query
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The layout of writePDF: landscape A4 pages of Courier, which keeps the
+// columns of a tabwriter table lined up.
+const (
+	pdfPageWidth  = 842
+	pdfPageHeight = 595
+	pdfMargin     = 36
+	pdfFontSize   = 9
+)
+
+// The font sizes --print-size accepts. From largePrintSize on, the text is
+// set in bold for contrast.
+const (
+	minPrintSize   = 6
+	maxPrintSize   = 36
+	largePrintSize = 14
+)
+
+var printSize = flag.Int("print-size", pdfFontSize, fmt.Sprintf("Font size in points of the plan.pdf of "+
+	"--bundle, from %d to %d; %d or more prints it large and in bold", minPrintSize, maxPrintSize, largePrintSize))
+
+// pdfLayout is the font of writePDF's text and the distance between its
+// lines, in points.
+type pdfLayout struct {
+	fontSize int
+	leading  int
+	bold     bool
+}
+
+// newPDFLayout returns the layout of text set in size points, its lines 1.2
+// times as far apart.
+func newPDFLayout(size int) pdfLayout {
+	return pdfLayout{fontSize: size, leading: (size*6 + 4) / 5, bold: size >= largePrintSize}
+}
+
+// checkPrintSize rejects a --print-size writePDF cannot lay out.
+func checkPrintSize() error {
+	if *printSize < minPrintSize || *printSize > maxPrintSize {
+		return fmt.Errorf("--print-size must be from %d to %d points", minPrintSize, maxPrintSize)
+	}
+	return nil
+}
+
+// writePDF writes the lines as a PDF document of as many pages as they need,
+// lines too long for the page width wrapping onto the next. The standard
+// Courier fonts only have the Latin-1 characters, so the others are written
+// as question marks.
+func writePDF(w io.Writer, lines []string, layout pdfLayout) error {
+	// A Courier character is 0.6 times as wide as the font size
+	lines = wrapLines(lines, (pdfPageWidth-2*pdfMargin)*5/(3*layout.fontSize))
+	perPage := (pdfPageHeight - 2*pdfMargin) / layout.leading
+	var pages [][]string
+	for len(lines) > perPage {
+		pages = append(pages, lines[:perPage])
+		lines = lines[perPage:]
+	}
+	pages = append(pages, lines)
+
+	var out bytes.Buffer
+	var offsets []int
+	object := func(content string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), content)
+	}
+	out.WriteString("%PDF-1.4\n")
+	// Objects 1 to 3 are the catalog, the page tree and the font, followed by
+	// each page and its content
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	font := "Courier"
+	if layout.bold {
+		font = "Courier-Bold"
+	}
+	object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font))
+	for i, page := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+			"/Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pdfPageWidth, pdfPageHeight, 5+2*i))
+		var content strings.Builder
+		// Each line moves down a line first, so the text starts a line below
+		// the top margin
+		fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", layout.fontSize, layout.leading, pdfMargin,
+			pdfPageHeight-pdfMargin)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) '\n", pdfText(line))
+		}
+		content.WriteString("ET")
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// wrapLines splits the lines longer than width characters into lines of
+// width.
+func wrapLines(lines []string, width int) []string {
+	wrapped := make([]string, 0, len(lines))
+	for _, line := range lines {
+		runes := []rune(line)
+		for len(runes) > width {
+			wrapped = append(wrapped, string(runes[:width]))
+			runes = runes[width:]
+		}
+		wrapped = append(wrapped, string(runes))
+	}
+	return wrapped
+}
+
+// pdfText encodes a line as the bytes of a PDF string in WinAnsiEncoding,
+// escaping what the string syntax needs.
+func pdfText(line string) string {
+	var text strings.Builder
+	for _, r := range line {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			text.WriteByte('\\')
+			text.WriteRune(r)
+		case r == '\t':
+			text.WriteByte(' ')
+		case r >= ' ' && r < 0x7F, r >= 0xA0 && r <= 0xFF:
+			text.WriteByte(byte(r))
+		default:
+			text.WriteByte('?')
+		}
+	}
+	return text.String()
+}
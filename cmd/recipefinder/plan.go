@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+var (
+	days              = flag.Int("days", 7, "Number of days to plan, with the plan command")
+	mealsPerDay       = flag.Int("mealsPerDay", 3, "Number of meals per day, with the plan command")
+	minimizeLeftovers = flag.Bool("minimizeLeftovers", false, "With the plan command, take --ingredients with "+
+		"quantities, as chicken:500g,rice:2cups, and choose the meals that leave the least of them while missing "+
+		"the fewest ingredients")
+	stretch = flag.Bool("stretch", false, "With the plan command, plan one recipe a week with cooking techniques "+
+		"not among the recipes logged with \"recipefinder cooked\", and only known techniques in the others")
+)
+
+// leftoverCandidates is how many recipes per meal --minimizeLeftovers searches
+// for to choose from.
+const leftoverCandidates = 3
+
+// runPlan implements "recipefinder plan": it searches for enough recipes to
+// fill every meal and spreads them over the days.
+func runPlan(ctx context.Context, cfg *config.Config, provider recipes.RecipeProvider, reporter *errorReporter) error {
+	if *ingredients == "" || *days <= 0 || *mealsPerDay <= 0 {
+		return errors.New("usage: recipefinder plan --ingredients=<ingredient1>,... [--days=7] [--mealsPerDay=3] " +
+			"[flags], see recipefinder help plan")
+	}
+	if *output != "text" && *output != "json" && *output != "html" {
+		return fmt.Errorf("the plan command supports text, json and html output, not %q", *output)
+	}
+	err := checkExport(true)
+	if err != nil {
+		return err
+	}
+	if *bundle != "" && *export != "" {
+		return errors.New("--bundle already holds every export, it cannot be combined with --export")
+	}
+	if *bundle != "" {
+		if err := checkPrintSize(); err != nil {
+			return err
+		}
+	}
+
+	// The plan starts on the day its exports do
+	start := firstPlanDay(exportTime(), timeZone(cfg))
+	constraints := recipes.PlanConstraints{Window: eatingWindow(cfg), Start: start.Weekday()}
+	if *activity != "" {
+		constraints.DayCalories, err = activityCalories(*activity, dailyGoals(cfg).Calories, start, *days)
+		if err != nil {
+			return err
+		}
+	}
+
+	meals := *days * *mealsPerDay
+	var query recipes.Query
+	var stock []recipes.StockItem
+	if *minimizeLeftovers {
+		stock, err = recipes.ParseStock(*ingredients)
+		if err != nil {
+			return err
+		}
+		query, err = parseFilters(meals*leftoverCandidates, cfg.Allergens)
+		query.Ingredients = recipes.StockNames(stock)
+	} else {
+		query, err = parseArguments(meals, cfg.Allergens)
+	}
+	if err != nil {
+		return err
+	}
+	if query.Targets.MaxCalories == 0 {
+		query.Targets.MaxCalories = mealCalories(dailyGoals(cfg).Calories, constraints.DayCalories, *mealsPerDay)
+	}
+	ruleSets, err := selectedRuleSets()
+	if err != nil {
+		return err
+	}
+	formPreferences, err := recipes.ParseDislikedForms(*dislikedForms)
+	if err != nil {
+		return err
+	}
+
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	pantry := readPantry(ctx, cache)
+	query = addPantry(query, pantry)
+	provider = withQuota(ctx, provider, cache, cfg)
+
+	allRecipes, err := newFinder(provider, cache, reporter, query.Ingredients, cfg.Sources).Find(ctx, query)
+	printQuota(provider)
+	if err != nil {
+		slog.Error("search failed", "error", err)
+		reporter.captureError(err, newErrorContext(provider, query.Ingredients))
+		return errors.New(searchError(err, cfg.Timeout))
+	}
+	// BuildPlan prefers the first recipes, so the ones missing the fewest
+	// ingredients come first whatever order the cache and API gave
+	tieBreakOrder(ctx, cache, allRecipes)
+	allRecipes = contentFilter(cfg.Filters).Apply(allRecipes)
+	recipes.SortRecipes(allRecipes, "missing", recipes.RankWeights{})
+	if *seed != 0 {
+		// Which of the equally good recipes are planned is up to the seed
+		shuffleTies(allRecipes, newRand())
+	}
+	allRecipes = regionAvailability(cfg.Region).Apply(allRecipes)
+	allRecipes = reportedRecipes(ctx, cache, cfg.Reports).Apply(allRecipes)
+	for _, ruleSet := range ruleSets {
+		allRecipes = ruleSet.Apply(allRecipes)
+	}
+	allRecipes = formPreferences.Apply(allRecipes)
+	allRecipes = recipes.MaxPrice(allRecipes, recipes.DollarsToMoney(*maxPrice))
+	allRecipes = recipes.MinHealthScore(allRecipes, *minHealthScore)
+	var leftovers []recipes.StockItem
+	if *minimizeLeftovers {
+		allRecipes, leftovers = recipes.MinimizeLeftovers(allRecipes, stock, meals)
+	}
+
+	for cuisine, limit := range cfg.Planning.CuisineLimits {
+		if constraints.CuisineLimits == nil {
+			constraints.CuisineLimits = make(map[string]int)
+		}
+		constraints.CuisineLimits[strings.ToLower(cuisine)] = limit
+	}
+	for _, theme := range cfg.Planning.Themes {
+		// The themes were checked by Config.Validate
+		weekday, _ := theme.Day()
+		constraints.Themes = append(constraints.Themes, recipes.PlanTheme{
+			Name: theme.Name, Weekday: weekday, Cuisine: theme.Cuisine, Dish: theme.Dish,
+		})
+	}
+	constraints.NewCuisines = cfg.Planning.NewCuisinesPerWeek
+	constraints.Stretch = *stretch
+	if constraints.NewCuisines > 0 || constraints.Stretch {
+		constraints.KnownCuisines, constraints.KnownTechniques = cookedSkills(ctx, cache)
+	}
+
+	plan, err := recipes.ConstrainedPlan(allRecipes, *days, *mealsPerDay, constraints)
+	if err != nil {
+		return err
+	}
+	plan.Summary = recipes.SummarizePlan(plan, query.Targets, pantry)
+	plan.Summary.Goals = planGoals(plan, dailyGoals(cfg))
+	plan.Leftovers = leftovers
+
+	if *bundle != "" {
+		return bundlePlan(ctx, cfg, plan)
+	}
+	if *export != "" {
+		return exportPlan(plan, timeZone(cfg))
+	}
+	if *output == "html" {
+		images := downloadImages(ctx, http.DefaultClient, filepath.Join(cfg.DataDir, "images"), planRecipes(plan))
+		return writePlanReport(plan, images)
+	}
+	if *output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(plan)
+	}
+	if *accessible {
+		return printAccessiblePlan(os.Stdout, plan)
+	}
+	return printPlan(os.Stdout, plan)
+}
+
+// printPlan writes the plan as a table with a row per meal and the day's
+// totals after its last meal.
+func printPlan(w io.Writer, plan recipes.Plan) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "Day\tMeal\tRecipe\tCalories\tProtein\tPrice\tMissing")
+	for dayIndex, day := range plan.Days {
+		for mealIndex, meal := range day.Meals {
+			title := meal.Title
+			if day.Theme != "" && mealIndex == len(day.Meals)-1 {
+				title += " (" + day.Theme + ")"
+			}
+			fmt.Fprintf(table, "%d\t%d\t%s\t%.0f\t%.1f\t%s\t%s\n", dayIndex+1, mealIndex+1, title,
+				meal.Nutrients["Calories"].Amount, meal.Nutrients["Protein"].Amount, shortPrice(meal),
+				strings.Join(recipes.IngredientNames(meal.MissedIngredients), ", "))
+		}
+		fmt.Fprintf(table, "\t\tTotal\t%.0f\t%.1f\t%s\t\n", day.Calories, day.Protein, formatPrice(day.Cost))
+	}
+	err := table.Flush()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, planCost(plan))
+	printPlanSummary(w, plan.Summary)
+	if leftovers := formatLeftovers(plan.Leftovers); leftovers != "" {
+		fmt.Fprintf(w, "Leftovers: %s\n", leftovers)
+	}
+	return nil
+}
+
+// printPlanSummary writes the roll-up of a plan after its cost.
+func printPlanSummary(w io.Writer, summary recipes.PlanSummary) {
+	fmt.Fprintf(w, "Total: %s\n", formatMacros(summary.Total))
+	fmt.Fprintf(w, "Daily average: %s\n", formatMacros(summary.DailyAverage))
+	fmt.Fprintf(w, "Pantry items used: %d\n", summary.PantryItemsUsed)
+	for _, target := range summary.Targets {
+		fmt.Fprintf(w, "Average meal %s: %.1f, %s\n", strings.ToLower(target.Nutrient), target.Average,
+			formatVariance(target))
+	}
+	printGoals(w, summary.Goals, true, "")
+}
+
+// formatLeftovers describes what is left of the stock after a plan, e.g.
+// "chicken 100 g, rice none, eggs 2".
+func formatLeftovers(leftovers []recipes.StockItem) string {
+	var parts []string
+	for _, item := range leftovers {
+		if item.Amount < 0.05 {
+			parts = append(parts, item.Name+" none")
+			continue
+		}
+		parts = append(parts, strings.TrimSpace(fmt.Sprintf("%s %.4g %s", item.Name, item.Amount, item.Unit)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatMacros(macros recipes.Macros) string {
+	return fmt.Sprintf("%.0f calories, %.1f g protein, %.1f g carbohydrates", macros.Calories, macros.Protein,
+		macros.Carbohydrates)
+}
+
+// formatVariance describes how far the average meal is from a target, e.g.
+// "12.0 under the max of 600".
+func formatVariance(target recipes.TargetVariance) string {
+	direction := "over"
+	if target.Variance < 0 {
+		direction = "under"
+	}
+	return fmt.Sprintf("%.1f %s the %s of %g", math.Abs(target.Variance), direction, target.Limit, target.Target)
+}
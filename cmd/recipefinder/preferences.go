@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+var quizQuestions = flag.Int("questions", 8, "How many pairs of recipes preferences quiz asks about")
+
+const preferencesUsage = "usage: recipefinder preferences quiz [--questions=8] | preferences show | preferences reset"
+
+// runPreferences implements "recipefinder preferences <subcommand>". The
+// preferences belong to the profile chosen with --profile, or the default
+// one.
+func runPreferences(ctx context.Context, args []string, cfg *config.Config) error {
+	if len(args) != 1 || args[0] != "quiz" && args[0] != "show" && args[0] != "reset" {
+		return errors.New(preferencesUsage)
+	}
+	if args[0] == "quiz" && !isTerminal(os.Stdin) {
+		return errors.New("preferences quiz needs a terminal to answer in")
+	}
+	if *quizQuestions < 1 {
+		return errors.New("--questions must be at least 1")
+	}
+
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache, which keeps the preferences")
+	}
+
+	switch args[0] {
+	case "quiz":
+		candidates, err := cache.QuizRecipes(ctx, 4**quizQuestions)
+		if err != nil {
+			return fmt.Errorf("error reading cached recipes: %v", err)
+		}
+		pairs := quizPairs(candidates, *quizQuestions)
+		if len(pairs) == 0 {
+			return errors.New("the cache has too few recipes for the quiz, search for some first")
+		}
+		answers := askQuiz(bufio.NewReader(os.Stdin), os.Stdout, pairs)
+		if len(answers) == 0 {
+			fmt.Println("No answers, nothing saved")
+			return nil
+		}
+		ranking, disliked := recipes.LearnPreferences(answers, rankWeights(cfg.Ranking))
+		prefs := store.Preferences{Profile: cfg.Profile, Ranking: ranking, Disliked: disliked}
+		err = cache.SavePreferences(ctx, prefs)
+		if err != nil {
+			return fmt.Errorf("error saving preferences: %v", err)
+		}
+		fmt.Printf("\nLearned from %d answers:\n", len(answers))
+		printPreferences(os.Stdout, prefs)
+	case "show":
+		prefs, err := cache.Preferences(ctx, cfg.Profile)
+		if err != nil {
+			return fmt.Errorf("error reading preferences: %v", err)
+		}
+		if prefs == nil {
+			fmt.Println("No preferences learned yet, take recipefinder preferences quiz")
+			return nil
+		}
+		fmt.Printf("Learned %s:\n", prefs.UpdatedAt.In(timeZone(cfg)).Format(time.DateTime))
+		printPreferences(os.Stdout, *prefs)
+	default:
+		found, err := cache.DeletePreferences(ctx, cfg.Profile)
+		if err != nil {
+			return fmt.Errorf("error deleting preferences: %v", err)
+		}
+		if !found {
+			fmt.Println("No preferences learned yet")
+			return nil
+		}
+		fmt.Println("Forgot the learned preferences")
+	}
+	return nil
+}
+
+// quizPairs pairs up to count pairs of the recipes at random, see --seed, two
+// recipes with the same title never making a pair.
+func quizPairs(candidates []recipes.Recipe, count int) [][2]recipes.Recipe {
+	newRand().Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	var pairs [][2]recipes.Recipe
+	for i := 0; i+1 < len(candidates) && len(pairs) < count; i++ {
+		if strings.EqualFold(candidates[i].Title, candidates[i+1].Title) {
+			continue
+		}
+		pairs = append(pairs, [2]recipes.Recipe{candidates[i], candidates[i+1]})
+		i++
+	}
+	return pairs
+}
+
+// askQuiz asks which recipe of each pair the user would rather eat. A pair
+// can be skipped, and the quiz stopped early, keeping the answers so far.
+func askQuiz(in *bufio.Reader, out io.Writer, pairs [][2]recipes.Recipe) []recipes.QuizAnswer {
+	fmt.Fprintln(out, "Which would you rather eat? Answer 1 or 2, s to skip, q to stop.")
+	var answers []recipes.QuizAnswer
+	for i, pair := range pairs {
+		fmt.Fprintf(out, "\n%d of %d\n  1) %s\n  2) %s\n", i+1, len(pairs), quizLine(pair[0]), quizLine(pair[1]))
+		for {
+			fmt.Fprint(out, "> ")
+			line, err := in.ReadString('\n')
+			answer := strings.ToLower(strings.TrimSpace(line))
+			switch {
+			case answer == "1":
+				answers = append(answers, recipes.QuizAnswer{Chosen: pair[0], Rejected: pair[1]})
+			case answer == "2":
+				answers = append(answers, recipes.QuizAnswer{Chosen: pair[1], Rejected: pair[0]})
+			case answer == "s":
+			case answer == "q" || err != nil:
+				return answers
+			default:
+				fmt.Fprintln(out, "Answer 1, 2, s or q")
+				continue
+			}
+			break
+		}
+	}
+	return answers
+}
+
+// quizLine describes a recipe by its title and what tells recipes apart in
+// the ranking: calories, protein and time.
+func quizLine(recipe recipes.Recipe) string {
+	var details []string
+	if calories, ok := recipe.Nutrients["Calories"]; ok {
+		details = append(details, fmt.Sprintf("%.0f kcal", calories.Amount))
+	}
+	if protein, ok := recipe.Nutrients["Protein"]; ok {
+		details = append(details, fmt.Sprintf("%.0f g protein", protein.Amount))
+	}
+	if recipe.ReadyInMinutes > 0 {
+		details = append(details, fmt.Sprintf("ready in %d min", recipe.ReadyInMinutes))
+	}
+	if len(details) == 0 {
+		return recipe.Title
+	}
+	return fmt.Sprintf("%s (%s)", recipe.Title, strings.Join(details, ", "))
+}
+
+func printPreferences(w io.Writer, prefs store.Preferences) {
+	fmt.Fprintf(w, "  ranking: missing %.2g, protein %.2g, calories %.2g, time %.2g\n", prefs.Ranking.Missing,
+		prefs.Ranking.Protein, prefs.Ranking.Calories, prefs.Ranking.Time)
+	if len(prefs.Disliked) == 0 {
+		fmt.Fprintln(w, "  disliked: none")
+		return
+	}
+	fmt.Fprintf(w, "  disliked: %s\n", strings.Join(prefs.Disliked, ", "))
+}
+
+// applyPreferences uses what the preference quiz learned about the profile
+// for a user who did not set the ranking, in the config file or with the
+// --rank* flags, and did not give --excludeIngredients. The learned
+// preferences are only a start for users with no history, so anything set
+// explicitly wins. Without a cache nothing is applied.
+func applyPreferences(ctx context.Context, cfg *config.Config) {
+	cache, err := connectStore(ctx, cfg, store.Options{TTL: cfg.CacheTTL})
+	if err != nil {
+		return
+	}
+	defer func() {
+		err := cache.Close()
+		if err != nil {
+			slog.Warn("error closing DB", "error", err)
+		}
+	}()
+	prefs, err := cache.Preferences(ctx, cfg.Profile)
+	if err != nil {
+		slog.Warn("error reading learned preferences", "error", err)
+		return
+	}
+	if prefs == nil {
+		return
+	}
+
+	given := givenFlags()
+	if cfg.Ranking == config.Default().Ranking && !given["rankMissing"] && !given["rankProtein"] &&
+		!given["rankCalories"] && !given["rankTime"] {
+		cfg.Ranking.Missing = prefs.Ranking.Missing
+		cfg.Ranking.Protein = prefs.Ranking.Protein
+		cfg.Ranking.Calories = prefs.Ranking.Calories
+		cfg.Ranking.Time = prefs.Ranking.Time
+	}
+	if !given["excludeIngredients"] && *excludeArg == "" && len(prefs.Disliked) > 0 {
+		*excludeArg = strings.Join(prefs.Disliked, ",")
+	}
+}
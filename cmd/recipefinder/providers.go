@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/edamam"
+	"github.com/mawojcik/meals_generator/pkg/offline"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/spoonacular"
+	"github.com/mawojcik/meals_generator/pkg/store"
+	"github.com/mawojcik/meals_generator/pkg/themealdb"
+)
+
+var (
+	providerFlag = flag.String("provider", "",
+		"Comma-separated recipe providers to try in order: spoonacular, edamam, themealdb, offline")
+	nutrientsFlag = flag.String("nutrients", "",
+		"Comma-separated nutrients to keep from Spoonacular, e.g. Calories,Fat,Fiber,Sodium, or all")
+	strict = flag.Bool("strict", false,
+		"Fail when Spoonacular returns a malformed or incomplete recipe, instead of skipping it with a warning")
+	parallel = flag.Int("parallel", recipes.DefaultParallel,
+		"How many follow-up requests for recipe details, such as TheMealDB lookups or cache refreshes, to make at once")
+)
+
+// newSpoonacular returns a Spoonacular client keeping the configured
+// nutrients, with the configured API keys in the order they are listed.
+func newSpoonacular(cfg *config.Config) *spoonacular.Client {
+	client := spoonacular.NewClient("")
+	if keys := cfg.SpoonacularKeys(); len(keys) > 0 {
+		client = spoonacular.NewClient(keys[0], keys[1:]...)
+	}
+	client.Nutrients = cfg.Nutrients
+	client.Strict = *strict
+	client.Limits.Parallel = *parallel
+	return client
+}
+
+// newProvider builds the provider chain configured in cfg. Edamam and
+// TheMealDB are experimental and need the new_providers feature; the offline
+// provider needs neither a key nor a feature so it works out of the box. The
+// Edamam and TheMealDB clients make their requests with httpClient, the
+// default client when nil.
+func newProvider(cfg *config.Config, client *spoonacular.Client, httpClient *http.Client) (recipes.RecipeProvider, error) {
+	var providers []recipes.RecipeProvider
+	for _, name := range cfg.Providers {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "spoonacular":
+			providers = append(providers, client)
+			continue
+		case "offline":
+			index, err := offlineIndex(cfg.DataDir)
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, offline.NewProvider(index))
+			continue
+		case "edamam":
+			edamamClient := edamam.NewClient(cfg.Edamam.AppID, cfg.Edamam.AppKey)
+			edamamClient.HTTPClient = httpClient
+			providers = append(providers, edamamClient)
+		case "themealdb":
+			mealDBClient := themealdb.NewClient(cfg.TheMealDB.APIKey)
+			mealDBClient.HTTPClient = httpClient
+			mealDBClient.Limits.Parallel = *parallel
+			providers = append(providers, mealDBClient)
+		default:
+			return nil, fmt.Errorf("unknown provider %q, expected spoonacular, edamam, themealdb or offline", name)
+		}
+		err := requireFeature(featureNewProviders)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if injectedFaults != nil {
+		for i := range providers {
+			providers[i] = faultyProvider{providers[i], injectedFaults}
+		}
+	}
+
+	if len(providers) == 1 {
+		return providers[0], nil
+	}
+	return &recipes.Fallback{
+		Providers: providers,
+		OnFallback: func(provider string, err error) {
+			slog.Warn("provider failed, trying the next one", "provider", provider, "error", err)
+		},
+	}, nil
+}
+
+// cacheProvider searches the recipes already cached, whatever query they were
+// found for, for --offline.
+type cacheProvider struct {
+	cache store.Store
+}
+
+func (p cacheProvider) Name() string {
+	return "cache"
+}
+
+func (p cacheProvider) Search(ctx context.Context, query recipes.Query) ([]recipes.Recipe, error) {
+	found, err := p.cache.SearchCached(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if query.Offset >= len(found) {
+		return nil, nil
+	}
+	found = found[query.Offset:]
+	return found[:min(len(found), query.NumberOfRecipes)], nil
+}
+
+// providerChain returns the providers a provider chain tries, in order.
+func providerChain(provider recipes.RecipeProvider) []recipes.RecipeProvider {
+	if fallback, ok := provider.(*recipes.Fallback); ok {
+		var chain []recipes.RecipeProvider
+		for _, chained := range fallback.Providers {
+			chain = append(chain, providerChain(chained)...)
+		}
+		return chain
+	}
+	if faulty, ok := provider.(faultyProvider); ok {
+		return providerChain(faulty.RecipeProvider)
+	}
+	return []recipes.RecipeProvider{provider}
+}
+
+// quotaLeft returns the quota reported by the Spoonacular client in the
+// provider chain, if there is one.
+func quotaLeft(provider recipes.RecipeProvider) string {
+	if fallback, ok := provider.(*recipes.Fallback); ok {
+		for _, chained := range fallback.Providers {
+			if quota := quotaLeft(chained); quota != "" {
+				return quota
+			}
+		}
+		return ""
+	}
+	if faulty, ok := provider.(faultyProvider); ok {
+		return quotaLeft(faulty.RecipeProvider)
+	}
+	if reserving, ok := provider.(quotaProvider); ok {
+		return reserving.QuotaLeft()
+	}
+	if client, ok := provider.(*spoonacular.Client); ok {
+		return client.QuotaLeft()
+	}
+	return ""
+}
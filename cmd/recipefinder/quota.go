@@ -0,0 +1,223 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/spoonacular"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+var (
+	budget = flag.Float64("budget", 0,
+		"Most Spoonacular points to spend in a day, refusing requests beyond it; 0 for no limit (overrides the config file)")
+	dryRun = flag.Bool("dry-run", false,
+		"Print the Spoonacular request a search would make and its estimated cost in points, without making it")
+)
+
+// errOverBudget is returned for the requests refused by --budget. It matches
+// spoonacular.ErrQuotaExhausted, so a fallback provider takes over.
+var errOverBudget = fmt.Errorf("%w: the daily --budget of points is spent", spoonacular.ErrQuotaExhausted)
+
+// maxReservation is how long a reservation is held when there is no timeout
+// to bound the work it is for, so points reserved by a process that died are
+// freed.
+const maxReservation = 10 * time.Minute
+
+// keyUsageDays is how many days back the points spent with each API key are
+// counted when choosing the key to start with.
+const keyUsageDays = 7
+
+// quotaReserver reserves Spoonacular points in the database around API calls,
+// see config.Quota, and records the points the API reports spent after them.
+type quotaReserver struct {
+	client *spoonacular.Client
+	cache  store.Store
+	quota  config.Quota
+	ttl    time.Duration
+}
+
+// newQuotaReserver returns nil without a cache to reserve and record points
+// in.
+func newQuotaReserver(client *spoonacular.Client, cache store.Store, cfg *config.Config) *quotaReserver {
+	if cache == nil {
+		return nil
+	}
+	ttl := maxReservation
+	if cfg.Timeout > 0 {
+		ttl = min(cfg.Timeout, maxReservation)
+	}
+	return &quotaReserver{client: client, cache: cache, quota: cfg.Quota, ttl: ttl}
+}
+
+// do runs call with points reserved, unless config.Quota.ReservePoints is
+// zero. When the quota is spoken for it fails with an error matching
+// spoonacular.ErrQuotaExhausted, so a fallback provider takes over. Failing
+// to reserve does not stop call: the coordination is a courtesy between
+// processes, not something searches depend on. The points are reserved for
+// the API key in use, and what the API reports is recorded for the key in use
+// after the call, which is another one when the first was spent meanwhile.
+func (r *quotaReserver) do(ctx context.Context, call func() error) error {
+	if r.quota.ReservePoints <= 0 {
+		callErr := call()
+		used, left := r.reported()
+		err := r.cache.RecordQuotaUsage(context.WithoutCancel(ctx), r.client.APIKey(), used, used+left)
+		if err != nil {
+			slog.Warn("error recording API quota usage", "error", err)
+		}
+		return callErr
+	}
+	id, err := r.cache.ReserveQuota(ctx, r.client.APIKey(), r.quota.ReservePoints, r.quota.DailyPoints, r.ttl)
+	if errors.Is(err, store.ErrQuotaReserved) {
+		return fmt.Errorf("%w: %w", spoonacular.ErrQuotaExhausted, err)
+	}
+	if err != nil {
+		slog.Warn("error reserving API quota", "error", err)
+		return call()
+	}
+
+	callErr := call()
+	used, left := r.reported()
+	// The reservation is released even when the call was cancelled
+	err = r.cache.ReleaseQuota(context.WithoutCancel(ctx), id, r.client.APIKey(), used, used+left)
+	if err != nil {
+		slog.Warn("error releasing API quota reservation", "error", err)
+	}
+	return callErr
+}
+
+// reported returns the points the API last reported spent today and left.
+func (r *quotaReserver) reported() (float64, float64) {
+	used, _ := strconv.ParseFloat(r.client.QuotaUsed(), 64)
+	left, _ := strconv.ParseFloat(r.client.QuotaLeft(), 64)
+	return used, left
+}
+
+// quotaBudget refuses the Spoonacular requests that would go over
+// config.Quota.BudgetPoints, counting the points spent today as the API last
+// reported them to this process or, when more, as recorded in the cache by
+// any process. The budget is per API key.
+type quotaBudget struct {
+	client *spoonacular.Client
+	cache  store.Store
+	points float64
+}
+
+// setBudget has the client refuse the requests over the configured budget,
+// if there is one.
+func setBudget(client *spoonacular.Client, cache store.Store, cfg *config.Config) {
+	if cfg.Quota.BudgetPoints > 0 {
+		client.Spend = quotaBudget{client: client, cache: cache, points: cfg.Quota.BudgetPoints}.spend
+	}
+}
+
+// spend is the client's Spend hook.
+func (b quotaBudget) spend(ctx context.Context, points float64) error {
+	used, _ := strconv.ParseFloat(b.client.QuotaUsed(), 64)
+	if b.cache != nil {
+		recorded, err := b.cache.QuotaUsage(ctx, b.client.APIKey())
+		if err != nil {
+			slog.Warn("error reading the API points spent today", "error", err)
+		}
+		used = max(used, recorded)
+	}
+	if used+points > b.points {
+		return fmt.Errorf("%w (%.2f of %g points spent, the request would cost %.2f)", errOverBudget, used,
+			b.points, points)
+	}
+	return nil
+}
+
+// quotaProvider searches Spoonacular with points reserved.
+type quotaProvider struct {
+	*spoonacular.Client
+	reserver *quotaReserver
+}
+
+func (p quotaProvider) Search(ctx context.Context, query recipes.Query) ([]recipes.Recipe, error) {
+	var found []recipes.Recipe
+	err := p.reserver.do(ctx, func() error {
+		var err error
+		found, err = p.Client.Search(ctx, query)
+		return err
+	})
+	return found, err
+}
+
+// rotateKeys orders the API keys of the client by what the cache recorded
+// spent with them: the keys spent today go last and the others least used
+// over the past keyUsageDays first, so several keys are used evenly across
+// days. Keys the API reports spent are recorded as such, for the processes
+// that start after.
+func rotateKeys(ctx context.Context, client *spoonacular.Client, cache store.Store, cfg *config.Config) {
+	keys := cfg.SpoonacularKeys()
+	if cache == nil || len(keys) < 2 {
+		return
+	}
+	usage := make(map[string]store.KeyUsage, len(keys))
+	for _, key := range keys {
+		keyUsage, err := cache.KeyUsage(ctx, key, keyUsageDays)
+		if err != nil {
+			slog.Warn("error reading API key usage, using the keys in the configured order", "error", err)
+			return
+		}
+		usage[key] = keyUsage
+	}
+	slices.SortStableFunc(keys, func(a, b string) int {
+		if usage[a].Spent() != usage[b].Spent() {
+			if usage[a].Spent() {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(usage[a].Recent, usage[b].Recent)
+	})
+	client.SetKeys(keys)
+
+	client.OnKeyExhausted = func(apiKey string, apiErr *spoonacular.APIError) {
+		used, _ := strconv.ParseFloat(apiErr.QuotaUsed, 64)
+		if used <= 0 {
+			used = cfg.Quota.DailyPoints
+		}
+		err := cache.RecordQuotaUsage(context.WithoutCancel(ctx), apiKey, used, used)
+		if err != nil {
+			slog.Warn("error recording a spent API key", "error", err)
+		}
+	}
+}
+
+// withQuota has the Spoonacular client in a provider chain reserve and record
+// points for its searches, once the cache they are reserved in is open, gives
+// it the Spend hook of the budget and orders its API keys, see rotateKeys.
+func withQuota(ctx context.Context, provider recipes.RecipeProvider, cache store.Store,
+	cfg *config.Config) recipes.RecipeProvider {
+	switch p := provider.(type) {
+	case *recipes.Fallback:
+		chained := &recipes.Fallback{Providers: make([]recipes.RecipeProvider, len(p.Providers)), OnFallback: p.OnFallback}
+		for i, each := range p.Providers {
+			chained.Providers[i] = withQuota(ctx, each, cache, cfg)
+		}
+		return chained
+	case faultyProvider:
+		p.RecipeProvider = withQuota(ctx, p.RecipeProvider, cache, cfg)
+		return p
+	case *spoonacular.Client:
+		rotateKeys(ctx, p, cache, cfg)
+		setBudget(p, cache, cfg)
+		reserver := newQuotaReserver(p, cache, cfg)
+		if reserver == nil {
+			return p
+		}
+		return quotaProvider{Client: p, reserver: reserver}
+	}
+	return provider
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+var (
+	randomTags   = flag.String("tags", "", "Comma-separated tags random recipes must all have, e.g. dinner,vegetarian")
+	randomNumber = flag.Int("number", 1, "Number of random recipes")
+)
+
+// maxRandom is the most recipes /recipes/random returns at once.
+const maxRandom = 100
+
+// runRandom implements "recipefinder random": recipes picked at random by
+// Spoonacular, printed like search results. They are cached in full, so
+// "recipefinder show" finds them later, and their ingredients are split into
+// used and missing against the pantry. Spoonacular cannot repeat its picks, so
+// with --seed they are picked from the cache instead.
+func runRandom(ctx context.Context, args []string, cfg *config.Config) error {
+	if len(args) != 0 {
+		return errors.New("usage: recipefinder random [--tags=<tag1>,...] [--number=1] [--seed=<n>] [flags]")
+	}
+	if *seed != 0 && *randomTags != "" {
+		return errors.New("--seed picks from the cache, which has no tags, it cannot be combined with --tags")
+	}
+	if *seed == 0 && len(cfg.SpoonacularKeys()) == 0 {
+		return config.ErrNoAPIKey
+	}
+	if *randomNumber < 1 || *randomNumber > maxRandom {
+		return fmt.Errorf("invalid --number %d, expected 1 to %d", *randomNumber, maxRandom)
+	}
+	outputFormat, err := lookupFormatter(*output)
+	if err != nil {
+		return err
+	}
+	err = checkAmounts()
+	if err != nil {
+		return err
+	}
+
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	var found []recipes.Recipe
+	if *seed != 0 {
+		found, err = pickCached(ctx, cache, *randomNumber, newRand())
+		if err != nil {
+			return err
+		}
+	} else {
+		client := newSpoonacular(cfg)
+		found, err = client.Random(ctx, parseTags(*randomTags), *randomNumber)
+		printQuota(client)
+		if err != nil {
+			slog.Error("random search failed", "error", err)
+			return errors.New(searchError(err, cfg.Timeout))
+		}
+		now := time.Now()
+		for i, recipe := range found {
+			recipe = recipes.Sanitize(recipe)
+			recipe.FetchedAt = now
+			if cache != nil {
+				err := cache.SaveRecipeDetails(ctx, recipe)
+				if err != nil {
+					slog.Warn("error caching recipe", "error", err)
+				}
+			}
+			found[i] = recipe
+		}
+	}
+
+	pantry := readPantry(ctx, cache)
+	for i, recipe := range found {
+		recipe.UsedIngredients, recipe.MissedIngredients = recipes.MatchIngredients(recipe.UsedIngredients, pantry)
+		found[i] = recipe
+	}
+	found = contentFilter(cfg.Filters).Apply(sourcePolicy(cfg.Sources).Apply(found))
+	if len(found) == 0 {
+		fmt.Println("No recipes found.")
+		return nil
+	}
+	found = markFavorites(ctx, cache, found)
+	found = scaledRecipes(found)
+	return outputFormat.Format(os.Stdout, found, *instructions)
+}
+
+// pickCached picks up to number cached recipes with rng and returns them with
+// their details. The same seed picks the same recipes as long as no recipes
+// are added to or purged from the cache.
+func pickCached(ctx context.Context, cache store.Store, number int, rng *rand.Rand) ([]recipes.Recipe, error) {
+	if cache == nil {
+		return nil, errors.New("cannot connect to the recipe cache, which --seed picks recipes from")
+	}
+	cached, err := cache.CachedRecipes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading cached recipes: %v", err)
+	}
+	if len(cached) == 0 {
+		return nil, errors.New("the cache has no recipes for --seed to pick from, search for some first")
+	}
+	var picked []recipes.Recipe
+	for _, i := range rng.Perm(len(cached))[:min(number, len(cached))] {
+		recipe, _, err := cache.CachedRecipe(ctx, cached[i].Source, cached[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("error reading cached recipe %d: %v", cached[i].ID, err)
+		}
+		if recipe != nil {
+			picked = append(picked, *recipe)
+		}
+	}
+	return picked, nil
+}
+
+// parseTags splits a comma-separated list of tags, lowercased.
+func parseTags(list string) []string {
+	var tags []string
+	for _, tag := range strings.Split(list, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+var (
+	record = flag.Bool("record", false,
+		"Save the raw responses of the recipe APIs to the data directory, for --replay")
+	replay = flag.Bool("replay", false,
+		"Answer the requests to the recipe APIs with the responses saved by --record instead of calling them")
+)
+
+// secretParameters are the query parameters holding API credentials. They are
+// left out of the saved responses and of their keys, so responses recorded
+// with one key replay with another, or none.
+var secretParameters = []string{"apiKey", "app_id", "app_key"}
+
+// savedResponse is a raw API response as --record saves it, gzip-compressed,
+// in a file named by responseKey.
+type savedResponse struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+// responseTransport records the responses it gets from next to dir with
+// --record, or serves the recorded ones without calling next with --replay.
+type responseTransport struct {
+	next   http.RoundTripper
+	dir    string
+	replay bool
+}
+
+// withResponses wraps a client's transport in a responseTransport, for
+// --record and --replay. A nil client stands for http.DefaultClient.
+func withResponses(client *http.Client, dir string, replay bool) *http.Client {
+	next := http.DefaultTransport
+	if client != nil && client.Transport != nil {
+		next = client.Transport
+	}
+	return &http.Client{Transport: responseTransport{next: next, dir: dir, replay: replay}}
+}
+
+func (t responseTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	file := filepath.Join(t.dir, responseKey(request)+".json.gz")
+	if t.replay {
+		saved, err := loadResponse(file)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no recorded response for %s %s, record it with --record first", request.Method,
+				redactedURL(request))
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", saved.StatusCode, http.StatusText(saved.StatusCode)),
+			StatusCode:    saved.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        saved.Header,
+			Body:          io.NopCloser(bytes.NewReader([]byte(saved.Body))),
+			ContentLength: int64(len(saved.Body)),
+			Request:       request,
+		}, nil
+	}
+
+	resp, err := t.next.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	closeErr := resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if closeErr != nil {
+		return nil, closeErr
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	err = saveResponse(file, savedResponse{
+		Method:     request.Method,
+		URL:        redactedURL(request),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       string(body),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error recording response: %v", err)
+	}
+	return resp, nil
+}
+
+// redactedURL is the URL of a request without its secretParameters.
+func redactedURL(request *http.Request) string {
+	redacted := *request.URL
+	query := redacted.Query()
+	for _, parameter := range secretParameters {
+		query.Del(parameter)
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// responseKey names the file the response to a request is saved in, a hash of
+// its method and redacted URL.
+func responseKey(request *http.Request) string {
+	sum := sha256.Sum256([]byte(request.Method + " " + redactedURL(request)))
+	return hex.EncodeToString(sum[:])
+}
+
+func saveResponse(file string, saved savedResponse) error {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	err := json.NewEncoder(writer).Encode(saved)
+	if err != nil {
+		return err
+	}
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(file), 0o700)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, compressed.Bytes(), 0o600)
+}
+
+func loadResponse(file string) (savedResponse, error) {
+	var saved savedResponse
+	f, err := os.Open(file)
+	if err != nil {
+		return saved, err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+	reader, err := gzip.NewReader(f)
+	if err != nil {
+		return saved, fmt.Errorf("error reading recorded response %s: %v", file, err)
+	}
+	err = json.NewDecoder(reader).Decode(&saved)
+	if err != nil {
+		return saved, fmt.Errorf("error reading recorded response %s: %v", file, err)
+	}
+	return saved, nil
+}
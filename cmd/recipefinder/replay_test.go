@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-API-Quota-Left", "42")
+		_, _ = io.WriteString(w, `{"results":[]}`)
+	}))
+	dir := t.TempDir()
+	get := func(client *http.Client, apiKey string) (string, string) {
+		t.Helper()
+		resp, err := client.Get(server.URL + "/recipes/complexSearch?apiKey=" + apiKey + "&query=egg")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body), resp.Header.Get("X-API-Quota-Left")
+	}
+
+	recorded, _ := get(withResponses(server.Client(), dir, false), "secret")
+	server.Close()
+	replayed, quota := get(withResponses(nil, dir, true), "")
+	if replayed != recorded || quota != "42" {
+		t.Errorf("replayed %q with quota %q, want %q with 42", replayed, quota, recorded)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("got %d recorded files (%v), want 1", len(files), err)
+	}
+	saved, err := loadResponse(filepath.Join(dir, files[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(saved.URL, "secret") {
+		t.Errorf("recorded URL %q holds the API key", saved.URL)
+	}
+
+	_, err = withResponses(nil, dir, true).Get(server.URL + "/recipes/complexSearch?query=rice")
+	if err == nil || !strings.Contains(err.Error(), "--record") {
+		t.Errorf("got %v replaying an unrecorded request, want to record it first", err)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+//go:embed report.html
+var reportHTML string
+
+// reportTemplate lays out --output=html. The page holds its CSS and images
+// itself, so it can be mailed or opened offline as a single file.
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"inc":  func(i int) int { return i + 1 },
+	"join": func(names []string) string { return strings.Join(names, ", ") },
+	"nutrient": func(recipe recipes.Recipe, name string) float64 {
+		return recipe.Nutrients[name].Amount
+	},
+	"nutrientAmount": func(recipe recipes.Recipe, name string) string {
+		nutrient := recipe.Nutrients[name]
+		return fmt.Sprintf("%.2f %s%s%s", nutrient.Amount, nutrient.Unit, dailyValue(nutrient), nutrientTotal(recipe, name))
+	},
+	"ingredientNames":  recipes.IngredientNames,
+	"missedNames":      missedNames,
+	"replacementNotes": replacementNotes,
+	"recipePrice":      recipePrice,
+	"scaledServings":   scaledServings,
+	"shortPrice":       shortPrice,
+	"formatPrice":      formatPrice,
+	"planCost":         planCost,
+	"newAisle":         newAisle,
+	"aisleHeading":     aisleHeading,
+	"shoppingAmount":   shoppingAmount,
+}).Parse(reportHTML))
+
+// htmlReport is what an HTML report shows. The plan, recipes and shopping
+// list are each left out when empty.
+type htmlReport struct {
+	Title        string
+	Generated    string
+	Plan         *recipes.Plan
+	Recipes      []reportRecipe
+	ShoppingList []recipes.ShoppingItem
+}
+
+// reportRecipe is a recipe with the image to show for it.
+type reportRecipe struct {
+	recipes.Recipe
+	// Image is the downloaded image as a data URL, or the recipe's image URL
+	// when it could not be downloaded.
+	Image template.URL
+}
+
+// htmlFormatter writes the recipes as a self-contained HTML page followed by
+// their shopping list. Instructions are always included, collapsed, so
+// withInstructions is ignored.
+type htmlFormatter struct {
+	// images are the downloaded images, by recipeKey, embedded in the page.
+	images map[string]string
+}
+
+func (f htmlFormatter) Format(w io.Writer, allRecipes []recipes.Recipe, withInstructions bool) error {
+	return writeReport(w, htmlReport{
+		Title:        "Recipes",
+		Recipes:      f.reportRecipes(allRecipes),
+		ShoppingList: convertedShoppingList(allRecipes),
+	})
+}
+
+func (htmlFormatter) FormatShoppingList(w io.Writer, items []recipes.ShoppingItem) error {
+	return writeReport(w, htmlReport{Title: "Shopping list", ShoppingList: items})
+}
+
+// writeReport renders the report, dated exportTime in UTC.
+func writeReport(w io.Writer, report htmlReport) error {
+	report.Generated = exportTime().UTC().Format("2 January 2006 15:04 MST")
+	return reportTemplate.Execute(w, report)
+}
+
+func (f htmlFormatter) reportRecipes(allRecipes []recipes.Recipe) []reportRecipe {
+	reported := make([]reportRecipe, len(allRecipes))
+	for i, recipe := range allRecipes {
+		reported[i] = reportRecipe{Recipe: recipe, Image: f.image(recipe)}
+	}
+	return reported
+}
+
+// image is the image of a recipe as a data URL, falling back to its image
+// URL when it was not downloaded. Only http and https URLs are trusted.
+func (f htmlFormatter) image(recipe recipes.Recipe) template.URL {
+	if file, ok := f.images[recipeKey(recipe)]; ok {
+		data, err := os.ReadFile(file)
+		if err == nil {
+			mimeType := mime.TypeByExtension(filepath.Ext(file))
+			if mimeType == "" {
+				mimeType = "image/jpeg"
+			}
+			return template.URL("data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data))
+		}
+		slog.Warn("error reading recipe image", "recipe", recipe.ID, "error", err)
+	}
+	imageURL, err := url.Parse(recipe.ImageURL)
+	if err != nil || (imageURL.Scheme != "http" && imageURL.Scheme != "https") {
+		return ""
+	}
+	return template.URL(imageURL.String())
+}
+
+// planReport is the report of a plan: its days, its recipes and their
+// shopping list.
+func planReport(plan recipes.Plan, images map[string]string) htmlReport {
+	allRecipes := planRecipes(plan)
+	return htmlReport{
+		Title:        "Meal plan",
+		Plan:         &plan,
+		Recipes:      htmlFormatter{images: images}.reportRecipes(allRecipes),
+		ShoppingList: convertedShoppingList(allRecipes),
+	}
+}
+
+// writePlanReport writes the planReport to --out, or to stdout when there is
+// none.
+func writePlanReport(plan recipes.Plan, images map[string]string) error {
+	return writeExport("report", func(w io.Writer) error {
+		return writeReport(w, planReport(plan, images))
+	})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+const reportUsage = `usage: recipefinder report-issue <recipeID> "<what is wrong>"`
+
+// reportTimeout bounds sending a report to the configured endpoint.
+const reportTimeout = 10 * time.Second
+
+// issueReport is what the reports endpoint receives.
+type issueReport struct {
+	RecipeID int    `json:"recipeID"`
+	Title    string `json:"title,omitempty"`
+	Source   string `json:"source,omitempty"`
+	Reason   string `json:"reason"`
+	// ReportedAt is in RFC 3339.
+	ReportedAt string `json:"reportedAt"`
+}
+
+// runReportIssue implements "recipefinder report-issue": it records the
+// report, so the recipe is screened out of later results, see
+// reportedRecipes, and sends it to the configured endpoint. A report that
+// cannot be sent is still recorded.
+func runReportIssue(ctx context.Context, args []string, cfg *config.Config) error {
+	if len(args) != 2 || strings.TrimSpace(args[1]) == "" {
+		return errors.New(reportUsage)
+	}
+	recipeID, err := strconv.Atoi(args[0])
+	if err != nil || recipeID <= 0 {
+		return fmt.Errorf("invalid recipe ID %q", args[0])
+	}
+	reason := strings.TrimSpace(args[1])
+
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache")
+	}
+
+	report := store.RecipeReport{RecipeID: recipeID, Reason: reason}
+	if cfg.Reports.Endpoint != "" {
+		err = fileReport(ctx, cfg.Reports.Endpoint, cache, report)
+		if err != nil {
+			slog.Warn("error sending report", "error", err)
+			fmt.Println("The report could not be sent, it is only kept locally")
+		}
+		report.Filed = err == nil
+	}
+	err = cache.AddRecipeReport(ctx, report)
+	if err != nil {
+		return fmt.Errorf("error recording report: %v", err)
+	}
+
+	if cfg.Reports.Mode == "flag" {
+		fmt.Printf("Reported recipe %d, it will be shown last with a warning\n", recipeID)
+	} else {
+		fmt.Printf("Reported recipe %d, it will be left out of results\n", recipeID)
+	}
+	if report.Filed {
+		fmt.Println("Sent the report to", cfg.Reports.Endpoint)
+	}
+	return nil
+}
+
+// fileReport sends a report to the endpoint, with the title and source of the
+// recipe when the cache has them.
+func fileReport(ctx context.Context, endpoint string, cache store.Store, report store.RecipeReport) error {
+	payload := issueReport{
+		RecipeID:   report.RecipeID,
+		Reason:     report.Reason,
+		ReportedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	recipe, _, err := cache.CachedRecipe(ctx, "spoonacular", report.RecipeID)
+	if err != nil {
+		slog.Warn("error reading cached recipe", "error", err)
+	}
+	if recipe != nil {
+		payload.Title = recipe.Title
+		payload.Source = recipe.Source
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error encoding report: %v", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	resp, err := (&http.Client{Timeout: reportTimeout}).Do(request)
+	if err != nil {
+		return err
+	}
+	err = resp.Body.Close()
+	if err != nil {
+		slog.Warn("error closing response body", "error", err)
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("reports endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+// reportedRecipes returns the recipes reported with report-issue, screened
+// as the config says. Without a cache nothing is reported.
+func reportedRecipes(ctx context.Context, cache store.Store, reports config.Reports) recipes.Reported {
+	reported := recipes.Reported{Exclude: reports.Mode == "exclude"}
+	if cache == nil {
+		return reported
+	}
+	all, err := cache.RecipeReports(ctx)
+	if err != nil {
+		slog.Warn("error reading recipe reports", "error", err)
+		return reported
+	}
+	reported.Reasons = make(map[int]string, len(all))
+	for _, report := range all {
+		reported.Reasons[report.RecipeID] = report.Reason
+	}
+	return reported
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+var searchName = flag.String("name", "", "Name to save the search under with search save")
+
+const savedSearchUsage = "usage: recipefinder search save --name=<name> [flags] | search run <name> [flags] | " +
+	"search list | search delete <name>"
+
+// validSearchName keeps saved search names easy to type as arguments.
+var validSearchName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// unsavedFlags are not kept by search save: they choose where and how the
+// program runs rather than what the search looks for.
+var unsavedFlags = []string{"name", "serve", "port", "interactive"}
+
+// runSavedSearches implements "recipefinder search save|list|delete".
+func runSavedSearches(ctx context.Context, args []string, cfg *config.Config) error {
+	switch args[0] {
+	case "save", "list":
+		if len(args) != 1 {
+			return errors.New(savedSearchUsage)
+		}
+	case "delete":
+		if len(args) != 2 {
+			return errors.New(savedSearchUsage)
+		}
+	default:
+		return errors.New(savedSearchUsage)
+	}
+	if args[0] == "save" && !validSearchName.MatchString(*searchName) {
+		return errors.New("search save needs --name, made of up to 64 letters, digits, dashes and underscores")
+	}
+
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache, which holds the saved searches")
+	}
+
+	switch args[0] {
+	case "save":
+		search := store.SavedSearch{Name: *searchName, Flags: make(map[string]string)}
+		commandFlags.Visit(func(f *flag.Flag) {
+			if !slices.Contains(globalFlags, f.Name) && !slices.Contains(hiddenFlags, f.Name) &&
+				!slices.Contains(unsavedFlags, f.Name) {
+				search.Flags[f.Name] = f.Value.String()
+			}
+		})
+		if len(search.Flags) == 0 {
+			return errors.New("nothing to save, give the search's flags, e.g. --ingredients")
+		}
+		err := cache.SaveSearch(ctx, search)
+		if err != nil {
+			return fmt.Errorf("error saving search: %v", err)
+		}
+		fmt.Printf("Saved search %s, run it with recipefinder search run %s\n", search.Name, search.Name)
+	case "delete":
+		found, err := cache.DeleteSavedSearch(ctx, args[1])
+		if err != nil {
+			return fmt.Errorf("error deleting saved search: %v", err)
+		}
+		if !found {
+			fmt.Printf("There is no saved search %s\n", args[1])
+			return nil
+		}
+		fmt.Printf("Deleted saved search %s\n", args[1])
+	default:
+		searches, err := cache.SavedSearches(ctx)
+		if err != nil {
+			return fmt.Errorf("error listing saved searches: %v", err)
+		}
+		if len(searches) == 0 {
+			fmt.Println("No saved searches")
+			return nil
+		}
+		for _, search := range searches {
+			fmt.Printf("%-20s  %s  %s\n", search.Name, search.UpdatedAt.In(timeZone(cfg)).Format(time.DateTime),
+				formatSavedFlags(search.Flags))
+		}
+	}
+	return nil
+}
+
+// useSavedSearch sets the flags of the search saved under name, except for
+// the ones given on the command line, which take precedence. Flags the
+// command does not accept, such as --shopping-list for watch, are skipped.
+func useSavedSearch(ctx context.Context, cfg *config.Config, name string) error {
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache, which holds the saved searches")
+	}
+	search, err := cache.SavedSearch(ctx, name)
+	if err != nil {
+		return fmt.Errorf("error reading saved search: %v", err)
+	}
+	if search == nil {
+		return fmt.Errorf("there is no saved search %s, see recipefinder search list", name)
+	}
+
+	return setSavedFlags(*search, givenFlags())
+}
+
+// givenFlags returns the names of the flags given on the command line.
+func givenFlags() map[string]bool {
+	given := make(map[string]bool)
+	commandFlags.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	return given
+}
+
+// setSavedFlags sets the flags of a saved search that the command accepts,
+// except for the given ones.
+func setSavedFlags(search store.SavedSearch, given map[string]bool) error {
+	for flagName, value := range search.Flags {
+		if given[flagName] || commandFlags.Lookup(flagName) == nil {
+			continue
+		}
+		err := commandFlags.Set(flagName, value)
+		if err != nil {
+			return fmt.Errorf("saved search %s has an invalid --%s: %v", search.Name, flagName, err)
+		}
+	}
+	return nil
+}
+
+// formatSavedFlags writes saved flags back as command line flags, sorted.
+func formatSavedFlags(flags map[string]string) string {
+	formatted := make([]string, 0, len(flags))
+	for flagName, value := range flags {
+		formatted = append(formatted, fmt.Sprintf("--%s=%s", flagName, value))
+	}
+	sort.Strings(formatted)
+	return strings.Join(formatted, " ")
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/cron"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+const scheduleUsage = "usage: recipefinder schedule [list]"
+
+// scheduledRecipes is how many recipes a scheduled search looks for when
+// neither the saved search nor the command line set --numberOfRecipes.
+const scheduledRecipes = 5
+
+// scheduledSearch is a configured schedule with when it runs next.
+type scheduledSearch struct {
+	config.Schedule
+	cron cron.Schedule
+	next time.Time
+}
+
+// runSchedule implements "recipefinder schedule": it runs the configured
+// saved searches at the times of their cron expressions and sends the
+// recipes each did not find the last time to its webhook and email
+// addresses. It runs until it is stopped. "schedule list" prints when each
+// runs next instead.
+func runSchedule(args []string, cfg *config.Config, provider recipes.RecipeProvider,
+	reporter *errorReporter) error {
+	if len(args) > 1 || len(args) == 1 && args[0] != "list" {
+		return errors.New(scheduleUsage)
+	}
+	if len(cfg.Schedules) == 0 {
+		return errors.New("no schedules are configured, see schedules in config.example.yaml")
+	}
+	scheduled, err := parseSchedules(cfg.Schedules, time.Now().In(timeZone(cfg)))
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		for _, search := range scheduled {
+			fmt.Printf("%-20s  %-16s  next %s  to %s\n", search.Search, search.Cron,
+				search.next.Format(time.DateTime), strings.Join(scheduleDestinations(search.Schedule), ", "))
+		}
+		return nil
+	}
+
+	// A schedule runs until it is stopped, not for the command timeout
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache, which holds the saved searches")
+	}
+	provider = withQuota(ctx, provider, cache, cfg)
+	// Each run starts from the flags of the command line
+	baseline := flagValues()
+	given := givenFlags()
+
+	fmt.Printf("Running %d scheduled searches\n", len(scheduled))
+	for {
+		next := scheduled[0].next
+		for _, search := range scheduled[1:] {
+			if search.next.Before(next) {
+				next = search.next
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Until(next)):
+		}
+
+		now := time.Now().In(timeZone(cfg))
+		for i := range scheduled {
+			if scheduled[i].next.After(now) {
+				continue
+			}
+			err := runScheduled(ctx, cfg, provider, cache, reporter, scheduled[i].Schedule, baseline, given)
+			if err != nil && ctx.Err() == nil {
+				// Run again at its next time
+				slog.Error("scheduled search failed", "search", scheduled[i].Search, "error", err)
+			}
+			scheduled[i].next = scheduled[i].cron.Next(now)
+		}
+	}
+}
+
+// parseSchedules parses the cron expressions of the schedules and finds when
+// each runs next after now.
+func parseSchedules(schedules []config.Schedule, now time.Time) ([]scheduledSearch, error) {
+	scheduled := make([]scheduledSearch, 0, len(schedules))
+	for _, schedule := range schedules {
+		parsed, err := cron.Parse(schedule.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule of %s: %v", schedule.Search, err)
+		}
+		next := parsed.Next(now)
+		if next.IsZero() {
+			return nil, fmt.Errorf("the schedule of %s never runs, %q matches no date", schedule.Search, schedule.Cron)
+		}
+		scheduled = append(scheduled, scheduledSearch{Schedule: schedule, cron: parsed, next: next})
+	}
+	return scheduled, nil
+}
+
+// runScheduled runs a saved search with the flags it was saved with and
+// sends the recipes it did not find the last time it ran, all of them the
+// first time.
+func runScheduled(ctx context.Context, cfg *config.Config, provider recipes.RecipeProvider, cache store.Store,
+	reporter *errorReporter, schedule config.Schedule, baseline map[string]string, given map[string]bool) error {
+	search, err := cache.SavedSearch(ctx, schedule.Search)
+	if err != nil {
+		return fmt.Errorf("error reading saved search: %v", err)
+	}
+	if search == nil {
+		return fmt.Errorf("there is no saved search %s, see recipefinder search list", schedule.Search)
+	}
+	err = restoreFlags(baseline)
+	if err != nil {
+		return err
+	}
+	err = setSavedFlags(*search, given)
+	if err != nil {
+		return err
+	}
+	count := *numberOfRecipes
+	if count == 0 {
+		count = scheduledRecipes
+	}
+	query, err := parseArguments(count, cfg.Allergens)
+	if err != nil {
+		return fmt.Errorf("saved search %s: %v", schedule.Search, err)
+	}
+	screen, err := parseScreening()
+	if err != nil {
+		return fmt.Errorf("saved search %s: %v", schedule.Search, err)
+	}
+
+	found, err := screenedSearch(ctx, cfg, provider, cache, reporter, screen, query)
+	if err != nil {
+		return err
+	}
+	previous, err := cache.LastSnapshot(ctx, query)
+	if err != nil {
+		return fmt.Errorf("error reading the previous results: %v", err)
+	}
+	err = cache.SaveSnapshot(ctx, query, found)
+	if err != nil {
+		return fmt.Errorf("error saving the results: %v", err)
+	}
+	fresh := found
+	if previous != nil {
+		fresh = recipes.DiffResults(previous.Recipes, found).Added
+	}
+	if len(fresh) == 0 {
+		slog.Info("no new recipes for the scheduled search", "search", schedule.Search)
+		return nil
+	}
+	sendNotification(ctx, scheduleNotifiers(cfg, schedule), notification{
+		Event:   store.EventNewMatches,
+		Message: fmt.Sprintf("Here's what you can cook: %d new recipes for %s", len(fresh), schedule.Search),
+		Recipes: fresh,
+	})
+	return nil
+}
+
+// scheduleNotifiers returns the channels a schedule sends its results
+// through, printing to stdout first.
+func scheduleNotifiers(cfg *config.Config, schedule config.Schedule) []notifier {
+	notifiers := []notifier{stdoutNotifier{location: timeZone(cfg)}}
+	if schedule.WebhookURL != "" {
+		notifiers = append(notifiers, webhookNotifier{
+			url:     schedule.WebhookURL,
+			secrets: cfg.Notifications.WebhookSecrets,
+			client:  &http.Client{Timeout: 10 * time.Second},
+		})
+	}
+	if len(schedule.Email) > 0 {
+		notifiers = append(notifiers, emailNotifier{server: cfg.Email, to: schedule.Email})
+	}
+	return notifiers
+}
+
+// scheduleDestinations describes where a schedule sends its results.
+func scheduleDestinations(schedule config.Schedule) []string {
+	var destinations []string
+	if schedule.WebhookURL != "" {
+		destinations = append(destinations, schedule.WebhookURL)
+	}
+	return append(destinations, schedule.Email...)
+}
+
+// flagValues returns the values of the command's flags, by name, except for
+// the global ones, which saved searches do not set.
+func flagValues() map[string]string {
+	values := make(map[string]string)
+	commandFlags.VisitAll(func(f *flag.Flag) {
+		if !slices.Contains(globalFlags, f.Name) && !slices.Contains(hiddenFlags, f.Name) {
+			values[f.Name] = f.Value.String()
+		}
+	})
+	return values
+}
+
+// restoreFlags sets the command's flags back to values from flagValues.
+func restoreFlags(values map[string]string) error {
+	for flagName, value := range values {
+		err := commandFlags.Set(flagName, value)
+		if err != nil {
+			return fmt.Errorf("error restoring --%s: %v", flagName, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// screening is how the commands that search repeatedly order and narrow down
+// what they find, the way a single search does, parsed from the flags once.
+type screening struct {
+	ruleSets        []recipes.RuleSet
+	formPreferences recipes.FormPreferences
+}
+
+func parseScreening() (screening, error) {
+	err := recipes.CheckSortOrder(*sortOrder)
+	if err != nil {
+		return screening{}, err
+	}
+	ruleSets, err := selectedRuleSets()
+	if err != nil {
+		return screening{}, err
+	}
+	formPreferences, err := recipes.ParseDislikedForms(*dislikedForms)
+	if err != nil {
+		return screening{}, err
+	}
+	return screening{ruleSets: ruleSets, formPreferences: formPreferences}, nil
+}
+
+// rankWeights are the weights of the "score" order the config file and the
+// --rank* flags set.
+func rankWeights(ranking config.Ranking) recipes.RankWeights {
+	return recipes.RankWeights{
+		Missing:       ranking.Missing,
+		Protein:       ranking.Protein,
+		Calories:      ranking.Calories,
+		Time:          ranking.Time,
+		CalorieTarget: ranking.CalorieTarget,
+	}
+}
+
+// apply sorts and screens the found recipes and keeps the first count.
+func (s screening) apply(allRecipes []recipes.Recipe, cfg *config.Config, reported recipes.Reported,
+	count int) []recipes.Recipe {
+	allRecipes = contentFilter(cfg.Filters).Apply(allRecipes)
+	recipes.SortRecipes(allRecipes, *sortOrder, rankWeights(cfg.Ranking))
+	allRecipes = regionAvailability(cfg.Region).Apply(allRecipes)
+	allRecipes = reported.Apply(allRecipes)
+	for _, ruleSet := range s.ruleSets {
+		allRecipes = ruleSet.Apply(allRecipes)
+	}
+	allRecipes = s.formPreferences.Apply(allRecipes)
+	allRecipes = recipes.MaxPrice(allRecipes, recipes.DollarsToMoney(*maxPrice))
+	allRecipes = recipes.MinHealthScore(allRecipes, *minHealthScore)
+	if len(allRecipes) > count {
+		allRecipes = allRecipes[:count]
+	}
+	return allRecipes
+}
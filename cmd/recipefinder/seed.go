@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"math/rand/v2"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+var seed = flag.Uint64("seed", 0, "Seed for the random choices of plan, random and preferences quiz, the same "+
+	"seed making the same choices again; 0 chooses differently every time")
+
+// newRand returns the generator for random choices, seeded with --seed when
+// it is given.
+func newRand() *rand.Rand {
+	if *seed != 0 {
+		return rand.New(rand.NewPCG(*seed, *seed))
+	}
+	return rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+}
+
+// shuffleTies shuffles each run of recipes missing the same number of
+// ingredients, keeping the runs in place, so that recipes sorted by what they
+// miss stay sorted.
+func shuffleTies(allRecipes []recipes.Recipe, rng *rand.Rand) {
+	for start := 0; start < len(allRecipes); {
+		end := start + 1
+		for end < len(allRecipes) && len(allRecipes[end].MissedIngredients) == len(allRecipes[start].MissedIngredients) {
+			end++
+		}
+		tie := allRecipes[start:end]
+		rng.Shuffle(len(tie), func(i, j int) {
+			tie[i], tie[j] = tie[j], tie[i]
+		})
+		start = end
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+func TestShuffleTies(t *testing.T) {
+	missing := func(id int, count int) recipes.Recipe {
+		return recipes.Recipe{ID: id, MissedIngredients: make([]recipes.Ingredient, count)}
+	}
+	var sorted []recipes.Recipe
+	for id := 1; id <= 20; id++ {
+		sorted = append(sorted, missing(id, id/10))
+	}
+	shuffled := func(seed uint64) []int {
+		allRecipes := slices.Clone(sorted)
+		shuffleTies(allRecipes, rand.New(rand.NewPCG(seed, seed)))
+		var ids []int
+		for i, recipe := range allRecipes {
+			if recipe.ID/10 != sorted[i].ID/10 {
+				t.Fatalf("seed %d moved recipe %d out of its tie", seed, recipe.ID)
+			}
+			ids = append(ids, recipe.ID)
+		}
+		return ids
+	}
+
+	first := shuffled(42)
+	if !slices.Equal(shuffled(42), first) {
+		t.Errorf("seed 42 shuffled differently the second time")
+	}
+	if slices.Equal(shuffled(43), first) {
+		t.Errorf("seeds 42 and 43 shuffled the same: %v", first)
+	}
+}
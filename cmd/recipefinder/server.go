@@ -0,0 +1,491 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/spoonacular"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+var (
+	serve    = flag.Bool("serve", false, "Deprecated, use the serve command instead")
+	port     = flag.Int("port", 8080, "Port the HTTP server listens on")
+	ui       = flag.Bool("ui", true, "Serve a web UI for searching at / alongside the REST API")
+	grpcPort = flag.Int("grpc-port", 0,
+		"Port to also serve the gRPC API of proto/recipefinder/v1 on, 0 to not serve it")
+)
+
+// serverAPIVersion is the version of the REST API /capabilities reports. It
+// only changes when existing endpoints change incompatibly; new endpoints are
+// listed in the capabilities instead.
+const serverAPIVersion = 1
+
+// maxServerRecipes bounds the number of recipes a request may ask for: every
+// recipes.PageSize of them is another search upstream, all made at once.
+const maxServerRecipes = 5 * recipes.PageSize
+
+const (
+	shutdownTimeout = 10 * time.Second
+	// healthTimeout bounds the checks of /healthz.
+	healthTimeout = 5 * time.Second
+)
+
+type recipeServer struct {
+	provider     recipes.RecipeProvider
+	cache        store.Store
+	reporter     *errorReporter
+	availability recipes.Availability
+	reports      config.Reports
+	sources      config.Sources
+	content      recipes.ContentFilter
+	// allergens are excluded from every search, see config.Config.
+	allergens []string
+	// ranking weighs the "score" order, the default one.
+	ranking recipes.RankWeights
+	// timeout bounds each request's search, zero disables it.
+	timeout time.Duration
+	// jobs work on background jobs while serving, nil without a cache.
+	jobs *jobWorkers
+	// metrics are served on /metrics, nil to not collect any.
+	metrics *serverMetrics
+	// ui serves the web UI on /.
+	ui bool
+	// spoonacular fetches the recipe details of the gRPC GetRecipe, nil
+	// without an API key.
+	spoonacular *spoonacular.Client
+	// grpcPort is the port the gRPC API is served on, 0 to not serve it.
+	grpcPort int
+}
+
+// runServer serves the REST API, and the gRPC API when srv has a port for it,
+// and works on queued jobs until SIGINT or SIGTERM, then gives in-flight
+// requests shutdownTimeout to finish.
+func runServer(port int, srv *recipeServer) error {
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           srv.recoverPanics(logRequests(srv.routes())),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("listening", "addr", server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+	// grpcErr stays nil, never ready, without the gRPC API
+	var grpcErr chan error
+	var rpcServer *grpc.Server
+	if srv.grpcPort > 0 {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", srv.grpcPort))
+		if err != nil {
+			return fmt.Errorf("error listening for gRPC: %v", err)
+		}
+		rpcServer = newGRPCServer(srv)
+		defer rpcServer.Stop()
+		grpcErr = make(chan error, 1)
+		go func() {
+			slog.Info("listening for gRPC", "addr", listener.Addr().String())
+			grpcErr <- rpcServer.Serve(listener)
+		}()
+	}
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		if srv.jobs != nil {
+			srv.jobs.run(ctx)
+		}
+	}()
+	// The current job finishes before the server returns
+	defer func() {
+		stop()
+		<-workerDone
+	}()
+
+	select {
+	case err := <-serverErr:
+		return err
+	case err := <-grpcErr:
+		return fmt.Errorf("gRPC server failed: %v", err)
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if rpcServer != nil {
+		// GracefulStop waits for every call, so calls still running at the
+		// deadline are cut short by the deferred Stop
+		stopped := make(chan struct{})
+		go func() {
+			rpcServer.GracefulStop()
+			close(stopped)
+		}()
+		defer func() {
+			select {
+			case <-stopped:
+			case <-shutdownCtx.Done():
+			}
+		}()
+	}
+	err := server.Shutdown(shutdownCtx)
+	if err != nil {
+		return fmt.Errorf("error shutting down server: %v", err)
+	}
+	err = <-serverErr
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// routes are the endpoints of the REST API, and the web UI when s serves it.
+func (s *recipeServer) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /recipes", s.handleRecipes)
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("GET /capabilities", s.handleCapabilities)
+	if s.metrics != nil {
+		mux.Handle("GET /metrics", s.metrics.registry.Handler())
+	}
+	if s.ui {
+		mux.Handle("GET /", webUI())
+	}
+	return mux
+}
+
+func (s *recipeServer) handleRecipes(w http.ResponseWriter, r *http.Request) {
+	ingredients := r.URL.Query().Get("ingredients")
+	if ingredients == "" {
+		writeJSONError(w, http.StatusBadRequest, "ingredients parameter is required")
+		return
+	}
+	ingredientList, notes, err := recipes.CleanIngredientList(strings.Split(ingredients, ","))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if r.URL.Query().Get("fuzzy") == "true" {
+		var corrections []string
+		ingredientList, corrections = recipes.CorrectSpelling(ingredientList)
+		notes = append(notes, corrections...)
+	}
+	for _, note := range notes {
+		slog.Info("corrected ingredients", "note", note)
+	}
+	numberOfRecipes, err := strconv.Atoi(r.URL.Query().Get("number"))
+	if err != nil || numberOfRecipes <= 0 {
+		writeJSONError(w, http.StatusBadRequest, "number must be a positive integer")
+		return
+	}
+	if numberOfRecipes > maxServerRecipes {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("number must be at most %d", maxServerRecipes))
+		return
+	}
+
+	diets, err := recipes.NormalizeDiets(r.URL.Query().Get("diet"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	intolerances, err := recipes.NormalizeIntolerances(r.URL.Query().Get("intolerances"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var ruleSets []recipes.RuleSet
+	if name := r.URL.Query().Get("religiousDiet"); name != "" {
+		found, err := recipes.LookupReligiousDiet(name)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		ruleSets = append(ruleSets, found)
+	}
+	if r.URL.Query().Get("noAlcohol") == "true" {
+		ruleSets = append(ruleSets, recipes.NoAlcohol)
+	}
+	if r.URL.Query().Get("lowFODMAP") == "true" {
+		ruleSets = append(ruleSets, recipes.LowFODMAP)
+	}
+	if r.URL.Query().Get("pregnancySafe") == "true" {
+		ruleSets = append(ruleSets, recipes.Pregnancy)
+	}
+	formPreferences, err := recipes.ParseDislikedForms(r.URL.Query().Get("dislikedForms"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var targets recipes.NutritionTargets
+	for name, target := range map[string]*float64{
+		"maxCalories": &targets.MaxCalories,
+		"minProtein":  &targets.MinProtein,
+		"maxCarbs":    &targets.MaxCarbs,
+	} {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			continue
+		}
+		*target, err = strconv.ParseFloat(value, 64)
+		if err != nil || *target < 0 {
+			writeJSONError(w, http.StatusBadRequest, name+" must be a non-negative number")
+			return
+		}
+	}
+	prep := recipes.PrepLimits{
+		Equipment:   recipes.NormalizeEquipment(r.URL.Query().Get("equipment")),
+		NoEquipment: recipes.NormalizeEquipment(r.URL.Query().Get("noEquipment")),
+	}
+	if value := r.URL.Query().Get("maxReadyTime"); value != "" {
+		prep.MaxReadyTime, err = strconv.Atoi(value)
+		if err != nil || prep.MaxReadyTime < 0 {
+			writeJSONError(w, http.StatusBadRequest, "maxReadyTime must be a non-negative number of minutes")
+			return
+		}
+	}
+	var servings int
+	if value := r.URL.Query().Get("servings"); value != "" {
+		servings, err = strconv.Atoi(value)
+		if err != nil || servings < 0 {
+			writeJSONError(w, http.StatusBadRequest, "servings must be a non-negative number")
+			return
+		}
+	}
+	var maxPrice recipes.Money
+	if value := r.URL.Query().Get("maxPricePerServing"); value != "" {
+		dollars, err := strconv.ParseFloat(value, 64)
+		if err != nil || dollars < 0 {
+			writeJSONError(w, http.StatusBadRequest, "maxPricePerServing must be a non-negative number")
+			return
+		}
+		maxPrice = recipes.DollarsToMoney(dollars)
+	}
+	order := r.URL.Query().Get("sort")
+	if order == "" {
+		order = "score"
+	}
+	err = recipes.CheckSortOrder(order)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	query := recipes.Query{
+		Ingredients:     ingredientList,
+		NumberOfRecipes: numberOfRecipes,
+		Diets:           diets,
+		Intolerances:    intolerances,
+		Exclude:         recipes.NormalizeExclusions(r.URL.Query().Get("excludeIngredients"), s.allergens),
+		Targets:         targets,
+		Prep:            prep,
+	}
+	allRecipes, err := s.search(r.Context(), query, searchOptions{
+		order:           order,
+		ruleSets:        ruleSets,
+		formPreferences: formPreferences,
+		maxPrice:        maxPrice,
+		servings:        servings,
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		writeJSONError(w, http.StatusGatewayTimeout, "search timed out")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusBadGateway, "problem fetching recipes from API")
+		return
+	}
+	writeJSON(w, http.StatusOK, allRecipes)
+}
+
+// searchOptions screen, order and scale the recipes a server search finds,
+// besides its query.
+type searchOptions struct {
+	order           string
+	ruleSets        []recipes.RuleSet
+	formPreferences recipes.FormPreferences
+	maxPrice        recipes.Money
+	servings        int
+}
+
+// search finds the recipes of a query then screens, orders and scales them,
+// the same for the REST and the gRPC API. Failed searches are logged and
+// reported.
+func (s *recipeServer) search(ctx context.Context, query recipes.Query, options searchOptions) ([]recipes.Recipe, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	finder := newFinder(s.provider, s.cache, s.reporter, query.Ingredients, s.sources)
+	if countLookup := finder.OnCacheLookup; s.metrics != nil && countLookup != nil {
+		finder.OnCacheLookup = func(hit bool) {
+			countLookup(hit)
+			s.metrics.cacheLookup(hit)
+		}
+	}
+	allRecipes, err := finder.Find(ctx, query)
+	if err != nil {
+		slog.Error("search failed", "error", err)
+		s.reporter.captureError(err, newErrorContext(s.provider, query.Ingredients))
+		return nil, err
+	}
+
+	tieBreakOrder(ctx, s.cache, allRecipes)
+	allRecipes = s.content.Apply(allRecipes)
+	recipes.SortRecipes(allRecipes, options.order, s.ranking)
+	allRecipes = s.availability.Apply(allRecipes)
+	allRecipes = reportedRecipes(ctx, s.cache, s.reports).Apply(allRecipes)
+	for _, ruleSet := range options.ruleSets {
+		allRecipes = ruleSet.Apply(allRecipes)
+	}
+	allRecipes = options.formPreferences.Apply(allRecipes)
+	allRecipes = recipes.MaxPrice(allRecipes, options.maxPrice)
+	if len(allRecipes) > query.NumberOfRecipes {
+		allRecipes = allRecipes[:query.NumberOfRecipes]
+	}
+	return recipes.ScaleServings(allRecipes, options.servings), nil
+}
+
+// health is the response of /healthz: "ok" or why a check failed, for the
+// database and each provider.
+type health struct {
+	Status    string            `json:"status"`
+	Database  string            `json:"database"`
+	Providers map[string]string `json:"providers"`
+}
+
+// handleHealth checks that the database and the providers can be reached. It
+// responds 503 when the database cannot, or none of the providers can.
+func (s *recipeServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthTimeout)
+	defer cancel()
+	result := health{Status: "ok", Database: "ok", Providers: make(map[string]string)}
+	healthy := true
+	if s.cache == nil {
+		result.Database, healthy = "not connected", false
+	} else if err := s.cache.Ping(ctx); err != nil {
+		result.Database, healthy = err.Error(), false
+	}
+	reachable := false
+	for _, provider := range providerChain(s.provider) {
+		result.Providers[provider.Name()] = "ok"
+		if pinger, ok := provider.(interface{ Ping(context.Context) error }); ok {
+			if err := pinger.Ping(ctx); err != nil {
+				result.Providers[provider.Name()] = err.Error()
+				continue
+			}
+		}
+		reachable = true
+	}
+	status := http.StatusOK
+	if !healthy || !reachable {
+		result.Status, status = "unavailable", http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, result)
+}
+
+// capabilities describe what a server offers, for clients to find out which
+// requests they can make rather than meeting 404s.
+type capabilities struct {
+	APIVersion int      `json:"apiVersion"`
+	Endpoints  []string `json:"endpoints"`
+	Providers  []string `json:"providers"`
+	// Cache and Jobs report whether the server has a recipe cache and works
+	// on background jobs, UI whether it serves the web UI on /.
+	Cache bool `json:"cache"`
+	Jobs  bool `json:"jobs"`
+	UI    bool `json:"ui"`
+	// GRPCPort is the port of the gRPC API, 0 when it is not served.
+	GRPCPort int `json:"grpcPort,omitempty"`
+}
+
+func (s *recipeServer) capabilities() capabilities {
+	result := capabilities{
+		APIVersion: serverAPIVersion,
+		Endpoints:  []string{"/recipes", "/healthz", "/capabilities"},
+		Providers:  []string{},
+		Cache:      s.cache != nil,
+		Jobs:       s.jobs != nil,
+		UI:         s.ui,
+		GRPCPort:   s.grpcPort,
+	}
+	if s.metrics != nil {
+		result.Endpoints = append(result.Endpoints, "/metrics")
+	}
+	for _, provider := range providerChain(s.provider) {
+		result.Providers = append(result.Providers, provider.Name())
+	}
+	return result
+}
+
+func (s *recipeServer) handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.capabilities())
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(value)
+	if err != nil {
+		slog.Error("error writing response", "error", err)
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// statusRecorder remembers the status code written by a handler so it can be
+// logged after the request completes.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		slog.Info("request", "method", r.Method, "uri", r.URL.RequestURI(), "status", recorder.status,
+			"duration", time.Since(start))
+	})
+}
+
+// recoverPanics turns a panicking handler into a 500 response and reports the
+// panic, instead of letting net/http log it and drop the connection.
+func (s *recipeServer) recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				slog.Error("panic serving request", "path", r.URL.Path, "panic", recovered)
+				s.reporter.capturePanic(recovered, errorContext{Provider: s.provider.Name(), QuotaLeft: quotaLeft(s.provider)})
+				writeJSONError(w, http.StatusInternalServerError, "internal server error")
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// serveTest serves the request for target with srv's routes and returns the
+// response.
+func serveTest(t *testing.T, srv *recipeServer, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	response := httptest.NewRecorder()
+	srv.recoverPanics(srv.routes()).ServeHTTP(response, httptest.NewRequest(http.MethodGet, target, nil))
+	return response
+}
+
+func TestServerRecipes(t *testing.T) {
+	srv := &recipeServer{provider: stubProvider{recipes: testRecipes(t)}}
+
+	response := serveTest(t, srv, "/recipes?ingredients=pasta,garlic&number=2&sort=missing")
+	if response.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", response.Code, response.Body)
+	}
+	if got := response.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("got Content-Type %q, want application/json", got)
+	}
+	var found []map[string]any
+	err := json.Unmarshal(response.Body.Bytes(), &found)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 {
+		t.Fatalf("got %d recipes, want 2", len(found))
+	}
+	for _, field := range []string{"id", "title", "usedIngredients", "missedIngredients", "nutrients"} {
+		if _, ok := found[0][field]; !ok {
+			t.Errorf("recipe has no %q field: %v", field, found[0])
+		}
+	}
+
+	// Decoded as a recipe, the first one round-trips with its nutrients
+	var typed []recipes.Recipe
+	err = json.Unmarshal(response.Body.Bytes(), &typed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typed[0].ID <= 0 || typed[0].Title == "" || typed[0].Nutrients["Calories"].Amount <= 0 {
+		t.Errorf("got %+v, want a titled recipe with its calories", typed[0])
+	}
+}
+
+func TestServerRecipesBadRequest(t *testing.T) {
+	srv := &recipeServer{provider: stubProvider{recipes: testRecipes(t)}}
+
+	for name, target := range map[string]string{
+		"no ingredients":   "/recipes?number=1",
+		"no number":        "/recipes?ingredients=egg",
+		"zero number":      "/recipes?ingredients=egg&number=0",
+		"too many":         fmt.Sprintf("/recipes?ingredients=egg&number=%d", maxServerRecipes+1),
+		"unknown diet":     "/recipes?ingredients=egg&number=1&diet=carnivore",
+		"negative target":  "/recipes?ingredients=egg&number=1&maxCalories=-1",
+		"bad ready time":   "/recipes?ingredients=egg&number=1&maxReadyTime=soon",
+		"negative price":   "/recipes?ingredients=egg&number=1&maxPricePerServing=-2",
+		"unknown sort":     "/recipes?ingredients=egg&number=1&sort=color",
+		"unknown religion": "/recipes?ingredients=egg&number=1&religiousDiet=pastafarian",
+	} {
+		response := serveTest(t, srv, target)
+		if response.Code != http.StatusBadRequest {
+			t.Errorf("%s: got status %d, want 400", name, response.Code)
+			continue
+		}
+		var body map[string]string
+		err := json.Unmarshal(response.Body.Bytes(), &body)
+		if err != nil || body["error"] == "" {
+			t.Errorf("%s: got %q, want a JSON error", name, response.Body)
+		}
+	}
+}
+
+func TestServerCapabilities(t *testing.T) {
+	srv := &recipeServer{provider: stubProvider{}, ui: true, grpcPort: 9090}
+
+	response := serveTest(t, srv, "/capabilities")
+	if response.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", response.Code, response.Body)
+	}
+	var got capabilities
+	err := json.Unmarshal(response.Body.Bytes(), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.APIVersion != serverAPIVersion || strings.Join(got.Endpoints, ",") != "/recipes,/healthz,/capabilities" ||
+		strings.Join(got.Providers, ",") != "stub" || got.Cache || got.Jobs || !got.UI || got.GRPCPort != 9090 {
+		t.Errorf("got %+v", got)
+	}
+
+	// Without a gRPC API there is no grpcPort at all
+	response = serveTest(t, &recipeServer{provider: stubProvider{}}, "/capabilities")
+	if strings.Contains(response.Body.String(), "grpcPort") {
+		t.Errorf("got %s, want no grpcPort", response.Body)
+	}
+}
+
+func TestServerWebUI(t *testing.T) {
+	response := serveTest(t, &recipeServer{provider: stubProvider{}, ui: true}, "/")
+	if response.Code != http.StatusOK {
+		t.Fatalf("got status %d", response.Code)
+	}
+	if got := response.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/html") {
+		t.Errorf("got Content-Type %q, want text/html", got)
+	}
+	if !strings.Contains(response.Body.String(), "<title>Recipe finder</title>") {
+		t.Errorf("/ does not serve the embedded index.html")
+	}
+
+	response = serveTest(t, &recipeServer{provider: stubProvider{}}, "/")
+	if response.Code != http.StatusNotFound {
+		t.Errorf("got status %d without the UI, want 404", response.Code)
+	}
+}
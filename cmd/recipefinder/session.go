@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+var saveSession = flag.String("save-session", "",
+	"Keep the printed recipes under this name, to pick from and act on later with recipefinder session")
+
+const sessionUsage = "usage: recipefinder session list | session <name> [show] | session <name> pick <n1>,... | " +
+	"session <name> shopping-list | session <name> instructions | session <name> nutrition | session <name> delete"
+
+// checkSaveSession rejects a --save-session name that is not valid, before
+// any search is made. Session names follow the saved search ones.
+func checkSaveSession() error {
+	if *saveSession != "" && !validSearchName.MatchString(*saveSession) {
+		return errors.New("--save-session needs a name made of up to 64 letters, digits, dashes and underscores")
+	}
+	return nil
+}
+
+// recordSession keeps the printed recipes under --save-session. Failing to
+// is reported but does not fail the search, whose results are printed.
+func recordSession(ctx context.Context, cache store.Store, allRecipes []recipes.Recipe) {
+	if *saveSession == "" {
+		return
+	}
+	if cache == nil {
+		fmt.Fprintln(os.Stderr, "Cannot save the session without the recipe cache")
+		return
+	}
+	err := cache.SaveSession(ctx, *saveSession, allRecipes)
+	if err != nil {
+		slog.Error("error saving session", "error", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Saved %d recipes as session %s, pick from them with recipefinder session %s pick <n1>,...\n",
+		len(allRecipes), *saveSession, *saveSession)
+}
+
+// runSession implements "recipefinder session": the recipes saved by a
+// search with --save-session are listed, picked from, and the picks' shopping
+// list, instructions or nutrition printed, without searching again.
+func runSession(ctx context.Context, args []string, cfg *config.Config) error {
+	if len(args) == 0 || len(args) > 3 {
+		return errors.New(sessionUsage)
+	}
+	name, action := args[0], "show"
+	if len(args) > 1 {
+		action = args[1]
+	}
+	var picks []int
+	switch {
+	case len(args) == 1 && name == "list":
+		action = "list"
+	case len(args) == 3 && action == "pick":
+		var err error
+		picks, err = parsePicks(args[2])
+		if err != nil {
+			return err
+		}
+	case len(args) <= 2 && slices.Contains([]string{"show", "shopping-list", "instructions", "nutrition", "delete"}, action):
+	default:
+		return errors.New(sessionUsage)
+	}
+	if *export != "" && action != "shopping-list" {
+		return errors.New("--export applies to session <name> shopping-list")
+	}
+	if _, ok := exporters[*export]; *export != "" && !ok {
+		return fmt.Errorf("unknown export format %q, expected ics, todo or csv", *export)
+	}
+
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache, which holds the sessions")
+	}
+
+	switch action {
+	case "list":
+		return listSessions(ctx, cache, timeZone(cfg))
+	case "delete":
+		found, err := cache.DeleteSession(ctx, name)
+		if err != nil {
+			return fmt.Errorf("error deleting session: %v", err)
+		}
+		if !found {
+			fmt.Printf("There is no session %s\n", name)
+			return nil
+		}
+		fmt.Printf("Deleted session %s\n", name)
+		return nil
+	}
+
+	session, err := cache.Session(ctx, name)
+	if err != nil {
+		return fmt.Errorf("error reading session: %v", err)
+	}
+	if session == nil {
+		return fmt.Errorf("there is no session %s, save one with search --save-session=%s", name, name)
+	}
+	switch action {
+	case "pick":
+		return pickSession(ctx, cache, *session, picks, dailyGoals(cfg))
+	case "shopping-list", "instructions", "nutrition":
+		picked := session.Picked()
+		if len(picked) == 0 {
+			return fmt.Errorf("no recipes are picked from session %s yet, pick them with recipefinder session %s pick <n1>,...",
+				name, name)
+		}
+		if action == "nutrition" {
+			printNutritionSummary(os.Stdout, recipes.SummarizeNutrition(picked, 1, dailyGoals(cfg)))
+			return nil
+		}
+		if action == "shopping-list" && *export != "" {
+			return exportShoppingList(convertedShoppingList(picked))
+		}
+		f, err := lookupFormatter(*output)
+		if err != nil {
+			return err
+		}
+		if action == "shopping-list" {
+			return f.FormatShoppingList(os.Stdout, convertedShoppingList(picked))
+		}
+		return f.Format(os.Stdout, picked, true)
+	default:
+		printSession(*session, timeZone(cfg))
+		return nil
+	}
+}
+
+// parsePicks parses the comma-separated positions, from 1, of the recipes to
+// pick, dropping repeats.
+func parsePicks(arg string) ([]int, error) {
+	var picks []int
+	for _, field := range strings.Split(arg, ",") {
+		position, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || position <= 0 {
+			return nil, fmt.Errorf("invalid recipe number %q, expected numbers from 1 as session <name> lists them", field)
+		}
+		if !slices.Contains(picks, position) {
+			picks = append(picks, position)
+		}
+	}
+	return picks, nil
+}
+
+// pickSession records the picks of a session, then prints them with their
+// nutrition, as the meals of a day.
+func pickSession(ctx context.Context, cache store.Store, session store.Session, picks []int,
+	goals recipes.DailyGoals) error {
+	for _, position := range picks {
+		if position > len(session.Recipes) {
+			return fmt.Errorf("session %s has %d recipes, there is no recipe %d", session.Name, len(session.Recipes),
+				position)
+		}
+	}
+	_, err := cache.PickSession(ctx, session.Name, picks)
+	if err != nil {
+		return fmt.Errorf("error picking recipes: %v", err)
+	}
+	session.Picks = picks
+	fmt.Printf("Picked from session %s:\n", session.Name)
+	for _, recipe := range session.Picked() {
+		fmt.Printf("- %s\n", recipe.Title)
+	}
+	fmt.Println()
+	printNutritionSummary(os.Stdout, recipes.SummarizeNutrition(session.Picked(), 1, goals))
+	return nil
+}
+
+// printSession lists the recipes of a session by the numbers they are picked
+// with, the picked ones marked.
+func printSession(session store.Session, location *time.Location) {
+	fmt.Printf("Session %s, updated %s:\n", session.Name, session.UpdatedAt.In(location).Format(time.DateTime))
+	for i, recipe := range session.Recipes {
+		mark := " "
+		if slices.Contains(session.Picks, i+1) {
+			mark = "*"
+		}
+		fmt.Printf("%s %2d. %s (missing %d)\n", mark, i+1, recipe.Title, len(recipe.MissedIngredients))
+	}
+	if len(session.Picks) == 0 {
+		fmt.Printf("Nothing picked yet, pick with recipefinder session %s pick <n1>,...\n", session.Name)
+	}
+}
+
+func listSessions(ctx context.Context, cache store.Store, location *time.Location) error {
+	sessions, err := cache.Sessions(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing sessions: %v", err)
+	}
+	if len(sessions) == 0 {
+		fmt.Println("No sessions yet, save one with search --save-session=<name>")
+		return nil
+	}
+	for _, session := range sessions {
+		fmt.Printf("%s  %d recipes, %d picked  %s\n", session.Name, len(session.Recipes), len(session.Picks),
+			session.UpdatedAt.In(location).Format(time.DateTime))
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/spoonacular"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+const showUsage = "usage: recipefinder show <recipeID>"
+
+// runShow implements "recipefinder show". The details are read from the cache
+// while they are fresh, and fetched from Spoonacular and cached otherwise. When
+// they cannot be fetched, whatever the cache holds on the recipe is shown.
+func runShow(ctx context.Context, args []string, cfg *config.Config) error {
+	if len(args) != 1 {
+		return errors.New(showUsage)
+	}
+	recipeID, err := strconv.Atoi(args[0])
+	if err != nil || recipeID <= 0 {
+		return fmt.Errorf("invalid recipe ID %q", args[0])
+	}
+	f, err := lookupFormatter(*output)
+	if err != nil {
+		return err
+	}
+	err = checkAmounts()
+	if err != nil {
+		return err
+	}
+	writeRecipe := func(w io.Writer, f formatter, recipe recipes.Recipe) error {
+		if *showImages {
+			f = withImages(ctx, cfg, f, []recipes.Recipe{recipe})
+		}
+		return writeRecipe(w, f, recipe)
+	}
+
+	// The cache is optional: without it the details are always fetched
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	var client *spoonacular.Client
+	if len(cfg.SpoonacularKeys()) > 0 {
+		client = newSpoonacular(cfg)
+	}
+	recipe, err := lookupRecipe(ctx, cache, client, recipeID)
+	switch {
+	case recipe == nil && errors.Is(err, config.ErrNoAPIKey):
+		return err
+	case recipe == nil:
+		return fmt.Errorf("error fetching recipe %d: %v", recipeID, err)
+	case errors.Is(err, config.ErrNoAPIKey):
+		fmt.Println("No API key configured, showing the cached recipe, which may be incomplete")
+	case err != nil:
+		fmt.Printf("Could not fetch recipe %d (%v), showing the cached recipe, which may be incomplete\n",
+			recipeID, err)
+	}
+	return writeRecipe(os.Stdout, f, *recipe)
+}
+
+// lookupRecipe returns the details of a Spoonacular recipe, read from the
+// cache while they are fresh, and fetched with client and cached otherwise.
+// Both cache and client may be nil; without a client the error is
+// config.ErrNoAPIKey. When the details cannot be fetched, whatever the cache
+// holds on the recipe is returned along with the error, nil when it holds
+// nothing.
+func lookupRecipe(ctx context.Context, cache store.Store, client *spoonacular.Client,
+	recipeID int) (*recipes.Recipe, error) {
+	var cached *recipes.Recipe
+	if cache != nil {
+		recipe, fresh, err := cache.CachedRecipe(ctx, "spoonacular", recipeID)
+		if err != nil {
+			slog.Warn("error reading cached recipe", "error", err)
+		}
+		if fresh {
+			return recipe, nil
+		}
+		cached = recipe
+	}
+
+	if client == nil {
+		return cached, config.ErrNoAPIKey
+	}
+	recipe, err := client.Information(ctx, recipeID)
+	if err != nil {
+		return cached, err
+	}
+	// The taste is a nicety, not worth failing the details for
+	recipe.Taste, err = client.Taste(ctx, recipeID)
+	if err != nil {
+		slog.Warn("error fetching the taste of the recipe", "error", err)
+	}
+	recipe.FetchedAt = time.Now()
+	if cache != nil {
+		err := cache.SaveRecipeDetails(ctx, recipe)
+		if err != nil {
+			slog.Warn("error caching recipe", "error", err)
+		}
+	}
+	return &recipe, nil
+}
+
+// writeRecipe writes a single recipe in full, with amounts in the --units
+// system. The text layout is its own; the other formats are the ones searches
+// use.
+func writeRecipe(w io.Writer, f formatter, recipe recipes.Recipe) error {
+	recipe = scaledRecipes([]recipes.Recipe{recipe})[0]
+	if _, ok := f.(textFormatter); !ok {
+		return f.Format(w, []recipes.Recipe{recipe}, true)
+	}
+	fmt.Fprintf(w, "%s (recipe %d)\n", recipe.Title, recipe.ID)
+	if servings := scaledServings(recipe); servings != "" {
+		fmt.Fprintln(w, "Servings:", servings)
+	} else if recipe.Servings > 0 {
+		fmt.Fprintln(w, "Servings:", recipe.Servings)
+	}
+	fmt.Fprintln(w, "Price per serving:", recipePrice(recipe))
+	if recipe.ReadyInMinutes > 0 {
+		fmt.Fprintf(w, "Ready in: %d minutes\n", recipe.ReadyInMinutes)
+	}
+	if len(recipe.Equipment) > 0 {
+		fmt.Fprintln(w, "Equipment:", strings.Join(recipe.Equipment, ", "))
+	}
+	if recipe.SourceURL != "" {
+		fmt.Fprintln(w, "Source:", recipe.SourceURL)
+	}
+	if recipe.Likes > 0 {
+		fmt.Fprintln(w, "Likes:", recipe.Likes)
+	}
+	if recipe.HealthScore > 0 {
+		fmt.Fprintf(w, "Health score: %.0f/100\n", recipe.HealthScore)
+	}
+	if recipe.Taste != nil {
+		fmt.Fprintf(w, "Taste: sweetness %.0f/100, spiciness %.0f Scoville\n", recipe.Taste.Sweetness,
+			recipe.Taste.Spiciness)
+	}
+	if text := f.(textFormatter); text.images != nil {
+		text.images.write(w, recipe)
+	} else if recipe.ImageURL != "" {
+		fmt.Fprintln(w, "Image:", recipe.ImageURL)
+	}
+	fmt.Fprintln(w, "Ingredients:")
+	for _, ingredient := range append(append([]recipes.Ingredient(nil), recipe.UsedIngredients...),
+		recipe.MissedIngredients...) {
+		amount := ""
+		if ingredient.Amount != 0 {
+			amount = strings.TrimSpace(fmt.Sprintf("%.4g %s", ingredient.Amount, ingredient.Unit)) + " "
+		}
+		fmt.Fprintf(w, "- %s%s\n", amount, ingredient.Name)
+	}
+	fmt.Fprintln(w, nutrientsHeading(recipe, nil))
+	for _, name := range recipe.NutrientNames() {
+		nutrient := recipe.Nutrients[name]
+		fmt.Fprintf(w, "%s: %.2f %s%s%s\n", name, nutrient.Amount, nutrient.Unit, dailyValue(nutrient),
+			nutrientTotal(recipe, name))
+	}
+	if len(recipe.Instructions) > 0 {
+		fmt.Fprintln(w, "Instructions:")
+		for i, step := range recipe.Instructions {
+			fmt.Fprintf(w, "%d. %s\n", i+1, step)
+		}
+	}
+	return nil
+}
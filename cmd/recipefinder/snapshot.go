@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+const snapshotUsage = "usage: recipefinder snapshot create <file> | restore <file>"
+
+// restoredConfigFile is where a restored config goes when there already is
+// a config file, for merging by hand.
+const restoredConfigFile = "config.restored.yaml"
+
+// householdSnapshot is what a snapshot file holds, as JSON: every table of
+// the database, and the settings in use without their secrets as a YAML
+// config file in a string.
+type householdSnapshot struct {
+	store.Archive
+	Config string `json:"config"`
+}
+
+// runSnapshot implements "recipefinder snapshot create", which copies the
+// whole state to a file, and "recipefinder snapshot restore", which replaces
+// the state with a file's, for moving to another machine or going back after
+// trying something destructive.
+func runSnapshot(args []string, cfg *config.Config) error {
+	if len(args) != 2 || (args[0] != "create" && args[0] != "restore") {
+		return errors.New(snapshotUsage)
+	}
+	// Copying a large cache can take longer than a search may
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cache, closeCache := openStore(ctx, cfg, store.Options{TTL: cfg.CacheTTL})
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache")
+	}
+	if args[0] == "create" {
+		return createSnapshot(ctx, cache, cfg, args[1])
+	}
+	return restoreSnapshot(ctx, cache, args[1], timeZone(cfg))
+}
+
+func createSnapshot(ctx context.Context, cache store.Store, cfg *config.Config, file string) error {
+	archive, err := cache.CreateArchive(ctx)
+	if err != nil {
+		return fmt.Errorf("error copying the database: %v", err)
+	}
+	settings, err := yaml.Marshal(cfg.WithoutSecrets())
+	if err != nil {
+		return fmt.Errorf("error encoding the config: %v", err)
+	}
+	encoded, err := json.Marshal(householdSnapshot{Archive: *archive, Config: string(settings)})
+	if err != nil {
+		return err
+	}
+	// The snapshot holds the user's data, so it is only for them to read
+	err = os.WriteFile(file, encoded, 0o600)
+	if err != nil {
+		return fmt.Errorf("error writing snapshot: %v", err)
+	}
+	rows := 0
+	for _, table := range archive.Tables {
+		rows += len(table.Rows)
+	}
+	fmt.Printf("Saved %d rows of %d tables and the config, without its secrets, to %s\n", rows,
+		len(archive.Tables), file)
+	return nil
+}
+
+func restoreSnapshot(ctx context.Context, cache store.Store, file string, location *time.Location) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("error reading snapshot: %v", err)
+	}
+	var snapshot householdSnapshot
+	// Numbers are kept as written, so IDs and timestamps stay exact
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&snapshot)
+	if err != nil {
+		return fmt.Errorf("error parsing snapshot: %v", err)
+	}
+	if !confirmRestore(snapshot.TakenAt.In(location)) {
+		fmt.Println("Nothing restored")
+		return nil
+	}
+
+	err = cache.RestoreArchive(ctx, snapshot.Archive)
+	if err != nil {
+		return fmt.Errorf("error restoring the database: %v", err)
+	}
+	fmt.Printf("Restored the database as it was at %s\n", snapshot.TakenAt.In(location).Format(time.DateTime))
+	if snapshot.Config == "" {
+		return nil
+	}
+	path, err := restoreConfig(snapshot.Config)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Wrote the snapshot's config to %s; add its secrets, such as the API keys, back\n", path)
+	return nil
+}
+
+// confirmRestore asks on stderr before replacing the database. Anything but
+// yes keeps it as it is.
+func confirmRestore(takenAt time.Time) bool {
+	fmt.Fprintf(os.Stderr, "This replaces everything in the recipe cache database, pantries, favorites and "+
+		"members included,\nwith the snapshot taken at %s. Restore it? [y/N] ", takenAt.Format(time.DateTime))
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// restoreConfig writes a snapshot's config next to the config file in use:
+// as the config file when there is none yet, and as restoredConfigFile
+// otherwise, so the current one is never overwritten.
+func restoreConfig(settings string) (string, error) {
+	path := *configPath
+	if path == "" {
+		dirs, err := userDirs()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(dirs.Config, "config.yaml")
+	}
+	if _, err := os.Stat(path); err == nil {
+		path = filepath.Join(filepath.Dir(path), restoredConfigFile)
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return "", fmt.Errorf("error creating config directory: %v", err)
+	}
+	err = os.WriteFile(path, []byte(settings), 0o600)
+	if err != nil {
+		return "", fmt.Errorf("error writing config: %v", err)
+	}
+	return path, nil
+}
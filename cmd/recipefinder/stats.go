@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+// runStats implements "recipefinder stats": how often each provider's
+// recipes miss nutrition, have a broken image or implausible values, then the
+// profile's cooking progress, see cookingProgress.
+func runStats(ctx context.Context, args []string, cfg *config.Config) error {
+	if len(args) != 0 {
+		return errors.New("usage: recipefinder stats")
+	}
+
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache")
+	}
+
+	providers, err := cache.ProviderQuality(ctx)
+	if err != nil {
+		return fmt.Errorf("error reading provider data quality: %v", err)
+	}
+	if len(providers) == 0 {
+		fmt.Println("No recipes saved from any provider yet")
+	} else {
+		fmt.Printf("%-12s  %8s  %17s  %13s  %11s  %5s\n", "Provider", "Recipes", "Missing nutrition", "Broken images",
+			"Implausible", "Score")
+	}
+	for _, provider := range providers {
+		fmt.Printf("%-12s  %8d  %17s  %13s  %11s  %5.2f\n", provider.Source, provider.Recipes,
+			incidence(provider.MissingNutrition, provider.Recipes), incidence(provider.BrokenImages, provider.Recipes),
+			incidence(provider.ImplausibleValues, provider.Recipes), provider.Score())
+	}
+
+	meals, err := cache.Cooked(ctx, time.Time{})
+	if err != nil {
+		return fmt.Errorf("error reading cooked meals: %v", err)
+	}
+	fmt.Println()
+	if len(meals) == 0 {
+		fmt.Println("No meals logged with \"recipefinder cooked\" yet")
+		return nil
+	}
+	budget := recipes.DollarsToMoney(cfg.Planning.WeeklyBudget)
+	writeProgress(os.Stdout, measureProgress(meals, time.Now().In(timeZone(cfg)), budget), budget)
+	return nil
+}
+
+// incidence writes a count with the share of the total it is, e.g.
+// "3 (2.5%)".
+func incidence(count int, total int) string {
+	if total == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%d (%.1f%%)", count, 100*float64(count)/float64(total))
+}
+
+// tieBreakOrder puts the recipes in the order SortRecipes leaves equally
+// ranked ones in: the recipes of providers with better data first, then by
+// ID, see recipes.SortByID. Without a cache, or when it fails, only the IDs
+// count.
+func tieBreakOrder(ctx context.Context, cache store.Store, allRecipes []recipes.Recipe) {
+	recipes.SortByID(allRecipes)
+	if cache == nil {
+		return
+	}
+	providers, err := cache.ProviderQuality(ctx)
+	if err != nil {
+		slog.Warn("error reading provider data quality", "error", err)
+		return
+	}
+	scores := make(map[string]float64, len(providers))
+	for _, provider := range providers {
+		scores[provider.Source] = provider.Score()
+	}
+	recipes.PreferQuality(allRecipes, scores)
+}
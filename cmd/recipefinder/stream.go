@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+var stream = flag.Bool("stream", true, "Print the recipes of searches needing several pages of results as they "+
+	"come in, in the order found; --stream=false prints them all at once, ranked by --sort")
+
+// resultScreen is the screening of a search's results that follows their
+// ranking, which applies to each recipe on its own.
+type resultScreen struct {
+	region   recipes.Availability
+	reported recipes.Reported
+	ruleSets []recipes.RuleSet
+	forms    recipes.FormPreferences
+	maxPrice recipes.Money
+	minScore float64
+}
+
+func newResultScreen(ctx context.Context, cache store.Store, cfg *config.Config, ruleSets []recipes.RuleSet,
+	forms recipes.FormPreferences) resultScreen {
+	return resultScreen{
+		region:   regionAvailability(cfg.Region),
+		reported: reportedRecipes(ctx, cache, cfg.Reports),
+		ruleSets: ruleSets,
+		forms:    forms,
+		maxPrice: recipes.DollarsToMoney(*maxPrice),
+		minScore: *minHealthScore,
+	}
+}
+
+// apply drops the recipes the region, the reports, the dietary rule sets,
+// the disliked forms, --maxPricePerServing or --minHealthScore rule out.
+func (s resultScreen) apply(allRecipes []recipes.Recipe) []recipes.Recipe {
+	allRecipes = s.region.Apply(allRecipes)
+	allRecipes = s.reported.Apply(allRecipes)
+	for _, ruleSet := range s.ruleSets {
+		allRecipes = ruleSet.Apply(allRecipes)
+	}
+	allRecipes = s.forms.Apply(allRecipes)
+	allRecipes = recipes.MaxPrice(allRecipes, s.maxPrice)
+	return recipes.MinHealthScore(allRecipes, s.minScore)
+}
+
+// resolveRecipes completes the recipes about to be printed: favorites are
+// marked, substitutes suggested, amounts scaled and pairings and, with
+// --substitutes, ways of doing without the missing ingredients looked up.
+func resolveRecipes(ctx context.Context, cache store.Store, cfg *config.Config, query recipes.Query,
+	allRecipes []recipes.Recipe) []recipes.Recipe {
+	allRecipes = markFavorites(ctx, cache, allRecipes)
+	allRecipes = recipes.SuggestSubstitutes(allRecipes, query.Ingredients)
+	allRecipes = scaledRecipes(allRecipes)
+	if *pairing && !*shoppingList {
+		allRecipes = addPairings(ctx, cache, cfg, allRecipes)
+	}
+	if *substitutes && !*shoppingList {
+		allRecipes = addSubstitutes(ctx, cache, cfg, query.Ingredients, allRecipes)
+	}
+	return allRecipes
+}
+
+// streaming reports whether a search prints its recipes as they come in: only
+// with --stream, for searches of more than a page, printed as text.
+// Experiments, images, shopping lists, exports and browsing need all the
+// recipes first.
+func streaming(cfg *config.Config, query recipes.Query) bool {
+	return *stream && query.NumberOfRecipes > recipes.PageSize && *output == "text" && !*interactive &&
+		!*shoppingList && *export == "" && !*showImages && !*dryRun && !experimenting(cfg)
+}
+
+// streamResults prints the recipes of a search as each is resolved, instead
+// of once the search is over, and returns the ones printed. The finder's
+// pages go through a pipeline: the screening and resolving of each recipe
+// runs while the next page is fetched, and printing while the next recipe is
+// resolved. The user's own recipes come first.
+func streamResults(ctx context.Context, w io.Writer, cfg *config.Config, finder *recipes.Finder,
+	cache store.Store, query recipes.Query, f formatter, screen resultScreen) ([]recipes.Recipe, error) {
+	pages, errs := finder.Stream(ctx, query)
+
+	resolved := make(chan recipes.Recipe)
+	go func() {
+		defer close(resolved)
+		content := contentFilter(cfg.Filters)
+		resolve := func(page []recipes.Recipe) {
+			for _, recipe := range screen.apply(content.Apply(page)) {
+				resolved <- resolveRecipes(ctx, cache, cfg, query, []recipes.Recipe{recipe})[0]
+			}
+		}
+		resolve(withLocalRecipes(ctx, cache, query, nil))
+		for page := range pages {
+			resolve(page)
+		}
+	}()
+
+	var printed []recipes.Recipe
+	var formatErr error
+	// The channel is read to the end, so the search finishes and is cached
+	for recipe := range resolved {
+		if len(printed) == query.NumberOfRecipes || formatErr != nil {
+			continue
+		}
+		formatErr = f.Format(w, []recipes.Recipe{recipe}, *instructions)
+		printed = append(printed, recipe)
+	}
+	err := <-errs
+	if err != nil {
+		return printed, err
+	}
+	return printed, formatErr
+}
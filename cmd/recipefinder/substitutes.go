@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"slices"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/spoonacular"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+var substitutes = flag.Bool("substitutes", false,
+	"Suggest ways of doing without each missing ingredient, from Spoonacular, those using what you have first")
+
+// checkSubstitutes checks that --substitutes can be looked up.
+func checkSubstitutes(cfg *config.Config) error {
+	if !*substitutes {
+		return nil
+	}
+	if len(cfg.SpoonacularKeys()) == 0 {
+		return errors.New("--substitutes looks substitutes up on Spoonacular and needs its API key")
+	}
+	if *offlineSearch {
+		return errors.New("--substitutes looks substitutes up on Spoonacular, it cannot be combined with --offline")
+	}
+	return nil
+}
+
+// addSubstitutes sets the Replacements of the recipes' missing ingredients,
+// those naming an ingredient in have first, see recipes.HaveFirst. Each
+// ingredient is looked up once, from the cache while it is fresh, and one
+// that cannot be looked up is left without, as it is not worth failing the
+// search for.
+func addSubstitutes(ctx context.Context, cache store.Store, cfg *config.Config, have []string,
+	allRecipes []recipes.Recipe) []recipes.Recipe {
+	client := newSpoonacular(cfg)
+	found := make(map[string][]string)
+	for i := range allRecipes {
+		// The ingredients may be shared with other copies of the recipe
+		missed := slices.Clone(allRecipes[i].MissedIngredients)
+		for j, ingredient := range missed {
+			name := recipes.NormalizeIngredient(ingredient.Name)
+			replacements, ok := found[name]
+			if !ok {
+				var err error
+				replacements, err = fetchSubstitutes(ctx, cache, client, name)
+				if err != nil {
+					slog.Warn("error looking up substitutes", "ingredient", name, "error", err)
+				}
+				replacements = recipes.HaveFirst(replacements, have)
+				found[name] = replacements
+			}
+			missed[j].Replacements = replacements
+		}
+		allRecipes[i].MissedIngredients = missed
+	}
+	return allRecipes
+}
+
+// fetchSubstitutes returns the substitutes of an ingredient. When looking
+// them up fails the cached ones are returned with the error.
+func fetchSubstitutes(ctx context.Context, cache store.Store, client *spoonacular.Client,
+	ingredient string) ([]string, error) {
+	var cached []string
+	if cache != nil {
+		found, fresh, err := cache.CachedSubstitutes(ctx, ingredient)
+		if err != nil {
+			slog.Warn("error reading cached substitutes", "error", err)
+		}
+		if fresh {
+			return found, nil
+		}
+		cached = found
+	}
+
+	found, err := client.Substitutes(ctx, ingredient)
+	if err != nil {
+		return cached, err
+	}
+	if cache != nil {
+		err := cache.SaveSubstitutes(ctx, ingredient, found)
+		if err != nil {
+			slog.Warn("error caching substitutes", "error", err)
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// loadTaxonomy adds the ingredients of the user's taxonomy file, a YAML list
+// of entries, to the built-in taxonomy. An empty path adds nothing.
+func loadTaxonomy(path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading taxonomy file: %v", err)
+	}
+	var entries []recipes.TaxonomyEntry
+	err = yaml.Unmarshal(data, &entries)
+	if err != nil {
+		return fmt.Errorf("error parsing taxonomy file %s: %v", path, err)
+	}
+	err = recipes.ExtendTaxonomy(entries)
+	if err != nil {
+		return fmt.Errorf("invalid taxonomy file %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+)
+
+const telemetryUsage = "usage: recipefinder telemetry status | telemetry enable | telemetry disable"
+
+// telemetryFile holds the user's telemetry choice in the data directory. Until
+// it exists the user has not been asked.
+const telemetryFile = "telemetry.json"
+
+type telemetryState struct {
+	Enabled bool `json:"enabled"`
+	// InstallID is random and only ties the reports of one installation
+	// together.
+	InstallID string `json:"installID,omitempty"`
+}
+
+// telemetry counts what a run used and sends the counts when it ends. It only
+// ever records names of commands, flags, features and error categories, never
+// flag values, so ingredients cannot end up in a report.
+type telemetry struct {
+	endpoint  string
+	installID string
+	client    *http.Client
+	usage     map[string]int
+	errors    map[string]int
+}
+
+type telemetryReport struct {
+	InstallID string         `json:"installID"`
+	OS        string         `json:"os"`
+	Arch      string         `json:"arch"`
+	Usage     map[string]int `json:"usage"`
+	Errors    map[string]int `json:"errors,omitempty"`
+}
+
+// runTelemetry implements "recipefinder telemetry <subcommand>".
+func runTelemetry(args []string, cfg *config.Config) error {
+	if len(args) != 1 {
+		return errors.New(telemetryUsage)
+	}
+	state, asked, err := loadTelemetryState(cfg.DataDir)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "status":
+		switch {
+		case !asked:
+			fmt.Println("Telemetry is disabled (not chosen yet)")
+		case state.Enabled:
+			fmt.Println("Telemetry is enabled")
+		default:
+			fmt.Println("Telemetry is disabled")
+		}
+		if cfg.Telemetry.Endpoint == "" {
+			fmt.Println("No telemetry endpoint is configured, nothing is sent")
+		} else {
+			fmt.Println("Endpoint:", cfg.Telemetry.Endpoint)
+		}
+		return nil
+	case "enable":
+		return setTelemetry(cfg.DataDir, state, true)
+	case "disable":
+		return setTelemetry(cfg.DataDir, state, false)
+	default:
+		return errors.New(telemetryUsage)
+	}
+}
+
+func setTelemetry(dataDir string, state telemetryState, enabled bool) error {
+	state.Enabled = enabled
+	if !enabled {
+		state.InstallID = ""
+	}
+	err := saveTelemetryState(dataDir, state)
+	if err != nil {
+		return err
+	}
+	if enabled {
+		fmt.Println("Telemetry enabled, thank you")
+	} else {
+		fmt.Println("Telemetry disabled")
+	}
+	return nil
+}
+
+// newTelemetry returns the telemetry of this run, or nil, on which every
+// method is a no-op, when the user has not opted in. On the first run from a
+// terminal the user is asked; without a terminal nothing is asked or saved.
+func newTelemetry(cfg *config.Config) *telemetry {
+	state, asked, err := loadTelemetryState(cfg.DataDir)
+	if err != nil {
+		slog.Warn("error loading telemetry state", "error", err)
+		return nil
+	}
+	if !asked && isTerminal(os.Stdin) {
+		state.Enabled = askTelemetry()
+		err = saveTelemetryState(cfg.DataDir, state)
+		if err != nil {
+			slog.Warn("error saving telemetry state", "error", err)
+		}
+	}
+	if !state.Enabled || cfg.Telemetry.Endpoint == "" {
+		return nil
+	}
+	if state.InstallID == "" {
+		state.InstallID = newInstallID()
+		err = saveTelemetryState(cfg.DataDir, state)
+		if err != nil {
+			slog.Warn("error saving telemetry state", "error", err)
+		}
+	}
+	return &telemetry{
+		endpoint:  cfg.Telemetry.Endpoint,
+		installID: state.InstallID,
+		client:    &http.Client{Timeout: 2 * time.Second},
+		usage:     map[string]int{},
+		errors:    map[string]int{},
+	}
+}
+
+// askTelemetry asks on stderr, so the question does not end up in piped
+// output. Anything but yes keeps telemetry off.
+func askTelemetry() bool {
+	fmt.Fprint(os.Stderr, "recipefinder can send anonymous usage metrics (which commands, options and features\n"+
+		"are used and which kinds of errors happen, never ingredients) to help decide what to\n"+
+		"improve. You can change this later with \"recipefinder telemetry enable|disable\".\n"+
+		"Send usage metrics? [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func newInstallID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+func loadTelemetryState(dataDir string) (telemetryState, bool, error) {
+	var state telemetryState
+	data, err := os.ReadFile(filepath.Join(dataDir, telemetryFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return state, false, nil
+	}
+	if err != nil {
+		return state, false, fmt.Errorf("error reading telemetry settings: %v", err)
+	}
+	err = json.Unmarshal(data, &state)
+	if err != nil {
+		return state, false, fmt.Errorf("error parsing telemetry settings: %v", err)
+	}
+	return state, true, nil
+}
+
+func saveTelemetryState(dataDir string, state telemetryState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("error encoding telemetry settings: %v", err)
+	}
+	err = os.MkdirAll(dataDir, 0o700)
+	if err != nil {
+		return fmt.Errorf("error creating data directory: %v", err)
+	}
+	err = os.WriteFile(filepath.Join(dataDir, telemetryFile), data, 0o600)
+	if err != nil {
+		return fmt.Errorf("error saving telemetry settings: %v", err)
+	}
+	return nil
+}
+
+// countRun records the command and the names of the flags and features this
+// run uses.
+func (t *telemetry) countRun(command string) {
+	if t == nil {
+		return
+	}
+	t.usage["command:"+command]++
+	commandFlags.Visit(func(f *flag.Flag) {
+		t.usage["flag:"+f.Name]++
+	})
+	for name, enabled := range enabledFeatures {
+		if enabled {
+			t.usage["feature:"+name]++
+		}
+	}
+}
+
+// countError records the category of a failure, see errorCategory.
+func (t *telemetry) countError(category string) {
+	if t == nil {
+		return
+	}
+	t.errors[category]++
+}
+
+// send reports the counts. Failures are only logged: telemetry must never get
+// in the way of the user.
+func (t *telemetry) send() {
+	if t == nil {
+		return
+	}
+	payload, err := json.Marshal(telemetryReport{
+		InstallID: t.installID,
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Usage:     t.usage,
+		Errors:    t.errors,
+	})
+	if err != nil {
+		slog.Warn("error encoding telemetry", "error", err)
+		return
+	}
+	resp, err := t.client.Post(t.endpoint, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		slog.Warn("error sending telemetry", "error", err)
+		return
+	}
+	err = resp.Body.Close()
+	if err != nil {
+		slog.Warn("error closing response body", "error", err)
+	}
+}
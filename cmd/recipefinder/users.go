@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/store"
+	"github.com/mawojcik/meals_generator/pkg/telegram"
+)
+
+var inviteTTL = flag.Duration("inviteTTL", 72*time.Hour, "How long an invitation from \"user invite\" can be accepted")
+
+var (
+	notifyChannels = flag.String("channels", "",
+		"Comma-separated channels \"user notifications\" sets: "+strings.Join(store.Channels, ", "))
+	notifyEvents = flag.String("events", "",
+		"Comma-separated events \"user notifications\" sets: "+strings.Join(store.Events, ", ")+", or none")
+	quietHours = flag.String("quietHours", "",
+		"Hours \"user notifications\" sets when nothing is sent, as 22:00-07:00, or off")
+)
+
+const userUsage = "usage: recipefinder user invite <profile> [--inviteTTL=72h] | list | remove <profile> | " +
+	"notifications <profile> [--channels=telegram|none] [--events=<event1>,...] [--quietHours=22:00-07:00|off]"
+
+// profileName is what a profile invited with "user invite" may be called, so
+// it is safe in the config file, links and chat messages.
+var profileName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// runUser implements "recipefinder user": "invite" creates a one-time
+// invitation to join as a new profile, which starts with the default
+// preferences and an empty pantry; "list" shows the members and pending
+// invitations; "remove" removes the members and invitations of a profile;
+// "notifications" shows and changes what a profile is notified of.
+// Invitations are accepted through the Telegram bot, with the link or the
+// /start command "invite" prints.
+func runUser(ctx context.Context, args []string, cfg *config.Config) error {
+	if len(args) == 0 {
+		return errors.New(userUsage)
+	}
+	switch args[0] {
+	case "invite", "remove", "notifications":
+		if len(args) != 2 {
+			return errors.New(userUsage)
+		}
+		if !profileName.MatchString(args[1]) {
+			return fmt.Errorf("invalid profile name %q, use letters, digits, - and _", args[1])
+		}
+	case "list":
+		if len(args) != 1 {
+			return errors.New(userUsage)
+		}
+	default:
+		return errors.New(userUsage)
+	}
+
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache")
+	}
+
+	switch args[0] {
+	case "invite":
+		if *inviteTTL <= 0 {
+			return errors.New("--inviteTTL must be positive")
+		}
+		token, err := newInviteToken()
+		if err != nil {
+			return err
+		}
+		expires := time.Now().Add(*inviteTTL)
+		err = cache.CreateInvite(ctx, token, args[1], expires)
+		if err != nil {
+			return fmt.Errorf("error creating invitation: %v", err)
+		}
+		fmt.Printf("Invitation to join as %s, valid until %s and only once:\n", args[1],
+			expires.In(timeZone(cfg)).Format(time.DateTime))
+		if link := botInviteLink(ctx, cfg, token); link != "" {
+			fmt.Println("  ", link)
+		}
+		fmt.Printf("   or send \"/start %s\" to the Telegram bot\n", token)
+		if _, ok := cfg.Profiles[args[1]]; !ok {
+			fmt.Printf("%s starts with the default preferences; set them under profiles in the config file\n", args[1])
+		}
+	case "list":
+		members, err := cache.Members(ctx)
+		if err != nil {
+			return fmt.Errorf("error listing members: %v", err)
+		}
+		invites, err := cache.Invites(ctx)
+		if err != nil {
+			return fmt.Errorf("error listing invitations: %v", err)
+		}
+		location := timeZone(cfg)
+		if len(members) == 0 && len(invites) == 0 {
+			fmt.Println("No members nor invitations, invite someone with recipefinder user invite <profile>")
+		}
+		for _, member := range members {
+			fmt.Printf("%-20s %-24s joined %s\n", member.Profile, member.Account,
+				member.JoinedAt.In(location).Format(time.DateTime))
+		}
+		for _, invite := range invites {
+			fmt.Printf("%-20s %-24s expires %s\n", invite.Profile, "(invited)",
+				invite.ExpiresAt.In(location).Format(time.DateTime))
+		}
+	case "remove":
+		removed, err := cache.RemoveProfile(ctx, args[1])
+		if err != nil {
+			return fmt.Errorf("error removing profile: %v", err)
+		}
+		fmt.Printf("Removed %d members of %s and its pending invitations\n", removed, args[1])
+	case "notifications":
+		return runUserNotifications(ctx, cache, args[1])
+	}
+	return nil
+}
+
+// runUserNotifications changes what a profile is notified of as the
+// --channels, --events and --quietHours given say, then shows it.
+func runUserNotifications(ctx context.Context, cache store.Store, profile string) error {
+	prefs, err := cache.NotificationPrefs(ctx, profile)
+	if err != nil {
+		return fmt.Errorf("error reading notification preferences: %v", err)
+	}
+	changed := *notifyChannels != "" || *notifyEvents != "" || *quietHours != ""
+	if *notifyChannels != "" {
+		prefs.Channels, err = parseChoices(*notifyChannels, store.Channels, "channel")
+		if err != nil {
+			return err
+		}
+		if len(prefs.Channels) > 1 && slices.Contains(prefs.Channels, store.ChannelNone) {
+			return fmt.Errorf("channel %s cannot be combined with others", store.ChannelNone)
+		}
+	}
+	if *notifyEvents == "none" {
+		prefs.Events = nil
+	} else if *notifyEvents != "" {
+		prefs.Events, err = parseChoices(*notifyEvents, store.Events, "event")
+		if err != nil {
+			return err
+		}
+	}
+	if *quietHours != "" {
+		prefs.QuietStart, prefs.QuietEnd, err = parseQuietHours(*quietHours)
+		if err != nil {
+			return err
+		}
+	}
+	if changed {
+		err = cache.SaveNotificationPrefs(ctx, prefs)
+		if err != nil {
+			return fmt.Errorf("error saving notification preferences: %v", err)
+		}
+	}
+
+	events := strings.Join(prefs.Events, ", ")
+	if events == "" {
+		events = "none"
+	}
+	quiet := "none"
+	if prefs.QuietStart != prefs.QuietEnd {
+		quiet = fmt.Sprintf("%s-%s", clockTime(prefs.QuietStart), clockTime(prefs.QuietEnd))
+	}
+	fmt.Printf("Notifications of %s\n", profile)
+	fmt.Printf("  Channels:    %s\n", strings.Join(prefs.Channels, ", "))
+	fmt.Printf("  Events:      %s\n", events)
+	fmt.Printf("  Quiet hours: %s\n", quiet)
+	return nil
+}
+
+// parseChoices splits a comma-separated list whose entries must be among the
+// known ones.
+func parseChoices(list string, known []string, kind string) ([]string, error) {
+	var chosen []string
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if !slices.Contains(known, entry) {
+			return nil, fmt.Errorf("unknown %s %q, use %s", kind, entry, strings.Join(known, ", "))
+		}
+		if !slices.Contains(chosen, entry) {
+			chosen = append(chosen, entry)
+		}
+	}
+	return chosen, nil
+}
+
+// parseQuietHours parses quiet hours such as 22:00-07:00 into minutes after
+// midnight, or "off" into none.
+func parseQuietHours(hours string) (int, int, error) {
+	if hours == "off" {
+		return 0, 0, nil
+	}
+	from, to, found := strings.Cut(hours, "-")
+	start, startErr := time.Parse("15:04", strings.TrimSpace(from))
+	end, endErr := time.Parse("15:04", strings.TrimSpace(to))
+	if !found || startErr != nil || endErr != nil {
+		return 0, 0, fmt.Errorf("invalid quiet hours %q, use the form 22:00-07:00", hours)
+	}
+	return start.Hour()*60 + start.Minute(), end.Hour()*60 + end.Minute(), nil
+}
+
+// clockTime formats minutes after midnight as 15:04.
+func clockTime(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// newInviteToken returns a random invitation token, made of the characters
+// Telegram allows in a /start parameter.
+func newInviteToken() (string, error) {
+	random := make([]byte, 18)
+	_, err := rand.Read(random)
+	if err != nil {
+		return "", fmt.Errorf("error generating invitation: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(random), nil
+}
+
+// botInviteLink returns the link opening a chat with the Telegram bot that
+// accepts the invitation, or "" without a bot.
+func botInviteLink(ctx context.Context, cfg *config.Config, token string) string {
+	if cfg.Telegram.Token == "" {
+		return ""
+	}
+	username, err := telegram.NewClient(cfg.Telegram.Token).Username(ctx)
+	if err != nil || username == "" {
+		slog.Warn("error looking up the Telegram bot", "error", err)
+		return ""
+	}
+	return fmt.Sprintf("https://t.me/%s?start=%s", username, token)
+}
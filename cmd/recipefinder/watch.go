@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mawojcik/meals_generator/config"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+var watchInterval = flag.Duration("interval", 0,
+	"With watch, also search again this often, not only when the pantry changes; 0 for only then")
+
+const (
+	// pantryPollInterval is how often watch checks the pantry for changes.
+	pantryPollInterval = 30 * time.Second
+	// watchRecipes is how many recipes watch searches for without
+	// --numberOfRecipes.
+	watchRecipes = 20
+)
+
+// runWatch implements "recipefinder watch": it searches whenever the pantry
+// changes, and every --interval, and notifies about the recipes it had not
+// found before, sending the daily digests of the members as they fall due.
+// It runs until it is stopped.
+func runWatch(cfg *config.Config, provider recipes.RecipeProvider, reporter *errorReporter) error {
+	if *ingredients == "" {
+		return errors.New("usage: recipefinder watch --ingredients=<ingredient1>,... [--interval=<duration>] " +
+			"[flags], see recipefinder help watch")
+	}
+	count := *numberOfRecipes
+	if count == 0 {
+		count = watchRecipes
+	}
+	query, err := parseArguments(count, cfg.Allergens)
+	if err != nil {
+		return err
+	}
+	screen, err := parseScreening()
+	if err != nil {
+		return err
+	}
+
+	// A watch runs until it is stopped, not for the command timeout
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	cache, closeCache := openCache(ctx, cfg)
+	defer closeCache()
+	if cache == nil {
+		return errors.New("cannot connect to the recipe cache, which holds the pantry")
+	}
+	provider = withQuota(ctx, provider, cache, cfg)
+	notifiers := newNotifiers(cfg, cache)
+
+	type recipeKey struct {
+		source string
+		id     int
+	}
+	seen := make(map[recipeKey]bool)
+	var pantry []string
+	var lastRun time.Time
+	for {
+		current, err := cache.Pantry(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("error reading pantry", "error", err)
+		}
+		due := lastRun.IsZero() || !slices.Equal(current, pantry) ||
+			*watchInterval > 0 && time.Since(lastRun) >= *watchInterval
+		if err == nil && due {
+			found, err := screenedSearch(ctx, cfg, provider, cache, reporter, screen, query)
+			if err != nil && ctx.Err() == nil {
+				// Searched again on the next poll
+				slog.Error("search failed", "error", err)
+			}
+			if err == nil {
+				var fresh []recipes.Recipe
+				for _, recipe := range found {
+					key := recipeKey{recipe.Source, recipe.ID}
+					if !seen[key] {
+						fresh = append(fresh, recipe)
+						seen[key] = true
+					}
+				}
+				switch {
+				case lastRun.IsZero():
+					fmt.Printf("Watching for new recipes, %d match now\n", len(found))
+				case len(fresh) > 0:
+					sendNotification(ctx, notifiers, notification{
+						Event:   store.EventNewMatches,
+						Message: watchMessage(len(fresh), pantryAdditions(pantry, current)),
+						Recipes: fresh,
+					})
+				}
+				pantry, lastRun = current, time.Now()
+			}
+		}
+		sendDigests(ctx, notifiers)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(pantryPollInterval):
+		}
+	}
+}
+
+// screenedSearch runs one search of a long-running command, with the pantry
+// as it is now, and screens what it finds.
+func screenedSearch(ctx context.Context, cfg *config.Config, provider recipes.RecipeProvider, cache store.Store,
+	reporter *errorReporter, screen screening, query recipes.Query) ([]recipes.Recipe, error) {
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+	query = withPantry(ctx, cache, query)
+	found, err := newFinder(provider, cache, reporter, query.Ingredients, cfg.Sources).Find(ctx, query)
+	printQuota(provider)
+	if err != nil {
+		reporter.captureError(err, newErrorContext(provider, query.Ingredients))
+		return nil, err
+	}
+	tieBreakOrder(ctx, cache, found)
+	return screen.apply(found, cfg, reportedRecipes(ctx, cache, cfg.Reports), query.NumberOfRecipes), nil
+}
+
+// pantryAdditions returns the items in the current pantry that were not in
+// the earlier one.
+func pantryAdditions(earlier []string, current []string) []string {
+	var added []string
+	for _, item := range current {
+		if !slices.Contains(earlier, item) {
+			added = append(added, item)
+		}
+	}
+	return added
+}
+
+// watchMessage announces new matches, crediting the pantry items added since
+// the last search when there are any.
+func watchMessage(matches int, added []string) string {
+	if len(added) == 0 {
+		return fmt.Sprintf("%d new recipes match", matches)
+	}
+	return fmt.Sprintf("Now that you have %s, %d new recipes match", strings.Join(added, ", "), matches)
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// webFiles are the pages of the web UI, built into the binary so the server
+// needs nothing besides it.
+//
+//go:embed web
+var webFiles embed.FS
+
+// webUI serves the web UI: a page searching with GET /recipes, for those who
+// would rather not use the command line.
+func webUI() http.Handler {
+	files, err := fs.Sub(webFiles, "web")
+	if err != nil {
+		// The directory is embedded, so it is always there
+		panic(err)
+	}
+	return http.FileServerFS(files)
+}
@@ -0,0 +1,108 @@
+"use strict";
+
+// The page searches with the server's own REST API, GET /recipes, and lays
+// the recipes out from the template. Recipe text is only ever set as text,
+// never as HTML.
+
+const form = document.getElementById("search");
+const status = document.getElementById("status");
+const results = document.getElementById("results");
+const recipeTemplate = document.getElementById("recipe");
+
+form.addEventListener("submit", async (event) => {
+  event.preventDefault();
+  const data = new FormData(form);
+  const query = new URLSearchParams({
+    ingredients: data.get("ingredients"),
+    number: data.get("number"),
+  });
+  const diets = data.getAll("diet");
+  if (diets.length > 0) {
+    query.set("diet", diets.join(","));
+  }
+
+  showStatus("Searching…");
+  results.replaceChildren();
+  form.querySelector("button").disabled = true;
+  try {
+    const response = await fetch("recipes?" + query);
+    const body = await response.json();
+    if (!response.ok) {
+      showStatus(body.error || "The search failed.", true);
+      return;
+    }
+    if (body.length === 0) {
+      showStatus("No recipes found, try other ingredients or fewer diets.");
+      return;
+    }
+    showStatus(body.length === 1 ? "Found 1 recipe." : `Found ${body.length} recipes.`);
+    results.replaceChildren(...body.map(recipeCard));
+  } catch (err) {
+    showStatus("Cannot reach the server.", true);
+  } finally {
+    form.querySelector("button").disabled = false;
+  }
+});
+
+function showStatus(message, isError = false) {
+  status.textContent = message;
+  status.classList.toggle("error", isError);
+}
+
+function recipeCard(recipe) {
+  const card = recipeTemplate.content.firstElementChild.cloneNode(true);
+  const image = card.querySelector("img");
+  if (isWebURL(recipe.imageUrl)) {
+    image.src = recipe.imageUrl;
+  } else {
+    image.remove();
+  }
+  card.querySelector("h2").textContent = recipe.title;
+
+  const facts = [];
+  if (recipe.readyInMinutes) {
+    facts.push(`Ready in ${recipe.readyInMinutes} minutes`);
+  }
+  if (recipe.servings) {
+    facts.push(`${recipe.servings} servings`);
+  }
+  card.querySelector(".facts").textContent = facts.join(" · ");
+
+  const missed = recipe.missedIngredients || [];
+  card.querySelector(".missing").textContent = missed.length === 0
+    ? "You have everything."
+    : "Missing: " + missed.map((ingredient) => ingredient.name).join(", ");
+
+  const nutrients = Object.entries(recipe.nutrients || {})
+    .map(([name, nutrient]) => `${name} ${Math.round(nutrient.amount)} ${nutrient.unit}`);
+  card.querySelector(".nutrients").textContent = nutrients.join(" · ");
+
+  const instructions = recipe.instructions || [];
+  if (instructions.length > 0) {
+    const steps = card.querySelector("ol");
+    for (const instruction of instructions) {
+      const step = document.createElement("li");
+      step.textContent = instruction;
+      steps.append(step);
+    }
+  } else {
+    card.querySelector("details").remove();
+  }
+
+  const source = card.querySelector(".source");
+  if (isWebURL(recipe.sourceUrl)) {
+    source.href = recipe.sourceUrl;
+  } else {
+    source.remove();
+  }
+  return card;
+}
+
+function isWebURL(value) {
+  try {
+    const parsed = new URL(value);
+    return parsed.protocol === "http:" || parsed.protocol === "https:";
+  } catch {
+    return false;
+  }
+}
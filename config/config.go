@@ -0,0 +1,709 @@
+// Package config loads recipeFinder settings.
+//
+// Every setting is resolved in the following order, later sources winning:
+//
+//  1. built-in defaults
+//  2. the config file, config.yaml in the config directory (see Dirs) unless
+//     --config is given
+//  3. environment variables (RECIPEFINDER_API_KEY, RECIPEFINDER_DB_*), or for
+//     secrets the files named by the same variables suffixed with _FILE
+//  4. command-line flags such as --apiKey
+//
+// Secret settings may refer to a file, a systemd credential or a command
+// printing the secret instead, see ResolveSecrets.
+//
+// The config file is optional when it is read from the default location.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoAPIKey is returned by Validate when no source provided an API key.
+var ErrNoAPIKey = errors.New("no Spoonacular API key configured: set RECIPEFINDER_API_KEY, " +
+	"pass --apiKey=<key> or add apiKey to the config file, or search without a key with --provider=offline")
+
+type Config struct {
+	APIKey string `yaml:"apiKey"`
+	// APIKeys are more Spoonacular API keys, used in turn once the daily
+	// points of the others are spent.
+	APIKeys  []string      `yaml:"apiKeys"`
+	DB       DB            `yaml:"db"`
+	CacheTTL time.Duration `yaml:"cacheTTL"`
+	// Timeout bounds a whole search, API retries and cache queries included.
+	// Zero disables it.
+	Timeout   time.Duration `yaml:"timeout"`
+	Log       Log           `yaml:"log"`
+	SentryDSN string        `yaml:"sentryDSN"`
+	Features  []string      `yaml:"features"`
+	Region    Region        `yaml:"region"`
+	// Allergens are ingredients left out of every search, on top of the ones
+	// excluded for a single search.
+	Allergens []string `yaml:"allergens"`
+	// Nutrients are the nutrients kept from Spoonacular's nutrition data, see
+	// spoonacular.Client.Nutrients.
+	Nutrients []string `yaml:"nutrients"`
+	// Profiles are the people sharing the machine, by name. Profile is the
+	// one used, chosen with --profile; none when empty.
+	Profiles map[string]Profile `yaml:"profiles"`
+	Profile  string             `yaml:"profile"`
+	// Providers are the recipe APIs to search, in order; when one fails the
+	// next one is tried.
+	Providers []string  `yaml:"providers"`
+	Edamam    Edamam    `yaml:"edamam"`
+	TheMealDB TheMealDB `yaml:"theMealDB"`
+	FDC       FDC       `yaml:"fdc"`
+	Telegram  Telegram  `yaml:"telegram"`
+	Telemetry Telemetry `yaml:"telemetry"`
+	Reports   Reports   `yaml:"reports"`
+	Sources   Sources   `yaml:"sources"`
+	Filters   Filters   `yaml:"filters"`
+	Jobs      Jobs      `yaml:"jobs"`
+	Quota     Quota     `yaml:"quota"`
+
+	// Translation translates ingredients given in another language, see
+	// Translation.
+	Translation Translation `yaml:"translation"`
+
+	Notifications Notifications `yaml:"notifications"`
+	Email         Email         `yaml:"email"`
+	Schedules     []Schedule    `yaml:"schedules"`
+	Ranking       Ranking       `yaml:"ranking"`
+	// Experiment compares another ranking with Ranking, see Experiment.
+	Experiment Experiment `yaml:"experiment"`
+	// Planning shapes the weeks of "recipefinder plan", see Planning.
+	Planning Planning `yaml:"planning"`
+
+	// TimeZone is the IANA time zone, such as Europe/Warsaw, that dates and
+	// times are shown in and days start and end in: log timestamps, "today"
+	// and "tomorrow" for plans, listed times. Empty uses the system's, which
+	// is often UTC on servers.
+	TimeZone string `yaml:"timeZone"`
+
+	// TaxonomyFile is a YAML list of ingredients added to the built-in
+	// taxonomy, see recipes.TaxonomyEntry. When empty, taxonomy.yaml in the
+	// config directory is used if it exists.
+	TaxonomyFile string `yaml:"taxonomyFile"`
+
+	// DataDir is where the SQLite cache is kept when no database is
+	// configured, next to the telemetry choice. It comes from Dirs, not from
+	// the file.
+	DataDir string `yaml:"-"`
+}
+
+type Edamam struct {
+	AppID  string `yaml:"appID"`
+	AppKey string `yaml:"appKey"`
+}
+
+// TheMealDB needs no key for development use; APIKey is only set for a
+// paid key.
+type TheMealDB struct {
+	APIKey string `yaml:"apiKey"`
+}
+
+// FDC is USDA FoodData Central, where ingredient cards get their nutrition
+// and unit weights. Without an APIKey its rate-limited demo key is used.
+type FDC struct {
+	APIKey string `yaml:"apiKey"`
+}
+
+// Telegram is the chat bot "recipefinder bot" answers as. Token is the one
+// @BotFather gave it.
+type Telegram struct {
+	Token string `yaml:"token"`
+}
+
+// Telemetry is where anonymous usage metrics are sent once the user opts in
+// with "recipefinder telemetry enable". Nothing is sent while Endpoint is
+// empty.
+type Telemetry struct {
+	Endpoint string `yaml:"endpoint"`
+}
+
+// Reports is what becomes of the recipes reported with "recipefinder
+// report-issue".
+type Reports struct {
+	// Mode is "flag" to move reported recipes last with a warning, or
+	// "exclude" to drop them.
+	Mode string `yaml:"mode"`
+	// Endpoint receives each report as a JSON POST, such as the issue
+	// tracker of a recipe dataset. Reports are only kept locally while it is
+	// empty.
+	Endpoint string `yaml:"endpoint"`
+}
+
+// Sources is where recipes may come from, see recipes.SourcePolicy: each
+// source is a provider, a site such as example.com or an author.
+type Sources struct {
+	// BlockedRecipes are the IDs of recipes never shown.
+	BlockedRecipes []int    `yaml:"blockedRecipes"`
+	Blocked        []string `yaml:"blocked"`
+	// Allowed, when not empty, restricts results to these sources.
+	Allowed []string `yaml:"allowed"`
+}
+
+// Filters leave out recipes by their titles, see recipes.NewContentFilter.
+type Filters struct {
+	// Keywords are words or phrases matched as whole words, ignoring case.
+	Keywords []string `yaml:"keywords"`
+	// Patterns are regular expressions, matched ignoring case.
+	Patterns []string `yaml:"patterns"`
+}
+
+// Translation is how --translate-ingredients translates the ingredient names
+// the built-in dictionaries lack. Command is run with the language in
+// $RECIPEFINDER_LANG and the names on stdin, one per line, and prints their
+// English names one per line in the same order, for example a script calling
+// a translation API. Without it, the names are searched for as given.
+type Translation struct {
+	Command string `yaml:"command"`
+}
+
+// Region selects which of the configured availability lists applies.
+type Region struct {
+	Name string `yaml:"name"`
+	// RareIngredients maps a region name to ingredients that are hard to buy
+	// there.
+	RareIngredients map[string][]string `yaml:"rareIngredients"`
+	// Mode is "rank" to move recipes needing rare ingredients last, or
+	// "exclude" to drop them.
+	Mode string `yaml:"mode"`
+}
+
+// DB selects the recipe cache. URL, when set, names the backend directly
+// (sqlite:///path/to/cache.db, mysql://... or postgres://...) and the other fields are
+// ignored; otherwise they describe a MySQL server. With none of them set the
+// cache is a SQLite file in the data directory.
+type DB struct {
+	URL      string `yaml:"url"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Addr     string `yaml:"addr"`
+	Name     string `yaml:"name"`
+	// ShardDigits spreads the cached queries over tables, see
+	// store.Options.ShardDigits.
+	ShardDigits int `yaml:"shardDigits"`
+	// ManualMigrations leaves schema upgrades to "recipefinder migrate"
+	// instead of applying them whenever the cache is opened. Until then the
+	// cache is not used.
+	ManualMigrations bool `yaml:"manualMigrations"`
+	// RequireCache makes commands fail when the database is unreachable,
+	// rather than running without the cache.
+	RequireCache bool `yaml:"requireCache"`
+}
+
+// Jobs tunes the background job workers of "recipefinder jobs work" and the
+// server to the machine they run on.
+type Jobs struct {
+	// Workers is how many jobs run at once in one process.
+	Workers int `yaml:"workers"`
+	// ProviderConcurrency caps how many running jobs call each recipe
+	// provider at once, keyed by provider name. Providers left out are only
+	// limited by Workers.
+	ProviderConcurrency map[string]int `yaml:"providerConcurrency"`
+	// MaxQueued is how many jobs may wait in the queue before adding more
+	// fails, zero for no limit.
+	MaxQueued int `yaml:"maxQueued"`
+}
+
+// Validate checks the job settings.
+func (j Jobs) Validate() error {
+	if j.Workers < 1 {
+		return fmt.Errorf("invalid jobs workers %d, expected at least 1", j.Workers)
+	}
+	for provider, limit := range j.ProviderConcurrency {
+		if limit < 1 {
+			return fmt.Errorf("invalid jobs providerConcurrency %d for %s, expected at least 1", limit, provider)
+		}
+	}
+	if j.MaxQueued < 0 {
+		return fmt.Errorf("invalid jobs maxQueued %d, expected 0 or more", j.MaxQueued)
+	}
+	return nil
+}
+
+// Notifications are where commands that keep running, such as watch, report
+// what they find, besides printing it.
+type Notifications struct {
+	// WebhookURL receives every notification as a JSON POST.
+	WebhookURL string `yaml:"webhookURL"`
+	// WebhookSecrets sign the webhook's payloads, one signature each, see
+	// package webhook. None leaves them unsigned.
+	WebhookSecrets []string `yaml:"webhookSecrets"`
+	// Digest lists the low-priority events, such as new_matches, that the
+	// members get in a single daily digest at DigestTime rather than as they
+	// happen.
+	Digest []string `yaml:"digest"`
+	// DigestTime is the local time of day, as 15:04, digests are sent at.
+	DigestTime string `yaml:"digestTime"`
+}
+
+// Email is the SMTP server schedules send their results by email through.
+type Email struct {
+	Host string `yaml:"host"`
+	// Port is 587 by default, for STARTTLS.
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+// Schedule runs a saved search by "recipefinder schedule" at the times of a
+// cron expression, and sends the recipes it did not find the last time to a
+// webhook, by email or both.
+type Schedule struct {
+	// Search is the name of the saved search.
+	Search string `yaml:"search"`
+	// Cron is a five-field cron expression in local time, such as
+	// "0 17 * * *" for 17:00 every day.
+	Cron string `yaml:"cron"`
+	// WebhookURL receives the results as a JSON POST, signed with the
+	// notifications' webhookSecrets.
+	WebhookURL string `yaml:"webhookURL"`
+	// Email lists the addresses the results are mailed to.
+	Email []string `yaml:"email"`
+}
+
+// Validate checks that the schedule names a search and a destination, and
+// that the email server is configured when it sends email. The cron
+// expression is parsed by the schedule command.
+func (s Schedule) Validate(email Email) error {
+	if s.Search == "" || s.Cron == "" {
+		return errors.New("invalid schedule, search and cron are required")
+	}
+	if s.WebhookURL == "" && len(s.Email) == 0 {
+		return fmt.Errorf("invalid schedule of %s, expected a webhookURL or email addresses to send results to", s.Search)
+	}
+	if len(s.Email) > 0 && (email.Host == "" || email.From == "") {
+		return fmt.Errorf("schedule of %s sends email, which needs email.host and email.from", s.Search)
+	}
+	return nil
+}
+
+// DigestMinute returns DigestTime in minutes after midnight.
+func (n Notifications) DigestMinute() (int, error) {
+	at, err := time.Parse("15:04", n.DigestTime)
+	if err != nil {
+		return 0, fmt.Errorf("invalid notifications digestTime %q, expected a time such as 08:00", n.DigestTime)
+	}
+	return at.Hour()*60 + at.Minute(), nil
+}
+
+// Quota shares out the daily Spoonacular points between the processes using
+// one API key and database: every search reserves points in the database
+// while it runs, and none starts once the points spent and reserved would
+// exceed the quota.
+type Quota struct {
+	// ReservePoints is how many points a search reserves, zero to not
+	// coordinate spending at all.
+	ReservePoints float64 `yaml:"reservePoints"`
+	// DailyPoints is the daily quota of the API key. Zero uses the quota the
+	// API last reported.
+	DailyPoints float64 `yaml:"dailyPoints"`
+	// BudgetPoints is the most points to spend in a day, below the quota,
+	// zero for no limit. Requests that would go over it are refused.
+	BudgetPoints float64 `yaml:"budgetPoints"`
+}
+
+// Ranking weighs what the "score" sort order ranks recipes by, see
+// recipes.RankWeights: fewest missing ingredients, protein density, how close
+// their calories come to CalorieTarget and how quick they are.
+type Ranking struct {
+	Missing       float64 `yaml:"missing"`
+	Protein       float64 `yaml:"protein"`
+	Calories      float64 `yaml:"calories"`
+	Time          float64 `yaml:"time"`
+	CalorieTarget float64 `yaml:"calorieTarget"`
+}
+
+// Validate checks the weights.
+func (r Ranking) Validate() error {
+	if r.Missing < 0 || r.Protein < 0 || r.Calories < 0 || r.Time < 0 || r.CalorieTarget < 0 {
+		return errors.New("invalid ranking, weights and calorieTarget cannot be negative")
+	}
+	if r.Missing+r.Protein+r.Calories+r.Time == 0 {
+		return errors.New("invalid ranking, at least one weight must be positive")
+	}
+	return nil
+}
+
+// Experiment compares two rankings: while enabled, searches in the "score"
+// order interleave the recipes Ranking and the experiment's Ranking rank
+// best, and the recipes picked with "recipefinder experiment pick" tell which
+// of them ranks better.
+type Experiment struct {
+	Enabled bool    `yaml:"enabled"`
+	Ranking Ranking `yaml:"ranking"`
+}
+
+// Planning shapes the meal plans of "recipefinder plan", week by week.
+type Planning struct {
+	// CuisineLimits are the most meals of a cuisine a week may have, by
+	// cuisine such as italian: 2.
+	CuisineLimits map[string]int `yaml:"cuisineLimits"`
+	// NewCuisinesPerWeek is how many meals a week should be of a cuisine not
+	// cooked before, as logged with "recipefinder cooked".
+	NewCuisinesPerWeek int `yaml:"newCuisinesPerWeek"`
+	// Themes are recurring theme nights, see Theme.
+	Themes []Theme `yaml:"themes"`
+	// WeeklyBudget is the most the meals cooked in a week should cost, in
+	// dollars a serving, for the weeks under budget "recipefinder stats" and
+	// the digest count. Zero counts none.
+	WeeklyBudget float64 `yaml:"weeklyBudget"`
+}
+
+// Theme is a theme night, such as Taco Tuesday: the last meal of every
+// Weekday of a plan is of Cuisine and has Dish in its title, whichever of the
+// two is set.
+type Theme struct {
+	Name    string `yaml:"name"`
+	Weekday string `yaml:"weekday"`
+	Cuisine string `yaml:"cuisine"`
+	Dish    string `yaml:"dish"`
+}
+
+// Day parses Weekday, an English weekday name such as tuesday or its first
+// three letters.
+func (t Theme) Day() (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if weekday := strings.ToLower(t.Weekday); weekday == name || weekday == name[:3] {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday %q of the %s theme", t.Weekday, t.Name)
+}
+
+// Validate checks the limits.
+func (p Planning) Validate() error {
+	if p.NewCuisinesPerWeek < 0 || p.WeeklyBudget < 0 {
+		return errors.New("invalid planning, newCuisinesPerWeek and weeklyBudget cannot be negative")
+	}
+	for cuisine, limit := range p.CuisineLimits {
+		if limit < 0 {
+			return fmt.Errorf("invalid planning, the cuisineLimits of %s cannot be negative", cuisine)
+		}
+	}
+	weekdays := make(map[time.Weekday]string)
+	for _, theme := range p.Themes {
+		day, err := theme.Day()
+		if err != nil {
+			return err
+		}
+		if theme.Cuisine == "" && theme.Dish == "" {
+			return fmt.Errorf("invalid planning, the %s theme needs a cuisine or a dish", theme.Name)
+		}
+		if other, ok := weekdays[day]; ok {
+			return fmt.Errorf("invalid planning, the %s and %s themes are both on %s", other, theme.Name, day)
+		}
+		weekdays[day] = theme.Name
+	}
+	return nil
+}
+
+// Profile bundles what one person searches with. Its diets, intolerances and
+// nutrition targets apply unless the flags set them, its allergens are left
+// out on top of Config.Allergens, and it keeps a pantry of its own.
+type Profile struct {
+	Diets        []string `yaml:"diets"`
+	Intolerances []string `yaml:"intolerances"`
+	Allergens    []string `yaml:"allergens"`
+	MaxCalories  float64  `yaml:"maxCalories"`
+	MinProtein   float64  `yaml:"minProtein"`
+	MaxCarbs     float64  `yaml:"maxCarbs"`
+	// DailyGoals are compared with the meals of a plan, and the recipes
+	// picked from a session, a day's worth at a time.
+	DailyGoals DailyGoals `yaml:"dailyGoals"`
+	// EatingWindow is the time of day meals are eaten in, such as
+	// "12:00-20:00" for 16:8 fasting; empty for the usual 08:00-20:00.
+	EatingWindow string `yaml:"eatingWindow"`
+	// MealsPerDay is the number of meals a day of a plan has when
+	// --mealsPerDay is not given, 0 for its default.
+	MealsPerDay int `yaml:"mealsPerDay"`
+}
+
+// EatingMinutes returns the start and end of EatingWindow in minutes after
+// midnight, 0 and 0 when it is empty.
+func (p Profile) EatingMinutes() (int, int, error) {
+	if p.EatingWindow == "" {
+		return 0, 0, nil
+	}
+	from, to, found := strings.Cut(p.EatingWindow, "-")
+	start, startErr := time.Parse("15:04", strings.TrimSpace(from))
+	end, endErr := time.Parse("15:04", strings.TrimSpace(to))
+	if !found || startErr != nil || endErr != nil {
+		return 0, 0, fmt.Errorf("invalid eatingWindow %q, expected the form 12:00-20:00", p.EatingWindow)
+	}
+	startMinute, endMinute := start.Hour()*60+start.Minute(), end.Hour()*60+end.Minute()
+	if endMinute-startMinute < 60 {
+		return 0, 0, fmt.Errorf("invalid eatingWindow %q, it must last an hour at least and not span midnight",
+			p.EatingWindow)
+	}
+	return startMinute, endMinute, nil
+}
+
+// DailyGoals are how much of each nutrient to eat in a day, zero for no
+// goal: calories in kcal, the others in grams.
+type DailyGoals struct {
+	Calories      float64 `yaml:"calories"`
+	Protein       float64 `yaml:"protein"`
+	Carbohydrates float64 `yaml:"carbohydrates"`
+	Fat           float64 `yaml:"fat"`
+}
+
+// Location returns the time zone TimeZone names, the system's when it is
+// empty.
+func (c *Config) Location() (*time.Location, error) {
+	if c.TimeZone == "" {
+		return time.Local, nil
+	}
+	location, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeZone %q, expected an IANA name such as Europe/Warsaw: %v", c.TimeZone, err)
+	}
+	return location, nil
+}
+
+// SpoonacularKeys returns APIKey and APIKeys, without the empty and repeated
+// ones.
+func (c *Config) SpoonacularKeys() []string {
+	var keys []string
+	for _, key := range append([]string{c.APIKey}, c.APIKeys...) {
+		if key != "" && !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// ActiveProfile returns the profile chosen with Profile, the zero profile
+// when none is.
+func (c *Config) ActiveProfile() (Profile, error) {
+	if c.Profile == "" {
+		return Profile{}, nil
+	}
+	profile, ok := c.Profiles[c.Profile]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown profile %q, add it under profiles in the config file", c.Profile)
+	}
+	if profile.MaxCalories < 0 || profile.MinProtein < 0 || profile.MaxCarbs < 0 {
+		return Profile{}, fmt.Errorf("invalid profile %q, nutrition targets cannot be negative", c.Profile)
+	}
+	goals := profile.DailyGoals
+	if goals.Calories < 0 || goals.Protein < 0 || goals.Carbohydrates < 0 || goals.Fat < 0 {
+		return Profile{}, fmt.Errorf("invalid profile %q, daily goals cannot be negative", c.Profile)
+	}
+	if profile.MealsPerDay < 0 {
+		return Profile{}, fmt.Errorf("invalid profile %q, mealsPerDay cannot be negative", c.Profile)
+	}
+	_, _, err := profile.EatingMinutes()
+	if err != nil {
+		return Profile{}, fmt.Errorf("invalid profile %q: %v", c.Profile, err)
+	}
+	return profile, nil
+}
+
+type Log struct {
+	File       string `yaml:"file"`
+	MaxSizeMB  int64  `yaml:"maxSizeMB"`
+	MaxBackups int    `yaml:"maxBackups"`
+	// Format is text or json, empty for json when serving and text
+	// otherwise.
+	Format string `yaml:"format"`
+	// Level is the least severe level logged: debug, info, warn or error.
+	Level string `yaml:"level"`
+}
+
+func Default() *Config {
+	return &Config{
+		CacheTTL: 7 * 24 * time.Hour,
+		Timeout:  time.Minute,
+		Log: Log{
+			MaxSizeMB:  10,
+			MaxBackups: 3,
+			Level:      "info",
+		},
+		Region: Region{
+			Mode: "rank",
+		},
+		Reports: Reports{
+			Mode: "exclude",
+		},
+		Providers: []string{"spoonacular"},
+		Jobs: Jobs{
+			Workers: 1,
+		},
+		Quota: Quota{
+			ReservePoints: 3,
+		},
+		Notifications: Notifications{
+			DigestTime: "08:00",
+		},
+		Email: Email{
+			Port: 587,
+		},
+		Ranking: Ranking{
+			Missing:       4,
+			Protein:       1,
+			Calories:      1,
+			Time:          1,
+			CalorieTarget: 600,
+		},
+	}
+}
+
+// UsesMySQL reports whether the fields describe a MySQL server, which is the
+// case when any of them is set and URL is not.
+func (d DB) UsesMySQL() bool {
+	return d.URL == "" && (d.User != "" || d.Password != "" || d.Addr != "" || d.Name != "")
+}
+
+// WithMySQLDefaults fills the MySQL fields left empty with root@127.0.0.1:3306
+// and the recipe_finder database.
+func (d DB) WithMySQLDefaults() DB {
+	if d.User == "" {
+		d.User = "root"
+	}
+	if d.Addr == "" {
+		d.Addr = "127.0.0.1:3306"
+	}
+	if d.Name == "" {
+		d.Name = "recipe_finder"
+	}
+	return d
+}
+
+// Load builds a Config from the defaults, the file at path and the
+// environment. An empty path means config.yaml in dirs.Config, which may be
+// missing; an explicitly given file must exist.
+func Load(path string, dirs Dirs) (*Config, error) {
+	cfg := Default()
+	cfg.DataDir = dirs.Data
+
+	explicit := path != ""
+	if !explicit {
+		path = filepath.Join(dirs.Config, "config.yaml")
+	}
+
+	err := cfg.loadFile(path)
+	if err != nil && (explicit || !errors.Is(err, os.ErrNotExist)) {
+		return nil, err
+	}
+
+	cfg.loadEnv()
+	if cfg.TaxonomyFile == "" {
+		taxonomyFile := filepath.Join(dirs.Config, "taxonomy.yaml")
+		if _, err := os.Stat(taxonomyFile); err == nil {
+			cfg.TaxonomyFile = taxonomyFile
+		}
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+	err = yaml.Unmarshal(data, c)
+	if err != nil {
+		return fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+	return nil
+}
+
+func (c *Config) loadEnv() {
+	setSecretFromEnv(&c.APIKey, "RECIPEFINDER_API_KEY")
+	setFromEnv(&c.Profile, "RECIPEFINDER_PROFILE")
+	setSecretFromEnv(&c.DB.URL, "RECIPEFINDER_DB_URL")
+	setFromEnv(&c.DB.User, "RECIPEFINDER_DB_USER")
+	setSecretFromEnv(&c.DB.Password, "RECIPEFINDER_DB_PASSWORD")
+	setFromEnv(&c.DB.Addr, "RECIPEFINDER_DB_ADDR")
+	setFromEnv(&c.DB.Name, "RECIPEFINDER_DB_NAME")
+	setFromEnv(&c.Edamam.AppID, "RECIPEFINDER_EDAMAM_APP_ID")
+	setSecretFromEnv(&c.Edamam.AppKey, "RECIPEFINDER_EDAMAM_APP_KEY")
+	setSecretFromEnv(&c.FDC.APIKey, "RECIPEFINDER_FDC_API_KEY")
+	setSecretFromEnv(&c.Telegram.Token, "RECIPEFINDER_TELEGRAM_TOKEN")
+	setFromEnv(&c.TimeZone, "RECIPEFINDER_TIME_ZONE")
+	setFromEnv(&c.Telemetry.Endpoint, "RECIPEFINDER_TELEMETRY_ENDPOINT")
+}
+
+func setFromEnv(field *string, name string) {
+	if value, ok := os.LookupEnv(name); ok {
+		*field = value
+	}
+}
+
+// Validate checks that the settings needed to talk to the API are present.
+// The Spoonacular API key is only needed when Spoonacular is a provider.
+func (c *Config) Validate() error {
+	if len(c.Providers) == 0 {
+		return errors.New("no recipe providers configured")
+	}
+	if c.Region.Mode != "rank" && c.Region.Mode != "exclude" {
+		return fmt.Errorf("invalid region mode %q, expected rank or exclude", c.Region.Mode)
+	}
+	if c.Reports.Mode != "flag" && c.Reports.Mode != "exclude" {
+		return fmt.Errorf("invalid reports mode %q, expected flag or exclude", c.Reports.Mode)
+	}
+	if c.Region.Name != "" {
+		if _, ok := c.Region.RareIngredients[c.Region.Name]; !ok {
+			return fmt.Errorf("region %q has no rareIngredients list in the config file", c.Region.Name)
+		}
+	}
+	if c.DB.ShardDigits < 0 || c.DB.ShardDigits > 2 {
+		return fmt.Errorf("invalid db shardDigits %d, expected 0, 1 or 2", c.DB.ShardDigits)
+	}
+	err := c.Jobs.Validate()
+	if err != nil {
+		return err
+	}
+	if c.Quota.ReservePoints < 0 || c.Quota.DailyPoints < 0 || c.Quota.BudgetPoints < 0 {
+		return errors.New("invalid quota, reservePoints, dailyPoints and budgetPoints cannot be negative")
+	}
+	err = c.Ranking.Validate()
+	if err != nil {
+		return err
+	}
+	if c.Experiment.Enabled {
+		err := c.Experiment.Ranking.Validate()
+		if err != nil {
+			return fmt.Errorf("invalid experiment: %v", err)
+		}
+	}
+	err = c.Planning.Validate()
+	if err != nil {
+		return err
+	}
+	_, err = c.Notifications.DigestMinute()
+	if err != nil {
+		return err
+	}
+	for _, schedule := range c.Schedules {
+		err := schedule.Validate(c.Email)
+		if err != nil {
+			return err
+		}
+	}
+	for _, pattern := range c.Filters.Patterns {
+		_, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid filters pattern %q: %v", pattern, err)
+		}
+	}
+	// Checked last, so searches that call no API can ignore it
+	if len(c.SpoonacularKeys()) == 0 && slices.Contains(c.Providers, "spoonacular") {
+		return ErrNoAPIKey
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Dirs are where recipefinder keeps its files: Config holds config.yaml and
+// Data holds the SQLite cache.
+type Dirs struct {
+	Config string
+	Data   string
+}
+
+// DefaultDirs returns the per-user directories of the operating system:
+// os.UserConfigDir for the config and the platform's data directory
+// (%LocalAppData% on Windows, ~/Library/Application Support on macOS,
+// $XDG_DATA_HOME or ~/.local/share elsewhere) for the cache. A ~/.recipefinder
+// directory left by older versions is still used for both when it exists.
+func DefaultDirs() (Dirs, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return Dirs{}, fmt.Errorf("error locating home directory: %v", err)
+	}
+	legacy := filepath.Join(home, ".recipefinder")
+	if info, err := os.Stat(legacy); err == nil && info.IsDir() {
+		return Dirs{Config: legacy, Data: legacy}, nil
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return Dirs{}, fmt.Errorf("error locating config directory: %v", err)
+	}
+	dataDir, err := userDataDir(home)
+	if err != nil {
+		return Dirs{}, err
+	}
+	return Dirs{
+		Config: filepath.Join(configDir, "recipefinder"),
+		Data:   filepath.Join(dataDir, "recipefinder"),
+	}, nil
+}
+
+// PortableDirs keeps everything in the directory of the executable, so the
+// program can run from a USB stick or an unzipped folder.
+func PortableDirs() (Dirs, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return Dirs{}, fmt.Errorf("error locating executable: %v", err)
+	}
+	executable, err = filepath.EvalSymlinks(executable)
+	if err != nil {
+		return Dirs{}, fmt.Errorf("error locating executable: %v", err)
+	}
+	dir := filepath.Dir(executable)
+	return Dirs{Config: dir, Data: dir}, nil
+}
+
+func userDataDir(home string) (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		dir := os.Getenv("LocalAppData")
+		if dir == "" {
+			return "", errors.New("%LocalAppData% is not set")
+		}
+		return dir, nil
+	case "darwin", "ios":
+		return filepath.Join(home, "Library", "Application Support"), nil
+	default:
+		if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+			return dir, nil
+		}
+		return filepath.Join(home, ".local", "share"), nil
+	}
+}
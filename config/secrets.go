@@ -0,0 +1,150 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// secretCommandTimeout bounds an exec: secret reference, such as a call to
+// Vault or SOPS.
+const secretCommandTimeout = 30 * time.Second
+
+// A secret setting, such as apiKey or db.password, may hold a reference to
+// where the secret is kept instead of the secret itself, so it never has to
+// appear in the config file, the environment or the command line:
+//
+//	file:<path>        the contents of the file
+//	credential:<name>  the systemd credential of that name, the file in
+//	                   $CREDENTIALS_DIRECTORY (see LoadCredential= in
+//	                   systemd.exec)
+//	exec:<command>     the output of the shell command, for example
+//	                   "vault kv get -field=apiKey secret/recipefinder" or
+//	                   "sops -d --extract '[\"apiKey\"]' secrets.yaml"
+//
+// Surrounding whitespace, such as the trailing newline of a file, is dropped.
+// References are resolved by ResolveSecrets.
+var secretPrefixes = []string{"file:", "credential:", "exec:"}
+
+// secrets are the settings that may hold a secret reference, by name.
+func (c *Config) secrets() map[string]*string {
+	secrets := map[string]*string{
+		"apiKey":           &c.APIKey,
+		"db.url":           &c.DB.URL,
+		"db.password":      &c.DB.Password,
+		"edamam.appKey":    &c.Edamam.AppKey,
+		"theMealDB.apiKey": &c.TheMealDB.APIKey,
+		"fdc.apiKey":       &c.FDC.APIKey,
+		"telegram.token":   &c.Telegram.Token,
+		"email.password":   &c.Email.Password,
+		"sentryDSN":        &c.SentryDSN,
+	}
+	for i := range c.APIKeys {
+		secrets[fmt.Sprintf("apiKeys[%d]", i)] = &c.APIKeys[i]
+	}
+	for i := range c.Notifications.WebhookSecrets {
+		secrets[fmt.Sprintf("notifications.webhookSecrets[%d]", i)] = &c.Notifications.WebhookSecrets[i]
+	}
+	return secrets
+}
+
+// WithoutSecrets returns a copy of the settings with every secret setting
+// empty, for writing them out where the secrets must not go.
+func (c *Config) WithoutSecrets() *Config {
+	clean := *c
+	clean.APIKeys = nil
+	clean.Notifications.WebhookSecrets = nil
+	for _, value := range clean.secrets() {
+		*value = ""
+	}
+	return &clean
+}
+
+// ResolveSecrets replaces the secret references in the secret settings with
+// the secrets they refer to. It is called once every source of settings has
+// been applied, so only the references in use are read.
+func (c *Config) ResolveSecrets(ctx context.Context) error {
+	for name, value := range c.secrets() {
+		secret, err := resolveSecret(ctx, *value)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", name, err)
+		}
+		*value = secret
+	}
+	return nil
+}
+
+func resolveSecret(ctx context.Context, value string) (string, error) {
+	kind, reference, found := strings.Cut(value, ":")
+	if !found {
+		return value, nil
+	}
+	switch kind {
+	case "file":
+		data, err := os.ReadFile(reference)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	case "credential":
+		dir := os.Getenv("CREDENTIALS_DIRECTORY")
+		if dir == "" {
+			return "", fmt.Errorf("no systemd credentials, $CREDENTIALS_DIRECTORY is not set to read %s from", reference)
+		}
+		if reference == "" || strings.ContainsAny(reference, `/\`) {
+			return "", fmt.Errorf("invalid credential name %q", reference)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, reference))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	case "exec":
+		return runSecretCommand(ctx, reference)
+	}
+	// A URL or a key that happens to hold a colon
+	return value, nil
+}
+
+func runSecretCommand(ctx context.Context, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, secretCommandTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stderr.Len() > 0 {
+			return "", fmt.Errorf("%q failed: %s", command, strings.TrimSpace(stderr.String()))
+		}
+		return "", fmt.Errorf("%q failed: %v", command, err)
+	}
+	secret := strings.TrimSpace(string(out))
+	if secret == "" {
+		return "", fmt.Errorf("%q printed nothing", command)
+	}
+	return secret, nil
+}
+
+// setSecretFromEnv is setFromEnv for secret settings, which may also be
+// read from the file named by the variable with _FILE appended, the
+// convention of Docker and Kubernetes secrets.
+func setSecretFromEnv(field *string, name string) {
+	if path, ok := os.LookupEnv(name + "_FILE"); ok {
+		*field = "file:" + path
+	}
+	setFromEnv(field, name)
+}
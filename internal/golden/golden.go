@@ -0,0 +1,57 @@
+// Package golden compares the output of tests with golden files kept in the
+// testdata directory of the package under test, so changes to an output show
+// up in review as changes to its golden file. Running the tests with
+// -update-golden rewrites the golden files with the current output instead.
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update-golden", false, "Rewrite the golden files in testdata with the current output")
+
+// Check compares got with the golden file testdata/name.
+func Check(t testing.TB, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		err := os.WriteFile(path, got, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v, run the tests with -update-golden to create it", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s, run the tests with -update-golden if the change is intended:\n%s", path, got)
+	}
+}
+
+// CheckJSON compares got, encoded as indented JSON, with the golden file
+// testdata/name.
+func CheckJSON(t testing.TB, name string, got any) {
+	t.Helper()
+	encoded, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Check(t, name, append(encoded, '\n'))
+}
+
+// Fixture returns the contents of testdata/name, an input of the test such as
+// a recorded API response.
+func Fixture(t testing.TB, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
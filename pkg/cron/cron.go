@@ -0,0 +1,153 @@
+// Package cron parses the five-field cron expressions of crontab(5) and
+// finds the times they match.
+//
+// The fields are minute (0-59), hour (0-23), day of month (1-31), month
+// (1-12) and day of week (0-7, both 0 and 7 being Sunday). Each is "*", a
+// number, a range such as 1-5, any of those followed by a step such as */15,
+// or a comma-separated list of them. As in cron, when both the day of month
+// and the day of week are restricted, a day matching either matches.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearch bounds how far ahead Next looks, so expressions that never match,
+// such as February 30th, end the search.
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	minutes, hours, days, months, weekdays uint64
+	// anyDay and anyWeekday are set when the field starts with "*", for the
+	// rule of matching either day field.
+	anyDay, anyWeekday bool
+}
+
+// field describes the range of a field.
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = [...]field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// Parse parses a cron expression such as "0 17 * * 1-5".
+func Parse(expression string) (Schedule, error) {
+	parts := strings.Fields(expression)
+	if len(parts) != len(fields) {
+		return Schedule{}, fmt.Errorf("invalid cron expression %q, expected 5 fields: minute hour day month weekday",
+			expression)
+	}
+	var bits [len(fields)]uint64
+	for i, part := range parts {
+		var err error
+		bits[i], err = parseField(part, fields[i])
+		if err != nil {
+			return Schedule{}, fmt.Errorf("invalid cron expression %q: %v", expression, err)
+		}
+	}
+	// Sunday is both 0 and 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	return Schedule{
+		minutes:    bits[0],
+		hours:      bits[1],
+		days:       bits[2],
+		months:     bits[3],
+		weekdays:   bits[4],
+		anyDay:     strings.HasPrefix(parts[2], "*"),
+		anyWeekday: strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// parseField returns the values a field matches as bits.
+func parseField(part string, f field) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(part, ",") {
+		span, stepText, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepText)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q in the %s field", stepText, f.name)
+			}
+		}
+		low, high := f.min, f.max
+		if span != "*" {
+			first, last, isRange := strings.Cut(span, "-")
+			var err error
+			low, err = parseValue(first, f)
+			if err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				high, err = parseValue(last, f)
+				if err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// "5/15" is every 15 from 5 on
+				high = f.max
+			}
+			if high < low {
+				return 0, fmt.Errorf("invalid range %q in the %s field", span, f.name)
+			}
+		}
+		for value := low; value <= high; value += step {
+			bits |= 1 << value
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(text string, f field) (int, error) {
+	value, err := strconv.Atoi(text)
+	if err != nil || value < f.min || value > f.max {
+		return 0, fmt.Errorf("invalid %s %q, expected %d to %d", f.name, text, f.min, f.max)
+	}
+	return value, nil
+}
+
+// Next returns the first time after t that the schedule matches, in t's
+// location, or the zero time when it matches none in the next five years.
+func (s Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	end := t.Add(maxSearch)
+	for t.Before(end) {
+		switch {
+		case s.months&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hours&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minutes&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s Schedule) matchesDay(t time.Time) bool {
+	day := s.days&(1<<t.Day()) != 0
+	weekday := s.weekdays&(1<<int(t.Weekday())) != 0
+	if s.anyDay || s.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
@@ -0,0 +1,45 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2026, time.October, 14, 17, 30, 0, 0, time.UTC)
+	for _, test := range []struct {
+		expression string
+		want       time.Time
+	}{
+		{"* * * * *", time.Date(2026, time.October, 14, 17, 31, 0, 0, time.UTC)},
+		{"0 17 * * *", time.Date(2026, time.October, 15, 17, 0, 0, 0, time.UTC)},
+		{"*/20 * * * *", time.Date(2026, time.October, 14, 17, 40, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2026, time.October, 15, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 0", time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2026, time.October, 18, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"15,45 8-10/2 * * *", time.Date(2026, time.October, 15, 8, 15, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted
+		{"0 12 20 * 5", time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	} {
+		schedule, err := Parse(test.expression)
+		if err != nil {
+			t.Errorf("%q: %v", test.expression, err)
+			continue
+		}
+		if got := schedule.Next(from); !got.Equal(test.want) {
+			t.Errorf("%q: got %v, want %v", test.expression, got, test.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expression := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *",
+		"* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := Parse(expression); err == nil {
+			t.Errorf("%q: got no error", expression)
+		}
+	}
+}
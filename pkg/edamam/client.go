@@ -0,0 +1,243 @@
+// Package edamam is a client for the Edamam Recipe Search API.
+package edamam
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+const baseURL = "https://api.edamam.com/api/recipes/v2"
+
+// ErrNoCredentials is returned by Search when no application ID and key were
+// configured.
+var ErrNoCredentials = errors.New("no Edamam credentials configured: set edamam.appID and edamam.appKey")
+
+type Response struct {
+	Hits []struct {
+		Recipe struct {
+			URI         string   `json:"uri"`
+			Label       string   `json:"label"`
+			Image       string   `json:"image"`
+			URL         string   `json:"url"`
+			Source      string   `json:"source"`
+			CuisineType []string `json:"cuisineType"`
+			Yield       float64  `json:"yield"`
+			TotalTime   float64  `json:"totalTime"`
+			Ingredients []struct {
+				Text     string  `json:"text"`
+				Food     string  `json:"food"`
+				Quantity float64 `json:"quantity"`
+				Measure  string  `json:"measure"`
+				Weight   float64 `json:"weight"`
+			} `json:"ingredients"`
+			TotalNutrients map[string]struct {
+				Label    string  `json:"label"`
+				Quantity float64 `json:"quantity"`
+				Unit     string  `json:"unit"`
+			} `json:"totalNutrients"`
+		} `json:"recipe"`
+	} `json:"hits"`
+}
+
+// healthLabels maps the canonical diets and intolerances to Edamam's health
+// labels. Filters missing here cannot be expressed.
+var healthLabels = map[string][]string{
+	"gluten free": {"gluten-free"},
+	"ketogenic":   {"keto-friendly"},
+	"vegetarian":  {"vegetarian"},
+	"vegan":       {"vegan"},
+	"pescetarian": {"pescatarian"},
+	"paleo":       {"paleo"},
+	"low fodmap":  {"fodmap-free"},
+	"dairy":       {"dairy-free"},
+	"egg":         {"egg-free"},
+	"gluten":      {"gluten-free"},
+	"peanut":      {"peanut-free"},
+	"seafood":     {"fish-free", "shellfish-free"},
+	"sesame":      {"sesame-free"},
+	"shellfish":   {"shellfish-free"},
+	"soy":         {"soy-free"},
+	"sulfite":     {"sulfite-free"},
+	"tree nut":    {"tree-nut-free"},
+	"wheat":       {"wheat-free"},
+}
+
+// nutrientCodes maps Edamam's nutrient codes to the names the rest of the
+// program uses.
+var nutrientCodes = map[string]string{
+	"ENERC_KCAL": "Calories",
+	"CHOCDF":     "Carbohydrates",
+	"PROCNT":     "Protein",
+}
+
+// Client talks to Edamam. It is safe for concurrent use.
+type Client struct {
+	// HTTPClient makes the requests, http.DefaultClient when nil.
+	HTTPClient *http.Client
+
+	appID  string
+	appKey string
+}
+
+func NewClient(appID string, appKey string) *Client {
+	return &Client{appID: appID, appKey: appKey}
+}
+
+func (c *Client) Name() string {
+	return "edamam"
+}
+
+// Ping checks that the API answers, without spending quota: any response to
+// a HEAD request of its base URL will do.
+func (c *Client) Ping(ctx context.Context) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, baseURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("error reaching %s: %w", baseURL, err)
+	}
+	return resp.Body.Close()
+}
+
+// Search looks for recipes containing the query's ingredients. Nutrients are
+// given per serving, like Spoonacular's.
+func (c *Client) Search(ctx context.Context, search recipes.Query) ([]recipes.Recipe, error) {
+	if c.appID == "" || c.appKey == "" {
+		return nil, ErrNoCredentials
+	}
+
+	query := url.Values{}
+	query.Set("type", "public")
+	query.Set("app_id", c.appID)
+	query.Set("app_key", c.appKey)
+	query.Set("q", strings.Join(search.Ingredients, " "))
+	for _, filter := range append(append([]string(nil), search.Diets...), search.Intolerances...) {
+		labels, ok := healthLabels[filter]
+		if !ok {
+			return nil, fmt.Errorf("edamam cannot filter on %q: %w", filter, recipes.ErrUnsupportedQuery)
+		}
+		for _, label := range labels {
+			query.Add("health", label)
+		}
+	}
+	for _, ingredient := range search.Exclude {
+		query.Add("excluded", ingredient)
+	}
+	if len(search.Prep.Equipment) > 0 || len(search.Prep.NoEquipment) > 0 {
+		return nil, fmt.Errorf("edamam has no equipment data: %w", recipes.ErrUnsupportedQuery)
+	}
+	if search.Prep.MaxReadyTime > 0 {
+		query.Set("time", fmt.Sprintf("1-%d", search.Prep.MaxReadyTime))
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	// The application ID is not secret, only the key is
+	query.Set("app_key", "REDACTED")
+	slog.Debug("requesting", "url", baseURL+"?"+query.Encode())
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching URL: %w", err)
+	}
+	defer func() {
+		err := resp.Body.Close()
+		if err != nil {
+			slog.Warn("error closing response body", "error", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	var response Response
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %v", err)
+	}
+
+	allRecipes := parseResponse(&response, search.Ingredients)
+	if len(allRecipes) > search.NumberOfRecipes {
+		allRecipes = allRecipes[:search.NumberOfRecipes]
+	}
+	return allRecipes, nil
+}
+
+func parseResponse(response *Response, ingredientList []string) []recipes.Recipe {
+	allRecipes := make([]recipes.Recipe, 0, len(response.Hits))
+	for _, hit := range response.Hits {
+		servings := hit.Recipe.Yield
+		if servings <= 0 {
+			servings = 1
+		}
+
+		nutrients := make(map[string]recipes.NutrientAmount, len(nutrientCodes))
+		for code, name := range nutrientCodes {
+			if nutrient, ok := hit.Recipe.TotalNutrients[code]; ok {
+				nutrients[name] = recipes.NutrientAmount{Amount: nutrient.Quantity, Unit: nutrient.Unit}.Mul(1 / servings)
+			}
+		}
+
+		ingredients := make([]recipes.Ingredient, 0, len(hit.Recipe.Ingredients))
+		for _, ingredient := range hit.Recipe.Ingredients {
+			unit := ingredient.Measure
+			if unit == "<unit>" {
+				unit = ""
+			}
+			ingredients = append(ingredients, recipes.Ingredient{
+				Name:   strings.ToLower(ingredient.Food),
+				Amount: ingredient.Quantity,
+				Unit:   unit,
+				Forms:  recipes.TagForms(ingredient.Text),
+			})
+		}
+		used, missed := recipes.MatchIngredients(ingredients, ingredientList)
+
+		allRecipes = append(allRecipes, recipes.Recipe{
+			ID:                recipeID(hit.Recipe.URI),
+			Title:             hit.Recipe.Label,
+			UsedIngredients:   used,
+			MissedIngredients: missed,
+			Nutrients:         nutrients,
+			Servings:          int(servings),
+			ReadyInMinutes:    int(hit.Recipe.TotalTime),
+			ImageURL:          hit.Recipe.Image,
+			SourceURL:         hit.Recipe.URL,
+			Author:            hit.Recipe.Source,
+			Cuisines:          recipes.Cuisines(hit.Recipe.CuisineType),
+			Source:            "edamam",
+			// The totals Edamam gives are divided by its yield
+			Estimated: []string{recipes.EstimatedNutrition},
+		})
+	}
+	recipes.SortByMissing(allRecipes)
+	return allRecipes
+}
+
+// recipeID derives a numeric ID from Edamam's recipe URI, since the cache
+// keys recipes by number.
+func recipeID(uri string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(uri))
+	return int(hash.Sum32() & 0x7fffffff)
+}
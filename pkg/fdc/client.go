@@ -0,0 +1,144 @@
+// Package fdc is a client for USDA FoodData Central, the nutrition and
+// household measures of foods.
+package fdc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+const baseURL = "https://api.nal.usda.gov/fdc/v1"
+
+// DemoKey is the key FoodData Central accepts without signing up, limited to
+// a few requests an hour.
+const DemoKey = "DEMO_KEY"
+
+// ErrNotFound is returned by Food when no food matches the name.
+var ErrNotFound = errors.New("no food found in FoodData Central")
+
+type Response struct {
+	Foods []struct {
+		Description   string `json:"description"`
+		FoodNutrients []struct {
+			NutrientName string  `json:"nutrientName"`
+			UnitName     string  `json:"unitName"`
+			Value        float64 `json:"value"`
+		} `json:"foodNutrients"`
+		FoodMeasures []struct {
+			DisseminationText string  `json:"disseminationText"`
+			GramWeight        float64 `json:"gramWeight"`
+		} `json:"foodMeasures"`
+	} `json:"foods"`
+}
+
+// nutrientNames maps FoodData Central's nutrient names to the names the rest
+// of the program uses.
+var nutrientNames = map[string]string{
+	"Energy":                       "Calories",
+	"Protein":                      "Protein",
+	"Carbohydrate, by difference":  "Carbohydrates",
+	"Total lipid (fat)":            "Fat",
+	"Fiber, total dietary":         "Fiber",
+	"Sugars, total including NLEA": "Sugar",
+}
+
+// Client talks to FoodData Central. It is safe for concurrent use.
+type Client struct {
+	// HTTPClient makes the requests, http.DefaultClient when nil.
+	HTTPClient *http.Client
+
+	apiKey string
+}
+
+// NewClient returns a client using the API key, or DemoKey when it is empty.
+func NewClient(apiKey string) *Client {
+	if apiKey == "" {
+		apiKey = DemoKey
+	}
+	return &Client{apiKey: apiKey}
+}
+
+// Food looks up the generic food best matching an ingredient name, from the
+// Foundation and SR Legacy data whose nutrients are given per 100 g.
+func (c *Client) Food(ctx context.Context, name string) (*recipes.FoodData, error) {
+	query := url.Values{}
+	query.Set("api_key", c.apiKey)
+	query.Set("query", name)
+	query.Set("dataType", "Foundation,SR Legacy")
+	query.Set("pageSize", "1")
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/foods/search?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	query.Set("api_key", "REDACTED")
+	redacted := baseURL + "/foods/search?" + query.Encode()
+	slog.Debug("requesting", "url", redacted)
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(request)
+	if err != nil {
+		// The error names the URL, key included
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = redacted
+		}
+		return nil, fmt.Errorf("error fetching URL: %w", err)
+	}
+	defer func() {
+		err := resp.Body.Close()
+		if err != nil {
+			slog.Warn("error closing response body", "error", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	var response Response
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %v", err)
+	}
+	if len(response.Foods) == 0 {
+		return nil, ErrNotFound
+	}
+	return parseFood(response), nil
+}
+
+func parseFood(response Response) *recipes.FoodData {
+	food := response.Foods[0]
+	data := &recipes.FoodData{
+		Description: food.Description,
+		Nutrients:   make(map[string]recipes.NutrientAmount, len(nutrientNames)),
+	}
+	for _, nutrient := range food.FoodNutrients {
+		name, ok := nutrientNames[nutrient.NutrientName]
+		unit := strings.ToLower(nutrient.UnitName)
+		// Energy is given both in kcal and kJ
+		if !ok || name == "Calories" && unit != "kcal" {
+			continue
+		}
+		data.Nutrients[name] = recipes.NutrientAmount{Amount: nutrient.Value, Unit: unit}
+	}
+	for _, measure := range food.FoodMeasures {
+		if measure.DisseminationText == "" || measure.GramWeight <= 0 {
+			continue
+		}
+		data.UnitWeights = append(data.UnitWeights, recipes.UnitWeight{
+			Unit:  measure.DisseminationText,
+			Grams: measure.GramWeight,
+		})
+	}
+	return data
+}
@@ -0,0 +1,284 @@
+// Package metrics keeps counters, histograms and gauges and writes them in
+// the Prometheus text exposition format, for scraping from a /metrics
+// endpoint.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are histogram buckets for request durations in seconds,
+// from 5 ms to 10 s.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry holds metrics in the order they were added. It is safe for
+// concurrent use.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+type metric interface {
+	write(w io.Writer) error
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) add(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+// Write writes every metric in the text exposition format, the series of
+// each sorted by their label values.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+	for _, m := range metrics {
+		err := m.write(w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler serves the metrics for scraping.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = r.Write(w)
+	})
+}
+
+// desc is what every metric has: a name, help text and label names.
+type desc struct {
+	name       string
+	help       string
+	labelNames []string
+}
+
+func (d desc) header(w io.Writer, kind string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, kind)
+	return err
+}
+
+// labels formats label pairs, extra ones such as le last, as {a="x",b="y"},
+// or an empty string without any.
+func (d desc) labels(values []string, extra ...string) string {
+	var pairs []string
+	for i, name := range d.labelNames {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, values[i]))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", extra[i], extra[i+1]))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// checkLabels panics on a wrong number of label values, a programming error.
+func (d desc) checkLabels(values []string) {
+	if len(values) != len(d.labelNames) {
+		panic(fmt.Sprintf("metric %s takes %d label values, got %d", d.name, len(d.labelNames), len(values)))
+	}
+}
+
+// Counter is a count that only goes up, one per combination of label values.
+type Counter struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labelValues []string
+	value       float64
+}
+
+// Counter adds a counter with the label names.
+func (r *Registry) Counter(name string, help string, labelNames ...string) *Counter {
+	c := &Counter{desc: desc{name, help, labelNames}, series: make(map[string]*counterSeries)}
+	r.add(c)
+	return c
+}
+
+// Inc adds one to the series of the label values.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds a non-negative value to the series of the label values.
+func (c *Counter) Add(value float64, labelValues ...string) {
+	c.checkLabels(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := strings.Join(labelValues, "\xff")
+	series, ok := c.series[key]
+	if !ok {
+		series = &counterSeries{labelValues: labelValues}
+		c.series[key] = series
+	}
+	series.value += value
+}
+
+// Value returns the count of the label values.
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	series, ok := c.series[strings.Join(labelValues, "\xff")]
+	if !ok {
+		return 0
+	}
+	return series.value
+}
+
+func (c *Counter) write(w io.Writer) error {
+	err := c.header(w, "counter")
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range sortedKeys(c.series) {
+		series := c.series[key]
+		_, err := fmt.Fprintf(w, "%s%s %s\n", c.name, c.labels(series.labelValues), formatValue(series.value))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Histogram counts observations, such as durations, in cumulative buckets,
+// one set per combination of label values.
+type Histogram struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+// Histogram adds a histogram with the bucket upper bounds, in increasing
+// order, and the label names.
+func (r *Registry) Histogram(name string, help string, buckets []float64, labelNames ...string) *Histogram {
+	h := &Histogram{desc: desc{name, help, labelNames}, buckets: buckets, series: make(map[string]*histogramSeries)}
+	r.add(h)
+	return h
+}
+
+// Observe records a value in the series of the label values.
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.checkLabels(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := strings.Join(labelValues, "\xff")
+	series, ok := h.series[key]
+	if !ok {
+		series = &histogramSeries{labelValues: labelValues, counts: make([]uint64, len(h.buckets))}
+		h.series[key] = series
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			series.counts[i]++
+		}
+	}
+	series.count++
+	series.sum += value
+}
+
+func (h *Histogram) write(w io.Writer) error {
+	err := h.header(w, "histogram")
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range sortedKeys(h.series) {
+		series := h.series[key]
+		for i, bound := range h.buckets {
+			_, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labels(series.labelValues, "le", formatValue(bound)),
+				series.counts[i])
+			if err != nil {
+				return err
+			}
+		}
+		_, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.name, h.labels(series.labelValues, "le", "+Inf"), series.count,
+			h.name, h.labels(series.labelValues), formatValue(series.sum),
+			h.name, h.labels(series.labelValues), series.count)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gaugeFunc is a gauge read when the metrics are written.
+type gaugeFunc struct {
+	desc
+	value func() (float64, bool)
+}
+
+// GaugeFunc adds a gauge without labels whose value is read from the
+// function every time the metrics are written. The gauge is left out while
+// the function reports its value as unknown.
+func (r *Registry) GaugeFunc(name string, help string, value func() (float64, bool)) {
+	r.add(gaugeFunc{desc: desc{name: name, help: help}, value: value})
+}
+
+func (g gaugeFunc) write(w io.Writer) error {
+	value, ok := g.value()
+	if !ok {
+		return nil
+	}
+	err := g.header(w, "gauge")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s %s\n", g.name, formatValue(value))
+	return err
+}
+
+func sortedKeys[V any](series map[string]V) []string {
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
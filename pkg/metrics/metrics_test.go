@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	registry := NewRegistry()
+	requests := registry.Counter("requests_total", "Requests made.", "code")
+	duration := registry.Histogram("duration_seconds", "How long they took.", []float64{0.1, 1})
+	registry.GaugeFunc("unknown", "Left out.", func() (float64, bool) { return 0, false })
+	registry.GaugeFunc("ratio", "A share.", func() (float64, bool) { return 0.5, true })
+	requests.Inc("500")
+	requests.Add(2, "200")
+	duration.Observe(0.05)
+	duration.Observe(0.5)
+
+	var written bytes.Buffer
+	err := registry.Write(&written)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP requests_total Requests made.
+# TYPE requests_total counter
+requests_total{code="200"} 2
+requests_total{code="500"} 1
+# HELP duration_seconds How long they took.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{le="0.1"} 1
+duration_seconds_bucket{le="1"} 2
+duration_seconds_bucket{le="+Inf"} 2
+duration_seconds_sum 0.55
+duration_seconds_count 2
+# HELP ratio A share.
+# TYPE ratio gauge
+ratio 0.5
+`
+	if written.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", written.String(), want)
+	}
+}
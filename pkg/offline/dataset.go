@@ -0,0 +1,339 @@
+package offline
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// IndexFile is the name of the index "recipefinder dataset build" writes to
+// the data directory. When it exists it replaces the bundled dataset.
+const IndexFile = "offline-index.json"
+
+// datasetIDBase is added to the line number of recipes without an ID, keeping
+// them apart from the IDs of the bundled recipes and of the online providers.
+const datasetIDBase = 800000000
+
+// maxLineSize is the longest dataset line read; recipes with very long
+// instructions can exceed bufio.Scanner's default of 64 KiB.
+const maxLineSize = 16 << 20
+
+// datasetLine is one recipe of a user-supplied JSON Lines dataset. Ingredients
+// are objects like the bundled ones or plain lines such as "2 cups flour".
+// Nutrients are per serving, keyed by nutrient name. Directions is accepted
+// as another name for instructions, as used by RecipeNLG.
+type datasetLine struct {
+	ID           int                `json:"id"`
+	Title        string             `json:"title"`
+	Servings     int                `json:"servings"`
+	Ingredients  []json.RawMessage  `json:"ingredients"`
+	Nutrients    map[string]float64 `json:"nutrients"`
+	Diets        []string           `json:"diets"`
+	Allergens    []string           `json:"allergens"`
+	Instructions []string           `json:"instructions"`
+	Directions   []string           `json:"directions"`
+}
+
+// nutrientNames maps the accepted nutrient names to the field they fill.
+var nutrientNames = map[string]string{
+	"calories":      "calories",
+	"energy":        "calories",
+	"kcal":          "calories",
+	"carbohydrates": "carbohydrates",
+	"carbohydrate":  "carbohydrates",
+	"carbs":         "carbohydrates",
+	"protein":       "protein",
+	"proteins":      "protein",
+}
+
+// ReadDataset reads a JSON Lines dataset. Lines that are not usable recipes
+// are skipped and described in the notes; only failing to read is an error.
+func ReadDataset(r io.Reader) ([]Recipe, []string, error) {
+	var dataset []Recipe
+	var notes []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var line datasetLine
+		err := json.Unmarshal([]byte(text), &line)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("line %d: invalid JSON: %v", lineNumber, err))
+			continue
+		}
+		recipe, lineNotes, err := normalize(line)
+		for _, note := range lineNotes {
+			notes = append(notes, fmt.Sprintf("line %d: %s", lineNumber, note))
+		}
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("line %d: skipped: %v", lineNumber, err))
+			continue
+		}
+		if recipe.ID == 0 {
+			recipe.ID = datasetIDBase + lineNumber
+		}
+		dataset = append(dataset, recipe)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, notes, fmt.Errorf("error reading dataset: %v", err)
+	}
+	return dataset, notes, nil
+}
+
+// ReadRecipes reads recipes written by hand: a JSON array of objects, or a
+// single object, in the format of the dataset lines. Recipes that are not
+// usable are skipped and described in the notes; only invalid JSON is an
+// error. The recipes keep the IDs given, zero when there is none.
+func ReadRecipes(data []byte) ([]Recipe, []string, error) {
+	var lines []datasetLine
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		lines = make([]datasetLine, 1)
+		err := json.Unmarshal(trimmed, &lines[0])
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid recipe: %v", err)
+		}
+	} else {
+		err := json.Unmarshal(data, &lines)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid recipes: %v", err)
+		}
+	}
+
+	var found []Recipe
+	var notes []string
+	for i, line := range lines {
+		recipe, lineNotes, err := normalize(line)
+		for _, note := range lineNotes {
+			notes = append(notes, fmt.Sprintf("recipe %d: %s", i+1, note))
+		}
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("recipe %d: skipped: %v", i+1, err))
+			continue
+		}
+		found = append(found, recipe)
+	}
+	return found, notes, nil
+}
+
+// normalize turns a dataset line into a Recipe: ingredient names lowercased
+// and stripped of preparation notes, nutrients, diets and allergens under
+// their canonical names. Unknown diets and allergens are dropped with a note.
+func normalize(line datasetLine) (Recipe, []string, error) {
+	var notes []string
+	title := recipes.SanitizeText(line.Title)
+	if title == "" {
+		return Recipe{}, nil, errors.New("no title")
+	}
+
+	recipe := Recipe{
+		ID:       line.ID,
+		Title:    title,
+		Servings: line.Servings,
+	}
+	for _, raw := range line.Ingredients {
+		ingredient, err := parseIngredient(raw)
+		if err != nil {
+			notes = append(notes, err.Error())
+			continue
+		}
+		if ingredient.Name != "" {
+			recipe.Ingredients = append(recipe.Ingredients, ingredient)
+		}
+	}
+	if len(recipe.Ingredients) == 0 {
+		return Recipe{}, notes, errors.New("no ingredients")
+	}
+
+	for name, amount := range line.Nutrients {
+		switch nutrientNames[strings.ToLower(strings.TrimSpace(name))] {
+		case "calories":
+			recipe.Nutrients.Calories = amount
+		case "carbohydrates":
+			recipe.Nutrients.Carbohydrates = amount
+		case "protein":
+			recipe.Nutrients.Protein = amount
+		}
+	}
+
+	for _, diet := range line.Diets {
+		normalized, err := recipes.NormalizeDiets(diet)
+		if err != nil {
+			notes = append(notes, err.Error())
+			continue
+		}
+		recipe.Diets = append(recipe.Diets, normalized...)
+	}
+	for _, allergen := range line.Allergens {
+		normalized, err := recipes.NormalizeIntolerances(allergen)
+		if err != nil {
+			notes = append(notes, err.Error())
+			continue
+		}
+		recipe.Allergens = append(recipe.Allergens, normalized...)
+	}
+
+	steps := line.Instructions
+	if len(steps) == 0 {
+		steps = line.Directions
+	}
+	for _, step := range steps {
+		if step = recipes.SanitizeText(step); step != "" {
+			recipe.Instructions = append(recipe.Instructions, step)
+		}
+	}
+	return recipe, notes, nil
+}
+
+// parseIngredient accepts an ingredient object or an ingredient line.
+func parseIngredient(raw json.RawMessage) (Ingredient, error) {
+	var line string
+	if json.Unmarshal(raw, &line) == nil {
+		return parseIngredientLine(line), nil
+	}
+	var ingredient Ingredient
+	err := json.Unmarshal(raw, &ingredient)
+	if err != nil {
+		return Ingredient{}, fmt.Errorf("invalid ingredient %s", raw)
+	}
+	ingredient.Name = cleanIngredientName(ingredient.Name)
+	return ingredient, nil
+}
+
+// unitAbbreviations are the abbreviations common in recipe datasets that the
+// shopping list does not know.
+var unitAbbreviations = map[string]string{
+	"c":     "cup",
+	"tbs":   "tbsp",
+	"tbl":   "tbsp",
+	"tblsp": "tbsp",
+	"pkg":   "package",
+	"pkgs":  "packages",
+}
+
+// parseIngredientLine splits a line such as "1 1/2 cups flour, sifted" into
+// its amount, unit and name. Lines without an amount are all name.
+func parseIngredientLine(line string) Ingredient {
+	words := strings.Fields(recipes.SanitizeText(line))
+	var ingredient Ingredient
+	for len(words) > 0 {
+		amount, ok := parseAmount(words[0])
+		if !ok {
+			break
+		}
+		ingredient.Amount += amount
+		words = words[1:]
+	}
+	if ingredient.Amount > 0 && len(words) > 1 {
+		unit := strings.ToLower(strings.TrimSuffix(words[0], "."))
+		if expanded, ok := unitAbbreviations[unit]; ok {
+			unit = expanded
+		}
+		if recipes.IsUnit(unit) {
+			ingredient.Unit = unit
+			words = words[1:]
+		}
+	}
+	ingredient.Name = cleanIngredientName(strings.Join(words, " "))
+	return ingredient
+}
+
+// parseAmount parses "2", "0.5" or "1/2".
+func parseAmount(word string) (float64, bool) {
+	if numerator, denominator, found := strings.Cut(word, "/"); found {
+		n, err := strconv.ParseFloat(numerator, 64)
+		if err != nil {
+			return 0, false
+		}
+		d, err := strconv.ParseFloat(denominator, 64)
+		if err != nil || d == 0 {
+			return 0, false
+		}
+		return n / d, true
+	}
+	amount, err := strconv.ParseFloat(word, 64)
+	return amount, err == nil && amount > 0
+}
+
+// cleanIngredientName lowercases a name and drops what follows a comma and
+// anything in parentheses, so "Onion (large), chopped" becomes "onion".
+func cleanIngredientName(name string) string {
+	name, _, _ = strings.Cut(name, ",")
+	for {
+		start := strings.Index(name, "(")
+		end := strings.Index(name, ")")
+		if start < 0 || end < start {
+			break
+		}
+		name = name[:start] + name[end+1:]
+	}
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// SaveIndex writes the index to path, replacing the file in one step so a
+// search running meanwhile never reads half of it.
+func SaveIndex(path string, index *Index) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return fmt.Errorf("error creating data directory: %v", err)
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), IndexFile+".*")
+	if err != nil {
+		return fmt.Errorf("error writing index: %v", err)
+	}
+	defer os.Remove(file.Name())
+
+	writer := bufio.NewWriter(file)
+	err = json.NewEncoder(writer).Encode(index)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing index: %v", err)
+	}
+	err = os.Rename(file.Name(), path)
+	if err != nil {
+		return fmt.Errorf("error writing index: %v", err)
+	}
+	return nil
+}
+
+// LoadIndex reads an index written by SaveIndex. The error wraps
+// os.ErrNotExist when there is none.
+func LoadIndex(path string) (*Index, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading index: %w", err)
+	}
+	defer func() {
+		err := file.Close()
+		if err != nil {
+			slog.Warn("error closing index", "error", err)
+		}
+	}()
+
+	var index Index
+	err = json.NewDecoder(bufio.NewReader(file)).Decode(&index)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing index %s: %v", path, err)
+	}
+	return &index, nil
+}
@@ -0,0 +1,247 @@
+// Package offline is a recipe provider that needs no API key or network. It
+// searches a dataset of recipes bundled with the program through an inverted
+// index from ingredient words to recipes.
+package offline
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// bundled is a small curated set of everyday recipes with nutrition per
+// serving, enough to get useful results without any account.
+//
+//go:embed recipes.json
+var bundled []byte
+
+// Recipe is a recipe as stored in a dataset. Nutrients are per serving.
+type Recipe struct {
+	ID           int          `json:"id"`
+	Title        string       `json:"title"`
+	Servings     int          `json:"servings"`
+	Ingredients  []Ingredient `json:"ingredients"`
+	Nutrients    Nutrients    `json:"nutrients"`
+	Diets        []string     `json:"diets"`
+	Allergens    []string     `json:"allergens"`
+	Instructions []string     `json:"instructions"`
+}
+
+type Ingredient struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+	Unit   string  `json:"unit"`
+}
+
+type Nutrients struct {
+	Calories      float64 `json:"calories"`
+	Carbohydrates float64 `json:"carbohydrates"`
+	Protein       float64 `json:"protein"`
+}
+
+// Index holds a dataset with the positions of the recipes using each word of
+// an ingredient name.
+type Index struct {
+	Recipes []Recipe         `json:"recipes"`
+	Words   map[string][]int `json:"words"`
+}
+
+// BuildIndex indexes the recipes.
+func BuildIndex(dataset []Recipe) *Index {
+	index := &Index{Recipes: dataset, Words: make(map[string][]int)}
+	for i, recipe := range dataset {
+		for _, ingredient := range recipe.Ingredients {
+			for _, word := range strings.Fields(strings.ToLower(ingredient.Name)) {
+				positions := index.Words[word]
+				if len(positions) == 0 || positions[len(positions)-1] != i {
+					index.Words[word] = append(positions, i)
+				}
+			}
+		}
+	}
+	return index
+}
+
+// Bundled returns the index of the dataset shipped with the program.
+func Bundled() (*Index, error) {
+	var dataset []Recipe
+	err := json.Unmarshal(bundled, &dataset)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing bundled recipes: %v", err)
+	}
+	return BuildIndex(dataset), nil
+}
+
+// Provider searches an Index. It is safe for concurrent use.
+type Provider struct {
+	index *Index
+}
+
+func NewProvider(index *Index) *Provider {
+	return &Provider{index: index}
+}
+
+func (p *Provider) Name() string {
+	return "offline"
+}
+
+// match is a recipe of the index counted against a search, before it is
+// turned into a recipes.Recipe.
+type match struct {
+	position int
+	used     int
+	missed   int
+}
+
+// Search returns the recipes using any of the query's ingredients, fewest
+// missing ingredients first. Diets are matched against the diets each recipe
+// is tagged with, intolerances against its allergens and excluded ingredients
+// against its ingredients.
+//
+// Only the recipes sharing words with the query, found through the index, are
+// looked at, and their ingredients are only counted; just the page of
+// recipes returned is built, which keeps searches over large datasets fast.
+func (p *Provider) Search(ctx context.Context, search recipes.Query) ([]recipes.Recipe, error) {
+	// Ingredient names repeat across recipes, so each is only matched once
+	wanted := make(map[string]bool)
+	var matches []match
+	for i, position := range p.candidates(search.Ingredients) {
+		if i%1024 == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		recipe := &p.index.Recipes[position]
+		if !suits(recipe, search) {
+			continue
+		}
+		used := 0
+		for _, ingredient := range recipe.Ingredients {
+			have, seen := wanted[ingredient.Name]
+			if !seen {
+				have = recipes.HasIngredient(strings.ToLower(ingredient.Name), search.Ingredients)
+				wanted[ingredient.Name] = have
+			}
+			if have {
+				used++
+			}
+		}
+		if used > 0 {
+			matches = append(matches, match{position: position, used: used, missed: len(recipe.Ingredients) - used})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].missed != matches[j].missed {
+			return matches[i].missed < matches[j].missed
+		}
+		if matches[i].used != matches[j].used {
+			return matches[i].used > matches[j].used
+		}
+		return matches[i].position < matches[j].position
+	})
+	if search.Offset >= len(matches) {
+		return nil, nil
+	}
+	matches = matches[search.Offset:]
+	if len(matches) > search.NumberOfRecipes {
+		matches = matches[:search.NumberOfRecipes]
+	}
+
+	found := make([]recipes.Recipe, 0, len(matches))
+	for _, m := range matches {
+		found = append(found, toRecipe(p.index.Recipes[m.position], search.Ingredients))
+	}
+	return found, nil
+}
+
+// candidates returns, in dataset order, the positions of the recipes having an
+// ingredient that shares a word with one of the ingredients. Singular and
+// plural forms are looked up too; Search decides which really match.
+func (p *Provider) candidates(ingredientList []string) []int {
+	selected := make([]bool, len(p.index.Recipes))
+	for _, ingredient := range ingredientList {
+		for _, word := range strings.Fields(strings.ToLower(ingredient)) {
+			for _, form := range wordForms(word) {
+				for _, position := range p.index.Words[form] {
+					selected[position] = true
+				}
+			}
+		}
+	}
+
+	var positions []int
+	for position, ok := range selected {
+		if ok {
+			positions = append(positions, position)
+		}
+	}
+	return positions
+}
+
+func wordForms(word string) []string {
+	forms := []string{word, word + "s", word + "es"}
+	if singular, ok := strings.CutSuffix(word, "es"); ok {
+		forms = append(forms, singular)
+	}
+	if singular, ok := strings.CutSuffix(word, "s"); ok {
+		forms = append(forms, singular)
+	}
+	if normalized := recipes.NormalizeIngredient(word); !slices.Contains(forms, normalized) {
+		forms = append(forms, normalized)
+	}
+	return forms
+}
+
+// suits reports whether the recipe follows every diet of the search and has
+// none of its intolerances or excluded ingredients.
+func suits(recipe *Recipe, search recipes.Query) bool {
+	for _, diet := range search.Diets {
+		if !slices.Contains(recipe.Diets, diet) {
+			return false
+		}
+	}
+	for _, intolerance := range search.Intolerances {
+		if slices.Contains(recipe.Allergens, intolerance) {
+			return false
+		}
+	}
+	for _, ingredient := range recipe.Ingredients {
+		if recipes.IsExcluded(ingredient.Name, search.Exclude) {
+			return false
+		}
+	}
+	return true
+}
+
+func toRecipe(recipe Recipe, ingredientList []string) recipes.Recipe {
+	ingredients := make([]recipes.Ingredient, 0, len(recipe.Ingredients))
+	for _, ingredient := range recipe.Ingredients {
+		ingredients = append(ingredients, recipes.Ingredient{
+			Name:   ingredient.Name,
+			Amount: ingredient.Amount,
+			Unit:   ingredient.Unit,
+			Forms:  recipes.TagForms(ingredient.Name),
+		})
+	}
+	used, missed := recipes.MatchIngredients(ingredients, ingredientList)
+
+	return recipes.Recipe{
+		ID:                recipe.ID,
+		Title:             recipe.Title,
+		UsedIngredients:   used,
+		MissedIngredients: missed,
+		Nutrients: map[string]recipes.NutrientAmount{
+			"Calories":      {Amount: recipe.Nutrients.Calories, Unit: "kcal"},
+			"Carbohydrates": {Amount: recipe.Nutrients.Carbohydrates, Unit: "g"},
+			"Protein":       {Amount: recipe.Nutrients.Protein, Unit: "g"},
+		},
+		Instructions: slices.Clone(recipe.Instructions),
+		Servings:     recipe.Servings,
+		Source:       "offline",
+	}
+}
@@ -0,0 +1,8 @@
+// Package recipefinderpb is the Go code generated from
+// proto/recipefinder/v1/recipefinder.proto, the gRPC contract of the
+// recipefinder service: its messages and the RecipeFinder client and server
+// stubs. Regenerate it after changing the definition with go generate, which
+// needs protoc, protoc-gen-go and protoc-gen-go-grpc.
+package recipefinderpb
+
+//go:generate protoc --proto_path=../../proto --go_out=. --go_opt=module=github.com/mawojcik/meals_generator/pkg/recipefinderpb --go-grpc_out=. --go-grpc_opt=module=github.com/mawojcik/meals_generator/pkg/recipefinderpb recipefinder/v1/recipefinder.proto
@@ -0,0 +1,1194 @@
+// The gRPC contract of the recipefinder service: the searches of
+// "recipefinder search", "show" and "plan" for services that call them
+// rather than run the CLI or parse the HTTP server's JSON. The Go code for
+// it is generated into pkg/recipefinderpb, see its doc.go.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: recipefinder/v1/recipefinder.proto
+
+package recipefinderpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Query describes a search; unset fields do not filter.
+type Query struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ingredients []string `protobuf:"bytes,1,rep,name=ingredients,proto3" json:"ingredients,omitempty"`
+	// Diets and intolerances use the names "recipefinder search" accepts, such
+	// as "gluten free" or "peanut".
+	Diets              []string `protobuf:"bytes,2,rep,name=diets,proto3" json:"diets,omitempty"`
+	Intolerances       []string `protobuf:"bytes,3,rep,name=intolerances,proto3" json:"intolerances,omitempty"`
+	ExcludeIngredients []string `protobuf:"bytes,4,rep,name=exclude_ingredients,json=excludeIngredients,proto3" json:"exclude_ingredients,omitempty"`
+	MaxCalories        float64  `protobuf:"fixed64,5,opt,name=max_calories,json=maxCalories,proto3" json:"max_calories,omitempty"`
+	MinProtein         float64  `protobuf:"fixed64,6,opt,name=min_protein,json=minProtein,proto3" json:"min_protein,omitempty"`
+	MaxCarbs           float64  `protobuf:"fixed64,7,opt,name=max_carbs,json=maxCarbs,proto3" json:"max_carbs,omitempty"`
+	// Largest estimated price per serving in US dollars.
+	MaxPricePerServing float64 `protobuf:"fixed64,8,opt,name=max_price_per_serving,json=maxPricePerServing,proto3" json:"max_price_per_serving,omitempty"`
+	// Most minutes a recipe may take.
+	MaxReadyTime int32 `protobuf:"varint,9,opt,name=max_ready_time,json=maxReadyTime,proto3" json:"max_ready_time,omitempty"`
+	// Equipment recipes should use, any of it, and equipment they may not.
+	Equipment   []string `protobuf:"bytes,10,rep,name=equipment,proto3" json:"equipment,omitempty"`
+	NoEquipment []string `protobuf:"bytes,11,rep,name=no_equipment,json=noEquipment,proto3" json:"no_equipment,omitempty"`
+}
+
+func (x *Query) Reset() {
+	*x = Query{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Query) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Query) ProtoMessage() {}
+
+func (x *Query) ProtoReflect() protoreflect.Message {
+	mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Query.ProtoReflect.Descriptor instead.
+func (*Query) Descriptor() ([]byte, []int) {
+	return file_recipefinder_v1_recipefinder_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Query) GetIngredients() []string {
+	if x != nil {
+		return x.Ingredients
+	}
+	return nil
+}
+
+func (x *Query) GetDiets() []string {
+	if x != nil {
+		return x.Diets
+	}
+	return nil
+}
+
+func (x *Query) GetIntolerances() []string {
+	if x != nil {
+		return x.Intolerances
+	}
+	return nil
+}
+
+func (x *Query) GetExcludeIngredients() []string {
+	if x != nil {
+		return x.ExcludeIngredients
+	}
+	return nil
+}
+
+func (x *Query) GetMaxCalories() float64 {
+	if x != nil {
+		return x.MaxCalories
+	}
+	return 0
+}
+
+func (x *Query) GetMinProtein() float64 {
+	if x != nil {
+		return x.MinProtein
+	}
+	return 0
+}
+
+func (x *Query) GetMaxCarbs() float64 {
+	if x != nil {
+		return x.MaxCarbs
+	}
+	return 0
+}
+
+func (x *Query) GetMaxPricePerServing() float64 {
+	if x != nil {
+		return x.MaxPricePerServing
+	}
+	return 0
+}
+
+func (x *Query) GetMaxReadyTime() int32 {
+	if x != nil {
+		return x.MaxReadyTime
+	}
+	return 0
+}
+
+func (x *Query) GetEquipment() []string {
+	if x != nil {
+		return x.Equipment
+	}
+	return nil
+}
+
+func (x *Query) GetNoEquipment() []string {
+	if x != nil {
+		return x.NoEquipment
+	}
+	return nil
+}
+
+type SearchRecipesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Query           *Query `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	NumberOfRecipes int32  `protobuf:"varint,2,opt,name=number_of_recipes,json=numberOfRecipes,proto3" json:"number_of_recipes,omitempty"`
+	// One of score, missing, calories, protein or popularity; score when empty.
+	Sort string `protobuf:"bytes,3,opt,name=sort,proto3" json:"sort,omitempty"`
+	// Include the cooking instructions of each recipe.
+	Instructions bool `protobuf:"varint,4,opt,name=instructions,proto3" json:"instructions,omitempty"`
+}
+
+func (x *SearchRecipesRequest) Reset() {
+	*x = SearchRecipesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SearchRecipesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRecipesRequest) ProtoMessage() {}
+
+func (x *SearchRecipesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRecipesRequest.ProtoReflect.Descriptor instead.
+func (*SearchRecipesRequest) Descriptor() ([]byte, []int) {
+	return file_recipefinder_v1_recipefinder_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SearchRecipesRequest) GetQuery() *Query {
+	if x != nil {
+		return x.Query
+	}
+	return nil
+}
+
+func (x *SearchRecipesRequest) GetNumberOfRecipes() int32 {
+	if x != nil {
+		return x.NumberOfRecipes
+	}
+	return 0
+}
+
+func (x *SearchRecipesRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *SearchRecipesRequest) GetInstructions() bool {
+	if x != nil {
+		return x.Instructions
+	}
+	return false
+}
+
+type SearchRecipesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Recipes []*Recipe `protobuf:"bytes,1,rep,name=recipes,proto3" json:"recipes,omitempty"`
+}
+
+func (x *SearchRecipesResponse) Reset() {
+	*x = SearchRecipesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SearchRecipesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRecipesResponse) ProtoMessage() {}
+
+func (x *SearchRecipesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRecipesResponse.ProtoReflect.Descriptor instead.
+func (*SearchRecipesResponse) Descriptor() ([]byte, []int) {
+	return file_recipefinder_v1_recipefinder_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SearchRecipesResponse) GetRecipes() []*Recipe {
+	if x != nil {
+		return x.Recipes
+	}
+	return nil
+}
+
+type GetRecipeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The provider the recipe came from, spoonacular when empty.
+	Source string `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Id     int64  `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *GetRecipeRequest) Reset() {
+	*x = GetRecipeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetRecipeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRecipeRequest) ProtoMessage() {}
+
+func (x *GetRecipeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRecipeRequest.ProtoReflect.Descriptor instead.
+func (*GetRecipeRequest) Descriptor() ([]byte, []int) {
+	return file_recipefinder_v1_recipefinder_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetRecipeRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *GetRecipeRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type BuildMealPlanRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Query       *Query `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Days        int32  `protobuf:"varint,2,opt,name=days,proto3" json:"days,omitempty"`
+	MealsPerDay int32  `protobuf:"varint,3,opt,name=meals_per_day,json=mealsPerDay,proto3" json:"meals_per_day,omitempty"`
+}
+
+func (x *BuildMealPlanRequest) Reset() {
+	*x = BuildMealPlanRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BuildMealPlanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildMealPlanRequest) ProtoMessage() {}
+
+func (x *BuildMealPlanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildMealPlanRequest.ProtoReflect.Descriptor instead.
+func (*BuildMealPlanRequest) Descriptor() ([]byte, []int) {
+	return file_recipefinder_v1_recipefinder_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BuildMealPlanRequest) GetQuery() *Query {
+	if x != nil {
+		return x.Query
+	}
+	return nil
+}
+
+func (x *BuildMealPlanRequest) GetDays() int32 {
+	if x != nil {
+		return x.Days
+	}
+	return 0
+}
+
+func (x *BuildMealPlanRequest) GetMealsPerDay() int32 {
+	if x != nil {
+		return x.MealsPerDay
+	}
+	return 0
+}
+
+type Ingredient struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name   string  `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Amount float64 `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	Unit   string  `protobuf:"bytes,3,opt,name=unit,proto3" json:"unit,omitempty"`
+	// Ingredients the user has that can stand in for a missing one.
+	Substitutes []string `protobuf:"bytes,4,rep,name=substitutes,proto3" json:"substitutes,omitempty"`
+}
+
+func (x *Ingredient) Reset() {
+	*x = Ingredient{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Ingredient) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ingredient) ProtoMessage() {}
+
+func (x *Ingredient) ProtoReflect() protoreflect.Message {
+	mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ingredient.ProtoReflect.Descriptor instead.
+func (*Ingredient) Descriptor() ([]byte, []int) {
+	return file_recipefinder_v1_recipefinder_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Ingredient) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Ingredient) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Ingredient) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+func (x *Ingredient) GetSubstitutes() []string {
+	if x != nil {
+		return x.Substitutes
+	}
+	return nil
+}
+
+type NutrientAmount struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Amount float64 `protobuf:"fixed64,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	Unit   string  `protobuf:"bytes,2,opt,name=unit,proto3" json:"unit,omitempty"`
+	// Share of the daily value, zero when unknown.
+	DailyPercent float64 `protobuf:"fixed64,3,opt,name=daily_percent,json=dailyPercent,proto3" json:"daily_percent,omitempty"`
+}
+
+func (x *NutrientAmount) Reset() {
+	*x = NutrientAmount{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NutrientAmount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NutrientAmount) ProtoMessage() {}
+
+func (x *NutrientAmount) ProtoReflect() protoreflect.Message {
+	mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NutrientAmount.ProtoReflect.Descriptor instead.
+func (*NutrientAmount) Descriptor() ([]byte, []int) {
+	return file_recipefinder_v1_recipefinder_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *NutrientAmount) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *NutrientAmount) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+func (x *NutrientAmount) GetDailyPercent() float64 {
+	if x != nil {
+		return x.DailyPercent
+	}
+	return 0
+}
+
+type Recipe struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id                int64         `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Source            string        `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	Title             string        `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	UsedIngredients   []*Ingredient `protobuf:"bytes,4,rep,name=used_ingredients,json=usedIngredients,proto3" json:"used_ingredients,omitempty"`
+	MissedIngredients []*Ingredient `protobuf:"bytes,5,rep,name=missed_ingredients,json=missedIngredients,proto3" json:"missed_ingredients,omitempty"`
+	// Nutrients per serving, keyed by name, such as Calories or Protein.
+	Nutrients    map[string]*NutrientAmount `protobuf:"bytes,6,rep,name=nutrients,proto3" json:"nutrients,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Instructions []string                   `protobuf:"bytes,7,rep,name=instructions,proto3" json:"instructions,omitempty"`
+	Servings     int32                      `protobuf:"varint,8,opt,name=servings,proto3" json:"servings,omitempty"`
+	// Estimated cost of a serving in US cents, zero when unknown.
+	PricePerServingCents float64  `protobuf:"fixed64,9,opt,name=price_per_serving_cents,json=pricePerServingCents,proto3" json:"price_per_serving_cents,omitempty"`
+	ReadyInMinutes       int32    `protobuf:"varint,10,opt,name=ready_in_minutes,json=readyInMinutes,proto3" json:"ready_in_minutes,omitempty"`
+	Equipment            []string `protobuf:"bytes,11,rep,name=equipment,proto3" json:"equipment,omitempty"`
+	SourceUrl            string   `protobuf:"bytes,12,opt,name=source_url,json=sourceUrl,proto3" json:"source_url,omitempty"`
+	ImageUrl             string   `protobuf:"bytes,13,opt,name=image_url,json=imageUrl,proto3" json:"image_url,omitempty"`
+	Warnings             []string `protobuf:"bytes,14,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	// The fields holding estimates: price or nutrition.
+	Estimated []string `protobuf:"bytes,15,rep,name=estimated,proto3" json:"estimated,omitempty"`
+	// Unix seconds, zero when unknown.
+	FetchedAt int64 `protobuf:"varint,16,opt,name=fetched_at,json=fetchedAt,proto3" json:"fetched_at,omitempty"`
+}
+
+func (x *Recipe) Reset() {
+	*x = Recipe{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Recipe) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Recipe) ProtoMessage() {}
+
+func (x *Recipe) ProtoReflect() protoreflect.Message {
+	mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Recipe.ProtoReflect.Descriptor instead.
+func (*Recipe) Descriptor() ([]byte, []int) {
+	return file_recipefinder_v1_recipefinder_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Recipe) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Recipe) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Recipe) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Recipe) GetUsedIngredients() []*Ingredient {
+	if x != nil {
+		return x.UsedIngredients
+	}
+	return nil
+}
+
+func (x *Recipe) GetMissedIngredients() []*Ingredient {
+	if x != nil {
+		return x.MissedIngredients
+	}
+	return nil
+}
+
+func (x *Recipe) GetNutrients() map[string]*NutrientAmount {
+	if x != nil {
+		return x.Nutrients
+	}
+	return nil
+}
+
+func (x *Recipe) GetInstructions() []string {
+	if x != nil {
+		return x.Instructions
+	}
+	return nil
+}
+
+func (x *Recipe) GetServings() int32 {
+	if x != nil {
+		return x.Servings
+	}
+	return 0
+}
+
+func (x *Recipe) GetPricePerServingCents() float64 {
+	if x != nil {
+		return x.PricePerServingCents
+	}
+	return 0
+}
+
+func (x *Recipe) GetReadyInMinutes() int32 {
+	if x != nil {
+		return x.ReadyInMinutes
+	}
+	return 0
+}
+
+func (x *Recipe) GetEquipment() []string {
+	if x != nil {
+		return x.Equipment
+	}
+	return nil
+}
+
+func (x *Recipe) GetSourceUrl() string {
+	if x != nil {
+		return x.SourceUrl
+	}
+	return ""
+}
+
+func (x *Recipe) GetImageUrl() string {
+	if x != nil {
+		return x.ImageUrl
+	}
+	return ""
+}
+
+func (x *Recipe) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+func (x *Recipe) GetEstimated() []string {
+	if x != nil {
+		return x.Estimated
+	}
+	return nil
+}
+
+func (x *Recipe) GetFetchedAt() int64 {
+	if x != nil {
+		return x.FetchedAt
+	}
+	return 0
+}
+
+type MealPlanDay struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Meals     []*Recipe `protobuf:"bytes,1,rep,name=meals,proto3" json:"meals,omitempty"`
+	Calories  float64   `protobuf:"fixed64,2,opt,name=calories,proto3" json:"calories,omitempty"`
+	Protein   float64   `protobuf:"fixed64,3,opt,name=protein,proto3" json:"protein,omitempty"`
+	CostCents float64   `protobuf:"fixed64,4,opt,name=cost_cents,json=costCents,proto3" json:"cost_cents,omitempty"`
+}
+
+func (x *MealPlanDay) Reset() {
+	*x = MealPlanDay{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MealPlanDay) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MealPlanDay) ProtoMessage() {}
+
+func (x *MealPlanDay) ProtoReflect() protoreflect.Message {
+	mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MealPlanDay.ProtoReflect.Descriptor instead.
+func (*MealPlanDay) Descriptor() ([]byte, []int) {
+	return file_recipefinder_v1_recipefinder_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *MealPlanDay) GetMeals() []*Recipe {
+	if x != nil {
+		return x.Meals
+	}
+	return nil
+}
+
+func (x *MealPlanDay) GetCalories() float64 {
+	if x != nil {
+		return x.Calories
+	}
+	return 0
+}
+
+func (x *MealPlanDay) GetProtein() float64 {
+	if x != nil {
+		return x.Protein
+	}
+	return 0
+}
+
+func (x *MealPlanDay) GetCostCents() float64 {
+	if x != nil {
+		return x.CostCents
+	}
+	return 0
+}
+
+type MealPlan struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Days []*MealPlanDay `protobuf:"bytes,1,rep,name=days,proto3" json:"days,omitempty"`
+	// Estimated cost of a serving of every meal in US cents, leaving out the
+	// unpriced ones.
+	CostCents float64 `protobuf:"fixed64,2,opt,name=cost_cents,json=costCents,proto3" json:"cost_cents,omitempty"`
+	Unpriced  int32   `protobuf:"varint,3,opt,name=unpriced,proto3" json:"unpriced,omitempty"`
+}
+
+func (x *MealPlan) Reset() {
+	*x = MealPlan{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MealPlan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MealPlan) ProtoMessage() {}
+
+func (x *MealPlan) ProtoReflect() protoreflect.Message {
+	mi := &file_recipefinder_v1_recipefinder_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MealPlan.ProtoReflect.Descriptor instead.
+func (*MealPlan) Descriptor() ([]byte, []int) {
+	return file_recipefinder_v1_recipefinder_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *MealPlan) GetDays() []*MealPlanDay {
+	if x != nil {
+		return x.Days
+	}
+	return nil
+}
+
+func (x *MealPlan) GetCostCents() float64 {
+	if x != nil {
+		return x.CostCents
+	}
+	return 0
+}
+
+func (x *MealPlan) GetUnpriced() int32 {
+	if x != nil {
+		return x.Unpriced
+	}
+	return 0
+}
+
+var File_recipefinder_v1_recipefinder_proto protoreflect.FileDescriptor
+
+var file_recipefinder_v1_recipefinder_proto_rawDesc = []byte{
+	0x0a, 0x22, 0x72, 0x65, 0x63, 0x69, 0x70, 0x65, 0x66, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x2f, 0x76,
+	0x31, 0x2f, 0x72, 0x65, 0x63, 0x69, 0x70, 0x65, 0x66, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0f, 0x72, 0x65, 0x63, 0x69, 0x70, 0x65, 0x66, 0x69, 0x6e, 0x64,
+	0x65, 0x72, 0x2e, 0x76, 0x31, 0x22, 0x8f, 0x03, 0x0a, 0x05, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12,
+	0x20, 0x0a, 0x0b, 0x69, 0x6e, 0x67, 0x72, 0x65, 0x64, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x69, 0x6e, 0x67, 0x72, 0x65, 0x64, 0x69, 0x65, 0x6e, 0x74,
+	0x73, 0x12, 0x14, 0x0a, 0x05, 0x64, 0x69, 0x65, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x05, 0x64, 0x69, 0x65, 0x74, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x69, 0x6e, 0x74, 0x6f, 0x6c,
+	0x65, 0x72, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x69,
+	0x6e, 0x74, 0x6f, 0x6c, 0x65, 0x72, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x12, 0x2f, 0x0a, 0x13, 0x65,
+	0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x5f, 0x69, 0x6e, 0x67, 0x72, 0x65, 0x64, 0x69, 0x65, 0x6e,
+	0x74, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x12, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64,
+	0x65, 0x49, 0x6e, 0x67, 0x72, 0x65, 0x64, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x21, 0x0a, 0x0c,
+	0x6d, 0x61, 0x78, 0x5f, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x0b, 0x6d, 0x61, 0x78, 0x43, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x12,
+	0x1f, 0x0a, 0x0b, 0x6d, 0x69, 0x6e, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x65, 0x69, 0x6e, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x0a, 0x6d, 0x69, 0x6e, 0x50, 0x72, 0x6f, 0x74, 0x65, 0x69, 0x6e,
+	0x12, 0x1b, 0x0a, 0x09, 0x6d, 0x61, 0x78, 0x5f, 0x63, 0x61, 0x72, 0x62, 0x73, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x08, 0x6d, 0x61, 0x78, 0x43, 0x61, 0x72, 0x62, 0x73, 0x12, 0x31, 0x0a,
+	0x15, 0x6d, 0x61, 0x78, 0x5f, 0x70, 0x72, 0x69, 0x63, 0x65, 0x5f, 0x70, 0x65, 0x72, 0x5f, 0x73,
+	0x65, 0x72, 0x76, 0x69, 0x6e, 0x67, 0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x52, 0x12, 0x6d, 0x61,
+	0x78, 0x50, 0x72, 0x69, 0x63, 0x65, 0x50, 0x65, 0x72, 0x53, 0x65, 0x72, 0x76, 0x69, 0x6e, 0x67,
+	0x12, 0x24, 0x0a, 0x0e, 0x6d, 0x61, 0x78, 0x5f, 0x72, 0x65, 0x61, 0x64, 0x79, 0x5f, 0x74, 0x69,
+	0x6d, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c, 0x6d, 0x61, 0x78, 0x52, 0x65, 0x61,
+	0x64, 0x79, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x65, 0x71, 0x75, 0x69, 0x70, 0x6d,
+	0x65, 0x6e, 0x74, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x65, 0x71, 0x75, 0x69, 0x70,
+	0x6d, 0x65, 0x6e, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x6e, 0x6f, 0x5f, 0x65, 0x71, 0x75, 0x69, 0x70,
+	0x6d, 0x65, 0x6e, 0x74, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x6e, 0x6f, 0x45, 0x71,
+	0x75, 0x69, 0x70, 0x6d, 0x65, 0x6e, 0x74, 0x22, 0xa8, 0x01, 0x0a, 0x14, 0x53, 0x65, 0x61, 0x72,
+	0x63, 0x68, 0x52, 0x65, 0x63, 0x69, 0x70, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x2c, 0x0a, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x16, 0x2e, 0x72, 0x65, 0x63, 0x69, 0x70, 0x65, 0x66, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x12, 0x2a,
+	0x0a, 0x11, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x5f, 0x6f, 0x66, 0x5f, 0x72, 0x65, 0x63, 0x69,
+	0x70, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0f, 0x6e, 0x75, 0x6d, 0x62, 0x65,
+	0x72, 0x4f, 0x66, 0x52, 0x65, 0x63, 0x69, 0x70, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x6f,
+	0x72, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x73, 0x6f, 0x72, 0x74, 0x12, 0x22,
+	0x0a, 0x0c, 0x69, 0x6e, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x69, 0x6e, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x22, 0x4a, 0x0a, 0x15, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x63, 0x69,
+	0x70, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x31, 0x0a, 0x07, 0x72,
+	0x65, 0x63, 0x69, 0x70, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x72,
+	0x65, 0x63, 0x69, 0x70, 0x65, 0x66, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x52,
+	0x65, 0x63, 0x69, 0x70, 0x65, 0x52, 0x07, 0x72, 0x65, 0x63, 0x69, 0x70, 0x65, 0x73, 0x22, 0x3a,
+	0x0a, 0x10, 0x47, 0x65, 0x74, 0x52, 0x65, 0x63, 0x69, 0x70, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x22, 0x7c, 0x0a, 0x14, 0x42, 0x75,
+	0x69, 0x6c, 0x64, 0x4d, 0x65, 0x61, 0x6c, 0x50, 0x6c, 0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x2c, 0x0a, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x16, 0x2e, 0x72, 0x65, 0x63, 0x69, 0x70, 0x65, 0x66, 0x69, 0x6e, 0x64, 0x65, 0x72,
+	0x2e, 0x76, 0x31, 0x2e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x52, 0x05, 0x71, 0x75, 0x65, 0x72, 0x79,
+	0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x79, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04,
+	0x64, 0x61, 0x79, 0x73, 0x12, 0x22, 0x0a, 0x0d, 0x6d, 0x65, 0x61, 0x6c, 0x73, 0x5f, 0x70, 0x65,
+	0x72, 0x5f, 0x64, 0x61, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x6d, 0x65, 0x61,
+	0x6c, 0x73, 0x50, 0x65, 0x72, 0x44, 0x61, 0x79, 0x22, 0x6e, 0x0a, 0x0a, 0x49, 0x6e, 0x67, 0x72,
+	0x65, 0x64, 0x69, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x6d,
+	0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x61, 0x6d, 0x6f, 0x75,
+	0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x6e, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x75, 0x6e, 0x69, 0x74, 0x12, 0x20, 0x0a, 0x0b, 0x73, 0x75, 0x62, 0x73, 0x74, 0x69,
+	0x74, 0x75, 0x74, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x73, 0x75, 0x62,
+	0x73, 0x74, 0x69, 0x74, 0x75, 0x74, 0x65, 0x73, 0x22, 0x61, 0x0a, 0x0e, 0x4e, 0x75, 0x74, 0x72,
+	0x69, 0x65, 0x6e, 0x74, 0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x6d,
+	0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x61, 0x6d, 0x6f, 0x75,
+	0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x6e, 0x69, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x75, 0x6e, 0x69, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x61, 0x69, 0x6c, 0x79, 0x5f,
+	0x70, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0c, 0x64,
+	0x61, 0x69, 0x6c, 0x79, 0x50, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x22, 0xd3, 0x05, 0x0a, 0x06,
+	0x52, 0x65, 0x63, 0x69, 0x70, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x14,
+	0x0a, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74,
+	0x69, 0x74, 0x6c, 0x65, 0x12, 0x46, 0x0a, 0x10, 0x75, 0x73, 0x65, 0x64, 0x5f, 0x69, 0x6e, 0x67,
+	0x72, 0x65, 0x64, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b,
+	0x2e, 0x72, 0x65, 0x63, 0x69, 0x70, 0x65, 0x66, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x2e, 0x76, 0x31,
+	0x2e, 0x49, 0x6e, 0x67, 0x72, 0x65, 0x64, 0x69, 0x65, 0x6e, 0x74, 0x52, 0x0f, 0x75, 0x73, 0x65,
+	0x64, 0x49, 0x6e, 0x67, 0x72, 0x65, 0x64, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x4a, 0x0a, 0x12,
+	0x6d, 0x69, 0x73, 0x73, 0x65, 0x64, 0x5f, 0x69, 0x6e, 0x67, 0x72, 0x65, 0x64, 0x69, 0x65, 0x6e,
+	0x74, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x72, 0x65, 0x63, 0x69, 0x70,
+	0x65, 0x66, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6e, 0x67, 0x72, 0x65,
+	0x64, 0x69, 0x65, 0x6e, 0x74, 0x52, 0x11, 0x6d, 0x69, 0x73, 0x73, 0x65, 0x64, 0x49, 0x6e, 0x67,
+	0x72, 0x65, 0x64, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x44, 0x0a, 0x09, 0x6e, 0x75, 0x74, 0x72,
+	0x69, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x72, 0x65,
+	0x63, 0x69, 0x70, 0x65, 0x66, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65,
+	0x63, 0x69, 0x70, 0x65, 0x2e, 0x4e, 0x75, 0x74, 0x72, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x52, 0x09, 0x6e, 0x75, 0x74, 0x72, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x22,
+	0x0a, 0x0c, 0x69, 0x6e, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x07,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x69, 0x6e, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x72, 0x76, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x73, 0x65, 0x72, 0x76, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x35,
+	0x0a, 0x17, 0x70, 0x72, 0x69, 0x63, 0x65, 0x5f, 0x70, 0x65, 0x72, 0x5f, 0x73, 0x65, 0x72, 0x76,
+	0x69, 0x6e, 0x67, 0x5f, 0x63, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x14, 0x70, 0x72, 0x69, 0x63, 0x65, 0x50, 0x65, 0x72, 0x53, 0x65, 0x72, 0x76, 0x69, 0x6e, 0x67,
+	0x43, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x28, 0x0a, 0x10, 0x72, 0x65, 0x61, 0x64, 0x79, 0x5f, 0x69,
+	0x6e, 0x5f, 0x6d, 0x69, 0x6e, 0x75, 0x74, 0x65, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x0e, 0x72, 0x65, 0x61, 0x64, 0x79, 0x49, 0x6e, 0x4d, 0x69, 0x6e, 0x75, 0x74, 0x65, 0x73, 0x12,
+	0x1c, 0x0a, 0x09, 0x65, 0x71, 0x75, 0x69, 0x70, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x0b, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x09, 0x65, 0x71, 0x75, 0x69, 0x70, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x1d, 0x0a,
+	0x0a, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x0c, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x55, 0x72, 0x6c, 0x12, 0x1b, 0x0a, 0x09,
+	0x69, 0x6d, 0x61, 0x67, 0x65, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x55, 0x72, 0x6c, 0x12, 0x1a, 0x0a, 0x08, 0x77, 0x61, 0x72,
+	0x6e, 0x69, 0x6e, 0x67, 0x73, 0x18, 0x0e, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x77, 0x61, 0x72,
+	0x6e, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x65, 0x73, 0x74, 0x69, 0x6d, 0x61, 0x74,
+	0x65, 0x64, 0x18, 0x0f, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x65, 0x73, 0x74, 0x69, 0x6d, 0x61,
+	0x74, 0x65, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x66, 0x65, 0x74, 0x63, 0x68, 0x65, 0x64, 0x5f, 0x61,
+	0x74, 0x18, 0x10, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x66, 0x65, 0x74, 0x63, 0x68, 0x65, 0x64,
+	0x41, 0x74, 0x1a, 0x5d, 0x0a, 0x0e, 0x4e, 0x75, 0x74, 0x72, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x35, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x72, 0x65, 0x63, 0x69, 0x70, 0x65, 0x66, 0x69,
+	0x6e, 0x64, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4e, 0x75, 0x74, 0x72, 0x69, 0x65, 0x6e, 0x74,
+	0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38,
+	0x01, 0x22, 0x91, 0x01, 0x0a, 0x0b, 0x4d, 0x65, 0x61, 0x6c, 0x50, 0x6c, 0x61, 0x6e, 0x44, 0x61,
+	0x79, 0x12, 0x2d, 0x0a, 0x05, 0x6d, 0x65, 0x61, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x17, 0x2e, 0x72, 0x65, 0x63, 0x69, 0x70, 0x65, 0x66, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x52, 0x65, 0x63, 0x69, 0x70, 0x65, 0x52, 0x05, 0x6d, 0x65, 0x61, 0x6c, 0x73,
+	0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x08, 0x63, 0x61, 0x6c, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07,
+	0x70, 0x72, 0x6f, 0x74, 0x65, 0x69, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x07, 0x70,
+	0x72, 0x6f, 0x74, 0x65, 0x69, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6f, 0x73, 0x74, 0x5f, 0x63,
+	0x65, 0x6e, 0x74, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x63, 0x6f, 0x73, 0x74,
+	0x43, 0x65, 0x6e, 0x74, 0x73, 0x22, 0x77, 0x0a, 0x08, 0x4d, 0x65, 0x61, 0x6c, 0x50, 0x6c, 0x61,
+	0x6e, 0x12, 0x30, 0x0a, 0x04, 0x64, 0x61, 0x79, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x1c, 0x2e, 0x72, 0x65, 0x63, 0x69, 0x70, 0x65, 0x66, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x4d, 0x65, 0x61, 0x6c, 0x50, 0x6c, 0x61, 0x6e, 0x44, 0x61, 0x79, 0x52, 0x04, 0x64,
+	0x61, 0x79, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6f, 0x73, 0x74, 0x5f, 0x63, 0x65, 0x6e, 0x74,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x63, 0x6f, 0x73, 0x74, 0x43, 0x65, 0x6e,
+	0x74, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x6e, 0x70, 0x72, 0x69, 0x63, 0x65, 0x64, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x75, 0x6e, 0x70, 0x72, 0x69, 0x63, 0x65, 0x64, 0x32, 0x8a,
+	0x02, 0x0a, 0x0c, 0x52, 0x65, 0x63, 0x69, 0x70, 0x65, 0x46, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x12,
+	0x5e, 0x0a, 0x0d, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x63, 0x69, 0x70, 0x65, 0x73,
+	0x12, 0x25, 0x2e, 0x72, 0x65, 0x63, 0x69, 0x70, 0x65, 0x66, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x63, 0x69, 0x70, 0x65, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x26, 0x2e, 0x72, 0x65, 0x63, 0x69, 0x70, 0x65,
+	0x66, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68,
+	0x52, 0x65, 0x63, 0x69, 0x70, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x47, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x52, 0x65, 0x63, 0x69, 0x70, 0x65, 0x12, 0x21, 0x2e, 0x72,
+	0x65, 0x63, 0x69, 0x70, 0x65, 0x66, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x47,
+	0x65, 0x74, 0x52, 0x65, 0x63, 0x69, 0x70, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x17, 0x2e, 0x72, 0x65, 0x63, 0x69, 0x70, 0x65, 0x66, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x52, 0x65, 0x63, 0x69, 0x70, 0x65, 0x12, 0x51, 0x0a, 0x0d, 0x42, 0x75, 0x69, 0x6c,
+	0x64, 0x4d, 0x65, 0x61, 0x6c, 0x50, 0x6c, 0x61, 0x6e, 0x12, 0x25, 0x2e, 0x72, 0x65, 0x63, 0x69,
+	0x70, 0x65, 0x66, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x75, 0x69, 0x6c,
+	0x64, 0x4d, 0x65, 0x61, 0x6c, 0x50, 0x6c, 0x61, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x19, 0x2e, 0x72, 0x65, 0x63, 0x69, 0x70, 0x65, 0x66, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x2e,
+	0x76, 0x31, 0x2e, 0x4d, 0x65, 0x61, 0x6c, 0x50, 0x6c, 0x61, 0x6e, 0x42, 0x38, 0x5a, 0x36, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6d, 0x61, 0x77, 0x6f, 0x6a, 0x63,
+	0x69, 0x6b, 0x2f, 0x6d, 0x65, 0x61, 0x6c, 0x73, 0x5f, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74,
+	0x6f, 0x72, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x72, 0x65, 0x63, 0x69, 0x70, 0x65, 0x66, 0x69, 0x6e,
+	0x64, 0x65, 0x72, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_recipefinder_v1_recipefinder_proto_rawDescOnce sync.Once
+	file_recipefinder_v1_recipefinder_proto_rawDescData = file_recipefinder_v1_recipefinder_proto_rawDesc
+)
+
+func file_recipefinder_v1_recipefinder_proto_rawDescGZIP() []byte {
+	file_recipefinder_v1_recipefinder_proto_rawDescOnce.Do(func() {
+		file_recipefinder_v1_recipefinder_proto_rawDescData = protoimpl.X.CompressGZIP(file_recipefinder_v1_recipefinder_proto_rawDescData)
+	})
+	return file_recipefinder_v1_recipefinder_proto_rawDescData
+}
+
+var file_recipefinder_v1_recipefinder_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_recipefinder_v1_recipefinder_proto_goTypes = []any{
+	(*Query)(nil),                 // 0: recipefinder.v1.Query
+	(*SearchRecipesRequest)(nil),  // 1: recipefinder.v1.SearchRecipesRequest
+	(*SearchRecipesResponse)(nil), // 2: recipefinder.v1.SearchRecipesResponse
+	(*GetRecipeRequest)(nil),      // 3: recipefinder.v1.GetRecipeRequest
+	(*BuildMealPlanRequest)(nil),  // 4: recipefinder.v1.BuildMealPlanRequest
+	(*Ingredient)(nil),            // 5: recipefinder.v1.Ingredient
+	(*NutrientAmount)(nil),        // 6: recipefinder.v1.NutrientAmount
+	(*Recipe)(nil),                // 7: recipefinder.v1.Recipe
+	(*MealPlanDay)(nil),           // 8: recipefinder.v1.MealPlanDay
+	(*MealPlan)(nil),              // 9: recipefinder.v1.MealPlan
+	nil,                           // 10: recipefinder.v1.Recipe.NutrientsEntry
+}
+var file_recipefinder_v1_recipefinder_proto_depIdxs = []int32{
+	0,  // 0: recipefinder.v1.SearchRecipesRequest.query:type_name -> recipefinder.v1.Query
+	7,  // 1: recipefinder.v1.SearchRecipesResponse.recipes:type_name -> recipefinder.v1.Recipe
+	0,  // 2: recipefinder.v1.BuildMealPlanRequest.query:type_name -> recipefinder.v1.Query
+	5,  // 3: recipefinder.v1.Recipe.used_ingredients:type_name -> recipefinder.v1.Ingredient
+	5,  // 4: recipefinder.v1.Recipe.missed_ingredients:type_name -> recipefinder.v1.Ingredient
+	10, // 5: recipefinder.v1.Recipe.nutrients:type_name -> recipefinder.v1.Recipe.NutrientsEntry
+	7,  // 6: recipefinder.v1.MealPlanDay.meals:type_name -> recipefinder.v1.Recipe
+	8,  // 7: recipefinder.v1.MealPlan.days:type_name -> recipefinder.v1.MealPlanDay
+	6,  // 8: recipefinder.v1.Recipe.NutrientsEntry.value:type_name -> recipefinder.v1.NutrientAmount
+	1,  // 9: recipefinder.v1.RecipeFinder.SearchRecipes:input_type -> recipefinder.v1.SearchRecipesRequest
+	3,  // 10: recipefinder.v1.RecipeFinder.GetRecipe:input_type -> recipefinder.v1.GetRecipeRequest
+	4,  // 11: recipefinder.v1.RecipeFinder.BuildMealPlan:input_type -> recipefinder.v1.BuildMealPlanRequest
+	2,  // 12: recipefinder.v1.RecipeFinder.SearchRecipes:output_type -> recipefinder.v1.SearchRecipesResponse
+	7,  // 13: recipefinder.v1.RecipeFinder.GetRecipe:output_type -> recipefinder.v1.Recipe
+	9,  // 14: recipefinder.v1.RecipeFinder.BuildMealPlan:output_type -> recipefinder.v1.MealPlan
+	12, // [12:15] is the sub-list for method output_type
+	9,  // [9:12] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_recipefinder_v1_recipefinder_proto_init() }
+func file_recipefinder_v1_recipefinder_proto_init() {
+	if File_recipefinder_v1_recipefinder_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_recipefinder_v1_recipefinder_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Query); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recipefinder_v1_recipefinder_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*SearchRecipesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recipefinder_v1_recipefinder_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*SearchRecipesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recipefinder_v1_recipefinder_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*GetRecipeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recipefinder_v1_recipefinder_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*BuildMealPlanRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recipefinder_v1_recipefinder_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*Ingredient); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recipefinder_v1_recipefinder_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*NutrientAmount); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recipefinder_v1_recipefinder_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*Recipe); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recipefinder_v1_recipefinder_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*MealPlanDay); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_recipefinder_v1_recipefinder_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*MealPlan); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_recipefinder_v1_recipefinder_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_recipefinder_v1_recipefinder_proto_goTypes,
+		DependencyIndexes: file_recipefinder_v1_recipefinder_proto_depIdxs,
+		MessageInfos:      file_recipefinder_v1_recipefinder_proto_msgTypes,
+	}.Build()
+	File_recipefinder_v1_recipefinder_proto = out.File
+	file_recipefinder_v1_recipefinder_proto_rawDesc = nil
+	file_recipefinder_v1_recipefinder_proto_goTypes = nil
+	file_recipefinder_v1_recipefinder_proto_depIdxs = nil
+}
@@ -0,0 +1,214 @@
+// The gRPC contract of the recipefinder service: the searches of
+// "recipefinder search", "show" and "plan" for services that call them
+// rather than run the CLI or parse the HTTP server's JSON. The Go code for
+// it is generated into pkg/recipefinderpb, see its doc.go.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: recipefinder/v1/recipefinder.proto
+
+package recipefinderpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RecipeFinder_SearchRecipes_FullMethodName = "/recipefinder.v1.RecipeFinder/SearchRecipes"
+	RecipeFinder_GetRecipe_FullMethodName     = "/recipefinder.v1.RecipeFinder/GetRecipe"
+	RecipeFinder_BuildMealPlan_FullMethodName = "/recipefinder.v1.RecipeFinder/BuildMealPlan"
+)
+
+// RecipeFinderClient is the client API for RecipeFinder service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RecipeFinderClient interface {
+	// SearchRecipes finds recipes using the ingredients, like "recipefinder
+	// search", served from the cache when it holds enough of them.
+	SearchRecipes(ctx context.Context, in *SearchRecipesRequest, opts ...grpc.CallOption) (*SearchRecipesResponse, error)
+	// GetRecipe returns a recipe with its full details, like "recipefinder
+	// show".
+	GetRecipe(ctx context.Context, in *GetRecipeRequest, opts ...grpc.CallOption) (*Recipe, error)
+	// BuildMealPlan spreads the recipes found for the ingredients over days,
+	// like "recipefinder plan".
+	BuildMealPlan(ctx context.Context, in *BuildMealPlanRequest, opts ...grpc.CallOption) (*MealPlan, error)
+}
+
+type recipeFinderClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRecipeFinderClient(cc grpc.ClientConnInterface) RecipeFinderClient {
+	return &recipeFinderClient{cc}
+}
+
+func (c *recipeFinderClient) SearchRecipes(ctx context.Context, in *SearchRecipesRequest, opts ...grpc.CallOption) (*SearchRecipesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchRecipesResponse)
+	err := c.cc.Invoke(ctx, RecipeFinder_SearchRecipes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recipeFinderClient) GetRecipe(ctx context.Context, in *GetRecipeRequest, opts ...grpc.CallOption) (*Recipe, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Recipe)
+	err := c.cc.Invoke(ctx, RecipeFinder_GetRecipe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recipeFinderClient) BuildMealPlan(ctx context.Context, in *BuildMealPlanRequest, opts ...grpc.CallOption) (*MealPlan, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MealPlan)
+	err := c.cc.Invoke(ctx, RecipeFinder_BuildMealPlan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RecipeFinderServer is the server API for RecipeFinder service.
+// All implementations must embed UnimplementedRecipeFinderServer
+// for forward compatibility.
+type RecipeFinderServer interface {
+	// SearchRecipes finds recipes using the ingredients, like "recipefinder
+	// search", served from the cache when it holds enough of them.
+	SearchRecipes(context.Context, *SearchRecipesRequest) (*SearchRecipesResponse, error)
+	// GetRecipe returns a recipe with its full details, like "recipefinder
+	// show".
+	GetRecipe(context.Context, *GetRecipeRequest) (*Recipe, error)
+	// BuildMealPlan spreads the recipes found for the ingredients over days,
+	// like "recipefinder plan".
+	BuildMealPlan(context.Context, *BuildMealPlanRequest) (*MealPlan, error)
+	mustEmbedUnimplementedRecipeFinderServer()
+}
+
+// UnimplementedRecipeFinderServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRecipeFinderServer struct{}
+
+func (UnimplementedRecipeFinderServer) SearchRecipes(context.Context, *SearchRecipesRequest) (*SearchRecipesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchRecipes not implemented")
+}
+func (UnimplementedRecipeFinderServer) GetRecipe(context.Context, *GetRecipeRequest) (*Recipe, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRecipe not implemented")
+}
+func (UnimplementedRecipeFinderServer) BuildMealPlan(context.Context, *BuildMealPlanRequest) (*MealPlan, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BuildMealPlan not implemented")
+}
+func (UnimplementedRecipeFinderServer) mustEmbedUnimplementedRecipeFinderServer() {}
+func (UnimplementedRecipeFinderServer) testEmbeddedByValue()                      {}
+
+// UnsafeRecipeFinderServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RecipeFinderServer will
+// result in compilation errors.
+type UnsafeRecipeFinderServer interface {
+	mustEmbedUnimplementedRecipeFinderServer()
+}
+
+func RegisterRecipeFinderServer(s grpc.ServiceRegistrar, srv RecipeFinderServer) {
+	// If the following call pancis, it indicates UnimplementedRecipeFinderServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RecipeFinder_ServiceDesc, srv)
+}
+
+func _RecipeFinder_SearchRecipes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRecipesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecipeFinderServer).SearchRecipes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecipeFinder_SearchRecipes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecipeFinderServer).SearchRecipes(ctx, req.(*SearchRecipesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecipeFinder_GetRecipe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRecipeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecipeFinderServer).GetRecipe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecipeFinder_GetRecipe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecipeFinderServer).GetRecipe(ctx, req.(*GetRecipeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecipeFinder_BuildMealPlan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BuildMealPlanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecipeFinderServer).BuildMealPlan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecipeFinder_BuildMealPlan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecipeFinderServer).BuildMealPlan(ctx, req.(*BuildMealPlanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RecipeFinder_ServiceDesc is the grpc.ServiceDesc for RecipeFinder service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RecipeFinder_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "recipefinder.v1.RecipeFinder",
+	HandlerType: (*RecipeFinderServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SearchRecipes",
+			Handler:    _RecipeFinder_SearchRecipes_Handler,
+		},
+		{
+			MethodName: "GetRecipe",
+			Handler:    _RecipeFinder_GetRecipe_Handler,
+		},
+		{
+			MethodName: "BuildMealPlan",
+			Handler:    _RecipeFinder_BuildMealPlan_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "recipefinder/v1/recipefinder.proto",
+}
@@ -0,0 +1,33 @@
+package recipes
+
+// NoAlcohol is the rule set selected with --no-alcohol. Drinks with a standard
+// cooking substitute are flagged with it, since the recipe still works without
+// them; drinks a recipe is built around, as in cocktails, exclude it.
+var NoAlcohol = RuleSet{
+	Name:     "alcohol-free",
+	Excluded: []string{"vodka", "gin", "tequila", "liqueur", "absinthe", "schnapps"},
+	Allowed:  []string{"wine vinegar", "cider vinegar", "ginger ale", "root beer", "non-alcoholic"},
+	Flagged: map[string]string{
+		"wine":            "contains alcohol, substitute stock with a splash of vinegar",
+		"beer":            "contains alcohol, substitute stock or non-alcoholic beer",
+		"ale":             "contains alcohol, substitute stock or non-alcoholic beer",
+		"lager":           "contains alcohol, substitute stock or non-alcoholic beer",
+		"stout":           "contains alcohol, substitute beef stock or non-alcoholic stout",
+		"rum":             "contains alcohol, substitute apple juice with a little vanilla",
+		"whiskey":         "contains alcohol, substitute apple juice with a little vanilla",
+		"whisky":          "contains alcohol, substitute apple juice with a little vanilla",
+		"bourbon":         "contains alcohol, substitute apple juice with a little vanilla",
+		"brandy":          "contains alcohol, substitute apple or white grape juice",
+		"cognac":          "contains alcohol, substitute apple or white grape juice",
+		"sherry":          "contains alcohol, substitute apple juice with a splash of vinegar",
+		"marsala":         "contains alcohol, substitute grape juice with a splash of balsamic vinegar",
+		"vermouth":        "contains alcohol, substitute white grape juice with a splash of vinegar",
+		"champagne":       "contains alcohol, substitute sparkling white grape juice",
+		"prosecco":        "contains alcohol, substitute sparkling white grape juice",
+		"cider":           "contains alcohol, substitute apple juice",
+		"sake":            "contains alcohol, substitute rice vinegar diluted with water",
+		"mirin":           "contains alcohol, substitute rice vinegar with a pinch of sugar",
+		"kahlua":          "contains alcohol, substitute strong coffee with a little sugar",
+		"vanilla extract": "contains alcohol, substitute alcohol-free vanilla or vanilla powder",
+	},
+}
@@ -0,0 +1,164 @@
+package recipes
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount of US dollars in hundredths of a cent. Prices are kept
+// as integers so adding up a plan or scaling a recipe does not drift the way
+// float64 cents do; they are rounded once, when converted, and printed
+// rounded half up to the cent. In JSON it is a number of cents, as the APIs
+// give prices.
+type Money int64
+
+// Cent is one US cent.
+const Cent Money = 100
+
+// CentsToMoney converts an amount in cents, rounding it to the nearest
+// hundredth of a cent.
+func CentsToMoney(cents float64) Money {
+	return Money(math.Round(cents * float64(Cent)))
+}
+
+// DollarsToMoney converts an amount in dollars, rounding it to the nearest
+// hundredth of a cent.
+func DollarsToMoney(dollars float64) Money {
+	return CentsToMoney(dollars * 100)
+}
+
+// Cents returns the amount in cents.
+func (m Money) Cents() float64 {
+	return float64(m) / float64(Cent)
+}
+
+// Mul scales the amount, rounding to the nearest hundredth of a cent.
+func (m Money) Mul(factor float64) Money {
+	return Money(math.Round(float64(m) * factor))
+}
+
+// RoundedCents returns the amount in whole cents, halves rounded away from
+// zero.
+func (m Money) RoundedCents() int64 {
+	if m < 0 {
+		return -(-m).RoundedCents()
+	}
+	return int64((m + Cent/2) / Cent)
+}
+
+// String returns the amount in dollars, such as "$2.77".
+func (m Money) String() string {
+	cents := m.RoundedCents()
+	sign := ""
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s$%d.%02d", sign, cents/100, cents%100)
+}
+
+// Decimal returns the amount in dollars without the currency sign, such as
+// "2.77", for spreadsheets.
+func (m Money) Decimal() string {
+	return m.String()[len("$"):]
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return strconv.AppendFloat(nil, m.Cents(), 'f', -1, 64), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	cents, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount of money %s", data)
+	}
+	*m = CentsToMoney(cents)
+	return nil
+}
+
+// NutrientAmount is an amount of a nutrient in a serving, in an explicit
+// unit such as kcal, g, mg or µg.
+type NutrientAmount struct {
+	Amount float64 `json:"amount"`
+	Unit   string  `json:"unit"`
+	// DailyPercent is the share of the daily value the amount is, in percent,
+	// zero when the source gives none.
+	DailyPercent float64 `json:"dailyPercent,omitempty"`
+}
+
+// nutrientUnits convert the units of nutrient amounts into a base unit of
+// the same kind: grams for masses, kcal for energy.
+var nutrientUnits = map[string]struct {
+	base   string
+	factor float64
+}{
+	"g":    {"g", 1},
+	"mg":   {"g", 1e-3},
+	"µg":   {"g", 1e-6},
+	"mcg":  {"g", 1e-6},
+	"kcal": {"kcal", 1},
+	"kj":   {"kcal", 1 / 4.184},
+}
+
+// nutrientSteps are what amounts in each unit are rounded to for printing,
+// after the rounding rules of nutrition labels.
+var nutrientSteps = map[string]float64{
+	"kcal": 1,
+	"kj":   1,
+	"g":    0.1,
+	"mg":   1,
+	"µg":   0.1,
+	"mcg":  0.1,
+	"%":    1,
+}
+
+// In converts the amount to another unit of the same kind, reporting whether
+// it could; kJ to kcal can, mg to kcal cannot.
+func (n NutrientAmount) In(unit string) (NutrientAmount, bool) {
+	if n.Unit == unit {
+		return n, true
+	}
+	from, fromOK := nutrientUnits[strings.ToLower(n.Unit)]
+	to, toOK := nutrientUnits[strings.ToLower(unit)]
+	if !fromOK || !toOK || from.base != to.base {
+		return n, false
+	}
+	return NutrientAmount{Amount: n.Amount * from.factor / to.factor, Unit: unit, DailyPercent: n.DailyPercent}, true
+}
+
+// Add returns the sum of two amounts in the unit of n, or an error when the
+// other cannot be converted to it. A zero n takes the unit of other.
+func (n NutrientAmount) Add(other NutrientAmount) (NutrientAmount, error) {
+	if n == (NutrientAmount{}) {
+		return other, nil
+	}
+	converted, ok := other.In(n.Unit)
+	if !ok {
+		return n, fmt.Errorf("cannot add %s to %s", other.Unit, n.Unit)
+	}
+	return NutrientAmount{
+		Amount:       n.Amount + converted.Amount,
+		Unit:         n.Unit,
+		DailyPercent: n.DailyPercent + converted.DailyPercent,
+	}, nil
+}
+
+// Mul scales the amount, such as to a number of servings.
+func (n NutrientAmount) Mul(factor float64) NutrientAmount {
+	return NutrientAmount{Amount: n.Amount * factor, Unit: n.Unit, DailyPercent: n.DailyPercent * factor}
+}
+
+// Rounded returns the amount rounded as nutrition labels round it in its
+// unit, to whole kcal or tenths of a gram, so rounding happens once, when
+// printing, rather than at every step.
+func (n NutrientAmount) Rounded() NutrientAmount {
+	step, ok := nutrientSteps[strings.ToLower(n.Unit)]
+	if !ok {
+		step = 0.01
+	}
+	return NutrientAmount{Amount: math.Round(n.Amount/step) * step, Unit: n.Unit, DailyPercent: math.Round(n.DailyPercent)}
+}
@@ -0,0 +1,49 @@
+package recipes
+
+import "strings"
+
+// Availability describes ingredients that are hard to buy in a region.
+type Availability struct {
+	// Rare lists ingredient names that are rarely available. A recipe
+	// ingredient matches when it contains one of them as whole words.
+	Rare []string
+	// Exclude drops recipes missing a rare ingredient. Otherwise they are
+	// kept but moved after the other recipes.
+	Exclude bool
+}
+
+// Apply filters or reorders recipes according to the availability list. Only
+// missing ingredients count: a rare ingredient the user already has does not
+// need to be bought.
+func (a Availability) Apply(allRecipes []Recipe) []Recipe {
+	if len(a.Rare) == 0 {
+		return allRecipes
+	}
+
+	available := make([]Recipe, 0, len(allRecipes))
+	var needsRare []Recipe
+	for _, recipe := range allRecipes {
+		if a.needsRareIngredient(recipe) {
+			needsRare = append(needsRare, recipe)
+		} else {
+			available = append(available, recipe)
+		}
+	}
+
+	if a.Exclude {
+		return available
+	}
+	return append(available, needsRare...)
+}
+
+func (a Availability) needsRareIngredient(recipe Recipe) bool {
+	for _, ingredient := range recipe.MissedIngredients {
+		name := strings.ToLower(ingredient.Name)
+		for _, rare := range a.Rare {
+			if containsWords(name, strings.ToLower(rare)) {
+				return true
+			}
+		}
+	}
+	return false
+}
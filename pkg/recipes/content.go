@@ -0,0 +1,74 @@
+package recipes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ContentFilter leaves out recipes by their titles, such as copycat recipes,
+// brand names or clickbait. The zero ContentFilter keeps every recipe.
+type ContentFilter struct {
+	keywords []string
+	patterns []*regexp.Regexp
+}
+
+// NewContentFilter returns a filter leaving out the recipes whose title
+// contains one of the keywords as whole words, or matches one of the regular
+// expressions, both ignoring case.
+func NewContentFilter(keywords []string, patterns []string) (ContentFilter, error) {
+	var filter ContentFilter
+	for _, keyword := range keywords {
+		if words := filterWords(keyword); words != "" {
+			filter.keywords = append(filter.keywords, words)
+		}
+	}
+	for _, pattern := range patterns {
+		compiled, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return ContentFilter{}, fmt.Errorf("invalid title pattern %q: %v", pattern, err)
+		}
+		filter.patterns = append(filter.patterns, compiled)
+	}
+	return filter, nil
+}
+
+// Apply leaves out the recipes the filter excludes.
+func (f ContentFilter) Apply(allRecipes []Recipe) []Recipe {
+	if len(f.keywords) == 0 && len(f.patterns) == 0 {
+		return allRecipes
+	}
+	kept := make([]Recipe, 0, len(allRecipes))
+	for _, recipe := range allRecipes {
+		if !f.Excludes(recipe) {
+			kept = append(kept, recipe)
+		}
+	}
+	return kept
+}
+
+// Excludes reports whether the filter leaves the recipe out.
+func (f ContentFilter) Excludes(recipe Recipe) bool {
+	words := filterWords(recipe.Title)
+	for _, keyword := range f.keywords {
+		if containsWords(words, keyword) {
+			return true
+		}
+	}
+	for _, pattern := range f.patterns {
+		if pattern.MatchString(recipe.Title) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterWords lower-cases text and separates its words by single spaces,
+// dropping punctuation, so "Copycat: Chipotle's Bowl" has the word
+// "copycat".
+func filterWords(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	}), " ")
+}
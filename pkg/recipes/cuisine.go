@@ -0,0 +1,31 @@
+package recipes
+
+import (
+	"slices"
+	"strings"
+)
+
+// Cuisines normalizes the cuisines a source gives a recipe: lowercase,
+// without blanks, repeats and "unknown", or nil when none is left.
+func Cuisines(given []string) []string {
+	var cuisines []string
+	for _, cuisine := range given {
+		cuisine = strings.ToLower(strings.TrimSpace(cuisine))
+		if cuisine == "" || cuisine == "unknown" || slices.Contains(cuisines, cuisine) {
+			continue
+		}
+		cuisines = append(cuisines, cuisine)
+	}
+	return cuisines
+}
+
+// newCuisine reports whether the recipe belongs to a cuisine that is not
+// known.
+func newCuisine(recipe Recipe, known []string) bool {
+	for _, cuisine := range recipe.Cuisines {
+		if !slices.Contains(known, cuisine) {
+			return true
+		}
+	}
+	return false
+}
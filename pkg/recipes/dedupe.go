@@ -0,0 +1,93 @@
+package recipes
+
+import (
+	"strings"
+	"unicode"
+)
+
+// titleFillers are words recipe titles add without changing the dish, left
+// out when comparing titles.
+var titleFillers = map[string]bool{
+	"a": true, "an": true, "and": true, "the": true, "with": true, "easy": true, "simple": true, "quick": true,
+	"best": true, "homemade": true, "classic": true, "perfect": true, "recipe": true, "my": true, "ever": true,
+	"i": true, "ii": true, "iii": true, "iv": true, "v": true, "vi": true,
+}
+
+// minTitleSimilarity is the share of words two titles must have in common to
+// be taken for the same dish.
+const minTitleSimilarity = 0.8
+
+// CollapseDuplicates drops recipes that repeat an earlier one: the same
+// recipe from the same source, or a title so similar that it is likely the
+// same dish, such as "Easy Pancakes" and "Easy Pancakes II". The first of
+// each is kept.
+func CollapseDuplicates(allRecipes []Recipe) []Recipe {
+	type key struct {
+		source string
+		id     int
+	}
+	seen := make(map[key]bool, len(allRecipes))
+	var keptTitles [][]string
+	kept := make([]Recipe, 0, len(allRecipes))
+	for _, recipe := range allRecipes {
+		id := key{recipe.Source, recipe.ID}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		words := titleWords(recipe.Title)
+		duplicate := false
+		for _, other := range keptTitles {
+			if similarTitles(words, other) {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+		keptTitles = append(keptTitles, words)
+		kept = append(kept, recipe)
+	}
+	return kept
+}
+
+// titleWords returns the distinct words of a title that tell dishes apart:
+// lowercased, singular, without punctuation, numbers and titleFillers.
+func titleWords(title string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	var words []string
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if titleFillers[field] {
+			continue
+		}
+		word := singular(field)
+		if !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// similarTitles reports whether two titles share at least
+// minTitleSimilarity of their words, counted over both. Titles with no words
+// left are never similar.
+func similarTitles(a []string, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return false
+	}
+	common := 0
+	for _, word := range a {
+		for _, other := range b {
+			if word == other {
+				common++
+				break
+			}
+		}
+	}
+	return float64(common)/float64(len(a)+len(b)-common) >= minTitleSimilarity
+}
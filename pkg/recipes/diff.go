@@ -0,0 +1,118 @@
+package recipes
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ResultDiff is how the results of a search changed between two runs.
+type ResultDiff struct {
+	// Added recipes are only in the current results, Removed ones only in
+	// the earlier ones.
+	Added   []Recipe       `json:"added"`
+	Removed []Recipe       `json:"removed"`
+	Changed []RecipeChange `json:"changed"`
+}
+
+// RecipeChange is a recipe found by both runs whose data changed.
+type RecipeChange struct {
+	Recipe Recipe `json:"recipe"`
+	// Changes describe each difference, for example "Calories 480 → 520 kcal".
+	Changes []string `json:"changes"`
+}
+
+// Empty reports whether the results did not change.
+func (d ResultDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffResults compares the results of two runs of a search, matching recipes
+// by source and ID. The recipes keep the order of the run they come from.
+func DiffResults(previous []Recipe, current []Recipe) ResultDiff {
+	type key struct {
+		source string
+		id     int
+	}
+	before := make(map[key]Recipe, len(previous))
+	for _, recipe := range previous {
+		before[key{recipe.Source, recipe.ID}] = recipe
+	}
+	after := make(map[key]bool, len(current))
+
+	var diff ResultDiff
+	for _, recipe := range current {
+		after[key{recipe.Source, recipe.ID}] = true
+		old, found := before[key{recipe.Source, recipe.ID}]
+		if !found {
+			diff.Added = append(diff.Added, recipe)
+			continue
+		}
+		if changes := recipeChanges(old, recipe); len(changes) > 0 {
+			diff.Changed = append(diff.Changed, RecipeChange{Recipe: recipe, Changes: changes})
+		}
+	}
+	for _, recipe := range previous {
+		if !after[key{recipe.Source, recipe.ID}] {
+			diff.Removed = append(diff.Removed, recipe)
+		}
+	}
+	return diff
+}
+
+// recipeChanges describes how the title, missing ingredients and nutrients of
+// a recipe changed. Nutrient amounts differing only by rounding are equal.
+func recipeChanges(old Recipe, recipe Recipe) []string {
+	var changes []string
+	if old.Title != recipe.Title {
+		changes = append(changes, fmt.Sprintf("title was %q", old.Title))
+	}
+
+	oldMissing := IngredientNames(old.MissedIngredients)
+	missing := IngredientNames(recipe.MissedIngredients)
+	var nowMissing, noLongerMissing []string
+	for _, name := range missing {
+		if !slices.Contains(oldMissing, name) {
+			nowMissing = append(nowMissing, name)
+		}
+	}
+	for _, name := range oldMissing {
+		if !slices.Contains(missing, name) {
+			noLongerMissing = append(noLongerMissing, name)
+		}
+	}
+	if len(nowMissing) > 0 {
+		changes = append(changes, "now missing "+strings.Join(nowMissing, ", "))
+	}
+	if len(noLongerMissing) > 0 {
+		changes = append(changes, "no longer missing "+strings.Join(noLongerMissing, ", "))
+	}
+
+	names := make(map[string]bool)
+	for name := range old.Nutrients {
+		names[name] = true
+	}
+	for name := range recipe.Nutrients {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		before, hadBefore := old.Nutrients[name]
+		after, hasNow := recipe.Nutrients[name]
+		switch {
+		case !hadBefore:
+			changes = append(changes, fmt.Sprintf("%s now %.4g %s", name, after.Amount, after.Unit))
+		case !hasNow:
+			changes = append(changes, fmt.Sprintf("%s no longer known", name))
+		case math.Abs(before.Amount-after.Amount) >= 0.5 || before.Unit != after.Unit:
+			changes = append(changes, fmt.Sprintf("%s %.4g → %.4g %s", name, before.Amount, after.Amount, after.Unit))
+		}
+	}
+	return changes
+}
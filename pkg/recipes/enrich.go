@@ -0,0 +1,64 @@
+package recipes
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultParallel is how many follow-up requests Enrich makes at once when
+// EnrichLimits.Parallel is zero.
+const DefaultParallel = 4
+
+// EnrichLimits bound the follow-up requests providers make per recipe, such
+// as for the instructions a search result lacks.
+type EnrichLimits struct {
+	// Parallel is how many requests run at once, DefaultParallel when zero.
+	Parallel int
+	// Interval is the least time between the starts of two requests, to
+	// stay within the API's rate limit; no limit when zero.
+	Interval time.Duration
+}
+
+// Enrich calls fetch for each item on a pool of Parallel workers, starting
+// the calls no closer together than Interval, and returns the results in the
+// order of the items. The first error cancels the calls still running, skips
+// the ones not started and is returned.
+func Enrich[T any, R any](ctx context.Context, items []T, limits EnrichLimits,
+	fetch func(context.Context, T) (R, error)) ([]R, error) {
+	parallel := limits.Parallel
+	if parallel <= 0 {
+		parallel = DefaultParallel
+	}
+	var ticker *time.Ticker
+	if limits.Interval > 0 {
+		ticker = time.NewTicker(limits.Interval)
+		defer ticker.Stop()
+	}
+
+	results := make([]R, len(items))
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(parallel)
+	for i, item := range items {
+		if ticker != nil && i > 0 {
+			select {
+			case <-groupCtx.Done():
+			case <-ticker.C:
+			}
+		}
+		if groupCtx.Err() != nil {
+			break
+		}
+		group.Go(func() error {
+			result, err := fetch(groupCtx, item)
+			results[i] = result
+			return err
+		})
+	}
+	err := group.Wait()
+	if err != nil {
+		return nil, err
+	}
+	return results, ctx.Err()
+}
@@ -0,0 +1,75 @@
+package recipes
+
+import (
+	"slices"
+	"strings"
+)
+
+// PrepLimits limits how a recipe is made. Zero fields are unset.
+type PrepLimits struct {
+	// MaxReadyTime is the most minutes a recipe may take, preparation and
+	// cooking together.
+	MaxReadyTime int
+	// Equipment lists the equipment a recipe should use, any one of it, and
+	// NoEquipment the equipment no recipe may use. Both hold names as
+	// NormalizeEquipment returns them.
+	Equipment   []string
+	NoEquipment []string
+}
+
+func (l PrepLimits) IsZero() bool {
+	return l.MaxReadyTime == 0 && len(l.Equipment) == 0 && len(l.NoEquipment) == 0
+}
+
+// NormalizeEquipment turns a comma-separated equipment list as typed by a
+// user into sorted lowercase names without duplicates.
+func NormalizeEquipment(list string) []string {
+	var normalized []string
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.Join(strings.Fields(strings.ToLower(entry)), " ")
+		if entry != "" && !slices.Contains(normalized, entry) {
+			normalized = append(normalized, entry)
+		}
+	}
+	slices.Sort(normalized)
+	return normalized
+}
+
+// Apply drops the recipes outside the limits. Sources that cannot filter on
+// them themselves rely on it, so a recipe whose ready time is unknown is
+// dropped when there is a MaxReadyTime, and one listing no equipment when
+// there is Equipment.
+func (l PrepLimits) Apply(allRecipes []Recipe) []Recipe {
+	if l.IsZero() {
+		return allRecipes
+	}
+	kept := make([]Recipe, 0, len(allRecipes))
+	for _, recipe := range allRecipes {
+		if l.MaxReadyTime > 0 && (recipe.ReadyInMinutes == 0 || recipe.ReadyInMinutes > l.MaxReadyTime) {
+			continue
+		}
+		if len(l.Equipment) > 0 && !usesEquipment(recipe, l.Equipment) {
+			continue
+		}
+		if usesEquipment(recipe, l.NoEquipment) {
+			continue
+		}
+		kept = append(kept, recipe)
+	}
+	return kept
+}
+
+// usesEquipment reports whether the recipe uses any of the equipment. A name
+// matches the equipment named by any of its words, so "oven" matches "oven"
+// and "microwave oven" but not "ovenproof dish".
+func usesEquipment(recipe Recipe, equipment []string) bool {
+	for _, used := range recipe.Equipment {
+		used = " " + strings.ToLower(used) + " "
+		for _, name := range equipment {
+			if strings.Contains(used, " "+name+" ") {
+				return true
+			}
+		}
+	}
+	return false
+}
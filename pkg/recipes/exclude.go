@@ -0,0 +1,65 @@
+package recipes
+
+import (
+	"sort"
+	"strings"
+)
+
+// NormalizeExclusions turns a comma-separated list of ingredients to leave
+// out, as typed by a user, into sorted normalized names, see
+// NormalizeIngredient. The allergens, ingredients left out of every search,
+// are added to them.
+func NormalizeExclusions(list string, allergens []string) []string {
+	seen := make(map[string]bool)
+	var normalized []string
+	for _, entry := range append(strings.Split(list, ","), allergens...) {
+		entry = NormalizeIngredient(entry)
+		if entry == "" || seen[entry] {
+			continue
+		}
+		seen[entry] = true
+		normalized = append(normalized, entry)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+// ExcludeIngredients drops the recipes with an ingredient containing one of
+// the excluded names as whole words, so excluding "peanut" also drops recipes
+// using "roasted peanuts" or "peanut butter". The excluded names must be
+// normalized.
+func ExcludeIngredients(allRecipes []Recipe, excluded []string) []Recipe {
+	if len(excluded) == 0 {
+		return allRecipes
+	}
+	kept := make([]Recipe, 0, len(allRecipes))
+	for _, recipe := range allRecipes {
+		if !usesExcluded(recipe, excluded) {
+			kept = append(kept, recipe)
+		}
+	}
+	return kept
+}
+
+func usesExcluded(recipe Recipe, excluded []string) bool {
+	for _, ingredients := range [][]Ingredient{recipe.UsedIngredients, recipe.MissedIngredients} {
+		for _, ingredient := range ingredients {
+			if IsExcluded(ingredient.Name, excluded) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsExcluded reports whether an ingredient name contains one of the
+// normalized excluded names, the way ExcludeIngredients decides.
+func IsExcluded(name string, excluded []string) bool {
+	name = NormalizeIngredient(name)
+	for _, exclusion := range excluded {
+		if containsWords(name, exclusion) {
+			return true
+		}
+	}
+	return false
+}
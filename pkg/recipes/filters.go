@@ -0,0 +1,98 @@
+package recipes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// diets maps the names accepted for a diet to the name the API uses.
+var diets = map[string]string{
+	"gluten free":      "gluten free",
+	"gluten-free":      "gluten free",
+	"glutenfree":       "gluten free",
+	"ketogenic":        "ketogenic",
+	"keto":             "ketogenic",
+	"vegetarian":       "vegetarian",
+	"lacto-vegetarian": "lacto-vegetarian",
+	"lacto vegetarian": "lacto-vegetarian",
+	"ovo-vegetarian":   "ovo-vegetarian",
+	"ovo vegetarian":   "ovo-vegetarian",
+	"vegan":            "vegan",
+	"pescetarian":      "pescetarian",
+	"pescatarian":      "pescetarian",
+	"paleo":            "paleo",
+	"primal":           "primal",
+	"low fodmap":       "low fodmap",
+	"low-fodmap":       "low fodmap",
+	"whole30":          "whole30",
+	"whole 30":         "whole30",
+}
+
+// intolerances maps the names accepted for an intolerance to the name the
+// API uses.
+var intolerances = map[string]string{
+	"dairy":     "dairy",
+	"egg":       "egg",
+	"eggs":      "egg",
+	"gluten":    "gluten",
+	"grain":     "grain",
+	"peanut":    "peanut",
+	"peanuts":   "peanut",
+	"seafood":   "seafood",
+	"sesame":    "sesame",
+	"shellfish": "shellfish",
+	"soy":       "soy",
+	"sulfite":   "sulfite",
+	"sulfites":  "sulfite",
+	"tree nut":  "tree nut",
+	"tree nuts": "tree nut",
+	"tree-nut":  "tree nut",
+	"wheat":     "wheat",
+}
+
+// NormalizeDiets turns a comma-separated diet list as typed by a user into
+// sorted canonical diet names.
+func NormalizeDiets(list string) ([]string, error) {
+	return normalizeFilter(list, diets, "diet")
+}
+
+// NormalizeIntolerances turns a comma-separated intolerance list as typed by
+// a user into sorted canonical intolerance names.
+func NormalizeIntolerances(list string) ([]string, error) {
+	return normalizeFilter(list, intolerances, "intolerance")
+}
+
+func normalizeFilter(list string, known map[string]string, kind string) ([]string, error) {
+	seen := make(map[string]bool)
+	var normalized []string
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		canonical, ok := known[entry]
+		if !ok {
+			return nil, fmt.Errorf("unknown %s %q (known: %s)", kind, entry, strings.Join(canonicalNames(known), ", "))
+		}
+		if !seen[canonical] {
+			seen[canonical] = true
+			normalized = append(normalized, canonical)
+		}
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+func canonicalNames(known map[string]string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, canonical := range known {
+		if !seen[canonical] {
+			seen[canonical] = true
+			names = append(names, canonical)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,43 @@
+package recipes
+
+// LowFODMAP is the rule set selected with --low-fodmap. The limits are the
+// approximate largest low-FODMAP servings from published food tables; an
+// ingredient above its limit is flagged rather than excluded, since
+// tolerance differs from person to person.
+var LowFODMAP = RuleSet{
+	Name: "low-FODMAP",
+	Allowed: []string{
+		"lactose-free", "garlic-infused oil", "garlic oil", "green onion", "green onions", "spring onion",
+		"spring onions", "apple cider vinegar", "coconut milk", "almond milk", "rice milk", "gluten-free",
+	},
+	Limits: []Limit{
+		{Ingredient: "garlic", Reason: "fructans"},
+		{Ingredient: "onion", Reason: "fructans"},
+		{Ingredient: "shallot", Reason: "fructans"},
+		{Ingredient: "leek", Reason: "fructans"},
+		{Ingredient: "mushroom", Reason: "mannitol"},
+		{Ingredient: "cauliflower", Reason: "mannitol"},
+		{Ingredient: "apple", Reason: "fructose and sorbitol"},
+		{Ingredient: "pear", Reason: "fructose and sorbitol"},
+		{Ingredient: "watermelon", Reason: "fructose"},
+		{Ingredient: "agave", Reason: "fructose"},
+		{Ingredient: "milk", Reason: "lactose"},
+		{Ingredient: "yogurt", Reason: "lactose"},
+		{Ingredient: "kidney beans", Reason: "galacto-oligosaccharides"},
+		{Ingredient: "black beans", Reason: "galacto-oligosaccharides"},
+		{Ingredient: "cashews", Reason: "galacto-oligosaccharides"},
+		{Ingredient: "pistachios", Reason: "galacto-oligosaccharides"},
+		{Ingredient: "honey", MaxGrams: 7, Reason: "fructose"},
+		{Ingredient: "celery", MaxGrams: 10, Reason: "mannitol"},
+		{Ingredient: "almonds", MaxGrams: 12, Reason: "galacto-oligosaccharides"},
+		{Ingredient: "asparagus", MaxGrams: 12, Reason: "fructans"},
+		{Ingredient: "bread", MaxGrams: 24, Reason: "fructans"},
+		{Ingredient: "avocado", MaxGrams: 30, Reason: "sorbitol"},
+		{Ingredient: "chickpeas", MaxGrams: 42, Reason: "galacto-oligosaccharides"},
+		{Ingredient: "lentils", MaxGrams: 46, Reason: "galacto-oligosaccharides"},
+		{Ingredient: "butternut squash", MaxGrams: 63, Reason: "mannitol"},
+		{Ingredient: "pasta", MaxGrams: 74, Reason: "fructans"},
+		{Ingredient: "broccoli", MaxGrams: 75, Reason: "fructans"},
+		{Ingredient: "sweet potato", MaxGrams: 75, Reason: "mannitol"},
+	},
+}
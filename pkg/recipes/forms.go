@@ -0,0 +1,141 @@
+package recipes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// formDescriptors maps preparation words found in ingredient lines to the
+// form they describe, so "diced" and "cubed" tomatoes are both "chunks".
+var formDescriptors = map[string]string{
+	"raw":      "raw",
+	"fresh":    "raw",
+	"diced":    "chunks",
+	"cubed":    "chunks",
+	"chopped":  "chunks",
+	"chunks":   "chunks",
+	"chunked":  "chunks",
+	"chunky":   "chunks",
+	"wedges":   "chunks",
+	"sliced":   "sliced",
+	"slices":   "sliced",
+	"minced":   "minced",
+	"grated":   "grated",
+	"shredded": "grated",
+	"blended":  "blended",
+	"pureed":   "blended",
+	"puree":    "blended",
+	"purée":    "blended",
+	"paste":    "blended",
+	"sauce":    "blended",
+	"crushed":  "blended",
+	"mashed":   "blended",
+	"whole":    "whole",
+	"cooked":   "cooked",
+	"roasted":  "cooked",
+	"fried":    "cooked",
+	"sauteed":  "cooked",
+	"sautéed":  "cooked",
+	"boiled":   "cooked",
+	"baked":    "cooked",
+	"grilled":  "cooked",
+	"stewed":   "cooked",
+	"canned":   "cooked",
+}
+
+// TagForms returns the forms described by the preparation words of an
+// ingredient line, such as "chunks" for "2 tomatoes, diced", sorted and
+// without duplicates.
+func TagForms(line string) []string {
+	seen := make(map[string]bool)
+	var forms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(line), func(r rune) bool {
+		return r == ' ' || r == ',' || r == '(' || r == ')' || r == ';' || r == '-'
+	}) {
+		form, ok := formDescriptors[word]
+		if ok && !seen[form] {
+			seen[form] = true
+			forms = append(forms, form)
+		}
+	}
+	sort.Strings(forms)
+	return forms
+}
+
+// DislikedForm is an ingredient that is unwanted in some forms only.
+type DislikedForm struct {
+	Ingredient string
+	Forms      []string
+}
+
+// FormPreferences drops recipes using an ingredient in a disliked form.
+// Ingredients read back from the cache only carry the forms their names
+// describe, such as "tomato paste".
+type FormPreferences []DislikedForm
+
+// ParseDislikedForms parses a comma-separated list such as
+// "raw tomato,diced onion": each entry starts with one or more preparation
+// words followed by the ingredient.
+func ParseDislikedForms(list string) (FormPreferences, error) {
+	var preferences FormPreferences
+	for _, entry := range strings.Split(list, ",") {
+		words := strings.Fields(strings.ToLower(entry))
+		if len(words) == 0 {
+			continue
+		}
+		var disliked DislikedForm
+		for len(words) > 0 {
+			form, ok := formDescriptors[words[0]]
+			if !ok {
+				break
+			}
+			disliked.Forms = append(disliked.Forms, form)
+			words = words[1:]
+		}
+		if len(disliked.Forms) == 0 || len(words) == 0 {
+			return nil, fmt.Errorf("%q should be a preparation such as raw or diced followed by an ingredient",
+				strings.TrimSpace(entry))
+		}
+		disliked.Ingredient = strings.Join(words, " ")
+		preferences = append(preferences, disliked)
+	}
+	return preferences, nil
+}
+
+// Apply drops the recipes using a disliked ingredient in a disliked form.
+func (p FormPreferences) Apply(allRecipes []Recipe) []Recipe {
+	if len(p) == 0 {
+		return allRecipes
+	}
+	kept := make([]Recipe, 0, len(allRecipes))
+	for _, recipe := range allRecipes {
+		if !p.dislikes(recipe.UsedIngredients) && !p.dislikes(recipe.MissedIngredients) {
+			kept = append(kept, recipe)
+		}
+	}
+	return kept
+}
+
+func (p FormPreferences) dislikes(ingredients []Ingredient) bool {
+	for _, ingredient := range ingredients {
+		name := strings.ToLower(ingredient.Name)
+		forms := ingredient.Forms
+		if len(forms) == 0 {
+			forms = TagForms(name)
+		}
+		for _, disliked := range p {
+			if !containsIngredient(name, disliked.Ingredient) {
+				continue
+			}
+			for _, form := range disliked.Forms {
+				for _, tagged := range forms {
+					if form == tagged {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
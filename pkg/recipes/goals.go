@@ -0,0 +1,116 @@
+package recipes
+
+import "math"
+
+// GoalTolerance is how far from a daily goal, as a share of it, an amount
+// still counts as on target.
+const GoalTolerance = 0.1
+
+// DailyGoals are how much of each nutrient to eat in a day, zero for no goal.
+type DailyGoals struct {
+	Calories      float64
+	Protein       float64
+	Carbohydrates float64
+	Fat           float64
+}
+
+func (g DailyGoals) IsZero() bool {
+	return g == DailyGoals{}
+}
+
+func (g DailyGoals) goal(nutrient string) float64 {
+	switch nutrient {
+	case "Calories":
+		return g.Calories
+	case "Protein":
+		return g.Protein
+	case "Carbohydrates":
+		return g.Carbohydrates
+	case "Fat":
+		return g.Fat
+	}
+	return 0
+}
+
+// summedNutrients are the nutrients SummarizeNutrition totals, in the units
+// it totals them in.
+var summedNutrients = []struct{ name, unit string }{
+	{"Calories", "kcal"}, {"Protein", "g"}, {"Carbohydrates", "g"}, {"Fat", "g"},
+}
+
+// NutrientTally is how much of a nutrient some meals hold.
+type NutrientTally struct {
+	Nutrient string  `json:"nutrient"`
+	Unit     string  `json:"unit"`
+	Total    float64 `json:"total"`
+	// Daily is Total spread evenly over the days the meals are eaten in.
+	Daily float64 `json:"daily"`
+	// Missing counts the meals without the nutrient, which Total leaves out.
+	Missing int `json:"missing,omitempty"`
+	// Goal is the daily goal, zero when there is none.
+	Goal float64 `json:"goal,omitempty"`
+	// Status compares Daily with Goal: "over", "under", or "on target" within
+	// GoalTolerance of it. It is empty without a goal.
+	Status string `json:"status,omitempty"`
+}
+
+// Percent is Daily as a percentage of Goal, zero without a goal.
+func (t NutrientTally) Percent() float64 {
+	if t.Goal <= 0 {
+		return 0
+	}
+	return t.Daily / t.Goal * 100
+}
+
+// NutritionSummary totals the nutrients of meals eaten over some days.
+type NutritionSummary struct {
+	Meals     int             `json:"meals"`
+	Days      int             `json:"days"`
+	Nutrients []NutrientTally `json:"nutrients"`
+}
+
+// Goals returns the tallies of the nutrients that have a goal.
+func (s NutritionSummary) Goals() []NutrientTally {
+	var goals []NutrientTally
+	for _, tally := range s.Nutrients {
+		if tally.Goal > 0 {
+			goals = append(goals, tally)
+		}
+	}
+	return goals
+}
+
+// SummarizeNutrition totals the calories, protein, carbohydrates and fat of a
+// serving of each meal, spreads them over days, at least one, and compares
+// the daily amounts with the goals.
+func SummarizeNutrition(meals []Recipe, days int, goals DailyGoals) NutritionSummary {
+	days = max(days, 1)
+	summary := NutritionSummary{Meals: len(meals), Days: days}
+	for _, nutrient := range summedNutrients {
+		tally := NutrientTally{Nutrient: nutrient.name, Unit: nutrient.unit, Goal: goals.goal(nutrient.name)}
+		for _, meal := range meals {
+			if _, ok := meal.Nutrients[nutrient.name]; !ok {
+				tally.Missing++
+				continue
+			}
+			tally.Total += nutrientIn(meal, nutrient.name, nutrient.unit)
+		}
+		tally.Daily = tally.Total / float64(days)
+		tally.Status = goalStatus(tally.Daily, tally.Goal)
+		summary.Nutrients = append(summary.Nutrients, tally)
+	}
+	return summary
+}
+
+func goalStatus(amount float64, goal float64) string {
+	switch {
+	case goal <= 0:
+		return ""
+	case math.Abs(amount-goal) <= goal*GoalTolerance:
+		return "on target"
+	case amount > goal:
+		return "over"
+	default:
+		return "under"
+	}
+}
@@ -0,0 +1,65 @@
+package recipes
+
+import "time"
+
+// UnitWeight is what one of a household measure of an ingredient weighs,
+// such as a cup of flour.
+type UnitWeight struct {
+	Unit  string  `json:"unit"`
+	Grams float64 `json:"grams"`
+}
+
+// FoodData is what a food composition database knows of an ingredient.
+type FoodData struct {
+	// Description is the food the database matched the ingredient with.
+	Description string       `json:"description"`
+	UnitWeights []UnitWeight `json:"unitWeights,omitempty"`
+	// Nutrients are per 100 g.
+	Nutrients map[string]NutrientAmount `json:"nutrients,omitempty"`
+}
+
+// IngredientInfo is everything known of an ingredient, shown as its card.
+// Category, Season, PricePerKg and Substitutes come from the taxonomy, see
+// DescribeIngredient; ImageURL and Food are fetched, when FetchedAt is set.
+type IngredientInfo struct {
+	Name        string       `json:"name"`
+	Category    string       `json:"category,omitempty"`
+	Season      []time.Month `json:"season,omitempty"`
+	PricePerKg  float64      `json:"pricePerKg,omitempty"`
+	Substitutes []string     `json:"substitutes,omitempty"`
+	ImageURL    string       `json:"imageURL,omitempty"`
+	Food        *FoodData    `json:"food,omitempty"`
+	FetchedAt   time.Time    `json:"fetchedAt"`
+}
+
+// DescribeIngredient returns what the taxonomy knows of an ingredient, under
+// its normalized name. Nothing is fetched.
+func DescribeIngredient(name string) IngredientInfo {
+	name = NormalizeIngredient(name)
+	kind := kindOf(name)
+	return IngredientInfo{
+		Name:     name,
+		Category: Aisle(name),
+		Season: nearest(kind, func(entry taxonomyEntry) bool {
+			return len(entry.season) > 0
+		}).season,
+		PricePerKg: nearest(kind, func(entry taxonomyEntry) bool {
+			return entry.pricePerKg > 0
+		}).pricePerKg,
+		Substitutes: Alternatives(name),
+	}
+}
+
+// InSeason reports whether the ingredient is in season in the month. An
+// ingredient without a season always is.
+func (i IngredientInfo) InSeason(month time.Month) bool {
+	if len(i.Season) == 0 {
+		return true
+	}
+	for _, inSeason := range i.Season {
+		if inSeason == month {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package recipes
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// CleanIngredientList checks an ingredient list typed by a user before it is
+// searched for. Entries are trimmed and normalized, see NormalizeIngredient,
+// and empty or numeric-only entries are rejected. Duplicates are collapsed,
+// including an ingredient that is part of a more specific one ("chicken" next
+// to "chicken breast"), which keeps the more specific form. Every collapse is
+// described in the notes.
+func CleanIngredientList(ingredientList []string) ([]string, []string, error) {
+	var cleaned []string
+	var notes []string
+
+	for _, ingredient := range ingredientList {
+		ingredient = strings.ToLower(strings.Join(strings.Fields(ingredient), " "))
+		if ingredient == "" {
+			return nil, nil, errors.New("empty ingredient in list, check for a trailing or doubled comma")
+		}
+		if !strings.ContainsFunc(ingredient, unicode.IsLetter) {
+			return nil, nil, fmt.Errorf("%q is not an ingredient", ingredient)
+		}
+		normalized, synonym := normalizeIngredient(ingredient)
+		if synonym {
+			notes = append(notes, fmt.Sprintf("%q is searched as %q", ingredient, normalized))
+		}
+		ingredient = normalized
+
+		duplicate := false
+		for index, kept := range cleaned {
+			switch {
+			case kept == ingredient:
+				notes = append(notes, fmt.Sprintf("%q was given more than once", ingredient))
+				duplicate = true
+			case containsWords(kept, ingredient):
+				notes = append(notes, fmt.Sprintf("%q is covered by %q", ingredient, kept))
+				duplicate = true
+			case containsWords(ingredient, kept):
+				notes = append(notes, fmt.Sprintf("%q is covered by %q", kept, ingredient))
+				cleaned[index] = ingredient
+				duplicate = true
+			}
+			if duplicate {
+				break
+			}
+		}
+		if !duplicate {
+			cleaned = append(cleaned, ingredient)
+		}
+	}
+	return cleaned, notes, nil
+}
+
+// containsWords reports whether the words of part appear, in order and next
+// to each other, in whole.
+func containsWords(whole string, part string) bool {
+	return strings.Contains(" "+whole+" ", " "+part+" ")
+}
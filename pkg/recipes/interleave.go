@@ -0,0 +1,50 @@
+package recipes
+
+import "math/rand/v2"
+
+// The rankings Interleave merges: StrategyA is the configured ranking and
+// StrategyB the one it is compared with.
+const (
+	StrategyA = "a"
+	StrategyB = "b"
+)
+
+// Interleave merges two rankings of the same recipes by team-draft
+// interleaving. The ranking that picked fewer recipes so far, or the one a
+// coin toss decides between two that picked as many, adds its best recipe
+// not in the merged ranking yet. Both rankings have their best recipes near
+// the top, so which of them the user chooses from tells which ranks better.
+// It returns the merged ranking and which strategy picked each recipe, by
+// recipe ID.
+func Interleave(a []Recipe, b []Recipe, rng *rand.Rand) ([]Recipe, map[int]string) {
+	merged := make([]Recipe, 0, max(len(a), len(b)))
+	picked := make(map[int]string, cap(merged))
+	var nextA, nextB, countA, countB int
+	// pick adds the best recipe of a ranking not picked yet, false when it
+	// has none left
+	pick := func(ranking []Recipe, next *int, strategy string) bool {
+		for *next < len(ranking) {
+			recipe := ranking[*next]
+			*next++
+			if _, ok := picked[recipe.ID]; !ok {
+				picked[recipe.ID] = strategy
+				merged = append(merged, recipe)
+				return true
+			}
+		}
+		return false
+	}
+	for {
+		aFirst := countA < countB || countA == countB && rng.IntN(2) == 0
+		if aFirst && pick(a, &nextA, StrategyA) {
+			countA++
+		} else if pick(b, &nextB, StrategyB) {
+			countB++
+		} else if pick(a, &nextA, StrategyA) {
+			// b has no recipes left that a did not pick
+			countA++
+		} else {
+			return merged, picked
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package recipes
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The weights of what MinimizeLeftovers optimizes, in stock items used up: a
+// selection scores the share of each stock item it uses, less missingPenalty
+// for each ingredient it needs to buy, and unmeasuredBonus for each use of a
+// stock item in an amount that cannot be compared with the stock, such as a
+// pinch or an unknown amount.
+const (
+	missingPenalty  = 0.5
+	unmeasuredBonus = 0.1
+	// maxLeftoverPasses bounds the swaps MinimizeLeftovers tries after its
+	// greedy pick.
+	maxLeftoverPasses = 10
+)
+
+// StockItem is an ingredient the user has and how much of it, in the base
+// unit of its dimension: g, ml or a count. Amount is zero when the quantity
+// is not known.
+type StockItem struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount,omitempty"`
+	Unit   string  `json:"unit,omitempty"`
+}
+
+// stockQuantity is a number followed by an optional unit, as in "500g",
+// "2 cups" or "6".
+var stockQuantity = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(.*)$`)
+
+// ParseStock parses the ingredients a user has with their quantities, such as
+// "chicken:500g,rice:2cups,eggs:6". A quantity is optional, and its unit must
+// be one the shopping list knows. Names are normalized as
+// CleanIngredientList does.
+func ParseStock(list string) ([]StockItem, error) {
+	var stock []StockItem
+	for _, entry := range strings.Split(list, ",") {
+		name, quantity, _ := strings.Cut(entry, ":")
+		name = strings.ToLower(strings.Join(strings.Fields(name), " "))
+		if name == "" {
+			return nil, errors.New("empty ingredient in list, check for a trailing or doubled comma")
+		}
+		name, _ = normalizeIngredient(name)
+		if slices.ContainsFunc(stock, func(item StockItem) bool { return item.Name == name }) {
+			return nil, fmt.Errorf("%q was given more than once", name)
+		}
+		item := StockItem{Name: name}
+		if quantity = strings.TrimSpace(quantity); quantity != "" {
+			match := stockQuantity.FindStringSubmatch(strings.ToLower(quantity))
+			if match == nil {
+				return nil, fmt.Errorf("invalid quantity %q for %s, expected an amount such as 500g or 2cups", quantity, name)
+			}
+			amount, _ := strconv.ParseFloat(match[1], 64)
+			var ok bool
+			item.Amount, item.Unit, ok = baseAmount(amount, match[2])
+			if !ok {
+				return nil, fmt.Errorf("unknown unit %q for %s", match[2], name)
+			}
+		}
+		stock = append(stock, item)
+	}
+	return stock, nil
+}
+
+// StockNames returns the names of the stock items, to search with.
+func StockNames(stock []StockItem) []string {
+	names := make([]string, 0, len(stock))
+	for _, item := range stock {
+		names = append(names, item.Name)
+	}
+	return names
+}
+
+// baseAmount converts an amount to the base unit of its dimension, false for
+// units the shopping list does not know.
+func baseAmount(amount float64, unit string) (float64, string, bool) {
+	conversion, ok := unitConversions[strings.ToLower(strings.TrimSpace(unit))]
+	if !ok {
+		return 0, "", false
+	}
+	return amount * conversion.factor, conversion.base, true
+}
+
+// comparableUnits reports whether amounts in two base units can be
+// subtracted, counting a milliliter as a gram as the dietary limits do.
+func comparableUnits(a string, b string) bool {
+	weightOrVolume := func(unit string) bool { return unit == "g" || unit == "ml" }
+	return a == b || weightOrVolume(a) && weightOrVolume(b)
+}
+
+// MinimizeLeftovers chooses count of the candidates that together use as much
+// of the stock as they can while needing the fewest ingredients to be bought,
+// see missingPenalty. It picks the best recipe to add one at a time, then
+// swaps chosen recipes for others while that improves the choice. The chosen
+// recipes are returned in the candidates' order, with what is left of the
+// stock items of known quantity after making them. Fewer than count different
+// candidates are all chosen.
+func MinimizeLeftovers(candidates []Recipe, stock []StockItem, count int) ([]Recipe, []StockItem) {
+	var distinct []Recipe
+	for _, recipe := range candidates {
+		if !slices.ContainsFunc(distinct, func(kept Recipe) bool { return kept.ID == recipe.ID }) {
+			distinct = append(distinct, recipe)
+		}
+	}
+
+	var chosen []int
+	for len(chosen) < min(count, len(distinct)) {
+		best, bestScore := -1, 0.0
+		for i := range distinct {
+			if slices.Contains(chosen, i) {
+				continue
+			}
+			score, _ := stockUsage(distinct, append(chosen, i), stock)
+			if best == -1 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		chosen = append(chosen, best)
+	}
+
+	current, _ := stockUsage(distinct, chosen, stock)
+	for pass := 0; pass < maxLeftoverPasses; pass++ {
+		improved := false
+		for position := range chosen {
+			for i := range distinct {
+				if slices.Contains(chosen, i) {
+					continue
+				}
+				swapped := slices.Clone(chosen)
+				swapped[position] = i
+				if score, _ := stockUsage(distinct, swapped, stock); score > current+1e-9 {
+					chosen, current, improved = swapped, score, true
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+
+	slices.Sort(chosen)
+	selected := make([]Recipe, 0, len(chosen))
+	for _, i := range chosen {
+		selected = append(selected, distinct[i])
+	}
+	_, left := stockUsage(distinct, chosen, stock)
+	var leftovers []StockItem
+	for i, item := range left {
+		if stock[i].Amount > 0 {
+			leftovers = append(leftovers, item)
+		}
+	}
+	return selected, leftovers
+}
+
+// stockUsage scores making the chosen recipes, see MinimizeLeftovers, and
+// returns what is left of the stock after them.
+func stockUsage(candidates []Recipe, chosen []int, stock []StockItem) (float64, []StockItem) {
+	left := slices.Clone(stock)
+	score := 0.0
+	missing := make(map[string]bool)
+	for _, i := range chosen {
+		recipe := candidates[i]
+		for _, ingredient := range append(slices.Clip(recipe.UsedIngredients), recipe.MissedIngredients...) {
+			name := strings.ToLower(ingredient.Name)
+			item := slices.IndexFunc(stock, func(item StockItem) bool { return HasIngredient(name, []string{item.Name}) })
+			if item == -1 {
+				if slices.ContainsFunc(recipe.MissedIngredients, func(missed Ingredient) bool {
+					return missed.Name == ingredient.Name
+				}) {
+					missing[name] = true
+				}
+				continue
+			}
+			amount, unit, ok := baseAmount(ingredient.Amount, ingredient.Unit)
+			if stock[item].Amount == 0 || amount == 0 || !ok || !comparableUnits(unit, stock[item].Unit) {
+				score += unmeasuredBonus
+				continue
+			}
+			used := min(left[item].Amount, amount)
+			left[item].Amount -= used
+			score += used / stock[item].Amount
+		}
+	}
+	return score - missingPenalty*float64(len(missing)), left
+}
@@ -0,0 +1,218 @@
+package recipes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ingredientSynonyms maps other names of an ingredient, and varieties that
+// recipes use interchangeably, to the name searched for. Keys and values are
+// singular.
+var ingredientSynonyms = map[string]string{
+	"aubergine":           "eggplant",
+	"courgette":           "zucchini",
+	"capsicum":            "bell pepper",
+	"sweet pepper":        "bell pepper",
+	"scallion":            "green onion",
+	"spring onion":        "green onion",
+	"coriander leaf":      "cilantro",
+	"fresh coriander":     "cilantro",
+	"garbanzo":            "chickpea",
+	"garbanzo bean":       "chickpea",
+	"prawn":               "shrimp",
+	"rocket":              "arugula",
+	"minced beef":         "ground beef",
+	"beef mince":          "ground beef",
+	"minced pork":         "ground pork",
+	"icing sugar":         "powdered sugar",
+	"confectioners sugar": "powdered sugar",
+	"caster sugar":        "sugar",
+	"granulated sugar":    "sugar",
+	"cherry tomato":       "tomato",
+	"plum tomato":         "tomato",
+	"roma tomato":         "tomato",
+	"all-purpose flour":   "flour",
+	"plain flour":         "flour",
+	"double cream":        "heavy cream",
+	"whipping cream":      "heavy cream",
+	"maize":               "corn",
+	"sweetcorn":           "corn",
+	"swede":               "rutabaga",
+	"beetroot":            "beet",
+	"mangetout":           "snow pea",
+}
+
+// commonIngredients, together with the names in ingredientSynonyms, are the
+// names CorrectSpelling corrects towards.
+var commonIngredients = []string{
+	"apple", "avocado", "bacon", "banana", "basil", "bean", "beef", "bread", "broccoli", "butter",
+	"cabbage", "carrot", "cauliflower", "celery", "cheddar", "cheese", "chicken", "chicken breast",
+	"chili", "chocolate", "cinnamon", "cream", "cucumber", "cumin", "egg", "feta", "flour", "garlic",
+	"ginger", "ham", "honey", "kale", "leek", "lemon", "lentil", "lettuce", "lime", "mayonnaise",
+	"milk", "mozzarella", "mushroom", "mustard", "noodle", "oats", "olive", "olive oil", "onion",
+	"orange", "oregano", "paprika", "parmesan", "parsley", "pasta", "pea", "peanut butter", "pear",
+	"pepper", "pineapple", "pork", "potato", "pumpkin", "quinoa", "raspberry", "rice", "rosemary",
+	"salmon", "salt", "sausage", "spaghetti", "spinach", "strawberry", "sugar", "sweet potato",
+	"thyme", "tofu", "tomato", "tortilla", "tuna", "turkey", "vanilla", "vinegar", "yogurt",
+}
+
+// uncountables end like plurals but are not, or keep the plural as their
+// name.
+var uncountables = map[string]bool{
+	"asparagus": true, "brussels": true, "couscous": true, "grits": true, "hummus": true,
+	"molasses": true, "swiss": true, "oats": true, "greens": true, "bitters": true,
+	"series": true, "species": true,
+}
+
+var irregularPlurals = map[string]string{
+	"leaves":   "leaf",
+	"loaves":   "loaf",
+	"halves":   "half",
+	"cookies":  "cookie",
+	"pies":     "pie",
+	"brownies": "brownie",
+	"calories": "calorie",
+	"geese":    "goose",
+}
+
+// NormalizeIngredient returns the name an ingredient is searched and cached
+// under: lowercased, every word singular and synonyms replaced, so
+// "Tomatoes", "tomato" and "cherry tomatoes" are the same ingredient.
+func NormalizeIngredient(name string) string {
+	normalized, _ := normalizeIngredient(name)
+	return normalized
+}
+
+// normalizeIngredient also reports whether a synonym was replaced, which is
+// worth telling the user about unlike a plural.
+func normalizeIngredient(name string) (string, bool) {
+	words := strings.Fields(strings.ToLower(name))
+	for i, word := range words {
+		words[i] = singular(word)
+	}
+	normalized := strings.Join(words, " ")
+	if synonym, ok := ingredientSynonyms[normalized]; ok {
+		return synonym, true
+	}
+	return normalized, false
+}
+
+func singular(word string) string {
+	if uncountables[word] {
+		return word
+	}
+	if irregular, ok := irregularPlurals[word]; ok {
+		return irregular
+	}
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case len(word) > 4 && strings.HasSuffix(word, "oes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"),
+		strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"):
+		return strings.TrimSuffix(word, "es")
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") &&
+		!strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		return strings.TrimSuffix(word, "s")
+	default:
+		return word
+	}
+}
+
+// CorrectSpelling replaces ingredients that are not known but are one typo
+// (two for names of eight letters or more, none under five letters) away
+// from exactly one known ingredient, such as "brocolli" for "broccoli". The
+// ingredients must already have gone through CleanIngredientList; the
+// corrected list is cleaned again, since a correction can produce a
+// duplicate. Every correction is described in the notes.
+func CorrectSpelling(ingredientList []string) ([]string, []string) {
+	known := knownIngredients()
+	var notes []string
+	corrected := make([]string, 0, len(ingredientList))
+	for _, ingredient := range ingredientList {
+		match, ok := closestIngredient(ingredient, known)
+		if ok && match != ingredient {
+			notes = append(notes, fmt.Sprintf("%q was read as %q", ingredient, match))
+			ingredient = match
+		}
+		corrected = append(corrected, ingredient)
+	}
+	cleaned, cleanNotes, err := CleanIngredientList(corrected)
+	if err != nil {
+		// Cannot happen for a list CleanIngredientList accepted before
+		return ingredientList, nil
+	}
+	return cleaned, append(notes, cleanNotes...)
+}
+
+func knownIngredients() []string {
+	seen := make(map[string]bool)
+	for _, name := range commonIngredients {
+		seen[name] = true
+	}
+	for name, synonym := range ingredientSynonyms {
+		seen[name] = true
+		seen[synonym] = true
+	}
+	known := make([]string, 0, len(seen))
+	for name := range seen {
+		known = append(known, name)
+	}
+	sort.Strings(known)
+	return known
+}
+
+// closestIngredient returns the ingredient itself when it is known, and
+// otherwise the single known name close enough to it.
+func closestIngredient(ingredient string, known []string) (string, bool) {
+	if len(ingredient) < 5 {
+		return "", false
+	}
+	maxDistance := 1
+	if len(ingredient) >= 8 {
+		maxDistance = 2
+	}
+
+	best, bestDistance, ties := "", maxDistance+1, 0
+	for _, name := range known {
+		if name == ingredient {
+			return name, true
+		}
+		distance := levenshtein(ingredient, name)
+		switch {
+		case distance < bestDistance:
+			best, bestDistance, ties = name, distance, 0
+		case distance == bestDistance:
+			ties++
+		}
+	}
+	if best == "" || ties > 0 {
+		return "", false
+	}
+	return best, true
+}
+
+// levenshtein is the number of single-letter insertions, deletions and
+// substitutions turning a into b.
+func levenshtein(a string, b string) int {
+	first, second := []rune(a), []rune(b)
+	previous := make([]int, len(second)+1)
+	current := make([]int, len(second)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(first); i++ {
+		current[0] = i
+		for j := 1; j <= len(second); j++ {
+			cost := 1
+			if first[i-1] == second[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(second)]
+}
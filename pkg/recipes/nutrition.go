@@ -0,0 +1,105 @@
+package recipes
+
+import (
+	"fmt"
+	"sort"
+)
+
+// NutritionTargets limits the nutrients of a serving. Zero fields are unset.
+type NutritionTargets struct {
+	MaxCalories float64
+	MinProtein  float64
+	MaxCarbs    float64
+}
+
+func (t NutritionTargets) IsZero() bool {
+	return t == NutritionTargets{}
+}
+
+// Apply drops the recipes outside the targets. Sources that cannot filter on
+// nutrients themselves rely on it, so a recipe missing a nutrient that has a
+// target is dropped too.
+func (t NutritionTargets) Apply(allRecipes []Recipe) []Recipe {
+	if t.IsZero() {
+		return allRecipes
+	}
+	kept := make([]Recipe, 0, len(allRecipes))
+	for _, recipe := range allRecipes {
+		if t.MaxCalories > 0 && !nutrientAtMost(recipe, "Calories", t.MaxCalories) {
+			continue
+		}
+		if t.MaxCarbs > 0 && !nutrientAtMost(recipe, "Carbohydrates", t.MaxCarbs) {
+			continue
+		}
+		if t.MinProtein > 0 {
+			protein, ok := recipe.Nutrients["Protein"]
+			if !ok || protein.Amount < t.MinProtein {
+				continue
+			}
+		}
+		kept = append(kept, recipe)
+	}
+	return kept
+}
+
+func nutrientAtMost(recipe Recipe, name string, limit float64) bool {
+	nutrient, ok := recipe.Nutrients[name]
+	return ok && nutrient.Amount <= limit
+}
+
+// recipeOrders are the orders accepted by SortRecipes.
+var recipeOrders = map[string]func(a Recipe, b Recipe) bool{
+	"missing": func(a Recipe, b Recipe) bool {
+		return len(a.MissedIngredients) < len(b.MissedIngredients)
+	},
+	"calories": func(a Recipe, b Recipe) bool {
+		return a.Nutrients["Calories"].Amount < b.Nutrients["Calories"].Amount
+	},
+	"protein": func(a Recipe, b Recipe) bool {
+		return a.Nutrients["Protein"].Amount > b.Nutrients["Protein"].Amount
+	},
+	"popularity": func(a Recipe, b Recipe) bool {
+		return a.Likes > b.Likes
+	},
+}
+
+// CheckSortOrder returns an error unless SortRecipes accepts order.
+func CheckSortOrder(order string) error {
+	if _, ok := recipeOrders[order]; !ok && order != "score" {
+		return fmt.Errorf("unknown sort order %q, expected score, missing, calories, protein or popularity", order)
+	}
+	return nil
+}
+
+// SortByID orders recipes by ID, then by source: the order SortRecipes
+// leaves equally ranked recipes in when it is called first, so that the same
+// recipes are always output in the same order, wherever they came from.
+func SortByID(allRecipes []Recipe) {
+	sort.SliceStable(allRecipes, func(i, j int) bool {
+		if allRecipes[i].ID != allRecipes[j].ID {
+			return allRecipes[i].ID < allRecipes[j].ID
+		}
+		return allRecipes[i].Source < allRecipes[j].Source
+	})
+}
+
+// SortRecipes orders recipes by their score with the weights, highest first
+// ("score"), fewest missing ingredients ("missing"), fewest calories
+// ("calories"), most protein ("protein") or most likes ("popularity"). The
+// weights only matter to "score". Equal recipes keep their order, and so do
+// all of them for an order CheckSortOrder rejects.
+func SortRecipes(allRecipes []Recipe, order string, weights RankWeights) {
+	if order == "score" {
+		sort.SliceStable(allRecipes, func(i, j int) bool {
+			return weights.Score(allRecipes[i]) > weights.Score(allRecipes[j])
+		})
+		return
+	}
+	less, ok := recipeOrders[order]
+	if !ok {
+		return
+	}
+	sort.SliceStable(allRecipes, func(i, j int) bool {
+		return less(allRecipes[i], allRecipes[j])
+	})
+}
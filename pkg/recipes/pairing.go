@@ -0,0 +1,52 @@
+package recipes
+
+import (
+	"slices"
+	"strings"
+)
+
+// Pairing is a drink suggested with a recipe: the wines going with it and a
+// note on why. A recipe that was looked up but has none has no Wines.
+type Pairing struct {
+	Wines []string `json:"wines,omitempty"`
+	Note  string   `json:"note,omitempty"`
+	// Food is what the pairing was found for, such as the recipe's main
+	// ingredient.
+	Food string `json:"food,omitempty"`
+}
+
+// String is the wines and the first sentence of the note, on one line.
+func (p Pairing) String() string {
+	note, _, _ := strings.Cut(strings.Join(strings.Fields(p.Note), " "), ". ")
+	if note == "" {
+		return strings.Join(p.Wines, ", ")
+	}
+	return strings.Join(p.Wines, ", ") + " - " + strings.TrimSuffix(note, ".") + "."
+}
+
+// PairingFoods returns up to limit foods of the recipe to look up a drink
+// pairing for, best first: the kinds of its meat and fish, then those of its
+// other ingredients. Spices and baking ingredients decide no pairing and are
+// left out, as are the ingredients the taxonomy does not know.
+func PairingFoods(recipe Recipe, limit int) []string {
+	var main, other []string
+	for _, ingredient := range append(slices.Clip(recipe.UsedIngredients), recipe.MissedIngredients...) {
+		name := NormalizeIngredient(ingredient.Name)
+		kind := kindOf(name)
+		if kind == "" || slices.Contains(main, kind) || slices.Contains(other, kind) {
+			continue
+		}
+		switch Aisle(name) {
+		case "meat and fish":
+			main = append(main, kind)
+		case "spices", "baking":
+		default:
+			other = append(other, kind)
+		}
+	}
+	foods := append(main, other...)
+	if len(foods) > limit {
+		foods = foods[:limit]
+	}
+	return foods
+}
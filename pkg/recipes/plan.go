@@ -0,0 +1,492 @@
+package recipes
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Plan is a meal plan, one entry per day.
+type Plan struct {
+	Days []PlanDay `json:"days"`
+	// Cost is the estimated cost of a serving of every meal, leaving out the
+	// meals whose price is unknown, which Unpriced counts.
+	Cost     Money `json:"cost"`
+	Unpriced int   `json:"unpriced,omitempty"`
+	// Summary is filled by SummarizePlan.
+	Summary PlanSummary `json:"summary"`
+	// Leftovers is what is left of the stock the meals were chosen for by
+	// MinimizeLeftovers, nil for other plans.
+	Leftovers []StockItem `json:"leftovers,omitempty"`
+	// Window is when the meals are eaten, nil for the usual 8:00 to 20:00.
+	Window *EatingWindow `json:"window,omitempty"`
+}
+
+// EatingWindow is the time of day meals are eaten in, from Start to End in
+// minutes after midnight, such as 12:00 to 20:00 for 16:8 fasting.
+type EatingWindow struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// MealMinute returns when a meal of a day with meals meals is eaten, in
+// minutes after midnight. The meals are spread a whole number of hours apart
+// from the start of the window to an hour before its end, and a single meal a
+// day is the last, dinner.
+func (p Plan) MealMinute(meal int, meals int) int {
+	window := EatingWindow{Start: 8 * 60, End: 20 * 60}
+	if p.Window != nil {
+		window = *p.Window
+	}
+	last := window.End - 60
+	if meals <= 1 {
+		return last
+	}
+	return window.Start + meal*((last-window.Start)/60)/(meals-1)*60
+}
+
+// Macros are amounts of the tracked nutrients: calories in kcal, the others
+// in grams.
+type Macros struct {
+	Calories      float64 `json:"calories"`
+	Protein       float64 `json:"protein"`
+	Carbohydrates float64 `json:"carbohydrates"`
+}
+
+// PlanSummary rolls up the nutrients of a plan and compares them with the
+// nutrition targets it was searched with.
+type PlanSummary struct {
+	Total        Macros `json:"total"`
+	DailyAverage Macros `json:"dailyAverage"`
+	// PantryItemsUsed counts the pantry items used by at least one meal.
+	PantryItemsUsed int `json:"pantryItemsUsed"`
+	// Targets compare the average meal with each target that was set.
+	Targets []TargetVariance `json:"targets,omitempty"`
+	// Goals compare the average day with each daily goal that was set, see
+	// SummarizeNutrition.
+	Goals []NutrientTally `json:"goals,omitempty"`
+}
+
+// TargetVariance is how far the average meal of a plan is from a nutrition
+// target.
+type TargetVariance struct {
+	Nutrient string `json:"nutrient"`
+	// Limit is "max" or "min".
+	Limit   string  `json:"limit"`
+	Target  float64 `json:"target"`
+	Average float64 `json:"average"`
+	// Variance is Average minus Target, negative when below it.
+	Variance float64 `json:"variance"`
+}
+
+// PlanDay holds the meals of one day and their nutrient and cost totals.
+type PlanDay struct {
+	Meals    []Recipe `json:"meals"`
+	Calories float64  `json:"calories"`
+	Protein  float64  `json:"protein"`
+	Cost     Money    `json:"cost"`
+	// Theme is the name of the day's theme, whose meal is the last one, or
+	// empty on days without one.
+	Theme string `json:"theme,omitempty"`
+}
+
+// PlanTheme is a recurring theme night, such as Taco Tuesday: the last meal
+// on its weekday is of its cuisine and has its dish in the title, whichever
+// of the two is set.
+type PlanTheme struct {
+	Name    string
+	Weekday time.Weekday
+	Cuisine string
+	Dish    string
+}
+
+// Matches reports whether the recipe fits the theme.
+func (t PlanTheme) Matches(recipe Recipe) bool {
+	if t.Cuisine != "" && !slices.Contains(recipe.Cuisines, strings.ToLower(t.Cuisine)) {
+		return false
+	}
+	return strings.Contains(strings.ToLower(recipe.Title), strings.ToLower(t.Dish))
+}
+
+// PlanConstraints shape a plan beyond its number of days and meals, see
+// ConstrainedPlan. The zero value constrains nothing.
+type PlanConstraints struct {
+	// DayCalories are the calories to eat on each day of the plan, such as a
+	// daily goal raised by the day's expected activity, or nil for days that
+	// are all alike.
+	DayCalories []float64
+	// Window is when the meals are eaten, see Plan.Window. It must last an
+	// hour at least, and not span midnight.
+	Window *EatingWindow
+	// CuisineLimits are the most meals of a cuisine each week of the plan
+	// may have, by lowercase cuisine such as {"italian": 2}. Weeks are seven
+	// days from the first day of the plan, the last one possibly shorter.
+	CuisineLimits map[string]int
+	// NewCuisines is how many meals a week should be of a cuisine not among
+	// KnownCuisines, such as the cuisines cooked before, as far as the
+	// candidates have such meals.
+	NewCuisines   int
+	KnownCuisines []string
+	// Themes are the theme nights, at most one a weekday, placed by Start,
+	// the weekday of the first day of the plan.
+	Themes []PlanTheme
+	Start  time.Weekday
+	// Stretch allows each week one meal calling for techniques not among
+	// KnownTechniques, see Techniques, and plans one when the candidates
+	// have such a meal. The other meals use the known techniques only.
+	Stretch         bool
+	KnownTechniques []string
+}
+
+// BuildPlan spreads candidates over days with mealsPerDay meals each. Every
+// recipe is used at most once, and the earlier candidates, which need the
+// fewest missing ingredients, are preferred. Meals are assigned heaviest first
+// to the day with the fewest calories so far, which keeps the daily calorie
+// and protein totals close to each other.
+func BuildPlan(candidates []Recipe, days int, mealsPerDay int) (Plan, error) {
+	return ConstrainedPlan(candidates, days, mealsPerDay, PlanConstraints{})
+}
+
+// ConstrainedPlan builds a plan as BuildPlan does, within the constraints.
+// With DayCalories, meals are assigned to the day with the most calories left
+// instead, so the days with more to eat get the heavier meals. With
+// NewCuisines, the first candidates of a new cuisine are chosen before the
+// others and spread over the weeks, and meals of a cuisine in CuisineLimits
+// go only to weeks that are below its limit. When the weeks have no room
+// left for such a meal, as a short last week may not, an error is returned.
+// The meal of each theme night is the first candidate that matches the theme,
+// and it is an error when none does. With Stretch, the first candidates with
+// new techniques are chosen before the others too, one a week.
+func ConstrainedPlan(candidates []Recipe, days int, mealsPerDay int, constraints PlanConstraints) (Plan, error) {
+	if days <= 0 || mealsPerDay <= 0 {
+		return Plan{}, errors.New("a plan needs at least one day and one meal per day")
+	}
+	if constraints.DayCalories != nil && len(constraints.DayCalories) != days {
+		return Plan{}, fmt.Errorf("got calories for %d days, the plan has %d", len(constraints.DayCalories), days)
+	}
+	if window := constraints.Window; window != nil && (window.Start < 0 || window.End > 24*60 ||
+		window.End-window.Start < 60) {
+		return Plan{}, errors.New("an eating window must last an hour at least, within a day")
+	}
+	if constraints.NewCuisines < 0 {
+		return Plan{}, errors.New("the number of new cuisines cannot be negative")
+	}
+	for i, theme := range constraints.Themes {
+		if theme.Cuisine == "" && theme.Dish == "" {
+			return Plan{}, fmt.Errorf("the %s theme needs a cuisine or a dish", theme.Name)
+		}
+		for _, other := range constraints.Themes[:i] {
+			if other.Weekday == theme.Weekday {
+				return Plan{}, fmt.Errorf("the %s and %s themes are both on %s", other.Name, theme.Name, theme.Weekday)
+			}
+		}
+	}
+	for cuisine, limit := range constraints.CuisineLimits {
+		if limit < 0 {
+			return Plan{}, fmt.Errorf("the limit of %s meals cannot be negative", cuisine)
+		}
+	}
+
+	// A week of the plan takes as many meals of a cuisine, and as many new
+	// ones, as its limit allows, but no more than it has meals
+	weeks := (days + 6) / 7
+	weekSlots := func(week int) int {
+		return min(7, days-7*week) * mealsPerDay
+	}
+	planned := func(perWeek int) int {
+		total := 0
+		for week := range weeks {
+			total += min(perWeek, weekSlots(week))
+		}
+		return total
+	}
+	stretch := make(map[int]bool)
+	if constraints.Stretch {
+		for _, recipe := range candidates {
+			stretch[recipe.ID] = newTechniques(recipe, constraints.KnownTechniques)
+		}
+	}
+	limited := func(recipe Recipe) bool {
+		for _, cuisine := range recipe.Cuisines {
+			if _, ok := constraints.CuisineLimits[cuisine]; ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	slots := days * mealsPerDay
+	var meals []Recipe
+	seen := make(map[int]bool)
+	reserved := make(map[int]bool)
+	counts := make(map[string]int)
+	stretches := 0
+	fits := func(recipe Recipe) bool {
+		if stretch[recipe.ID] && stretches == planned(1) {
+			return false
+		}
+		for _, cuisine := range recipe.Cuisines {
+			if limit, ok := constraints.CuisineLimits[cuisine]; ok && counts[cuisine] == planned(limit) {
+				return false
+			}
+		}
+		return true
+	}
+	take := func(recipe Recipe) {
+		seen[recipe.ID] = true
+		meals = append(meals, recipe)
+		for _, cuisine := range recipe.Cuisines {
+			counts[cuisine]++
+		}
+		if stretch[recipe.ID] {
+			stretches++
+		}
+	}
+	// Each theme night takes the first candidate that fits its theme
+	themed := make(map[int]int)
+	themes := make(map[int]string)
+	for index := range days {
+		weekday := (constraints.Start + time.Weekday(index)) % 7
+		for _, theme := range constraints.Themes {
+			if theme.Weekday != weekday {
+				continue
+			}
+			themes[index] = theme.Name
+			found := false
+			for _, recipe := range candidates {
+				if !seen[recipe.ID] && theme.Matches(recipe) && fits(recipe) {
+					take(recipe)
+					themed[recipe.ID] = index
+					found = true
+					break
+				}
+			}
+			if !found {
+				return Plan{}, fmt.Errorf("found no recipe for the %s theme on day %d", theme.Name, index+1)
+			}
+		}
+	}
+	if constraints.NewCuisines > 0 {
+		wanted := planned(constraints.NewCuisines)
+		for _, recipe := range candidates {
+			if len(reserved) == wanted {
+				break
+			}
+			if !seen[recipe.ID] && newCuisine(recipe, constraints.KnownCuisines) && fits(recipe) {
+				take(recipe)
+				reserved[recipe.ID] = true
+			}
+		}
+	}
+	if constraints.Stretch {
+		for _, recipe := range candidates {
+			if stretches == planned(1) {
+				break
+			}
+			if !seen[recipe.ID] && stretch[recipe.ID] && fits(recipe) {
+				take(recipe)
+			}
+		}
+	}
+	for _, recipe := range candidates {
+		if len(meals) == slots {
+			break
+		}
+		if !seen[recipe.ID] && fits(recipe) {
+			take(recipe)
+		}
+	}
+	if len(meals) < slots {
+		return Plan{}, fmt.Errorf("found %d different recipes, a %d-day plan with %d meals per day needs %d",
+			len(meals), days, mealsPerDay, slots)
+	}
+
+	// Theme nights are placed first, on their days, then the limited
+	// cuisines and new techniques, while the weeks have room for them, then
+	// the new cuisines, so they can still be spread over the weeks
+	group := func(recipe Recipe) int {
+		_, isThemed := themed[recipe.ID]
+		switch {
+		case isThemed:
+			return 0
+		case limited(recipe) || stretch[recipe.ID]:
+			return 1
+		case reserved[recipe.ID]:
+			return 2
+		}
+		return 3
+	}
+	sort.SliceStable(meals, func(i, j int) bool {
+		if group(meals[i]) != group(meals[j]) {
+			return group(meals[i]) < group(meals[j])
+		}
+		if calories(meals[i]) != calories(meals[j]) {
+			return calories(meals[i]) > calories(meals[j])
+		}
+		return protein(meals[i]) > protein(meals[j])
+	})
+
+	plan := Plan{Days: make([]PlanDay, days), Window: constraints.Window}
+	// Without DayCalories every day has none to eat, so the day with the
+	// most left is the one with the fewest calories so far
+	left := func(index int) float64 {
+		if constraints.DayCalories == nil {
+			return -plan.Days[index].Calories
+		}
+		return constraints.DayCalories[index] - plan.Days[index].Calories
+	}
+	weekCuisines := make([]map[string]int, weeks)
+	for week := range weekCuisines {
+		weekCuisines[week] = make(map[string]int)
+	}
+	weekNew := make([]int, weeks)
+	weekStretches := make([]int, weeks)
+	allowed := func(index int, meal Recipe) bool {
+		if len(plan.Days[index].Meals) == mealsPerDay || stretch[meal.ID] && weekStretches[index/7] == 1 {
+			return false
+		}
+		for _, cuisine := range meal.Cuisines {
+			if limit, ok := constraints.CuisineLimits[cuisine]; ok && weekCuisines[index/7][cuisine] >= limit {
+				return false
+			}
+		}
+		return true
+	}
+	short := func(index int) bool {
+		return weekNew[index/7] < min(constraints.NewCuisines, weekSlots(index/7))
+	}
+	for _, meal := range meals {
+		lightest := -1
+		for index, day := range plan.Days {
+			if !allowed(index, meal) {
+				continue
+			}
+			if themeDay, ok := themed[meal.ID]; ok && index != themeDay {
+				continue
+			}
+			if reserved[meal.ID] && lightest != -1 && short(index) != short(lightest) {
+				if short(index) {
+					lightest = index
+				}
+				continue
+			}
+			if lightest == -1 || left(index) > left(lightest) ||
+				left(index) == left(lightest) && day.Protein < plan.Days[lightest].Protein {
+				lightest = index
+			}
+		}
+		if lightest == -1 {
+			return Plan{}, fmt.Errorf("%s cannot be placed within the weekly limits", meal.Title)
+		}
+		if stretch[meal.ID] {
+			weekStretches[lightest/7]++
+		}
+		for _, cuisine := range meal.Cuisines {
+			weekCuisines[lightest/7][cuisine]++
+		}
+		if reserved[meal.ID] {
+			weekNew[lightest/7]++
+		}
+		day := &plan.Days[lightest]
+		day.Meals = append(day.Meals, meal)
+		day.Calories += calories(meal)
+		day.Protein += protein(meal)
+		day.Cost += meal.PricePerServing
+		plan.Cost += meal.PricePerServing
+		if meal.PricePerServing == 0 {
+			plan.Unpriced++
+		}
+	}
+	// The theme's meal, the first placed on its day, is the day's last
+	for index, theme := range themes {
+		day := &plan.Days[index]
+		day.Theme = theme
+		day.Meals = append(day.Meals[1:], day.Meals[0])
+	}
+	return plan, nil
+}
+
+// SummarizePlan rolls up the plan's nutrients, compares its average meal with
+// the targets, and counts the pantry items its meals use.
+func SummarizePlan(plan Plan, targets NutritionTargets, pantry []string) PlanSummary {
+	var summary PlanSummary
+	meals := 0
+	used := make(map[string]bool)
+	for _, day := range plan.Days {
+		for _, meal := range day.Meals {
+			meals++
+			summary.Total.Calories += calories(meal)
+			summary.Total.Protein += protein(meal)
+			summary.Total.Carbohydrates += nutrientIn(meal, "Carbohydrates", "g")
+			for _, item := range pantry {
+				if usesIngredient(meal, item) {
+					used[item] = true
+				}
+			}
+		}
+	}
+	summary.PantryItemsUsed = len(used)
+	if meals == 0 {
+		return summary
+	}
+
+	days := float64(len(plan.Days))
+	summary.DailyAverage = Macros{
+		Calories:      summary.Total.Calories / days,
+		Protein:       summary.Total.Protein / days,
+		Carbohydrates: summary.Total.Carbohydrates / days,
+	}
+	variance := func(nutrient string, limit string, target float64, total float64) {
+		if target > 0 {
+			average := total / float64(meals)
+			summary.Targets = append(summary.Targets, TargetVariance{
+				Nutrient: nutrient,
+				Limit:    limit,
+				Target:   target,
+				Average:  average,
+				Variance: average - target,
+			})
+		}
+	}
+	variance("Calories", "max", targets.MaxCalories, summary.Total.Calories)
+	variance("Protein", "min", targets.MinProtein, summary.Total.Protein)
+	variance("Carbohydrates", "max", targets.MaxCarbs, summary.Total.Carbohydrates)
+	return summary
+}
+
+// usesIngredient reports whether any of the recipe's ingredients is, or
+// names, the item, so "eggs" counts as a use of "egg".
+func usesIngredient(recipe Recipe, item string) bool {
+	item = strings.ToLower(item)
+	for _, ingredient := range recipe.UsedIngredients {
+		if strings.Contains(strings.ToLower(ingredient.Name), item) {
+			return true
+		}
+	}
+	return false
+}
+
+func calories(recipe Recipe) float64 {
+	return nutrientIn(recipe, "Calories", "kcal")
+}
+
+func protein(recipe Recipe) float64 {
+	return nutrientIn(recipe, "Protein", "g")
+}
+
+// nutrientIn returns the amount of a nutrient of the recipe in the unit,
+// zero when the recipe has none or gives it in a unit of another kind.
+func nutrientIn(recipe Recipe, name string, unit string) float64 {
+	nutrient, ok := recipe.Nutrients[name]
+	if !ok || nutrient.Unit == "" {
+		return nutrient.Amount
+	}
+	converted, ok := nutrient.In(unit)
+	if !ok {
+		return 0
+	}
+	return converted.Amount
+}
@@ -0,0 +1,24 @@
+package recipes
+
+// Taste is a recipe's taste profile. Sweetness is scored from 0 to 100
+// against the recipe's strongest taste, and Spiciness in Scoville heat units.
+type Taste struct {
+	Sweetness float64 `json:"sweetness"`
+	Spiciness float64 `json:"spiciness"`
+}
+
+// MinHealthScore drops the recipes whose health score is below minScore.
+// Recipes without a score are kept, since nothing says they are unhealthy.
+// Zero means no limit.
+func MinHealthScore(allRecipes []Recipe, minScore float64) []Recipe {
+	if minScore <= 0 {
+		return allRecipes
+	}
+	kept := make([]Recipe, 0, len(allRecipes))
+	for _, recipe := range allRecipes {
+		if recipe.HealthScore == 0 || recipe.HealthScore >= minScore {
+			kept = append(kept, recipe)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,53 @@
+package recipes
+
+// Pregnancy is the rule set selected with --pregnancy-safe. It follows common
+// food safety advice for pregnancy. Nothing is excluded: most of the flagged
+// ingredients are safe once cooked through or bought pasteurized, which only
+// the cook can tell, so each recipe gets a warning instead.
+var Pregnancy = RuleSet{
+	Name: "pregnancy",
+	Allowed: []string{
+		"pasteurized", "cooked ham", "canned tuna", "light tuna", "egg noodles", "eggplant", "eggplants",
+	},
+	Flagged: map[string]string{
+		"sushi":           "often contains raw fish, use cooked or vegetarian fillings",
+		"sashimi":         "is raw fish",
+		"smoked salmon":   "is a cold-smoked fish, heat it until steaming or leave it out",
+		"lox":             "is a cold-smoked fish, heat it until steaming or leave it out",
+		"oyster":          "is often eaten raw, cook it through",
+		"clam":            "is often eaten raw, cook it through",
+		"swordfish":       "is high in mercury",
+		"shark":           "is high in mercury",
+		"king mackerel":   "is high in mercury",
+		"marlin":          "is high in mercury",
+		"tilefish":        "is high in mercury",
+		"bigeye tuna":     "is high in mercury",
+		"tuna steak":      "is high in mercury, limit how often you eat it",
+		"egg":             "must be cooked until the yolk is firm",
+		"mayonnaise":      "may contain raw egg, use a store-bought pasteurized one",
+		"aioli":           "may contain raw egg, use a pasteurized mayonnaise base",
+		"brie":            "is a mold-ripened cheese, use a pasteurized one cooked until steaming",
+		"camembert":       "is a mold-ripened cheese, use a pasteurized one cooked until steaming",
+		"blue cheese":     "is a blue-veined cheese, use it only cooked until steaming",
+		"gorgonzola":      "is a blue-veined cheese, use it only cooked until steaming",
+		"roquefort":       "is a blue-veined cheese, use it only cooked until steaming",
+		"feta":            "must be made from pasteurized milk",
+		"queso fresco":    "must be made from pasteurized milk",
+		"raw milk":        "is unpasteurized",
+		"liver":           "is very high in vitamin A",
+		"pate":            "may carry listeria and is high in vitamin A",
+		"pâté":            "may carry listeria and is high in vitamin A",
+		"prosciutto":      "is a cured raw meat, cook it until steaming",
+		"salami":          "is a cured raw meat, cook it until steaming",
+		"chorizo":         "may be a cured raw meat, cook it until steaming",
+		"ham":             "must be cooked or heated until steaming",
+		"deli meat":       "must be heated until steaming",
+		"hot dog":         "must be heated until steaming",
+		"bean sprouts":    "must be cooked thoroughly",
+		"alfalfa sprouts": "must be cooked thoroughly",
+		"wine":            "contains alcohol, which is not considered safe in pregnancy",
+		"beer":            "contains alcohol, which is not considered safe in pregnancy",
+		"rum":             "contains alcohol, which is not considered safe in pregnancy",
+		"brandy":          "contains alcohol, which is not considered safe in pregnancy",
+	},
+}
@@ -0,0 +1,17 @@
+package recipes
+
+// MaxPrice drops the recipes whose price per serving is above maxPrice.
+// Recipes without a price are kept, since nothing says they are too
+// expensive. Zero means no limit.
+func MaxPrice(allRecipes []Recipe, maxPrice Money) []Recipe {
+	if maxPrice <= 0 {
+		return allRecipes
+	}
+	kept := make([]Recipe, 0, len(allRecipes))
+	for _, recipe := range allRecipes {
+		if recipe.PricePerServing <= maxPrice {
+			kept = append(kept, recipe)
+		}
+	}
+	return kept
+}
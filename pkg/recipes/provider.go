@@ -0,0 +1,93 @@
+package recipes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RecipeProvider is a recipe API. Each provider builds its own requests and
+// parses its own responses into Recipes.
+type RecipeProvider interface {
+	Source
+	// Name is the name the provider is selected by, such as "spoonacular".
+	Name() string
+}
+
+// ErrUnsupportedQuery is wrapped by providers that cannot serve a query, for
+// example because they have no diet filters.
+var ErrUnsupportedQuery = errors.New("query not supported by provider")
+
+// Fallback is a chain of providers. A search goes to the first provider and
+// moves on to the next one whenever a provider fails, so a second API can
+// take over when the quota of the first is used up.
+type Fallback struct {
+	Providers []RecipeProvider
+
+	// OnFallback is called with the error of every provider that is skipped.
+	OnFallback func(provider string, err error)
+}
+
+func (f *Fallback) Name() string {
+	names := make([]string, 0, len(f.Providers))
+	for _, provider := range f.Providers {
+		names = append(names, provider.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+// Search returns the results of the first provider that succeeds, or the
+// errors of all of them.
+func (f *Fallback) Search(ctx context.Context, query Query) ([]Recipe, error) {
+	var errs []error
+	for _, provider := range f.Providers {
+		found, err := provider.Search(ctx, query)
+		if err == nil {
+			return found, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+		if f.OnFallback != nil {
+			f.OnFallback(provider.Name(), err)
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// MatchIngredients splits the ingredients of a recipe into the ones the query
+// asked for and the missing ones, for providers that do not do it themselves.
+func MatchIngredients(ingredients []Ingredient, ingredientList []string) ([]Ingredient, []Ingredient) {
+	var used, missed []Ingredient
+	for _, ingredient := range ingredients {
+		if HasIngredient(strings.ToLower(ingredient.Name), ingredientList) {
+			used = append(used, ingredient)
+		} else {
+			missed = append(missed, ingredient)
+		}
+	}
+	return used, missed
+}
+
+// HasIngredient reports whether the lowercased recipe ingredient name is one
+// of the ingredients, part of one, or a kind of one by the taxonomy, so
+// "cheese" covers "cheddar", the way MatchIngredients decides.
+func HasIngredient(name string, ingredientList []string) bool {
+	for _, wanted := range ingredientList {
+		if containsIngredient(name, wanted) || containsIngredient(wanted, name) || IsKindOf(name, wanted) {
+			return true
+		}
+	}
+	return false
+}
+
+// SortByMissing orders recipes by how many ingredients are missing, fewest
+// first, which is the order Spoonacular returns them in.
+func SortByMissing(allRecipes []Recipe) {
+	sort.SliceStable(allRecipes, func(i, j int) bool {
+		return len(allRecipes[i].MissedIngredients) < len(allRecipes[j].MissedIngredients)
+	})
+}
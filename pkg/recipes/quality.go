@@ -0,0 +1,117 @@
+package recipes
+
+import (
+	"net/url"
+	"sort"
+)
+
+// The data quality issues QualityIssues finds.
+const (
+	IssueMissingNutrition = "missing_nutrition"
+	IssueBrokenImage      = "broken_image"
+	IssueImplausible      = "implausible_values"
+)
+
+const (
+	// maxPlausibleCalories is more than any single serving has.
+	maxPlausibleCalories = 5000
+	// maxPlausiblePrice is more than any serving costs.
+	maxPlausiblePrice = 100 * 100 * Cent
+)
+
+// QualityIssues returns the data quality issues of a recipe: no nutrients, an
+// image URL that is not an absolute http(s) URL, or values no real serving
+// has, such as negative amounts or more protein and carbohydrates than its
+// calories allow for.
+func QualityIssues(recipe Recipe) []string {
+	var issues []string
+	if len(recipe.Nutrients) == 0 {
+		issues = append(issues, IssueMissingNutrition)
+	}
+	if recipe.ImageURL != "" && !validImageURL(recipe.ImageURL) {
+		issues = append(issues, IssueBrokenImage)
+	}
+	if implausible(recipe) {
+		issues = append(issues, IssueImplausible)
+	}
+	return issues
+}
+
+func validImageURL(imageURL string) bool {
+	u, err := url.Parse(imageURL)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func implausible(recipe Recipe) bool {
+	if recipe.Servings < 0 || recipe.PricePerServing < 0 || recipe.PricePerServing > maxPlausiblePrice {
+		return true
+	}
+	for _, nutrient := range recipe.Nutrients {
+		if nutrient.Amount < 0 {
+			return true
+		}
+	}
+	calories, ok := recipe.Nutrients["Calories"]
+	if !ok {
+		return false
+	}
+	if calories.Amount > maxPlausibleCalories {
+		return true
+	}
+	// Protein and carbohydrates have 4 kcal per gram; some slack is left for
+	// rounding and fibre
+	macros := 4 * (recipe.Nutrients["Protein"].Amount + recipe.Nutrients["Carbohydrates"].Amount)
+	return macros > 1.5*calories.Amount+50
+}
+
+// Quality counts the data quality issues found in a provider's recipes.
+type Quality struct {
+	Recipes           int
+	MissingNutrition  int
+	BrokenImages      int
+	ImplausibleValues int
+}
+
+// Add counts a recipe and its issues.
+func (q *Quality) Add(recipe Recipe) {
+	q.Recipes++
+	for _, issue := range QualityIssues(recipe) {
+		switch issue {
+		case IssueMissingNutrition:
+			q.MissingNutrition++
+		case IssueBrokenImage:
+			q.BrokenImages++
+		case IssueImplausible:
+			q.ImplausibleValues++
+		}
+	}
+}
+
+// Score is one minus the average incidence of the issues, from 0 when every
+// recipe has all of them to 1 when none has any or no recipe was counted.
+func (q Quality) Score() float64 {
+	if q.Recipes == 0 {
+		return 1
+	}
+	issues := q.MissingNutrition + q.BrokenImages + q.ImplausibleValues
+	return 1 - float64(issues)/float64(3*q.Recipes)
+}
+
+// PreferQuality orders recipes by the score of their source, best first,
+// keeping the order of recipes with equal scores. Sources without a score
+// count as perfect. SortRecipes keeps the order of equal recipes, so calling
+// it afterwards makes the scores its tie-breaker.
+func PreferQuality(allRecipes []Recipe, scores map[string]float64) {
+	if len(scores) == 0 {
+		return
+	}
+	score := func(recipe Recipe) float64 {
+		if s, ok := scores[recipe.Source]; ok {
+			return s
+		}
+		return 1
+	}
+	sort.SliceStable(allRecipes, func(i, j int) bool {
+		return score(allRecipes[i]) > score(allRecipes[j])
+	})
+}
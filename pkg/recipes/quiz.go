@@ -0,0 +1,85 @@
+package recipes
+
+import "slices"
+
+// minRejections is how many times an ingredient must be in the recipe turned
+// down, and never in the one chosen, for LearnPreferences to call it
+// disliked.
+const minRejections = 2
+
+// QuizAnswer is one answer of the preference quiz: of two recipes, the one
+// the user would rather eat.
+type QuizAnswer struct {
+	Chosen   Recipe
+	Rejected Recipe
+}
+
+// LearnPreferences turns quiz answers into ranking weights and disliked
+// ingredients for a user with no history to go by. Each of the protein,
+// calorie and time weights of base is scaled by how often the chosen recipe
+// scored better on that factor, from 0 when it never did to twice the base
+// weight when it always did, counting only the answers where the two
+// recipes score differently. Missing ingredients say nothing about taste, so
+// that weight is kept. Ingredients are disliked when they were in the
+// rejected recipe of at least minRejections answers and in no chosen one.
+func LearnPreferences(answers []QuizAnswer, base RankWeights) (RankWeights, []string) {
+	learned := base
+	for _, factor := range []struct {
+		weight *float64
+		alone  RankWeights
+	}{
+		{&learned.Protein, RankWeights{Protein: 1}},
+		{&learned.Calories, RankWeights{Calories: 1, CalorieTarget: base.CalorieTarget}},
+		{&learned.Time, RankWeights{Time: 1}},
+	} {
+		var wins, decided int
+		for _, answer := range answers {
+			chosen, rejected := factor.alone.Score(answer.Chosen), factor.alone.Score(answer.Rejected)
+			if chosen == rejected {
+				continue
+			}
+			decided++
+			if chosen > rejected {
+				wins++
+			}
+		}
+		if decided > 0 {
+			*factor.weight *= 2 * float64(wins) / float64(decided)
+		}
+	}
+	if learned.Missing+learned.Protein+learned.Calories+learned.Time == 0 {
+		learned = base
+	}
+
+	chosen := make(map[string]bool)
+	rejected := make(map[string]int)
+	for _, answer := range answers {
+		for _, name := range quizIngredients(answer.Chosen) {
+			chosen[name] = true
+		}
+		for _, name := range quizIngredients(answer.Rejected) {
+			rejected[name]++
+		}
+	}
+	var disliked []string
+	for name, count := range rejected {
+		if count >= minRejections && !chosen[name] {
+			disliked = append(disliked, name)
+		}
+	}
+	slices.Sort(disliked)
+	return learned, disliked
+}
+
+// quizIngredients returns the distinct normalized ingredient names of a
+// recipe.
+func quizIngredients(recipe Recipe) []string {
+	var names []string
+	for _, ingredient := range append(slices.Clip(recipe.UsedIngredients), recipe.MissedIngredients...) {
+		name, _ := normalizeIngredient(ingredient.Name)
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,47 @@
+package recipes
+
+import "math"
+
+// RankWeights weigh the factors of the "score" order of SortRecipes. Every
+// factor scores a recipe from 0 to 1, and recipes are ordered by the
+// weighted sum, highest first. Factors the recipe has no data for score 0.
+type RankWeights struct {
+	// Missing favors recipes missing fewer ingredients: 1 when none are
+	// missing, 1/2 for one, 1/3 for two and so on.
+	Missing float64
+	// Protein favors protein-dense recipes, by the share of their calories
+	// that comes from protein.
+	Protein float64
+	// Calories favors recipes close to CalorieTarget per serving, scoring 0
+	// once they are off by the whole target.
+	Calories float64
+	// Time favors quick recipes: 1/2 for ones ready in 30 minutes, 1/3 in
+	// an hour.
+	Time float64
+
+	CalorieTarget float64
+}
+
+// DefaultRankWeights mostly rank recipes by missing ingredients, like the
+// "missing" order, letting the other factors decide between recipes missing
+// as many.
+var DefaultRankWeights = RankWeights{Missing: 4, Protein: 1, Calories: 1, Time: 1, CalorieTarget: 600}
+
+// Score returns the weighted score of a recipe, see RankWeights.
+func (w RankWeights) Score(recipe Recipe) float64 {
+	score := w.Missing / float64(1+len(recipe.MissedIngredients))
+
+	calories, hasCalories := recipe.Nutrients["Calories"]
+	if protein, ok := recipe.Nutrients["Protein"]; ok && hasCalories && calories.Amount > 0 {
+		// A gram of protein has 4 kcal
+		score += w.Protein * min(4*protein.Amount/calories.Amount, 1)
+	}
+	if hasCalories && w.CalorieTarget > 0 {
+		off := math.Abs(calories.Amount-w.CalorieTarget) / w.CalorieTarget
+		score += w.Calories * (1 - min(off, 1))
+	}
+	if recipe.ReadyInMinutes > 0 {
+		score += w.Time * 30 / float64(30+recipe.ReadyInMinutes)
+	}
+	return score
+}
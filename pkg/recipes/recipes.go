@@ -0,0 +1,392 @@
+// Package recipes holds the recipe types shared by the API client, the cache
+// and the CLI, and the Finder that puts a cache in front of a recipe source.
+package recipes
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+type Recipe struct {
+	ID                int                       `json:"id"`
+	Title             string                    `json:"title"`
+	UsedIngredients   []Ingredient              `json:"usedIngredients"`
+	MissedIngredients []Ingredient              `json:"missedIngredients"`
+	Nutrients         map[string]NutrientAmount `json:"nutrients"`
+	Instructions      []string                  `json:"instructions,omitempty"`
+	// Servings is how many servings the ingredient amounts make, zero when
+	// unknown.
+	Servings int `json:"servings,omitempty"`
+	// ScaledFrom is the servings the ingredient amounts were given for
+	// before ScaleServings scaled them, zero when they were not.
+	ScaledFrom int `json:"scaledFrom,omitempty"`
+	// Warnings are notes attached while screening the recipe, for example
+	// ingredients a dietary rule set could not decide on.
+	Warnings []string `json:"warnings,omitempty"`
+	// Favorite is set for recipes the user has starred.
+	Favorite bool `json:"favorite,omitempty"`
+	// Source is the name of the provider the recipe came from, empty for
+	// recipes cached before it was recorded. IDs are only unique per source.
+	Source string `json:"source,omitempty"`
+	// PricePerServing is the estimated cost of a serving in US cents, zero
+	// when the source gives none.
+	PricePerServing Money `json:"pricePerServing,omitempty"`
+	// ReadyInMinutes is how long the recipe takes in all, zero when the
+	// source gives no time. Equipment names what it is made with, when the
+	// source says.
+	ReadyInMinutes int      `json:"readyInMinutes,omitempty"`
+	Equipment      []string `json:"equipment,omitempty"`
+	// SourceURL is the page the recipe was published on and Author who
+	// published it, such as a blog's name, when the source says. ImageURL is
+	// also set on search results, but only cached with the details, see
+	// "recipefinder show".
+	SourceURL string `json:"sourceUrl,omitempty"`
+	Author    string `json:"author,omitempty"`
+	ImageURL  string `json:"imageUrl,omitempty"`
+	// FetchedAt is when the recipe's data was fetched from its source, zero
+	// when it is not known.
+	FetchedAt time.Time `json:"fetchedAt"`
+	// Estimated names the fields holding the source's estimates rather than
+	// exact data: EstimatedPrice or EstimatedNutrition.
+	Estimated []string `json:"estimated,omitempty"`
+	// Pairing is the drink suggested with the recipe, only looked up when
+	// asked for and nil otherwise.
+	Pairing *Pairing `json:"pairing,omitempty"`
+	// Likes is how many people liked the recipe on its source, and
+	// HealthScore the source's rating of how healthy it is, from 0 to 100.
+	// Both are zero when the source gives none.
+	Likes       int     `json:"likes,omitempty"`
+	HealthScore float64 `json:"healthScore,omitempty"`
+	// Taste is the recipe's taste profile, only looked up for the recipes
+	// shown in full and nil otherwise.
+	Taste *Taste `json:"taste,omitempty"`
+	// Cuisines are the lowercase cuisines the recipe belongs to, such as
+	// italian, when the source says.
+	Cuisines []string `json:"cuisines,omitempty"`
+}
+
+// The fields Recipe.Estimated names.
+const (
+	EstimatedPrice     = "price"
+	EstimatedNutrition = "nutrition"
+)
+
+// Ingredient is an ingredient line of a recipe. Amount, Unit and Forms are
+// zero when only the name is known. The cache keeps amounts and units but not
+// forms.
+type Ingredient struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount,omitempty"`
+	Unit   string  `json:"unit,omitempty"`
+	// Forms are the preparations the ingredient line describes, see TagForms.
+	Forms []string `json:"forms,omitempty"`
+	// Substitutes are ingredients the user has that can stand in for a
+	// missing one, see SuggestSubstitutes.
+	Substitutes []string `json:"substitutes,omitempty"`
+	// Replacements are the ways of doing without a missing ingredient the
+	// provider suggests, such as "1 cup = 1 cup milk + 1 tbsp lemon juice"
+	// for buttermilk, see HaveFirst.
+	Replacements []string `json:"replacements,omitempty"`
+}
+
+// NutrientNames returns the names of the recipe's nutrients in alphabetical
+// order, for printing them consistently.
+func (r Recipe) NutrientNames() []string {
+	names := make([]string, 0, len(r.Nutrients))
+	for name := range r.Nutrients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IngredientNames returns the names of the ingredients in order.
+func IngredientNames(ingredients []Ingredient) []string {
+	names := make([]string, 0, len(ingredients))
+	for _, ingredient := range ingredients {
+		names = append(names, ingredient.Name)
+	}
+	return names
+}
+
+// Query describes a recipe search.
+type Query struct {
+	Ingredients     []string
+	NumberOfRecipes int
+	// Diets and Intolerances hold canonical names, see NormalizeDiets and
+	// NormalizeIntolerances. Every diet must be satisfied.
+	Diets        []string
+	Intolerances []string
+	// Exclude holds normalized names of ingredients no recipe may use, see
+	// NormalizeExclusions. It is passed to sources that can exclude
+	// ingredients and enforced by Finder for the others.
+	Exclude []string
+	// Targets are passed to sources that can filter on nutrients, and
+	// applied to the results with NutritionTargets.Apply for the others.
+	Targets NutritionTargets
+	// Prep is passed to sources that can filter on ready time and equipment,
+	// and applied to the results with PrepLimits.Apply for the others.
+	Prep PrepLimits
+	// Offset skips that many results of the source, for fetching the recipes
+	// after the ones already cached. Sources that cannot page ignore it.
+	Offset int
+}
+
+// Source searches for recipes matching a query. It returns fewer recipes than
+// asked for only when it has no more, which is how Finder knows to stop
+// paging.
+type Source interface {
+	Search(ctx context.Context, query Query) ([]Recipe, error)
+}
+
+// Cache stores the recipes found for a query. The number of recipes asked for
+// is not part of what identifies a query. Save replaces the recipes stored
+// for the query before.
+type Cache interface {
+	Lookup(ctx context.Context, query Query) ([]Recipe, error)
+	Save(ctx context.Context, query Query, recipes []Recipe) error
+}
+
+// Finder serves searches from Cache when it already holds enough recipes.
+// Otherwise the cached recipes are kept and only the missing ones are fetched
+// from Source, which are then saved after the cached ones. Recipes are
+// sanitized, see Sanitize, before they are saved or returned, and recipes
+// using an excluded ingredient are dropped, cached ones included since they
+// may have been saved before the ingredient was excluded. Near-duplicate
+// recipes are collapsed, see CollapseDuplicates, unless AllowDuplicates is
+// set. Recipes Sources does not allow are left out of the results, but still
+// saved. Cache may be nil.
+type Finder struct {
+	Source Source
+	Cache  Cache
+
+	// Sources are the recipes, providers, sites and authors results may come
+	// from.
+	Sources SourcePolicy
+
+	// Refresh skips the cache lookup, so results always come from Source and
+	// replace what is cached.
+	Refresh bool
+
+	// AllowDuplicates keeps recipes CollapseDuplicates would drop.
+	AllowDuplicates bool
+
+	// OnCacheError is called with cache failures, which never fail a search.
+	OnCacheError func(error)
+
+	// OnProgress is called as pages of recipes come in from Source, with how
+	// many were fetched so far and how many are wanted, when more than one
+	// page is needed. It may be called from several goroutines at once.
+	OnProgress func(fetched int, wanted int)
+
+	// OnCacheLookup is called after each cache lookup with whether the cache
+	// had enough recipes for the query. Lookups that fail are not reported.
+	OnCacheLookup func(hit bool)
+}
+
+// PageSize is the most recipes asked from Source in one search, the limit
+// of Spoonacular's complexSearch. Searches for more take several pages.
+const PageSize = 100
+
+func (f *Finder) Find(ctx context.Context, query Query) ([]Recipe, error) {
+	return f.find(ctx, query, nil)
+}
+
+// Stream runs Find and sends its recipes on the returned channel as soon as
+// they are known, for printing them while a long search goes on: the cached
+// ones first, then those of each page as Source returns it. They are
+// screened as Find screens its results, and each is sent once, in the order
+// found rather than the order Find would return them in. The channel is
+// closed when the search is over, after which the error channel receives
+// Find's error, nil when it succeeded. The channel must be read until it is
+// closed.
+func (f *Finder) Stream(ctx context.Context, query Query) (<-chan []Recipe, <-chan error) {
+	found := make(chan []Recipe)
+	errs := make(chan error, 1)
+	go func() {
+		var mu sync.Mutex
+		var sent []Recipe
+		_, err := f.find(ctx, query, func(page []Recipe) {
+			mu.Lock()
+			defer mu.Unlock()
+			// Recipes already sent, and their near-duplicates, are left out
+			kept := f.collapse(mergeRecipes(sent, page))[len(sent):]
+			if len(kept) == 0 {
+				return
+			}
+			sent = append(sent, kept...)
+			select {
+			case found <- kept:
+			case <-ctx.Done():
+			}
+		})
+		close(found)
+		errs <- err
+	}()
+	return found, errs
+}
+
+// find is Find, calling send, when it is not nil, with the recipes found so
+// far: the cached ones, then each page of Source, screened.
+func (f *Finder) find(ctx context.Context, query Query, send func(page []Recipe)) ([]Recipe, error) {
+	// cached are the recipes of a partial hit to return, lookedUp all the
+	// ones the cache holds for the query, which are saved again with the
+	// fetched ones
+	var cached, lookedUp []Recipe
+	if f.Cache != nil && !f.Refresh {
+		found, err := f.Cache.Lookup(ctx, query)
+		if err != nil {
+			f.cacheError(err)
+		} else {
+			// Recipes cached before sanitizing existed may still hold markup
+			for i := range found {
+				found[i] = Sanitize(found[i])
+			}
+			lookedUp = slices.Clone(found)
+			found = ExcludeIngredients(found, query.Exclude)
+			found = f.Sources.Apply(f.collapse(found))
+			hit := len(found) >= query.NumberOfRecipes
+			if f.OnCacheLookup != nil {
+				f.OnCacheLookup(hit)
+			}
+			if send != nil && len(found) > 0 {
+				send(found)
+			}
+			if hit {
+				slog.Debug("cache hit", "ingredients", strings.Join(query.Ingredients, ","), "recipes", len(found))
+				return found, nil
+			}
+			slog.Debug("cache miss", "ingredients", strings.Join(query.Ingredients, ","), "cached", len(found),
+				"wanted", query.NumberOfRecipes)
+			cached = found
+		}
+	}
+
+	var onPage func([]Recipe)
+	if send != nil {
+		onPage = func(page []Recipe) {
+			send(f.Sources.Apply(f.screen(query, slices.Clone(page), time.Now())))
+		}
+	}
+	fetched, err := f.fetch(ctx, query, len(cached), query.NumberOfRecipes-len(cached), onPage)
+	if err != nil {
+		return nil, err
+	}
+	fetched = f.screen(query, fetched, time.Now())
+
+	if f.Cache != nil {
+		// Saving replaces what is cached for the query, so the recipes of a
+		// partial hit go first, in the order they were cached; the cache keeps
+		// the first of a recipe given twice
+		err = f.Cache.Save(ctx, query, slices.Concat(lookedUp, fetched))
+		if err != nil {
+			f.cacheError(err)
+		}
+	}
+	return f.collapse(mergeRecipes(cached, f.Sources.Apply(fetched))), nil
+}
+
+// screen sanitizes recipes fetched from Source at now, and drops the ones
+// the query's targets, prep limits and exclusions rule out, and the
+// near-duplicates.
+func (f *Finder) screen(query Query, fetched []Recipe, now time.Time) []Recipe {
+	for i := range fetched {
+		fetched[i] = Sanitize(fetched[i])
+		fetched[i].FetchedAt = now
+	}
+	fetched = query.Targets.Apply(fetched)
+	fetched = query.Prep.Apply(fetched)
+	fetched = ExcludeIngredients(fetched, query.Exclude)
+	return f.collapse(fetched)
+}
+
+// collapse drops near-duplicate recipes unless AllowDuplicates is set.
+func (f *Finder) collapse(allRecipes []Recipe) []Recipe {
+	if f.AllowDuplicates {
+		return allRecipes
+	}
+	return CollapseDuplicates(allRecipes)
+}
+
+// fetch searches Source for count recipes after the first offset ones. More
+// than PageSize recipes are fetched as concurrent searches of a page each,
+// each passed to onPage, when it is not nil, as soon as it comes in.
+// Pages are searched again further on until count distinct recipes are
+// found, Source runs out, shown by a page shorter than asked for, or a round
+// of pages finds nothing new, as with sources that cannot page.
+func (f *Finder) fetch(ctx context.Context, query Query, offset int, count int,
+	onPage func([]Recipe)) ([]Recipe, error) {
+	var fetched []Recipe
+	var received atomic.Int64
+	for len(fetched) < count {
+		wanted := count - len(fetched)
+		pages := make([][]Recipe, (wanted+PageSize-1)/PageSize)
+		group, groupCtx := errgroup.WithContext(ctx)
+		for page := range pages {
+			pageQuery := query
+			pageQuery.Offset = offset + page*PageSize
+			pageQuery.NumberOfRecipes = min(PageSize, wanted-page*PageSize)
+			group.Go(func() error {
+				found, err := f.Source.Search(groupCtx, pageQuery)
+				pages[page] = found
+				if err == nil && count > PageSize && f.OnProgress != nil {
+					f.OnProgress(min(int(received.Add(int64(len(found)))), count), count)
+				}
+				if err == nil && onPage != nil {
+					onPage(found)
+				}
+				return err
+			})
+		}
+		err := group.Wait()
+		if err != nil {
+			return nil, err
+		}
+
+		before := len(fetched)
+		exhausted := false
+		for page, found := range pages {
+			fetched = mergeRecipes(fetched, found)
+			if len(found) < min(PageSize, wanted-page*PageSize) {
+				exhausted = true
+				break
+			}
+		}
+		if exhausted || len(fetched) == before {
+			break
+		}
+		offset += wanted
+	}
+	return fetched[:min(len(fetched), count)], nil
+}
+
+// mergeRecipes concatenates the lists, keeping only the first recipe with
+// each ID.
+func mergeRecipes(lists ...[]Recipe) []Recipe {
+	var merged []Recipe
+	seen := make(map[int]bool)
+	for _, list := range lists {
+		for _, recipe := range list {
+			if seen[recipe.ID] {
+				continue
+			}
+			seen[recipe.ID] = true
+			merged = append(merged, recipe)
+		}
+	}
+	return merged
+}
+
+func (f *Finder) cacheError(err error) {
+	if f.OnCacheError != nil {
+		f.OnCacheError(err)
+	}
+}
@@ -0,0 +1,1071 @@
+package recipes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeSource returns numbered recipes, counting the searches it serves. It
+// has total recipes, or any number when total is 0.
+type fakeSource struct {
+	mu       sync.Mutex
+	searches []Query
+	err      error
+	total    int
+}
+
+func (s *fakeSource) Search(_ context.Context, query Query) ([]Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.searches = append(s.searches, query)
+	if s.err != nil {
+		return nil, s.err
+	}
+	count := query.NumberOfRecipes
+	if s.total > 0 {
+		count = max(0, min(count, s.total-query.Offset))
+	}
+	found := make([]Recipe, count)
+	for i := range found {
+		id := query.Offset + i + 1
+		found[i] = Recipe{
+			ID:              id,
+			Title:           "Recipe <b>" + letters(id) + "</b>",
+			UsedIngredients: []Ingredient{{Name: query.Ingredients[0]}},
+		}
+	}
+	return found, nil
+}
+
+// letters names recipe id like spreadsheet columns, A to Z, then AA, so
+// every fake recipe has a distinct title.
+func letters(id int) string {
+	name := ""
+	for ; id > 0; id = (id - 1) / 26 {
+		name = string(rune('A'+(id-1)%26)) + name
+	}
+	return name
+}
+
+// mapCache is a Cache keeping the recipes saved last for each query in memory,
+// each save replacing the ones before as the SQL store's does.
+type mapCache struct {
+	saved     map[string][]Recipe
+	lookupErr error
+	saves     int
+}
+
+func newMapCache() *mapCache {
+	return &mapCache{saved: make(map[string][]Recipe)}
+}
+
+func cacheKey(query Query) string {
+	return query.Ingredients[0]
+}
+
+func (c *mapCache) Lookup(_ context.Context, query Query) ([]Recipe, error) {
+	if c.lookupErr != nil {
+		return nil, c.lookupErr
+	}
+	return append([]Recipe(nil), c.saved[cacheKey(query)]...), nil
+}
+
+func (c *mapCache) Save(_ context.Context, query Query, allRecipes []Recipe) error {
+	c.saves++
+	c.saved[cacheKey(query)] = slices.Clone(allRecipes)
+	return nil
+}
+
+func TestFinderFetchesAndCaches(t *testing.T) {
+	source, cache := &fakeSource{}, newMapCache()
+	finder := &Finder{Source: source, Cache: cache}
+	query := Query{Ingredients: []string{"egg"}, NumberOfRecipes: 3}
+
+	found, err := finder.Find(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 3 || len(source.searches) != 1 || cache.saves != 1 {
+		t.Fatalf("got %d recipes from %d searches and %d saves, want 3 from 1 search and 1 save", len(found),
+			len(source.searches), cache.saves)
+	}
+	// Fetched recipes are sanitized before they are saved and returned
+	if found[0].Title != "Recipe A" || cache.saved["egg"][0].Title != "Recipe A" {
+		t.Errorf("got title %q, saved %q, want markup removed", found[0].Title, cache.saved["egg"][0].Title)
+	}
+
+	found, err = finder.Find(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 3 || len(source.searches) != 1 {
+		t.Errorf("got %d recipes after %d searches, want 3 served from the cache", len(found), len(source.searches))
+	}
+}
+
+func TestFinderFetchesOnlyMissingRecipes(t *testing.T) {
+	source, cache := &fakeSource{}, newMapCache()
+	cache.saved["egg"] = []Recipe{{ID: 1, Title: "Recipe A"}, {ID: 2, Title: "Recipe B"}}
+	finder := &Finder{Source: source, Cache: cache}
+
+	found, err := finder.Find(context.Background(), Query{Ingredients: []string{"egg"}, NumberOfRecipes: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(source.searches) != 1 {
+		t.Fatalf("made %d searches, want 1", len(source.searches))
+	}
+	if got := source.searches[0]; got.Offset != 2 || got.NumberOfRecipes != 3 {
+		t.Errorf("searched offset %d for %d recipes, want offset 2 for 3", got.Offset, got.NumberOfRecipes)
+	}
+	if len(found) != 5 {
+		t.Errorf("got %d recipes, want 5", len(found))
+	}
+	// The save replaces the cached recipes, so it holds them too
+	var saved []int
+	for _, recipe := range cache.saved["egg"] {
+		saved = append(saved, recipe.ID)
+	}
+	if !slices.Equal(saved, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("saved recipes %v, want 1 to 5 in order", saved)
+	}
+}
+
+func TestFinderRefreshSkipsCache(t *testing.T) {
+	source, cache := &fakeSource{}, newMapCache()
+	cache.saved["egg"] = []Recipe{{ID: 1, Title: "Recipe A"}}
+	finder := &Finder{Source: source, Cache: cache, Refresh: true}
+
+	_, err := finder.Find(context.Background(), Query{Ingredients: []string{"egg"}, NumberOfRecipes: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(source.searches) != 1 {
+		t.Errorf("made %d searches, want 1 despite the cached recipe", len(source.searches))
+	}
+}
+
+func TestFinderSurvivesCacheErrors(t *testing.T) {
+	source, cache := &fakeSource{}, newMapCache()
+	cache.lookupErr = errors.New("database is down")
+	var cacheErrors []error
+	finder := &Finder{Source: source, Cache: cache, OnCacheError: func(err error) {
+		cacheErrors = append(cacheErrors, err)
+	}}
+
+	found, err := finder.Find(context.Background(), Query{Ingredients: []string{"egg"}, NumberOfRecipes: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || len(cacheErrors) != 1 {
+		t.Errorf("got %d recipes and %d cache errors, want 2 and 1", len(found), len(cacheErrors))
+	}
+}
+
+func TestFinderReturnsSourceErrors(t *testing.T) {
+	sourceErr := errors.New("quota exhausted")
+	finder := &Finder{Source: &fakeSource{err: sourceErr}}
+
+	_, err := finder.Find(context.Background(), Query{Ingredients: []string{"egg"}, NumberOfRecipes: 2})
+	if !errors.Is(err, sourceErr) {
+		t.Errorf("got error %v, want %v", err, sourceErr)
+	}
+}
+
+func TestFinderPagesLargeSearches(t *testing.T) {
+	source := &fakeSource{}
+	finder := &Finder{Source: source}
+
+	found, err := finder.Find(context.Background(), Query{Ingredients: []string{"egg"}, NumberOfRecipes: 250})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 250 || len(source.searches) != 3 {
+		t.Errorf("got %d recipes from %d searches, want 250 from 3 pages", len(found), len(source.searches))
+	}
+}
+
+func TestFinderStreamsPages(t *testing.T) {
+	source, cache := &fakeSource{}, newMapCache()
+	finder := &Finder{Source: source, Cache: cache}
+	query := Query{Ingredients: []string{"egg"}, NumberOfRecipes: 250}
+	// Cached recipes come first, then the pages fetching the rest
+	cache.saved["egg"] = []Recipe{{ID: 1000, Title: "Cached omelette"}}
+
+	found, errs := finder.Stream(context.Background(), query)
+	var pages int
+	seen := make(map[int]bool)
+	for page := range found {
+		pages++
+		for _, recipe := range page {
+			if seen[recipe.ID] {
+				t.Errorf("recipe %d was sent twice", recipe.ID)
+			}
+			seen[recipe.ID] = true
+			if strings.Contains(recipe.Title, "<b>") {
+				t.Errorf("got unsanitized title %q", recipe.Title)
+			}
+		}
+		if pages == 1 && (len(page) != 1 || page[0].ID != 1000) {
+			t.Errorf("got first page %v, want the cached recipe", page)
+		}
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if pages != 4 || len(seen) != 250 {
+		t.Errorf("got %d recipes in %d pages, want 250 in the cached one and 3 fetched", len(seen), pages)
+	}
+	if cache.saves != 1 {
+		t.Errorf("got %d saves, want the streamed search cached once", cache.saves)
+	}
+}
+
+func TestFinderStopsWhenSourceRunsOut(t *testing.T) {
+	source := &fakeSource{total: 150}
+	var progress []int
+	var mu sync.Mutex
+	finder := &Finder{Source: source, OnProgress: func(fetched int, wanted int) {
+		mu.Lock()
+		defer mu.Unlock()
+		progress = append(progress, fetched)
+		if wanted != 250 {
+			t.Errorf("got progress towards %d recipes, want 250", wanted)
+		}
+	}}
+
+	found, err := finder.Find(context.Background(), Query{Ingredients: []string{"egg"}, NumberOfRecipes: 250})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 150 || len(source.searches) != 3 {
+		t.Errorf("got %d recipes from %d searches, want all 150 from 3 pages", len(found), len(source.searches))
+	}
+	if len(progress) != 3 || max(progress[0], progress[1], progress[2]) != 150 {
+		t.Errorf("got progress %v, want a report per page reaching 150", progress)
+	}
+}
+
+func TestFinderDropsExcludedCachedRecipes(t *testing.T) {
+	source, cache := &fakeSource{}, newMapCache()
+	cache.saved["egg"] = []Recipe{
+		{ID: 1, Title: "Peanut Eggs", UsedIngredients: []Ingredient{{Name: "egg"}, {Name: "peanuts"}}},
+		{ID: 2, Title: "Plain Eggs", UsedIngredients: []Ingredient{{Name: "egg"}}},
+	}
+	finder := &Finder{Source: source, Cache: cache}
+
+	found, err := finder.Find(context.Background(),
+		Query{Ingredients: []string{"egg"}, NumberOfRecipes: 1, Exclude: NormalizeExclusions("peanuts", nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].ID != 2 || len(source.searches) != 0 {
+		t.Errorf("got %v after %d searches, want only Plain Eggs from the cache", found, len(source.searches))
+	}
+}
+
+func TestQualityIssues(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		recipe Recipe
+		want   []string
+	}{
+		{"fine", Recipe{Nutrients: map[string]NutrientAmount{"Calories": {Amount: 400}, "Protein": {Amount: 30}}}, nil},
+		{"no nutrients", Recipe{ImageURL: "https://img.example/1.jpg"}, []string{IssueMissingNutrition}},
+		{"relative image", Recipe{Nutrients: map[string]NutrientAmount{"Calories": {Amount: 400}}, ImageURL: "1.jpg"},
+			[]string{IssueBrokenImage}},
+		{"more protein than calories", Recipe{Nutrients: map[string]NutrientAmount{"Calories": {Amount: 100},
+			"Protein": {Amount: 80}}}, []string{IssueImplausible}},
+		{"negative price", Recipe{Nutrients: map[string]NutrientAmount{"Calories": {Amount: 400}}, PricePerServing: -1},
+			[]string{IssueImplausible}},
+	} {
+		got := QualityIssues(test.recipe)
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: got issues %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestPreferQualityBreaksTies(t *testing.T) {
+	allRecipes := []Recipe{
+		{ID: 1, Source: "edamam", MissedIngredients: make([]Ingredient, 1)},
+		{ID: 2, Source: "themealdb"},
+		{ID: 3, Source: "spoonacular", MissedIngredients: make([]Ingredient, 1)},
+	}
+	PreferQuality(allRecipes, map[string]float64{"edamam": 0.6, "spoonacular": 0.9})
+	SortRecipes(allRecipes, "missing", DefaultRankWeights)
+	var ids []int
+	for _, recipe := range allRecipes {
+		ids = append(ids, recipe.ID)
+	}
+	if !slices.Equal(ids, []int{2, 3, 1}) {
+		t.Errorf("got order %v, want [2 3 1]", ids)
+	}
+}
+
+func TestPopularityAndHealthScore(t *testing.T) {
+	allRecipes := []Recipe{
+		{ID: 1, Likes: 12, HealthScore: 20},
+		{ID: 2, Likes: 340, HealthScore: 75},
+		{ID: 3},
+	}
+	SortRecipes(allRecipes, "popularity", DefaultRankWeights)
+	kept := MinHealthScore(allRecipes, 50)
+	var ids []int
+	for _, recipe := range kept {
+		ids = append(ids, recipe.ID)
+	}
+	if !slices.Equal(ids, []int{2, 3}) {
+		t.Errorf("got %v, want the most liked recipe and the one without a score", ids)
+	}
+}
+
+func TestTaxonomyMatching(t *testing.T) {
+	if !HasIngredient("sharp cheddar cheese", []string{"cheese"}) || !HasIngredient("cheddar", []string{"dairy"}) {
+		t.Error("cheese did not cover cheddar")
+	}
+	if HasIngredient("cheese", []string{"cheddar"}) || HasIngredient("chicken breast", []string{"beef"}) {
+		t.Error("an ingredient covered one it is not a kind of")
+	}
+	for name, want := range map[string]string{"Cheddar": "dairy", "red onions": "produce", "saffron": ""} {
+		if got := Aisle(name); got != want {
+			t.Errorf("got aisle %q for %s, want %q", got, name, want)
+		}
+	}
+}
+
+func TestExtendTaxonomy(t *testing.T) {
+	saved := taxonomy
+	t.Cleanup(func() {
+		taxonomy = saved
+	})
+	err := ExtendTaxonomy([]TaxonomyEntry{{Name: "Halloumi", Parent: "cheese"}, {Name: "tahini", Category: "International"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !HasIngredient("grilled halloumi", []string{"cheese"}) || Aisle("halloumi") != "dairy" ||
+		Aisle("tahini") != "international" {
+		t.Error("the added entries were not used")
+	}
+
+	err = ExtendTaxonomy([]TaxonomyEntry{{Name: "dairy", Parent: "cheddar"}})
+	if err == nil {
+		t.Error("got no error for a cycle")
+	}
+	if Aisle("milk") != "dairy" {
+		t.Error("a failed extension changed the taxonomy")
+	}
+}
+
+func TestSuggestSubstitutes(t *testing.T) {
+	missed := []Ingredient{{Name: "cheddar"}, {Name: "saffron"}}
+	allRecipes := SuggestSubstitutes([]Recipe{{MissedIngredients: missed}}, []string{"gouda", "rice"})
+	if got := allRecipes[0].MissedIngredients[0].Substitutes; !slices.Equal(got, []string{"gouda"}) {
+		t.Errorf("got substitutes %q for cheddar, want [gouda]", got)
+	}
+	if allRecipes[0].MissedIngredients[1].Substitutes != nil || missed[0].Substitutes != nil {
+		t.Error("got substitutes for saffron, or the original ingredients were changed")
+	}
+}
+
+func TestSummarizeNutrition(t *testing.T) {
+	meals := []Recipe{
+		{Nutrients: map[string]NutrientAmount{
+			"Calories": {Amount: 800, Unit: "kcal"}, "Protein": {Amount: 30, Unit: "g"}, "Fat": {Amount: 20, Unit: "g"},
+		}},
+		{Nutrients: map[string]NutrientAmount{"Calories": {Amount: 1200, Unit: "kcal"}, "Protein": {Amount: 10000, Unit: "mg"}}},
+	}
+	summary := SummarizeNutrition(meals, 2, DailyGoals{Calories: 1000, Protein: 50, Fat: 30})
+	if summary.Meals != 2 || summary.Days != 2 || len(summary.Nutrients) != 4 {
+		t.Fatalf("got %+v, want 2 meals over 2 days and 4 nutrients", summary)
+	}
+	want := map[string]NutrientTally{
+		"Calories":      {Nutrient: "Calories", Unit: "kcal", Total: 2000, Daily: 1000, Goal: 1000, Status: "on target"},
+		"Protein":       {Nutrient: "Protein", Unit: "g", Total: 40, Daily: 20, Goal: 50, Status: "under"},
+		"Carbohydrates": {Nutrient: "Carbohydrates", Unit: "g", Missing: 2},
+		"Fat":           {Nutrient: "Fat", Unit: "g", Total: 20, Daily: 10, Missing: 1, Goal: 30, Status: "under"},
+	}
+	for _, tally := range summary.Nutrients {
+		if tally != want[tally.Nutrient] {
+			t.Errorf("got %+v, want %+v", tally, want[tally.Nutrient])
+		}
+	}
+	if goals := summary.Goals(); len(goals) != 3 || goals[2].Nutrient != "Fat" {
+		t.Errorf("got goals %+v, want calories, protein and fat", goals)
+	}
+	over := SummarizeNutrition(meals, 1, DailyGoals{Calories: 1500})
+	if over.Nutrients[0].Status != "over" || math.Round(over.Nutrients[0].Percent()) != 133 {
+		t.Errorf("got %+v, want 2000 kcal over a goal of 1500", over.Nutrients[0])
+	}
+}
+
+func TestHaveFirst(t *testing.T) {
+	replacements := []string{"1 cup plain yogurt", "1 cup milk + 1 tbsp Lemon Juice", "1 cup sour cream"}
+	got := HaveFirst(replacements, []string{"lemon juice", "rice"})
+	want := []string{"1 cup milk + 1 tbsp Lemon Juice", "1 cup plain yogurt", "1 cup sour cream"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if replacements[0] != "1 cup plain yogurt" {
+		t.Error("the replacements given were reordered")
+	}
+}
+
+func TestCollapseDuplicates(t *testing.T) {
+	allRecipes := CollapseDuplicates([]Recipe{
+		{ID: 1, Title: "Easy Pancakes"},
+		{ID: 2, Title: "Easy Pancakes II"},
+		{ID: 1, Title: "Pancakes, renamed"},
+		{ID: 3, Title: "The Best Pancake Recipe!"},
+		{ID: 4, Title: "Banana Pancakes"},
+		{ID: 4, Source: "edamam", Title: "Tomato Soup"},
+	})
+	var ids []int
+	for _, recipe := range allRecipes {
+		ids = append(ids, recipe.ID)
+	}
+	if !slices.Equal(ids, []int{1, 4, 4}) {
+		t.Errorf("kept recipes %v, want [1 4 4]", ids)
+	}
+}
+
+func TestDescribeIngredient(t *testing.T) {
+	info := DescribeIngredient("Sharp Cheddar")
+	if info.Name != "sharp cheddar" || info.Category != "dairy" || info.PricePerKg != 12 ||
+		!slices.Contains(info.Substitutes, "gouda") {
+		t.Errorf("got %+v, want a dairy cheddar at $12/kg with substitutes", info)
+	}
+	tomato := DescribeIngredient("tomatoes")
+	if !tomato.InSeason(time.August) || tomato.InSeason(time.January) || !info.InSeason(time.January) {
+		t.Error("got the wrong seasons for tomatoes or cheddar")
+	}
+}
+
+func TestSortByScore(t *testing.T) {
+	allRecipes := []Recipe{
+		{ID: 1, MissedIngredients: make([]Ingredient, 2)},
+		{ID: 2, ReadyInMinutes: 90},
+		{ID: 3, ReadyInMinutes: 15},
+		{ID: 4, ReadyInMinutes: 15, Nutrients: map[string]NutrientAmount{"Calories": {Amount: 600}, "Protein": {Amount: 45}}},
+	}
+	SortRecipes(allRecipes, "score", DefaultRankWeights)
+	var ids []int
+	for _, recipe := range allRecipes {
+		ids = append(ids, recipe.ID)
+	}
+	if !slices.Equal(ids, []int{4, 3, 2, 1}) {
+		t.Errorf("got order %v, want [4 3 2 1]", ids)
+	}
+
+	SortRecipes(allRecipes, "score", RankWeights{Time: 1})
+	if allRecipes[len(allRecipes)-1].ID != 1 || allRecipes[2].ID != 2 {
+		t.Errorf("got %v with only time weighed, want the slow and unknown recipes last", allRecipes)
+	}
+}
+
+func TestSortIsDeterministic(t *testing.T) {
+	ordered := []Recipe{{ID: 3}, {ID: 2, Source: "edamam"}, {ID: 2, Source: "spoonacular"}, {ID: 1}}
+	shuffled := []Recipe{ordered[2], ordered[0], ordered[3], ordered[1]}
+	for _, allRecipes := range [][]Recipe{ordered, shuffled} {
+		allRecipes = append([]Recipe(nil), allRecipes...)
+		SortByID(allRecipes)
+		SortRecipes(allRecipes, "missing", DefaultRankWeights)
+		var got []string
+		for _, recipe := range allRecipes {
+			got = append(got, fmt.Sprint(recipe.Source, recipe.ID))
+		}
+		if want := []string{"1", "edamam2", "spoonacular2", "3"}; !slices.Equal(got, want) {
+			t.Errorf("got order %q, want %q", got, want)
+		}
+	}
+}
+
+func TestMoney(t *testing.T) {
+	// A third of a dollar three times is a dollar, not $0.99 or $1.0000001
+	var total Money
+	for range 3 {
+		total += CentsToMoney(33.3333)
+	}
+	if got := total.String(); got != "$1.00" {
+		t.Errorf("got total %s, want $1.00", got)
+	}
+	if got := CentsToMoney(0.5).String(); got != "$0.01" {
+		t.Errorf("got half a cent as %s, want it rounded up to $0.01", got)
+	}
+	if got := DollarsToMoney(2.5).Mul(1.0 / 3).Decimal(); got != "0.83" {
+		t.Errorf("got a third of $2.50 as %s, want 0.83", got)
+	}
+
+	var decoded struct{ Price Money }
+	err := json.Unmarshal([]byte(`{"Price": 276.67}`), &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(encoded) != `{"Price":276.67}` {
+		t.Errorf("got %s, want the price in cents as decoded", encoded)
+	}
+}
+
+func TestNutrientAmount(t *testing.T) {
+	energy := NutrientAmount{Amount: 418.4, Unit: "kJ"}
+	if kcal, ok := energy.In("kcal"); !ok || math.Abs(kcal.Amount-100) > 1e-9 {
+		t.Errorf("got %v in kcal, want 100", kcal)
+	}
+	sum, err := NutrientAmount{Amount: 1, Unit: "g"}.Add(NutrientAmount{Amount: 250, Unit: "mg"})
+	if err != nil || sum != (NutrientAmount{Amount: 1.25, Unit: "g"}) {
+		t.Errorf("got %v, %v, want 1.25 g", sum, err)
+	}
+	_, err = NutrientAmount{Amount: 1, Unit: "g"}.Add(energy)
+	if err == nil {
+		t.Error("added kJ to grams")
+	}
+	if got := (NutrientAmount{Amount: 12.345, Unit: "g"}).Mul(2).Rounded(); math.Abs(got.Amount-24.7) > 1e-9 {
+		t.Errorf("got %v, want 24.7 g", got)
+	}
+}
+
+func TestPrepLimits(t *testing.T) {
+	allRecipes := []Recipe{
+		{ID: 1, ReadyInMinutes: 25, Equipment: []string{"microwave oven"}},
+		{ID: 2, ReadyInMinutes: 45, Equipment: []string{"blender"}},
+		{ID: 3, Equipment: []string{"Blender", "ovenproof dish"}},
+		{ID: 4, ReadyInMinutes: 10},
+	}
+	for _, test := range []struct {
+		limits PrepLimits
+		want   []int
+	}{
+		{PrepLimits{}, []int{1, 2, 3, 4}},
+		{PrepLimits{MaxReadyTime: 30}, []int{1, 4}},
+		{PrepLimits{Equipment: NormalizeEquipment("Oven, blender")}, []int{1, 2, 3}},
+		{PrepLimits{NoEquipment: NormalizeEquipment("oven")}, []int{2, 3, 4}},
+		{PrepLimits{MaxReadyTime: 60, NoEquipment: []string{"blender"}}, []int{1, 4}},
+	} {
+		var ids []int
+		for _, recipe := range test.limits.Apply(allRecipes) {
+			ids = append(ids, recipe.ID)
+		}
+		if !slices.Equal(ids, test.want) {
+			t.Errorf("%+v kept %v, want %v", test.limits, ids, test.want)
+		}
+	}
+}
+
+func TestScaleServings(t *testing.T) {
+	allRecipes := []Recipe{
+		{
+			ID:                1,
+			Servings:          4,
+			UsedIngredients:   []Ingredient{{Name: "flour", Amount: 200, Unit: "g"}},
+			MissedIngredients: []Ingredient{{Name: "eggs", Amount: 2}},
+			Nutrients:         map[string]NutrientAmount{"Calories": {Amount: 300, Unit: "kcal"}},
+		},
+		{ID: 2, UsedIngredients: []Ingredient{{Name: "rice", Amount: 100, Unit: "g"}}},
+	}
+	scaled := ScaleServings(allRecipes, 6)
+
+	recipe := scaled[0]
+	if recipe.Servings != 6 || recipe.ScaledFrom != 4 || recipe.UsedIngredients[0].Amount != 300 ||
+		recipe.MissedIngredients[0].Amount != 3 {
+		t.Errorf("got %+v, want the amounts scaled from 4 servings to 6", recipe)
+	}
+	if allRecipes[0].UsedIngredients[0].Amount != 200 {
+		t.Error("scaling changed the original recipe")
+	}
+	if calories, ok := recipe.TotalNutrient("Calories"); !ok || calories.Amount != 1800 ||
+		recipe.Nutrients["Calories"].Amount != 300 {
+		t.Errorf("got %v in all, %v per serving, want 1800 and 300", calories, recipe.Nutrients["Calories"])
+	}
+	if scaled[1].UsedIngredients[0].Amount != 100 || len(scaled[1].Warnings) != 1 {
+		t.Errorf("got %+v, want a recipe of unknown servings left as it is, with a warning", scaled[1])
+	}
+}
+
+func TestParseStock(t *testing.T) {
+	stock, err := ParseStock("Chicken:500g, rice:2cups,eggs:6,salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []StockItem{{Name: "chicken", Amount: 500, Unit: "g"}, {Name: "rice", Amount: 480, Unit: "ml"},
+		{Name: "egg", Amount: 6}, {Name: "salt"}}
+	if !slices.Equal(stock, want) {
+		t.Errorf("got %+v, want %+v", stock, want)
+	}
+	for _, list := range []string{"chicken:lots", "chicken:2 buckets", "chicken,,rice", "rice:1cup,rice:2cups"} {
+		if _, err := ParseStock(list); err == nil {
+			t.Errorf("ParseStock(%q) succeeded, want an error", list)
+		}
+	}
+}
+
+func TestMinimizeLeftovers(t *testing.T) {
+	stock := []StockItem{{Name: "chicken", Amount: 500, Unit: "g"}, {Name: "rice", Amount: 480, Unit: "ml"},
+		{Name: "salt"}}
+	candidates := []Recipe{
+		{
+			ID:                1,
+			UsedIngredients:   []Ingredient{{Name: "chicken", Amount: 250, Unit: "g"}},
+			MissedIngredients: []Ingredient{{Name: "saffron", Amount: 1, Unit: "g"}},
+		},
+		{ID: 2, UsedIngredients: []Ingredient{{Name: "chicken breast", Amount: 250, Unit: "g"}}},
+		{ID: 3, UsedIngredients: []Ingredient{{Name: "rice", Amount: 1, Unit: "cup"},
+			{Name: "chicken", Amount: 250, Unit: "g"}, {Name: "salt", Amount: 1, Unit: "pinch"}}},
+		{ID: 4, MissedIngredients: []Ingredient{{Name: "tofu", Amount: 200, Unit: "g"}}},
+		{ID: 3},
+	}
+	selected, leftovers := MinimizeLeftovers(candidates, stock, 2)
+
+	var ids []int
+	for _, recipe := range selected {
+		ids = append(ids, recipe.ID)
+	}
+	if !slices.Equal(ids, []int{2, 3}) {
+		t.Errorf("got recipes %v, want 2 and 3, using all the chicken without buying anything", ids)
+	}
+	want := []StockItem{{Name: "chicken", Unit: "g"}, {Name: "rice", Amount: 240, Unit: "ml"}}
+	if !slices.Equal(leftovers, want) {
+		t.Errorf("got leftovers %+v, want %+v", leftovers, want)
+	}
+	if selected, _ := MinimizeLeftovers(candidates, stock, 10); len(selected) != 4 {
+		t.Errorf("got %d recipes, want all 4 different candidates", len(selected))
+	}
+}
+
+// planIDs returns the recipe IDs of each day of the plan.
+func planIDs(plan Plan) [][]int {
+	var days [][]int
+	for _, day := range plan.Days {
+		var ids []int
+		for _, meal := range day.Meals {
+			ids = append(ids, meal.ID)
+		}
+		days = append(days, ids)
+	}
+	return days
+}
+
+func TestPlanDayCalories(t *testing.T) {
+	meal := func(id int, calories float64) Recipe {
+		return Recipe{ID: id, Nutrients: map[string]NutrientAmount{"Calories": {Amount: calories, Unit: "kcal"}}}
+	}
+	candidates := []Recipe{meal(1, 300), meal(2, 900), meal(3, 600), meal(4, 500), meal(5, 700), meal(6, 400)}
+
+	plan, err := ConstrainedPlan(candidates, 3, 2, PlanConstraints{DayCalories: []float64{1500, 2500, 2000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 800, 1500 and 1100 kcal, the most on the day with the most to eat
+	got := planIDs(plan)
+	want := [][]int{{4, 1}, {2, 3}, {5, 6}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("got days %v, want %v", got, want)
+	}
+
+	// Equal days plan as BuildPlan does
+	equal, err := ConstrainedPlan(candidates, 3, 2, PlanConstraints{DayCalories: []float64{2000, 2000, 2000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	built, err := BuildPlan(candidates, 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.EqualFunc(planIDs(equal), planIDs(built), slices.Equal) {
+		t.Errorf("got days %v with equal calories, want %v", planIDs(equal), planIDs(built))
+	}
+
+	_, err = ConstrainedPlan(candidates, 3, 2, PlanConstraints{DayCalories: []float64{2000}})
+	if err == nil {
+		t.Error("got no error for calories of 1 day in a 3-day plan")
+	}
+}
+
+func TestPlanCuisines(t *testing.T) {
+	var candidates []Recipe
+	for id := 1; id <= 15; id++ {
+		recipe := Recipe{ID: id}
+		if id <= 3 {
+			recipe.Cuisines = []string{"italian"}
+		}
+		candidates = append(candidates, recipe)
+	}
+	candidates = append(candidates, Recipe{ID: 20, Cuisines: []string{"thai"}},
+		Recipe{ID: 21, Cuisines: []string{"mexican", "italian"}}, Recipe{ID: 22, Cuisines: []string{"greek"}})
+
+	plan, err := ConstrainedPlan(candidates, 14, 1, PlanConstraints{
+		CuisineLimits: map[string]int{"italian": 1},
+		NewCuisines:   1,
+		KnownCuisines: []string{"italian"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for week := range 2 {
+		italian, fresh := 0, 0
+		for _, day := range plan.Days[7*week : 7*week+7] {
+			for _, meal := range day.Meals {
+				if slices.Contains(meal.Cuisines, "italian") {
+					italian++
+				}
+				if newCuisine(meal, []string{"italian"}) {
+					fresh++
+				}
+			}
+		}
+		// 21 is both, and uses up the italian meal of its week
+		if italian != 1 || fresh != 1 {
+			t.Errorf("got %d italian and %d new meals in week %d, want 1 of each: %v",
+				italian, fresh, week+1, planIDs(plan))
+		}
+	}
+
+	_, err = ConstrainedPlan(candidates[:3], 3, 1, PlanConstraints{CuisineLimits: map[string]int{"italian": 2}})
+	if err == nil {
+		t.Error("got no error for 3 italian recipes with 2 italian meals a week")
+	}
+}
+
+func TestPlanThemes(t *testing.T) {
+	candidates := []Recipe{
+		{ID: 1, Title: "Pasta"}, {ID: 2, Title: "Soup"}, {ID: 3, Title: "Salad"}, {ID: 4, Title: "Stew"},
+		{ID: 5, Title: "Fish Tacos", Cuisines: []string{"mexican"}}, {ID: 6, Title: "Taco Salad"},
+		{ID: 7, Title: "Pizza Margherita"},
+	}
+	tacoTuesday := PlanTheme{Name: "Taco Tuesday", Weekday: time.Tuesday, Cuisine: "Mexican", Dish: "taco"}
+	pizzaFriday := PlanTheme{Name: "Pizza Friday", Weekday: time.Friday, Dish: "pizza"}
+
+	// Monday to Wednesday, so Tuesday is the second day
+	plan, err := ConstrainedPlan(candidates, 3, 2, PlanConstraints{
+		Themes: []PlanTheme{tacoTuesday, pizzaFriday},
+		Start:  time.Monday,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tuesday := plan.Days[1]
+	if tuesday.Theme != "Taco Tuesday" || tuesday.Meals[len(tuesday.Meals)-1].ID != 5 {
+		t.Errorf("got Tuesday %q with meals %v, want Taco Tuesday ending with 5", tuesday.Theme, planIDs(plan)[1])
+	}
+	for _, day := range []int{0, 2} {
+		if plan.Days[day].Theme != "" {
+			t.Errorf("got theme %q on day %d, want none", plan.Days[day].Theme, day+1)
+		}
+	}
+
+	// Without the fish tacos only the taco salad has tacos, and it is not Mexican
+	_, err = ConstrainedPlan(slices.Delete(slices.Clone(candidates), 4, 5), 2, 1, PlanConstraints{
+		Themes: []PlanTheme{tacoTuesday},
+		Start:  time.Tuesday})
+	if err == nil {
+		t.Error("got no error without a recipe for the theme")
+	}
+}
+
+func TestPlanStretch(t *testing.T) {
+	steps := map[int]string{
+		1: "Sauté the onion.", 2: "Braise the beef for two hours.", 3: "Poach the eggs.",
+		4: "Sautéed greens, seasoned.", 5: "Check the temperature.", 6: "Temper the chocolate.",
+	}
+	var candidates []Recipe
+	for id := 1; id <= 10; id++ {
+		candidates = append(candidates, Recipe{ID: id, Instructions: []string{steps[id]}})
+	}
+	known := []string{"sauté"}
+	if got := Techniques(candidates[4]); len(got) != 0 {
+		t.Errorf("got techniques %q for the temperature, want none", got)
+	}
+
+	plan, err := ConstrainedPlan(candidates, 8, 1, PlanConstraints{Stretch: true, KnownTechniques: known})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2 and 3 are stretches, one a week; 6 is left out
+	var stretches []int
+	for index, day := range plan.Days {
+		for _, meal := range day.Meals {
+			if newTechniques(meal, known) {
+				stretches = append(stretches, index/7)
+			}
+		}
+	}
+	if !slices.Equal(stretches, []int{0, 1}) || slices.Contains(slices.Concat(planIDs(plan)...), 6) {
+		t.Errorf("got days %v, want one stretch in each week and no 6", planIDs(plan))
+	}
+}
+
+func TestMealMinute(t *testing.T) {
+	clock := func(plan Plan, meals int) []int {
+		var hours []int
+		for meal := range meals {
+			minute := plan.MealMinute(meal, meals)
+			hours = append(hours, minute/60*100+minute%60)
+		}
+		return hours
+	}
+	fasting := Plan{Window: &EatingWindow{Start: 12 * 60, End: 20 * 60}}
+	for _, test := range []struct {
+		plan  Plan
+		meals int
+		want  []int
+	}{
+		{Plan{}, 1, []int{1900}},
+		{Plan{}, 3, []int{800, 1300, 1900}},
+		{fasting, 1, []int{1900}},
+		{fasting, 2, []int{1200, 1900}},
+		{Plan{Window: &EatingWindow{Start: 11*60 + 30, End: 19 * 60}}, 3, []int{1130, 1430, 1730}},
+	} {
+		if got := clock(test.plan, test.meals); !slices.Equal(got, test.want) {
+			t.Errorf("%d meals in %+v: got %v, want %v", test.meals, test.plan.Window, got, test.want)
+		}
+	}
+
+	_, err := ConstrainedPlan([]Recipe{{ID: 1}}, 1, 1, PlanConstraints{Window: &EatingWindow{Start: 600, End: 630}})
+	if err == nil {
+		t.Error("got no error for a half-hour window")
+	}
+}
+
+func TestReported(t *testing.T) {
+	allRecipes := []Recipe{{ID: 1}, {ID: 2}, {ID: 3}}
+	reported := Reported{Reasons: map[int]string{1: "nutrition looks wrong"}}
+
+	flagged := reported.Apply(allRecipes)
+	if len(flagged) != 3 || flagged[2].ID != 1 || !slices.Equal(flagged[2].Warnings,
+		[]string{"reported: nutrition looks wrong"}) {
+		t.Errorf("got %+v, want recipe 1 last with a warning", flagged)
+	}
+	if len(allRecipes[0].Warnings) != 0 {
+		t.Error("flagging changed the original recipe")
+	}
+	reported.Exclude = true
+	if kept := reported.Apply(allRecipes); len(kept) != 2 || kept[0].ID != 2 {
+		t.Errorf("got %+v, want recipe 1 dropped", kept)
+	}
+}
+
+func TestSourcePolicy(t *testing.T) {
+	allRecipes := []Recipe{
+		{ID: 1, Source: "spoonacular", SourceURL: "https://www.example.com/soup", Author: "Example Kitchen"},
+		{ID: 2, Source: "spoonacular", SourceURL: "https://blog.trusted.org/stew", Author: "Ann"},
+		{ID: 3, Source: "edamam", Author: "Foodista"},
+		{ID: 4, Source: "spoonacular"},
+	}
+	ids := func(kept []Recipe) []int {
+		var ids []int
+		for _, recipe := range kept {
+			ids = append(ids, recipe.ID)
+		}
+		return ids
+	}
+
+	for _, test := range []struct {
+		name   string
+		policy SourcePolicy
+		want   []int
+	}{
+		{"none", SourcePolicy{}, []int{1, 2, 3, 4}},
+		{"recipe", SourcePolicy{BlockedRecipes: []int{4}}, []int{1, 2, 3}},
+		{"domain", SourcePolicy{Blocked: []string{"example.com"}}, []int{2, 3, 4}},
+		{"author", SourcePolicy{Blocked: []string{"foodista"}}, []int{1, 2, 4}},
+		{"provider", SourcePolicy{Blocked: []string{"Edamam"}}, []int{1, 2, 4}},
+		{"allowlist", SourcePolicy{Allowed: []string{"trusted.org", "Foodista"}}, []int{2, 3}},
+		{"blocked wins", SourcePolicy{Allowed: []string{"spoonacular"}, BlockedRecipes: []int{1}}, []int{2, 4}},
+	} {
+		if got := ids(test.policy.Apply(allRecipes)); !slices.Equal(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestEnrich(t *testing.T) {
+	var running, most atomic.Int32
+	fetch := func(ctx context.Context, n int) (int, error) {
+		now := running.Add(1)
+		defer running.Add(-1)
+		for {
+			seen := most.Load()
+			if now <= seen || most.CompareAndSwap(seen, now) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return n * n, nil
+	}
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	results, err := Enrich(context.Background(), items, EnrichLimits{Parallel: 3}, fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(results, []int{1, 4, 9, 16, 25, 36, 49, 64}) {
+		t.Errorf("got %v, want the squares in order", results)
+	}
+	if most.Load() > 3 || most.Load() < 2 {
+		t.Errorf("got %d calls at once, want up to 3", most.Load())
+	}
+
+	start := time.Now()
+	_, err = Enrich(context.Background(), items[:3], EnrichLimits{Interval: 20 * time.Millisecond}, fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("3 calls took %v, want them started 20ms apart", elapsed)
+	}
+
+	calls := atomic.Int32{}
+	_, err = Enrich(context.Background(), items, EnrichLimits{Parallel: 1}, func(ctx context.Context, n int) (int, error) {
+		calls.Add(1)
+		if n == 2 {
+			return 0, errors.New("lookup failed")
+		}
+		return n, nil
+	})
+	if err == nil || calls.Load() > 3 {
+		t.Errorf("got %v after %d calls, want the error and the calls after it skipped", err, calls.Load())
+	}
+}
+
+func TestContentFilter(t *testing.T) {
+	filter, err := NewContentFilter([]string{"Copycat", "brand x"}, []string{`you won't believe`, `^\d+ best`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	allRecipes := []Recipe{
+		{ID: 1, Title: "COPYCAT: Chipotle Bowl"},
+		{ID: 2, Title: "Copycats' Favorite Stew"},
+		{ID: 3, Title: "Brand X Pancakes"},
+		{ID: 4, Title: "The Soup You Won't Believe"},
+		{ID: 5, Title: "10 Best Salads"},
+		{ID: 6, Title: "Leek Soup"},
+	}
+	var kept []int
+	for _, recipe := range filter.Apply(allRecipes) {
+		kept = append(kept, recipe.ID)
+	}
+	if !slices.Equal(kept, []int{2, 6}) {
+		t.Errorf("got %v, want 2 and 6, matching keywords as whole words", kept)
+	}
+	if _, err := NewContentFilter(nil, []string{"(unclosed"}); err == nil {
+		t.Error("got no error for an invalid pattern")
+	}
+}
+
+func TestInterleave(t *testing.T) {
+	a := []Recipe{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}
+	b := []Recipe{{ID: 3}, {ID: 1}, {ID: 4}, {ID: 2}}
+	for seed := range uint64(20) {
+		merged, picked := Interleave(a, b, rand.New(rand.NewPCG(seed, seed)))
+		var ids []int
+		for _, recipe := range merged {
+			ids = append(ids, recipe.ID)
+		}
+		sorted := slices.Clone(ids)
+		slices.Sort(sorted)
+		if !slices.Equal(sorted, []int{1, 2, 3, 4}) {
+			t.Fatalf("seed %d: got %v, want every recipe once", seed, ids)
+		}
+		if len(picked) != 4 {
+			t.Fatalf("seed %d: got %v, want a strategy for every recipe", seed, picked)
+		}
+		// Both rankings place their best recipe in the first two
+		if !slices.Contains(ids[:2], 1) || !slices.Contains(ids[:2], 3) {
+			t.Errorf("seed %d: got %v, want 1 and 3 first", seed, ids)
+		}
+		counts := map[string]int{}
+		for _, strategy := range picked {
+			counts[strategy]++
+		}
+		if counts[StrategyA] != 2 || counts[StrategyB] != 2 {
+			t.Errorf("seed %d: got %v, want each strategy to pick 2", seed, counts)
+		}
+	}
+}
+
+func TestLearnPreferences(t *testing.T) {
+	quick := func(id int, minutes int, ingredients ...string) Recipe {
+		recipe := Recipe{ID: id, ReadyInMinutes: minutes}
+		for _, name := range ingredients {
+			recipe.UsedIngredients = append(recipe.UsedIngredients, Ingredient{Name: name})
+		}
+		return recipe
+	}
+	answers := []QuizAnswer{
+		{Chosen: quick(1, 15, "rice", "eggs"), Rejected: quick(2, 90, "mushrooms", "rice")},
+		{Chosen: quick(3, 20, "chicken"), Rejected: quick(4, 60, "Mushroom", "olives")},
+		{Chosen: quick(5, 10, "pasta"), Rejected: quick(6, 45, "olives")},
+	}
+	ranking, disliked := LearnPreferences(answers, DefaultRankWeights)
+	if ranking.Time != 2*DefaultRankWeights.Time {
+		t.Errorf("got time weight %v, want it doubled as the quicker recipe always won", ranking.Time)
+	}
+	if ranking.Missing != DefaultRankWeights.Missing || ranking.Protein != DefaultRankWeights.Protein {
+		t.Errorf("got %+v, want the weights the answers say nothing about kept", ranking)
+	}
+	if !slices.Equal(disliked, []string{"mushroom", "olive"}) {
+		t.Errorf("got disliked %v, want mushroom and olive", disliked)
+	}
+}
+
+// upperTranslator translates every name by upper-casing it.
+type upperTranslator struct{ got []string }
+
+func (u *upperTranslator) Translate(ctx context.Context, lang string, names []string) ([]string, error) {
+	u.got = append(u.got, names...)
+	translated := make([]string, len(names))
+	for i, name := range names {
+		translated[i] = strings.ToUpper(name)
+	}
+	return translated, nil
+}
+
+func TestTranslate(t *testing.T) {
+	names := []string{"Jajka", "kurczak", "rukola"}
+	got, err := Dictionaries.Translate(context.Background(), "pl", names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []string{"eggs", "chicken", "rukola"}) {
+		t.Errorf("got %v, want the unknown name kept", got)
+	}
+
+	fallback := &upperTranslator{}
+	got, err = Chain{Dictionaries, fallback}.Translate(context.Background(), "pl", names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []string{"eggs", "chicken", "RUKOLA"}) || !slices.Equal(fallback.got, []string{"rukola"}) {
+		t.Errorf("got %v with %v passed on, want only the name the dictionary lacks passed on", got, fallback.got)
+	}
+}
+
+func TestPairingFoods(t *testing.T) {
+	recipe := Recipe{
+		UsedIngredients:   []Ingredient{{Name: "Penne Pasta"}, {Name: "salt"}, {Name: "mystery sauce"}},
+		MissedIngredients: []Ingredient{{Name: "chicken breasts"}, {Name: "parmesan"}, {Name: "pasta"}},
+	}
+	if got := PairingFoods(recipe, 3); !slices.Equal(got, []string{"chicken breast", "penne", "parmesan"}) {
+		t.Errorf("got %v, want the meat first, then the other known ingredients once each", got)
+	}
+	if got := PairingFoods(recipe, 1); !slices.Equal(got, []string{"chicken breast"}) {
+		t.Errorf("got %v, want only the best food", got)
+	}
+
+	pairing := Pairing{Wines: []string{"merlot", "malbec"}, Note: "Beef loves a  red. Merlot is soft."}
+	if got := pairing.String(); got != "merlot, malbec - Beef loves a red." {
+		t.Errorf("got %q, want the wines and the note's first sentence", got)
+	}
+}
@@ -0,0 +1,86 @@
+package recipes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var porkProducts = []string{
+	"pork", "bacon", "ham", "lard", "prosciutto", "pancetta", "chorizo", "salami", "pepperoni",
+	"guanciale", "speck", "pork rinds", "sausage casing",
+}
+
+var alcoholicDrinks = []string{
+	"wine", "beer", "ale", "lager", "stout", "rum", "vodka", "whiskey", "whisky", "bourbon", "brandy",
+	"cognac", "gin", "tequila", "sake", "mirin", "sherry", "vermouth", "liqueur", "kahlua", "marsala",
+	"champagne", "prosecco", "cider",
+}
+
+var shellfish = []string{
+	"shrimp", "prawn", "prawns", "crab", "lobster", "crawfish", "crayfish", "clam", "clams", "mussel",
+	"mussels", "oyster", "oysters", "scallop", "scallops", "squid", "calamari", "octopus",
+}
+
+var meats = []string{
+	"beef", "chicken", "lamb", "veal", "turkey", "duck", "goose", "mutton", "steak", "brisket",
+	"ground meat", "meatballs",
+}
+
+var dairyProducts = []string{
+	"milk", "cream", "cheese", "yogurt", "yoghurt", "sour cream", "ghee", "parmesan", "mozzarella",
+	"cheddar", "ricotta", "mascarpone", "feta", "buttermilk",
+}
+
+// ReligiousDiets holds the curated rule sets selectable with
+// --religious-diet. They are conservative: anything a common interpretation
+// forbids is excluded, and animal-derived ingredients of unknown origin are
+// flagged rather than trusted.
+var ReligiousDiets = map[string]RuleSet{
+	"halal": {
+		Name:     "halal",
+		Excluded: append(append([]string{"blood", "blood sausage"}, porkProducts...), alcoholicDrinks...),
+		Allowed:  []string{"wine vinegar", "cider vinegar", "ginger ale", "root beer"},
+		Flagged: map[string]string{
+			"gelatin":         "may be pork-derived, use a halal-certified or agar alternative",
+			"vanilla extract": "contains alcohol, use vanilla powder or alcohol-free extract",
+			"marshmallows":    "usually contain pork gelatin",
+			"rennet":          "may be animal-derived",
+			"beef":            "must be from a halal source",
+			"chicken":         "must be from a halal source",
+			"lamb":            "must be from a halal source",
+			"broth":           "check the meat source",
+			"stock":           "check the meat source",
+		},
+	},
+	"kosher": {
+		Name:     "kosher",
+		Excluded: append(append([]string{"rabbit", "catfish", "eel", "shark", "blood"}, porkProducts...), shellfish...),
+		Flagged: map[string]string{
+			"gelatin":      "may be non-kosher, use a kosher-certified or agar alternative",
+			"marshmallows": "usually contain non-kosher gelatin",
+			"rennet":       "may be animal-derived",
+			"wine":         "must be kosher wine",
+			"cheese":       "must be kosher-certified",
+			"broth":        "check the meat source",
+			"stock":        "check the meat source",
+		},
+		Conflicts: []Conflict{
+			{First: meats, Second: dairyProducts, Reason: "mix meat and dairy"},
+		},
+	},
+}
+
+// LookupReligiousDiet returns the rule set for a --religious-diet value.
+func LookupReligiousDiet(name string) (RuleSet, error) {
+	ruleSet, ok := ReligiousDiets[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		names := make([]string, 0, len(ReligiousDiets))
+		for known := range ReligiousDiets {
+			names = append(names, known)
+		}
+		sort.Strings(names)
+		return RuleSet{}, fmt.Errorf("unknown religious diet %q (known: %s)", name, strings.Join(names, ", "))
+	}
+	return ruleSet, nil
+}
@@ -0,0 +1,29 @@
+package recipes
+
+import (
+	"slices"
+	"strings"
+)
+
+// HaveFirst orders the replacements of a missing ingredient so the ones
+// naming an ingredient the user has come first, keeping the provider's order
+// otherwise. The replacements are free text, so an ingredient is named when
+// it appears anywhere in one.
+func HaveFirst(replacements []string, have []string) []string {
+	ordered := slices.Clone(replacements)
+	slices.SortStableFunc(ordered, func(a, b string) int {
+		return haveRank(b, have) - haveRank(a, have)
+	})
+	return ordered
+}
+
+func haveRank(replacement string, have []string) int {
+	replacement = strings.ToLower(replacement)
+	for _, ingredient := range have {
+		if ingredient = strings.ToLower(strings.TrimSpace(ingredient)); ingredient != "" &&
+			strings.Contains(replacement, ingredient) {
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,40 @@
+package recipes
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Reported describes the recipes the user reported to have wrong data.
+type Reported struct {
+	// Reasons maps the ID of a reported recipe to what was reported, the
+	// latest report when there were several.
+	Reasons map[int]string
+	// Exclude drops the reported recipes. Otherwise they are kept, with a
+	// warning, but moved after the other recipes.
+	Exclude bool
+}
+
+// Apply filters or reorders recipes according to the reports.
+func (r Reported) Apply(allRecipes []Recipe) []Recipe {
+	if len(r.Reasons) == 0 {
+		return allRecipes
+	}
+
+	unreported := make([]Recipe, 0, len(allRecipes))
+	var reported []Recipe
+	for _, recipe := range allRecipes {
+		reason, ok := r.Reasons[recipe.ID]
+		if !ok {
+			unreported = append(unreported, recipe)
+			continue
+		}
+		recipe.Warnings = append(slices.Clip(recipe.Warnings), fmt.Sprintf("reported: %s", reason))
+		reported = append(reported, recipe)
+	}
+
+	if r.Exclude {
+		return unreported
+	}
+	return append(unreported, reported...)
+}
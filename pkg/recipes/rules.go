@@ -0,0 +1,174 @@
+package recipes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RuleSet screens recipes against ingredient rules the API cannot filter on.
+// Ingredient names in rules match recipe ingredients containing them as whole
+// words, so "pork" matches "ground pork".
+type RuleSet struct {
+	Name string
+	// Excluded ingredients make a recipe unacceptable.
+	Excluded []string
+	// Allowed ingredients are never excluded or flagged even when they contain
+	// a listed name, such as "wine vinegar" next to "wine".
+	Allowed []string
+	// Flagged ingredients map to a note. Recipes using them are kept with a
+	// warning built from the note, such as an ingredient whose source decides
+	// whether it is acceptable or a substitute for it.
+	Flagged map[string]string
+	// Conflicts warn about recipes combining ingredients from both sides.
+	Conflicts []Conflict
+	// Limits warn about recipes using more of an ingredient per serving than
+	// the limit allows.
+	Limits []Limit
+}
+
+// Limit is the largest acceptable amount of an ingredient per serving.
+// MaxGrams of zero means any amount is too much.
+type Limit struct {
+	Ingredient string
+	MaxGrams   float64
+	Reason     string
+}
+
+// Conflict is a pair of ingredient groups that may not be combined.
+type Conflict struct {
+	First  []string
+	Second []string
+	Reason string
+}
+
+// Apply drops the recipes using an excluded ingredient and adds warnings to
+// the ones using flagged or conflicting ingredients.
+func (r RuleSet) Apply(allRecipes []Recipe) []Recipe {
+	kept := make([]Recipe, 0, len(allRecipes))
+	for _, recipe := range allRecipes {
+		names := recipe.allIngredientNames()
+		if r.excludes(names) {
+			continue
+		}
+		recipe.Warnings = append(recipe.Warnings, r.warnings(names)...)
+		recipe.Warnings = append(recipe.Warnings, r.limitWarnings(recipe)...)
+		kept = append(kept, recipe)
+	}
+	return kept
+}
+
+func (r RuleSet) excludes(names []string) bool {
+	for _, name := range names {
+		if matchesAny(name, r.Allowed) {
+			continue
+		}
+		if matchesAny(name, r.Excluded) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r RuleSet) warnings(names []string) []string {
+	var warnings []string
+
+	flagged := make([]string, 0, len(r.Flagged))
+	for ingredient := range r.Flagged {
+		flagged = append(flagged, ingredient)
+	}
+	sort.Strings(flagged)
+	for _, ingredient := range flagged {
+		for _, name := range names {
+			if containsIngredient(name, ingredient) && !matchesAny(name, r.Allowed) {
+				warnings = append(warnings, fmt.Sprintf("%s: %s %s", r.Name, name, r.Flagged[ingredient]))
+				break
+			}
+		}
+	}
+
+	for _, conflict := range r.Conflicts {
+		first := firstMatch(names, conflict.First)
+		second := firstMatch(names, conflict.Second)
+		if first != "" && second != "" {
+			warnings = append(warnings, fmt.Sprintf("%s: %s and %s %s", r.Name, first, second, conflict.Reason))
+		}
+	}
+	return warnings
+}
+
+// limitWarnings checks the amounts of the recipe's ingredients against the
+// limits. Ingredients whose amount is unknown, or given in units that cannot
+// be converted to grams, are flagged so the user can check the serving size.
+func (r RuleSet) limitWarnings(recipe Recipe) []string {
+	var warnings []string
+	servings := float64(recipe.Servings)
+	if servings <= 0 {
+		servings = 1
+	}
+	for _, limit := range r.Limits {
+		ingredients := make([]Ingredient, 0, len(recipe.UsedIngredients)+len(recipe.MissedIngredients))
+		ingredients = append(ingredients, recipe.UsedIngredients...)
+		ingredients = append(ingredients, recipe.MissedIngredients...)
+		for _, ingredient := range ingredients {
+			name := strings.ToLower(ingredient.Name)
+			if !containsIngredient(name, limit.Ingredient) || matchesAny(name, r.Allowed) {
+				continue
+			}
+			if limit.MaxGrams == 0 {
+				warnings = append(warnings, fmt.Sprintf("%s: %s is high in %s at any amount", r.Name, name, limit.Reason))
+				break
+			}
+			grams, ok := ingredient.grams()
+			if !ok {
+				warnings = append(warnings, fmt.Sprintf("%s: %s is high in %s above %.0f g per serving, check the amount",
+					r.Name, name, limit.Reason, limit.MaxGrams))
+				break
+			}
+			if grams/servings > limit.MaxGrams {
+				warnings = append(warnings, fmt.Sprintf("%s: %.0f g of %s per serving is above the %.0f g limit (%s)",
+					r.Name, grams/servings, name, limit.MaxGrams, limit.Reason))
+				break
+			}
+		}
+	}
+	return warnings
+}
+
+// allIngredientNames returns the lowercased names of every ingredient of the
+// recipe, the ones the user has and the ones they are missing.
+func (r Recipe) allIngredientNames() []string {
+	names := make([]string, 0, len(r.UsedIngredients)+len(r.MissedIngredients))
+	for _, ingredient := range r.UsedIngredients {
+		names = append(names, strings.ToLower(ingredient.Name))
+	}
+	for _, ingredient := range r.MissedIngredients {
+		names = append(names, strings.ToLower(ingredient.Name))
+	}
+	return names
+}
+
+func matchesAny(name string, ingredients []string) bool {
+	for _, ingredient := range ingredients {
+		if containsWords(name, ingredient) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsIngredient is containsWords that also accepts the plural of the
+// ingredient, so "onion" matches "red onions".
+func containsIngredient(name string, ingredient string) bool {
+	return containsWords(name, ingredient) || containsWords(name, ingredient+"s") ||
+		containsWords(name, ingredient+"es")
+}
+
+func firstMatch(names []string, ingredients []string) string {
+	for _, name := range names {
+		if matchesAny(name, ingredients) {
+			return name
+		}
+	}
+	return ""
+}
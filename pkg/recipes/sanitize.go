@@ -0,0 +1,71 @@
+package recipes
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// unicodeFractions maps the vulgar fraction characters to plain text.
+var unicodeFractions = map[rune]string{
+	'½': "1/2", '⅓': "1/3", '⅔': "2/3", '¼': "1/4", '¾': "3/4",
+	'⅕': "1/5", '⅖': "2/5", '⅗': "3/5", '⅘': "4/5", '⅙': "1/6",
+	'⅚': "5/6", '⅛': "1/8", '⅜': "3/8", '⅝': "5/8", '⅞': "7/8",
+}
+
+// SanitizeText turns text from the API into plain text: HTML tags are
+// removed, entities decoded, fractions such as "1½" written as "1 1/2" and
+// whitespace collapsed to single spaces.
+func SanitizeText(text string) string {
+	text = html.UnescapeString(htmlTag.ReplaceAllString(text, " "))
+
+	var builder strings.Builder
+	var previous rune
+	for _, r := range text {
+		switch {
+		case unicodeFractions[r] != "":
+			if unicode.IsDigit(previous) {
+				builder.WriteByte(' ')
+			}
+			builder.WriteString(unicodeFractions[r])
+		case r == '⁄':
+			builder.WriteByte('/')
+		default:
+			builder.WriteRune(r)
+		}
+		previous = r
+	}
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
+// Sanitize applies SanitizeText to the title, ingredient names and
+// instructions of the recipe. Instructions left empty are dropped.
+func Sanitize(recipe Recipe) Recipe {
+	recipe.Title = SanitizeText(recipe.Title)
+	recipe.UsedIngredients = sanitizeIngredients(recipe.UsedIngredients)
+	recipe.MissedIngredients = sanitizeIngredients(recipe.MissedIngredients)
+
+	var instructions []string
+	for _, step := range recipe.Instructions {
+		if step = SanitizeText(step); step != "" {
+			instructions = append(instructions, step)
+		}
+	}
+	recipe.Instructions = instructions
+	return recipe
+}
+
+func sanitizeIngredients(ingredients []Ingredient) []Ingredient {
+	if ingredients == nil {
+		return nil
+	}
+	sanitized := make([]Ingredient, len(ingredients))
+	for i, ingredient := range ingredients {
+		ingredient.Name = SanitizeText(ingredient.Name)
+		sanitized[i] = ingredient
+	}
+	return sanitized
+}
@@ -0,0 +1,56 @@
+package recipes
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ScaleServings scales the ingredient amounts of the recipes to make the
+// given servings, keeping the servings they were given for in ScaledFrom.
+// Nutrients stay per serving, see TotalNutrient. A recipe whose servings are
+// unknown cannot be scaled and gets a warning instead. Zero servings leave
+// the recipes as they are.
+func ScaleServings(allRecipes []Recipe, servings int) []Recipe {
+	if servings <= 0 {
+		return allRecipes
+	}
+	scaled := make([]Recipe, len(allRecipes))
+	for i, recipe := range allRecipes {
+		switch {
+		case recipe.Servings <= 0:
+			recipe.Warnings = append(slices.Clip(recipe.Warnings),
+				fmt.Sprintf("amounts not scaled to %d servings, the source does not say how many it makes", servings))
+		case recipe.Servings != servings:
+			factor := float64(servings) / float64(recipe.Servings)
+			recipe.UsedIngredients = scaleIngredients(recipe.UsedIngredients, factor)
+			recipe.MissedIngredients = scaleIngredients(recipe.MissedIngredients, factor)
+			recipe.ScaledFrom = recipe.Servings
+			recipe.Servings = servings
+		}
+		scaled[i] = recipe
+	}
+	return scaled
+}
+
+func scaleIngredients(ingredients []Ingredient, factor float64) []Ingredient {
+	if ingredients == nil {
+		return nil
+	}
+	scaled := make([]Ingredient, len(ingredients))
+	for i, ingredient := range ingredients {
+		ingredient.Amount *= factor
+		scaled[i] = ingredient
+	}
+	return scaled
+}
+
+// TotalNutrient returns the amount of a nutrient in all the servings of the
+// recipe, false when the nutrient or the servings are unknown. Nutrients are
+// per serving, so this is what the recipe makes as a whole.
+func (r Recipe) TotalNutrient(name string) (NutrientAmount, bool) {
+	nutrient, ok := r.Nutrients[name]
+	if !ok || r.Servings <= 0 {
+		return NutrientAmount{}, false
+	}
+	return NutrientAmount{Amount: nutrient.Amount * float64(r.Servings), Unit: nutrient.Unit}, true
+}
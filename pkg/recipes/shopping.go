@@ -0,0 +1,159 @@
+package recipes
+
+import (
+	"sort"
+	"strings"
+)
+
+// ShoppingItem is one line of a shopping list. Amount is zero when no recipe
+// gave an amount for the ingredient, as for recipes read back from the cache.
+type ShoppingItem struct {
+	Name    string   `json:"name"`
+	Amount  float64  `json:"amount,omitempty"`
+	Unit    string   `json:"unit,omitempty"`
+	Recipes []string `json:"recipes"`
+	// Aisle is where the ingredient is found in a shop, see Aisle, empty
+	// when the taxonomy does not know it.
+	Aisle string `json:"aisle,omitempty"`
+}
+
+// unitConversion converts a unit to the base unit of its dimension.
+type unitConversion struct {
+	base   string
+	factor float64
+}
+
+var unitConversions = map[string]unitConversion{
+	"g":            {base: "g", factor: 1},
+	"gram":         {base: "g", factor: 1},
+	"grams":        {base: "g", factor: 1},
+	"kg":           {base: "g", factor: 1000},
+	"oz":           {base: "g", factor: 28.35},
+	"ounce":        {base: "g", factor: 28.35},
+	"ounces":       {base: "g", factor: 28.35},
+	"lb":           {base: "g", factor: 453.6},
+	"lbs":          {base: "g", factor: 453.6},
+	"pound":        {base: "g", factor: 453.6},
+	"pounds":       {base: "g", factor: 453.6},
+	"ml":           {base: "ml", factor: 1},
+	"milliliter":   {base: "ml", factor: 1},
+	"milliliters":  {base: "ml", factor: 1},
+	"l":            {base: "ml", factor: 1000},
+	"liter":        {base: "ml", factor: 1000},
+	"liters":       {base: "ml", factor: 1000},
+	"tsp":          {base: "ml", factor: 4.93},
+	"teaspoon":     {base: "ml", factor: 4.93},
+	"teaspoons":    {base: "ml", factor: 4.93},
+	"tbsp":         {base: "ml", factor: 14.79},
+	"tablespoon":   {base: "ml", factor: 14.79},
+	"tablespoons":  {base: "ml", factor: 14.79},
+	"cup":          {base: "ml", factor: 240},
+	"cups":         {base: "ml", factor: 240},
+	"fl oz":        {base: "ml", factor: 29.57},
+	"pint":         {base: "ml", factor: 473},
+	"pints":        {base: "ml", factor: 473},
+	"":             {base: "", factor: 1},
+	"piece":        {base: "", factor: 1},
+	"pieces":       {base: "", factor: 1},
+	"serving":      {base: "", factor: 1},
+	"servings":     {base: "", factor: 1},
+	"large":        {base: "", factor: 1},
+	"medium":       {base: "", factor: 1},
+	"small":        {base: "", factor: 1},
+	"whole":        {base: "", factor: 1},
+	"clove":        {base: "cloves", factor: 1},
+	"cloves":       {base: "cloves", factor: 1},
+	"slice":        {base: "slices", factor: 1},
+	"slices":       {base: "slices", factor: 1},
+	"can":          {base: "cans", factor: 1},
+	"cans":         {base: "cans", factor: 1},
+	"pinch":        {base: "pinches", factor: 1},
+	"pinches":      {base: "pinches", factor: 1},
+	"handful":      {base: "handfuls", factor: 1},
+	"handfuls":     {base: "handfuls", factor: 1},
+	"bunch":        {base: "bunches", factor: 1},
+	"bunches":      {base: "bunches", factor: 1},
+	"package":      {base: "packages", factor: 1},
+	"packages":     {base: "packages", factor: 1},
+	"fluid ounce":  {base: "ml", factor: 29.57},
+	"fluid ounces": {base: "ml", factor: 29.57},
+}
+
+// IsUnit reports whether word is a unit the shopping list knows, such as "g",
+// "cups" or "cloves".
+func IsUnit(word string) bool {
+	word = strings.ToLower(strings.TrimSpace(word))
+	_, ok := unitConversions[word]
+	return ok && word != ""
+}
+
+// grams returns the amount of the ingredient in grams, counting a milliliter as
+// a gram, or false when the amount is unknown or not a weight or volume.
+func (i Ingredient) grams() (float64, bool) {
+	conversion, ok := unitConversions[strings.ToLower(strings.TrimSpace(i.Unit))]
+	if i.Amount == 0 || !ok || conversion.base != "g" && conversion.base != "ml" {
+		return 0, false
+	}
+	return i.Amount * conversion.factor, true
+}
+
+// ShoppingList merges the missing ingredients of the recipes into one list.
+// Amounts of the same ingredient are converted to grams, milliliters or a
+// count and summed; an ingredient measured in units that cannot be converted
+// into each other gets a line per unit. Items are sorted by aisle, the ones
+// in no known aisle last, then by name.
+func ShoppingList(allRecipes []Recipe) []ShoppingItem {
+	type key struct {
+		name string
+		unit string
+	}
+	items := make(map[key]*ShoppingItem)
+
+	for _, recipe := range allRecipes {
+		for _, ingredient := range recipe.MissedIngredients {
+			name := strings.ToLower(strings.TrimSpace(ingredient.Name))
+			unit := strings.ToLower(strings.TrimSpace(ingredient.Unit))
+			amount := ingredient.Amount
+			if conversion, ok := unitConversions[unit]; ok {
+				unit = conversion.base
+				amount *= conversion.factor
+			}
+
+			item, ok := items[key{name: name, unit: unit}]
+			if !ok {
+				item = &ShoppingItem{Name: name, Unit: unit, Aisle: Aisle(name)}
+				items[key{name: name, unit: unit}] = item
+			}
+			item.Amount += amount
+			if len(item.Recipes) == 0 || item.Recipes[len(item.Recipes)-1] != recipe.Title {
+				item.Recipes = append(item.Recipes, recipe.Title)
+			}
+		}
+	}
+
+	list := make([]ShoppingItem, 0, len(items))
+	for _, item := range items {
+		switch {
+		case item.Unit == "g" && item.Amount >= 1000:
+			item.Unit = "kg"
+			item.Amount /= 1000
+		case item.Unit == "ml" && item.Amount >= 1000:
+			item.Unit = "l"
+			item.Amount /= 1000
+		}
+		if item.Amount == 0 {
+			item.Unit = ""
+		}
+		list = append(list, *item)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Aisle != list[j].Aisle {
+			return list[j].Aisle == "" || list[i].Aisle != "" && list[i].Aisle < list[j].Aisle
+		}
+		if list[i].Name != list[j].Name {
+			return list[i].Name < list[j].Name
+		}
+		return list[i].Unit < list[j].Unit
+	})
+	return list
+}
@@ -0,0 +1,66 @@
+package recipes
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// SourcePolicy is where recipes may come from. A source names a provider,
+// such as edamam, the site of recipe pages, such as example.com and its
+// subdomains, or an author, see Recipe.Author, ignoring case. The zero
+// SourcePolicy allows every recipe.
+type SourcePolicy struct {
+	// BlockedRecipes are the IDs of recipes never shown.
+	BlockedRecipes []int
+	// Blocked are the sources whose recipes are never shown.
+	Blocked []string
+	// Allowed, when not empty, are the only sources whose recipes are
+	// shown. A recipe matching none of them, such as one whose site and
+	// author are unknown, is left out.
+	Allowed []string
+}
+
+// Apply leaves out the recipes the policy does not allow.
+func (p SourcePolicy) Apply(allRecipes []Recipe) []Recipe {
+	if len(p.BlockedRecipes) == 0 && len(p.Blocked) == 0 && len(p.Allowed) == 0 {
+		return allRecipes
+	}
+	allowed := make([]Recipe, 0, len(allRecipes))
+	for _, recipe := range allRecipes {
+		if p.Allows(recipe) {
+			allowed = append(allowed, recipe)
+		}
+	}
+	return allowed
+}
+
+// Allows reports whether the recipe may be shown.
+func (p SourcePolicy) Allows(recipe Recipe) bool {
+	if slices.Contains(p.BlockedRecipes, recipe.ID) {
+		return false
+	}
+	if slices.ContainsFunc(p.Blocked, func(source string) bool { return fromSource(recipe, source) }) {
+		return false
+	}
+	return len(p.Allowed) == 0 ||
+		slices.ContainsFunc(p.Allowed, func(source string) bool { return fromSource(recipe, source) })
+}
+
+// fromSource reports whether the recipe comes from the provider, site or
+// author.
+func fromSource(recipe Recipe, source string) bool {
+	source = strings.ToLower(strings.TrimSpace(source))
+	if source == "" {
+		return false
+	}
+	if strings.EqualFold(recipe.Source, source) || strings.EqualFold(recipe.Author, source) {
+		return true
+	}
+	page, err := url.Parse(recipe.SourceURL)
+	if err != nil || page.Hostname() == "" {
+		return false
+	}
+	host := strings.TrimPrefix(strings.ToLower(page.Hostname()), "www.")
+	return host == source || strings.HasSuffix(host, "."+source)
+}
@@ -0,0 +1,63 @@
+package recipes
+
+import (
+	"sort"
+	"strings"
+)
+
+// Relaxation is a suggested change to a query that found nothing: searching
+// without Removed matches Matches cached recipes.
+type Relaxation struct {
+	Removed string
+	Matches int
+}
+
+// SuggestRelaxations tries dropping each ingredient of a query in turn and
+// counts the recipes in recipeIngredients (ingredient names by recipe ID)
+// that contain all the remaining ones. Relaxations matching nothing are left
+// out; the rest are ordered by most matches first.
+func SuggestRelaxations(recipeIngredients map[int][]string, ingredientList []string) []Relaxation {
+	if len(ingredientList) < 2 {
+		return nil
+	}
+
+	var relaxations []Relaxation
+	for index, removed := range ingredientList {
+		remaining := make([]string, 0, len(ingredientList)-1)
+		remaining = append(remaining, ingredientList[:index]...)
+		remaining = append(remaining, ingredientList[index+1:]...)
+
+		matches := 0
+		for _, names := range recipeIngredients {
+			if containsAllIngredients(names, remaining) {
+				matches++
+			}
+		}
+		if matches > 0 {
+			relaxations = append(relaxations, Relaxation{Removed: removed, Matches: matches})
+		}
+	}
+
+	sort.SliceStable(relaxations, func(i, j int) bool {
+		return relaxations[i].Matches > relaxations[j].Matches
+	})
+	return relaxations
+}
+
+// containsAllIngredients reports whether every wanted ingredient appears in
+// the recipe's ingredient names, "chicken" matching "chicken breast".
+func containsAllIngredients(recipeIngredientNames []string, wanted []string) bool {
+	for _, ingredient := range wanted {
+		found := false
+		for _, name := range recipeIngredientNames {
+			if containsWords(strings.ToLower(name), ingredient) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
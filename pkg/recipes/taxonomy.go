@@ -0,0 +1,331 @@
+package recipes
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TaxonomyEntry places an ingredient in the taxonomy. Parent is the more
+// general ingredient it is a kind of, such as "cheese" for "cheddar", and
+// Category the shop aisle it is found in. Season lists the months it is in
+// season in, in the northern hemisphere, and PricePerKg is a rough average
+// price in US dollars. A kind without a category, season or price of its own
+// has its parent's.
+type TaxonomyEntry struct {
+	Name       string       `yaml:"name" json:"name"`
+	Parent     string       `yaml:"parent" json:"parent,omitempty"`
+	Category   string       `yaml:"category" json:"category,omitempty"`
+	Season     []time.Month `yaml:"season" json:"season,omitempty"`
+	PricePerKg float64      `yaml:"pricePerKg" json:"pricePerKg,omitempty"`
+}
+
+// taxonomyEntry is what the taxonomy knows of a normalized ingredient name.
+type taxonomyEntry struct {
+	parent     string
+	category   string
+	season     []time.Month
+	pricePerKg float64
+}
+
+// taxonomy holds the built-in entries, extended with ExtendTaxonomy, keyed by
+// normalized name.
+var taxonomy = map[string]taxonomyEntry{}
+
+// defaultTaxonomy is the built-in taxonomy: the common kinds of ingredients
+// recipes name and the aisles of the general ingredients.
+var defaultTaxonomy = []TaxonomyEntry{
+	{Name: "dairy", Category: "dairy"},
+	{Name: "cheese", Parent: "dairy", PricePerKg: 11},
+	{Name: "cheddar", Parent: "cheese", PricePerKg: 12},
+	{Name: "mozzarella", Parent: "cheese", PricePerKg: 10},
+	{Name: "parmesan", Parent: "cheese", PricePerKg: 25},
+	{Name: "feta", Parent: "cheese", PricePerKg: 13},
+	{Name: "gouda", Parent: "cheese"},
+	{Name: "ricotta", Parent: "cheese"},
+	{Name: "goat cheese", Parent: "cheese"},
+	{Name: "milk", Parent: "dairy", PricePerKg: 1},
+	{Name: "butter", Parent: "dairy", PricePerKg: 10},
+	{Name: "yogurt", Parent: "dairy", PricePerKg: 4},
+	{Name: "cream", Parent: "dairy", PricePerKg: 6},
+	{Name: "heavy cream", Parent: "cream"},
+	{Name: "sour cream", Parent: "cream"},
+	{Name: "egg", Category: "dairy", PricePerKg: 5},
+
+	{Name: "meat", Category: "meat and fish"},
+	{Name: "poultry", Parent: "meat"},
+	{Name: "chicken", Parent: "poultry", PricePerKg: 7},
+	{Name: "chicken breast", Parent: "chicken", PricePerKg: 9},
+	{Name: "chicken thigh", Parent: "chicken"},
+	{Name: "turkey", Parent: "poultry", PricePerKg: 8},
+	{Name: "beef", Parent: "meat", PricePerKg: 12},
+	{Name: "ground beef", Parent: "beef", PricePerKg: 10},
+	{Name: "steak", Parent: "beef", PricePerKg: 22},
+	{Name: "pork", Parent: "meat", PricePerKg: 8},
+	{Name: "ground pork", Parent: "pork"},
+	{Name: "bacon", Parent: "pork", PricePerKg: 14},
+	{Name: "ham", Parent: "pork", PricePerKg: 11},
+	{Name: "sausage", Parent: "meat", PricePerKg: 10},
+	{Name: "lamb", Parent: "meat", PricePerKg: 17},
+	{Name: "fish", Category: "meat and fish", PricePerKg: 15},
+	{Name: "salmon", Parent: "fish", PricePerKg: 20},
+	{Name: "tuna", Parent: "fish", PricePerKg: 14},
+	{Name: "cod", Parent: "fish", PricePerKg: 17},
+	{Name: "seafood", Category: "meat and fish"},
+	{Name: "shrimp", Parent: "seafood", PricePerKg: 18},
+
+	{Name: "vegetable", Category: "produce", PricePerKg: 3},
+	{Name: "onion", Parent: "vegetable", PricePerKg: 2},
+	{Name: "red onion", Parent: "onion"},
+	{Name: "green onion", Parent: "onion", Season: []time.Month{time.April, time.May, time.June, time.July, time.August, time.September}},
+	{Name: "shallot", Parent: "onion"},
+	{Name: "garlic", Parent: "vegetable", PricePerKg: 9},
+	{Name: "tomato", Parent: "vegetable", Season: []time.Month{time.June, time.July, time.August, time.September}, PricePerKg: 4.5},
+	{Name: "potato", Parent: "vegetable", Season: []time.Month{time.July, time.August, time.September, time.October}, PricePerKg: 2},
+	{Name: "sweet potato", Parent: "vegetable", Season: []time.Month{time.September, time.October, time.November, time.December}, PricePerKg: 3},
+	{Name: "carrot", Parent: "vegetable", Season: []time.Month{time.July, time.August, time.September, time.October, time.November}, PricePerKg: 2},
+	{Name: "bell pepper", Parent: "vegetable", Season: []time.Month{time.July, time.August, time.September}, PricePerKg: 6},
+	{Name: "leafy green", Parent: "vegetable"},
+	{Name: "spinach", Parent: "leafy green", Season: []time.Month{time.March, time.April, time.May, time.September, time.October}, PricePerKg: 9},
+	{Name: "kale", Parent: "leafy green", Season: []time.Month{time.October, time.November, time.December, time.January, time.February, time.March}, PricePerKg: 8},
+	{Name: "lettuce", Parent: "leafy green", Season: []time.Month{time.May, time.June, time.July, time.August, time.September}, PricePerKg: 5},
+	{Name: "arugula", Parent: "leafy green", Season: []time.Month{time.April, time.May, time.June, time.July, time.August, time.September, time.October}},
+	{Name: "mushroom", Parent: "vegetable", Season: []time.Month{time.September, time.October, time.November}, PricePerKg: 9},
+	{Name: "zucchini", Parent: "vegetable", Season: []time.Month{time.June, time.July, time.August, time.September}, PricePerKg: 4},
+	{Name: "eggplant", Parent: "vegetable", Season: []time.Month{time.July, time.August, time.September}, PricePerKg: 4.5},
+	{Name: "broccoli", Parent: "vegetable", Season: []time.Month{time.June, time.July, time.August, time.September, time.October}, PricePerKg: 5},
+	{Name: "fruit", Category: "produce", PricePerKg: 4},
+	{Name: "citrus", Parent: "fruit"},
+	{Name: "lemon", Parent: "citrus", Season: []time.Month{time.December, time.January, time.February, time.March}, PricePerKg: 4.5},
+	{Name: "lime", Parent: "citrus", PricePerKg: 5},
+	{Name: "orange", Parent: "citrus", Season: []time.Month{time.December, time.January, time.February, time.March}, PricePerKg: 3},
+	{Name: "berry", Parent: "fruit", PricePerKg: 10},
+	{Name: "strawberry", Parent: "berry", Season: []time.Month{time.May, time.June, time.July}},
+	{Name: "raspberry", Parent: "berry", Season: []time.Month{time.June, time.July, time.August, time.September}},
+	{Name: "blueberry", Parent: "berry", Season: []time.Month{time.June, time.July, time.August}},
+	{Name: "apple", Parent: "fruit", Season: []time.Month{time.August, time.September, time.October, time.November}, PricePerKg: 4.5},
+	{Name: "banana", Parent: "fruit", PricePerKg: 1.5},
+	{Name: "herb", Category: "produce", PricePerKg: 30},
+	{Name: "basil", Parent: "herb", Season: []time.Month{time.June, time.July, time.August, time.September}},
+	{Name: "parsley", Parent: "herb"},
+	{Name: "cilantro", Parent: "herb", Season: []time.Month{time.May, time.June, time.July, time.August, time.September}},
+	{Name: "thyme", Parent: "herb"},
+	{Name: "rosemary", Parent: "herb"},
+
+	{Name: "grain", Category: "pantry"},
+	{Name: "rice", Parent: "grain", PricePerKg: 3},
+	{Name: "quinoa", Parent: "grain", PricePerKg: 9},
+	{Name: "oats", Parent: "grain", PricePerKg: 3},
+	{Name: "flour", Parent: "grain", PricePerKg: 1.5},
+	{Name: "pasta", Category: "pantry", PricePerKg: 3.5},
+	{Name: "spaghetti", Parent: "pasta"},
+	{Name: "penne", Parent: "pasta"},
+	{Name: "noodle", Parent: "pasta"},
+	{Name: "legume", Category: "pantry"},
+	{Name: "bean", Parent: "legume", PricePerKg: 4},
+	{Name: "black bean", Parent: "bean"},
+	{Name: "kidney bean", Parent: "bean"},
+	{Name: "chickpea", Parent: "legume", PricePerKg: 4},
+	{Name: "lentil", Parent: "legume", PricePerKg: 4},
+	{Name: "oil", Category: "pantry", PricePerKg: 6},
+	{Name: "olive oil", Parent: "oil", PricePerKg: 12},
+	{Name: "vegetable oil", Parent: "oil"},
+	{Name: "vinegar", Category: "pantry", PricePerKg: 3},
+	{Name: "sugar", Category: "baking", PricePerKg: 2},
+	{Name: "brown sugar", Parent: "sugar"},
+	{Name: "powdered sugar", Parent: "sugar"},
+	{Name: "spice", Category: "spices", PricePerKg: 30},
+	{Name: "salt", Parent: "spice", PricePerKg: 1.5},
+	{Name: "pepper", Parent: "spice"},
+	{Name: "cumin", Parent: "spice"},
+	{Name: "paprika", Parent: "spice"},
+	{Name: "cinnamon", Parent: "spice"},
+	{Name: "bread", Category: "bakery", PricePerKg: 6},
+	{Name: "tortilla", Parent: "bread", PricePerKg: 6},
+}
+
+func init() {
+	err := ExtendTaxonomy(defaultTaxonomy)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// ExtendTaxonomy adds entries to the taxonomy, replacing the ones of the same
+// ingredients, such as the user's own from a data file. Names are normalized
+// with NormalizeIngredient. It fails, without changing anything, on entries
+// without a name, with invalid months or a negative price, and on parents
+// that would make an ingredient a kind of itself. It is not safe to call
+// while recipes are being matched.
+func ExtendTaxonomy(entries []TaxonomyEntry) error {
+	extended := make(map[string]taxonomyEntry, len(taxonomy)+len(entries))
+	for name, entry := range taxonomy {
+		extended[name] = entry
+	}
+	for _, entry := range entries {
+		name := NormalizeIngredient(strings.TrimSpace(entry.Name))
+		if name == "" {
+			return errors.New("taxonomy entry without a name")
+		}
+		for _, month := range entry.Season {
+			if month < time.January || month > time.December {
+				return fmt.Errorf("invalid month %d in the season of taxonomy entry %q", month, name)
+			}
+		}
+		if entry.PricePerKg < 0 {
+			return fmt.Errorf("invalid price %g of taxonomy entry %q", entry.PricePerKg, name)
+		}
+		parent := ""
+		if entry.Parent != "" {
+			parent = NormalizeIngredient(strings.TrimSpace(entry.Parent))
+		}
+		extended[name] = taxonomyEntry{
+			parent:     parent,
+			category:   strings.ToLower(strings.TrimSpace(entry.Category)),
+			season:     entry.Season,
+			pricePerKg: entry.PricePerKg,
+		}
+	}
+	for name := range extended {
+		seen := map[string]bool{name: true}
+		for parent := extended[name].parent; parent != ""; parent = extended[parent].parent {
+			if seen[parent] {
+				return fmt.Errorf("taxonomy entry %q is a kind of itself through %q", name, parent)
+			}
+			seen[parent] = true
+		}
+	}
+	taxonomy = extended
+	return nil
+}
+
+// kindOf returns the most specific ingredient of the taxonomy the lowercased
+// recipe ingredient name contains: the one with the most words, then the one
+// furthest down the taxonomy, so "sharp cheddar cheese" is a cheddar. It is
+// empty when the name contains none.
+func kindOf(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		words[i] = singular(word)
+	}
+	normalized := " " + strings.Join(words, " ") + " "
+	if synonym, ok := ingredientSynonyms[strings.TrimSpace(normalized)]; ok {
+		normalized = " " + synonym + " "
+	}
+	best, bestWords, bestDepth := "", 0, 0
+	for kind := range taxonomy {
+		if !strings.Contains(normalized, " "+kind+" ") {
+			continue
+		}
+		words, depth := strings.Count(kind, " "), len(ancestors(kind))
+		if best == "" || words > bestWords || words == bestWords && (depth > bestDepth ||
+			depth == bestDepth && kind < best) {
+			best, bestWords, bestDepth = kind, words, depth
+		}
+	}
+	return best
+}
+
+// ancestors returns the more general ingredients a kind belongs to, nearest
+// first.
+func ancestors(kind string) []string {
+	var parents []string
+	for parent := taxonomy[kind].parent; parent != ""; parent = taxonomy[parent].parent {
+		parents = append(parents, parent)
+	}
+	return parents
+}
+
+// IsKindOf reports whether the lowercased recipe ingredient name is, by the
+// taxonomy, a kind of the general ingredient, such as "cheddar" of "cheese".
+func IsKindOf(name string, general string) bool {
+	kind := kindOf(name)
+	if kind == "" {
+		return false
+	}
+	general = NormalizeIngredient(general)
+	for _, parent := range ancestors(kind) {
+		if parent == general {
+			return true
+		}
+	}
+	return false
+}
+
+// Aisle returns the shop aisle of an ingredient by the taxonomy, inherited
+// from the nearest more general ingredient with one, or "" when it is not
+// known.
+func Aisle(name string) string {
+	return nearest(kindOf(strings.ToLower(name)), func(entry taxonomyEntry) bool {
+		return entry.category != ""
+	}).category
+}
+
+// nearest returns the entry of the kind, or of the nearest more general
+// ingredient, that has what the entry is wanted for, or the zero entry when
+// none has.
+func nearest(kind string, has func(entry taxonomyEntry) bool) taxonomyEntry {
+	if kind == "" {
+		return taxonomyEntry{}
+	}
+	for _, candidate := range append([]string{kind}, ancestors(kind)...) {
+		if entry := taxonomy[candidate]; has(entry) {
+			return entry
+		}
+	}
+	return taxonomyEntry{}
+}
+
+// Alternatives returns the ingredients the taxonomy groups with the one named,
+// the other kinds of its nearest more general ingredient, sorted, for
+// substituting it: "gouda" and "mozzarella" among others for "cheddar".
+func Alternatives(name string) []string {
+	kind := kindOf(strings.ToLower(name))
+	parent := taxonomy[kind].parent
+	if kind == "" || parent == "" {
+		return nil
+	}
+	var alternatives []string
+	for other, entry := range taxonomy {
+		if entry.parent == parent && other != kind {
+			alternatives = append(alternatives, other)
+		}
+	}
+	sort.Strings(alternatives)
+	return alternatives
+}
+
+// Vegetables returns the kinds of vegetable among the recipe's ingredients by
+// the taxonomy, each once, such as "tomato" for cherry tomatoes.
+func Vegetables(recipe Recipe) []string {
+	var vegetables []string
+	for _, ingredient := range slices.Concat(recipe.UsedIngredients, recipe.MissedIngredients) {
+		kind := kindOf(strings.ToLower(ingredient.Name))
+		if kind != "" && slices.Contains(ancestors(kind), "vegetable") && !slices.Contains(vegetables, kind) {
+			vegetables = append(vegetables, kind)
+		}
+	}
+	return vegetables
+}
+
+// SuggestSubstitutes sets the Substitutes of the recipes' missing ingredients
+// to the ingredients the user has that the taxonomy groups with them, see
+// Alternatives, so a recipe missing cheddar suggests the gouda at hand.
+func SuggestSubstitutes(allRecipes []Recipe, have []string) []Recipe {
+	for i := range allRecipes {
+		// The ingredients may be shared with other copies of the recipe
+		missed := append([]Ingredient(nil), allRecipes[i].MissedIngredients...)
+		for j := range missed {
+			for _, alternative := range Alternatives(missed[j].Name) {
+				if slices.Contains(have, alternative) && !slices.Contains(missed[j].Substitutes, alternative) {
+					missed[j].Substitutes = append(slices.Clip(missed[j].Substitutes), alternative)
+				}
+			}
+		}
+		allRecipes[i].MissedIngredients = missed
+	}
+	return allRecipes
+}
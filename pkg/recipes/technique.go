@@ -0,0 +1,82 @@
+package recipes
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// techniques are the cooking techniques Techniques finds in instructions,
+// each with the forms of the word it goes by.
+var techniques = []struct {
+	name  string
+	forms []string
+}{
+	{"bake blind", []string{"blind bake", "blind baked", "blind baking", "bake blind"}},
+	{"blanch", []string{"blanch", "blanched", "blanching"}},
+	{"braise", []string{"braise", "braised", "braising"}},
+	{"brine", []string{"brine", "brined", "brining"}},
+	{"caramelize", []string{"caramelize", "caramelized", "caramelizing", "caramelise", "caramelised", "caramelising"}},
+	{"confit", []string{"confit"}},
+	{"cure", []string{"cure", "cured", "curing"}},
+	{"deep-fry", []string{"deep-fry", "deep fry", "deep-fried", "deep fried", "deep-frying", "deep frying"}},
+	{"deglaze", []string{"deglaze", "deglazed", "deglazing"}},
+	{"emulsify", []string{"emulsify", "emulsified", "emulsifying", "emulsion"}},
+	{"ferment", []string{"ferment", "fermented", "fermenting"}},
+	{"flambé", []string{"flambé", "flambe", "flambéed", "flambeed", "flambéing", "flambeing"}},
+	{"fold", []string{"fold in", "folding in", "gently fold"}},
+	{"grill", []string{"grill", "grilled", "grilling"}},
+	{"julienne", []string{"julienne", "julienned"}},
+	{"knead", []string{"knead", "kneaded", "kneading"}},
+	{"laminate", []string{"laminate", "laminated", "laminating"}},
+	{"marinate", []string{"marinate", "marinated", "marinating"}},
+	{"pickle", []string{"pickle", "pickled", "pickling"}},
+	{"poach", []string{"poach", "poached", "poaching"}},
+	{"proof", []string{"proof", "proofed", "proofing", "prove", "proved", "proving"}},
+	{"roast", []string{"roast", "roasted", "roasting"}},
+	{"sauté", []string{"sauté", "saute", "sautéed", "sauteed", "sautéing", "sauteing"}},
+	{"sear", []string{"sear", "seared", "searing"}},
+	{"sous vide", []string{"sous vide", "sous-vide"}},
+	{"steam", []string{"steam", "steamed", "steaming"}},
+	{"stir-fry", []string{"stir-fry", "stir fry", "stir-fried", "stir fried", "stir-frying", "stir frying"}},
+	{"temper", []string{"temper", "tempered", "tempering"}},
+	{"whip", []string{"whip", "whipped", "whipping"}},
+}
+
+// techniquePatterns match the forms of each technique as whole words.
+var techniquePatterns = func() []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, len(techniques))
+	for i, technique := range techniques {
+		forms := make([]string, len(technique.forms))
+		for j, form := range technique.forms {
+			forms[j] = regexp.QuoteMeta(form)
+		}
+		patterns[i] = regexp.MustCompile(`(^|[^\pL])(` + strings.Join(forms, "|") + `)($|[^\pL])`)
+	}
+	return patterns
+}()
+
+// Techniques returns the cooking techniques the recipe's instructions call
+// for, such as braise or temper, in the order of the list they are looked up
+// in. Recipes without instructions have none.
+func Techniques(recipe Recipe) []string {
+	text := strings.ToLower(strings.Join(recipe.Instructions, "\n"))
+	var found []string
+	for i, pattern := range techniquePatterns {
+		if pattern.MatchString(text) {
+			found = append(found, techniques[i].name)
+		}
+	}
+	return found
+}
+
+// newTechniques reports whether the recipe calls for a technique that is not
+// known.
+func newTechniques(recipe Recipe, known []string) bool {
+	for _, technique := range Techniques(recipe) {
+		if !slices.Contains(known, technique) {
+			return true
+		}
+	}
+	return false
+}
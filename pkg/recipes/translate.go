@@ -0,0 +1,101 @@
+package recipes
+
+import (
+	"context"
+	"strings"
+)
+
+// Translator translates ingredient names from a language, such as "pl", to
+// English, the only language the recipe APIs understand. Names it cannot
+// translate are returned as they are, in the same order.
+type Translator interface {
+	Translate(ctx context.Context, lang string, names []string) ([]string, error)
+}
+
+// Dictionary is a Translator looking names up in word lists, by language and
+// lowercased name.
+type Dictionary map[string]map[string]string
+
+// Translate looks the names up, keeping those the dictionary lacks.
+func (d Dictionary) Translate(ctx context.Context, lang string, names []string) ([]string, error) {
+	translated := make([]string, len(names))
+	for i, name := range names {
+		english, ok := d[lang][strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			english = name
+		}
+		translated[i] = english
+	}
+	return translated, nil
+}
+
+// Chain is a Translator trying its translators in order: each gets the
+// names the ones before it left untranslated.
+type Chain []Translator
+
+// Translate runs the translators in order.
+func (c Chain) Translate(ctx context.Context, lang string, names []string) ([]string, error) {
+	translated := append([]string(nil), names...)
+	for _, translator := range c {
+		var pending []int
+		var untranslated []string
+		for i, name := range translated {
+			if name == names[i] {
+				pending = append(pending, i)
+				untranslated = append(untranslated, name)
+			}
+		}
+		if len(untranslated) == 0 {
+			break
+		}
+		result, err := translator.Translate(ctx, lang, untranslated)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range pending {
+			if j < len(result) {
+				translated[i] = result[j]
+			}
+		}
+	}
+	return translated, nil
+}
+
+// Dictionaries translate common ingredients from Polish, German and Spanish.
+var Dictionaries = Dictionary{
+	"pl": {
+		"jajko": "egg", "jajka": "eggs", "mleko": "milk", "masło": "butter", "ser": "cheese", "mąka": "flour",
+		"cukier": "sugar", "sól": "salt", "pieprz": "pepper", "ryż": "rice", "makaron": "pasta",
+		"ziemniaki": "potatoes", "ziemniak": "potato", "cebula": "onion", "czosnek": "garlic",
+		"pomidor": "tomato", "pomidory": "tomatoes", "marchew": "carrot", "marchewka": "carrot",
+		"kurczak": "chicken", "wołowina": "beef", "wieprzowina": "pork", "ryba": "fish", "łosoś": "salmon",
+		"szpinak": "spinach", "papryka": "bell pepper", "grzyby": "mushrooms", "pieczarki": "mushrooms",
+		"jabłko": "apple", "jabłka": "apples", "cytryna": "lemon", "oliwa": "olive oil", "śmietana": "sour cream",
+		"twaróg": "cottage cheese", "kapusta": "cabbage", "ogórek": "cucumber", "fasola": "beans",
+		"soczewica": "lentils", "boczek": "bacon", "chleb": "bread", "jogurt": "yogurt", "por": "leek",
+	},
+	"de": {
+		"ei": "egg", "eier": "eggs", "milch": "milk", "butter": "butter", "käse": "cheese", "mehl": "flour",
+		"zucker": "sugar", "salz": "salt", "pfeffer": "pepper", "reis": "rice", "nudeln": "pasta",
+		"kartoffeln": "potatoes", "kartoffel": "potato", "zwiebel": "onion", "zwiebeln": "onions",
+		"knoblauch": "garlic", "tomate": "tomato", "tomaten": "tomatoes", "karotte": "carrot",
+		"möhre": "carrot", "hähnchen": "chicken", "huhn": "chicken", "rindfleisch": "beef",
+		"schweinefleisch": "pork", "fisch": "fish", "lachs": "salmon", "spinat": "spinach",
+		"paprika": "bell pepper", "pilze": "mushrooms", "champignons": "mushrooms", "apfel": "apple",
+		"äpfel": "apples", "zitrone": "lemon", "olivenöl": "olive oil", "sahne": "cream", "quark": "quark",
+		"kohl": "cabbage", "gurke": "cucumber", "bohnen": "beans", "linsen": "lentils", "speck": "bacon",
+		"brot": "bread", "joghurt": "yogurt", "lauch": "leek",
+	},
+	"es": {
+		"huevo": "egg", "huevos": "eggs", "leche": "milk", "mantequilla": "butter", "queso": "cheese",
+		"harina": "flour", "azúcar": "sugar", "sal": "salt", "pimienta": "pepper", "arroz": "rice",
+		"pasta": "pasta", "patatas": "potatoes", "papas": "potatoes", "patata": "potato", "cebolla": "onion",
+		"ajo": "garlic", "tomate": "tomato", "tomates": "tomatoes", "zanahoria": "carrot", "pollo": "chicken",
+		"carne de res": "beef", "ternera": "beef", "cerdo": "pork", "pescado": "fish", "salmón": "salmon",
+		"espinacas": "spinach", "pimiento": "bell pepper", "champiñones": "mushrooms", "setas": "mushrooms",
+		"manzana": "apple", "manzanas": "apples", "limón": "lemon", "aceite de oliva": "olive oil",
+		"nata": "cream", "repollo": "cabbage", "col": "cabbage", "pepino": "cucumber", "frijoles": "beans",
+		"judías": "beans", "lentejas": "lentils", "tocino": "bacon", "pan": "bread", "yogur": "yogurt",
+		"puerro": "leek",
+	},
+}
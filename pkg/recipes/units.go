@@ -0,0 +1,114 @@
+package recipes
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// UnitSystem is the system ingredient amounts are shown in. The zero value
+// keeps the units recipes give.
+type UnitSystem string
+
+const (
+	OriginalUnits UnitSystem = ""
+	Metric        UnitSystem = "metric"
+	Imperial      UnitSystem = "imperial"
+)
+
+// ParseUnitSystem parses a --units value, empty for OriginalUnits.
+func ParseUnitSystem(name string) (UnitSystem, error) {
+	switch system := UnitSystem(strings.ToLower(strings.TrimSpace(name))); system {
+	case OriginalUnits, Metric, Imperial:
+		return system, nil
+	default:
+		return "", fmt.Errorf("unknown unit system %q, expected metric or imperial", name)
+	}
+}
+
+// Imperial units, in grams and milliliters, from the largest down.
+var (
+	imperialWeights = []struct {
+		unit   string
+		factor float64
+	}{{"lb", 453.6}, {"oz", 28.35}}
+	imperialVolumes = []struct {
+		unit   string
+		factor float64
+	}{{"cups", 240}, {"tbsp", 14.79}, {"tsp", 4.93}}
+)
+
+// Convert returns an amount in the system. Weights and volumes are converted
+// to g or kg and ml or l, or to oz or lb and tsp, tbsp or cups, picking the
+// largest unit the amount makes at least one of. Amounts in other units, such
+// as cloves or cans, are returned as they are.
+func (s UnitSystem) Convert(amount float64, unit string) (float64, string) {
+	conversion, ok := unitConversions[strings.ToLower(strings.TrimSpace(unit))]
+	if s == OriginalUnits || amount == 0 || !ok || conversion.base != "g" && conversion.base != "ml" {
+		return amount, unit
+	}
+	base := amount * conversion.factor
+
+	if s == Metric {
+		if base >= 1000 {
+			return roundAmount(base/1000, 0.01), map[string]string{"g": "kg", "ml": "l"}[conversion.base]
+		}
+		if base >= 10 {
+			return math.Round(base), conversion.base
+		}
+		return roundAmount(base, 0.1), conversion.base
+	}
+
+	units := imperialWeights
+	if conversion.base == "ml" {
+		units = imperialVolumes
+	}
+	for _, imperial := range units {
+		if base/imperial.factor >= 1 {
+			return roundAmount(base/imperial.factor, 0.25), imperial.unit
+		}
+	}
+	smallest := units[len(units)-1]
+	return roundAmount(base/smallest.factor, 0.125), smallest.unit
+}
+
+// roundAmount rounds to a multiple of step, never down to zero.
+func roundAmount(amount float64, step float64) float64 {
+	return max(math.Round(amount/step)*step, step)
+}
+
+// Recipes converts the ingredient amounts of the recipes.
+func (s UnitSystem) Recipes(allRecipes []Recipe) []Recipe {
+	if s == OriginalUnits {
+		return allRecipes
+	}
+	converted := make([]Recipe, len(allRecipes))
+	for i, recipe := range allRecipes {
+		recipe.UsedIngredients = s.ingredients(recipe.UsedIngredients)
+		recipe.MissedIngredients = s.ingredients(recipe.MissedIngredients)
+		converted[i] = recipe
+	}
+	return converted
+}
+
+func (s UnitSystem) ingredients(ingredients []Ingredient) []Ingredient {
+	if ingredients == nil {
+		return nil
+	}
+	converted := make([]Ingredient, len(ingredients))
+	for i, ingredient := range ingredients {
+		ingredient.Amount, ingredient.Unit = s.Convert(ingredient.Amount, ingredient.Unit)
+		converted[i] = ingredient
+	}
+	return converted
+}
+
+// ShoppingList converts the amounts of a shopping list.
+func (s UnitSystem) ShoppingList(items []ShoppingItem) []ShoppingItem {
+	converted := make([]ShoppingItem, len(items))
+	for i, item := range items {
+		item.Amount, item.Unit = s.Convert(item.Amount, item.Unit)
+		converted[i] = item
+	}
+	return converted
+}
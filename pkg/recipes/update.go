@@ -0,0 +1,27 @@
+package recipes
+
+import (
+	"context"
+	"maps"
+)
+
+// RecipeUpdate holds the fields of a recipe that can change at its source
+// after it was cached.
+type RecipeUpdate struct {
+	ID        int
+	Title     string
+	Nutrients map[string]NutrientAmount
+}
+
+// Updater is a provider that can fetch the changeable fields of recipes it
+// returned before, which costs less than searching for them again. IDs it
+// does not know are left out of the updates.
+type Updater interface {
+	Name() string
+	Updates(ctx context.Context, ids []int) ([]RecipeUpdate, error)
+}
+
+// Changes reports whether the update differs from the recipe.
+func (u RecipeUpdate) Changes(recipe Recipe) bool {
+	return u.Title != recipe.Title || !maps.Equal(u.Nutrients, recipe.Nutrients)
+}
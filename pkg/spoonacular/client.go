@@ -0,0 +1,952 @@
+// Package spoonacular is a client for the Spoonacular recipe API.
+package spoonacular
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+const baseURL = "https://api.spoonacular.com"
+
+type Response struct {
+	Results []Result `json:"results"`
+	// TotalResults is how many recipes match the search in all, over every
+	// page.
+	TotalResults int `json:"totalResults"`
+	// Invalid are the results left out of Results as malformed or incomplete.
+	Invalid []InvalidResult `json:"-"`
+}
+
+// Result is a recipe found by a search.
+type Result struct {
+	ID                    int          `json:"id"`
+	UsedIngredientCount   int          `json:"usedIngredientCount"`
+	MissedIngredientCount int          `json:"missedIngredientCount"`
+	MissedIngredients     []Ingredient `json:"missedIngredients"`
+	UsedIngredients       []Ingredient `json:"usedIngredients"`
+	UnusedIngredients     []Ingredient `json:"unusedIngredients"`
+	Title                 string       `json:"title"`
+	Image                 string       `json:"image"`
+	Servings              int          `json:"servings"`
+	ReadyInMinutes        int          `json:"readyInMinutes"`
+	PricePerServing       float64      `json:"pricePerServing"`
+	SourceURL             string       `json:"sourceUrl"`
+	SourceName            string       `json:"sourceName"`
+	AggregateLikes        int          `json:"aggregateLikes"`
+	HealthScore           float64      `json:"healthScore"`
+	Cuisines              []string     `json:"cuisines"`
+	Nutrition             struct {
+		Nutrients []nutrientData `json:"nutrients"`
+	} `json:"nutrition"`
+	AnalyzedInstructions []instructionPart `json:"analyzedInstructions"`
+}
+
+func (result Result) recipeID() int { return result.ID }
+
+func (result Result) problem() string {
+	return recipeProblem(result.ID, result.Title, result.Nutrition.Nutrients)
+}
+
+// instructionPart is a part of a recipe's analyzed instructions, such as "For
+// the sauce".
+type instructionPart struct {
+	Name  string `json:"name"`
+	Steps []struct {
+		Number    int    `json:"number"`
+		Step      string `json:"step"`
+		Equipment []struct {
+			Name string `json:"name"`
+		} `json:"equipment"`
+	} `json:"steps"`
+}
+
+// parseInstructions returns the steps of every part in order, trimmed and
+// kept on one line, and the equipment they use.
+func parseInstructions(parts []instructionPart) (steps []string, equipment []string) {
+	for _, part := range parts {
+		for _, step := range part.Steps {
+			steps = append(steps, strings.ReplaceAll(strings.TrimSpace(step.Step), "\n", " "))
+			for _, used := range step.Equipment {
+				if !slices.Contains(equipment, used.Name) {
+					equipment = append(equipment, used.Name)
+				}
+			}
+		}
+	}
+	return steps, equipment
+}
+
+type Ingredient struct {
+	ID       int      `json:"id"`
+	Amount   float64  `json:"amount"`
+	Unit     string   `json:"unit"`
+	Name     string   `json:"name"`
+	Original string   `json:"original"`
+	Meta     []string `json:"meta"`
+}
+
+type NutritionWidget struct {
+	Nutrients []struct {
+		Name                string  `json:"name"`
+		Amount              float64 `json:"amount"`
+		Unit                string  `json:"unit"`
+		PercentOfDailyNeeds float64 `json:"percentOfDailyNeeds"`
+	} `json:"nutrients"`
+	WeightPerServing struct {
+		Amount float64 `json:"amount"`
+		Unit   string  `json:"unit"`
+	} `json:"weightPerServing"`
+}
+
+// bodyBufferPool holds the buffers API responses are read into, so repeated
+// fetches reuse the same backing arrays instead of growing a new one each time.
+var bodyBufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// Client talks to the Spoonacular API. It is safe for concurrent use.
+type Client struct {
+	// HTTPClient makes the requests, http.DefaultClient when nil. Tests give
+	// it a Transport serving canned responses.
+	HTTPClient *http.Client
+	// Nutrients names the nutrients kept from the API's nutrition data, in
+	// any case: DefaultNutrients when empty, every one with AllNutrients.
+	Nutrients []string
+	// Spend, when set, is asked before each request whether its estimated
+	// cost in points, see EstimatePoints, may be spent. A request it returns
+	// an error for is not made and fails with the error.
+	Spend func(ctx context.Context, points float64) error
+	// DryRun, when set, has each request printed to it with the API key
+	// hidden and its estimated cost, instead of made. The requests fail with
+	// ErrDryRun.
+	DryRun io.Writer
+	// Limits bound the informationBulk requests of Updates.
+	Limits recipes.EnrichLimits
+	// OnKeyExhausted, when set, is called with an API key that ran out of
+	// points for the day and the 402 response saying so, before the request
+	// is made again with the next key.
+	OnKeyExhausted func(apiKey string, err *APIError)
+	// Strict has searches and random recipes fail with an
+	// InvalidResultsError when a result is malformed or incomplete, instead
+	// of leaving it out with a warning.
+	Strict bool
+
+	keysMu sync.Mutex
+	// keys are the API keys in the order they are used, and key the index of
+	// the one requests are made with. exhausted are the keys a 402 response
+	// was returned for.
+	keys      []string
+	key       int
+	exhausted map[string]bool
+	quotaUsed atomic.Value
+	quotaLeft atomic.Value
+}
+
+// NewClient returns a client making requests with apiKey and, once its
+// daily points are spent, with each of spareKeys in turn.
+func NewClient(apiKey string, spareKeys ...string) *Client {
+	return &Client{
+		keys:   append([]string{apiKey}, spareKeys...),
+		Limits: recipes.EnrichLimits{Interval: bulkInterval},
+	}
+}
+
+// APIKey returns the API key requests are made with.
+func (c *Client) APIKey() string {
+	c.keysMu.Lock()
+	defer c.keysMu.Unlock()
+	return c.keys[c.key]
+}
+
+// SetKeys replaces the API keys of the client, which starts over with the
+// first.
+func (c *Client) SetKeys(keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	c.keysMu.Lock()
+	defer c.keysMu.Unlock()
+	c.keys = slices.Clone(keys)
+	c.key = 0
+	c.exhausted = nil
+}
+
+// nextKey moves on from an API key that ran out of points to the next one
+// that has not, and returns it, or an empty string when every key ran out.
+// Requests made with a key another request already moved on from just take
+// the current key.
+func (c *Client) nextKey(spent string, apiErr *APIError) string {
+	c.keysMu.Lock()
+	newlySpent := !c.exhausted[spent]
+	if c.exhausted == nil {
+		c.exhausted = make(map[string]bool)
+	}
+	c.exhausted[spent] = true
+	next := ""
+	for range c.keys {
+		if !c.exhausted[c.keys[c.key]] {
+			next = c.keys[c.key]
+			break
+		}
+		c.key = (c.key + 1) % len(c.keys)
+	}
+	c.keysMu.Unlock()
+
+	if newlySpent && c.OnKeyExhausted != nil {
+		c.OnKeyExhausted(spent, apiErr)
+	}
+	return next
+}
+
+func (c *Client) Name() string {
+	return "spoonacular"
+}
+
+// Ping checks that the API answers, without spending quota: any response to
+// a HEAD request of its base URL will do.
+func (c *Client) Ping(ctx context.Context) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, baseURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	resp, err := c.httpClient().Do(request)
+	if err != nil {
+		return fmt.Errorf("error reaching %s: %w", baseURL, err)
+	}
+	return resp.Body.Close()
+}
+
+func (c *Client) keptNutrients() nutrientSet {
+	if len(c.Nutrients) == 0 {
+		return newNutrientSet(DefaultNutrients)
+	}
+	return newNutrientSet(c.Nutrients)
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// QuotaUsed returns the X-API-Quota-Used header of the last response that had
+// one, the API points spent today, or an empty string when none has.
+func (c *Client) QuotaUsed() string {
+	quota, _ := c.quotaUsed.Load().(string)
+	return quota
+}
+
+// QuotaLeft returns the X-API-Quota-Left header of the last response that had
+// one, the API points left for the day, or an empty string when none has.
+func (c *Client) QuotaLeft() string {
+	quota, _ := c.quotaLeft.Load().(string)
+	return quota
+}
+
+// Search runs complexSearch for recipes using the query's ingredients, with
+// the fewest missing ingredients first. The API returns fewer recipes than
+// asked for past its limit per request, so Search asks for the rest with a
+// further offset until it has enough or totalResults are used up.
+func (c *Client) Search(ctx context.Context, search recipes.Query) ([]recipes.Recipe, error) {
+	query := url.Values{}
+	query.Set("apiKey", c.APIKey())
+	query.Set("includeIngredients", strings.Join(search.Ingredients, ","))
+	if len(search.Diets) > 0 {
+		query.Set("diet", strings.Join(search.Diets, ","))
+	}
+	if len(search.Intolerances) > 0 {
+		query.Set("intolerances", strings.Join(search.Intolerances, ","))
+	}
+	if len(search.Exclude) > 0 {
+		query.Set("excludeIngredients", strings.Join(search.Exclude, ","))
+	}
+	if search.Targets.MaxCalories > 0 {
+		query.Set("maxCalories", fmt.Sprint(search.Targets.MaxCalories))
+	}
+	if search.Targets.MinProtein > 0 {
+		query.Set("minProtein", fmt.Sprint(search.Targets.MinProtein))
+	}
+	if search.Targets.MaxCarbs > 0 {
+		query.Set("maxCarbs", fmt.Sprint(search.Targets.MaxCarbs))
+	}
+	if search.Prep.MaxReadyTime > 0 {
+		query.Set("maxReadyTime", fmt.Sprint(search.Prep.MaxReadyTime))
+	}
+	if len(search.Prep.Equipment) > 0 {
+		query.Set("equipment", strings.Join(search.Prep.Equipment, ","))
+	}
+	query.Set("fillIngredients", "true")
+	query.Set("sort", "min-missing-ingredients")
+	query.Set("addRecipeInformation", "true")
+	query.Set("addRecipeNutrition", "true")
+	query.Set("addRecipeInstructions", "true")
+	query.Set("ignorePantry", "true")
+
+	body := bodyBufferPool.Get().(*bytes.Buffer)
+	body.Reset()
+	defer bodyBufferPool.Put(body)
+
+	found := make([]recipes.Recipe, 0, search.NumberOfRecipes)
+	offset := search.Offset
+	for len(found) < search.NumberOfRecipes {
+		query.Set("number", fmt.Sprint(search.NumberOfRecipes-len(found)))
+		if offset > 0 {
+			query.Set("offset", fmt.Sprint(offset))
+		}
+		err := c.fetchURL(ctx, baseURL+"/recipes/complexSearch?"+query.Encode(), body)
+		if err != nil {
+			return nil, err
+		}
+		response, err := parseJSON(body.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		err = c.checkResults(response.Invalid, offset)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, parseResponse(response, c.keptNutrients())...)
+		page := len(response.Results) + len(response.Invalid)
+		offset += page
+		if page == 0 || offset >= response.TotalResults {
+			break
+		}
+	}
+	return found[:min(len(found), search.NumberOfRecipes)], nil
+}
+
+// NutritionWidget returns the full nutrition data of a single recipe.
+func (c *Client) NutritionWidget(ctx context.Context, recipeID int) (*NutritionWidget, error) {
+	query := url.Values{}
+	query.Set("apiKey", c.APIKey())
+
+	body := bodyBufferPool.Get().(*bytes.Buffer)
+	body.Reset()
+	defer bodyBufferPool.Put(body)
+
+	err := c.fetchURL(ctx, fmt.Sprintf("%s/recipes/%d/nutritionWidget.json?%s", baseURL, recipeID, query.Encode()), body)
+	if err != nil {
+		return nil, err
+	}
+
+	var widget NutritionWidget
+	err = json.Unmarshal(body.Bytes(), &widget)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %v", err)
+	}
+	return &widget, nil
+}
+
+// information is a recipe as the information and random endpoints return it.
+type information struct {
+	ID                  int          `json:"id"`
+	Title               string       `json:"title"`
+	Servings            int          `json:"servings"`
+	ReadyInMinutes      int          `json:"readyInMinutes"`
+	PricePerServing     float64      `json:"pricePerServing"`
+	SourceURL           string       `json:"sourceUrl"`
+	SourceName          string       `json:"sourceName"`
+	Image               string       `json:"image"`
+	AggregateLikes      int          `json:"aggregateLikes"`
+	HealthScore         float64      `json:"healthScore"`
+	Cuisines            []string     `json:"cuisines"`
+	ExtendedIngredients []Ingredient `json:"extendedIngredients"`
+	Nutrition           struct {
+		Nutrients []nutrientData `json:"nutrients"`
+	} `json:"nutrition"`
+	AnalyzedInstructions []instructionPart `json:"analyzedInstructions"`
+}
+
+// Information returns the full details of a single recipe, every ingredient
+// of it as used.
+func (c *Client) Information(ctx context.Context, recipeID int) (recipes.Recipe, error) {
+	query := url.Values{}
+	query.Set("apiKey", c.APIKey())
+	query.Set("includeNutrition", "true")
+
+	body := bodyBufferPool.Get().(*bytes.Buffer)
+	body.Reset()
+	defer bodyBufferPool.Put(body)
+
+	err := c.fetchURL(ctx, fmt.Sprintf("%s/recipes/%d/information?%s", baseURL, recipeID, query.Encode()), body)
+	if err != nil {
+		return recipes.Recipe{}, err
+	}
+
+	var info information
+	err = json.Unmarshal(body.Bytes(), &info)
+	if err != nil {
+		return recipes.Recipe{}, fmt.Errorf("error parsing JSON: %v", err)
+	}
+	return info.recipe(c.keptNutrients()), nil
+}
+
+// Taste returns the taste profile of a single recipe.
+func (c *Client) Taste(ctx context.Context, recipeID int) (*recipes.Taste, error) {
+	query := url.Values{}
+	query.Set("apiKey", c.APIKey())
+
+	body := bodyBufferPool.Get().(*bytes.Buffer)
+	body.Reset()
+	defer bodyBufferPool.Put(body)
+
+	err := c.fetchURL(ctx, fmt.Sprintf("%s/recipes/%d/tasteWidget.json?%s", baseURL, recipeID, query.Encode()), body)
+	if err != nil {
+		return nil, err
+	}
+
+	var taste recipes.Taste
+	err = json.Unmarshal(body.Bytes(), &taste)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %v", err)
+	}
+	return &taste, nil
+}
+
+// ingredientImageURL is where Spoonacular serves the ingredient images its
+// ingredient search names.
+const ingredientImageURL = "https://img.spoonacular.com/ingredients_250x250/"
+
+// IngredientImage returns the URL of a photo of the ingredient best matching
+// the name, or "" when Spoonacular knows no such ingredient.
+func (c *Client) IngredientImage(ctx context.Context, name string) (string, error) {
+	query := url.Values{}
+	query.Set("apiKey", c.APIKey())
+	query.Set("query", name)
+	query.Set("number", "1")
+
+	body := bodyBufferPool.Get().(*bytes.Buffer)
+	body.Reset()
+	defer bodyBufferPool.Put(body)
+
+	err := c.fetchURL(ctx, baseURL+"/food/ingredients/search?"+query.Encode(), body)
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		Results []struct {
+			Image string `json:"image"`
+		} `json:"results"`
+	}
+	err = json.Unmarshal(body.Bytes(), &response)
+	if err != nil {
+		return "", fmt.Errorf("error parsing JSON: %v", err)
+	}
+	if len(response.Results) == 0 || response.Results[0].Image == "" {
+		return "", nil
+	}
+	return ingredientImageURL + response.Results[0].Image, nil
+}
+
+// WinePairing returns the wines Spoonacular pairs with a food, which may be a
+// dish, an ingredient or a cuisine, with its note on them, or nil when it has
+// no pairing for the food. It answers foods it does not know with a 400 or
+// with a failure payload, which are taken as no pairing too.
+func (c *Client) WinePairing(ctx context.Context, food string) (*recipes.Pairing, error) {
+	query := url.Values{}
+	query.Set("apiKey", c.APIKey())
+	query.Set("food", food)
+
+	body := bodyBufferPool.Get().(*bytes.Buffer)
+	body.Reset()
+	defer bodyBufferPool.Put(body)
+
+	err := c.fetchURL(ctx, baseURL+"/food/wine/pairing?"+query.Encode(), body)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Status      string   `json:"status"`
+		PairedWines []string `json:"pairedWines"`
+		PairingText string   `json:"pairingText"`
+	}
+	err = json.Unmarshal(body.Bytes(), &response)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %v", err)
+	}
+	if response.Status == "failure" || len(response.PairedWines) == 0 {
+		return nil, nil
+	}
+	return &recipes.Pairing{Wines: response.PairedWines, Note: strings.TrimSpace(response.PairingText), Food: food},
+		nil
+}
+
+// Substitutes returns the ways Spoonacular suggests of doing without an
+// ingredient, such as "1 cup = 1 cup milk + 1 tbsp lemon juice" for
+// buttermilk, or nil when it knows none. Like WinePairing, a 400 or a failure
+// payload is taken as none.
+func (c *Client) Substitutes(ctx context.Context, ingredient string) ([]string, error) {
+	query := url.Values{}
+	query.Set("apiKey", c.APIKey())
+	query.Set("ingredientName", ingredient)
+
+	body := bodyBufferPool.Get().(*bytes.Buffer)
+	body.Reset()
+	defer bodyBufferPool.Put(body)
+
+	err := c.fetchURL(ctx, baseURL+"/food/ingredients/substitutes?"+query.Encode(), body)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Status      string   `json:"status"`
+		Substitutes []string `json:"substitutes"`
+	}
+	err = json.Unmarshal(body.Bytes(), &response)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %v", err)
+	}
+	if response.Status == "failure" {
+		return nil, nil
+	}
+	return response.Substitutes, nil
+}
+
+// Random returns up to number random recipes with all the tags, such as
+// diets, dish types or cuisines, in full like Information. Every ingredient is
+// listed as used.
+func (c *Client) Random(ctx context.Context, tags []string, number int) ([]recipes.Recipe, error) {
+	query := url.Values{}
+	query.Set("apiKey", c.APIKey())
+	query.Set("number", fmt.Sprint(number))
+	query.Set("includeNutrition", "true")
+	if len(tags) > 0 {
+		query.Set("include-tags", strings.Join(tags, ","))
+	}
+
+	body := bodyBufferPool.Get().(*bytes.Buffer)
+	body.Reset()
+	defer bodyBufferPool.Put(body)
+
+	err := c.fetchURL(ctx, baseURL+"/recipes/random?"+query.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Recipes []json.RawMessage `json:"recipes"`
+	}
+	err = json.Unmarshal(body.Bytes(), &response)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %v", err)
+	}
+	infos, invalid := decodeResults[information](response.Recipes)
+	err = c.checkResults(invalid, 0)
+	if err != nil {
+		return nil, err
+	}
+	found := make([]recipes.Recipe, 0, len(infos))
+	for _, info := range infos {
+		found = append(found, info.recipe(c.keptNutrients()))
+	}
+	return found, nil
+}
+
+func (info information) recipeID() int { return info.ID }
+
+func (info information) problem() string {
+	return recipeProblem(info.ID, info.Title, info.Nutrition.Nutrients)
+}
+
+func (info information) recipe(kept nutrientSet) recipes.Recipe {
+	instructions, equipment := parseInstructions(info.AnalyzedInstructions)
+	return recipes.Recipe{
+		ID:              info.ID,
+		Title:           info.Title,
+		UsedIngredients: toIngredients(info.ExtendedIngredients),
+		Nutrients:       kept.pick(info.Nutrition.Nutrients),
+		Instructions:    instructions,
+		Servings:        info.Servings,
+		Source:          "spoonacular",
+		PricePerServing: recipes.CentsToMoney(info.PricePerServing),
+		ReadyInMinutes:  info.ReadyInMinutes,
+		Equipment:       equipment,
+		SourceURL:       info.SourceURL,
+		Author:          info.SourceName,
+		ImageURL:        info.Image,
+		Likes:           info.AggregateLikes,
+		HealthScore:     info.HealthScore,
+		Cuisines:        recipes.Cuisines(info.Cuisines),
+		Estimated:       estimatedFields(info.PricePerServing),
+	}
+}
+
+// bulkSize is the most recipes asked for in one informationBulk request.
+const bulkSize = 100
+
+// bulkInterval spaces out the informationBulk requests of Updates, keeping
+// within the API's requests per second.
+const bulkInterval = 200 * time.Millisecond
+
+// bulkRecipe is the part of an informationBulk result that can change.
+type bulkRecipe struct {
+	ID        int    `json:"id"`
+	Title     string `json:"title"`
+	Nutrition struct {
+		Nutrients []nutrientData `json:"nutrients"`
+	} `json:"nutrition"`
+}
+
+// Updates fetches the title and nutrients of known recipes through
+// informationBulk, which costs far fewer points than searching again, in
+// concurrent requests of up to bulkSize recipes, see Limits.
+func (c *Client) Updates(ctx context.Context, ids []int) ([]recipes.RecipeUpdate, error) {
+	var batches [][]int
+	for start := 0; start < len(ids); start += bulkSize {
+		batches = append(batches, ids[start:min(start+bulkSize, len(ids))])
+	}
+	fetched, err := recipes.Enrich(ctx, batches, c.Limits, func(ctx context.Context, batch []int) ([]bulkRecipe, error) {
+		idList := make([]string, 0, len(batch))
+		for _, id := range batch {
+			idList = append(idList, strconv.Itoa(id))
+		}
+		query := url.Values{}
+		query.Set("apiKey", c.APIKey())
+		query.Set("ids", strings.Join(idList, ","))
+		query.Set("includeNutrition", "true")
+		return c.fetchBulk(ctx, baseURL+"/recipes/informationBulk?"+query.Encode())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []recipes.RecipeUpdate
+	for _, found := range fetched {
+		for _, recipe := range found {
+			updates = append(updates, recipes.RecipeUpdate{
+				ID:        recipe.ID,
+				Title:     recipe.Title,
+				Nutrients: c.keptNutrients().pick(recipe.Nutrition.Nutrients),
+			})
+		}
+	}
+	return updates, nil
+}
+
+func (c *Client) fetchBulk(ctx context.Context, url string) ([]bulkRecipe, error) {
+	body := bodyBufferPool.Get().(*bytes.Buffer)
+	body.Reset()
+	defer bodyBufferPool.Put(body)
+
+	err := c.fetchURL(ctx, url, body)
+	if err != nil {
+		return nil, err
+	}
+	var found []bulkRecipe
+	err = json.Unmarshal(body.Bytes(), &found)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %v", err)
+	}
+	return found, nil
+}
+
+const (
+	// maxAttempts is how many times a request is tried before giving up on
+	// rate limiting or server errors.
+	maxAttempts = 4
+	// baseDelay is the wait before the first retry, doubled for every
+	// further one.
+	baseDelay = 500 * time.Millisecond
+	// maxDelay caps both the backoff and a Retry-After header.
+	maxDelay = 30 * time.Second
+)
+
+// fetchURL reads the body of a successful response to a GET into body.
+// Error responses are returned as an *APIError. Rate-limited (429) and failed
+// (5xx) requests, and requests that got no response, are retried with
+// exponential backoff and jitter, or after the delay the API asks for in
+// Retry-After. Cancelling ctx stops both the request and the wait between
+// attempts. A 402 response, the daily points of the API key being spent, has
+// the request made again with the next key, until every key is spent.
+func (c *Client) fetchURL(ctx context.Context, url string, body *bytes.Buffer) error {
+	points := EstimatePoints(url)
+	if c.DryRun != nil {
+		fmt.Fprintf(c.DryRun, "GET %s\n  estimated cost: %.2f points\n", redactedURLString(url), points)
+		return ErrDryRun
+	}
+	if c.Spend != nil {
+		err := c.Spend(ctx, points)
+		if err != nil {
+			return err
+		}
+	}
+	for {
+		err := c.fetchRetrying(ctx, url, body)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusPaymentRequired {
+			return err
+		}
+		spent, _ := withAPIKey(url, "")
+		next := c.nextKey(spent, apiErr)
+		if next == "" {
+			return err
+		}
+		slog.Warn("API key out of points, switching to the next one", "error", err)
+		_, url = withAPIKey(url, next)
+	}
+}
+
+// withAPIKey returns the API key of a request URL and the URL with apiKey
+// in its place, or unchanged when apiKey is empty.
+func withAPIKey(rawURL string, apiKey string) (string, string) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", rawURL
+	}
+	query := u.Query()
+	current := query.Get("apiKey")
+	if apiKey == "" {
+		return current, rawURL
+	}
+	query.Set("apiKey", apiKey)
+	u.RawQuery = query.Encode()
+	return current, u.String()
+}
+
+// fetchRetrying fetches a URL with one API key, retrying as fetchURL
+// describes.
+func (c *Client) fetchRetrying(ctx context.Context, url string, body *bytes.Buffer) error {
+	for attempt := 1; ; attempt++ {
+		body.Reset()
+		status, header, err := c.fetchOnce(ctx, url, body)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil && status < http.StatusMultipleChoices {
+			return nil
+		}
+		if err == nil {
+			err = newAPIError(status, header, body.Bytes())
+		}
+		retryable := status == 0 || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+		if !retryable || attempt == maxAttempts {
+			return err
+		}
+
+		delay := retryAfter(header)
+		if delay == 0 {
+			delay = baseDelay << (attempt - 1)
+			delay += time.Duration(rand.Int63n(int64(delay) / 2))
+		}
+		delay = min(delay, maxDelay)
+		slog.Warn("retrying request", "error", err, "delay", delay.Round(time.Millisecond), "attempt", attempt+1,
+			"maxAttempts", maxAttempts)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// redactedURL returns the URL with its API key hidden, for logging.
+func redactedURL(u *url.URL) string {
+	redacted := *u
+	query := redacted.Query()
+	if query.Has("apiKey") {
+		query.Set("apiKey", "REDACTED")
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// redactedURLString is redactedURL for a URL not parsed yet.
+func redactedURLString(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "(invalid URL)"
+	}
+	return redactedURL(u)
+}
+
+// fetchOnce makes a single request and returns the response status and
+// headers. The status is 0 when no response arrived.
+func (c *Client) fetchOnce(ctx context.Context, url string, body *bytes.Buffer) (int, http.Header, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error creating request: %v", err)
+	}
+	slog.Debug("requesting", "url", redactedURL(request.URL))
+	resp, err := c.httpClient().Do(request)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error fetching URL: %w", err)
+	}
+
+	defer func() {
+		err := resp.Body.Close()
+		if err != nil {
+			slog.Warn("error closing response body", "error", err)
+		}
+	}()
+
+	if quota := resp.Header.Get("X-API-Quota-Used"); quota != "" {
+		c.quotaUsed.Store(quota)
+	}
+	if quota := resp.Header.Get("X-API-Quota-Left"); quota != "" {
+		c.quotaLeft.Store(quota)
+	}
+
+	if resp.ContentLength > 0 {
+		body.Grow(int(resp.ContentLength))
+	}
+	_, err = body.ReadFrom(resp.Body)
+	if err != nil {
+		return resp.StatusCode, resp.Header, fmt.Errorf("error reading response body: %v", err)
+	}
+
+	return resp.StatusCode, resp.Header, nil
+}
+
+// retryAfter returns the delay asked for in a Retry-After header, zero when
+// there is none.
+func retryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// parseJSON parses a search response. Each result is decoded and checked on
+// its own, so that a malformed one is listed in Response.Invalid instead of
+// failing the others.
+func parseJSON(body []byte) (*Response, error) {
+	var raw struct {
+		Results      []json.RawMessage `json:"results"`
+		TotalResults int               `json:"totalResults"`
+	}
+	err := json.Unmarshal(body, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %v", err)
+	}
+	results, invalid := decodeResults[Result](raw.Results)
+	return &Response{Results: results, TotalResults: raw.TotalResults, Invalid: invalid}, nil
+}
+
+// DefaultNutrients are the nutrients kept from the API's nutrition data when
+// Client.Nutrients is empty.
+var DefaultNutrients = []string{"Calories", "Carbohydrates", "Protein"}
+
+// AllNutrients in Client.Nutrients keeps every nutrient the API gives.
+const AllNutrients = "all"
+
+// nutrientData is a nutrient of a recipe in the API's nutrition data.
+type nutrientData struct {
+	Name                string  `json:"name"`
+	Amount              float64 `json:"amount"`
+	Unit                string  `json:"unit"`
+	PercentOfDailyNeeds float64 `json:"percentOfDailyNeeds"`
+}
+
+// nutrientSet holds the lower-case names of the nutrients kept from the
+// API's nutrition data; nil keeps them all.
+type nutrientSet map[string]bool
+
+func newNutrientSet(names []string) nutrientSet {
+	set := make(nutrientSet, len(names))
+	for _, name := range names {
+		if strings.EqualFold(name, AllNutrients) {
+			return nil
+		}
+		set[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	return set
+}
+
+// pick returns the kept nutrients, by the name the API gives them.
+func (s nutrientSet) pick(data []nutrientData) map[string]recipes.NutrientAmount {
+	nutrients := make(map[string]recipes.NutrientAmount, len(s))
+	for _, nutrient := range data {
+		if s == nil || s[strings.ToLower(nutrient.Name)] {
+			nutrients[nutrient.Name] = recipes.NutrientAmount{
+				Amount:       nutrient.Amount,
+				Unit:         nutrient.Unit,
+				DailyPercent: nutrient.PercentOfDailyNeeds,
+			}
+		}
+	}
+	return nutrients
+}
+
+func parseResponse(response *Response, kept nutrientSet) []recipes.Recipe {
+	allRecipes := make([]recipes.Recipe, 0, len(response.Results))
+
+	for _, result := range response.Results {
+		instructions, equipment := parseInstructions(result.AnalyzedInstructions)
+		allRecipes = append(allRecipes, recipes.Recipe{
+			ID:                result.ID,
+			Title:             result.Title,
+			UsedIngredients:   toIngredients(result.UsedIngredients),
+			MissedIngredients: toIngredients(result.MissedIngredients),
+			Nutrients:         kept.pick(result.Nutrition.Nutrients),
+			Instructions:      instructions,
+			Servings:          result.Servings,
+			ReadyInMinutes:    result.ReadyInMinutes,
+			Equipment:         equipment,
+			PricePerServing:   recipes.CentsToMoney(result.PricePerServing),
+			SourceURL:         result.SourceURL,
+			Author:            result.SourceName,
+			ImageURL:          result.Image,
+			Likes:             result.AggregateLikes,
+			HealthScore:       result.HealthScore,
+			Cuisines:          recipes.Cuisines(result.Cuisines),
+			Source:            "spoonacular",
+			Estimated:         estimatedFields(result.PricePerServing),
+		})
+	}
+	return allRecipes
+}
+
+// estimatedFields lists the estimated fields of a recipe: Spoonacular's prices
+// are estimated from the ingredients.
+func estimatedFields(pricePerServing float64) []string {
+	if pricePerServing > 0 {
+		return []string{recipes.EstimatedPrice}
+	}
+	return nil
+}
+
+func toIngredients(ingredients []Ingredient) []recipes.Ingredient {
+	converted := make([]recipes.Ingredient, 0, len(ingredients))
+	for _, ingredient := range ingredients {
+		converted = append(converted, recipes.Ingredient{
+			Name:   ingredient.Name,
+			Amount: ingredient.Amount,
+			Unit:   ingredient.Unit,
+			Forms:  recipes.TagForms(ingredient.Name + " " + ingredient.Original + " " + strings.Join(ingredient.Meta, " ")),
+		})
+	}
+	return converted
+}
@@ -0,0 +1,490 @@
+package spoonacular
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mawojcik/meals_generator/internal/golden"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// fixtureTransport answers every request with a file from testdata and
+// records the requests. Requests made with one of the spent keys get a 402
+// response instead.
+type fixtureTransport struct {
+	status   int
+	fixture  string
+	header   http.Header
+	spent    map[string]bool
+	requests []*http.Request
+}
+
+func (t *fixtureTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	t.requests = append(t.requests, request)
+	status, fixture := t.status, t.fixture
+	if t.spent[request.URL.Query().Get("apiKey")] {
+		status, fixture = http.StatusPaymentRequired, "unauthorized.json"
+	}
+	body, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		return nil, err
+	}
+	header := t.header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set("Content-Type", "application/json")
+	return &http.Response{
+		StatusCode:    status,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}, nil
+}
+
+// newFixtureClient returns a client whose requests are answered by transport.
+func newFixtureClient(transport *fixtureTransport) *Client {
+	client := NewClient("secret-key")
+	client.HTTPClient = &http.Client{Transport: transport}
+	return client
+}
+
+func TestParseJSON(t *testing.T) {
+	response, err := parseJSON(golden.Fixture(t, "complexSearch.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Results) != 2 {
+		t.Fatalf("got %d results, want 2", len(response.Results))
+	}
+	first := response.Results[0]
+	if first.ID != 715415 || first.Title != "Red Lentil Soup with Chicken and Turnips" {
+		t.Errorf("got recipe %d %q, want 715415 \"Red Lentil Soup with Chicken and Turnips\"", first.ID, first.Title)
+	}
+	if len(first.UsedIngredients) != 2 || len(first.MissedIngredients) != 3 {
+		t.Errorf("got %d used and %d missed ingredients, want 2 and 3", len(first.UsedIngredients),
+			len(first.MissedIngredients))
+	}
+	if first.PricePerServing != 276.67 {
+		t.Errorf("got price per serving %v, want 276.67", first.PricePerServing)
+	}
+}
+
+func TestParseJSONInvalid(t *testing.T) {
+	_, err := parseJSON([]byte(`{"results": [`))
+	if err == nil {
+		t.Fatal("parsing truncated JSON succeeded")
+	}
+}
+
+func TestParseJSONSkipsInvalidResults(t *testing.T) {
+	response, err := parseJSON(golden.Fixture(t, "complexSearchInvalid.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Results) != 1 || response.Results[0].ID != 715415 {
+		t.Fatalf("got results %+v, want only recipe 715415", response.Results)
+	}
+	want := []struct {
+		index, id int
+		reason    string
+	}{
+		{1, 716406, "missing title"},
+		{2, 644387, "empty nutrition"},
+		{3, 782585, "cannot unmarshal"},
+		{4, 795751, "nutrient 1 has no name"},
+	}
+	if len(response.Invalid) != len(want) {
+		t.Fatalf("got invalid results %+v, want %d", response.Invalid, len(want))
+	}
+	for i, invalid := range response.Invalid {
+		if invalid.Index != want[i].index || invalid.ID != want[i].id || !strings.Contains(invalid.Reason, want[i].reason) {
+			t.Errorf("got invalid result %+v, want %+v", invalid, want[i])
+		}
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	response, err := parseJSON(golden.Fixture(t, "complexSearch.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allRecipes := parseResponse(response, newNutrientSet(DefaultNutrients))
+	golden.CheckJSON(t, "complexSearch.golden.json", allRecipes)
+
+	// Nutrients the program does not use are dropped
+	if _, found := allRecipes[0].Nutrients["Fat"]; found {
+		t.Error("untracked nutrient Fat was kept")
+	}
+	// unless asked for, in any case
+	chosen := parseResponse(response, newNutrientSet([]string{"fat"}))
+	if fat, found := chosen[0].Nutrients["Fat"]; !found || len(chosen[0].Nutrients) != 1 {
+		t.Errorf("got nutrients %v keeping fat, want only Fat", chosen[0].Nutrients)
+	} else if fat.DailyPercent <= 0 {
+		t.Errorf("got %v, want Fat's share of the daily value", fat)
+	}
+	if all := parseResponse(response, newNutrientSet([]string{AllNutrients})); len(all[0].Nutrients) <= 3 {
+		t.Errorf("got nutrients %v keeping all, want every one", all[0].Nutrients)
+	}
+	// Steps are trimmed and kept on one line, and the steps of every part of
+	// the instructions are kept in order
+	wantStep := "Add the onion, carrots and celery and cook for 8-10 minutes or until tender, stirring occasionally."
+	if got := allRecipes[0].Instructions[1]; got != wantStep {
+		t.Errorf("got step %q, want %q", got, wantStep)
+	}
+	if got := len(allRecipes[1].Instructions); got != 2 {
+		t.Errorf("got %d steps for a recipe in two parts, want 2", got)
+	}
+	// Equipment is listed once however many steps use it
+	if got := allRecipes[0].Equipment; !slices.Equal(got, []string{"dutch oven", "pot"}) ||
+		allRecipes[0].ReadyInMinutes != 55 {
+		t.Errorf("got equipment %q ready in %d minutes, want a dutch oven and a pot in 55", got,
+			allRecipes[0].ReadyInMinutes)
+	}
+}
+
+func TestParseResponseEmpty(t *testing.T) {
+	allRecipes := parseResponse(&Response{}, newNutrientSet(DefaultNutrients))
+	if allRecipes == nil || len(allRecipes) != 0 {
+		t.Errorf("got %v for no results, want an empty list", allRecipes)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	transport := &fixtureTransport{
+		status:  http.StatusOK,
+		fixture: "complexSearch.json",
+		header:  http.Header{"X-Api-Quota-Left": {"142.5"}, "X-Api-Quota-Used": {"7.5"}},
+	}
+	client := newFixtureClient(transport)
+
+	allRecipes, err := client.Search(context.Background(), recipes.Query{
+		Ingredients:     []string{"chicken breast", "red onion"},
+		NumberOfRecipes: 2,
+		Diets:           []string{"gluten free"},
+		Targets:         recipes.NutritionTargets{MaxCalories: 600},
+		Prep:            recipes.PrepLimits{MaxReadyTime: 30, Equipment: []string{"blender", "oven"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allRecipes) != 2 {
+		t.Fatalf("got %d recipes, want 2", len(allRecipes))
+	}
+	if len(transport.requests) != 1 {
+		t.Fatalf("made %d requests, want 1", len(transport.requests))
+	}
+
+	request := transport.requests[0]
+	if request.URL.Host != "api.spoonacular.com" || request.URL.Path != "/recipes/complexSearch" {
+		t.Errorf("requested %s, want https://api.spoonacular.com/recipes/complexSearch", request.URL)
+	}
+	query := request.URL.Query()
+	for name, want := range map[string]string{
+		"apiKey":             "secret-key",
+		"includeIngredients": "chicken breast,red onion",
+		"number":             "2",
+		"diet":               "gluten free",
+		"maxCalories":        "600",
+		"maxReadyTime":       "30",
+		"equipment":          "blender,oven",
+		"addRecipeNutrition": "true",
+	} {
+		if got := query.Get(name); got != want {
+			t.Errorf("got %s=%q, want %q", name, got, want)
+		}
+	}
+	if query.Has("offset") {
+		t.Error("the first page was requested with an offset")
+	}
+
+	if got := client.QuotaLeft(); got != "142.5" {
+		t.Errorf("got quota left %q, want 142.5", got)
+	}
+	if got := client.QuotaUsed(); got != "7.5" {
+		t.Errorf("got quota used %q, want 7.5", got)
+	}
+}
+
+func TestSearchPages(t *testing.T) {
+	transport := &fixtureTransport{status: http.StatusOK, fixture: "complexSearch.json"}
+	client := newFixtureClient(transport)
+
+	// The fixture has 2 of the 86 results whatever number is asked for
+	allRecipes, err := client.Search(context.Background(), recipes.Query{
+		Ingredients:     []string{"chicken breast"},
+		NumberOfRecipes: 5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allRecipes) != 5 {
+		t.Fatalf("got %d recipes, want 5", len(allRecipes))
+	}
+	if len(transport.requests) != 3 {
+		t.Fatalf("made %d requests, want 3", len(transport.requests))
+	}
+	for i, want := range []struct{ number, offset string }{{"5", ""}, {"3", "2"}, {"1", "4"}} {
+		query := transport.requests[i].URL.Query()
+		if query.Get("number") != want.number || query.Get("offset") != want.offset {
+			t.Errorf("request %d asked for number=%q offset=%q, want %q and %q", i+1, query.Get("number"),
+				query.Get("offset"), want.number, want.offset)
+		}
+	}
+
+	// Nothing is asked for past totalResults
+	transport.requests = nil
+	allRecipes, err = client.Search(context.Background(), recipes.Query{
+		Ingredients:     []string{"chicken breast"},
+		NumberOfRecipes: 10,
+		Offset:          84,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allRecipes) != 2 || len(transport.requests) != 1 {
+		t.Errorf("got %d recipes from %d requests, want the last 2 from 1", len(allRecipes), len(transport.requests))
+	}
+}
+
+func TestSearchInvalidResults(t *testing.T) {
+	transport := &fixtureTransport{status: http.StatusOK, fixture: "complexSearchInvalid.json"}
+	client := newFixtureClient(transport)
+	query := recipes.Query{Ingredients: []string{"chicken breast"}, NumberOfRecipes: 5}
+
+	// The good results are returned and the others left out
+	allRecipes, err := client.Search(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allRecipes) != 1 || allRecipes[0].ID != 715415 {
+		t.Errorf("got %+v, want only recipe 715415", allRecipes)
+	}
+	// and every result counts towards the total, so no more pages are asked
+	// for
+	if len(transport.requests) != 1 {
+		t.Errorf("made %d requests, want 1", len(transport.requests))
+	}
+
+	// unless the client is strict
+	client.Strict = true
+	_, err = client.Search(context.Background(), query)
+	var invalidErr *InvalidResultsError
+	if !errors.As(err, &invalidErr) || len(invalidErr.Results) != 4 {
+		t.Fatalf("got error %v, want the 4 invalid results", err)
+	}
+	if !strings.Contains(err.Error(), "result 2 (recipe 644387): empty nutrition") {
+		t.Errorf("got error %q, want each invalid result described", err)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	transport := &fixtureTransport{status: http.StatusOK, fixture: "random.json"}
+	client := newFixtureClient(transport)
+
+	found, err := client.Random(context.Background(), []string{"breakfast", "vegetarian"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := transport.requests[0].URL.Query()
+	if transport.requests[0].URL.Path != "/recipes/random" || query.Get("include-tags") != "breakfast,vegetarian" ||
+		query.Get("number") != "1" {
+		t.Errorf("requested %s, want /recipes/random with the tags and number", transport.requests[0].URL)
+	}
+	if len(found) != 1 {
+		t.Fatalf("got %d recipes, want 1", len(found))
+	}
+	recipe := found[0]
+	if recipe.ID != 715497 || recipe.Source != "spoonacular" || len(recipe.UsedIngredients) != 2 ||
+		recipe.ImageURL == "" || recipe.Instructions[0] != "Blend everything until smooth." {
+		t.Errorf("got %+v, want the fixture's recipe in full", recipe)
+	}
+	if _, ok := recipe.Nutrients["Sugar"]; ok || recipe.Nutrients["Calories"].Amount != 310.5 {
+		t.Errorf("got nutrients %v, want only the tracked ones", recipe.Nutrients)
+	}
+}
+
+func TestSearchUnauthorized(t *testing.T) {
+	transport := &fixtureTransport{status: http.StatusUnauthorized, fixture: "unauthorized.json"}
+	client := newFixtureClient(transport)
+
+	_, err := client.Search(context.Background(), recipes.Query{Ingredients: []string{"egg"}, NumberOfRecipes: 1})
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("got error %v, want ErrUnauthorized", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != 401 || apiErr.Message == "" {
+		t.Errorf("got %#v, want the API's error payload", err)
+	}
+	// Client errors are not retried
+	if len(transport.requests) != 1 {
+		t.Errorf("made %d requests, want 1", len(transport.requests))
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	transport := &fixtureTransport{status: http.StatusOK, fixture: "random.json",
+		spent: map[string]bool{"secret-key": true, "spare-1": true}}
+	client := NewClient("secret-key", "spare-1", "spare-2")
+	client.HTTPClient = &http.Client{Transport: transport}
+	var exhausted []string
+	client.OnKeyExhausted = func(apiKey string, err *APIError) {
+		exhausted = append(exhausted, apiKey)
+	}
+
+	_, err := client.Random(context.Background(), nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.APIKey() != "spare-2" || !slices.Equal(exhausted, []string{"secret-key", "spare-1"}) {
+		t.Errorf("using %q after spending %v, want spare-2 after the other two", client.APIKey(), exhausted)
+	}
+	// The spent keys are not tried again
+	_, err = client.Random(context.Background(), nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transport.requests) != 4 {
+		t.Errorf("made %d requests, want 4", len(transport.requests))
+	}
+
+	transport.spent["spare-2"] = true
+	_, err = client.Random(context.Background(), nil, 1)
+	if !errors.Is(err, ErrQuotaExhausted) {
+		t.Errorf("got error %v with every key spent, want ErrQuotaExhausted", err)
+	}
+}
+
+func TestRedactedURL(t *testing.T) {
+	u, err := url.Parse("https://api.spoonacular.com/recipes/complexSearch?apiKey=secret-key&number=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	redacted := redactedURL(u)
+	want := "https://api.spoonacular.com/recipes/complexSearch?apiKey=REDACTED&number=2"
+	if redacted != want {
+		t.Errorf("got %q, want %q", redacted, want)
+	}
+	if u.Query().Get("apiKey") != "secret-key" {
+		t.Error("redacting changed the original URL")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	transport := &fixtureTransport{status: http.StatusOK, fixture: "complexSearch.json"}
+	client := newFixtureClient(transport)
+	var printed bytes.Buffer
+	client.DryRun = &printed
+
+	_, err := client.Search(context.Background(), recipes.Query{Ingredients: []string{"egg"}, NumberOfRecipes: 10})
+	if !errors.Is(err, ErrDryRun) {
+		t.Fatalf("got error %v, want ErrDryRun", err)
+	}
+	if len(transport.requests) != 0 {
+		t.Errorf("made %d requests in a dry run, want none", len(transport.requests))
+	}
+	// 1 point, 0.01 per result and 0.025 per result for each of the 4 additions
+	if !bytes.Contains(printed.Bytes(), []byte("estimated cost: 2.10 points")) ||
+		bytes.Contains(printed.Bytes(), []byte("secret-key")) {
+		t.Errorf("printed %q, want the redacted URL and its cost", printed.String())
+	}
+}
+
+func TestSpend(t *testing.T) {
+	transport := &fixtureTransport{status: http.StatusOK, fixture: "complexSearch.json"}
+	client := newFixtureClient(transport)
+	errBudget := errors.New("over budget")
+	var asked []float64
+	client.Spend = func(ctx context.Context, points float64) error {
+		asked = append(asked, points)
+		return errBudget
+	}
+
+	_, err := client.Search(context.Background(), recipes.Query{Ingredients: []string{"egg"}, NumberOfRecipes: 2})
+	if !errors.Is(err, errBudget) || len(transport.requests) != 0 {
+		t.Fatalf("got error %v after %d requests, want the Spend error before any", err, len(transport.requests))
+	}
+	if len(asked) != 1 || asked[0] != EstimatePoints(baseURL+"/recipes/complexSearch?number=2&fillIngredients=true"+
+		"&addRecipeInformation=true&addRecipeNutrition=true&addRecipeInstructions=true") {
+		t.Errorf("Spend was asked for %v, want the search's estimated cost", asked)
+	}
+}
+
+func TestEstimatePoints(t *testing.T) {
+	tests := []struct {
+		url  string
+		want float64
+	}{
+		{baseURL + "/recipes/complexSearch?number=10", 1.1},
+		{baseURL + "/recipes/complexSearch?number=10&addRecipeNutrition=true", 1.35},
+		{baseURL + "/recipes/random?number=3", 1.03},
+		{baseURL + "/recipes/informationBulk?ids=1,2,3", 2},
+		{baseURL + "/recipes/1/nutritionWidget.json", 1},
+	}
+	for _, tt := range tests {
+		got := EstimatePoints(tt.url)
+		if got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("EstimatePoints(%q) = %g, want %g", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestWinePairing(t *testing.T) {
+	transport := &fixtureTransport{status: http.StatusOK, fixture: "winePairing.json"}
+	client := newFixtureClient(transport)
+
+	pairing, err := client.WinePairing(context.Background(), "chicken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport.requests[0].URL.Path != "/food/wine/pairing" || transport.requests[0].URL.Query().Get("food") != "chicken" {
+		t.Errorf("requested %s, want /food/wine/pairing for the food", transport.requests[0].URL)
+	}
+	if pairing == nil || !slices.Equal(pairing.Wines, []string{"chardonnay", "gewurztraminer", "riesling"}) ||
+		pairing.Food != "chicken" || pairing.Note == "" {
+		t.Fatalf("got %+v, want the fixture's pairing", pairing)
+	}
+
+	for _, status := range []int{http.StatusOK, http.StatusBadRequest} {
+		transport := &fixtureTransport{status: status, fixture: "winePairingFailure.json"}
+		pairing, err := newFixtureClient(transport).WinePairing(context.Background(), "apple")
+		if err != nil || pairing != nil {
+			t.Errorf("status %d: got %+v, %v, want no pairing", status, pairing, err)
+		}
+	}
+}
+
+func TestSubstitutes(t *testing.T) {
+	transport := &fixtureTransport{status: http.StatusOK, fixture: "substitutes.json"}
+	client := newFixtureClient(transport)
+
+	substitutes, err := client.Substitutes(context.Background(), "buttermilk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport.requests[0].URL.Path != "/food/ingredients/substitutes" ||
+		transport.requests[0].URL.Query().Get("ingredientName") != "buttermilk" {
+		t.Errorf("requested %s, want /food/ingredients/substitutes for the ingredient", transport.requests[0].URL)
+	}
+	if len(substitutes) != 3 || substitutes[0] != "1 cup = 1 cup milk + 1 tbsp lemon juice" {
+		t.Fatalf("got %q, want the fixture's substitutes", substitutes)
+	}
+
+	for _, status := range []int{http.StatusOK, http.StatusBadRequest} {
+		transport := &fixtureTransport{status: status, fixture: "substitutesFailure.json"}
+		substitutes, err := newFixtureClient(transport).Substitutes(context.Background(), "saffron")
+		if err != nil || substitutes != nil {
+			t.Errorf("status %d: got %q, %v, want no substitutes", status, substitutes, err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package spoonacular
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrUnauthorized is returned when the API rejects the API key.
+var ErrUnauthorized = errors.New("you are not authorized")
+
+// ErrQuotaExhausted is returned when the daily quota of API points is used up.
+var ErrQuotaExhausted = errors.New("daily Spoonacular API quota exhausted, try again tomorrow")
+
+// ErrRateLimited is returned when requests are still rate limited after all
+// retries.
+var ErrRateLimited = errors.New("too many requests to the Spoonacular API")
+
+// APIError is an error response of the API. It matches ErrUnauthorized,
+// ErrQuotaExhausted or ErrRateLimited with errors.Is, depending on the status.
+type APIError struct {
+	StatusCode int
+	// Code and Message come from the API's error payload and are empty when
+	// it sent none.
+	Code    int
+	Message string
+	// QuotaUsed and QuotaLeft are the X-API-Quota-Used and X-API-Quota-Left
+	// headers of the response.
+	QuotaUsed string
+	QuotaLeft string
+}
+
+// newAPIError builds an APIError from a response status, headers and body.
+func newAPIError(status int, header http.Header, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: status,
+		QuotaUsed:  header.Get("X-API-Quota-Used"),
+		QuotaLeft:  header.Get("X-API-Quota-Left"),
+	}
+	var payload struct {
+		Status  string `json:"status"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &payload) == nil && payload.Status == "failure" {
+		apiErr.Code = payload.Code
+		apiErr.Message = strings.TrimSpace(payload.Message)
+	}
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	text := fmt.Sprintf("API returned %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		text += ": " + e.Message
+	}
+	return text
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrQuotaExhausted:
+		return e.StatusCode == http.StatusPaymentRequired
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
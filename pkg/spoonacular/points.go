@@ -0,0 +1,65 @@
+package spoonacular
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrDryRun is returned for the requests a client with DryRun set prints
+// instead of making.
+var ErrDryRun = errors.New("dry run, no request made")
+
+// Points per request and per recipe returned, as the Spoonacular price list
+// gives them for the endpoints the client calls.
+const (
+	pointsPerRequest = 1
+	pointsPerResult  = 0.01
+	// pointsPerAddition is charged per result for each of fillIngredients,
+	// addRecipeInformation, addRecipeNutrition and addRecipeInstructions.
+	pointsPerAddition = 0.025
+	// pointsPerBulkRecipe is charged for each recipe of informationBulk past
+	// the first.
+	pointsPerBulkRecipe = 0.5
+)
+
+// EstimatePoints returns the points the API would charge for a request to
+// rawURL. The API reports what it actually charged in the X-API-Quota-Request
+// header, so this is only used before a request is made, for --budget and
+// --dry-run.
+func EstimatePoints(rawURL string) float64 {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return pointsPerRequest
+	}
+	query := u.Query()
+	switch {
+	case strings.HasSuffix(u.Path, "/recipes/complexSearch"):
+		results := float64(queryNumber(query, "number", 10))
+		points := pointsPerRequest + pointsPerResult*results
+		for _, addition := range []string{"fillIngredients", "addRecipeInformation", "addRecipeNutrition",
+			"addRecipeInstructions"} {
+			if query.Get(addition) == "true" {
+				points += pointsPerAddition * results
+			}
+		}
+		return points
+	case strings.HasSuffix(u.Path, "/recipes/random"), strings.HasSuffix(u.Path, "/food/ingredients/search"):
+		return pointsPerRequest + pointsPerResult*float64(queryNumber(query, "number", 1))
+	case strings.HasSuffix(u.Path, "/recipes/informationBulk"):
+		recipes := len(strings.Split(query.Get("ids"), ","))
+		return pointsPerRequest + pointsPerBulkRecipe*float64(recipes-1)
+	}
+	return pointsPerRequest
+}
+
+// queryNumber returns a number parameter of a query, or fallback when it is
+// missing, as the API does.
+func queryNumber(query url.Values, name string, fallback int) int {
+	number, err := strconv.Atoi(query.Get(name))
+	if err != nil || number <= 0 {
+		return fallback
+	}
+	return number
+}
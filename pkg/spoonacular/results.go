@@ -0,0 +1,112 @@
+package spoonacular
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// InvalidResult is a result of a response that was left out as malformed or
+// incomplete.
+type InvalidResult struct {
+	// Index is the position of the result, counting from 0: in its response
+	// in Response.Invalid, over every page of the search in an
+	// InvalidResultsError.
+	Index int
+	// ID is the recipe ID of the result, 0 when it has none.
+	ID     int
+	Reason string
+}
+
+func (invalid InvalidResult) String() string {
+	if invalid.ID > 0 {
+		return fmt.Sprintf("result %d (recipe %d): %s", invalid.Index, invalid.ID, invalid.Reason)
+	}
+	return fmt.Sprintf("result %d: %s", invalid.Index, invalid.Reason)
+}
+
+// InvalidResultsError is returned by a Client with Strict set when a
+// response has malformed or incomplete results.
+type InvalidResultsError struct {
+	Results []InvalidResult
+}
+
+func (e *InvalidResultsError) Error() string {
+	descriptions := make([]string, len(e.Results))
+	for i, invalid := range e.Results {
+		descriptions[i] = invalid.String()
+	}
+	return fmt.Sprintf("%d invalid results in the API response: %s", len(e.Results), strings.Join(descriptions, "; "))
+}
+
+// checkedResult is a result of a response that can tell whether it is fit
+// to be used.
+type checkedResult interface {
+	recipeID() int
+	// problem describes what makes the result unfit, empty when nothing does.
+	problem() string
+}
+
+// decodeResults decodes each of messages on its own and checks it, returning
+// the fit results in order and the others as InvalidResults indexed by their
+// position in messages.
+func decodeResults[T checkedResult](messages []json.RawMessage) ([]T, []InvalidResult) {
+	results := make([]T, 0, len(messages))
+	var invalid []InvalidResult
+	for i, message := range messages {
+		var result T
+		err := json.Unmarshal(message, &result)
+		if err != nil {
+			// The fields before the malformed one are set, so the ID often is
+			invalid = append(invalid, InvalidResult{Index: i, ID: result.recipeID(), Reason: err.Error()})
+			continue
+		}
+		if reason := result.problem(); reason != "" {
+			invalid = append(invalid, InvalidResult{Index: i, ID: result.recipeID(), Reason: reason})
+			continue
+		}
+		results = append(results, result)
+	}
+	return results, invalid
+}
+
+// recipeProblem describes what makes a recipe result unfit: it has no ID or
+// title to show it by, or no nutrition to screen and rank it with.
+func recipeProblem(id int, title string, nutrients []nutrientData) string {
+	switch {
+	case id <= 0:
+		return "missing recipe ID"
+	case strings.TrimSpace(title) == "":
+		return "missing title"
+	case len(nutrients) == 0:
+		return "empty nutrition"
+	}
+	for i, nutrient := range nutrients {
+		if strings.TrimSpace(nutrient.Name) == "" {
+			return fmt.Sprintf("nutrient %d has no name", i+1)
+		}
+	}
+	return ""
+}
+
+// checkResults fails with an InvalidResultsError when the client is strict
+// and there are invalid results, and otherwise warns of each. offset is the
+// position in the search of the first result of the response.
+func (c *Client) checkResults(invalid []InvalidResult, offset int) error {
+	if len(invalid) == 0 {
+		return nil
+	}
+	placed := make([]InvalidResult, len(invalid))
+	for i, result := range invalid {
+		result.Index += offset
+		placed[i] = result
+	}
+	if c.Strict {
+		return &InvalidResultsError{Results: placed}
+	}
+	for _, result := range placed {
+		slog.Warn("skipped invalid result", "index", result.Index, "recipe", result.ID, "reason", result.Reason)
+	}
+	return nil
+}
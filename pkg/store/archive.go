@@ -0,0 +1,229 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Archive is a consistent copy of every table of a database, for moving the
+// whole state to another database, see CreateArchive and RestoreArchive.
+type Archive struct {
+	// SchemaVersion is the schema the tables were copied at, which the
+	// database restored to must be at too.
+	SchemaVersion int       `json:"schemaVersion"`
+	TakenAt       time.Time `json:"takenAt"`
+	// Tables holds the tables by name. The rows of all the queries tables
+	// are under queriesTable, whatever the sharding of the database.
+	Tables map[string]BackupTable `json:"tables"`
+}
+
+// The column names and query hashes of an archive go into the statements
+// restoring it, so they are checked first.
+var (
+	columnName = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+	queryHash  = regexp.MustCompile(`^[0-9a-f]{64}$`)
+)
+
+// droppedTables were created by a migration and dropped by a later one.
+var droppedTables = []string{"pantry", "experiment_shown"}
+
+// generatedIDTables are the tables whose id column is generated, see
+// dialect.autoIncrement.
+var generatedIDTables = []string{"jobs", "quota_reservations", "digest_items", "recipe_reports", "custom_recipes",
+	"experiment_views"}
+
+// archivedTables are the tables of the latest schema, the queries tables
+// aside.
+func archivedTables() []string {
+	tables := []string{"recipes", "recipe_ingredients", "recipe_nutrients"}
+	for _, m := range migrations {
+		tables = append(tables, m.creates...)
+	}
+	return slices.DeleteFunc(tables, func(table string) bool {
+		return slices.Contains(droppedTables, table)
+	})
+}
+
+// schemaVersion returns the version of the database's schema, an error when
+// migrations are pending, as their tables would be missing.
+func (s *sqlStore) schemaVersion(ctx context.Context) (int, error) {
+	all, err := s.Migrations(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range all {
+		if m.AppliedAt.IsZero() {
+			return 0, fmt.Errorf("migration %d (%s) is pending, run recipefinder migrate first", m.Version, m.Name)
+		}
+	}
+	return all[len(all)-1].Version, nil
+}
+
+// CreateArchive copies every table in a single transaction, so the copy is of
+// one point in time even while other commands write: SQLite and InnoDB both
+// read a transaction's rows from the snapshot of its first read, and
+// PostgreSQL does at the isolation level of dialect.archiveTx.
+func (s *sqlStore) CreateArchive(ctx context.Context) (*Archive, error) {
+	version, err := s.schemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.db.BeginTx(ctx, s.dialect.archiveTx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %v", err)
+	}
+	// Nothing is written, so there is nothing to commit
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	archive := &Archive{SchemaVersion: version, TakenAt: time.Now(), Tables: make(map[string]BackupTable)}
+	for _, table := range archivedTables() {
+		archive.Tables[table], err = exportTable(ctx, tx, table)
+		if err != nil {
+			return nil, fmt.Errorf("error copying table %s: %v", table, err)
+		}
+	}
+	var queries BackupTable
+	for _, table := range shardTables(s.shardDigits) {
+		exported, err := exportTable(ctx, tx, table)
+		if err != nil {
+			return nil, fmt.Errorf("error copying table %s: %v", table, err)
+		}
+		queries.Columns = exported.Columns
+		queries.Rows = append(queries.Rows, exported.Rows...)
+	}
+	if queries.Rows == nil {
+		queries.Rows = [][]any{}
+	}
+	archive.Tables[queriesTable] = queries
+	return archive, nil
+}
+
+// RestoreArchive replaces the rows of every table with the archive's, in a
+// single transaction, so a failed restore leaves the database as it was. The
+// queries are put in the shards of this database.
+func (s *sqlStore) RestoreArchive(ctx context.Context, archive Archive) error {
+	version, err := s.schemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if archive.SchemaVersion != version {
+		return fmt.Errorf("the snapshot is of schema version %d and the database is at %d, restore it with the "+
+			"version of recipefinder that took it, or with a database at its version", archive.SchemaVersion, version)
+	}
+	tables := archivedTables()
+	for table := range archive.Tables {
+		if table != queriesTable && !slices.Contains(tables, table) {
+			return fmt.Errorf("the snapshot has unknown table %s", table)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	// Rolling back after a commit does nothing
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, table := range append(slices.Clone(tables), shardTables(s.shardDigits)...) {
+		_, err := tx.ExecContext(ctx, "DELETE FROM "+table)
+		if err != nil {
+			return fmt.Errorf("error emptying table %s: %v", table, err)
+		}
+	}
+	for _, table := range tables {
+		err := importRows(ctx, tx, archive.Tables[table], func([]any) (string, error) { return table, nil })
+		if err != nil {
+			return fmt.Errorf("error restoring table %s: %v", table, err)
+		}
+	}
+	queries := archive.Tables[queriesTable]
+	hashColumn := slices.Index(queries.Columns, "query_hash")
+	if hashColumn == -1 && len(queries.Rows) > 0 {
+		return errors.New("the snapshot's queries have no query_hash column")
+	}
+	err = importRows(ctx, tx, queries, func(row []any) (string, error) {
+		hash, _ := row[hashColumn].(string)
+		if !queryHash.MatchString(hash) {
+			return "", fmt.Errorf("invalid query hash %q", hash)
+		}
+		return s.queryTable(hash), nil
+	})
+	if err != nil {
+		return fmt.Errorf("error restoring queries: %v", err)
+	}
+	if s.dialect.resetID != "" {
+		for _, table := range generatedIDTables {
+			_, err := tx.ExecContext(ctx, fmt.Sprintf(s.dialect.resetID, table))
+			if err != nil {
+				return fmt.Errorf("error resetting the ids of table %s: %v", table, err)
+			}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing restore: %v", err)
+	}
+	return nil
+}
+
+// importRows inserts the rows of an exported table, each into the table
+// tableOf names for it.
+func importRows(ctx context.Context, tx *sql.Tx, exported BackupTable,
+	tableOf func(row []any) (string, error)) error {
+	for _, column := range exported.Columns {
+		if !columnName.MatchString(column) {
+			return fmt.Errorf("invalid column name %q", column)
+		}
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(exported.Columns)), ", ")
+	for _, row := range exported.Rows {
+		if len(row) != len(exported.Columns) {
+			return fmt.Errorf("row has %d values for %d columns", len(row), len(exported.Columns))
+		}
+		table, err := tableOf(row)
+		if err != nil {
+			return err
+		}
+		values := make([]any, len(row))
+		for i, value := range row {
+			values[i] = importedValue(value)
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO "+table+" ("+strings.Join(exported.Columns, ", ")+
+			") VALUES ("+placeholders+")", values...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importedValue turns a value decoded from JSON back into what its column
+// holds: whole numbers become integers, as timestamps and IDs are.
+func importedValue(value any) any {
+	switch number := value.(type) {
+	case json.Number:
+		if integer, err := number.Int64(); err == nil {
+			return integer
+		}
+		float, _ := number.Float64()
+		return float
+	case float64:
+		if number == math.Trunc(number) && math.Abs(number) < 1<<53 {
+			return int64(number)
+		}
+	}
+	return value
+}
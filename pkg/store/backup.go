@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Backup is what a backup file holds: the rows of the tables a migration,
+// or the rollback of one, was about to delete or rewrite.
+type Backup struct {
+	Version int `json:"version"`
+	// Direction is "up" for a migration and "down" for a rollback.
+	Direction string                 `json:"direction"`
+	TakenAt   time.Time              `json:"takenAt"`
+	Tables    map[string]BackupTable `json:"tables"`
+}
+
+// BackupTable holds the rows of a table, each value in the column order.
+// Text and blobs are strings.
+type BackupTable struct {
+	Columns []string `json:"columns"`
+	Rows    [][]any  `json:"rows"`
+}
+
+// backupTables exports the tables to a timestamped file in the backup
+// directory and returns its path. Nothing is written, and the path is empty,
+// when the tables are all empty or no backup directory is configured.
+func (s *sqlStore) backupTables(ctx context.Context, version int, direction string, tables []string) (string, error) {
+	if len(tables) == 0 {
+		return "", nil
+	}
+	backup := Backup{Version: version, Direction: direction, TakenAt: time.Now(), Tables: make(map[string]BackupTable)}
+	rows := 0
+	for _, table := range tables {
+		exported, err := exportTable(ctx, s.db, table)
+		if err != nil {
+			return "", fmt.Errorf("error backing up table %s: %v", table, err)
+		}
+		backup.Tables[table] = exported
+		rows += len(exported.Rows)
+	}
+	if rows == 0 {
+		return "", nil
+	}
+	if s.backupDir == "" {
+		slog.Warn("no backup directory configured, not backing up the tables", "migration", version,
+			"tables", strings.Join(tables, ","))
+		return "", nil
+	}
+
+	encoded, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	err = os.MkdirAll(s.backupDir, 0o700)
+	if err != nil {
+		return "", fmt.Errorf("error creating backup directory: %v", err)
+	}
+	path := filepath.Join(s.backupDir, fmt.Sprintf("migration-%d-%s-%s.json", version, direction,
+		backup.TakenAt.Format("20060102T150405")))
+	// The backup holds the user's data, so it is only for them to read
+	err = os.WriteFile(path, encoded, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("error writing backup: %v", err)
+	}
+	return path, nil
+}
+
+// queryer is a *sql.DB, or a *sql.Tx for reading in a transaction.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// exportTable reads every row of a table.
+func exportTable(ctx context.Context, db queryer, table string) (BackupTable, error) {
+	rows, err := db.QueryContext(ctx, "SELECT * FROM "+table)
+	if err != nil {
+		return BackupTable{}, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			slog.Warn("error closing rows", "error", err)
+		}
+	}(rows)
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return BackupTable{}, err
+	}
+	exported := BackupTable{Columns: columns, Rows: [][]any{}}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		err := rows.Scan(pointers...)
+		if err != nil {
+			return BackupTable{}, err
+		}
+		for i, value := range values {
+			// Encoded as JSON, bytes would become base64
+			if bytes, ok := value.([]byte); ok {
+				values[i] = string(bytes)
+			}
+		}
+		exported.Rows = append(exported.Rows, values)
+	}
+	return exported, rows.Err()
+}
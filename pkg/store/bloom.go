@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math"
+	"sync"
+)
+
+const (
+	// minFilterKeys is the fewest query keys a filter is sized for, so a
+	// server starting with an empty cache does not fill its filter at once.
+	minFilterKeys = 100_000
+	// filterFalsePositives is the share of uncached queries the filter lets
+	// through to the database while it holds no more keys than it was sized
+	// for.
+	filterFalsePositives = 0.01
+)
+
+// bloomFilter remembers which cache keys may have recipes. A key it has not
+// seen is certainly not cached; a key it has seen may be, and is looked up.
+// Keys cannot be removed, so expired and purged queries still pass through.
+// It is safe for concurrent use.
+type bloomFilter struct {
+	mu     sync.RWMutex
+	bits   []uint64
+	hashes int
+}
+
+// newBloomFilter sizes a filter for keys keys at filterFalsePositives.
+func newBloomFilter(keys int) *bloomFilter {
+	keys = max(keys, minFilterKeys)
+	bits := math.Ceil(-float64(keys) * math.Log(filterFalsePositives) / (math.Ln2 * math.Ln2))
+	hashes := int(math.Round(bits / float64(keys) * math.Ln2))
+	return &bloomFilter{
+		bits:   make([]uint64, (int(bits)+63)/64),
+		hashes: max(hashes, 1),
+	}
+}
+
+// positions returns the bits of a key, derived from one 64-bit hash by double
+// hashing.
+func (f *bloomFilter) positions(key string) []uint64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(key))
+	sum := hash.Sum64()
+	first, second := sum&math.MaxUint32, sum>>32|1
+
+	size := uint64(len(f.bits) * 64)
+	positions := make([]uint64, f.hashes)
+	for i := range positions {
+		positions[i] = (first + uint64(i)*second) % size
+	}
+	return positions
+}
+
+func (f *bloomFilter) add(key string) {
+	positions := f.positions(key)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, position := range positions {
+		f.bits[position/64] |= 1 << (position % 64)
+	}
+}
+
+// mayContain reports false only for keys that were never added.
+func (f *bloomFilter) mayContain(key string) bool {
+	positions := f.positions(key)
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, position := range positions {
+		if f.bits[position/64]&(1<<(position%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// loadQueryFilter fills a filter with the keys of the unexpired queries cached
+// in the tables, sized for twice as many so it stays accurate while the cache
+// grows.
+func loadQueryFilter(ctx context.Context, db *sql.DB, tables []string, cutoff int64) (*bloomFilter, error) {
+	rows, err := db.QueryContext(ctx,
+		"SELECT DISTINCT sorted_query FROM ("+unionQuery(tables, "sorted_query", "fetched_at >= ?")+") AS cached",
+		repeatArgs(tables, cutoff)...)
+	if err != nil {
+		return nil, fmt.Errorf("error loading cached queries: %v", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			slog.Warn("error closing rows", "error", err)
+		}
+	}(rows)
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		err := rows.Scan(&key)
+		if err != nil {
+			return nil, fmt.Errorf("error loading cached queries: %v", err)
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error loading cached queries: %v", err)
+	}
+
+	filter := newBloomFilter(2 * len(keys))
+	for _, key := range keys {
+		filter.add(key)
+	}
+	return filter, nil
+}
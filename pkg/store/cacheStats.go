@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// cacheLookupsSchema counts the cache lookups by whether the cache had
+// enough recipes, result being "hit" or "miss", since the first of each.
+const cacheLookupsSchema = `
+CREATE TABLE IF NOT EXISTS cache_lookups (
+	result VARCHAR(8) NOT NULL PRIMARY KEY,
+	count  BIGINT     NOT NULL,
+	since  BIGINT     NOT NULL
+)`
+
+// CacheStats describes what the cache holds.
+type CacheStats struct {
+	Recipes     int64
+	Ingredients int64
+	Nutrients   int64
+	// Queries counts the distinct searches recipes are cached for.
+	Queries int64
+	// Expired counts the recipes fetched longer than the TTL ago.
+	Expired int64
+	// Oldest and Newest are when the least and most recently fetched
+	// recipes were fetched, zero when no recipe says.
+	Oldest time.Time
+	Newest time.Time
+	// Hits and Misses count the lookups since TrackedSince, zero when no
+	// lookup was counted yet.
+	Hits         int64
+	Misses       int64
+	TrackedSince time.Time
+	// Size is the size of the database in bytes, tables and indexes.
+	Size int64
+}
+
+// HitRate returns the share of lookups that were hits, 0 without lookups.
+func (c CacheStats) HitRate() float64 {
+	if c.Hits+c.Misses == 0 {
+		return 0
+	}
+	return float64(c.Hits) / float64(c.Hits+c.Misses)
+}
+
+// RecordCacheLookup counts a cache lookup. Two processes counting the first
+// lookup at once may lose one of them, which the counts can afford.
+func (s *sqlStore) RecordCacheLookup(ctx context.Context, hit bool) error {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	updated, err := s.db.ExecContext(ctx, "UPDATE cache_lookups SET count = count + 1 WHERE result = ?", result)
+	if err != nil {
+		return err
+	}
+	count, err := updated.RowsAffected()
+	if err != nil || count > 0 {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, "REPLACE INTO cache_lookups (result, count, since) VALUES (?, 1, ?)", result,
+		time.Now().Unix())
+	return err
+}
+
+// CacheStats counts what the cache holds and how its lookups went.
+func (s *sqlStore) CacheStats(ctx context.Context) (CacheStats, error) {
+	var stats CacheStats
+	for _, count := range []struct {
+		query string
+		value *int64
+	}{
+		{"SELECT COUNT(*) FROM recipes", &stats.Recipes},
+		{"SELECT COUNT(*) FROM recipe_ingredients", &stats.Ingredients},
+		{"SELECT COUNT(*) FROM recipe_nutrients", &stats.Nutrients},
+	} {
+		err := s.db.QueryRowContext(ctx, count.query).Scan(count.value)
+		if err != nil {
+			return stats, err
+		}
+	}
+	// A query is always kept in the same shard, so the shards' counts add up
+	for _, table := range shardTables(s.shardDigits) {
+		var queries int64
+		err := s.db.QueryRowContext(ctx, "SELECT COUNT(DISTINCT query_hash) FROM "+table).Scan(&queries)
+		if err != nil {
+			return stats, err
+		}
+		stats.Queries += queries
+	}
+	if cutoff := s.cutoff(); cutoff > 0 {
+		err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM recipes WHERE fetched_at < ?", cutoff).
+			Scan(&stats.Expired)
+		if err != nil {
+			return stats, err
+		}
+	}
+
+	var oldest, newest int64
+	err := s.db.QueryRowContext(ctx, "SELECT COALESCE(MIN(fetched_at), 0), COALESCE(MAX(fetched_at), 0) "+
+		"FROM recipes WHERE fetched_at > 0").Scan(&oldest, &newest)
+	if err != nil {
+		return stats, err
+	}
+	if oldest > 0 {
+		stats.Oldest = time.Unix(oldest, 0)
+		stats.Newest = time.Unix(newest, 0)
+	}
+
+	var since int64
+	err = s.db.QueryRowContext(ctx, "SELECT COALESCE(SUM(CASE WHEN result = 'hit' THEN count ELSE 0 END), 0), "+
+		"COALESCE(SUM(CASE WHEN result = 'miss' THEN count ELSE 0 END), 0), COALESCE(MIN(since), 0) "+
+		"FROM cache_lookups").Scan(&stats.Hits, &stats.Misses, &since)
+	if err != nil {
+		return stats, err
+	}
+	if since > 0 {
+		stats.TrackedSince = time.Unix(since, 0)
+	}
+
+	err = s.db.QueryRowContext(ctx, s.dialect.databaseSize).Scan(&stats.Size)
+	return stats, err
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// cacheKeyVersion starts every cache key, so keys of a later format never
+// match the ones of this one.
+const cacheKeyVersion = "v2 "
+
+// canonicalQuery is what identifies a query in the cache. Every field is
+// named, so no ingredient can pass for a filter, and fields left empty are
+// omitted, so a filter added later leaves the keys of the queries not using it
+// as they are. The number of recipes asked for is left out on purpose: a
+// search asking for more recipes than are cached fetches only the pages after
+// them, then saves the cached and fetched recipes together, since a save
+// replaces the recipes of the query, see recipes.Finder.
+type canonicalQuery struct {
+	Ingredients  []string `json:"ingredients,omitempty"`
+	Diets        []string `json:"diets,omitempty"`
+	Intolerances []string `json:"intolerances,omitempty"`
+	Exclude      []string `json:"exclude,omitempty"`
+	MaxCalories  float64  `json:"maxCalories,omitempty"`
+	MinProtein   float64  `json:"minProtein,omitempty"`
+	MaxCarbs     float64  `json:"maxCarbs,omitempty"`
+	MaxReadyTime int      `json:"maxReadyTime,omitempty"`
+	Equipment    []string `json:"equipment,omitempty"`
+	NoEquipment  []string `json:"noEquipment,omitempty"`
+}
+
+// cacheKey is the cache key of a query: its canonicalQuery as JSON after
+// cacheKeyVersion. Lists are lowercased, sorted and rid of repeats, and
+// ingredients normalized with NormalizeIngredient, so neither the order nor
+// the spelling they were given in matters.
+func cacheKey(query recipes.Query) string {
+	ingredients := make([]string, len(query.Ingredients))
+	for i, ingredient := range query.Ingredients {
+		ingredients[i] = recipes.NormalizeIngredient(ingredient)
+	}
+	key, _ := json.Marshal(canonicalQuery{
+		Ingredients:  canonicalList(ingredients),
+		Diets:        canonicalList(query.Diets),
+		Intolerances: canonicalList(query.Intolerances),
+		Exclude:      canonicalList(query.Exclude),
+		MaxCalories:  query.Targets.MaxCalories,
+		MinProtein:   query.Targets.MinProtein,
+		MaxCarbs:     query.Targets.MaxCarbs,
+		MaxReadyTime: query.Prep.MaxReadyTime,
+		Equipment:    canonicalList(query.Prep.Equipment),
+		NoEquipment:  canonicalList(query.Prep.NoEquipment),
+	})
+	return cacheKeyVersion + string(key)
+}
+
+func canonicalList(list []string) []string {
+	var canonical []string
+	for _, entry := range list {
+		if entry = strings.ToLower(strings.TrimSpace(entry)); entry != "" {
+			canonical = append(canonical, entry)
+		}
+	}
+	slices.Sort(canonical)
+	return slices.Compact(canonical)
+}
+
+// adoptLegacyQuery moves the recipes cached under a query's key from before
+// cacheKey, see sortedQuery, to its current key, so they are found at once
+// from then on. The two keys may pick different shard tables.
+func (s *sqlStore) adoptLegacyQuery(ctx context.Context, legacy string, key string) error {
+	legacyHash, hash := hashKey(legacy), hashKey(key)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	// Rolling back after a commit does nothing
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO "+s.queryTable(hash)+
+		" (query_hash, sorted_query, source, recipe_id, position, fetched_at) "+
+		"SELECT ?, ?, source, recipe_id, position, fetched_at FROM "+s.queryTable(legacyHash)+" WHERE query_hash = ?",
+		hash, key, legacyHash)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM "+s.queryTable(legacyHash)+" WHERE query_hash = ?", legacyHash)
+	if err != nil {
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing query: %v", err)
+	}
+	if s.queryFilter != nil {
+		s.queryFilter.add(key)
+	}
+	return nil
+}
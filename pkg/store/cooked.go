@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// cookedMealsSchema logs the recipes each profile cooked, the profile of no
+// profile under the empty name. The title and cuisines are copied from the
+// recipe, so the log outlives the recipe's expiry from the cache. Migration 31
+// adds the techniques, see recipes.Techniques, and migration 32 the price of
+// a serving and the vegetables, see recipes.Vegetables.
+const cookedMealsSchema = `
+CREATE TABLE IF NOT EXISTS cooked_meals (
+	profile   VARCHAR(64)  NOT NULL,
+	source    VARCHAR(32)  NOT NULL,
+	recipe_id INTEGER      NOT NULL,
+	title     VARCHAR(255) NOT NULL,
+	cuisines  VARCHAR(255) NOT NULL DEFAULT '',
+	cooked_at BIGINT       NOT NULL
+)`
+
+// CookedMeal is a recipe logged as cooked with "recipefinder cooked".
+type CookedMeal struct {
+	Source     string
+	RecipeID   int
+	Title      string
+	Cuisines   []string
+	Techniques []string
+	Vegetables []string
+	Price      recipes.Money
+	CookedAt   time.Time
+}
+
+// AddCooked logs the recipe as cooked by the profile at the time.
+func (s *sqlStore) AddCooked(ctx context.Context, recipe recipes.Recipe, at time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO cooked_meals (profile, source, recipe_id, title, cuisines, techniques, vegetables, price, "+
+			"cooked_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", s.profile, recipe.Source, recipe.ID, recipe.Title,
+		strings.Join(recipe.Cuisines, ","), strings.Join(recipes.Techniques(recipe), ","),
+		strings.Join(recipes.Vegetables(recipe), ","), int64(recipe.PricePerServing), at.Unix())
+	return err
+}
+
+// Cooked returns the meals the profile cooked since the time, oldest first.
+func (s *sqlStore) Cooked(ctx context.Context, since time.Time) ([]CookedMeal, error) {
+	return s.ProfileCooked(ctx, s.profile, since)
+}
+
+// ProfileCooked returns the meals a profile cooked since the time, oldest
+// first.
+func (s *sqlStore) ProfileCooked(ctx context.Context, profile string, since time.Time) ([]CookedMeal, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT source, recipe_id, title, cuisines, techniques, vegetables, price, "+
+		"cooked_at FROM cooked_meals WHERE profile = ? AND cooked_at >= ? ORDER BY cooked_at, recipe_id",
+		profile, since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			slog.Warn("error closing rows", "error", err)
+		}
+	}(rows)
+
+	var meals []CookedMeal
+	for rows.Next() {
+		var meal CookedMeal
+		var cuisines, techniques, vegetables string
+		var price, cookedAt int64
+		err := rows.Scan(&meal.Source, &meal.RecipeID, &meal.Title, &cuisines, &techniques, &vegetables, &price,
+			&cookedAt)
+		if err != nil {
+			return nil, err
+		}
+		if cuisines != "" {
+			meal.Cuisines = strings.Split(cuisines, ",")
+		}
+		if techniques != "" {
+			meal.Techniques = strings.Split(techniques, ",")
+		}
+		if vegetables != "" {
+			meal.Vegetables = strings.Split(vegetables, ",")
+		}
+		meal.Price = recipes.Money(price)
+		meal.CookedAt = time.Unix(cookedAt, 0)
+		meals = append(meals, meal)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return meals, nil
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/offline"
+)
+
+// customRecipesSchema keeps the recipes the user added, each as the JSON of
+// an offline.Recipe, with %s completing the auto-incremented primary key for
+// the dialect.
+const customRecipesSchema = `
+CREATE TABLE IF NOT EXISTS custom_recipes (
+	id       INTEGER      NOT NULL PRIMARY KEY %s,
+	title    VARCHAR(255) NOT NULL,
+	recipe   TEXT         NOT NULL,
+	added_at BIGINT       NOT NULL
+)`
+
+// LocalSource is the recipes.Recipe.Source of the recipes the user added.
+const LocalSource = "local"
+
+// customIDBase is added to the row IDs of custom recipes, keeping them apart
+// from the IDs of the providers, which favorites and reports do not tell
+// apart by source.
+const customIDBase = 900000000
+
+// AddCustomRecipe saves a recipe the user wrote and returns its ID. A recipe
+// with the same title as one already added replaces it, keeping its ID, so a
+// file of recipes can be edited and imported again.
+func (s *sqlStore) AddCustomRecipe(ctx context.Context, recipe offline.Recipe) (int, error) {
+	recipe.ID = 0
+	encoded, err := json.Marshal(recipe)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %v", err)
+	}
+	// Rolling back after a commit does nothing
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var id int64
+	now := time.Now().Unix()
+	err = tx.QueryRowContext(ctx, "SELECT id FROM custom_recipes WHERE title = ?", recipe.Title).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		id, err = insertID(ctx, tx, s.dialect, "INSERT INTO custom_recipes (title, recipe, added_at) VALUES (?, ?, ?)",
+			recipe.Title, string(encoded), now)
+	} else if err == nil {
+		_, err = tx.ExecContext(ctx, "UPDATE custom_recipes SET recipe = ?, added_at = ? WHERE id = ?",
+			string(encoded), now, id)
+	}
+	if err != nil {
+		return 0, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("error committing recipe: %v", err)
+	}
+	return customIDBase + int(id), nil
+}
+
+// CustomRecipes returns the recipes the user added, in the order they were
+// first added.
+func (s *sqlStore) CustomRecipes(ctx context.Context) ([]offline.Recipe, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, recipe FROM custom_recipes ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			slog.Warn("error closing rows", "error", err)
+		}
+	}(rows)
+
+	var custom []offline.Recipe
+	for rows.Next() {
+		var id int
+		var encoded string
+		err := rows.Scan(&id, &encoded)
+		if err != nil {
+			return nil, err
+		}
+		var recipe offline.Recipe
+		err = json.Unmarshal([]byte(encoded), &recipe)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing custom recipe %d: %v", customIDBase+id, err)
+		}
+		recipe.ID = customIDBase + id
+		custom = append(custom, recipe)
+	}
+	return custom, rows.Err()
+}
+
+// DeleteCustomRecipe deletes a recipe the user added, by the ID
+// AddCustomRecipe returned, and reports whether there was one.
+func (s *sqlStore) DeleteCustomRecipe(ctx context.Context, id int) (bool, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM custom_recipes WHERE id = ?", id-customIDBase)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	return count > 0, err
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// recipeDetailsSchema holds the details only fetched for single recipes,
+// alongside their row in recipes. fetched_at is when the details were fetched.
+const recipeDetailsSchema = `
+CREATE TABLE IF NOT EXISTS recipe_details (
+	source           VARCHAR(32)  NOT NULL DEFAULT '',
+	recipe_id        INTEGER      NOT NULL,
+	ready_in_minutes INTEGER      NOT NULL DEFAULT 0,
+	source_url       VARCHAR(512) NOT NULL DEFAULT '',
+	image_url        VARCHAR(512) NOT NULL DEFAULT '',
+	fetched_at       BIGINT       NOT NULL,
+	PRIMARY KEY (source, recipe_id)
+)`
+
+// CachedRecipe returns a cached recipe, expired or not, with its details when
+// they were fetched, or nil when it is not cached. The boolean reports whether
+// its details are cached and unexpired.
+func (s *sqlStore) CachedRecipe(ctx context.Context, source string, id int) (*recipes.Recipe, bool, error) {
+	from := "FROM recipes r"
+	where := "r.source = ? AND r.id = ?"
+	found, err := s.readRecipes(ctx,
+		"SELECT "+recipeColumns+" "+from+" WHERE "+where, source, id)
+	if err != nil || len(found) == 0 {
+		return nil, false, err
+	}
+	err = s.readDetails(ctx, found, from, where, source, id)
+	if err != nil {
+		return nil, false, err
+	}
+	recipe := &found[0]
+
+	var fetchedAt int64
+	var taste string
+	err = s.db.QueryRowContext(ctx, "SELECT ready_in_minutes, source_url, image_url, taste, fetched_at "+
+		"FROM recipe_details WHERE source = ? AND recipe_id = ?", source, id).
+		Scan(&recipe.ReadyInMinutes, &recipe.SourceURL, &recipe.ImageURL, &taste, &fetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return recipe, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if taste != "" {
+		recipe.Taste = new(recipes.Taste)
+		err = json.Unmarshal([]byte(taste), recipe.Taste)
+		if err != nil {
+			return nil, false, fmt.Errorf("error parsing the taste of recipe %d: %v", id, err)
+		}
+	}
+	return recipe, fetchedAt >= s.cutoff(), nil
+}
+
+// CachedRecipes returns every cached recipe, expired ones included, by source
+// and ID, without their ingredients, nutrients and details. The order only
+// changes when recipes are added or purged, so a seeded pick from them can be
+// repeated.
+func (s *sqlStore) CachedRecipes(ctx context.Context) ([]recipes.Recipe, error) {
+	return s.readRecipes(ctx, "SELECT "+recipeColumns+" FROM recipes r ORDER BY r.source, r.id")
+}
+
+// SaveRecipeDetails caches a recipe with its details, overwriting what was
+// cached for it before. The queries it was found by are left as they are. Its
+// data quality is counted for its provider.
+func (s *sqlStore) SaveRecipeDetails(ctx context.Context, recipe recipes.Recipe) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	// Rolling back after a commit does nothing
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	statements, err := prepareRecipeStatements(ctx, tx)
+	if err != nil {
+		return err
+	}
+	defer statements.close()
+	now := time.Now().Unix()
+	err = statements.saveRecipe(ctx, recipe, now)
+	if err != nil {
+		return err
+	}
+	var taste []byte
+	if recipe.Taste != nil {
+		taste, err = json.Marshal(recipe.Taste)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, "REPLACE INTO recipe_details (source, recipe_id, ready_in_minutes, source_url, "+
+		"image_url, taste, fetched_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		recipe.Source, recipe.ID, recipe.ReadyInMinutes, recipe.SourceURL, recipe.ImageURL, string(taste), now)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing recipe: %v", err)
+	}
+	s.logQuality(ctx, []recipes.Recipe{recipe})
+	return nil
+}
@@ -0,0 +1,171 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// experimentShownSchema keeps which ranking strategy placed each recipe shown
+// by the ranking experiment, and experimentChoicesSchema the recipes the user
+// then picked, credited to the strategy that showed them last.
+// experimentViewsSchema replaced experimentShownSchema, its generated id
+// telling apart the recipes shown within the same second.
+const (
+	experimentShownSchema = `
+CREATE TABLE IF NOT EXISTS experiment_shown (
+	recipe_id INTEGER     NOT NULL,
+	strategy  VARCHAR(16) NOT NULL,
+	shown_at  BIGINT      NOT NULL
+)`
+	experimentViewsSchema = `
+CREATE TABLE IF NOT EXISTS experiment_views (
+	id        INTEGER     NOT NULL PRIMARY KEY %s,
+	recipe_id INTEGER     NOT NULL,
+	strategy  VARCHAR(16) NOT NULL,
+	shown_at  BIGINT      NOT NULL
+)`
+	experimentChoicesSchema = `
+CREATE TABLE IF NOT EXISTS experiment_choices (
+	recipe_id INTEGER     NOT NULL,
+	strategy  VARCHAR(16) NOT NULL,
+	chosen_at BIGINT      NOT NULL
+)`
+)
+
+// week is the length of the periods ExperimentResults groups by.
+const week = 7 * 24 * time.Hour
+
+// ExperimentResult counts how a ranking strategy did in one week.
+type ExperimentResult struct {
+	// Week is when the week started, weeks being counted from the Unix
+	// epoch.
+	Week     time.Time
+	Strategy string
+	Shown    int64
+	Chosen   int64
+}
+
+// ChoiceRate returns the share of the shown recipes that were chosen, 0
+// when none were shown.
+func (r ExperimentResult) ChoiceRate() float64 {
+	if r.Shown == 0 {
+		return 0
+	}
+	return float64(r.Chosen) / float64(r.Shown)
+}
+
+// RecordExperimentShown records the recipes a search showed, by recipe ID,
+// with the strategy that placed each.
+func (s *sqlStore) RecordExperimentShown(ctx context.Context, strategies map[int]string) error {
+	now := time.Now().Unix()
+	for recipeID, strategy := range strategies {
+		_, err := s.db.ExecContext(ctx, "INSERT INTO experiment_views (recipe_id, strategy, shown_at) VALUES (?, ?, ?)",
+			recipeID, strategy, now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordExperimentChoice records that the user picked a recipe and returns
+// the strategy it is credited to, the one that showed it last, the latest
+// recorded when shown twice in the same second. It returns an
+// empty strategy, recording nothing, for recipes the experiment never showed.
+func (s *sqlStore) RecordExperimentChoice(ctx context.Context, recipeID int) (string, error) {
+	var strategy string
+	err := s.db.QueryRowContext(ctx, "SELECT strategy FROM experiment_views WHERE recipe_id = ? "+
+		"ORDER BY shown_at DESC, id DESC LIMIT 1", recipeID).Scan(&strategy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	_, err = s.db.ExecContext(ctx, "INSERT INTO experiment_choices (recipe_id, strategy, chosen_at) VALUES (?, ?, ?)",
+		recipeID, strategy, time.Now().Unix())
+	if err != nil {
+		return "", fmt.Errorf("error recording choice: %v", err)
+	}
+	return strategy, nil
+}
+
+// ExperimentResults counts the recipes each strategy showed and the ones
+// chosen of them, by week, oldest first.
+func (s *sqlStore) ExperimentResults(ctx context.Context) ([]ExperimentResult, error) {
+	seconds := int64(week / time.Second)
+	type key struct {
+		week     int64
+		strategy string
+	}
+	counts := make(map[key]*ExperimentResult)
+	for _, table := range []struct {
+		name   string
+		column string
+		chosen bool
+	}{
+		{"experiment_views", "shown_at", false},
+		{"experiment_choices", "chosen_at", true},
+	} {
+		weekStart := fmt.Sprintf("%s - %[1]s %% %d", table.column, seconds)
+		query := fmt.Sprintf("SELECT %s, strategy, COUNT(*) FROM %s GROUP BY %[1]s, strategy", weekStart, table.name)
+		err := s.countExperiment(ctx, query, func(start int64, strategy string, n int64) {
+			k := key{start, strategy}
+			if counts[k] == nil {
+				counts[k] = &ExperimentResult{Week: time.Unix(start, 0), Strategy: strategy}
+			}
+			if table.chosen {
+				counts[k].Chosen += n
+			} else {
+				counts[k].Shown += n
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]ExperimentResult, 0, len(counts))
+	for _, result := range counts {
+		results = append(results, *result)
+	}
+	slices.SortFunc(results, func(a, b ExperimentResult) int {
+		if c := a.Week.Compare(b.Week); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Strategy, b.Strategy)
+	})
+	return results, nil
+}
+
+// countExperiment runs a query of week starts, strategies and counts, passing
+// each row to add.
+func (s *sqlStore) countExperiment(ctx context.Context, query string, add func(int64, string, int64)) error {
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			slog.Warn("error closing rows", "error", err)
+		}
+	}(rows)
+
+	for rows.Next() {
+		var start, n int64
+		var strategy string
+		err := rows.Scan(&start, &strategy, &n)
+		if err != nil {
+			return err
+		}
+		add(start, strategy, n)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// favoritesSchema and historySchema are valid for both MySQL and SQLite.
+const favoritesSchema = `
+CREATE TABLE IF NOT EXISTS favorites (
+	recipe_id INTEGER      NOT NULL PRIMARY KEY,
+	title     VARCHAR(255) NOT NULL,
+	added_at  BIGINT       NOT NULL
+)`
+
+const historySchema = `
+CREATE TABLE IF NOT EXISTS history (
+	searched_at  BIGINT  NOT NULL,
+	sorted_query TEXT    NOT NULL,
+	results      INTEGER NOT NULL
+)`
+
+// Favorite is a recipe the user starred.
+type Favorite struct {
+	RecipeID int
+	// Title is empty when the recipe was starred by ID without being cached.
+	Title   string
+	AddedAt time.Time
+}
+
+// HistoryEntry is one past search. Query is its readable form, the sorted
+// ingredients followed by the filters, see sortedQuery.
+type HistoryEntry struct {
+	SearchedAt time.Time
+	Query      string
+	Results    int
+}
+
+func (s *sqlStore) Favorites(ctx context.Context) ([]Favorite, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT recipe_id, title, added_at FROM favorites ORDER BY added_at, recipe_id")
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			slog.Warn("error closing rows", "error", err)
+		}
+	}(rows)
+
+	var favorites []Favorite
+	for rows.Next() {
+		var favorite Favorite
+		var addedAt int64
+		err := rows.Scan(&favorite.RecipeID, &favorite.Title, &addedAt)
+		if err != nil {
+			return nil, err
+		}
+		favorite.AddedAt = time.Unix(addedAt, 0)
+		favorites = append(favorites, favorite)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return favorites, nil
+}
+
+// AddFavorite stars a recipe. An empty title is taken from the cached recipe
+// with that ID, when there is one.
+func (s *sqlStore) AddFavorite(ctx context.Context, recipeID int, title string) error {
+	_, err := s.db.ExecContext(ctx,
+		"REPLACE INTO favorites (recipe_id, title, added_at) VALUES "+
+			"(?, COALESCE(NULLIF(?, ''), (SELECT name FROM recipes WHERE id = ? LIMIT 1), ''), ?)",
+		recipeID, title, recipeID, time.Now().Unix())
+	return err
+}
+
+func (s *sqlStore) RemoveFavorite(ctx context.Context, recipeID int) (bool, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM favorites WHERE recipe_id = ?", recipeID)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	return count > 0, err
+}
+
+func (s *sqlStore) AddHistory(ctx context.Context, query recipes.Query, results int) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO history (searched_at, sorted_query, results) VALUES (?, ?, ?)",
+		time.Now().Unix(), sortedQuery(query), results)
+	return err
+}
+
+func (s *sqlStore) History(ctx context.Context, limit int) ([]HistoryEntry, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT searched_at, sorted_query, results FROM history ORDER BY searched_at DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			slog.Warn("error closing rows", "error", err)
+		}
+	}(rows)
+
+	var history []HistoryEntry
+	for rows.Next() {
+		var entry HistoryEntry
+		var searchedAt int64
+		err := rows.Scan(&searchedAt, &entry.Query, &entry.Results)
+		if err != nil {
+			return nil, err
+		}
+		entry.SearchedAt = time.Unix(searchedAt, 0)
+		history = append(history, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
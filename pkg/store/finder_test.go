@@ -0,0 +1,155 @@
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+	"github.com/mawojcik/meals_generator/pkg/spoonacular"
+	"github.com/mawojcik/meals_generator/pkg/store"
+)
+
+// searchFixture serves the Spoonacular complexSearch fixture, counting the
+// requests.
+type searchFixture struct {
+	requests int
+}
+
+func (f *searchFixture) RoundTrip(request *http.Request) (*http.Response, error) {
+	f.requests++
+	body, err := os.ReadFile(filepath.Join("..", "spoonacular", "testdata", "complexSearch.json"))
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    request,
+	}, nil
+}
+
+// TestFinderWithSpoonacularAndSQLite runs a search the way the CLI does: the
+// first one goes to the API and is cached, the second is served from the
+// cache alone.
+func TestFinderWithSpoonacularAndSQLite(t *testing.T) {
+	ctx := context.Background()
+	fixture := &searchFixture{}
+	client := spoonacular.NewClient("secret-key")
+	client.HTTPClient = &http.Client{Transport: fixture}
+	cache, err := store.OpenSQLite(ctx, filepath.Join(t.TempDir(), "cache.db"), store.Options{TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := cache.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	finder := &recipes.Finder{Source: client, Cache: cache}
+	query := recipes.Query{Ingredients: []string{"chicken breast", "red onion"}, NumberOfRecipes: 2}
+
+	fetched, err := finder.Find(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fetched) != 2 || fixture.requests != 1 {
+		t.Fatalf("got %d recipes from %d requests, want 2 from 1", len(fetched), fixture.requests)
+	}
+
+	cached, err := finder.Find(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixture.requests != 1 {
+		t.Errorf("made %d requests, want the second search served from the cache", fixture.requests)
+	}
+	if len(cached) != len(fetched) {
+		t.Fatalf("got %d cached recipes, want %d", len(cached), len(fetched))
+	}
+	for i := range fetched {
+		if cached[i].ID != fetched[i].ID || cached[i].Title != fetched[i].Title ||
+			cached[i].PricePerServing != fetched[i].PricePerServing ||
+			cached[i].Nutrients["Calories"] != fetched[i].Nutrients["Calories"] ||
+			len(cached[i].Instructions) != len(fetched[i].Instructions) {
+			t.Errorf("cached recipe %+v differs from fetched %+v", cached[i], fetched[i])
+		}
+	}
+}
+
+// pagedSource finds recipes 1, 2, 3... from the query's offset on, counting
+// the recipes it returns.
+type pagedSource struct {
+	returned int
+}
+
+func (s *pagedSource) Search(_ context.Context, query recipes.Query) ([]recipes.Recipe, error) {
+	found := make([]recipes.Recipe, query.NumberOfRecipes)
+	for i := range found {
+		id := query.Offset + i + 1
+		found[i] = recipes.Recipe{ID: id, Title: fmt.Sprintf("Recipe %d", id), Source: "spoonacular"}
+	}
+	s.returned += len(found)
+	return found, nil
+}
+
+// TestFinderKeepsPartialHits asks for more recipes than are cached: the
+// cache ends up holding the ones it had and the ones fetched after them, so
+// asking again is served from it alone.
+func TestFinderKeepsPartialHits(t *testing.T) {
+	ctx := context.Background()
+	cache, err := store.OpenSQLite(ctx, filepath.Join(t.TempDir(), "cache.db"), store.Options{TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := cache.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	source := &pagedSource{}
+	finder := &recipes.Finder{Source: source, Cache: cache}
+	query := recipes.Query{Ingredients: []string{"egg"}, NumberOfRecipes: 3}
+
+	_, err = finder.Find(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query.NumberOfRecipes = 5
+	found, err := finder.Find(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 5 || source.returned != 5 {
+		t.Fatalf("got %d recipes after fetching %d, want 5 after fetching 5", len(found), source.returned)
+	}
+
+	cached, err := cache.Lookup(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for _, recipe := range cached {
+		ids = append(ids, recipe.ID)
+	}
+	if !slices.Equal(ids, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("cache holds recipes %v, want 1 to 5 in order", ids)
+	}
+	_, err = finder.Find(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source.returned != 5 {
+		t.Errorf("fetched %d recipes, want the third search served from the cache", source.returned)
+	}
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// ingredientInfoSchema caches what was fetched for ingredient cards, keyed by
+// normalized ingredient name. food is the recipes.FoodData encoded as JSON,
+// empty when the food was not found.
+const ingredientInfoSchema = `
+CREATE TABLE IF NOT EXISTS ingredient_info (
+	name       VARCHAR(255) NOT NULL PRIMARY KEY,
+	image_url  VARCHAR(512) NOT NULL DEFAULT '',
+	food       TEXT         NOT NULL,
+	fetched_at BIGINT       NOT NULL
+)`
+
+// CachedIngredient returns the fetched parts of an ingredient's card, its
+// image and food data, or nil when they are not cached. The boolean reports
+// whether they are unexpired.
+func (s *sqlStore) CachedIngredient(ctx context.Context, name string) (*recipes.IngredientInfo, bool, error) {
+	name = recipes.NormalizeIngredient(name)
+	info := &recipes.IngredientInfo{Name: name}
+	var food string
+	var fetchedAt int64
+	err := s.db.QueryRowContext(ctx, "SELECT image_url, food, fetched_at FROM ingredient_info WHERE name = ?", name).
+		Scan(&info.ImageURL, &food, &fetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if food != "" {
+		info.Food = &recipes.FoodData{}
+		err = json.Unmarshal([]byte(food), info.Food)
+		if err != nil {
+			return nil, false, fmt.Errorf("error reading food data: %v", err)
+		}
+	}
+	info.FetchedAt = time.Unix(fetchedAt, 0)
+	return info, fetchedAt >= s.cutoff(), nil
+}
+
+// SaveIngredient caches the fetched parts of an ingredient's card, replacing
+// what was cached for it before.
+func (s *sqlStore) SaveIngredient(ctx context.Context, info recipes.IngredientInfo) error {
+	food := ""
+	if info.Food != nil {
+		encoded, err := json.Marshal(info.Food)
+		if err != nil {
+			return err
+		}
+		food = string(encoded)
+	}
+	_, err := s.db.ExecContext(ctx, "REPLACE INTO ingredient_info (name, image_url, food, fetched_at) "+
+		"VALUES (?, ?, ?, ?)", recipes.NormalizeIngredient(info.Name), info.ImageURL, food, time.Now().Unix())
+	return err
+}
@@ -0,0 +1,234 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// jobsSchema creates the job queue, with %s completing the auto-incremented
+// primary key for the dialect. run_at is when a queued job may start, or when
+// the lease of a running one expires.
+const jobsSchema = `
+CREATE TABLE IF NOT EXISTS jobs (
+	id           INTEGER      NOT NULL PRIMARY KEY %s,
+	kind         VARCHAR(32)  NOT NULL,
+	payload      TEXT         NOT NULL,
+	priority     INTEGER      NOT NULL DEFAULT 0,
+	status       VARCHAR(16)  NOT NULL,
+	attempts     INTEGER      NOT NULL DEFAULT 0,
+	max_attempts INTEGER      NOT NULL,
+	last_error   TEXT         NOT NULL,
+	run_at       BIGINT       NOT NULL,
+	created_at   BIGINT       NOT NULL,
+	updated_at   BIGINT       NOT NULL
+)`
+
+// Job statuses. Queued jobs wait for a worker, running ones have been claimed
+// by one; the other statuses are final.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobDone      = "done"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+const (
+	// DefaultJobAttempts is how often a job is tried when MaxAttempts is not
+	// set.
+	DefaultJobAttempts = 3
+	// jobLease is how long a worker may run a job before it is assumed to
+	// have died and the job is given to another worker.
+	jobLease = 10 * time.Minute
+	// jobBackoff is the wait before the first retry, doubled for each further
+	// one.
+	jobBackoff = 30 * time.Second
+)
+
+// Job is a background task kept in the database, so servers and workers
+// sharing it pick up each other's work and nothing is lost on restart.
+type Job struct {
+	ID   int64
+	Kind string
+	// Payload is the job's input, in a format up to its kind.
+	Payload string
+	// Priority orders the queue, higher first; jobs of equal priority run
+	// oldest first.
+	Priority    int
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	// LastError is the error of the latest failed attempt.
+	LastError string
+	RunAt     time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ErrQueueFull is returned by EnqueueJob while Options.MaxQueuedJobs jobs are
+// waiting, so producers back off until the workers catch up.
+var ErrQueueFull = errors.New("job queue is full")
+
+// EnqueueJob queues a job to run at once and returns its ID. Only its kind,
+// payload, priority and maximum attempts are used. The queue limit is checked
+// before adding, so processes adding jobs at the same time may exceed it by a
+// few.
+func (s *sqlStore) EnqueueJob(ctx context.Context, job Job) (int64, error) {
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = DefaultJobAttempts
+	}
+	if s.maxQueuedJobs > 0 {
+		var queued int
+		err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM jobs WHERE status = ?", JobQueued).Scan(&queued)
+		if err != nil {
+			return 0, err
+		}
+		if queued >= s.maxQueuedJobs {
+			return 0, ErrQueueFull
+		}
+	}
+	now := time.Now().Unix()
+	return insertID(ctx, s.db, s.dialect,
+		"INSERT INTO jobs (kind, payload, priority, status, attempts, max_attempts, last_error, run_at, created_at, "+
+			"updated_at) VALUES (?, ?, ?, ?, 0, ?, '', ?, ?, ?)",
+		job.Kind, job.Payload, job.Priority, JobQueued, job.MaxAttempts, now, now, now)
+}
+
+// ClaimJob marks the next due job of one of the kinds as running and returns
+// it, or nil when there is none. Running jobs whose lease has expired are
+// claimed again, counting as another attempt.
+func (s *sqlStore) ClaimJob(ctx context.Context, kinds []string) (*Job, error) {
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+	kindList := "?" + strings.Repeat(", ?", len(kinds)-1)
+	for {
+		now := time.Now().Unix()
+		args := []any{JobQueued, JobRunning, now}
+		for _, kind := range kinds {
+			args = append(args, kind)
+		}
+		jobs, err := s.readJobs(ctx, "WHERE status IN (?, ?) AND run_at <= ? AND kind IN ("+kindList+") "+
+			"ORDER BY priority DESC, id LIMIT 1", args...)
+		if err != nil || len(jobs) == 0 {
+			return nil, err
+		}
+		job := jobs[0]
+
+		// Another worker may claim the job first, in which case the update
+		// matches nothing and the next job is tried
+		leaseEnd := time.Now().Add(jobLease).Unix()
+		result, err := s.db.ExecContext(ctx,
+			"UPDATE jobs SET status = ?, attempts = attempts + 1, run_at = ?, updated_at = ? "+
+				"WHERE id = ? AND status = ? AND run_at = ?",
+			JobRunning, leaseEnd, now, job.ID, job.Status, job.RunAt.Unix())
+		if err != nil {
+			return nil, err
+		}
+		count, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			continue
+		}
+		job.Status = JobRunning
+		job.Attempts++
+		job.RunAt = time.Unix(leaseEnd, 0)
+		job.UpdatedAt = time.Unix(now, 0)
+		return &job, nil
+	}
+}
+
+// FinishJob records the outcome of a claimed job. A failed job is queued
+// again after a backoff until it has used up its attempts. Jobs cancelled
+// while running stay cancelled.
+func (s *sqlStore) FinishJob(ctx context.Context, job Job, jobErr error) error {
+	now := time.Now()
+	status, runAt, lastError := JobDone, now, ""
+	if jobErr != nil {
+		lastError = jobErr.Error()
+		status = JobFailed
+		if job.Attempts < job.MaxAttempts {
+			status = JobQueued
+			runAt = now.Add(jobBackoff << (job.Attempts - 1))
+		}
+	}
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE jobs SET status = ?, run_at = ?, last_error = ?, updated_at = ? WHERE id = ? AND status = ?",
+		status, runAt.Unix(), lastError, now.Unix(), job.ID, JobRunning)
+	return err
+}
+
+// Jobs returns up to limit jobs, newest first, only those with the status
+// unless it is empty.
+func (s *sqlStore) Jobs(ctx context.Context, status string, limit int) ([]Job, error) {
+	if status == "" {
+		return s.readJobs(ctx, "ORDER BY id DESC LIMIT ?", limit)
+	}
+	return s.readJobs(ctx, "WHERE status = ? ORDER BY id DESC LIMIT ?", status, limit)
+}
+
+// ErrJobFinished is returned by CancelJob for jobs that have already ended.
+var ErrJobFinished = errors.New("job has already finished")
+
+// CancelJob cancels a queued or running job. A running job is not stopped,
+// but its outcome is discarded and it is not retried. It reports whether the
+// job exists.
+func (s *sqlStore) CancelJob(ctx context.Context, id int64) (bool, error) {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE jobs SET status = ?, updated_at = ? WHERE id = ? AND status IN (?, ?)",
+		JobCancelled, time.Now().Unix(), id, JobQueued, JobRunning)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+	jobs, err := s.readJobs(ctx, "WHERE id = ?", id)
+	if err != nil || len(jobs) == 0 {
+		return false, err
+	}
+	return true, ErrJobFinished
+}
+
+func (s *sqlStore) readJobs(ctx context.Context, condition string, args ...any) ([]Job, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, kind, payload, priority, status, attempts, max_attempts, "+
+		"last_error, run_at, created_at, updated_at FROM jobs "+condition, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			slog.Warn("error closing rows", "error", err)
+		}
+	}(rows)
+
+	var jobs []Job
+	for rows.Next() {
+		var job Job
+		var runAt, createdAt, updatedAt int64
+		err := rows.Scan(&job.ID, &job.Kind, &job.Payload, &job.Priority, &job.Status, &job.Attempts,
+			&job.MaxAttempts, &job.LastError, &runAt, &createdAt, &updatedAt)
+		if err != nil {
+			return nil, err
+		}
+		job.RunAt = time.Unix(runAt, 0)
+		job.CreatedAt = time.Unix(createdAt, 0)
+		job.UpdatedAt = time.Unix(updatedAt, 0)
+		jobs = append(jobs, job)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
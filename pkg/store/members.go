@@ -0,0 +1,189 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// invitesSchema keeps the one-time invitations to join as a profile. Only a
+// hash of each token is kept, so reading the database does not give away
+// unused invitations.
+const invitesSchema = `
+CREATE TABLE IF NOT EXISTS invites (
+	token_hash CHAR(64)    NOT NULL PRIMARY KEY,
+	profile    VARCHAR(64) NOT NULL,
+	created_at BIGINT      NOT NULL,
+	expires_at BIGINT      NOT NULL
+)`
+
+// membersSchema maps the accounts that accepted an invitation, such as a
+// Telegram chat, to their profile.
+const membersSchema = `
+CREATE TABLE IF NOT EXISTS members (
+	account   VARCHAR(128) NOT NULL PRIMARY KEY,
+	profile   VARCHAR(64)  NOT NULL,
+	joined_at BIGINT       NOT NULL
+)`
+
+// ErrInvalidInvite is returned by AcceptInvite for tokens that are unknown,
+// expired or already used.
+var ErrInvalidInvite = errors.New("invitation is invalid, expired or already used")
+
+// Invite is a pending invitation to join as a profile.
+type Invite struct {
+	Profile   string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Member is an account that joined as a profile.
+type Member struct {
+	// Account names the account and where it is, such as "telegram:1234"
+	// for a Telegram chat.
+	Account  string
+	Profile  string
+	JoinedAt time.Time
+}
+
+// CreateInvite records an invitation to join as the profile, accepted by
+// giving the token before it expires.
+func (s *sqlStore) CreateInvite(ctx context.Context, token string, profile string, expires time.Time) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO invites (token_hash, profile, created_at, expires_at) "+
+		"VALUES (?, ?, ?, ?)", hashKey(token), profile, time.Now().Unix(), expires.Unix())
+	return err
+}
+
+// Invites returns the unexpired invitations, oldest first.
+func (s *sqlStore) Invites(ctx context.Context) ([]Invite, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT profile, created_at, expires_at FROM invites WHERE expires_at > ? "+
+		"ORDER BY created_at, profile", time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			slog.Warn("error closing rows", "error", err)
+		}
+	}(rows)
+
+	var invites []Invite
+	for rows.Next() {
+		var invite Invite
+		var createdAt, expiresAt int64
+		err := rows.Scan(&invite.Profile, &createdAt, &expiresAt)
+		if err != nil {
+			return nil, err
+		}
+		invite.CreatedAt = time.Unix(createdAt, 0)
+		invite.ExpiresAt = time.Unix(expiresAt, 0)
+		invites = append(invites, invite)
+	}
+	return invites, rows.Err()
+}
+
+// AcceptInvite uses up the invitation of the token, making the account a
+// member as its profile, and returns the profile. An account accepting
+// another invitation moves to its profile.
+func (s *sqlStore) AcceptInvite(ctx context.Context, token string, account string) (string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("error starting transaction: %v", err)
+	}
+	// Rolling back after a commit does nothing
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	hash := hashKey(token)
+	var profile string
+	err = tx.QueryRowContext(ctx, "SELECT profile FROM invites WHERE token_hash = ? AND expires_at > ?", hash,
+		time.Now().Unix()).Scan(&profile)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrInvalidInvite
+	}
+	if err != nil {
+		return "", err
+	}
+	// Deleting the invitation is what makes it one-time: of two accounts
+	// accepting it at once, only one deletes it
+	result, err := tx.ExecContext(ctx, "DELETE FROM invites WHERE token_hash = ?", hash)
+	if err != nil {
+		return "", err
+	}
+	if deleted, err := result.RowsAffected(); err != nil || deleted == 0 {
+		return "", ErrInvalidInvite
+	}
+	_, err = tx.ExecContext(ctx, "REPLACE INTO members (account, profile, joined_at) VALUES (?, ?, ?)", account,
+		profile, time.Now().Unix())
+	if err != nil {
+		return "", fmt.Errorf("error adding member: %v", err)
+	}
+	return profile, tx.Commit()
+}
+
+// Members returns the accounts that joined, sorted by profile.
+func (s *sqlStore) Members(ctx context.Context) ([]Member, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT account, profile, joined_at FROM members ORDER BY profile, account")
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			slog.Warn("error closing rows", "error", err)
+		}
+	}(rows)
+
+	var members []Member
+	for rows.Next() {
+		var member Member
+		var joinedAt int64
+		err := rows.Scan(&member.Account, &member.Profile, &joinedAt)
+		if err != nil {
+			return nil, err
+		}
+		member.JoinedAt = time.Unix(joinedAt, 0)
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+// MemberProfile returns the profile the account joined as, empty when it is
+// not a member.
+func (s *sqlStore) MemberProfile(ctx context.Context, account string) (string, error) {
+	var profile string
+	err := s.db.QueryRowContext(ctx, "SELECT profile FROM members WHERE account = ?", account).Scan(&profile)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return profile, err
+}
+
+// RemoveProfile removes the members, the pending invitations, the
+// notification preferences and the queued digests of a profile and returns
+// how many members there were.
+func (s *sqlStore) RemoveProfile(ctx context.Context, profile string) (int64, error) {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM invites WHERE profile = ?", profile)
+	if err != nil {
+		return 0, err
+	}
+	_, err = s.db.ExecContext(ctx, "DELETE FROM notification_prefs WHERE profile = ?", profile)
+	if err != nil {
+		return 0, err
+	}
+	_, err = s.db.ExecContext(ctx, "DELETE FROM digest_items WHERE account IN "+
+		"(SELECT account FROM members WHERE profile = ?)", profile)
+	if err != nil {
+		return 0, err
+	}
+	result, err := s.db.ExecContext(ctx, "DELETE FROM members WHERE profile = ?", profile)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
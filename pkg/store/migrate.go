@@ -0,0 +1,529 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// migrationsSchema records the schema versions applied to a database.
+const migrationsSchema = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+	version    INTEGER      NOT NULL PRIMARY KEY,
+	name       VARCHAR(255) NOT NULL,
+	applied_at BIGINT       NOT NULL
+)`
+
+// Migration is a versioned change to the schema.
+type Migration struct {
+	Version int
+	Name    string
+	// AppliedAt is zero for migrations still pending.
+	AppliedAt time.Time
+}
+
+type migration struct {
+	version int
+	name    string
+	up      func(ctx context.Context, s *sqlStore) error
+	// backup are the tables up deletes or rewrites rows of, exported to a
+	// backup file before it runs.
+	backup []string
+	// creates are the tables up creates, dropped to roll it back.
+	creates []string
+	// down reverts what up changed besides creating tables, and downBackup
+	// are the tables it deletes or rewrites rows of. A migration without
+	// creates or down cannot be rolled back.
+	down       func(ctx context.Context, s *sqlStore) error
+	downBackup []string
+}
+
+// reversible reports whether the migration can be rolled back.
+func (m migration) reversible() bool {
+	return len(m.creates) > 0 || m.down != nil
+}
+
+// migrations are applied in order, each once per database. Databases created
+// before versions were recorded start at version 0; the first migrations
+// only create what is missing, so they are safe to apply to them. New
+// migrations are appended and never changed once released.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "recipe, ingredient, nutrient and query tables",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return createTables(ctx, s.db, s.shardDigits, s.dialect)
+		},
+	},
+	{
+		version: 2,
+		name:    "pantry, favorites and history tables",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, pantrySchema, favoritesSchema, historySchema)
+		},
+		creates: []string{"pantry", "favorites", "history"},
+	},
+	{
+		version: 3,
+		name:    "jobs table",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, fmt.Sprintf(jobsSchema, s.dialect.autoIncrement))
+		},
+		creates: []string{"jobs"},
+	},
+	{
+		version: 4,
+		name:    "recipe details table",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, recipeDetailsSchema)
+		},
+		creates: []string{"recipe_details"},
+	},
+	{
+		version: 5,
+		name:    "quota reservation tables",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, fmt.Sprintf(quotaReservationsSchema, s.dialect.autoIncrement), quotaUsageSchema)
+		},
+		creates: []string{"quota_reservations", "quota_usage"},
+	},
+	{
+		version: 6,
+		name:    "result snapshots table",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, snapshotsSchema)
+		},
+		creates: []string{"result_snapshots"},
+	},
+	{
+		version: 7,
+		name:    "recipe prices",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db,
+				"ALTER TABLE recipes ADD COLUMN price_per_serving DOUBLE NOT NULL DEFAULT 0")
+		},
+		down: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, "ALTER TABLE recipes DROP COLUMN price_per_serving")
+		},
+		downBackup: []string{"recipes"},
+	},
+	{
+		version: 8,
+		name:    "saved searches table",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, savedSearchesSchema)
+		},
+		creates: []string{"saved_searches"},
+	},
+	{
+		version: 9,
+		name:    "estimated recipe fields",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, "ALTER TABLE recipes ADD COLUMN estimated VARCHAR(64) NOT NULL DEFAULT ''")
+		},
+		down: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, "ALTER TABLE recipes DROP COLUMN estimated")
+		},
+		downBackup: []string{"recipes"},
+	},
+	{
+		version: 10,
+		name:    "provider quality table",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, providerQualitySchema)
+		},
+		creates: []string{"provider_quality"},
+	},
+	{
+		version: 11,
+		name:    "ingredient info table",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, ingredientInfoSchema)
+		},
+		creates: []string{"ingredient_info"},
+	},
+	{
+		version: 12,
+		name:    "pantries per profile",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, pantriesSchema,
+				"INSERT INTO pantries (profile, name) SELECT '', name FROM pantry",
+				"DROP TABLE pantry")
+		},
+		backup:  []string{"pantry"},
+		creates: []string{"pantries"},
+		// The pantries of the profiles are lost, only kept in the backup
+		down: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, pantrySchema,
+				"INSERT INTO pantry (name) SELECT name FROM pantries WHERE profile = ''")
+		},
+	},
+	{
+		version: 13,
+		name:    "nutrient daily values",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db,
+				"ALTER TABLE recipe_nutrients ADD COLUMN daily_percent DOUBLE NOT NULL DEFAULT 0")
+		},
+		down: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, "ALTER TABLE recipe_nutrients DROP COLUMN daily_percent")
+		},
+		downBackup: []string{"recipe_nutrients"},
+	},
+	{
+		version: 14,
+		name:    "recipe ready times and equipment",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db,
+				"ALTER TABLE recipes ADD COLUMN ready_in_minutes INTEGER NOT NULL DEFAULT 0",
+				"ALTER TABLE recipes ADD COLUMN equipment VARCHAR(512) NOT NULL DEFAULT ''")
+		},
+		down: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, "ALTER TABLE recipes DROP COLUMN equipment",
+				"ALTER TABLE recipes DROP COLUMN ready_in_minutes")
+		},
+		downBackup: []string{"recipes"},
+	},
+	{
+		version: 15,
+		name:    "invites and members tables",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, invitesSchema, membersSchema)
+		},
+		creates: []string{"invites", "members"},
+	},
+	{
+		version: 16,
+		name:    "notification preferences table",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, notificationPrefsSchema)
+		},
+		creates: []string{"notification_prefs"},
+	},
+	{
+		version: 17,
+		name:    "digest items table",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, fmt.Sprintf(digestItemsSchema, s.dialect.autoIncrement))
+		},
+		creates: []string{"digest_items"},
+	},
+	{
+		version: 18,
+		name:    "recipe reports table",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, fmt.Sprintf(recipeReportsSchema, s.dialect.autoIncrement))
+		},
+		creates: []string{"recipe_reports"},
+	},
+	{
+		version: 19,
+		name:    "recipe pages and authors",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db,
+				"ALTER TABLE recipes ADD COLUMN source_url VARCHAR(512) NOT NULL DEFAULT ''",
+				"ALTER TABLE recipes ADD COLUMN author VARCHAR(255) NOT NULL DEFAULT ''")
+		},
+		down: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, "ALTER TABLE recipes DROP COLUMN author",
+				"ALTER TABLE recipes DROP COLUMN source_url")
+		},
+		downBackup: []string{"recipes"},
+	},
+	{
+		version: 20,
+		name:    "cache lookups table",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, cacheLookupsSchema)
+		},
+		creates: []string{"cache_lookups"},
+	},
+	{
+		version: 21,
+		name:    "ranking experiment tables",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, experimentShownSchema, experimentChoicesSchema)
+		},
+		creates: []string{"experiment_shown", "experiment_choices"},
+	},
+	{
+		version: 22,
+		name:    "preferences table",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, preferencesSchema)
+		},
+		creates: []string{"preferences"},
+	},
+	{
+		version: 23,
+		name:    "recipe pairings table",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, recipePairingsSchema)
+		},
+		creates: []string{"recipe_pairings"},
+	},
+	{
+		version: 24,
+		name:    "custom recipes table",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, fmt.Sprintf(customRecipesSchema, s.dialect.autoIncrement))
+		},
+		creates: []string{"custom_recipes"},
+	},
+	{
+		version: 25,
+		name:    "recipe popularity and taste",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db,
+				"ALTER TABLE recipes ADD COLUMN likes INTEGER NOT NULL DEFAULT 0",
+				"ALTER TABLE recipes ADD COLUMN health_score DOUBLE NOT NULL DEFAULT 0",
+				"ALTER TABLE recipe_details ADD COLUMN taste VARCHAR(255) NOT NULL DEFAULT ''")
+		},
+		down: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, "ALTER TABLE recipe_details DROP COLUMN taste",
+				"ALTER TABLE recipes DROP COLUMN health_score", "ALTER TABLE recipes DROP COLUMN likes")
+		},
+		downBackup: []string{"recipes", "recipe_details"},
+	},
+	{
+		version: 26,
+		name:    "substitutes table",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, substitutesSchema)
+		},
+		creates: []string{"substitutes"},
+	},
+	{
+		version: 27,
+		name:    "sessions table",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, sessionsSchema)
+		},
+		creates: []string{"sessions"},
+	},
+	{
+		version: 28,
+		name:    "experiment views with generated ids",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, fmt.Sprintf(experimentViewsSchema, s.dialect.autoIncrement),
+				"INSERT INTO experiment_views (recipe_id, strategy, shown_at) "+
+					"SELECT recipe_id, strategy, shown_at FROM experiment_shown ORDER BY shown_at",
+				"DROP TABLE experiment_shown")
+		},
+		backup:  []string{"experiment_shown"},
+		creates: []string{"experiment_views"},
+		down: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, experimentShownSchema,
+				"INSERT INTO experiment_shown (recipe_id, strategy, shown_at) "+
+					"SELECT recipe_id, strategy, shown_at FROM experiment_views")
+		},
+	},
+	{
+		version: 29,
+		name:    "recipe cuisines",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, "ALTER TABLE recipes ADD COLUMN cuisines VARCHAR(255) NOT NULL DEFAULT ''")
+		},
+		down: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, "ALTER TABLE recipes DROP COLUMN cuisines")
+		},
+		downBackup: []string{"recipes"},
+	},
+	{
+		version: 30,
+		name:    "cooked meals table",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, cookedMealsSchema)
+		},
+		creates: []string{"cooked_meals"},
+	},
+	{
+		version: 31,
+		name:    "cooked meal techniques",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db,
+				"ALTER TABLE cooked_meals ADD COLUMN techniques VARCHAR(255) NOT NULL DEFAULT ''")
+		},
+		down: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, "ALTER TABLE cooked_meals DROP COLUMN techniques")
+		},
+		downBackup: []string{"cooked_meals"},
+	},
+	{
+		version: 32,
+		name:    "cooked meal prices and vegetables",
+		up: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db,
+				"ALTER TABLE cooked_meals ADD COLUMN price BIGINT NOT NULL DEFAULT 0",
+				"ALTER TABLE cooked_meals ADD COLUMN vegetables VARCHAR(255) NOT NULL DEFAULT ''")
+		},
+		down: func(ctx context.Context, s *sqlStore) error {
+			return execSchemas(ctx, s.db, "ALTER TABLE cooked_meals DROP COLUMN price",
+				"ALTER TABLE cooked_meals DROP COLUMN vegetables")
+		},
+		downBackup: []string{"cooked_meals"},
+	},
+}
+
+func execSchemas(ctx context.Context, db *sql.DB, schemas ...string) error {
+	for _, schema := range schemas {
+		_, err := db.ExecContext(ctx, schema)
+		if err != nil {
+			return fmt.Errorf("error creating schema: %v", err)
+		}
+	}
+	return nil
+}
+
+// Migrations returns every migration this version knows, oldest first, with
+// when it was applied to the database.
+func (s *sqlStore) Migrations(ctx context.Context) ([]Migration, error) {
+	_, err := s.db.ExecContext(ctx, migrationsSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error creating schema_migrations table: %v", err)
+	}
+	rows, err := s.db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("error reading schema version: %v", err)
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			slog.Warn("error closing rows", "error", err)
+		}
+	}(rows)
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt int64
+		err := rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error reading schema version: %v", err)
+		}
+		applied[version] = time.Unix(appliedAt, 0)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading schema version: %v", err)
+	}
+
+	all := make([]Migration, 0, len(migrations))
+	for _, m := range migrations {
+		all = append(all, Migration{Version: m.version, Name: m.name, AppliedAt: applied[m.version]})
+	}
+	return all, nil
+}
+
+// Migrate applies the pending migrations in order and returns them, then
+// creates the queries tables the sharding needs. It stops at the first
+// migration failing; the ones before it stay applied.
+func (s *sqlStore) Migrate(ctx context.Context) ([]Migration, error) {
+	all, err := s.Migrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var applied []Migration
+	for i, m := range migrations {
+		if !all[i].AppliedAt.IsZero() {
+			continue
+		}
+		err := s.backupMigration(ctx, m, "up", m.backup)
+		if err != nil {
+			return applied, err
+		}
+		err = m.up(ctx, s)
+		if err != nil {
+			return applied, fmt.Errorf("error applying migration %d (%s): %v", m.version, m.name, err)
+		}
+		now := time.Now()
+		_, err = s.db.ExecContext(ctx, "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+			m.version, m.name, now.Unix())
+		if err != nil {
+			return applied, fmt.Errorf("error recording migration %d: %v", m.version, err)
+		}
+		slog.Info("applied schema migration", "version", m.version, "name", m.name)
+		applied = append(applied, Migration{Version: m.version, Name: m.name, AppliedAt: now})
+	}
+	return applied, createQueryTables(ctx, s.db, s.shardDigits)
+}
+
+// pendingMigrations counts the migrations not applied yet.
+func pendingMigrations(all []Migration) int {
+	pending := 0
+	for _, m := range all {
+		if m.AppliedAt.IsZero() {
+			pending++
+		}
+	}
+	return pending
+}
+
+// ErrIrreversibleMigration is returned when rolling back a migration that
+// cannot be undone.
+var ErrIrreversibleMigration = errors.New("the last migration cannot be rolled back")
+
+// RollbackMigration reverts the last migration applied and returns it. The
+// rows it drops or rewrites are backed up first, like before a destructive
+// migration. Opening the store with AutoMigrate applies it again.
+func (s *sqlStore) RollbackMigration(ctx context.Context) (Migration, error) {
+	all, err := s.Migrations(ctx)
+	if err != nil {
+		return Migration{}, err
+	}
+	last := -1
+	for i := range all {
+		if !all[i].AppliedAt.IsZero() {
+			last = i
+		}
+	}
+	if last < 0 {
+		return Migration{}, errors.New("no migration is applied")
+	}
+	m := migrations[last]
+	if !m.reversible() {
+		return all[last], fmt.Errorf("%w: migration %d (%s)", ErrIrreversibleMigration, m.version, m.name)
+	}
+
+	err = s.backupMigration(ctx, m, "down", append(slices.Clone(m.creates), m.downBackup...))
+	if err != nil {
+		return all[last], err
+	}
+	if m.down != nil {
+		err := m.down(ctx, s)
+		if err != nil {
+			return all[last], fmt.Errorf("error rolling back migration %d (%s): %v", m.version, m.name, err)
+		}
+	}
+	for _, table := range m.creates {
+		_, err := s.db.ExecContext(ctx, "DROP TABLE IF EXISTS "+table)
+		if err != nil {
+			return all[last], fmt.Errorf("error dropping %s table: %v", table, err)
+		}
+	}
+	_, err = s.db.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", m.version)
+	if err != nil {
+		return all[last], fmt.Errorf("error recording the rollback of migration %d: %v", m.version, err)
+	}
+	slog.Info("rolled back schema migration", "version", m.version, "name", m.name)
+	return all[last], nil
+}
+
+// backupMigration backs up the tables before a migration, or its rollback,
+// changes them.
+func (s *sqlStore) backupMigration(ctx context.Context, m migration, direction string, tables []string) error {
+	path, err := s.backupTables(ctx, m.version, direction, tables)
+	if err != nil {
+		return fmt.Errorf("error backing up before migration %d (%s): %v", m.version, m.name, err)
+	}
+	if path != "" {
+		slog.Info("backed up the tables the migration changes", "version", m.version, "file", path)
+		if direction == "up" {
+			slog.Info("the migration can be undone with recipefinder db rollback-migration", "version", m.version)
+		}
+	}
+	return nil
+}
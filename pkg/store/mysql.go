@@ -0,0 +1,74 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Config holds the MySQL connection settings.
+type Config struct {
+	User     string
+	Password string
+	Addr     string
+	Name     string
+}
+
+var mysqlDialect = dialect{
+	countColumn: "SELECT COUNT(*) FROM information_schema.COLUMNS " +
+		"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?",
+	countIndex: "SELECT COUNT(*) FROM information_schema.STATISTICS " +
+		"WHERE TABLE_SCHEMA = DATABASE() AND INDEX_NAME = ?",
+	autoIncrement: "AUTO_INCREMENT",
+	databaseSize: "SELECT COALESCE(SUM(DATA_LENGTH + INDEX_LENGTH), 0) FROM information_schema.TABLES " +
+		"WHERE TABLE_SCHEMA = DATABASE()",
+}
+
+// OpenMySQL connects to MySQL and checks that it is reachable.
+func OpenMySQL(ctx context.Context, config Config, options Options) (Store, error) {
+	err := checkShardDigits(options.ShardDigits)
+	if err != nil {
+		return nil, err
+	}
+	cfg := mysql.Config{
+		User:                 config.User,
+		Passwd:               config.Password,
+		Net:                  "tcp",
+		Addr:                 config.Addr,
+		DBName:               config.Name,
+		AllowNativePasswords: true,
+	}
+
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return nil, err
+	}
+
+	pingErr := db.PingContext(ctx)
+	if pingErr != nil {
+		_ = db.Close()
+		return nil, pingErr
+	}
+
+	return newSQLStore(ctx, db, options, mysqlDialect)
+}
+
+func parseMySQLURL(rawURL string) (Config, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid database URL: %v", err)
+	}
+	config := Config{
+		Addr: parsed.Host,
+		Name: strings.TrimPrefix(parsed.Path, "/"),
+	}
+	if parsed.User != nil {
+		config.User = parsed.User.Username()
+		config.Password, _ = parsed.User.Password()
+	}
+	return config, nil
+}
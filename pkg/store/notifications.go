@@ -0,0 +1,179 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// notificationPrefsSchema keeps what each profile wants to be notified of,
+// channels and events comma-separated and quiet hours in minutes after
+// midnight.
+const notificationPrefsSchema = `
+CREATE TABLE IF NOT EXISTS notification_prefs (
+	profile     VARCHAR(64)  NOT NULL PRIMARY KEY,
+	channels    VARCHAR(255) NOT NULL,
+	events      VARCHAR(255) NOT NULL,
+	quiet_start INTEGER      NOT NULL,
+	quiet_end   INTEGER      NOT NULL
+)`
+
+// digestItemsSchema holds the notifications waiting for the daily digest of
+// each member account, with %s completing the auto-incremented primary key
+// for the dialect.
+const digestItemsSchema = `
+CREATE TABLE IF NOT EXISTS digest_items (
+	id         INTEGER      NOT NULL PRIMARY KEY %s,
+	account    VARCHAR(128) NOT NULL,
+	event      VARCHAR(32)  NOT NULL,
+	message    TEXT         NOT NULL,
+	created_at BIGINT       NOT NULL
+)`
+
+// The channels notifications can go out through. ChannelNone alone turns
+// them off.
+const (
+	ChannelTelegram = "telegram"
+	ChannelNone     = "none"
+)
+
+// The events a profile can be notified of.
+const (
+	EventNewMatches      = "new_matches"
+	EventDailySuggestion = "daily_suggestion"
+	EventExpiringItems   = "expiring_items"
+	EventPlanPublished   = "plan_published"
+)
+
+// Channels and Events list the known channels and events.
+var (
+	Channels = []string{ChannelTelegram, ChannelNone}
+	Events   = []string{EventNewMatches, EventDailySuggestion, EventExpiringItems, EventPlanPublished}
+)
+
+// NotificationPrefs is what a profile wants to be notified of and how.
+type NotificationPrefs struct {
+	Profile  string
+	Channels []string
+	Events   []string
+	// QuietStart and QuietEnd are the minutes after midnight, local time,
+	// between which nothing is sent; equal when there are no quiet hours.
+	// Quiet hours may span midnight, as 22:00 to 07:00 does.
+	QuietStart int
+	QuietEnd   int
+}
+
+// DefaultNotificationPrefs are the preferences of a profile that has not
+// chosen any: every event through Telegram, at any time.
+func DefaultNotificationPrefs(profile string) NotificationPrefs {
+	return NotificationPrefs{Profile: profile, Channels: []string{ChannelTelegram}, Events: slices.Clone(Events)}
+}
+
+// Wants reports whether the event should be sent through the channel at the
+// time.
+func (p NotificationPrefs) Wants(channel string, event string, at time.Time) bool {
+	return p.Subscribed(channel, event) && !p.quiet(at)
+}
+
+// Subscribed reports whether the event should be sent through the channel at
+// some time, such as in a digest.
+func (p NotificationPrefs) Subscribed(channel string, event string) bool {
+	return slices.Contains(p.Channels, channel) && slices.Contains(p.Events, event)
+}
+
+func (p NotificationPrefs) quiet(at time.Time) bool {
+	minute := at.Hour()*60 + at.Minute()
+	if p.QuietStart <= p.QuietEnd {
+		return minute >= p.QuietStart && minute < p.QuietEnd
+	}
+	return minute >= p.QuietStart || minute < p.QuietEnd
+}
+
+// NotificationPrefs returns the preferences of a profile, the defaults when
+// it has not chosen any.
+func (s *sqlStore) NotificationPrefs(ctx context.Context, profile string) (NotificationPrefs, error) {
+	prefs := NotificationPrefs{Profile: profile}
+	var channels, events string
+	err := s.db.QueryRowContext(ctx, "SELECT channels, events, quiet_start, quiet_end FROM notification_prefs "+
+		"WHERE profile = ?", profile).Scan(&channels, &events, &prefs.QuietStart, &prefs.QuietEnd)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DefaultNotificationPrefs(profile), nil
+	}
+	if err != nil {
+		return prefs, err
+	}
+	prefs.Channels = splitList(channels)
+	prefs.Events = splitList(events)
+	return prefs, nil
+}
+
+// SaveNotificationPrefs replaces the preferences of a profile.
+func (s *sqlStore) SaveNotificationPrefs(ctx context.Context, prefs NotificationPrefs) error {
+	_, err := s.db.ExecContext(ctx, "REPLACE INTO notification_prefs (profile, channels, events, quiet_start, "+
+		"quiet_end) VALUES (?, ?, ?, ?, ?)", prefs.Profile, strings.Join(prefs.Channels, ","),
+		strings.Join(prefs.Events, ","), prefs.QuietStart, prefs.QuietEnd)
+	return err
+}
+
+func splitList(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
+
+// DigestItem is a notification waiting for the daily digest of an account.
+type DigestItem struct {
+	Account   string
+	Event     string
+	Message   string
+	CreatedAt time.Time
+}
+
+// QueueDigest adds a notification to the next digest of its account.
+func (s *sqlStore) QueueDigest(ctx context.Context, item DigestItem) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO digest_items (account, event, message, created_at) "+
+		"VALUES (?, ?, ?, ?)", item.Account, item.Event, item.Message, time.Now().Unix())
+	return err
+}
+
+// PendingDigests returns the notifications queued before a time, by account
+// and oldest first.
+func (s *sqlStore) PendingDigests(ctx context.Context, before time.Time) ([]DigestItem, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT account, event, message, created_at FROM digest_items "+
+		"WHERE created_at < ? ORDER BY account, created_at, id", before.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			slog.Warn("error closing rows", "error", err)
+		}
+	}(rows)
+
+	var items []DigestItem
+	for rows.Next() {
+		var item DigestItem
+		var createdAt int64
+		err := rows.Scan(&item.Account, &item.Event, &item.Message, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+		item.CreatedAt = time.Unix(createdAt, 0)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// ClearDigest removes the notifications of an account queued before a time,
+// once its digest is sent.
+func (s *sqlStore) ClearDigest(ctx context.Context, account string, before time.Time) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM digest_items WHERE account = ? AND created_at < ?", account,
+		before.Unix())
+	return err
+}
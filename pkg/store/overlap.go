@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// SearchCached returns the cached recipes using any of the query's
+// ingredients, expired ones included, whatever query they were cached for.
+// The ones using the most ingredients come first, then the ones missing the
+// fewest, then by source and ID. The query's filters other than the
+// ingredients are not applied.
+func (s *sqlStore) SearchCached(ctx context.Context, query recipes.Query) ([]recipes.Recipe, error) {
+	if len(query.Ingredients) == 0 {
+		return nil, nil
+	}
+	// LIKE narrows the recipes down, MatchIngredients decides which
+	// ingredients really match
+	conditions := make([]string, 0, len(query.Ingredients))
+	args := make([]any, 0, len(query.Ingredients))
+	for _, ingredient := range query.Ingredients {
+		conditions = append(conditions, "LOWER(i.name) LIKE ?")
+		args = append(args, "%"+strings.ToLower(ingredient)+"%")
+	}
+	where := "EXISTS (SELECT 1 FROM recipe_ingredients i WHERE i.source = r.source AND i.recipe_id = r.id AND (" +
+		strings.Join(conditions, " OR ") + "))"
+
+	allRecipes, err := s.readRecipes(ctx,
+		"SELECT "+recipeColumns+" FROM recipes r WHERE "+where+" ORDER BY r.source, r.id", args...)
+	if err != nil || len(allRecipes) == 0 {
+		return nil, err
+	}
+	err = s.readDetails(ctx, allRecipes, "FROM recipes r", where, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := allRecipes[:0]
+	for _, recipe := range allRecipes {
+		recipe.UsedIngredients, recipe.MissedIngredients =
+			recipes.MatchIngredients(recipe.UsedIngredients, query.Ingredients)
+		if len(recipe.UsedIngredients) > 0 {
+			matching = append(matching, recipe)
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		if len(matching[i].UsedIngredients) != len(matching[j].UsedIngredients) {
+			return len(matching[i].UsedIngredients) > len(matching[j].UsedIngredients)
+		}
+		return len(matching[i].MissedIngredients) < len(matching[j].MissedIngredients)
+	})
+	return matching, nil
+}
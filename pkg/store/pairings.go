@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// recipePairingsSchema caches the drink pairings of recipes, alongside their
+// details. wines are comma-separated and empty for a recipe that was looked
+// up but has no pairing, so it is not looked up again until it expires.
+const recipePairingsSchema = `
+CREATE TABLE IF NOT EXISTS recipe_pairings (
+	source     VARCHAR(32)  NOT NULL DEFAULT '',
+	recipe_id  INTEGER      NOT NULL,
+	wines      VARCHAR(512) NOT NULL,
+	note       TEXT         NOT NULL,
+	food       VARCHAR(255) NOT NULL,
+	fetched_at BIGINT       NOT NULL,
+	PRIMARY KEY (source, recipe_id)
+)`
+
+// CachedPairing returns the cached pairing of a recipe, expired or not, or
+// nil when it was not looked up. The pairing has no wines when the recipe has
+// none. The boolean reports whether it is unexpired.
+func (s *sqlStore) CachedPairing(ctx context.Context, source string, id int) (*recipes.Pairing, bool, error) {
+	var pairing recipes.Pairing
+	var wines string
+	var fetchedAt int64
+	err := s.db.QueryRowContext(ctx, "SELECT wines, note, food, fetched_at FROM recipe_pairings "+
+		"WHERE source = ? AND recipe_id = ?", source, id).Scan(&wines, &pairing.Note, &pairing.Food, &fetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	pairing.Wines = splitList(wines)
+	return &pairing, fetchedAt >= s.cutoff(), nil
+}
+
+// SavePairing caches the pairing of a recipe, replacing what was cached for
+// it before. A pairing without wines records that the recipe has none.
+func (s *sqlStore) SavePairing(ctx context.Context, source string, id int, pairing recipes.Pairing) error {
+	_, err := s.db.ExecContext(ctx, "REPLACE INTO recipe_pairings (source, recipe_id, wines, note, food, fetched_at) "+
+		"VALUES (?, ?, ?, ?, ?, ?)", source, id, strings.Join(pairing.Wines, ","), pairing.Note, pairing.Food,
+		time.Now().Unix())
+	return err
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+)
+
+// pantrySchema is valid for both MySQL and SQLite. It was replaced by
+// pantriesSchema and is kept for the migrations.
+const pantrySchema = `
+CREATE TABLE IF NOT EXISTS pantry (
+	name VARCHAR(255) NOT NULL PRIMARY KEY
+)`
+
+// pantriesSchema keeps a pantry per profile, the pantry of no profile under
+// the empty name.
+const pantriesSchema = `
+CREATE TABLE IF NOT EXISTS pantries (
+	profile VARCHAR(64)  NOT NULL,
+	name    VARCHAR(255) NOT NULL,
+	PRIMARY KEY (profile, name)
+)`
+
+func (s *sqlStore) Pantry(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT name FROM pantries WHERE profile = ? ORDER BY name", s.profile)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			slog.Warn("error closing rows", "error", err)
+		}
+	}(rows)
+
+	var items []string
+	for rows.Next() {
+		var name string
+		err := rows.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *sqlStore) AddToPantry(ctx context.Context, items []string) error {
+	for _, item := range items {
+		_, err := s.db.ExecContext(ctx, "REPLACE INTO pantries (profile, name) VALUES (?, ?)", s.profile, item)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sqlStore) RemoveFromPantry(ctx context.Context, items []string) (int64, error) {
+	var removed int64
+	for _, item := range items {
+		result, err := s.db.ExecContext(ctx, "DELETE FROM pantries WHERE profile = ? AND name = ?", s.profile, item)
+		if err != nil {
+			return removed, err
+		}
+		count, err := result.RowsAffected()
+		if err != nil {
+			return removed, err
+		}
+		removed += count
+	}
+	return removed, nil
+}
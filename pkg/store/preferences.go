@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// preferencesSchema keeps the preferences learned from the quiz for each
+// profile, "" being the default one, with the disliked ingredients
+// comma-separated.
+const preferencesSchema = `
+CREATE TABLE IF NOT EXISTS preferences (
+	profile       VARCHAR(64) NOT NULL PRIMARY KEY,
+	rank_missing  DOUBLE      NOT NULL,
+	rank_protein  DOUBLE      NOT NULL,
+	rank_calories DOUBLE      NOT NULL,
+	rank_time     DOUBLE      NOT NULL,
+	disliked      TEXT        NOT NULL,
+	updated_at    BIGINT      NOT NULL
+)`
+
+// Preferences are what the preference quiz learned about a profile. The
+// calorie target of Ranking is not learned and left zero.
+type Preferences struct {
+	Profile   string
+	Ranking   recipes.RankWeights
+	Disliked  []string
+	UpdatedAt time.Time
+}
+
+// SavePreferences replaces the learned preferences of a profile.
+func (s *sqlStore) SavePreferences(ctx context.Context, prefs Preferences) error {
+	_, err := s.db.ExecContext(ctx, "REPLACE INTO preferences (profile, rank_missing, rank_protein, rank_calories, "+
+		"rank_time, disliked, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)", prefs.Profile, prefs.Ranking.Missing,
+		prefs.Ranking.Protein, prefs.Ranking.Calories, prefs.Ranking.Time, strings.Join(prefs.Disliked, ","),
+		time.Now().Unix())
+	return err
+}
+
+// Preferences returns the learned preferences of a profile, nil when it has
+// not taken the quiz.
+func (s *sqlStore) Preferences(ctx context.Context, profile string) (*Preferences, error) {
+	prefs := Preferences{Profile: profile}
+	var disliked string
+	var updatedAt int64
+	err := s.db.QueryRowContext(ctx, "SELECT rank_missing, rank_protein, rank_calories, rank_time, disliked, "+
+		"updated_at FROM preferences WHERE profile = ?", profile).Scan(&prefs.Ranking.Missing, &prefs.Ranking.Protein,
+		&prefs.Ranking.Calories, &prefs.Ranking.Time, &disliked, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	prefs.Disliked = splitList(disliked)
+	prefs.UpdatedAt = time.Unix(updatedAt, 0)
+	return &prefs, nil
+}
+
+// DeletePreferences forgets the learned preferences of a profile and
+// reports whether there were any.
+func (s *sqlStore) DeletePreferences(ctx context.Context, profile string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM preferences WHERE profile = ?", profile)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	return count > 0, err
+}
+
+// QuizRecipes returns up to limit of the most recently fetched recipes,
+// with their ingredients and nutrients, for the preference quiz.
+func (s *sqlStore) QuizRecipes(ctx context.Context, limit int) ([]recipes.Recipe, error) {
+	allRecipes, err := s.readRecipes(ctx,
+		"SELECT "+recipeColumns+" FROM recipes r ORDER BY r.fetched_at DESC, r.source, r.id LIMIT ?", limit)
+	if err != nil || len(allRecipes) == 0 {
+		return nil, err
+	}
+	ids := make([]any, len(allRecipes))
+	for i, recipe := range allRecipes {
+		ids[i] = recipe.ID
+	}
+	// readDetails only fills in the recipes read, whatever the source of
+	// others with the same IDs
+	where := "r.id IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ") + ")"
+	err = s.readDetails(ctx, allRecipes, "FROM recipes r", where, ids...)
+	if err != nil {
+		return nil, err
+	}
+	return allRecipes, nil
+}
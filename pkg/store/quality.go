@@ -0,0 +1,120 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// providerQualitySchema counts the recipes saved from each provider and the
+// data quality issues found in them, see recipes.QualityIssues.
+const providerQualitySchema = `
+CREATE TABLE IF NOT EXISTS provider_quality (
+	source             VARCHAR(32) NOT NULL PRIMARY KEY,
+	recipes            INTEGER     NOT NULL,
+	missing_nutrition  INTEGER     NOT NULL,
+	broken_images      INTEGER     NOT NULL,
+	implausible_values INTEGER     NOT NULL,
+	updated_at         BIGINT      NOT NULL
+)`
+
+// ProviderQuality is the data quality of the recipes saved from a provider.
+type ProviderQuality struct {
+	Source string
+	recipes.Quality
+	UpdatedAt time.Time
+}
+
+// recordQuality adds the recipes to the counts of their providers. Every
+// save counts, so a recipe fetched again is counted again. Recipes of an
+// unknown source are skipped.
+func (s *sqlStore) recordQuality(ctx context.Context, allRecipes []recipes.Recipe) error {
+	bySource := make(map[string]*recipes.Quality)
+	for _, recipe := range allRecipes {
+		if recipe.Source == "" {
+			continue
+		}
+		quality := bySource[recipe.Source]
+		if quality == nil {
+			quality = &recipes.Quality{}
+			bySource[recipe.Source] = quality
+		}
+		quality.Add(recipe)
+	}
+
+	now := time.Now().Unix()
+	for source, quality := range bySource {
+		// Two processes adding the first counts of a provider at once may
+		// lose one of them, which the counts can afford
+		result, err := s.db.ExecContext(ctx, "UPDATE provider_quality SET recipes = recipes + ?, "+
+			"missing_nutrition = missing_nutrition + ?, broken_images = broken_images + ?, "+
+			"implausible_values = implausible_values + ?, updated_at = ? WHERE source = ?",
+			quality.Recipes, quality.MissingNutrition, quality.BrokenImages, quality.ImplausibleValues, now, source)
+		if err != nil {
+			return err
+		}
+		count, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		_, err = s.db.ExecContext(ctx, "REPLACE INTO provider_quality (source, recipes, missing_nutrition, "+
+			"broken_images, implausible_values, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+			source, quality.Recipes, quality.MissingNutrition, quality.BrokenImages, quality.ImplausibleValues, now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// logQuality records the data quality of saved recipes, only logging
+// failures since the recipes themselves were saved.
+func (s *sqlStore) logQuality(ctx context.Context, allRecipes []recipes.Recipe) {
+	err := s.recordQuality(ctx, allRecipes)
+	if err != nil {
+		slog.Warn("error recording provider data quality", "error", err)
+	}
+}
+
+// ProviderQuality returns the data quality counted for every provider,
+// sorted by provider name.
+func (s *sqlStore) ProviderQuality(ctx context.Context) ([]ProviderQuality, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT source, recipes, missing_nutrition, broken_images, "+
+		"implausible_values, updated_at FROM provider_quality")
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			slog.Warn("error closing rows", "error", err)
+		}
+	}(rows)
+
+	var providers []ProviderQuality
+	for rows.Next() {
+		var provider ProviderQuality
+		var updatedAt int64
+		err := rows.Scan(&provider.Source, &provider.Recipes, &provider.MissingNutrition, &provider.BrokenImages,
+			&provider.ImplausibleValues, &updatedAt)
+		if err != nil {
+			return nil, err
+		}
+		provider.UpdatedAt = time.Unix(updatedAt, 0)
+		providers = append(providers, provider)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i].Source < providers[j].Source
+	})
+	return providers, nil
+}
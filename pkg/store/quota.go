@@ -0,0 +1,174 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// quotaReservationsSchema holds the API points reserved by running searches,
+// with %s completing the auto-incremented primary key for the dialect. API
+// keys are only stored hashed.
+const quotaReservationsSchema = `
+CREATE TABLE IF NOT EXISTS quota_reservations (
+	id         INTEGER  NOT NULL PRIMARY KEY %s,
+	key_hash   CHAR(64) NOT NULL,
+	points     DOUBLE   NOT NULL,
+	expires_at BIGINT   NOT NULL
+)`
+
+// quotaUsageSchema holds the points an API reported spent on each day, in
+// UTC, and the daily quota it reported.
+const quotaUsageSchema = `
+CREATE TABLE IF NOT EXISTS quota_usage (
+	key_hash CHAR(64) NOT NULL,
+	day      CHAR(10) NOT NULL,
+	used     DOUBLE   NOT NULL,
+	daily    DOUBLE   NOT NULL,
+	PRIMARY KEY (key_hash, day)
+)`
+
+// ErrQuotaReserved is returned by ReserveQuota when the points spent today and
+// reserved by others leave too few for another reservation.
+var ErrQuotaReserved = errors.New("the daily API quota is used up or reserved by running searches")
+
+// quotaDay is the day quotas are counted for, which is reset at midnight UTC.
+func quotaDay() string {
+	return time.Now().UTC().Format(time.DateOnly)
+}
+
+// ReserveQuota reserves points of an API key's daily quota until ReleaseQuota
+// is called with the returned ID, or ttl passes. daily is the quota, zero for
+// the last one recorded by ReleaseQuota; with neither, every reservation
+// succeeds. The check and the reservation are not atomic across databases
+// connections, so the quota is only kept approximately.
+func (s *sqlStore) ReserveQuota(ctx context.Context, apiKey string, points float64, daily float64,
+	ttl time.Duration) (int64, error) {
+	keyHash := hashKey(apiKey)
+	now := time.Now()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %v", err)
+	}
+	// Rolling back after a commit does nothing
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM quota_reservations WHERE expires_at < ?", now.Unix())
+	if err != nil {
+		return 0, err
+	}
+	var used, reportedDaily float64
+	err = tx.QueryRowContext(ctx, "SELECT used, daily FROM quota_usage WHERE key_hash = ? AND day = ?",
+		keyHash, quotaDay()).Scan(&used, &reportedDaily)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	var reserved float64
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(SUM(points), 0) FROM quota_reservations WHERE key_hash = ?",
+		keyHash).Scan(&reserved)
+	if err != nil {
+		return 0, err
+	}
+	if daily <= 0 {
+		daily = reportedDaily
+	}
+	if daily > 0 && used+reserved+points > daily {
+		return 0, ErrQuotaReserved
+	}
+
+	id, err := insertID(ctx, tx, s.dialect,
+		"INSERT INTO quota_reservations (key_hash, points, expires_at) VALUES (?, ?, ?)",
+		keyHash, points, now.Add(ttl).Unix())
+	if err != nil {
+		return 0, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("error committing reservation: %v", err)
+	}
+	return id, nil
+}
+
+// ReleaseQuota drops a reservation and records the points the API reported
+// spent today and its daily quota, as RecordQuotaUsage does.
+func (s *sqlStore) ReleaseQuota(ctx context.Context, id int64, apiKey string, used float64, daily float64) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM quota_reservations WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	return s.RecordQuotaUsage(ctx, apiKey, used, daily)
+}
+
+// RecordQuotaUsage records the points an API reported spent today and its
+// daily quota, unless used is zero. A lower count than the one recorded,
+// reported before it but recorded after, is ignored.
+func (s *sqlStore) RecordQuotaUsage(ctx context.Context, apiKey string, used float64, daily float64) error {
+	if used <= 0 {
+		return nil
+	}
+	keyHash := hashKey(apiKey)
+	day := quotaDay()
+	var recorded float64
+	err := s.db.QueryRowContext(ctx, "SELECT used FROM quota_usage WHERE key_hash = ? AND day = ?", keyHash, day).
+		Scan(&recorded)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err == nil && recorded >= used {
+		return nil
+	}
+	_, err = s.db.ExecContext(ctx, "REPLACE INTO quota_usage (key_hash, day, used, daily) VALUES (?, ?, ?, ?)",
+		keyHash, day, used, daily)
+	return err
+}
+
+// KeyUsage is what was recorded spent with an API key.
+type KeyUsage struct {
+	// Today and Daily are the points spent today and the daily quota last
+	// reported, zero when none were recorded.
+	Today float64
+	Daily float64
+	// Recent is the points spent over the last days, today's included.
+	Recent float64
+}
+
+// Spent reports whether the daily quota of the key is spent.
+func (u KeyUsage) Spent() bool {
+	return u.Daily > 0 && u.Today >= u.Daily
+}
+
+// KeyUsage returns the points recorded spent with an API key today and over
+// the days before it, today included.
+func (s *sqlStore) KeyUsage(ctx context.Context, apiKey string, days int) (KeyUsage, error) {
+	keyHash := hashKey(apiKey)
+	var usage KeyUsage
+	err := s.db.QueryRowContext(ctx, "SELECT used, daily FROM quota_usage WHERE key_hash = ? AND day = ?", keyHash,
+		quotaDay()).Scan(&usage.Today, &usage.Daily)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return KeyUsage{}, err
+	}
+	// Days are written as YYYY-MM-DD, so they sort as they follow each other
+	since := time.Now().UTC().AddDate(0, 0, 1-days).Format(time.DateOnly)
+	err = s.db.QueryRowContext(ctx, "SELECT COALESCE(SUM(used), 0) FROM quota_usage WHERE key_hash = ? AND day >= ?",
+		keyHash, since).Scan(&usage.Recent)
+	if err != nil {
+		return KeyUsage{}, err
+	}
+	return usage, nil
+}
+
+// QuotaUsage returns the points recorded spent today with an API key, zero
+// when none are.
+func (s *sqlStore) QuotaUsage(ctx context.Context, apiKey string) (float64, error) {
+	var used float64
+	err := s.db.QueryRowContext(ctx, "SELECT used FROM quota_usage WHERE key_hash = ? AND day = ?", hashKey(apiKey),
+		quotaDay()).Scan(&used)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return used, err
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// StaleRecipes returns the expired recipes cached from a source with their
+// titles and nutrients, for refreshing them with UpdateRecipes.
+func (s *sqlStore) StaleRecipes(ctx context.Context, source string) ([]recipes.Recipe, error) {
+	if s.ttl <= 0 {
+		return nil, nil
+	}
+	where := "r.source = ? AND r.fetched_at < ?"
+	allRecipes, err := s.readRecipes(ctx,
+		"SELECT "+recipeColumns+" FROM recipes r WHERE "+where+" ORDER BY r.id",
+		source, s.cutoff())
+	if err != nil || len(allRecipes) == 0 {
+		return nil, err
+	}
+	err = s.readDetails(ctx, allRecipes, "FROM recipes r", where, source, s.cutoff())
+	if err != nil {
+		return nil, err
+	}
+	return allRecipes, nil
+}
+
+// UpdateRecipes writes refreshed titles and nutrients to the recipes from a
+// source and marks them, and the queries that found them, fresh. Only those
+// fields are written, the ingredients and instructions are left alone. It
+// returns how many recipes were updated.
+func (s *sqlStore) UpdateRecipes(ctx context.Context, source string, updates []recipes.RecipeUpdate) (int64, error) {
+	if len(updates) == 0 {
+		return 0, nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %v", err)
+	}
+	// Rolling back after a commit does nothing
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	statements, err := prepareRecipeStatements(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	defer statements.close()
+	recipe, err := tx.PrepareContext(ctx, "UPDATE recipes SET name = ?, fetched_at = ? WHERE source = ? AND id = ?")
+	if err != nil {
+		return 0, fmt.Errorf("error preparing update: %v", err)
+	}
+	defer func() {
+		err := recipe.Close()
+		if err != nil {
+			slog.Warn("error closing statement", "error", err)
+		}
+	}()
+
+	fetchedAt := time.Now().Unix()
+	var updated int64
+	for _, update := range updates {
+		result, err := recipe.ExecContext(ctx, update.Title, fetchedAt, source, update.ID)
+		if err != nil {
+			return 0, err
+		}
+		count, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if count == 0 {
+			continue
+		}
+		updated++
+		err = statements.saveNutrients(ctx, source, update.ID, update.Nutrients)
+		if err != nil {
+			return 0, err
+		}
+		for _, table := range shardTables(s.shardDigits) {
+			_, err := tx.ExecContext(ctx, "UPDATE "+table+" SET fetched_at = ? WHERE source = ? AND recipe_id = ?",
+				fetchedAt, source, update.ID)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("error committing updates: %v", err)
+	}
+	return updated, nil
+}
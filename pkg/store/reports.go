@@ -0,0 +1,63 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+// recipeReportsSchema keeps the recipes the user reported to have wrong data,
+// with %s completing the auto-incremented primary key for the dialect.
+const recipeReportsSchema = `
+CREATE TABLE IF NOT EXISTS recipe_reports (
+	id         INTEGER NOT NULL PRIMARY KEY %s,
+	recipe_id  INTEGER NOT NULL,
+	reason     TEXT    NOT NULL,
+	filed      BOOLEAN NOT NULL,
+	created_at BIGINT  NOT NULL
+)`
+
+// RecipeReport is a data issue the user reported in a recipe.
+type RecipeReport struct {
+	RecipeID int
+	Reason   string
+	// Filed is whether the report was sent to the configured endpoint.
+	Filed     bool
+	CreatedAt time.Time
+}
+
+// AddRecipeReport records a report of a recipe.
+func (s *sqlStore) AddRecipeReport(ctx context.Context, report RecipeReport) error {
+	_, err := s.db.ExecContext(ctx, "INSERT INTO recipe_reports (recipe_id, reason, filed, created_at) "+
+		"VALUES (?, ?, ?, ?)", report.RecipeID, report.Reason, report.Filed, time.Now().Unix())
+	return err
+}
+
+// RecipeReports returns the reports of every recipe, oldest first.
+func (s *sqlStore) RecipeReports(ctx context.Context) ([]RecipeReport, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT recipe_id, reason, filed, created_at FROM recipe_reports "+
+		"ORDER BY created_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			slog.Warn("error closing rows", "error", err)
+		}
+	}(rows)
+
+	var reports []RecipeReport
+	for rows.Next() {
+		var report RecipeReport
+		var createdAt int64
+		err := rows.Scan(&report.RecipeID, &report.Reason, &report.Filed, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+		report.CreatedAt = time.Unix(createdAt, 0)
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
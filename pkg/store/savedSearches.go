@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// savedSearchesSchema keeps named searches, their flags encoded as a JSON
+// object of flag names to values.
+const savedSearchesSchema = `
+CREATE TABLE IF NOT EXISTS saved_searches (
+	name       VARCHAR(64) NOT NULL PRIMARY KEY,
+	flags      TEXT        NOT NULL,
+	updated_at BIGINT      NOT NULL
+)`
+
+// SavedSearch is a search kept under a name to be run again later.
+type SavedSearch struct {
+	Name string
+	// Flags are the values of the flags the search was saved with, keyed by
+	// flag name, as they were given on the command line.
+	Flags     map[string]string
+	UpdatedAt time.Time
+}
+
+// SaveSearch stores a search under its name, replacing any saved before
+// under the same name.
+func (s *sqlStore) SaveSearch(ctx context.Context, search SavedSearch) error {
+	flags, err := json.Marshal(search.Flags)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, "REPLACE INTO saved_searches (name, flags, updated_at) VALUES (?, ?, ?)",
+		search.Name, string(flags), time.Now().Unix())
+	return err
+}
+
+// SavedSearch returns the search saved under the name, or nil when there is
+// none.
+func (s *sqlStore) SavedSearch(ctx context.Context, name string) (*SavedSearch, error) {
+	searches, err := s.readSavedSearches(ctx, "WHERE name = ?", name)
+	if err != nil || len(searches) == 0 {
+		return nil, err
+	}
+	return &searches[0], nil
+}
+
+func (s *sqlStore) SavedSearches(ctx context.Context) ([]SavedSearch, error) {
+	return s.readSavedSearches(ctx, "ORDER BY name")
+}
+
+func (s *sqlStore) DeleteSavedSearch(ctx context.Context, name string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM saved_searches WHERE name = ?", name)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	return count > 0, err
+}
+
+func (s *sqlStore) readSavedSearches(ctx context.Context, condition string, args ...any) ([]SavedSearch, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT name, flags, updated_at FROM saved_searches "+condition, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			slog.Warn("error closing rows", "error", err)
+		}
+	}(rows)
+
+	var searches []SavedSearch
+	for rows.Next() {
+		var search SavedSearch
+		var flags string
+		var updatedAt int64
+		err := rows.Scan(&search.Name, &flags, &updatedAt)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal([]byte(flags), &search.Flags)
+		if err != nil {
+			return nil, fmt.Errorf("error reading saved search %s: %v", search.Name, err)
+		}
+		search.UpdatedAt = time.Unix(updatedAt, 0)
+		searches = append(searches, search)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return searches, nil
+}
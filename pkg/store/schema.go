@@ -0,0 +1,307 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// The cache is normalized into four tables, valid for both MySQL and SQLite:
+// recipes holds each recipe once, whatever query found it, with its
+// ingredients and nutrients in recipe_ingredients and recipe_nutrients, and
+// queries lists the recipes found for each query, in order. Recipe IDs are
+// only unique per source.
+const recipesSchema = `
+CREATE TABLE IF NOT EXISTS recipes (
+	source       VARCHAR(32)  NOT NULL DEFAULT '',
+	id           INTEGER      NOT NULL,
+	name         VARCHAR(255) NOT NULL,
+	servings     INTEGER      NOT NULL DEFAULT 0,
+	instructions TEXT,
+	fetched_at   BIGINT       NOT NULL,
+	PRIMARY KEY (source, id)
+)`
+
+const recipeIngredientsSchema = `
+CREATE TABLE IF NOT EXISTS recipe_ingredients (
+	source    VARCHAR(32)  NOT NULL DEFAULT '',
+	recipe_id INTEGER      NOT NULL,
+	position  INTEGER      NOT NULL,
+	name      VARCHAR(255) NOT NULL,
+	amount    DOUBLE       NOT NULL DEFAULT 0,
+	unit      VARCHAR(64)  NOT NULL DEFAULT '',
+	PRIMARY KEY (source, recipe_id, position)
+)`
+
+const recipeNutrientsSchema = `
+CREATE TABLE IF NOT EXISTS recipe_nutrients (
+	source    VARCHAR(32) NOT NULL DEFAULT '',
+	recipe_id INTEGER     NOT NULL,
+	name      VARCHAR(64) NOT NULL,
+	amount    DOUBLE      NOT NULL,
+	unit      VARCHAR(16) NOT NULL,
+	PRIMARY KEY (source, recipe_id, name)
+)`
+
+// queriesSchema creates the queries table named by %s, see shardTables.
+// fetched_at is when the query was searched, which is what expires; the
+// recipes' own fetched_at is when their data was last fetched.
+const queriesSchema = `
+CREATE TABLE IF NOT EXISTS %s (
+	query_hash   CHAR(64)    NOT NULL,
+	sorted_query TEXT        NOT NULL,
+	source       VARCHAR(32) NOT NULL DEFAULT '',
+	recipe_id    INTEGER     NOT NULL,
+	position     INTEGER     NOT NULL,
+	fetched_at   BIGINT      NOT NULL,
+	PRIMARY KEY (query_hash, source, recipe_id)
+)`
+
+// dialect holds the statements that differ between MySQL, SQLite and
+// PostgreSQL.
+type dialect struct {
+	// countColumn selects the number of columns of the table named by its
+	// first parameter that are named by its second, 0 when there is no such
+	// table.
+	countColumn string
+	// countIndex selects the number of indexes named by its single parameter.
+	countIndex string
+	// autoIncrement follows INTEGER PRIMARY KEY for generated IDs.
+	autoIncrement string
+	// databaseSize selects the size of the database in bytes.
+	databaseSize string
+	// returningID is set when generated IDs are read with RETURNING id, as
+	// the driver does not report them, see insertID.
+	returningID bool
+	// archiveTx are the options of the transaction CreateArchive reads in, nil
+	// when the default one reads a single snapshot.
+	archiveTx *sql.TxOptions
+	// resetID, when set, moves the generator of the id column of the table
+	// it is formatted with past the largest id, as inserting ids does not.
+	resetID string
+}
+
+// inserter is a database or a transaction.
+type inserter interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// insertID runs an INSERT into a table with a generated id column and returns
+// the id of the row.
+func insertID(ctx context.Context, db inserter, d dialect, query string, args ...any) (int64, error) {
+	if d.returningID {
+		var id int64
+		err := db.QueryRowContext(ctx, query+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// createTables creates the recipe tables and the index for finding recipes
+// by ingredient, then moves recipes cached by older versions into them.
+func createTables(ctx context.Context, db *sql.DB, shardDigits int, d dialect) error {
+	// Legacy tables are renamed out of the way first, since the new recipes
+	// table takes the name of the old one
+	legacyTables, err := renameLegacyTables(ctx, db, shardDigits, d)
+	if err != nil {
+		return err
+	}
+
+	for _, schema := range []string{recipesSchema, recipeIngredientsSchema, recipeNutrientsSchema} {
+		_, err := db.ExecContext(ctx, schema)
+		if err != nil {
+			return fmt.Errorf("error creating schema: %v", err)
+		}
+	}
+	err = createQueryTables(ctx, db, shardDigits)
+	if err != nil {
+		return err
+	}
+	var count int
+	err = db.QueryRowContext(ctx, d.countIndex, "recipe_ingredients_name").Scan(&count)
+	if err != nil {
+		return fmt.Errorf("error checking for ingredient index: %v", err)
+	}
+	if count == 0 {
+		_, err = db.ExecContext(ctx, "CREATE INDEX recipe_ingredients_name ON recipe_ingredients (name)")
+		if err != nil {
+			return fmt.Errorf("error creating ingredient index: %v", err)
+		}
+	}
+
+	for _, table := range legacyTables {
+		err := migrateLegacyTable(ctx, db, shardDigits, table, d)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createQueryTables creates the missing queries tables, which depend on the
+// sharding configured rather than on the schema version.
+func createQueryTables(ctx context.Context, db *sql.DB, shardDigits int) error {
+	for _, table := range shardTables(shardDigits) {
+		_, err := db.ExecContext(ctx, fmt.Sprintf(queriesSchema, table))
+		if err != nil {
+			return fmt.Errorf("error creating %s table: %v", table, err)
+		}
+	}
+	return nil
+}
+
+// legacyPrefix is prepended to the names of the tables of the old layout, one
+// row per recipe and query with the ingredients joined into columns, while
+// their recipes are moved.
+const legacyPrefix = "legacy_"
+
+// renameLegacyTables renames the old-layout recipes table, and its shards,
+// and returns every old-layout table left to migrate, including ones renamed
+// by a migration that was interrupted.
+func renameLegacyTables(ctx context.Context, db *sql.DB, shardDigits int, d dialect) ([]string, error) {
+	oldNames := []string{"recipes"}
+	if shardDigits > 0 {
+		for _, table := range shardTables(shardDigits) {
+			oldNames = append(oldNames, strings.Replace(table, queriesTable, "recipes", 1))
+		}
+	}
+
+	var legacyTables []string
+	for _, table := range oldNames {
+		renamed := legacyPrefix + table
+		legacy, err := hasColumn(ctx, db, d, table, "sorted_query")
+		if err != nil {
+			return nil, err
+		}
+		if legacy {
+			_, err = db.ExecContext(ctx, "ALTER TABLE "+table+" RENAME TO "+renamed)
+			if err != nil {
+				return nil, fmt.Errorf("error renaming %s table: %v", table, err)
+			}
+		}
+		pending, err := hasColumn(ctx, db, d, renamed, "sorted_query")
+		if err != nil {
+			return nil, err
+		}
+		if pending {
+			legacyTables = append(legacyTables, renamed)
+		}
+	}
+	return legacyTables, nil
+}
+
+func hasColumn(ctx context.Context, db *sql.DB, d dialect, table string, column string) (bool, error) {
+	var count int
+	err := db.QueryRowContext(ctx, d.countColumn, table, column).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("error checking for %s column: %v", column, err)
+	}
+	return count > 0, nil
+}
+
+// legacyColumns are the columns of the old recipes table that were added
+// after it was first created, with the definitions used to add them to older
+// tables so they can all be read the same way.
+var legacyColumns = []struct {
+	name       string
+	definition string
+}{
+	{name: "fetched_at", definition: "BIGINT NOT NULL DEFAULT 0"},
+	{name: "instructions", definition: "TEXT"},
+	{name: "source", definition: "VARCHAR(32) NOT NULL DEFAULT ''"},
+}
+
+// migrateLegacyTable moves the recipes of an old-layout table into the new
+// tables and drops it. Rerunning it after an interruption is safe.
+func migrateLegacyTable(ctx context.Context, db *sql.DB, shardDigits int, table string, d dialect) error {
+	for _, column := range legacyColumns {
+		exists, err := hasColumn(ctx, db, d, table, column.name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		_, err = db.ExecContext(ctx, "ALTER TABLE "+table+" ADD COLUMN "+column.name+" "+column.definition)
+		if err != nil {
+			return fmt.Errorf("error adding %s column: %v", column.name, err)
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT id, sorted_query, name, used_ingredients, missing_ingredients, "+
+		"calories, carbohydrates, protein, instructions, source, fetched_at FROM "+table)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", table, err)
+	}
+	byQuery := make(map[string][]recipes.Recipe)
+	fetchedAt := make(map[string]int64)
+	for rows.Next() {
+		var recipe recipes.Recipe
+		var key, usedIngredients, missingIngredients string
+		var calories, carbohydrates, protein float64
+		var instructions sql.NullString
+		var fetched int64
+		err := rows.Scan(&recipe.ID, &key, &recipe.Title, &usedIngredients, &missingIngredients, &calories,
+			&carbohydrates, &protein, &instructions, &recipe.Source, &fetched)
+		if err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("error reading %s: %v", table, err)
+		}
+		recipe.UsedIngredients = splitIngredients(usedIngredients)
+		recipe.MissedIngredients = splitIngredients(missingIngredients)
+		recipe.Nutrients = map[string]recipes.NutrientAmount{
+			"Calories":      {Amount: calories, Unit: "kcal"},
+			"Carbohydrates": {Amount: carbohydrates, Unit: "g"},
+			"Protein":       {Amount: protein, Unit: "g"},
+		}
+		recipe.Instructions = splitInstructions(instructions.String)
+		byQuery[key] = append(byQuery[key], recipe)
+		// A query is as old as its oldest recipe
+		if oldest, seen := fetchedAt[key]; !seen || fetched < oldest {
+			fetchedAt[key] = fetched
+		}
+	}
+	err = rows.Err()
+	if closeErr := rows.Close(); closeErr != nil {
+		slog.Warn("error closing rows", "error", closeErr)
+	}
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", table, err)
+	}
+
+	s := &sqlStore{db: db, shardDigits: shardDigits}
+	for key, allRecipes := range byQuery {
+		_, err := s.save(ctx, key, allRecipes, fetchedAt[key])
+		if err != nil {
+			return fmt.Errorf("error moving cached recipes: %v", err)
+		}
+	}
+	_, err = db.ExecContext(ctx, "DROP TABLE "+table)
+	if err != nil {
+		return fmt.Errorf("error dropping %s: %v", table, err)
+	}
+	slog.Info("moved cached queries to the new cache tables", "queries", len(byQuery), "table", table)
+	return nil
+}
+
+// The legacy ingredient columns hold comma-joined ingredient names.
+func splitIngredients(column string) []recipes.Ingredient {
+	if column == "" {
+		return nil
+	}
+	names := strings.Split(column, ", ")
+	ingredients := make([]recipes.Ingredient, 0, len(names))
+	for _, name := range names {
+		ingredients = append(ingredients, recipes.Ingredient{Name: name})
+	}
+	return ingredients
+}
@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// sessionsSchema keeps the results of searches saved with --save-session,
+// encoded as JSON with their instructions, and the positions of the recipes
+// picked from them, a JSON array of numbers from 1.
+const sessionsSchema = `
+CREATE TABLE IF NOT EXISTS sessions (
+	name       VARCHAR(64) NOT NULL PRIMARY KEY,
+	results    TEXT        NOT NULL,
+	picks      TEXT        NOT NULL,
+	updated_at BIGINT      NOT NULL
+)`
+
+// Session is the results of a search kept under a name, so recipes can be
+// picked from them and acted on later without searching again.
+type Session struct {
+	Name    string
+	Recipes []recipes.Recipe
+	// Picks are the positions in Recipes, from 1, of the picked recipes.
+	Picks     []int
+	UpdatedAt time.Time
+}
+
+// Picked returns the picked recipes, in the order they were picked.
+func (s Session) Picked() []recipes.Recipe {
+	picked := make([]recipes.Recipe, 0, len(s.Picks))
+	for _, position := range s.Picks {
+		if position >= 1 && position <= len(s.Recipes) {
+			picked = append(picked, s.Recipes[position-1])
+		}
+	}
+	return picked
+}
+
+// SaveSession stores the results of a search under the name, replacing any
+// session saved before under the same name, picks included.
+func (s *sqlStore) SaveSession(ctx context.Context, name string, allRecipes []recipes.Recipe) error {
+	results, err := json.Marshal(allRecipes)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, "REPLACE INTO sessions (name, results, picks, updated_at) VALUES (?, ?, ?, ?)",
+		name, string(results), "[]", time.Now().Unix())
+	return err
+}
+
+// Session returns the session saved under the name, or nil when there is
+// none.
+func (s *sqlStore) Session(ctx context.Context, name string) (*Session, error) {
+	sessions, err := s.readSessions(ctx, "WHERE name = ?", name)
+	if err != nil || len(sessions) == 0 {
+		return nil, err
+	}
+	return &sessions[0], nil
+}
+
+func (s *sqlStore) Sessions(ctx context.Context) ([]Session, error) {
+	return s.readSessions(ctx, "ORDER BY name")
+}
+
+// PickSession replaces the picks of a session, and reports whether there is
+// a session of that name.
+func (s *sqlStore) PickSession(ctx context.Context, name string, picks []int) (bool, error) {
+	encoded, err := json.Marshal(picks)
+	if err != nil {
+		return false, err
+	}
+	result, err := s.db.ExecContext(ctx, "UPDATE sessions SET picks = ?, updated_at = ? WHERE name = ?",
+		string(encoded), time.Now().Unix(), name)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	return count > 0, err
+}
+
+func (s *sqlStore) DeleteSession(ctx context.Context, name string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE name = ?", name)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	return count > 0, err
+}
+
+func (s *sqlStore) readSessions(ctx context.Context, condition string, args ...any) ([]Session, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT name, results, picks, updated_at FROM sessions "+condition, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			slog.Warn("error closing rows", "error", err)
+		}
+	}(rows)
+
+	var sessions []Session
+	for rows.Next() {
+		var session Session
+		var results, picks string
+		var updatedAt int64
+		err := rows.Scan(&session.Name, &results, &picks, &updatedAt)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal([]byte(results), &session.Recipes)
+		if err != nil {
+			return nil, fmt.Errorf("error reading session %s: %v", session.Name, err)
+		}
+		err = json.Unmarshal([]byte(picks), &session.Picks)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the picks of session %s: %v", session.Name, err)
+		}
+		session.UpdatedAt = time.Unix(updatedAt, 0)
+		sessions = append(sessions, session)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
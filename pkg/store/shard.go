@@ -0,0 +1,84 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// queriesTable is the queries table of an unsharded cache, and the prefix of
+// the shard tables.
+const queriesTable = "queries"
+
+// maxShardDigits caps sharding at 256 tables.
+const maxShardDigits = 2
+
+// QueryHash is the canonical hash of a query: the lowercase hexadecimal
+// SHA-256 of its cache key, see cacheKey. Queries differing only in the order
+// or repeats of their lists, spelling covered by NormalizeIngredient or the
+// number of recipes asked for hash the same. The hash is stable across
+// versions as long as the cache key is; Lookup moves the recipes cached under
+// the key before it, see sortedQuery. It is saved with every cached query, so
+// a database can be partitioned on it, and its leading digits pick the shard
+// table.
+func QueryHash(query recipes.Query) string {
+	return hashKey(cacheKey(query))
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// shardTables returns the queries tables of a cache sharded on digits hash
+// digits: queries_0 to queries_f for one digit, queries_00 to queries_ff for
+// two, and just queries for none. Recipes are stored once whichever query
+// found them, so only the queries are sharded.
+func shardTables(digits int) []string {
+	if digits == 0 {
+		return []string{queriesTable}
+	}
+	count := 1 << (4 * digits)
+	tables := make([]string, 0, count)
+	for shard := range count {
+		tables = append(tables, fmt.Sprintf("%s_%0*x", queriesTable, digits, shard))
+	}
+	return tables
+}
+
+func checkShardDigits(digits int) error {
+	if digits < 0 || digits > maxShardDigits {
+		return fmt.Errorf("shard digits must be between 0 and %d, not %d", maxShardDigits, digits)
+	}
+	return nil
+}
+
+// queryTable returns the table holding the recipes found for a query hash.
+func (s *sqlStore) queryTable(hash string) string {
+	if s.shardDigits == 0 {
+		return queriesTable
+	}
+	return queriesTable + "_" + hash[:s.shardDigits]
+}
+
+// unionQuery selects columns from every table with the same condition, whose
+// arguments must be repeated with repeatArgs.
+func unionQuery(tables []string, columns string, condition string) string {
+	selects := make([]string, 0, len(tables))
+	for _, table := range tables {
+		selects = append(selects, "SELECT "+columns+" FROM "+table+" WHERE "+condition)
+	}
+	return strings.Join(selects, " UNION ALL ")
+}
+
+// repeatArgs returns the arguments of a condition once per table.
+func repeatArgs(tables []string, args ...any) []any {
+	repeated := make([]any, 0, len(tables)*len(args))
+	for range tables {
+		repeated = append(repeated, args...)
+	}
+	return repeated
+}
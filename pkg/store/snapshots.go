@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// snapshotsSchema keeps the results of the latest run of each query, as
+// shown, encoded as JSON. Unlike the queries tables they never expire, since
+// the point is to compare runs far apart.
+const snapshotsSchema = `
+CREATE TABLE IF NOT EXISTS result_snapshots (
+	query_hash   CHAR(64) NOT NULL PRIMARY KEY,
+	sorted_query TEXT     NOT NULL,
+	taken_at     BIGINT   NOT NULL,
+	results      TEXT     NOT NULL
+)`
+
+// Snapshot is the results of a run of a query.
+type Snapshot struct {
+	TakenAt time.Time
+	Recipes []recipes.Recipe
+}
+
+// LastSnapshot returns the results saved by the last SaveSnapshot for the
+// query, or nil when there are none. Snapshots saved under the query's key
+// from before cacheKey are found too.
+func (s *sqlStore) LastSnapshot(ctx context.Context, query recipes.Query) (*Snapshot, error) {
+	var takenAt int64
+	var results string
+	var err error
+	for _, key := range []string{cacheKey(query), sortedQuery(query)} {
+		err = s.db.QueryRowContext(ctx, "SELECT taken_at, results FROM result_snapshots WHERE query_hash = ?",
+			hashKey(key)).Scan(&takenAt, &results)
+		if !errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{TakenAt: time.Unix(takenAt, 0)}
+	err = json.Unmarshal([]byte(results), &snapshot.Recipes)
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshot: %v", err)
+	}
+	return snapshot, nil
+}
+
+// SaveSnapshot replaces the results saved for the query. Instructions are not
+// kept.
+func (s *sqlStore) SaveSnapshot(ctx context.Context, query recipes.Query, allRecipes []recipes.Recipe) error {
+	stripped := make([]recipes.Recipe, len(allRecipes))
+	for i, recipe := range allRecipes {
+		recipe.Instructions = nil
+		stripped[i] = recipe
+	}
+	results, err := json.Marshal(stripped)
+	if err != nil {
+		return err
+	}
+	key := cacheKey(query)
+	_, err = s.db.ExecContext(ctx, "REPLACE INTO result_snapshots (query_hash, sorted_query, taken_at, results) "+
+		"VALUES (?, ?, ?, ?)", hashKey(key), key, time.Now().Unix(), string(results))
+	return err
+}
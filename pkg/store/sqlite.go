@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+var sqliteDialect = dialect{
+	countColumn:   "SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?",
+	countIndex:    "SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = ?",
+	autoIncrement: "AUTOINCREMENT",
+	databaseSize:  "SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()",
+}
+
+// OpenSQLite opens the cache file at path, creating the file and its
+// directory when they do not exist yet.
+func OpenSQLite(ctx context.Context, path string, options Options) (Store, error) {
+	if path == "" {
+		return nil, errors.New("missing SQLite database path")
+	}
+	err := checkShardDigits(options.ShardDigits)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("error creating database directory: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSQLStore(ctx, db, options, sqliteDialect)
+}
@@ -0,0 +1,1027 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/offline"
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// openTestStore opens a SQLite store in a file of its own, closed when the
+// test ends.
+func openTestStore(t *testing.T, options Options) *sqlStore {
+	t.Helper()
+	s, err := OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "cache.db"), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		err := s.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	return s.(*sqlStore)
+}
+
+func testRecipes() []recipes.Recipe {
+	return []recipes.Recipe{
+		{
+			ID:                1,
+			Title:             "Shakshuka",
+			Source:            "spoonacular",
+			UsedIngredients:   []recipes.Ingredient{{Name: "egg", Amount: 4}},
+			MissedIngredients: []recipes.Ingredient{{Name: "canned tomatoes", Amount: 400, Unit: "g"}},
+			Nutrients: map[string]recipes.NutrientAmount{
+				"Calories": {Amount: 320, Unit: "kcal"},
+				"Protein":  {Amount: 18.5, Unit: "g"},
+			},
+			Instructions:    []string{"Simmer the tomatoes.", "Poach the eggs in the sauce."},
+			Servings:        2,
+			PricePerServing: recipes.CentsToMoney(145.5),
+			ReadyInMinutes:  35,
+			Equipment:       []string{"frying pan"},
+		},
+		{
+			ID:              2,
+			Title:           "Egg Fried Rice",
+			Source:          "spoonacular",
+			UsedIngredients: []recipes.Ingredient{{Name: "egg", Amount: 2}, {Name: "rice", Amount: 1, Unit: "cup"}},
+			Nutrients:       map[string]recipes.NutrientAmount{"Calories": {Amount: 450, Unit: "kcal"}},
+			Servings:        1,
+		},
+	}
+}
+
+func TestLookupMiss(t *testing.T) {
+	s := openTestStore(t, Options{})
+	found, err := s.Lookup(context.Background(), recipes.Query{Ingredients: []string{"egg"}, NumberOfRecipes: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found != nil {
+		t.Errorf("got %v from an empty cache, want nil", found)
+	}
+}
+
+func TestSaveAndLookup(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{TTL: time.Hour})
+	saved := testRecipes()
+	err := s.Save(ctx, recipes.Query{Ingredients: []string{"egg", "rice"}, NumberOfRecipes: 2}, saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The order and spelling of the ingredients do not change the cache key
+	found, err := s.Lookup(ctx, recipes.Query{Ingredients: []string{"Rice", "eggs"}, NumberOfRecipes: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 {
+		t.Fatalf("got %d recipes, want 2", len(found))
+	}
+	for i, recipe := range found {
+		want := saved[i]
+		if recipe.ID != want.ID || recipe.Title != want.Title || recipe.Source != want.Source ||
+			recipe.Servings != want.Servings || recipe.PricePerServing != want.PricePerServing ||
+			recipe.ReadyInMinutes != want.ReadyInMinutes || !slices.Equal(recipe.Equipment, want.Equipment) {
+			t.Errorf("got recipe %+v, want %+v", recipe, want)
+		}
+		if !slices.Equal(recipe.Instructions, want.Instructions) {
+			t.Errorf("got instructions %q, want %q", recipe.Instructions, want.Instructions)
+		}
+		for name, nutrient := range want.Nutrients {
+			if recipe.Nutrients[name] != nutrient {
+				t.Errorf("got %s %v for %s, want %v", name, recipe.Nutrients[name], recipe.Title, nutrient)
+			}
+		}
+		if got, wanted := len(recipe.UsedIngredients)+len(recipe.MissedIngredients),
+			len(want.UsedIngredients)+len(want.MissedIngredients); got != wanted {
+			t.Errorf("got %d ingredients for %s, want %d", got, recipe.Title, wanted)
+		}
+	}
+	// Used and missing ingredients are matched against the query looked up
+	if len(found[0].UsedIngredients) != 1 || found[0].UsedIngredients[0].Name != "egg" {
+		t.Errorf("got used ingredients %v, want egg", found[0].UsedIngredients)
+	}
+}
+
+func TestLookupKeepsFiltersApart(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	err := s.Save(ctx, recipes.Query{Ingredients: []string{"egg"}, NumberOfRecipes: 2}, testRecipes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := s.Lookup(ctx, recipes.Query{Ingredients: []string{"egg"}, NumberOfRecipes: 2, Diets: []string{"vegan"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found != nil {
+		t.Errorf("got %d recipes cached without the diet, want none", len(found))
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	key := cacheKey(recipes.Query{Ingredients: []string{"Eggs", "milk"}, Diets: []string{"vegan", "gluten free"},
+		NumberOfRecipes: 5})
+	same := cacheKey(recipes.Query{Ingredients: []string{"milk", "egg", "egg"},
+		Diets: []string{"Gluten Free", "vegan", "vegan"}, NumberOfRecipes: 50})
+	if key != same {
+		t.Errorf("got keys %s and %s, want the same key whatever the order, case, repeats and number", key, same)
+	}
+	for _, other := range []recipes.Query{
+		{Ingredients: []string{"egg", "milk"}, Diets: []string{"vegan"}},
+		{Ingredients: []string{"egg", "milk"}, Diets: []string{"gluten free", "vegan"}, Intolerances: []string{"dairy"}},
+		{Ingredients: []string{"egg", "milk"}, Diets: []string{"gluten free", "vegan"},
+			Targets: recipes.NutritionTargets{MaxCalories: 500}},
+	} {
+		if cacheKey(other) == key {
+			t.Errorf("got the same key for %+v, want another", other)
+		}
+	}
+	// An ingredient cannot pass for a filter
+	if cacheKey(recipes.Query{Ingredients: []string{"salt|diet=vegan"}}) ==
+		cacheKey(recipes.Query{Ingredients: []string{"salt"}, Diets: []string{"vegan"}}) {
+		t.Error("got the same key for an ingredient spelling a filter and the filter")
+	}
+}
+
+func TestLookupAdoptsLegacyQueries(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	query := recipes.Query{Ingredients: []string{"egg"}, NumberOfRecipes: 2}
+	_, err := s.save(ctx, sortedQuery(query), testRecipes(), time.Now().Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := s.Lookup(ctx, query)
+	if err != nil || len(found) != 2 {
+		t.Fatalf("got %d recipes, %v, want the 2 cached under the old key", len(found), err)
+	}
+	var legacyRows, rows int
+	err = s.db.QueryRow("SELECT COUNT(*) FROM queries WHERE query_hash = ?", hashKey(sortedQuery(query))).
+		Scan(&legacyRows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.db.QueryRow("SELECT COUNT(*) FROM queries WHERE query_hash = ?", QueryHash(query)).Scan(&rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legacyRows != 0 || rows != 2 {
+		t.Errorf("got %d rows under the old key and %d under the new one, want all moved", legacyRows, rows)
+	}
+}
+
+func TestSaveReplacesQuery(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	query := recipes.Query{Ingredients: []string{"egg"}, NumberOfRecipes: 2}
+	err := s.Save(ctx, query, testRecipes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := s.SaveRecipes(ctx, query, testRecipes()[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Inserted != 0 || result.Replaced != 1 {
+		t.Errorf("got %+v, want the recipe counted as replaced", result)
+	}
+
+	found, err := s.Lookup(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].ID != 2 {
+		t.Errorf("got %v, want only the recipe saved last", found)
+	}
+}
+
+func TestSaveMoreRecipesForQuery(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	allRecipes := testRecipes()
+	err := s.Save(ctx, recipes.Query{Ingredients: []string{"egg"}, NumberOfRecipes: 1}, allRecipes[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A larger search for the same query saves the recipe it had with the new
+	// one, as recipes.Finder does
+	query := recipes.Query{Ingredients: []string{"Egg "}, NumberOfRecipes: 2}
+	result, err := s.SaveRecipes(ctx, query, allRecipes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Inserted != 1 || result.Replaced != 1 {
+		t.Errorf("got %+v, want 1 recipe inserted and 1 replaced", result)
+	}
+
+	found, err := s.Lookup(ctx, recipes.Query{Ingredients: []string{"egg"}, NumberOfRecipes: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || found[0].ID != 1 || found[1].ID != 2 {
+		t.Errorf("got %v, want both recipes in the order saved", found)
+	}
+}
+
+func TestSaveKeepsAgeOfCachedRecipes(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{TTL: 3 * time.Hour})
+	query := recipes.Query{Ingredients: []string{"egg"}, NumberOfRecipes: 2}
+	allRecipes := testRecipes()
+	fetchedAt := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	allRecipes[0].FetchedAt = fetchedAt
+	err := s.Save(ctx, query, allRecipes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := s.Lookup(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || !found[0].FetchedAt.Equal(fetchedAt) || !found[1].FetchedAt.After(fetchedAt) {
+		t.Errorf("got %v, want the first recipe fetched at %v and the second now", found, fetchedAt)
+	}
+}
+
+func TestLookupSkipsExpiredQueries(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{TTL: time.Hour})
+	query := recipes.Query{Ingredients: []string{"egg"}, NumberOfRecipes: 2}
+	_, err := s.save(ctx, cacheKey(query), testRecipes(), time.Now().Add(-2*time.Hour).Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := s.Lookup(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found != nil {
+		t.Errorf("got %d expired recipes, want none", len(found))
+	}
+	purged, err := s.Purge(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 2 {
+		t.Errorf("purged %d recipes, want 2", purged)
+	}
+}
+
+func TestMigrationsAreApplied(t *testing.T) {
+	s := openTestStore(t, Options{})
+	all, err := s.Migrations(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != len(migrations) || pendingMigrations(all) != 0 {
+		t.Errorf("got %d migrations with %d pending, want all %d applied", len(all), pendingMigrations(all),
+			len(migrations))
+	}
+	applied, err := s.Migrate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("migrating again applied %d migrations", len(applied))
+	}
+}
+
+func TestPantry(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	err := s.AddToPantry(ctx, []string{"salt", "olive oil", "salt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pantry, err := s.Pantry(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(pantry, []string{"olive oil", "salt"}) {
+		t.Errorf("got pantry %q, want [olive oil salt]", pantry)
+	}
+	removed, err := s.RemoveFromPantry(ctx, []string{"salt", "pepper"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Errorf("removed %d items, want 1", removed)
+	}
+}
+
+func TestPantryPerProfile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.db")
+	pantries := make(map[string][]string)
+	for _, profile := range []string{"", "alice"} {
+		s, err := OpenSQLite(ctx, path, Options{Profile: profile})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.AddToPantry(ctx, []string{profile + "salt"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pantries[profile], err = s.Pantry(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !slices.Equal(pantries[""], []string{"salt"}) || !slices.Equal(pantries["alice"], []string{"alicesalt"}) {
+		t.Errorf("got pantries %q, want separate ones", pantries)
+	}
+}
+
+func TestCookedPerProfile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.db")
+	cooked := make(map[string][]CookedMeal)
+	for _, profile := range []string{"", "alice"} {
+		s, err := OpenSQLite(ctx, path, Options{Profile: profile})
+		if err != nil {
+			t.Fatal(err)
+		}
+		recipe := recipes.Recipe{ID: 1, Title: profile + "pasta", Source: "spoonacular", Cuisines: []string{"italian"},
+			Instructions:    []string{"Blanch the tomatoes and sauté the garlic."},
+			UsedIngredients: []recipes.Ingredient{{Name: "tomatoes"}, {Name: "garlic"}, {Name: "pasta"}},
+			PricePerServing: 150}
+		err = s.AddCooked(ctx, recipe, time.Unix(1000, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cooked[profile], err = s.Cooked(ctx, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(cooked[""]) != 1 || len(cooked["alice"]) != 1 || cooked["alice"][0].Title != "alicepasta" {
+		t.Fatalf("got cooked meals %+v, want separate ones", cooked)
+	}
+	meal := cooked["alice"][0]
+	if !slices.Equal(meal.Cuisines, []string{"italian"}) || !slices.Equal(meal.Techniques, []string{"blanch", "sauté"}) ||
+		meal.CookedAt.Unix() != 1000 {
+		t.Errorf("got %+v, want the italian pasta blanched and sautéed at 1000", meal)
+	}
+	if !slices.Equal(meal.Vegetables, []string{"tomato", "garlic"}) || meal.Price != 150 {
+		t.Errorf("got vegetables %q for %d, want tomato and garlic for 150", meal.Vegetables, meal.Price)
+	}
+}
+
+func TestRollbackMigration(t *testing.T) {
+	ctx := context.Background()
+	backups := t.TempDir()
+	s := openTestStore(t, Options{Profile: "alice", BackupDir: backups})
+	err := s.AddToPantry(ctx, []string{"salt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rolling back to before the pantries per profile drops alice's pantry
+	rolledBack := 0
+	for version := migrations[len(migrations)-1].version; version >= 12; version-- {
+		migration, err := s.RollbackMigration(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if migration.Version != version {
+			t.Errorf("rolled back migration %d, want %d", migration.Version, version)
+		}
+		rolledBack++
+	}
+	files, err := filepath.Glob(filepath.Join(backups, "migration-12-down-*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("got backups %q (%v), want one", files, err)
+	}
+	contents, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var backup Backup
+	err = json.Unmarshal(contents, &backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows := backup.Tables["pantries"].Rows; len(rows) != 1 || rows[0][0] != "alice" || rows[0][1] != "salt" {
+		t.Errorf("got backed up pantries %v, want alice's salt", rows)
+	}
+
+	applied, err := s.Migrate(ctx)
+	if err != nil || len(applied) != rolledBack {
+		t.Errorf("migrated %+v (%v), want the rolled back migrations applied again", applied, err)
+	}
+
+	for {
+		_, err = s.RollbackMigration(ctx)
+		if err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrIrreversibleMigration) {
+		t.Errorf("got %v rolling back every migration, want ErrIrreversibleMigration", err)
+	}
+}
+
+func TestProviderQuality(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	saved := testRecipes()
+	saved[1].Nutrients = nil
+	for range 2 {
+		err := s.Save(ctx, recipes.Query{Ingredients: []string{"egg"}, NumberOfRecipes: 2}, saved)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	providers, err := s.ProviderQuality(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := recipes.Quality{Recipes: 4, MissingNutrition: 2}
+	if len(providers) != 1 || providers[0].Source != "spoonacular" || providers[0].Quality != want {
+		t.Errorf("got %+v, want spoonacular with %+v", providers, want)
+	}
+}
+
+func TestIngredientInfo(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	food := &recipes.FoodData{Description: "Cheese, cheddar", Nutrients: map[string]recipes.NutrientAmount{
+		"Protein": {Amount: 22.9, Unit: "g"},
+	}}
+	err := s.SaveIngredient(ctx, recipes.IngredientInfo{Name: "Cheddar", Food: food})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, fresh, err := s.CachedIngredient(ctx, "cheddar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil || !fresh || info.Food == nil || info.Food.Nutrients["Protein"] != food.Nutrients["Protein"] {
+		t.Errorf("got %+v (fresh %v), want the saved food data", info, fresh)
+	}
+	missing, _, err := s.CachedIngredient(ctx, "saffron")
+	if err != nil || missing != nil {
+		t.Errorf("got %+v, %v for an ingredient never saved, want nil", missing, err)
+	}
+}
+
+func TestInvites(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	err := s.CreateInvite(ctx, "token", "alice", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.CreateInvite(ctx, "expired", "bob", time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	invites, err := s.Invites(ctx)
+	if err != nil || len(invites) != 1 || invites[0].Profile != "alice" {
+		t.Fatalf("got invites %+v, %v, want alice's only", invites, err)
+	}
+
+	profile, err := s.AcceptInvite(ctx, "token", "telegram:1")
+	if err != nil || profile != "alice" {
+		t.Fatalf("got %q, %v accepting the invite, want alice", profile, err)
+	}
+	for _, token := range []string{"token", "expired", "unknown"} {
+		_, err = s.AcceptInvite(ctx, token, "telegram:2")
+		if !errors.Is(err, ErrInvalidInvite) {
+			t.Errorf("got %v accepting %s, want ErrInvalidInvite", err, token)
+		}
+	}
+	if profile, err := s.MemberProfile(ctx, "telegram:1"); err != nil || profile != "alice" {
+		t.Errorf("got profile %q, %v for the member, want alice", profile, err)
+	}
+
+	removed, err := s.RemoveProfile(ctx, "alice")
+	if err != nil || removed != 1 {
+		t.Errorf("removed %d members, %v, want 1", removed, err)
+	}
+	if members, err := s.Members(ctx); err != nil || len(members) != 0 {
+		t.Errorf("got members %+v, %v after removing the profile, want none", members, err)
+	}
+}
+
+func TestNotificationPrefs(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	prefs, err := s.NotificationPrefs(ctx, "alice")
+	if err != nil || !slices.Equal(prefs.Channels, []string{ChannelTelegram}) || len(prefs.Events) != len(Events) {
+		t.Fatalf("got %+v, %v for a profile that has not chosen, want the defaults", prefs, err)
+	}
+	prefs.Events = []string{EventNewMatches}
+	prefs.QuietStart, prefs.QuietEnd = 22*60, 7*60
+	err = s.SaveNotificationPrefs(ctx, prefs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := s.NotificationPrefs(ctx, "alice")
+	if err != nil || !slices.Equal(saved.Events, prefs.Events) || saved.QuietStart != prefs.QuietStart {
+		t.Fatalf("got %+v, %v, want the saved preferences %+v", saved, err, prefs)
+	}
+
+	day := time.Date(2026, 10, 15, 12, 0, 0, 0, time.Local)
+	night := time.Date(2026, 10, 15, 23, 30, 0, 0, time.Local)
+	morning := time.Date(2026, 10, 15, 6, 59, 0, 0, time.Local)
+	if !saved.Wants(ChannelTelegram, EventNewMatches, day) {
+		t.Error("got the event unwanted at noon, want it sent")
+	}
+	if saved.Wants(ChannelTelegram, EventNewMatches, night) || saved.Wants(ChannelTelegram, EventNewMatches, morning) {
+		t.Error("got the event wanted during quiet hours, want it held back")
+	}
+	if saved.Wants(ChannelTelegram, EventPlanPublished, day) {
+		t.Error("got an event that was not chosen wanted")
+	}
+}
+
+func TestQuotaUsage(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	if used, err := s.QuotaUsage(ctx, "key"); err != nil || used != 0 {
+		t.Fatalf("got %g, %v before any usage, want 0", used, err)
+	}
+	for _, used := range []float64{12.5, 8} {
+		err := s.RecordQuotaUsage(ctx, "key", used, 150)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if used, err := s.QuotaUsage(ctx, "key"); err != nil || used != 12.5 {
+		t.Errorf("got %g, %v, want the highest count reported today, 12.5", used, err)
+	}
+
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+	_, err := s.db.Exec("INSERT INTO quota_usage (key_hash, day, used, daily) VALUES (?, ?, ?, ?)",
+		hashKey("key"), yesterday, 150, 150)
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage, err := s.KeyUsage(ctx, "key", 7)
+	if err != nil || usage.Today != 12.5 || usage.Daily != 150 || usage.Recent != 162.5 || usage.Spent() {
+		t.Errorf("got %+v, %v, want 12.5 of 150 points today and 162.5 over the week", usage, err)
+	}
+	if usage, err := s.KeyUsage(ctx, "key", 1); err != nil || usage.Recent != 12.5 {
+		t.Errorf("got %+v, %v over a day, want today's 12.5 points", usage, err)
+	}
+}
+
+func TestDigestItems(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	for _, item := range []DigestItem{
+		{Account: "telegram:2", Event: EventNewMatches, Message: "second"},
+		{Account: "telegram:1", Event: EventNewMatches, Message: "first"},
+	} {
+		err := s.QueueDigest(ctx, item)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if items, err := s.PendingDigests(ctx, time.Now().Add(-time.Hour)); err != nil || len(items) != 0 {
+		t.Fatalf("got %+v, %v queued before an hour ago, want none", items, err)
+	}
+	later := time.Now().Add(time.Second)
+	items, err := s.PendingDigests(ctx, later)
+	if err != nil || len(items) != 2 || items[0].Message != "first" {
+		t.Fatalf("got %+v, %v, want both by account", items, err)
+	}
+	err = s.ClearDigest(ctx, "telegram:1", later)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items, err := s.PendingDigests(ctx, later); err != nil || len(items) != 1 || items[0].Account != "telegram:2" {
+		t.Errorf("got %+v, %v after clearing a digest, want the other account's only", items, err)
+	}
+}
+
+func TestRecipeReports(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	for _, report := range []RecipeReport{
+		{RecipeID: 7, Reason: "nutrition looks wrong", Filed: true},
+		{RecipeID: 9, Reason: "missing the eggs"},
+	} {
+		err := s.AddRecipeReport(ctx, report)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	reports, err := s.RecipeReports(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 2 || reports[0].RecipeID != 7 || !reports[0].Filed || reports[1].Filed ||
+		reports[1].Reason != "missing the eggs" {
+		t.Errorf("got %+v, want both reports oldest first", reports)
+	}
+}
+
+func TestRecipePagesAndAuthors(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	query := recipes.Query{Ingredients: []string{"leek"}, NumberOfRecipes: 1}
+	err := s.Save(ctx, query, []recipes.Recipe{{ID: 5, Title: "Leek soup", Source: "spoonacular",
+		SourceURL: "https://example.com/leek-soup", Author: "Example Kitchen"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := s.Lookup(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].SourceURL != "https://example.com/leek-soup" || found[0].Author != "Example Kitchen" {
+		t.Errorf("got %+v, want the page and author kept", found)
+	}
+}
+
+func TestRecipePopularityAndTaste(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	query := recipes.Query{Ingredients: []string{"chili"}, NumberOfRecipes: 1}
+	err := s.Save(ctx, query, []recipes.Recipe{{ID: 6, Title: "Chili", Source: "spoonacular", Likes: 42,
+		HealthScore: 61}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := s.Lookup(ctx, query)
+	if err != nil || len(found) != 1 || found[0].Likes != 42 || found[0].HealthScore != 61 {
+		t.Fatalf("got %+v, %v, want the likes and health score kept", found, err)
+	}
+
+	recipe := found[0]
+	recipe.Taste = &recipes.Taste{Sweetness: 12, Spiciness: 3500}
+	err = s.SaveRecipeDetails(ctx, recipe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, _, err := s.CachedRecipe(ctx, "spoonacular", 6)
+	if err != nil || cached == nil || cached.Taste == nil || *cached.Taste != *recipe.Taste || cached.Likes != 42 {
+		t.Errorf("got %+v, %v, want the taste kept with the details", cached, err)
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	err := s.Save(ctx, recipes.Query{Ingredients: []string{"leek"}, NumberOfRecipes: 2}, []recipes.Recipe{
+		{ID: 1, Title: "Leek soup", UsedIngredients: []recipes.Ingredient{{Name: "leek"}}},
+		{ID: 2, Title: "Leek pie", UsedIngredients: []recipes.Ingredient{{Name: "leek"}, {Name: "flour"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, hit := range []bool{true, false, true, true} {
+		err := s.RecordCacheLookup(ctx, hit)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := s.CacheStats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Recipes != 2 || stats.Ingredients != 3 || stats.Queries != 1 || stats.Expired != 0 {
+		t.Errorf("got %+v, want 2 recipes with 3 ingredients for 1 query", stats)
+	}
+	if stats.Hits != 3 || stats.Misses != 1 || stats.HitRate() != 0.75 || stats.TrackedSince.IsZero() {
+		t.Errorf("got %d hits, %d misses since %v, want 3 and 1", stats.Hits, stats.Misses, stats.TrackedSince)
+	}
+	if stats.Oldest.IsZero() || stats.Newest.Before(stats.Oldest) || stats.Size <= 0 {
+		t.Errorf("got fetch times %v to %v and size %d", stats.Oldest, stats.Newest, stats.Size)
+	}
+}
+
+func TestExperimentResults(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	err := s.RecordExperimentShown(ctx, map[int]string{1: "a", 2: "b", 3: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.RecordExperimentShown(ctx, map[int]string{2: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for recipeID, want := range map[int]string{1: "a", 2: "a", 4: ""} {
+		strategy, err := s.RecordExperimentChoice(ctx, recipeID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strategy != want {
+			t.Errorf("recipe %d credited to %q, want %q", recipeID, strategy, want)
+		}
+	}
+
+	results, err := s.ExperimentResults(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shown, chosen := map[string]int64{}, map[string]int64{}
+	for _, result := range results {
+		if result.Week.After(time.Now()) {
+			t.Errorf("got week starting %v, in the future", result.Week)
+		}
+		shown[result.Strategy] += result.Shown
+		chosen[result.Strategy] += result.Chosen
+	}
+	if shown["a"] != 3 || shown["b"] != 1 || chosen["a"] != 2 || chosen["b"] != 0 {
+		t.Errorf("got shown %v and chosen %v, want a showing 3 with 2 chosen and b showing 1", shown, chosen)
+	}
+}
+
+func TestPreferences(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	prefs, err := s.Preferences(ctx, "")
+	if err != nil || prefs != nil {
+		t.Fatalf("got %v, %v before the quiz, want none", prefs, err)
+	}
+	saved := Preferences{Profile: "", Ranking: recipes.RankWeights{Missing: 4, Protein: 2, Time: 0.5},
+		Disliked: []string{"mushroom", "olive"}}
+	err = s.SavePreferences(ctx, saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefs, err = s.Preferences(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefs == nil || prefs.Ranking != saved.Ranking || !slices.Equal(prefs.Disliked, saved.Disliked) {
+		t.Errorf("got %+v, want %+v", prefs, saved)
+	}
+	deleted, err := s.DeletePreferences(ctx, "")
+	if err != nil || !deleted {
+		t.Errorf("got %v, %v deleting, want deleted", deleted, err)
+	}
+}
+
+func TestPairings(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	pairing, fresh, err := s.CachedPairing(ctx, "spoonacular", 1)
+	if err != nil || pairing != nil || fresh {
+		t.Fatalf("got %v, %v, %v before looking it up, want none", pairing, fresh, err)
+	}
+	saved := recipes.Pairing{Wines: []string{"merlot", "malbec"}, Note: "Beef loves a red.", Food: "beef"}
+	err = s.SavePairing(ctx, "spoonacular", 1, saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.SavePairing(ctx, "spoonacular", 2, recipes.Pairing{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pairing, fresh, err = s.CachedPairing(ctx, "spoonacular", 1)
+	if err != nil || !fresh || pairing == nil || !slices.Equal(pairing.Wines, saved.Wines) ||
+		pairing.Note != saved.Note || pairing.Food != saved.Food {
+		t.Errorf("got %+v, %v, %v, want %+v fresh", pairing, fresh, err, saved)
+	}
+	pairing, fresh, err = s.CachedPairing(ctx, "spoonacular", 2)
+	if err != nil || !fresh || pairing == nil || len(pairing.Wines) != 0 {
+		t.Errorf("got %+v, %v, %v, want the recipe recorded as having no pairing", pairing, fresh, err)
+	}
+}
+
+func TestSubstitutes(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	substitutes, fresh, err := s.CachedSubstitutes(ctx, "buttermilk")
+	if err != nil || substitutes != nil || fresh {
+		t.Fatalf("got %q, %v, %v before looking them up, want none", substitutes, fresh, err)
+	}
+	saved := []string{"1 cup = 1 cup milk + 1 tbsp lemon juice", "1 cup = 1 cup plain yogurt"}
+	err = s.SaveSubstitutes(ctx, "Buttermilk", saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.SaveSubstitutes(ctx, "saffron", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	substitutes, fresh, err = s.CachedSubstitutes(ctx, "buttermilk")
+	if err != nil || !fresh || !slices.Equal(substitutes, saved) {
+		t.Errorf("got %q, %v, %v, want %q fresh", substitutes, fresh, err, saved)
+	}
+	substitutes, fresh, err = s.CachedSubstitutes(ctx, "saffron")
+	if err != nil || !fresh || substitutes != nil {
+		t.Errorf("got %q, %v, %v, want saffron recorded as having no substitutes", substitutes, fresh, err)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	allRecipes := []recipes.Recipe{
+		{ID: 1, Title: "Omelette", Instructions: []string{"Whisk the eggs."}},
+		{ID: 2, Title: "Fried rice"},
+		{ID: 3, Title: "Pancakes"},
+	}
+	err := s.SaveSession(ctx, "dinner", allRecipes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	picked, err := s.PickSession(ctx, "dinner", []int{3, 1})
+	if err != nil || !picked {
+		t.Fatalf("got %v, %v picking, want picked", picked, err)
+	}
+	session, err := s.Session(ctx, "dinner")
+	if err != nil || session == nil {
+		t.Fatalf("got %v, %v, want the session", session, err)
+	}
+	if len(session.Recipes) != 3 || len(session.Recipes[0].Instructions) != 1 {
+		t.Errorf("got recipes %+v, want the three saved with their instructions", session.Recipes)
+	}
+	if got := session.Picked(); len(got) != 2 || got[0].ID != 3 || got[1].ID != 1 {
+		t.Errorf("got picked %+v, want recipes 3 and 1", got)
+	}
+
+	// Saving again starts over
+	err = s.SaveSession(ctx, "dinner", allRecipes[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err = s.Session(ctx, "dinner")
+	if err != nil || session == nil || len(session.Recipes) != 1 || len(session.Picks) != 0 {
+		t.Errorf("got %+v, %v, want one recipe and no picks", session, err)
+	}
+
+	picked, err = s.PickSession(ctx, "lunch", []int{1})
+	if err != nil || picked {
+		t.Errorf("got %v, %v picking from a missing session, want not picked", picked, err)
+	}
+	deleted, err := s.DeleteSession(ctx, "dinner")
+	if err != nil || !deleted {
+		t.Errorf("got %v, %v deleting, want deleted", deleted, err)
+	}
+	sessions, err := s.Sessions(ctx)
+	if err != nil || len(sessions) != 0 {
+		t.Errorf("got %+v, %v, want no sessions left", sessions, err)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{TTL: time.Hour})
+	query := recipes.Query{Ingredients: []string{"egg", "rice"}, NumberOfRecipes: 2}
+	err := s.Save(ctx, query, testRecipes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.AddToPantry(ctx, []string{"salt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := s.CreateArchive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The archive goes through JSON as a snapshot file does
+	encoded, err := json.Marshal(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Archive
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	err = decoder.Decode(&decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A sharded database takes the queries into its own shards
+	restored := openTestStore(t, Options{TTL: time.Hour, ShardDigits: 1})
+	err = restored.AddToPantry(ctx, []string{"pepper"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = restored.RestoreArchive(ctx, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pantry, err := restored.Pantry(ctx)
+	if err != nil || !slices.Equal(pantry, []string{"salt"}) {
+		t.Errorf("got pantry %q, %v, want the archived one only", pantry, err)
+	}
+	found, err := restored.Lookup(ctx, query)
+	if err != nil || len(found) != 2 || found[0].Title != testRecipes()[0].Title {
+		t.Errorf("got %d recipes, %v, want the archived query's", len(found), err)
+	}
+
+	decoded.SchemaVersion--
+	if err := restored.RestoreArchive(ctx, decoded); err == nil {
+		t.Error("restored an archive of another schema version")
+	}
+}
+
+func TestPostgresQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			query: "SELECT id FROM jobs WHERE kind IN (?, ?) AND last_error = '?'",
+			want:  "SELECT id FROM jobs WHERE kind IN ($1, $2) AND last_error = '?'",
+		},
+		{
+			query: "REPLACE INTO saved_searches (name, flags, updated_at) VALUES (?, ?, ?)",
+			want: "INSERT INTO saved_searches (name, flags, updated_at) VALUES ($1, $2, $3) ON CONFLICT (name) " +
+				"DO UPDATE SET flags = EXCLUDED.flags, updated_at = EXCLUDED.updated_at",
+		},
+		{
+			query: "REPLACE INTO pantries (profile, name) VALUES (?, ?)",
+			want:  "INSERT INTO pantries (profile, name) VALUES ($1, $2) ON CONFLICT (profile, name) DO NOTHING",
+		},
+		{
+			query: "ALTER TABLE recipes ADD COLUMN price_per_serving DOUBLE NOT NULL DEFAULT 0",
+			want:  "ALTER TABLE recipes ADD COLUMN price_per_serving DOUBLE PRECISION NOT NULL DEFAULT 0",
+		},
+	}
+	for _, test := range tests {
+		if got := postgresQuery(test.query); got != test.want {
+			t.Errorf("postgresQuery(%q) = %q, want %q", test.query, got, test.want)
+		}
+	}
+}
+
+// TestReplaceKeys checks that every table written with REPLACE INTO can be
+// upserted on PostgreSQL.
+func TestReplaceKeys(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	replaced := regexp.MustCompile(`"REPLACE INTO (\w+)`)
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		source, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, match := range replaced.FindAllStringSubmatch(string(source), -1) {
+			if _, found := replaceKeys[match[1]]; !found {
+				t.Errorf("%s replaces rows of %s, which has no key in replaceKeys", file, match[1])
+			}
+		}
+	}
+}
+
+func TestCustomRecipes(t *testing.T) {
+	ctx := context.Background()
+	s := openTestStore(t, Options{})
+	omelette := offline.Recipe{Title: "Omelette", Ingredients: []offline.Ingredient{{Name: "egg", Amount: 3}}}
+	id, err := s.AddCustomRecipe(ctx, omelette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Adding a recipe of the same title again replaces it
+	omelette.Servings = 2
+	replaced, err := s.AddCustomRecipe(ctx, omelette)
+	if err != nil || replaced != id {
+		t.Fatalf("got ID %d, %v, want the first one's, %d", replaced, err, id)
+	}
+	custom, err := s.CustomRecipes(ctx)
+	if err != nil || len(custom) != 1 || custom[0].ID != id || custom[0].Servings != 2 {
+		t.Fatalf("got %+v, %v, want the replaced omelette", custom, err)
+	}
+
+	deleted, err := s.DeleteCustomRecipe(ctx, id)
+	if err != nil || !deleted {
+		t.Errorf("got %v, %v, want the recipe deleted", deleted, err)
+	}
+	deleted, err = s.DeleteCustomRecipe(ctx, id)
+	if err != nil || deleted {
+		t.Errorf("got %v, %v deleting it again, want nothing deleted", deleted, err)
+	}
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+// substitutesSchema caches the substitutes of missing ingredients, keyed by
+// normalized ingredient name. substitutes is a JSON array, as they contain
+// commas, and null for an ingredient that was looked up but has none, so it
+// is not looked up again until it expires.
+const substitutesSchema = `
+CREATE TABLE IF NOT EXISTS substitutes (
+	ingredient  VARCHAR(255) NOT NULL PRIMARY KEY,
+	substitutes TEXT         NOT NULL,
+	fetched_at  BIGINT       NOT NULL
+)`
+
+// CachedSubstitutes returns the cached substitutes of an ingredient, expired
+// or not, and nil when it has none or was not looked up. The boolean reports
+// whether they were looked up and are unexpired.
+func (s *sqlStore) CachedSubstitutes(ctx context.Context, ingredient string) ([]string, bool, error) {
+	var encoded string
+	var fetchedAt int64
+	err := s.db.QueryRowContext(ctx, "SELECT substitutes, fetched_at FROM substitutes WHERE ingredient = ?",
+		recipes.NormalizeIngredient(ingredient)).Scan(&encoded, &fetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var substitutes []string
+	err = json.Unmarshal([]byte(encoded), &substitutes)
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading substitutes: %v", err)
+	}
+	return substitutes, fetchedAt >= s.cutoff(), nil
+}
+
+// SaveSubstitutes caches the substitutes of an ingredient, replacing what was
+// cached for it before. No substitutes records that it has none.
+func (s *sqlStore) SaveSubstitutes(ctx context.Context, ingredient string, substitutes []string) error {
+	encoded, err := json.Marshal(substitutes)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, "REPLACE INTO substitutes (ingredient, substitutes, fetched_at) VALUES (?, ?, ?)",
+		recipes.NormalizeIngredient(ingredient), string(encoded), time.Now().Unix())
+	return err
+}
@@ -0,0 +1,169 @@
+// Package telegram is a client for the parts of the Telegram Bot API a bot
+// answering chat messages needs: long polling for updates, sending messages
+// with inline buttons and answering button presses.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const baseURL = "https://api.telegram.org"
+
+// MaxMessageLength is the most characters a message may have.
+const MaxMessageLength = 4096
+
+// Update is something that happened in a chat with the bot: a message sent
+// to it, or a button of one of its messages pressed.
+type Update struct {
+	ID            int            `json:"update_id"`
+	Message       *Message       `json:"message,omitempty"`
+	CallbackQuery *CallbackQuery `json:"callback_query,omitempty"`
+}
+
+type Message struct {
+	Chat Chat   `json:"chat"`
+	Text string `json:"text"`
+}
+
+type Chat struct {
+	ID int64 `json:"id"`
+}
+
+// CallbackQuery is a press of an inline button; Data is the button's
+// CallbackData and Message the message it belongs to.
+type CallbackQuery struct {
+	ID      string   `json:"id"`
+	Data    string   `json:"data"`
+	Message *Message `json:"message,omitempty"`
+}
+
+// Button is an inline button under a message. CallbackData, at most 64
+// bytes, is sent back in the CallbackQuery when it is pressed.
+type Button struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
+// Client talks to the Bot API as one bot. It is safe for concurrent use.
+type Client struct {
+	// HTTPClient makes the requests, http.DefaultClient when nil. Its
+	// timeout must be longer than the long polling one of Updates.
+	HTTPClient *http.Client
+
+	token string
+}
+
+func NewClient(token string) *Client {
+	return &Client{token: token}
+}
+
+// Updates waits up to timeout for updates after offset, the ID of the last
+// update handled plus one, and returns them oldest first.
+func (c *Client) Updates(ctx context.Context, offset int, timeout time.Duration) ([]Update, error) {
+	var updates []Update
+	err := c.call(ctx, "getUpdates", map[string]any{
+		"offset":          offset,
+		"timeout":         int(timeout.Seconds()),
+		"allowed_updates": []string{"message", "callback_query"},
+	}, &updates)
+	return updates, err
+}
+
+// SendMessage sends a text message to a chat, with a row of buttons under it
+// when there are any.
+func (c *Client) SendMessage(ctx context.Context, chatID int64, text string, buttons []Button) error {
+	params := map[string]any{
+		"chat_id": chatID,
+		"text":    text,
+	}
+	if len(buttons) > 0 {
+		params["reply_markup"] = map[string]any{"inline_keyboard": [][]Button{buttons}}
+	}
+	return c.call(ctx, "sendMessage", params, nil)
+}
+
+// AnswerCallback tells the chat app a button press was handled, showing the
+// text briefly when it is not empty.
+func (c *Client) AnswerCallback(ctx context.Context, callbackID string, text string) error {
+	return c.call(ctx, "answerCallbackQuery", map[string]any{
+		"callback_query_id": callbackID,
+		"text":              text,
+	}, nil)
+}
+
+// Username returns the bot's username, which links to it as
+// https://t.me/<username>.
+func (c *Client) Username(ctx context.Context) (string, error) {
+	var me struct {
+		Username string `json:"username"`
+	}
+	err := c.call(ctx, "getMe", map[string]any{}, &me)
+	return me.Username, err
+}
+
+type response struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
+
+// call calls a Bot API method and decodes its result into result, unless it
+// is nil.
+func (c *Client) call(ctx context.Context, method string, params map[string]any, result any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/bot"+c.token+"/"+method,
+		bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	slog.Debug("calling Telegram", "method", method)
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(request)
+	if err != nil {
+		// The error names the URL, which holds the token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = strings.Replace(urlErr.URL, c.token, "REDACTED", 1)
+		}
+		return fmt.Errorf("error calling %s: %w", method, err)
+	}
+	defer func() {
+		err := resp.Body.Close()
+		if err != nil {
+			slog.Warn("error closing response body", "error", err)
+		}
+	}()
+
+	var decoded response
+	err = json.NewDecoder(resp.Body).Decode(&decoded)
+	if err != nil {
+		return fmt.Errorf("error parsing JSON: %v", err)
+	}
+	if !decoded.OK {
+		return fmt.Errorf("%s failed: %d %s", method, resp.StatusCode, decoded.Description)
+	}
+	if result == nil {
+		return nil
+	}
+	err = json.Unmarshal(decoded.Result, result)
+	if err != nil {
+		return fmt.Errorf("error parsing %s result: %v", method, err)
+	}
+	return nil
+}
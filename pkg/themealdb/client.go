@@ -0,0 +1,225 @@
+// Package themealdb is a client for TheMealDB, a free recipe API without
+// nutrition data.
+package themealdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mawojcik/meals_generator/pkg/recipes"
+)
+
+const baseURL = "https://www.themealdb.com/api/json/v1"
+
+// testAPIKey is the key TheMealDB hands out for development and personal use.
+const testAPIKey = "1"
+
+// lookupInterval spaces out the lookups of a search, so they do not hit the
+// free API all at once.
+const lookupInterval = 100 * time.Millisecond
+
+type filterResponse struct {
+	Meals []struct {
+		ID    string `json:"idMeal"`
+		Title string `json:"strMeal"`
+	} `json:"meals"`
+}
+
+// meal is a lookup.php result. TheMealDB lists up to 20 ingredients in
+// numbered fields, strIngredient1 to strIngredient20, with their measures in
+// strMeasure1 to strMeasure20.
+type meal map[string]*string
+
+type lookupResponse struct {
+	Meals []meal `json:"meals"`
+}
+
+// Client talks to TheMealDB. It is safe for concurrent use.
+type Client struct {
+	// HTTPClient makes the requests, http.DefaultClient when nil.
+	HTTPClient *http.Client
+	// Limits bound the lookups of the meals a search found, which are what
+	// gives their ingredients and instructions.
+	Limits recipes.EnrichLimits
+
+	apiKey string
+}
+
+// NewClient returns a client using apiKey, or the public test key when it is
+// empty.
+func NewClient(apiKey string) *Client {
+	if apiKey == "" {
+		apiKey = testAPIKey
+	}
+	return &Client{apiKey: apiKey, Limits: recipes.EnrichLimits{Interval: lookupInterval}}
+}
+
+func (c *Client) Name() string {
+	return "themealdb"
+}
+
+// Ping checks that the API answers, without spending quota: any response to
+// a HEAD request of its base URL will do.
+func (c *Client) Ping(ctx context.Context) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, baseURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("error reaching %s: %w", baseURL, err)
+	}
+	return resp.Body.Close()
+}
+
+// Search filters meals by each ingredient in turn, since the API filters on a
+// single ingredient, and looks up the meals matching the most ingredients.
+// Diets, intolerances, ready times and equipment are not supported.
+func (c *Client) Search(ctx context.Context, search recipes.Query) ([]recipes.Recipe, error) {
+	if len(search.Diets) > 0 || len(search.Intolerances) > 0 {
+		return nil, fmt.Errorf("themealdb has no diet or intolerance filters: %w", recipes.ErrUnsupportedQuery)
+	}
+	if !search.Prep.IsZero() {
+		return nil, fmt.Errorf("themealdb has no ready times or equipment: %w", recipes.ErrUnsupportedQuery)
+	}
+
+	matches := make(map[string]int)
+	for _, ingredient := range search.Ingredients {
+		var response filterResponse
+		err := c.get(ctx, "filter.php", url.Values{"i": {strings.ReplaceAll(ingredient, " ", "_")}}, &response)
+		if err != nil {
+			return nil, err
+		}
+		for _, found := range response.Meals {
+			matches[found.ID]++
+		}
+	}
+
+	ids := make([]string, 0, len(matches))
+	for id := range matches {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if matches[ids[i]] != matches[ids[j]] {
+			return matches[ids[i]] > matches[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > search.NumberOfRecipes {
+		ids = ids[:search.NumberOfRecipes]
+	}
+
+	looked, err := recipes.Enrich(ctx, ids, c.Limits, func(ctx context.Context, id string) (*recipes.Recipe, error) {
+		var response lookupResponse
+		err := c.get(ctx, "lookup.php", url.Values{"i": {id}}, &response)
+		if err != nil || len(response.Meals) == 0 {
+			return nil, err
+		}
+		recipe, err := parseMeal(response.Meals[0], search.Ingredients)
+		return &recipe, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	allRecipes := make([]recipes.Recipe, 0, len(looked))
+	for _, recipe := range looked {
+		if recipe != nil {
+			allRecipes = append(allRecipes, *recipe)
+		}
+	}
+	recipes.SortByMissing(allRecipes)
+	return allRecipes, nil
+}
+
+func (c *Client) get(ctx context.Context, endpoint string, query url.Values, response any) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/%s/%s?%s", baseURL, c.apiKey, endpoint, query.Encode()), nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	slog.Debug("requesting", "url", fmt.Sprintf("%s/REDACTED/%s?%s", baseURL, endpoint, query.Encode()))
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("error fetching URL: %w", err)
+	}
+	defer func() {
+		err := resp.Body.Close()
+		if err != nil {
+			slog.Warn("error closing response body", "error", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	err = json.NewDecoder(resp.Body).Decode(response)
+	if err != nil {
+		return fmt.Errorf("error parsing JSON: %v", err)
+	}
+	return nil
+}
+
+func parseMeal(m meal, ingredientList []string) (recipes.Recipe, error) {
+	id, err := strconv.Atoi(m.field("idMeal"))
+	if err != nil {
+		return recipes.Recipe{}, fmt.Errorf("invalid meal ID %q", m.field("idMeal"))
+	}
+
+	var ingredients []recipes.Ingredient
+	for i := 1; i <= 20; i++ {
+		name := strings.TrimSpace(m.field(fmt.Sprintf("strIngredient%d", i)))
+		if name == "" {
+			continue
+		}
+		measure := strings.TrimSpace(m.field(fmt.Sprintf("strMeasure%d", i)))
+		ingredients = append(ingredients, recipes.Ingredient{
+			Name:  strings.ToLower(name),
+			Forms: recipes.TagForms(name + " " + measure),
+		})
+	}
+	used, missed := recipes.MatchIngredients(ingredients, ingredientList)
+
+	var instructions []string
+	for _, line := range strings.Split(m.field("strInstructions"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			instructions = append(instructions, line)
+		}
+	}
+
+	return recipes.Recipe{
+		ID:                id,
+		Title:             m.field("strMeal"),
+		UsedIngredients:   used,
+		MissedIngredients: missed,
+		Nutrients:         map[string]recipes.NutrientAmount{},
+		Instructions:      instructions,
+		ImageURL:          m.field("strMealThumb"),
+		SourceURL:         m.field("strSource"),
+		Cuisines:          recipes.Cuisines([]string{m.field("strArea")}),
+		Source:            "themealdb",
+	}, nil
+}
+
+// field returns a meal field, with missing and null fields as empty strings.
+func (m meal) field(name string) string {
+	if value := m[name]; value != nil {
+		return *value
+	}
+	return ""
+}
@@ -0,0 +1,177 @@
+// Package webhook signs webhook payloads and verifies their signatures, so
+// the receiver of a webhook exposed to the internet can tell it came from the
+// sender and is not a replay.
+//
+// A signature is an HMAC-SHA256 of the timestamp and the body, sent in
+// SignatureHeader as "t=<unix seconds>,v1=<hex HMAC>". A payload may carry a
+// signature per secret, which is how secrets are rotated: the receiver adds
+// the new secret, then the sender signs with both, then both drop the old one.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SignatureHeader is the header a payload's signatures are sent in.
+const SignatureHeader = "X-Recipefinder-Signature"
+
+// DefaultTolerance is how old a signature may be, or how far in the future,
+// before it is refused.
+const DefaultTolerance = 5 * time.Minute
+
+// maxBodySize is the largest body Handler reads.
+const maxBodySize = 1 << 20
+
+var (
+	ErrNoSignature      = errors.New("webhook is not signed")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrExpired          = errors.New("webhook signature is too old or from the future")
+	ErrReplayed         = errors.New("webhook was already received")
+)
+
+// Sign returns the SignatureHeader value of a body sent at timestamp, with a
+// signature for each secret.
+func Sign(secrets []string, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	parts := []string{"t=" + unix}
+	for _, secret := range secrets {
+		parts = append(parts, "v1="+hex.EncodeToString(signature(secret, unix, body)))
+	}
+	return strings.Join(parts, ",")
+}
+
+func signature(secret string, unix string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// Verifier checks the signatures of received webhooks. It is safe for
+// concurrent use.
+type Verifier struct {
+	// Secrets are accepted, any one signature made with one of them will do.
+	Secrets []string
+	// Tolerance is DefaultTolerance when zero.
+	Tolerance time.Duration
+	// Now is time.Now when nil.
+	Now func() time.Time
+
+	mu sync.Mutex
+	// seen are the deliveries verified within the tolerance, by timestamp and
+	// body hash, to refuse replays, with when they expire. Keying them on the
+	// signature instead would let a payload signed with two secrets be
+	// replayed with the other one.
+	seen map[string]time.Time
+}
+
+// Verify checks a SignatureHeader value against the body: one of its
+// signatures must be made with one of the secrets, at a time within the
+// tolerance, and must not have been verified before.
+func (v *Verifier) Verify(header string, body []byte) error {
+	if header == "" {
+		return ErrNoSignature
+	}
+	var unix string
+	var signatures [][]byte
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			unix = value
+		case "v1":
+			decoded, err := hex.DecodeString(value)
+			if err == nil {
+				signatures = append(signatures, decoded)
+			}
+		}
+	}
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return fmt.Errorf("%w: malformed %s", ErrInvalidSignature, SignatureHeader)
+	}
+
+	now := time.Now
+	if v.Now != nil {
+		now = v.Now
+	}
+	tolerance := v.Tolerance
+	if tolerance == 0 {
+		tolerance = DefaultTolerance
+	}
+	sentAt := time.Unix(seconds, 0)
+	if age := now().Sub(sentAt); age > tolerance || age < -tolerance {
+		return ErrExpired
+	}
+
+	matched := false
+	for _, secret := range v.Secrets {
+		expected := signature(secret, unix, body)
+		for _, sig := range signatures {
+			if hmac.Equal(sig, expected) {
+				matched = true
+			}
+		}
+	}
+	if !matched {
+		return ErrInvalidSignature
+	}
+	bodyHash := sha256.Sum256(body)
+	return v.remember(unix+"."+hex.EncodeToString(bodyHash[:]), sentAt.Add(tolerance), now())
+}
+
+// remember records a verified delivery until it expires, failing with
+// ErrReplayed when it was already recorded. Deliveries older than the
+// tolerance need not be kept, as they are refused as expired.
+func (v *Verifier) remember(key string, expires time.Time, now time.Time) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.seen == nil {
+		v.seen = make(map[string]time.Time)
+	}
+	for seen, seenExpires := range v.seen {
+		if now.After(seenExpires) {
+			delete(v.seen, seen)
+		}
+	}
+	if _, ok := v.seen[key]; ok {
+		return ErrReplayed
+	}
+	v.seen[key] = expires
+	return nil
+}
+
+// Handler passes on the requests whose body is signed in SignatureHeader,
+// with the body left to read, and answers the others with 401 Unauthorized.
+func (v *Verifier) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+		if err != nil {
+			http.Error(w, "error reading body", http.StatusBadRequest)
+			return
+		}
+		if len(body) > maxBodySize {
+			http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		err = v.Verify(r.Header.Get(SignatureHeader), body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	sentAt := time.Unix(1700000000, 0)
+	body := []byte(`{"message":"2 new recipes"}`)
+	verifier := &Verifier{Secrets: []string{"new"}, Now: func() time.Time { return sentAt.Add(time.Minute) }}
+
+	// Signed with the old and the new secret while rotating
+	header := Sign([]string{"old", "new"}, sentAt, body)
+	if err := verifier.Verify(header, body); err != nil {
+		t.Fatalf("got %v verifying a signed body", err)
+	}
+	for _, test := range []struct {
+		name   string
+		header string
+		body   string
+		want   error
+	}{
+		{"replayed", header, string(body), ErrReplayed},
+		{"unsigned", "", string(body), ErrNoSignature},
+		{"tampered", Sign([]string{"new"}, sentAt.Add(time.Second), body), `{"message":"spoofed"}`, ErrInvalidSignature},
+		{"other secret", Sign([]string{"old"}, sentAt, body), string(body), ErrInvalidSignature},
+		{"expired", Sign([]string{"new"}, sentAt.Add(-time.Hour), body), string(body), ErrExpired},
+	} {
+		err := verifier.Verify(test.header, []byte(test.body))
+		if !errors.Is(err, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestVerifyRefusesReplayWithOneSignature(t *testing.T) {
+	sentAt := time.Unix(1700000000, 0)
+	body := []byte(`{"message":"2 new recipes"}`)
+	// While rotating the receiver accepts both secrets
+	verifier := &Verifier{Secrets: []string{"old", "new"}, Now: func() time.Time { return sentAt }}
+
+	header := Sign([]string{"old", "new"}, sentAt, body)
+	if err := verifier.Verify(header, body); err != nil {
+		t.Fatalf("got %v verifying a signed body", err)
+	}
+	timestamp, signatures, _ := strings.Cut(header, ",")
+	for _, signature := range strings.Split(signatures, ",") {
+		err := verifier.Verify(timestamp+","+signature, body)
+		if !errors.Is(err, ErrReplayed) {
+			t.Errorf("got %v replaying with only %s, want %v", err, signature, ErrReplayed)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	verifier := &Verifier{Secrets: []string{"secret"}}
+	var received string
+	handler := verifier.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+	}))
+
+	request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("payload"))
+	request.Header.Set(SignatureHeader, Sign([]string{"secret"}, time.Now(), []byte("payload")))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK || received != "payload" {
+		t.Errorf("got %d with body %q, want the signed request passed on", recorder.Code, received)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("payload")))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("got %d for an unsigned request, want 401", recorder.Code)
+	}
+}